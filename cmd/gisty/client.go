@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/codec"
+	"github.com/huylvt/gisty/internal/version"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// requestTimeout bounds every API call
+	requestTimeout = 60 * time.Second
+	// apiKeyHeader carries the API key, see middleware.APIKeyHeader
+	apiKeyHeader = "X-API-Key"
+)
+
+// The API payloads the CLI uses. They mirror the service types, which are not imported
+// so the CLI does not link the server's storage dependencies.
+
+// createRequest is the body of POST /pastes
+type createRequest struct {
+	Content    string `json:"content"`
+	SyntaxType string `json:"syntax_type,omitempty"`
+	ExpiresIn  string `json:"expires_in,omitempty"`
+	IsPrivate  bool   `json:"is_private,omitempty"`
+	Encoding   string `json:"encoding,omitempty"`
+	Live       bool   `json:"live,omitempty"`
+
+	Title string   `json:"title,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+
+	Schema json.RawMessage `json:"schema,omitempty"`
+
+	OutputOf string    `json:"output_of,omitempty"`
+	Producer *producer `json:"producer,omitempty"`
+}
+
+// producer describes the job that produced a paste
+type producer struct {
+	Kind string `json:"kind"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// createResponse is the answer of POST /pastes
+type createResponse struct {
+	ShortID   string  `json:"short_id"`
+	URL       string  `json:"url"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+	// DeleteToken is returned for pastes created without an API key
+	DeleteToken string `json:"delete_token,omitempty"`
+
+	Validation *struct {
+		Valid  bool     `json:"valid"`
+		Errors []string `json:"errors"`
+	} `json:"validation,omitempty"`
+	ExpirationPolicy *struct {
+		SyntaxType string `json:"syntax_type"`
+		MaxTTL     string `json:"max_ttl"`
+		Capped     bool   `json:"capped"`
+	} `json:"expiration_policy,omitempty"`
+}
+
+// paste is the answer of GET /pastes/{id}
+type paste struct {
+	ShortID    string  `json:"short_id"`
+	Content    string  `json:"content"`
+	SyntaxType string  `json:"syntax_type"`
+	CreatedAt  string  `json:"created_at"`
+	ExpiresAt  *string `json:"expires_at,omitempty"`
+	Encrypted  bool    `json:"is_encrypted"`
+	Encoding   string  `json:"encoding,omitempty"`
+}
+
+// apiError is an error answer of the API
+type apiError struct {
+	Status  int
+	Message string `json:"error"`
+	Code    string `json:"code"`
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server returned %d %s", e.Status, http.StatusText(e.Status))
+	}
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, e.Code)
+}
+
+// client calls the Gisty API
+type client struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// newClient creates a client for the server at baseURL
+func newClient(cfg *cliConfig) *client {
+	return &client{
+		baseURL: strings.TrimRight(cfg.Server, "/") + "/api/v1",
+		apiKey:  cfg.APIKey,
+		http:    &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// create creates a paste
+func (c *client) create(ctx context.Context, req *createRequest) (*createResponse, error) {
+	var resp createResponse
+	if err := c.do(ctx, http.MethodPost, "/pastes", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// get fetches a paste with its content in the given transfer encoding (codec.None for none)
+func (c *client) get(ctx context.Context, shortID, encoding string) (*paste, error) {
+	path := "/pastes/" + url.PathEscape(shortID)
+	if encoding != codec.None {
+		path += "?encoding=" + url.QueryEscape(encoding)
+	}
+
+	var resp paste
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// delete deletes a paste, with the delete token returned when it was created unless empty
+func (c *client) delete(ctx context.Context, shortID, deleteToken string) error {
+	path := "/pastes/" + url.PathEscape(shortID)
+	if deleteToken != "" {
+		path += "?delete_token=" + url.QueryEscape(deleteToken)
+	}
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// appendRequest is the body of POST /pastes/{id}/append
+type appendRequest struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// appendResponse is the answer of POST /pastes/{id}/append
+type appendResponse struct {
+	ShortID string `json:"short_id"`
+	Size    int    `json:"size"`
+}
+
+// appendPaste appends to a live paste, with the delete token returned when it was created unless empty
+func (c *client) appendPaste(ctx context.Context, shortID, deleteToken string, req *appendRequest) (*appendResponse, error) {
+	path := "/pastes/" + url.PathEscape(shortID) + "/append"
+	if deleteToken != "" {
+		path += "?delete_token=" + url.QueryEscape(deleteToken)
+	}
+
+	var resp appendResponse
+	if err := c.do(ctx, http.MethodPost, path, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// liveChunk is a message of the /pastes/{id}/live/ws WebSocket
+type liveChunk struct {
+	Offset   int    `json:"offset"`
+	Content  string `json:"content"`
+	Size     int    `json:"size"`
+	Encoding string `json:"encoding,omitempty"`
+	Ended    bool   `json:"ended,omitempty"`
+}
+
+// follow opens the WebSocket pushing a live paste from offset on, base64 encoded
+func (c *client) follow(ctx context.Context, shortID string, offset int) (*websocket.Conn, error) {
+	path := "/pastes/" + url.PathEscape(shortID) + "/live/ws?" + url.Values{
+		"offset":   {strconv.Itoa(offset)},
+		"encoding": {codec.Base64},
+	}.Encode()
+
+	u, err := url.Parse(c.baseURL + path)
+	if err != nil {
+		return nil, err
+	}
+	origin := u.Scheme + "://" + u.Host
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+
+	config, err := websocket.NewConfig(u.String(), origin)
+	if err != nil {
+		return nil, err
+	}
+	config.Header.Set("User-Agent", "gisty-cli/"+version.Get().Version)
+	if c.apiKey != "" {
+		config.Header.Set(apiKeyHeader, c.apiKey)
+	}
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		// The handshake error says nothing; the server answers a plain request with why it refused
+		var apiErr *apiError
+		if errors.As(c.do(ctx, http.MethodGet, path, nil, nil), &apiErr) && apiErr.Code != "" {
+			return nil, apiErr
+		}
+		return nil, err
+	}
+	return ws, nil
+}
+
+// panicResponse lists the pastes destroyed by panic
+type panicResponse struct {
+	Deleted  int      `json:"deleted"`
+	ShortIDs []string `json:"short_ids"`
+}
+
+// panic destroys the unread burn-after-read and view-limited pastes created with the API key
+func (c *client) panic(ctx context.Context) (*panicResponse, error) {
+	var resp panicResponse
+	if err := c.do(ctx, http.MethodPost, "/me/panic", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do sends a JSON request and decodes the JSON answer into out, if not nil
+func (c *client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "gisty-cli/"+version.Get().Version)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(apiKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &apiError{Status: resp.StatusCode}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(apiErr)
+		return apiErr
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// bundleFile is a file of a bundle, at a slash-separated path relative to the pushed directory
+type bundleFile struct {
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// bundleRequest is the body of POST /bundles
+type bundleRequest struct {
+	Files       []bundleFile `json:"files"`
+	ExpiresIn   string       `json:"expires_in,omitempty"`
+	IsPrivate   bool         `json:"is_private,omitempty"`
+	Description string       `json:"description,omitempty"`
+}
+
+// bundleResponse is the answer of POST /bundles
+type bundleResponse struct {
+	GroupID string `json:"group_id"`
+	Files   []struct {
+		Path        string `json:"path"`
+		ShortID     string `json:"short_id"`
+		URL         string `json:"url"`
+		DeleteToken string `json:"delete_token,omitempty"`
+	} `json:"files"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+}
+
+// createBundle creates the pastes of a file tree, one per file
+func (c *client) createBundle(ctx context.Context, req *bundleRequest) (*bundleResponse, error) {
+	var resp bundleResponse
+	if err := c.do(ctx, http.MethodPost, "/bundles", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/codec"
+)
+
+func TestClient_SendsAPIKey(t *testing.T) {
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"short_id": "xK9a2B", "content": "aGk=", "encoding": "base64"}`))
+	}))
+	defer srv.Close()
+
+	c := newClient(&cliConfig{Server: srv.URL + "/", APIKey: "k3y"})
+	p, err := c.get(context.Background(), "xK9a2B", codec.Base64)
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+
+	if got.URL.Path != "/api/v1/pastes/xK9a2B" || got.URL.Query().Get("encoding") != codec.Base64 {
+		t.Errorf("get() requested %s, want /api/v1/pastes/xK9a2B?encoding=base64", got.URL)
+	}
+	if got.Header.Get(apiKeyHeader) != "k3y" {
+		t.Errorf("get() sent %s %q, want %q", apiKeyHeader, got.Header.Get(apiKeyHeader), "k3y")
+	}
+	if p.ShortID != "xK9a2B" || p.Encoding != codec.Base64 {
+		t.Errorf("get() = %+v", p)
+	}
+}
+
+func TestClient_APIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantMsg string
+	}{
+		{"message and code", http.StatusNotFound, `{"error": "Paste not found", "code": "paste_not_found"}`, "Paste not found (paste_not_found)"},
+		{"message only", http.StatusBadRequest, `{"error": "Invalid request"}`, "Invalid request"},
+		{"no JSON body", http.StatusBadGateway, `<html>bad gateway</html>`, "server returned 502 Bad Gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			err := newClient(&cliConfig{Server: srv.URL}).delete(context.Background(), "xK9a2B", "")
+			var apiErr *apiError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("delete() error = %v, want an *apiError", err)
+			}
+			if apiErr.Status != tt.status || apiErr.Error() != tt.wantMsg {
+				t.Errorf("delete() error = %d %q, want %d %q", apiErr.Status, apiErr.Error(), tt.status, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/version"
+)
+
+// completionCmd prints the completion script of a shell, generated from the commands and their flags
+func completionCmd(fs *flag.FlagSet) action {
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if len(args) != 1 {
+			fmt.Fprintln(stderr, "usage: gisty completion bash|zsh|fish")
+			return exitUsage
+		}
+
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(stdout)
+		case "zsh":
+			writeZshCompletion(stdout)
+		case "fish":
+			writeFishCompletion(stdout)
+		default:
+			fmt.Fprintf(stderr, "gisty: no completion for %s, choose bash, zsh or fish\n", args[0])
+			return exitUsage
+		}
+		return exitOK
+	}
+}
+
+// manCmd prints the gisty(1) man page, generated from the commands and their flags
+func manCmd(fs *flag.FlagSet) action {
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		writeManPage(stdout)
+		return exitOK
+	}
+}
+
+// commandFlags returns the flags of a command, without the connection flags unless global
+func commandFlags(cmd *command, global bool) []*flag.Flag {
+	fs := newFlagSet("gisty", &cliConfig{Server: defaultServer}, io.Discard)
+	connection := map[string]bool{}
+	fs.VisitAll(func(f *flag.Flag) { connection[f.Name] = true })
+	if cmd != nil {
+		cmd.setup(fs)
+	}
+
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		if connection[f.Name] == global {
+			flags = append(flags, f)
+		}
+	})
+	return flags
+}
+
+// isBoolFlag reports whether f takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// commandNames returns the names and aliases of the commands
+func commandNames() []string {
+	var names []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+		names = append(names, cmd.aliases...)
+	}
+	return names
+}
+
+// flagNames returns the flags of a command and the connection flags, as typed
+func flagNames(cmd *command) string {
+	var names []string
+	for _, f := range append(commandFlags(cmd, false), commandFlags(nil, true)...) {
+		names = append(names, "-"+f.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func writeBashCompletion(w io.Writer) {
+	fmt.Fprint(w, `# bash completion for gisty, generated by "gisty completion bash"
+
+_gisty() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" cmd="" flags="" words=""
+    if [[ ${COMP_CWORD} -gt 1 ]]; then
+        cmd="${COMP_WORDS[1]}"
+    fi
+
+    case "${cmd}" in
+`)
+	for _, cmd := range commands[1:] {
+		fmt.Fprintf(w, "    %s)\n        flags=%q\n", strings.Join(append([]string{cmd.name}, cmd.aliases...), "|"), flagNames(cmd))
+		if len(cmd.words) > 0 {
+			fmt.Fprintf(w, "        words=%q\n", strings.Join(cmd.words, " "))
+		}
+		fmt.Fprint(w, "        ;;\n")
+	}
+	fmt.Fprintf(w, "    *)\n        flags=%q\n        ;;\n    esac\n", flagNames(commands[0]))
+	fmt.Fprintf(w, `
+    if [[ "${cur}" == -* ]]; then
+        COMPREPLY=($(compgen -W "${flags}" -- "${cur}"))
+    elif [[ ${COMP_CWORD} -eq 1 ]]; then
+        COMPREPLY=($(compgen -W %q -- "${cur}"))
+    elif [[ -n "${words}" ]]; then
+        COMPREPLY=($(compgen -W "${words}" -- "${cur}"))
+    fi
+}
+
+complete -o default -F _gisty gisty
+`, strings.Join(commandNames(), " "))
+}
+
+// zshQuote quotes s for a single-quoted _arguments spec or _describe item
+func zshQuote(s string) string {
+	return strings.NewReplacer("'", `'\''`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// zshFlagSpecs returns the _arguments specs of flags
+func zshFlagSpecs(flags []*flag.Flag) []string {
+	var specs []string
+	for _, f := range flags {
+		name, usage := flag.UnquoteUsage(f)
+		spec := "'-" + f.Name + "[" + zshQuote(usage) + "]"
+		if !isBoolFlag(f) {
+			spec += ":" + name + ":"
+			if name == "file" {
+				spec += "_files"
+			}
+		}
+		specs = append(specs, spec+"'")
+	}
+	return specs
+}
+
+func writeZshCompletion(w io.Writer) {
+	global := zshFlagSpecs(commandFlags(nil, true))
+
+	fmt.Fprint(w, "#compdef gisty\n# zsh completion for gisty, generated by \"gisty completion zsh\"\n\n_gisty() {\n    local -a commands\n    commands=(\n")
+	for _, cmd := range commands {
+		for _, name := range append([]string{cmd.name}, cmd.aliases...) {
+			fmt.Fprintf(w, "        '%s:%s'\n", name, zshQuote(cmd.summary))
+		}
+	}
+	fmt.Fprint(w, "    )\n\n    if (( CURRENT == 2 )) && [[ \"${words[CURRENT]}\" != -* ]]; then\n")
+	fmt.Fprint(w, "        _describe 'command' commands\n        _files\n        return\n    fi\n\n")
+	fmt.Fprint(w, "    local cmd=\"${words[2]}\"\n    if (( ${commands[(I)${cmd}:*]} )); then\n        shift words\n        (( CURRENT-- ))\n    else\n        cmd=create\n    fi\n\n    case \"${cmd}\" in\n")
+	for _, cmd := range commands {
+		specs := append(zshFlagSpecs(commandFlags(cmd, false)), global...)
+		switch {
+		case len(cmd.words) > 0:
+			specs = append(specs, "'1:"+cmd.args+":("+strings.Join(cmd.words, " ")+")'")
+		case cmd.args == "[file]":
+			specs = append(specs, "'1:file:_files'")
+		case cmd.args != "":
+			specs = append(specs, "'1:"+zshQuote(cmd.args)+":'")
+		}
+		fmt.Fprintf(w, "    %s)\n        _arguments \\\n            %s\n        ;;\n",
+			strings.Join(append([]string{cmd.name}, cmd.aliases...), "|"), strings.Join(specs, " \\\n            "))
+	}
+	fmt.Fprint(w, "    esac\n}\n\n_gisty \"$@\"\n")
+}
+
+// fishQuote quotes s for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprint(w, "# fish completion for gisty, generated by \"gisty completion fish\"\n\n")
+	names := strings.Join(commandNames(), " ")
+	for _, cmd := range commands {
+		for _, name := range append([]string{cmd.name}, cmd.aliases...) {
+			fmt.Fprintf(w, "complete -c gisty -n '__fish_use_subcommand' -a %s -d %s\n", name, fishQuote(cmd.summary))
+		}
+	}
+
+	fishFlags := func(condition string, flags []*flag.Flag) {
+		for _, f := range flags {
+			_, usage := flag.UnquoteUsage(f)
+			line := fmt.Sprintf("complete -c gisty -n %s -o %s", fishQuote(condition), f.Name)
+			if !isBoolFlag(f) {
+				line += " -r"
+			}
+			fmt.Fprintf(w, "%s -d %s\n", line, fishQuote(usage))
+		}
+	}
+
+	fmt.Fprintln(w)
+	fishFlags("true", commandFlags(nil, true))
+	fishFlags("not __fish_seen_subcommand_from "+names, commandFlags(commands[0], false))
+	for _, cmd := range commands[1:] {
+		seen := "__fish_seen_subcommand_from " + strings.Join(append([]string{cmd.name}, cmd.aliases...), " ")
+		fishFlags(seen, commandFlags(cmd, false))
+		if len(cmd.words) > 0 {
+			fmt.Fprintf(w, "complete -c gisty -n %s -f -a %s\n", fishQuote(seen), fishQuote(strings.Join(cmd.words, " ")))
+		}
+	}
+}
+
+// roffEscape escapes text for a man page
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
+
+// writeManFlags writes the flags as a man page list
+func writeManFlags(w io.Writer, flags []*flag.Flag) {
+	for _, f := range flags {
+		name, usage := flag.UnquoteUsage(f)
+		if isBoolFlag(f) {
+			fmt.Fprintf(w, ".TP\n.B \\-%s\n", roffEscape(f.Name))
+		} else {
+			fmt.Fprintf(w, ".TP\n.BI \"\\-%s \" %s\n", roffEscape(f.Name), roffEscape(name))
+		}
+		fmt.Fprintf(w, "%s\n", roffEscape(usage))
+	}
+}
+
+func writeManPage(w io.Writer) {
+	info := version.Get()
+	fmt.Fprintf(w, ".TH GISTY 1 %q %q \"Gisty Manual\"\n", time.Now().UTC().Format("2006-01-02"), "gisty "+info.Version)
+	fmt.Fprint(w, ".SH NAME\ngisty \\- command line client for Gisty\n")
+	fmt.Fprint(w, ".SH SYNOPSIS\n")
+	for _, cmd := range commands {
+		name := "gisty"
+		if cmd != commands[0] {
+			name += " " + cmd.name
+		}
+		fmt.Fprintf(w, ".B %s\n[\\fIflags\\fR]", name)
+		if cmd.args != "" {
+			fmt.Fprintf(w, " %s", roffEscape(cmd.args))
+		}
+		fmt.Fprint(w, "\n.br\n")
+	}
+	fmt.Fprint(w, ".SH DESCRIPTION\n"+
+		"gisty creates, reads and deletes pastes on a Gisty server. Without a command it creates a paste "+
+		"from the file given, or from stdin, and prints its URL.\n")
+
+	fmt.Fprint(w, ".SH COMMANDS\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, ".SS %s\n%s.\n", cmd.name, roffEscape(cmd.summary))
+		if len(cmd.aliases) > 0 {
+			fmt.Fprintf(w, "Also \\fB%s\\fR.\n", strings.Join(cmd.aliases, "\\fR, \\fB"))
+		}
+		writeManFlags(w, commandFlags(cmd, false))
+	}
+
+	fmt.Fprint(w, ".SH CONNECTION FLAGS\nAccepted by all commands.\n")
+	writeManFlags(w, commandFlags(nil, true))
+
+	fmt.Fprintf(w, ".SH ENVIRONMENT\n"+
+		".TP\n.B %s\nGisty server URL, %s by default.\n"+
+		".TP\n.B %s\nAPI key sent in X\\-API\\-Key.\n"+
+		".TP\n.B %s\nPath of the config file.\n",
+		envServer, roffEscape(defaultServer), envAPIKey, envConfig)
+	fmt.Fprint(w, ".SH FILES\n.TP\n.I ~/.config/gisty/config.yaml\n"+
+		"Settings, overridden by the environment, then by flags:\n"+
+		".PP\n.RS\n.nf\nserver: https://gisty.io\napi_key: ...\n.fi\n.RE\n")
+	fmt.Fprint(w, ".SH EXAMPLES\n.nf\n"+
+		"cat main.go | gisty \\-syntax go \\-expires 1d\n"+
+		"gisty get https://gisty.io/xK9a2B > main.go\n"+
+		"gisty completion bash > /etc/bash_completion.d/gisty\n"+
+		".fi\n")
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompletion_ListsCommandsAndFlags(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			setupRun(t)
+			var stdout, stderr bytes.Buffer
+			if code := run([]string{"completion", shell}, nil, &stdout, &stderr); code != exitOK {
+				t.Fatalf("run(completion %s) = %d, stderr %q", shell, code, stderr.String())
+			}
+
+			script := stdout.String()
+			for _, word := range []string{"get", "delete", "rm", "panic", "completion", "man", "json", "token", "syntax", "expires", "server", "api-key", "config"} {
+				if !strings.Contains(script, word) {
+					t.Errorf("%s completion does not mention %q", shell, word)
+				}
+			}
+		})
+	}
+}
+
+func TestCompletion_UnknownShell(t *testing.T) {
+	setupRun(t)
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"completion", "tcsh"}, nil, &stdout, &stderr); code != exitUsage {
+		t.Errorf("run(completion tcsh) = %d, want %d", code, exitUsage)
+	}
+}
+
+func TestManPage(t *testing.T) {
+	setupRun(t)
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"man"}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("run(man) = %d, stderr %q", code, stderr.String())
+	}
+
+	page := stdout.String()
+	if !strings.HasPrefix(page, ".TH GISTY 1 ") {
+		t.Errorf("man page starts with %q, want a .TH header", page[:min(len(page), 20)])
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(page, ".SS "+cmd.name+"\n") {
+			t.Errorf("man page has no section for %s", cmd.name)
+		}
+		for _, f := range commandFlags(cmd, false) {
+			if !strings.Contains(page, `\-`+roffEscape(f.Name)) {
+				t.Errorf("man page does not document -%s of %s", f.Name, cmd.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// defaultServer is used when no server is configured
+	defaultServer = "http://localhost:8080"
+
+	envServer = "GISTY_SERVER"
+	envAPIKey = "GISTY_API_KEY"
+	envConfig = "GISTY_CONFIG"
+)
+
+// cliConfig holds the client settings. Values come from the config file, then the
+// environment, then command line flags, each overriding the previous one.
+type cliConfig struct {
+	Server string `yaml:"server"`
+	APIKey string `yaml:"api_key"`
+}
+
+// configPath returns the config file location: $GISTY_CONFIG, or gisty/config.yaml in the
+// user config directory (e.g., ~/.config/gisty/config.yaml). The config.json of earlier
+// versions is read when there is no config.yaml.
+func configPath() string {
+	if path := os.Getenv(envConfig); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(dir, "gisty", "config.yaml")
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if legacy := filepath.Join(dir, "gisty", "config.json"); fileExists(legacy) {
+			return legacy
+		}
+	}
+	return path
+}
+
+// configFlag returns the value of the -config flag of a command line, if given. It is read
+// before the flags are parsed, as the config file sets their defaults.
+func configFlag(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// loadConfig reads the config file, if any, and applies the environment on top. A file given
+// with -config must exist.
+func loadConfig(file string) (*cliConfig, error) {
+	cfg := &cliConfig{Server: defaultServer}
+
+	path := file
+	if path == "" {
+		path = configPath()
+	}
+	if path != "" {
+		// YAML is a superset of JSON, both formats are read alike
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, cfg); err != nil {
+				return nil, errors.New("invalid config file " + path + ": " + err.Error())
+			}
+		case file != "" || !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		}
+	}
+
+	if server := os.Getenv(envServer); server != "" {
+		cfg.Server = server
+	}
+	if apiKey := os.Getenv(envAPIKey); apiKey != "" {
+		cfg.APIKey = apiKey
+	}
+
+	return cfg, nil
+}
+
+// fileExists reports whether path names an existing file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
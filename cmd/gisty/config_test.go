@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfig writes a config file and points GISTY_CONFIG at it
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	t.Setenv(envConfig, path)
+	return path
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		env        map[string]string
+		flags      []string
+		wantServer string
+		wantAPIKey string
+	}{
+		{
+			name:       "defaults",
+			wantServer: defaultServer,
+		},
+		{
+			name:       "config file",
+			file:       `{"server": "https://file.example", "api_key": "file-key"}`,
+			wantServer: "https://file.example",
+			wantAPIKey: "file-key",
+		},
+		{
+			name:       "environment over config file",
+			file:       `{"server": "https://file.example", "api_key": "file-key"}`,
+			env:        map[string]string{envServer: "https://env.example"},
+			wantServer: "https://env.example",
+			wantAPIKey: "file-key",
+		},
+		{
+			name:       "flags over environment",
+			file:       `{"server": "https://file.example", "api_key": "file-key"}`,
+			env:        map[string]string{envServer: "https://env.example", envAPIKey: "env-key"},
+			flags:      []string{"-server", "https://flag.example"},
+			wantServer: "https://flag.example",
+			wantAPIKey: "env-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(envServer, "")
+			t.Setenv(envAPIKey, "")
+			if tt.file != "" {
+				writeConfig(t, tt.file)
+			} else {
+				t.Setenv(envConfig, filepath.Join(t.TempDir(), "missing.json"))
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := loadConfig("")
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if err := newFlagSet("gisty", cfg, nil).Parse(tt.flags); err != nil {
+				t.Fatalf("Parse(%v) error = %v", tt.flags, err)
+			}
+			if cfg.Server != tt.wantServer || cfg.APIKey != tt.wantAPIKey {
+				t.Errorf("config = %+v, want server %q and API key %q", cfg, tt.wantServer, tt.wantAPIKey)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_InvalidFile(t *testing.T) {
+	path := writeConfig(t, `{"server": `)
+
+	if _, err := loadConfig(""); err == nil {
+		t.Errorf("loadConfig() with a broken %s should fail", path)
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"get", "-config", "a.yaml", "xK9a2B"}, "a.yaml"},
+		{[]string{"--config=b.yaml", "get", "xK9a2B"}, "b.yaml"},
+		{[]string{"-config"}, ""},
+		{[]string{"get", "--", "-config", "c.yaml"}, ""},
+		{[]string{"-configure", "d.yaml"}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := configFlag(tt.args); got != tt.want {
+			t.Errorf("configFlag(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfig_File(t *testing.T) {
+	t.Setenv(envServer, "")
+	t.Setenv(envAPIKey, "")
+	writeConfig(t, "server: https://env-file.example\n")
+
+	// The -config file is read instead of $GISTY_CONFIG
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server: https://flag-file.example\napi_key: file-key\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig(%s) error = %v", path, err)
+	}
+	if cfg.Server != "https://flag-file.example" || cfg.APIKey != "file-key" {
+		t.Errorf("loadConfig(%s) = %+v", path, cfg)
+	}
+
+	// Unlike the default location, a file named with -config must exist
+	if _, err := loadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadConfig() of a missing -config file should fail")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreRule is a pattern of a .gitignore file, or of -ignore
+type ignoreRule struct {
+	base     string // slash-separated directory of the .gitignore file, "" for the pushed directory
+	pattern  string
+	negate   bool // a "!" pattern includes again what an earlier pattern left out
+	dirOnly  bool // a pattern ending in "/" only matches directories
+	anchored bool // a pattern with a "/" matches paths from base, others match names at any depth
+}
+
+// ignorer decides which files of a directory tree are left out of a push, as git does with
+// .gitignore files. Rules are matched in order and the last matching one wins.
+type ignorer struct {
+	rules []ignoreRule
+}
+
+// add adds a pattern in the .gitignore syntax, relative to the directory base
+func (ig *ignorer) add(base, line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	rule := ignoreRule{base: base}
+	if strings.HasPrefix(line, "!") {
+		rule.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		// Escapes a leading "#" or "!"
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly, line = true, strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored, line = true, strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return
+	}
+	rule.pattern = line
+	ig.rules = append(ig.rules, rule)
+}
+
+// load adds the patterns of the .gitignore file in the directory dir, at base in the tree, if any
+func (ig *ignorer) load(dir, base string) error {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		ig.add(base, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+// ignored reports whether the file or directory at the slash-separated path rel is left out
+func (ig *ignorer) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		name := rel
+		if rule.base != "" {
+			if !strings.HasPrefix(rel, rule.base+"/") {
+				continue
+			}
+			name = rel[len(rule.base)+1:]
+		}
+
+		var match bool
+		if rule.anchored {
+			match = matchSegments(strings.Split(rule.pattern, "/"), strings.Split(name, "/"))
+		} else {
+			match, _ = path.Match(rule.pattern, path.Base(name))
+		}
+		if match {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// matchSegments matches the segments of a path against those of a pattern, where "**" matches
+// any number of segments
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
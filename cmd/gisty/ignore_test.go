@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestIgnorer_Ignored(t *testing.T) {
+	ig := &ignorer{}
+	for _, line := range []string{
+		"# build output",
+		"*.log",
+		"!keep.log",
+		"/bin",
+		"node_modules/",
+		"docs/**/*.tmp",
+		`\#notes`,
+	} {
+		ig.add("", line)
+	}
+	ig.add("web", "dist")
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"logs/app.log", false, true},
+		{"keep.log", false, false},
+		{"bin", true, true},
+		{"cmd/bin", true, false},
+		{"node_modules", true, true},
+		{"node_modules", false, false},
+		{"web/node_modules", true, true},
+		{"docs/a.tmp", false, true},
+		{"docs/api/v1/a.tmp", false, true},
+		{"a.tmp", false, false},
+		{"#notes", false, true},
+		{"web/dist", true, true},
+		{"dist", true, false},
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := ig.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/codec"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// tailRetries is how many times in a row tail reconnects to a live paste before giving up
+	tailRetries = 5
+	// tailRetryDelay is the delay before the first reconnection, doubled after each failure
+	tailRetryDelay = time.Second
+)
+
+// appendCmd appends a file or stdin to a live paste
+func appendCmd(fs *flag.FlagSet) action {
+	token := fs.String("token", "", "delete token printed when the paste was created without an API key")
+
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if len(args) < 1 || len(args) > 2 {
+			fmt.Fprintln(stderr, "usage: gisty append [flags] <id or URL> [file]")
+			return exitUsage
+		}
+
+		content, code := readInput(args[1:], stdin, stderr)
+		if code != exitOK {
+			return code
+		}
+		req := &appendRequest{}
+		req.Content, req.Encoding = encodeContent(content)
+
+		shortID := parseID(args[0])
+		resp, err := newClient(cfg).appendPaste(context.Background(), shortID, *token, req)
+		if err != nil {
+			var apiErr *apiError
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusForbidden {
+				fmt.Fprintf(stderr, "gisty: paste %s was not created with this API key, pass its -token\n", shortID)
+				return exitError
+			}
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+
+		fmt.Fprintf(stderr, "Appended %d bytes, %d in total\n", len(content), resp.Size)
+		return exitOK
+	}
+}
+
+// tailCmd prints the end of a live paste and what is appended to it
+func tailCmd(fs *flag.FlagSet) action {
+	lines := fs.Int("n", 10, "print the last `lines` lines of the content first, -1 for all of it")
+	follow := fs.Bool("f", true, "keep printing what is appended until the paste is deleted or expires")
+
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if len(args) != 1 {
+			fmt.Fprintln(stderr, "usage: gisty tail [flags] <id or URL>")
+			return exitUsage
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		err := newClient(cfg).tail(ctx, parseID(args[0]), *lines, *follow, stdout, stderr)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+}
+
+// tail prints the last lines of a live paste, then what is appended to it while follow is set.
+// Dropped connections are resumed from the offset reached; tail returns once the paste is gone.
+func (c *client) tail(ctx context.Context, shortID string, lines int, follow bool, stdout, stderr io.Writer) error {
+	offset, first, connected := 0, true, false
+	delay, failures := tailRetryDelay, 0
+	for {
+		ws, err := c.follow(ctx, shortID, offset)
+		if err == nil {
+			connected, failures, delay = true, 0, tailRetryDelay
+			var ended bool
+			ended, err = readChunks(ctx, ws, func(chunk *liveChunk, content string) bool {
+				if first {
+					content, first = lastLines(content, lines), false
+				}
+				_, _ = io.WriteString(stdout, content)
+				offset = chunk.Size
+				return follow
+			})
+			if ended || (err == nil && !follow && !first) {
+				return nil
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Refusals, such as of a paste that is not live, are final, and so is a server that cannot
+		// be reached in the first place
+		var apiErr *apiError
+		if errors.As(err, &apiErr) || !connected {
+			return err
+		}
+		if failures++; failures > tailRetries {
+			return err
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "gisty: %v, reconnecting\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// readChunks passes the chunks received on ws to fn, decoded, until fn returns false, the paste
+// is gone, or the connection drops. It reports whether the paste is gone.
+func readChunks(ctx context.Context, ws *websocket.Conn, fn func(chunk *liveChunk, content string) bool) (bool, error) {
+	defer ws.Close()
+	// Interrupting the command closes the connection, ending the blocked read
+	stop := context.AfterFunc(ctx, func() { _ = ws.Close() })
+	defer stop()
+
+	for {
+		var chunk liveChunk
+		if err := websocket.JSON.Receive(ws, &chunk); err != nil {
+			if errors.Is(err, io.EOF) {
+				// Closed by the server at the end of the connection's lifetime
+				err = nil
+			}
+			return false, err
+		}
+		if chunk.Ended {
+			return true, nil
+		}
+		content, err := codec.Decode(chunk.Encoding, chunk.Content)
+		if err != nil {
+			return false, err
+		}
+		if !fn(&chunk, content) {
+			return false, nil
+		}
+	}
+}
+
+// lastLines returns the last n lines of s, all of it when n is negative. A final newline does
+// not start another line.
+func lastLines(s string, n int) string {
+	if n < 0 {
+		return s
+	}
+	if n == 0 {
+		return ""
+	}
+	end := strings.TrimSuffix(s, "\n")
+	for i := 0; i < n; i++ {
+		j := strings.LastIndexByte(end, '\n')
+		if j < 0 {
+			return s
+		}
+		end = end[:j]
+	}
+	return s[len(end)+1:]
+}
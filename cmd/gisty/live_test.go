@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestLastLines(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"a\nb\nc\n", 2, "b\nc\n"},
+		{"a\nb\nc", 2, "b\nc"},
+		{"a\nb\nc\n", 3, "a\nb\nc\n"},
+		{"a\nb\nc\n", 10, "a\nb\nc\n"},
+		{"a\nb\nc\n", 0, ""},
+		{"a\nb\nc\n", -1, "a\nb\nc\n"},
+	}
+
+	for _, tt := range tests {
+		if got := lastLines(tt.s, tt.n); got != tt.want {
+			t.Errorf("lastLines(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+// fakeLive serves the live WebSocket of paste xK9a2B: the first connection sends the content and
+// drops, the second sends an append and the end of the paste. Other pastes are not live.
+type fakeLive struct {
+	offsets []string
+}
+
+func (l *fakeLive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/pastes/xK9a2B/live/ws" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, `{"error": "The paste is not a live paste", "code": "paste_not_live"}`)
+		return
+	}
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		l.offsets = append(l.offsets, r.URL.Query().Get("offset"))
+		if len(l.offsets) == 1 {
+			_ = websocket.JSON.Send(ws, liveChunk{Offset: 0, Content: "YQpiCmMK", Size: 6, Encoding: "base64"})
+			return
+		}
+		_ = websocket.JSON.Send(ws, liveChunk{Offset: 6, Content: "ZAo=", Size: 8, Encoding: "base64"})
+		_ = websocket.JSON.Send(ws, liveChunk{Offset: 8, Size: 8, Ended: true})
+	}).ServeHTTP(w, r)
+}
+
+func setupLive(t *testing.T) *fakeLive {
+	t.Helper()
+	live := &fakeLive{}
+	srv := httptest.NewServer(live)
+	t.Cleanup(srv.Close)
+
+	t.Setenv(envConfig, filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv(envServer, srv.URL)
+	t.Setenv(envAPIKey, "")
+	return live
+}
+
+func TestRun_Tail(t *testing.T) {
+	live := setupLive(t)
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"tail", "-n", "2", "xK9a2B"}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, stderr %q", code, stderr.String())
+	}
+	if got := stdout.String(); got != "b\nc\nd\n" {
+		t.Errorf("tail stdout = %q, want the last 2 lines and the append", got)
+	}
+	if strings.Join(live.offsets, ",") != "0,6" {
+		t.Errorf("tail connected with offsets %v, want 0 then 6 after the drop", live.offsets)
+	}
+}
+
+func TestRun_TailNoFollow(t *testing.T) {
+	live := setupLive(t)
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"tail", "-f=false", "xK9a2B"}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, stderr %q", code, stderr.String())
+	}
+	if got := stdout.String(); got != "a\nb\nc\n" || len(live.offsets) != 1 {
+		t.Errorf("tail -f=false stdout = %q after %d connections, want the content once", got, len(live.offsets))
+	}
+}
+
+func TestRun_TailNotLive(t *testing.T) {
+	setupLive(t)
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"tail", "static"}, strings.NewReader(""), &stdout, &stderr); code != exitError {
+		t.Fatalf("run() = %d, want %d", code, exitError)
+	}
+	if !strings.Contains(stderr.String(), "paste_not_live") {
+		t.Errorf("tail stderr = %q, want the server's refusal", stderr.String())
+	}
+}
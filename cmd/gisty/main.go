@@ -0,0 +1,452 @@
+// Command gisty is the command line client of the Gisty API.
+//
+//	cat main.go | gisty -syntax go -expires 1d
+//	gisty get xK9a2B
+//	gisty delete xK9a2B
+//	gisty completion bash > /etc/bash_completion.d/gisty
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/huylvt/gisty/internal/codec"
+	"github.com/huylvt/gisty/internal/version"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	aliases []string
+	args    string   // positional arguments, as shown in usage lines
+	summary string   // one line description
+	words   []string // the values the argument takes, if fixed, offered by completion
+	// setup declares the flags of the command on fs and returns the action to run once they are
+	// parsed. Completion scripts and the man page call it to list the flags without running it.
+	setup func(fs *flag.FlagSet) action
+}
+
+// action runs a command with the arguments left after its flags
+type action func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int
+
+// commands lists the subcommands, create first as it runs when no subcommand is given. It is set
+// in init as the completion and man commands read it.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "create", args: "[file]", summary: "Create a paste from a file, or from stdin", setup: createCmd},
+		{name: "push", args: "<dir>", summary: "Create a paste of each file of a directory, shown as a tree", setup: pushCmd},
+		{name: "get", args: "<id|URL>", summary: "Print the content of a paste", setup: getCmd},
+		{name: "append", args: "<id|URL> [file]", summary: "Append a file, or stdin, to a live paste", setup: appendCmd},
+		{name: "tail", args: "<id|URL>", summary: "Print the end of a live paste and follow what is appended", setup: tailCmd},
+		{name: "delete", aliases: []string{"rm"}, args: "<id|URL>", summary: "Delete a paste", setup: deleteCmd},
+		{name: "panic", summary: "Delete all unread burn-after-read and view-limited pastes created with the API key", setup: panicCmd},
+		{name: "completion", args: "bash|zsh|fish", words: []string{"bash", "zsh", "fish"}, summary: "Print the shell completion script", setup: completionCmd},
+		{name: "man", summary: "Print the man page", setup: manCmd},
+		{name: "version", summary: "Print version information", setup: versionCmd},
+		{name: "help", summary: "Print usage", setup: helpCmd},
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cfg, err := loadConfig(configFlag(args))
+	if err != nil {
+		fmt.Fprintf(stderr, "gisty: %v\n", err)
+		return exitError
+	}
+
+	cmd := commands[0]
+	if len(args) > 0 {
+		switch args[0] {
+		case "-h", "-help", "--help":
+			printUsage(stdout)
+			return exitOK
+		case "-version", "--version":
+			fmt.Fprintln(stdout, version.Get().String())
+			return exitOK
+		}
+		if c := findCommand(args[0]); c != nil {
+			cmd, args = c, args[1:]
+		}
+	}
+
+	name := "gisty"
+	if cmd != commands[0] {
+		name += " " + cmd.name
+	}
+	fs := newFlagSet(name, cfg, stderr)
+	act := cmd.setup(fs)
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	return act(cfg, fs.Args(), stdin, stdout, stderr)
+}
+
+// findCommand returns the command named or aliased name, or nil
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name || slices.Contains(cmd.aliases, name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// newFlagSet creates the flags of a command, including the connection flags shared by all commands
+func newFlagSet(name string, cfg *cliConfig, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&cfg.Server, "server", cfg.Server, "Gisty server URL (env "+envServer+")")
+	fs.StringVar(&cfg.APIKey, "api-key", cfg.APIKey, "API key sent in X-API-Key (env "+envAPIKey+")")
+	// Read by configFlag before the flags are parsed, declared so that parsing accepts it
+	fs.String("config", "", "`file` to read settings from (env "+envConfig+")")
+	return fs
+}
+
+// createCmd creates a paste from a file or stdin and prints its URL
+func createCmd(fs *flag.FlagSet) action {
+	fs.Usage = func() { printUsage(fs.Output()) }
+	req := &createRequest{}
+	fs.StringVar(&req.SyntaxType, "syntax", "", "syntax type, e.g., go (detected by the server if omitted)")
+	fs.StringVar(&req.ExpiresIn, "expires", "", "expiration: 10m, 1h, 1d, 1w, never or burn")
+	fs.BoolVar(&req.IsPrivate, "private", false, "hide the paste from public listings")
+	burn := fs.Bool("burn", false, "delete the paste after its first read (same as -expires burn)")
+	fs.BoolVar(&req.Live, "live", false, "create a live paste, appended to with gisty append and followed with gisty tail")
+	fs.StringVar(&req.Title, "title", "", "title of the paste")
+	tags := fs.String("tags", "", "comma-separated tags, e.g., go,ops")
+	schema := fs.String("schema", "", "JSON Schema `file` to validate JSON or YAML content against")
+	outputOf := fs.String("output-of", "", "ID or URL of the paste this one holds the output of")
+	prod := &producer{}
+	fs.StringVar(&prod.Kind, "producer", "", "kind of job that produced the content, e.g., ci")
+	fs.StringVar(&prod.Name, "producer-name", "", "name of the producing job")
+	fs.StringVar(&prod.URL, "producer-url", "", "link to the producing job run")
+
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if *burn {
+			req.ExpiresIn = "burn"
+		}
+		for _, tag := range strings.Split(*tags, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				req.Tags = append(req.Tags, tag)
+			}
+		}
+		if len(args) > 1 {
+			fmt.Fprintln(stderr, "gisty: at most one file can be pasted")
+			return exitUsage
+		}
+		if *outputOf != "" {
+			req.OutputOf = parseID(*outputOf)
+		}
+		if prod.Kind != "" || prod.Name != "" || prod.URL != "" {
+			req.Producer = prod
+		}
+		if *schema != "" {
+			data, err := os.ReadFile(*schema)
+			if err != nil {
+				fmt.Fprintf(stderr, "gisty: %v\n", err)
+				return exitError
+			}
+			if !json.Valid(data) {
+				fmt.Fprintf(stderr, "gisty: %s is not valid JSON\n", *schema)
+				return exitError
+			}
+			req.Schema = data
+		}
+
+		content, code := readInput(args, stdin, stderr)
+		if code != exitOK {
+			return code
+		}
+		req.Content, req.Encoding = encodeContent(content)
+
+		resp, err := newClient(cfg).create(context.Background(), req)
+		if err != nil {
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+
+		fmt.Fprintln(stdout, resp.URL)
+		if resp.ExpiresAt != nil {
+			fmt.Fprintf(stderr, "Expires at %s\n", *resp.ExpiresAt)
+		}
+		if resp.DeleteToken != "" {
+			fmt.Fprintf(stderr, "Delete token: %s (gisty delete -token %s %s)\n", resp.DeleteToken, resp.DeleteToken, resp.ShortID)
+		}
+		if p := resp.ExpirationPolicy; p != nil && p.Capped {
+			fmt.Fprintf(stderr, "Expiration shortened: %s pastes are kept at most %s on this server\n", p.SyntaxType, p.MaxTTL)
+		}
+		if v := resp.Validation; v != nil && !v.Valid {
+			fmt.Fprintln(stderr, "Content does not match the schema:")
+			for _, e := range v.Errors {
+				fmt.Fprintf(stderr, "  %s\n", e)
+			}
+		}
+		return exitOK
+	}
+}
+
+// getCmd prints the content of a paste, or its JSON representation with -json
+func getCmd(fs *flag.FlagSet) action {
+	asJSON := fs.Bool("json", false, "print the paste with its metadata as JSON")
+
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if len(args) != 1 {
+			fmt.Fprintln(stderr, "usage: gisty get [flags] <id or URL>")
+			return exitUsage
+		}
+
+		// Content is fetched base64 encoded to print it byte for byte; -json shows it as the API does
+		encoding := codec.Base64
+		if *asJSON {
+			encoding = codec.None
+		}
+		p, err := newClient(cfg).get(context.Background(), parseID(args[0]), encoding)
+		if err != nil {
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(p); err != nil {
+				fmt.Fprintf(stderr, "gisty: %v\n", err)
+				return exitError
+			}
+			return exitOK
+		}
+
+		if p.Encrypted {
+			fmt.Fprintln(stderr, "gisty: the paste is client-side encrypted, printing the ciphertext")
+		}
+		content, err := codec.Decode(p.Encoding, p.Content)
+		if err != nil {
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+		_, _ = io.WriteString(stdout, content)
+		return exitOK
+	}
+}
+
+// deleteCmd deletes a paste
+func deleteCmd(fs *flag.FlagSet) action {
+	token := fs.String("token", "", "delete token printed when the paste was created without an API key")
+
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if len(args) != 1 {
+			fmt.Fprintln(stderr, "usage: gisty delete [flags] <id or URL>")
+			return exitUsage
+		}
+
+		shortID := parseID(args[0])
+		if err := newClient(cfg).delete(context.Background(), shortID, *token); err != nil {
+			var apiErr *apiError
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+				fmt.Fprintf(stderr, "gisty: paste %s not found\n", shortID)
+				return exitError
+			}
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusForbidden {
+				fmt.Fprintf(stderr, "gisty: paste %s was not created with this API key, pass its -token\n", shortID)
+				return exitError
+			}
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+
+		fmt.Fprintf(stderr, "Deleted %s\n", shortID)
+		return exitOK
+	}
+}
+
+// panicCmd destroys every unread secret paste created with the API key
+func panicCmd(fs *flag.FlagSet) action {
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if cfg.APIKey == "" {
+			fmt.Fprintln(stderr, "gisty: panic needs the API key the pastes were created with")
+			return exitUsage
+		}
+
+		resp, err := newClient(cfg).panic(context.Background())
+		if err != nil {
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+
+		for _, shortID := range resp.ShortIDs {
+			fmt.Fprintln(stdout, shortID)
+		}
+		fmt.Fprintf(stderr, "Deleted %d pastes\n", resp.Deleted)
+		return exitOK
+	}
+}
+
+// versionCmd prints version information
+func versionCmd(fs *flag.FlagSet) action {
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		fmt.Fprintln(stdout, version.Get().String())
+		return exitOK
+	}
+}
+
+// helpCmd prints usage
+func helpCmd(fs *flag.FlagSet) action {
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		printUsage(stdout)
+		return exitOK
+	}
+}
+
+// readInput reads the file named in args, or stdin when there is none or it is "-". Problems are
+// reported on stderr, with the exit code to return.
+func readInput(args []string, stdin io.Reader, stderr io.Writer) (string, int) {
+	input := stdin
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return "", exitError
+		}
+		defer f.Close()
+		input = f
+	} else if isTerminal(stdin) {
+		fmt.Fprintln(stderr, "gisty: no input, pipe content or pass a file (see gisty help)")
+		return "", exitUsage
+	}
+
+	content, err := io.ReadAll(input)
+	if err != nil {
+		fmt.Fprintf(stderr, "gisty: reading input: %v\n", err)
+		return "", exitError
+	}
+	if len(content) == 0 {
+		fmt.Fprintln(stderr, "gisty: input is empty")
+		return "", exitError
+	}
+	return string(content), exitOK
+}
+
+// encodeContent returns content with the transfer encoding to send it in: binary-ish content is
+// sent base64 encoded so JSON neither mangles nor bloats it
+func encodeContent(content string) (string, string) {
+	if codec.NeedsEncoding(content) {
+		encoded, _ := codec.Encode(codec.Base64, content)
+		return encoded, codec.Base64
+	}
+	return content, codec.None
+}
+
+// parseID accepts a short ID or a share URL such as https://gisty.io/xK9a2B or .../view/xK9a2B
+func parseID(arg string) string {
+	if u, err := url.Parse(arg); err == nil && u.Scheme != "" && u.Host != "" {
+		return path.Base(strings.TrimRight(u.Path, "/"))
+	}
+	return arg
+}
+
+// isTerminal reports whether r is an interactive terminal rather than a pipe or file
+func isTerminal(r io.Reader) bool {
+	f, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `gisty - command line client for Gisty
+
+Usage:
+  gisty [flags] [file]          Create a paste from a file, or from stdin
+  gisty push [-ignore pattern] <dir>
+                                Create a paste of each file of a directory, shown as a tree;
+                                .gitignore files are respected and .git is left out
+  gisty get [-json] <id|URL>    Print the content of a paste
+  gisty append [-token t] <id|URL> [file]
+                                Append a file, or stdin, to a live paste
+  gisty tail [-n lines] [-f=false] <id|URL>
+                                Print the end of a live paste and follow what is appended,
+                                until it is deleted or expires
+  gisty delete [-token t] <id|URL>
+                                Delete a paste, with the delete token printed when it was
+                                created without an API key
+  gisty panic                   Delete all unread burn-after-read and view-limited pastes
+                                created with the API key
+  gisty completion bash|zsh|fish
+                                Print the shell completion script
+  gisty man                     Print the man page
+  gisty version                 Print version information
+
+Create flags:
+  -syntax string    Syntax type, e.g., go (detected by the server if omitted)
+  -expires string   Expiration: 10m, 1h, 1d, 1w, never or burn
+  -private          Hide the paste from public listings
+  -burn             Delete the paste after its first read
+  -live             Create a live paste, appended to with gisty append
+  -title string     Title of the paste
+  -tags string      Comma-separated tags, e.g., go,ops
+  -schema file      JSON Schema to validate JSON or YAML content against
+  -output-of id     ID or URL of the paste this one holds the output of
+  -producer kind    Kind of job that produced the content, e.g., ci
+  -producer-name    Name of the producing job
+  -producer-url     Link to the producing job run
+
+Push flags:
+  -ignore pattern   Leave out matching files, in the .gitignore syntax (repeatable)
+  -expires string   Expiration: 10m, 1h, 1d, 1w or never
+  -private          Hide the pastes from public listings
+  -description      Description of the pastes
+  -dry-run          Print the files that would be pushed
+
+Connection flags (all commands):
+  -server string    Gisty server URL (default: http://localhost:8080)
+  -api-key string   API key sent in X-API-Key
+  -config file      Config file to read settings from
+
+Configuration:
+  Settings are read from the -config file, $GISTY_CONFIG or the user config directory
+  (e.g., ~/.config/gisty/config.yaml), then from the environment, then from flags:
+
+    server: https://gisty.io
+    api_key: ...
+
+Environment Variables:
+  GISTY_SERVER     Gisty server URL
+  GISTY_API_KEY    API key
+  GISTY_CONFIG     Path of the config file
+
+Examples:
+  cat main.go | gisty -syntax go -expires 1d
+  gisty -burn secret.txt
+  gisty get https://gisty.io/xK9a2B > main.go
+  gisty push -ignore '*.log' -expires 1w ./cmd
+  gisty -live build.log
+  gisty append xK9a2B step2.log
+  gisty tail -n 50 xK9a2B
+  gisty completion bash > /etc/bash_completion.d/gisty
+  gisty man > /usr/local/share/man/man1/gisty.1
+`)
+}
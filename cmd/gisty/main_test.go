@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseID(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"xK9a2B", "xK9a2B"},
+		{"https://gisty.io/xK9a2B", "xK9a2B"},
+		{"https://gisty.io/xK9a2B/", "xK9a2B"},
+		{"https://gisty.io/view/xK9a2B", "xK9a2B"},
+		{"http://localhost:8080/api/v1/pastes/xK9a2B", "xK9a2B"},
+		{"gisty.io/xK9a2B", "gisty.io/xK9a2B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			if got := parseID(tt.arg); got != tt.want {
+				t.Errorf("parseID(%q) = %q, want %q", tt.arg, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeAPI answers the API calls of the CLI and records them
+type fakeAPI struct {
+	requests []string
+	bodies   []map[string]any
+	headers  []http.Header
+}
+
+func (a *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.requests = append(a.requests, r.Method+" "+r.URL.RequestURI())
+	a.headers = append(a.headers, r.Header.Clone())
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	a.bodies = append(a.bodies, body)
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/pastes":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"short_id": "xK9a2B", "url": "https://gisty.io/xK9a2B", "delete_token": "t0k"}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v1/pastes/xK9a2B":
+		_, _ = io.WriteString(w, `{"short_id": "xK9a2B", "content": "aGVsbG8K", "encoding": "base64"}`)
+	case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/pastes/xK9a2B":
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/pastes/xK9a2B/append":
+		_, _ = io.WriteString(w, `{"short_id": "xK9a2B", "size": 12}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/bundles":
+		w.WriteHeader(http.StatusCreated)
+		_, _ = io.WriteString(w, `{"group_id": "Zk3q9XbW1pLm", "files": [{"path": "main.go", "short_id": "xK9a2B", "url": "https://gisty.io/xK9a2B", "delete_token": "t0k"}]}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v1/me/panic":
+		_, _ = io.WriteString(w, `{"deleted": 1, "short_ids": ["xK9a2B"]}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, `{"error": "Paste not found", "code": "paste_not_found"}`)
+	}
+}
+
+// setupRun points the CLI at a fake API, away from the user's config and environment
+func setupRun(t *testing.T) *fakeAPI {
+	t.Helper()
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	t.Setenv(envConfig, filepath.Join(t.TempDir(), "missing.json"))
+	t.Setenv(envServer, srv.URL)
+	t.Setenv(envAPIKey, "")
+	return api
+}
+
+func TestRun_Commands(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		wantCode    int
+		wantRequest string
+		wantStdout  string
+		wantStderr  string
+	}{
+		{
+			name:        "create from stdin",
+			args:        []string{"-syntax", "go", "-expires", "1d", "-tags", "go, ops"},
+			stdin:       "package main\n",
+			wantRequest: "POST /api/v1/pastes",
+			wantStdout:  "https://gisty.io/xK9a2B\n",
+			wantStderr:  "Delete token: t0k",
+		},
+		{
+			name:        "create subcommand",
+			args:        []string{"create", "-burn"},
+			stdin:       "secret",
+			wantRequest: "POST /api/v1/pastes",
+			wantStdout:  "https://gisty.io/xK9a2B\n",
+		},
+		{
+			name:       "create from empty stdin",
+			args:       []string{"create"},
+			wantCode:   exitError,
+			wantStderr: "input is empty",
+		},
+		{
+			name:        "get by URL",
+			args:        []string{"get", "https://gisty.io/xK9a2B"},
+			wantRequest: "GET /api/v1/pastes/xK9a2B?encoding=base64",
+			wantStdout:  "hello\n",
+		},
+		{
+			name:        "delete with token",
+			args:        []string{"rm", "-token", "t0k", "xK9a2B"},
+			wantRequest: "DELETE /api/v1/pastes/xK9a2B?delete_token=t0k",
+			wantStderr:  "Deleted xK9a2B",
+		},
+		{
+			name:        "append from stdin",
+			args:        []string{"append", "-token", "t0k", "https://gisty.io/xK9a2B"},
+			stdin:       "more\n",
+			wantRequest: "POST /api/v1/pastes/xK9a2B/append?delete_token=t0k",
+			wantStderr:  "Appended 5 bytes, 12 in total",
+		},
+		{
+			name:       "append without ID",
+			args:       []string{"append"},
+			wantCode:   exitUsage,
+			wantStderr: "usage: gisty append",
+		},
+		{
+			name:        "delete unknown paste",
+			args:        []string{"delete", "nope"},
+			wantCode:    exitError,
+			wantRequest: "DELETE /api/v1/pastes/nope",
+			wantStderr:  "paste nope not found",
+		},
+		{
+			name:       "delete without ID",
+			args:       []string{"delete"},
+			wantCode:   exitUsage,
+			wantStderr: "usage: gisty delete",
+		},
+		{
+			name:        "panic",
+			args:        []string{"panic", "-api-key", "k3y"},
+			wantRequest: "POST /api/v1/me/panic",
+			wantStdout:  "xK9a2B\n",
+			wantStderr:  "Deleted 1 pastes",
+		},
+		{
+			name:       "panic without API key",
+			args:       []string{"panic"},
+			wantCode:   exitUsage,
+			wantStderr: "needs the API key",
+		},
+		{
+			name:       "help",
+			args:       []string{"help"},
+			wantStdout: "gisty - command line client for Gisty",
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"get", "-nope", "xK9a2B"},
+			wantCode:   exitUsage,
+			wantStderr: "flag provided but not defined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := setupRun(t)
+			var stdout, stderr bytes.Buffer
+
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("run(%v) = %d, want %d (stderr %q)", tt.args, code, tt.wantCode, stderr.String())
+			}
+			if tt.wantRequest == "" && len(api.requests) != 0 {
+				t.Errorf("run(%v) sent %v, want no request", tt.args, api.requests)
+			}
+			if tt.wantRequest != "" && (len(api.requests) != 1 || api.requests[0] != tt.wantRequest) {
+				t.Errorf("run(%v) sent %v, want %q", tt.args, api.requests, tt.wantRequest)
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("run(%v) stdout = %q, want %q", tt.args, stdout.String(), tt.wantStdout)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("run(%v) stderr = %q, want %q", tt.args, stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRun_CreateRequest(t *testing.T) {
+	api := setupRun(t)
+	var stdout, stderr bytes.Buffer
+
+	args := []string{"-api-key", "k3y", "-syntax", "go", "-expires", "1d", "-private", "-title", "Main", "-tags", "go, ,ops"}
+	if code := run(args, strings.NewReader("package main\n"), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, stderr %q", code, stderr.String())
+	}
+
+	body := api.bodies[0]
+	want := map[string]any{
+		"content":     "package main\n",
+		"syntax_type": "go",
+		"expires_in":  "1d",
+		"is_private":  true,
+		"title":       "Main",
+	}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("create body %s = %v, want %v", k, body[k], v)
+		}
+	}
+	if tags, _ := json.Marshal(body["tags"]); string(tags) != `["go","ops"]` {
+		t.Errorf("create body tags = %s, want [\"go\",\"ops\"]", tags)
+	}
+	if got := api.headers[0].Get(apiKeyHeader); got != "k3y" {
+		t.Errorf("create sent %s %q, want %q", apiKeyHeader, got, "k3y")
+	}
+}
+
+func TestRun_CreateEncodesBinary(t *testing.T) {
+	api := setupRun(t)
+	var stdout, stderr bytes.Buffer
+
+	if code := run(nil, strings.NewReader("\x00\x01\x02"), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, stderr %q", code, stderr.String())
+	}
+	if body := api.bodies[0]; body["encoding"] != "base64" || body["content"] != "AAEC" {
+		t.Errorf("create body = %v, want the content base64 encoded", body)
+	}
+}
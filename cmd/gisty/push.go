@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// maxBundleFiles is the server's limit on the number of files pushed at once
+	maxBundleFiles = 100
+	// maxBundleSize is the server's limit on the size of the files pushed at once
+	maxBundleSize = 1 << 20
+)
+
+// pushCmd creates a paste of each file of a directory, grouped as a tree
+func pushCmd(fs *flag.FlagSet) action {
+	var ignores []string
+	fs.Func("ignore", "leave out files and directories matching `pattern`, in the .gitignore syntax (repeatable)", func(s string) error {
+		ignores = append(ignores, s)
+		return nil
+	})
+	req := &bundleRequest{}
+	fs.StringVar(&req.ExpiresIn, "expires", "", "expiration: 10m, 1h, 1d, 1w or never")
+	fs.BoolVar(&req.IsPrivate, "private", false, "hide the pastes from public listings")
+	fs.StringVar(&req.Description, "description", "", "description of the pastes")
+	dryRun := fs.Bool("dry-run", false, "print the files that would be pushed, without pushing them")
+
+	return func(cfg *cliConfig, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if len(args) != 1 {
+			fmt.Fprintln(stderr, "usage: gisty push [flags] <dir>")
+			return exitUsage
+		}
+
+		ig := &ignorer{}
+		for _, pattern := range ignores {
+			ig.add("", pattern)
+		}
+		files, err := collectFiles(args[0], ig)
+		if err != nil {
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+		if len(files) == 0 {
+			fmt.Fprintf(stderr, "gisty: no files to push in %s\n", args[0])
+			return exitError
+		}
+		if *dryRun {
+			for _, file := range files {
+				fmt.Fprintln(stdout, file.Path)
+			}
+			return exitOK
+		}
+
+		req.Files = files
+		resp, err := newClient(cfg).createBundle(context.Background(), req)
+		if err != nil {
+			fmt.Fprintf(stderr, "gisty: %v\n", err)
+			return exitError
+		}
+
+		for _, file := range resp.Files {
+			fmt.Fprintf(stdout, "%s\t%s\n", file.Path, file.URL)
+		}
+		if resp.ExpiresAt != nil {
+			fmt.Fprintf(stderr, "Expires at %s\n", *resp.ExpiresAt)
+		}
+		for _, file := range resp.Files {
+			if file.DeleteToken != "" {
+				fmt.Fprintf(stderr, "Delete token of %s: %s\n", file.Path, file.DeleteToken)
+			}
+		}
+		return exitOK
+	}
+}
+
+// collectFiles reads the regular files under root that are not ignored, by ig or by the
+// .gitignore files met on the way. The .git directory, empty files and symbolic links are left out.
+func collectFiles(root string, ig *ignorer) ([]bundleFile, error) {
+	var files []bundleFile
+	size := 0
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." {
+				if d.Name() == ".git" || ig.ignored(rel, true) {
+					return filepath.SkipDir
+				}
+			} else {
+				rel = ""
+			}
+			return ig.load(p, rel)
+		}
+		if !d.Type().IsRegular() || ig.ignored(rel, false) {
+			return nil
+		}
+
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if len(content) == 0 {
+			return nil
+		}
+		if len(files) == maxBundleFiles {
+			return fmt.Errorf("%s has more than %d files, leave some out with -ignore", root, maxBundleFiles)
+		}
+		if size += len(content); size > maxBundleSize {
+			return fmt.Errorf("%s has more than 1MB of content, leave some out with -ignore", root)
+		}
+		file := bundleFile{Path: rel}
+		file.Content, file.Encoding = encodeContent(string(content))
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
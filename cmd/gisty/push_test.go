@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates files under dir from slash-separated paths
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCollectFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		".gitignore":        "*.log\n",
+		".git/HEAD":         "ref: refs/heads/main\n",
+		"main.go":           "package main\n",
+		"build.log":         "ok\n",
+		"empty.txt":         "",
+		"web/.gitignore":    "dist/\n",
+		"web/index.html":    "<p>hi</p>\n",
+		"web/dist/app.js":   "x\n",
+		"vendor/lib/lib.go": "package lib\n",
+		"data/blob.bin":     "\x00\x01\x02",
+	})
+
+	ig := &ignorer{}
+	ig.add("", "vendor")
+	files, err := collectFiles(dir, ig)
+	if err != nil {
+		t.Fatalf("collectFiles() error = %v", err)
+	}
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.Path)
+		if file.Path == "data/blob.bin" && file.Encoding != "base64" {
+			t.Errorf("binary file sent with encoding %q, want base64", file.Encoding)
+		}
+	}
+	if got := strings.Join(paths, ","); got != ".gitignore,data/blob.bin,main.go,web/.gitignore,web/index.html" {
+		t.Errorf("collectFiles() paths = %s", got)
+	}
+}
+
+func TestRun_Push(t *testing.T) {
+	api := setupRun(t)
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"main.go": "package main\n", "README.md": "# Demo\n"})
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"push", "-expires", "1w", dir}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, stderr %q", code, stderr.String())
+	}
+	if len(api.requests) != 1 || api.requests[0] != "POST /api/v1/bundles" {
+		t.Fatalf("push sent %v, want POST /api/v1/bundles", api.requests)
+	}
+	if files, _ := api.bodies[0]["files"].([]any); len(files) != 2 || api.bodies[0]["expires_in"] != "1w" {
+		t.Errorf("push body = %v, want both files expiring in 1w", api.bodies[0])
+	}
+	if got := stdout.String(); got != "main.go\thttps://gisty.io/xK9a2B\n" {
+		t.Errorf("push stdout = %q, want the paths and URLs of the pastes", got)
+	}
+	if !strings.Contains(stderr.String(), "Delete token of main.go: t0k") {
+		t.Errorf("push stderr = %q, want the delete token", stderr.String())
+	}
+}
+
+func TestRun_PushDryRun(t *testing.T) {
+	api := setupRun(t)
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"main.go": "package main\n", "out/app": "bin\n"})
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"push", "-dry-run", "--ignore", "out/", dir}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Fatalf("run() = %d, stderr %q", code, stderr.String())
+	}
+	if stdout.String() != "main.go\n" || len(api.requests) != 0 {
+		t.Errorf("push -dry-run stdout = %q after %v, want the files without a request", stdout.String(), api.requests)
+	}
+}
@@ -0,0 +1,545 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/config"
+	"github.com/huylvt/gisty/internal/event"
+	"github.com/huylvt/gisty/internal/mail"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/service"
+	"github.com/huylvt/gisty/internal/tracing"
+	"github.com/huylvt/gisty/internal/worker"
+)
+
+// app holds the connections and services shared by the serve and worker modes
+type app struct {
+	cfg *config.Config
+
+	// baseURL is the public base of the API; shortURLBase is the base of share links
+	baseURL      string
+	shortURLBase string
+
+	mongoDB     *repository.MongoDB
+	redisClient *repository.Redis
+	s3Client    *repository.S3
+
+	kgs                *service.KGS
+	storageService     *service.Storage
+	cacheService       *service.Cache
+	maintenanceService *service.Maintenance
+	bans               *service.Bans
+	ipDenyList         *service.IPDenyList // nil unless the IP deny list is enabled
+	pasteRepo          *repository.PasteRepository
+	revisionRepo       *repository.RevisionRepository
+	userRepo           *repository.UserRepository // nil unless user accounts are configured
+	pasteService       *service.PasteService
+	eventBus           event.Bus
+	cdnPurger          *service.CDNPurger // nil unless a CDN is configured
+	watchdog           *service.Watchdog  // nil unless the watchdog is enabled
+	journal            *service.Journal   // nil unless a journal path is configured
+	uploadService      *service.UploadService
+	cleanupWorker      *worker.CleanupWorker
+	staleReporter      *worker.StaleReporter   // nil when stale reports are disabled
+	storageVerifier    *worker.StorageVerifier // nil when storage verification is disabled
+	mailer             mail.Mailer             // nil unless user accounts and SMTP are configured
+	digestSender       *worker.DigestSender    // nil unless notification digests are emailed
+
+	// changeStreamPublisher is nil unless paste events are enabled
+	changeStreamPublisher *worker.ChangeStreamPublisher
+
+	// drain is set on SIGUSR1 or through the admin API; a draining instance fails readiness
+	// for drainDelay, then shuts down within shutdownTimeout
+	drain           *service.Drain
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
+
+	// shutdownTracing flushes pending spans
+	shutdownTracing func(context.Context) error
+
+	// background is the context of the monitors started with the app, cancelled on close
+	background     context.Context
+	stopBackground context.CancelFunc
+}
+
+// newApp connects to MongoDB, Redis and S3 as boot retries and initializes the shared services
+func newApp(cfg *config.Config, boot *startup) *app {
+	a := &app{cfg: cfg}
+	ctx := context.Background()
+	a.background, a.stopBackground = context.WithCancel(ctx)
+
+	a.drain = service.NewDrain()
+	a.drainDelay = parseDuration("drain delay", cfg.Server.DrainDelay, 10*time.Second)
+	a.shutdownTimeout = parseDuration("shutdown timeout", cfg.Server.ShutdownTimeout, 30*time.Second)
+
+	// Set up tracing first, so the clients below export their spans
+	shutdownTracing, err := tracing.Setup(ctx, &tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up tracing: %v", err)
+	}
+	a.shutdownTracing = shutdownTracing
+	if cfg.Tracing.Enabled {
+		log.Printf("Tracing enabled (service: %s, sample ratio: %g)", cfg.Tracing.ServiceName, cfg.Tracing.SampleRatio)
+	}
+
+	// Connect to MongoDB, Redis and S3, retrying while they come up
+	boot.connect("mongodb", func(ctx context.Context) error {
+		a.mongoDB, err = repository.NewMongoClient(ctx, cfg.MongoDB.URI, cfg.MongoDB.Database)
+		return err
+	})
+	mongoDB := a.mongoDB
+	log.Println("Connected to MongoDB")
+
+	boot.connect("redis", func(ctx context.Context) error {
+		a.redisClient, err = repository.NewRedisClient(ctx, cfg.Redis.URI)
+		return err
+	})
+	redisClient := a.redisClient
+	log.Println("Connected to Redis")
+
+	boot.connect("s3", func(ctx context.Context) error {
+		s3Client, err := repository.NewS3Client(ctx, repository.S3Config{
+			BucketName:      cfg.S3.BucketName,
+			Region:          cfg.S3.Region,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			Endpoint:        cfg.S3.Endpoint,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create S3 client: %w", err)
+		}
+		if err := s3Client.EnsureBucketExists(ctx); err != nil {
+			return fmt.Errorf("failed to verify bucket '%s': %w", cfg.S3.BucketName, err)
+		}
+		a.s3Client = s3Client
+		return nil
+	})
+	s3Client := a.s3Client
+	log.Printf("Connected to S3, bucket '%s' verified", cfg.S3.BucketName)
+
+	// Initialize KGS (Key Generation Service)
+	a.kgs, err = service.NewKGS(mongoDB.Database)
+	if err != nil {
+		log.Fatalf("Failed to initialize KGS: %v", err)
+	}
+	if cfg.KGS.LeaseBlockSize > 0 {
+		owner := a.kgs.SetLeasing(cfg.KGS.LeaseBlockSize, parseDuration("KGS lease TTL", cfg.KGS.LeaseTTL, service.DefaultLeaseTTL))
+		log.Printf("Leasing keys in blocks of %d as %s", cfg.KGS.LeaseBlockSize, owner)
+	}
+	if err := a.kgs.SetKeyAlphabet(ctx, cfg.KGS.KeyCasing, cfg.KGS.UnambiguousKeys); err != nil {
+		log.Fatalf("Invalid key casing: %v", err)
+	}
+	if cfg.KGS.CriticalFloor > 0 {
+		a.kgs.SetCriticalFloor(cfg.KGS.CriticalFloor, parseDuration("KGS exhausted Retry-After", cfg.KGS.ExhaustedRetryAfter, service.DefaultKeyFloorRetryAfter))
+		go a.kgs.MonitorCriticalFloor(a.background, service.DefaultKeyFloorCheckInterval)
+		log.Printf("Rejecting creates below %d unused keys", cfg.KGS.CriticalFloor)
+	}
+	if cfg.KGS.BufferSize > 0 {
+		a.kgs.SetBuffer(cfg.KGS.BufferSize)
+		log.Printf("Buffering %d keys in memory", cfg.KGS.BufferSize)
+	}
+	if cfg.Startup.PrewarmKeys > 0 {
+		generated, err := a.kgs.Prewarm(context.Background(), cfg.Startup.PrewarmKeys)
+		if err != nil {
+			// The replenish worker fills the pool on its first run
+			log.Printf("Failed to pre-warm KGS: %v", err)
+		} else if generated > 0 {
+			log.Printf("Key pool was empty, generated %d keys", generated)
+		}
+	}
+
+	// Initialize services
+	// Content read back from storage is bounded by the largest upload the server accepts
+	maxContentSize := max(int64(service.DefaultMaxDecompressedSize), cfg.Upload.MaxSize, cfg.Upload.MultipartMaxSize)
+	a.storageService = service.NewStorageWithLimit(s3Client, maxContentSize)
+	compression, err := service.ParseCompression(cfg.S3.Compression)
+	if err != nil {
+		log.Printf("Invalid S3 compression '%s', using default %s", cfg.S3.Compression, service.CompressionGzip)
+		compression = service.CompressionGzip
+	}
+	a.storageService.SetCompression(compression)
+	a.cacheService = service.NewCache(redisClient)
+	if cfg.Cache.LocalSize > 0 {
+		localTTL := parseDuration("local cache TTL", cfg.Cache.LocalTTL, service.DefaultLocalCacheTTL)
+		a.cacheService.SetLocal(service.NewLocalCache(cfg.Cache.LocalSize, localTTL))
+		log.Printf("In-process cache enabled (size: %d bytes, TTL: %v)", cfg.Cache.LocalSize, localTTL)
+	}
+	a.maintenanceService = service.NewMaintenance(redisClient)
+	a.bans = service.NewBans(redisClient)
+	if cfg.IPDeny.Enabled {
+		a.ipDenyList, err = service.NewIPDenyList(redisClient, service.IPDenyListConfig{
+			DenyList:       strings.Split(cfg.IPDeny.DenyList, ","),
+			AllowList:      strings.Split(cfg.IPDeny.AllowList, ","),
+			File:           cfg.IPDeny.File,
+			ReloadInterval: parseDuration("IP deny list reload interval", cfg.IPDeny.ReloadInterval, service.DefaultIPDenyReloadInterval),
+		})
+		if err != nil {
+			log.Fatalf("Invalid IP deny list: %v", err)
+		}
+		if err := a.ipDenyList.Reload(ctx); err != nil {
+			log.Printf("Failed to load IP deny list: %v", err)
+		}
+		go a.ipDenyList.Start(a.background)
+	}
+
+	// Initialize repositories
+	a.pasteRepo, err = repository.NewPasteRepository(mongoDB.Database)
+	if err != nil {
+		log.Fatalf("Failed to initialize paste repository: %v", err)
+	}
+	a.revisionRepo, err = repository.NewRevisionRepository(mongoDB.Database)
+	if err != nil {
+		log.Fatalf("Failed to initialize revision repository: %v", err)
+	}
+
+	// Initialize paste service
+	a.baseURL = fmt.Sprintf("http://localhost:%s", cfg.Server.Port)
+	if cfg.Server.Env == "production" {
+		a.baseURL = cfg.Server.BaseURL
+	}
+	a.baseURL = strings.TrimRight(a.baseURL, "/")
+	a.shortURLBase = a.baseURL
+	if cfg.Server.ShortURLBase != "" {
+		a.shortURLBase = strings.TrimRight(cfg.Server.ShortURLBase, "/")
+	}
+	a.pasteService = service.NewPasteService(a.kgs, a.storageService, a.cacheService, a.pasteRepo, a.shortURLBase)
+	a.pasteService.SetRevisionRepository(a.revisionRepo)
+	// Comments on pastes, and the notifications of them, need user accounts
+	if cfg.Auth.SessionSecret != "" {
+		a.userRepo, err = repository.NewUserRepository(mongoDB.Database)
+		if err != nil {
+			log.Fatalf("Failed to initialize user repository: %v", err)
+		}
+		commentRepo, err := repository.NewCommentRepository(mongoDB.Database)
+		if err != nil {
+			log.Fatalf("Failed to initialize comment repository: %v", err)
+		}
+		notificationRepo, err := repository.NewNotificationRepository(mongoDB.Database)
+		if err != nil {
+			log.Fatalf("Failed to initialize notification repository: %v", err)
+		}
+		a.pasteService.SetComments(commentRepo, notificationRepo, a.userRepo)
+	}
+	if cfg.S3.DownloadURLMinSize > 0 {
+		expiry := parseDuration("download URL expiry", cfg.S3.DownloadURLExpiry, service.DefaultDownloadURLExpiry)
+		a.pasteService.SetDownloadURLs(cfg.S3.DownloadURLMinSize, expiry)
+		log.Printf("Pastes stored above %d bytes may be downloaded from S3 with pre-signed URLs", cfg.S3.DownloadURLMinSize)
+	}
+	if cfg.Admin.IPHashKey != "" {
+		a.pasteService.SetSourceIPHashKey(cfg.Admin.IPHashKey)
+		log.Println("Recording hashed source IPs of new pastes")
+	}
+	// Abuse reports are reviewed with the admin API
+	if cfg.Admin.Token != "" {
+		reportRepo, err := repository.NewReportRepository(mongoDB.Database)
+		if err != nil {
+			log.Fatalf("Failed to initialize report repository: %v", err)
+		}
+		a.pasteService.SetReports(reportRepo)
+	}
+	a.eventBus, err = event.New(&event.Config{
+		Driver:        cfg.Events.Driver,
+		QueueSize:     cfg.Events.QueueSize,
+		Region:        cfg.Server.Region,
+		NATSURL:       cfg.Events.NATSURL,
+		SubjectPrefix: cfg.Events.SubjectPrefix,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize event bus: %v", err)
+	}
+	if cfg.Events.WebhookURL != "" {
+		a.eventBus.Subscribe("webhook", worker.NewWebhookSink(cfg.Events.WebhookURL, worker.DefaultWebhookTimeout).Publish)
+	}
+	if cfg.CDN.Provider != "" {
+		if a.cdnPurger, err = service.NewCDNPurger(cfg.CDN.Provider, cfg.CDN.PurgeURL, cfg.CDN.PurgeToken); err != nil {
+			log.Printf("Invalid CDN provider '%s', not purging the CDN: %v", cfg.CDN.Provider, err)
+		} else {
+			a.eventBus.Subscribe("cdn", a.cdnPurger.HandleEvent)
+			log.Printf("Purging edited and deleted pastes from the %s CDN", cfg.CDN.Provider)
+		}
+	}
+	a.pasteService.SetEventBus(a.eventBus)
+	if cfg.KGS.CaseInsensitiveIDs {
+		if err := a.pasteService.SetCaseInsensitiveIDs(ctx); err != nil {
+			log.Fatalf("Failed to enable case-insensitive IDs: %v", err)
+		}
+		log.Println("Redirecting short IDs retyped in another case")
+	}
+	if cfg.Watchdog.Enabled {
+		a.watchdog = service.NewWatchdog(&service.WatchdogConfig{
+			Interval:         parseDuration("watchdog interval", cfg.Watchdog.Interval, service.DefaultWatchdogInterval),
+			Timeout:          parseDuration("watchdog timeout", cfg.Watchdog.Timeout, service.DefaultWatchdogTimeout),
+			FailureThreshold: cfg.Watchdog.FailureThreshold,
+		})
+		a.watchdog.AddDependency(service.DependencyMongoDB, mongoDB.Ping)
+		a.watchdog.AddDependency(service.DependencyRedis, redisClient.Ping)
+		a.watchdog.AddDependency(service.DependencyS3, s3Client.HealthCheck)
+		a.pasteService.SetWatchdog(a.watchdog)
+		go a.watchdog.Start(context.Background())
+	}
+	if cfg.Journal.Path != "" {
+		a.journal, err = service.OpenJournal(cfg.Journal.Path, cfg.Journal.InlineMaxSize)
+		if err != nil {
+			log.Fatalf("Failed to open journal: %v", err)
+		}
+		a.pasteService.SetJournal(a.journal)
+		replayed, err := a.pasteService.ReplayJournal(ctx)
+		if err != nil {
+			log.Printf("Failed to replay journal: %v", err)
+		} else if replayed > 0 {
+			log.Printf("Completed %d journaled creates", replayed)
+		}
+		log.Printf("Journaling creates to %s", cfg.Journal.Path)
+	}
+	log.Printf("Event bus initialized (driver: %s)", cfg.Events.Driver)
+	if cfg.Content.Linters != "" {
+		linters, err := service.NewLinters(strings.Split(cfg.Content.Linters, ","))
+		if err != nil {
+			log.Fatalf("Invalid linters: %v", err)
+		}
+		a.pasteService.SetLinters(linters...)
+		log.Printf("Linting pastes with %s", cfg.Content.Linters)
+	}
+	expirationPolicies, err := service.ParseExpirationPolicies(cfg.Content.ExpirationPolicies)
+	if err != nil {
+		log.Fatalf("Invalid expiration policies: %v", err)
+	}
+	if len(expirationPolicies) > 0 {
+		a.pasteService.SetExpirationPolicies(expirationPolicies)
+		log.Printf("Capping paste lifetime by syntax type: %s", service.FormatExpirationPolicies(expirationPolicies))
+	}
+	if cfg.Runner.Endpoint != "" {
+		runTimeout, err := time.ParseDuration(cfg.Runner.Timeout)
+		if err != nil {
+			log.Printf("Invalid runner timeout '%s', using default %v", cfg.Runner.Timeout, service.DefaultRunTimeout)
+			runTimeout = service.DefaultRunTimeout
+		}
+		a.pasteService.SetRunner(service.NewHTTPRunner(cfg.Runner.Endpoint, cfg.Runner.Token), service.RunnerConfig{
+			Languages: strings.Split(cfg.Runner.Languages, ","),
+			Timeout:   runTimeout,
+		})
+		log.Printf("Running pastes in sandbox (languages: %s, timeout: %v)", cfg.Runner.Languages, runTimeout)
+	}
+	if cfg.Gist.Enabled {
+		a.pasteService.SetGists(service.NewGitHubGists(cfg.Gist.APIURL, cfg.Gist.Token))
+		log.Printf("Gist import and export enabled (API: %s)", cfg.Gist.APIURL)
+	}
+	if cfg.URLImport.Enabled {
+		fetchTimeout, err := time.ParseDuration(cfg.URLImport.Timeout)
+		if err != nil {
+			log.Printf("Invalid URL import timeout '%s', using default %v", cfg.URLImport.Timeout, service.DefaultURLFetchTimeout)
+			fetchTimeout = service.DefaultURLFetchTimeout
+		}
+		fetcher, err := service.NewURLFetcher(service.URLFetcherConfig{
+			Timeout:      fetchTimeout,
+			ContentTypes: strings.Split(cfg.URLImport.ContentTypes, ","),
+			DenyList:     strings.Split(cfg.URLImport.DenyList, ","),
+		})
+		if err != nil {
+			log.Fatalf("Invalid URL import deny list: %v", err)
+		}
+		a.pasteService.SetURLFetcher(fetcher)
+		log.Printf("URL import enabled (timeout: %v)", fetchTimeout)
+	}
+	if cfg.Screening.Enabled {
+		screeners := []service.Screener{service.NewCredentialScreener(cfg.Screening.CredentialLines, cfg.Screening.SecretTokens)}
+		if cfg.Screening.Keywords != "" {
+			screeners = append(screeners, service.NewKeywordScreener(strings.Split(cfg.Screening.Keywords, ",")))
+		}
+		if cfg.Screening.DenyDomains != "" {
+			screeners = append(screeners, service.NewDomainScreener(strings.Split(cfg.Screening.DenyDomains, ",")))
+		}
+		if cfg.Screening.ScannerURL != "" {
+			screeners = append(screeners, service.NewHTTPScanner(cfg.Screening.ScannerURL, cfg.Screening.ScannerToken))
+		}
+		screeningPolicy := service.ScreeningPolicy{
+			Strictness: cfg.Screening.Strictness,
+			MaxAction:  cfg.Screening.MaxAction,
+			Timeout:    parseDuration("screening timeout", cfg.Screening.Timeout, service.DefaultScreeningTimeout),
+		}
+		if err := a.pasteService.SetScreening(screeningPolicy, screeners...); err != nil {
+			log.Fatalf("Invalid screening policy: %v", err)
+		}
+		log.Printf("Screening new pastes (strictness: %s, max action: %s)", screeningPolicy.Strictness, screeningPolicy.MaxAction)
+	}
+	if cfg.Trending.Enabled {
+		halfLife, err := time.ParseDuration(cfg.Trending.HalfLife)
+		if err != nil {
+			log.Printf("Invalid trending half-life '%s', using default 6h", cfg.Trending.HalfLife)
+			halfLife = service.DefaultTrendingHalfLife
+		}
+		a.pasteService.SetTrending(service.NewTrending(redisClient, halfLife))
+		log.Printf("Trending enabled (half-life: %v)", halfLife)
+	}
+	contentPolicy := service.ContentPolicy{
+		MaxLineLength: cfg.Content.MaxLineLength,
+		Action:        cfg.Content.Policy,
+	}
+	if err := a.pasteService.SetContentPolicy(contentPolicy); err != nil {
+		log.Printf("Invalid content policy '%s', using default %s", cfg.Content.Policy, service.ContentPolicyTruncatePreview)
+	}
+	cachePolicy := service.CachePolicy{
+		DefaultTTL:   parseDuration("cache TTL", cfg.Cache.TTL, service.DefaultCacheTTL),
+		HotTTL:       parseDuration("cache hot TTL", cfg.Cache.HotTTL, service.DefaultCacheHotTTL),
+		HotViews:     cfg.Cache.HotViews,
+		PermanentTTL: parseDuration("cache permanent TTL", cfg.Cache.PermanentTTL, service.DefaultCachePermanentTTL),
+		SmallTTL:     parseDuration("cache small TTL", cfg.Cache.SmallTTL, service.DefaultCacheSmallTTL),
+		SmallSize:    cfg.Cache.SmallSize,
+		MaxSize:      cfg.Cache.MaxSize,
+		AdmitViews:   cfg.Cache.AdmitViews,
+		AdmitWindow:  parseDuration("cache admission window", cfg.Cache.AdmitWindow, service.DefaultCacheAdmitWindow),
+	}
+	if err := a.pasteService.SetCachePolicy(cachePolicy); err != nil {
+		log.Printf("Invalid cache policy %+v, using defaults", cachePolicy)
+	}
+
+	// Initialize direct upload service
+	uploadURLExpiry, err := time.ParseDuration(cfg.Upload.URLExpiry)
+	if err != nil {
+		log.Printf("Invalid upload URL expiry '%s', using default 15m", cfg.Upload.URLExpiry)
+		uploadURLExpiry = service.DefaultUploadURLExpiry
+	}
+	uploadSessionTTL, err := time.ParseDuration(cfg.Upload.SessionTTL)
+	if err != nil {
+		log.Printf("Invalid upload session TTL '%s', using default 24h", cfg.Upload.SessionTTL)
+		uploadSessionTTL = service.DefaultUploadSessionTTL
+	}
+	a.uploadService = service.NewUploadService(a.pasteService, &service.UploadConfig{
+		MaxSize:          cfg.Upload.MaxSize,
+		URLExpiry:        uploadURLExpiry,
+		MultipartMaxSize: cfg.Upload.MultipartMaxSize,
+		PartSize:         cfg.Upload.PartSize,
+		SessionTTL:       uploadSessionTTL,
+	})
+
+	// Initialize cleanup worker (started only in worker mode)
+	cleanupInterval, err := time.ParseDuration(cfg.Cleanup.Interval)
+	if err != nil {
+		log.Printf("Invalid cleanup interval '%s', using default 5m", cfg.Cleanup.Interval)
+		cleanupInterval = 5 * time.Minute
+	}
+	cleanupMinAge, err := time.ParseDuration(cfg.Cleanup.MinAge)
+	if err != nil {
+		log.Printf("Invalid cleanup min age '%s', using default 1h", cfg.Cleanup.MinAge)
+		cleanupMinAge = worker.DefaultCleanupMinAge
+	}
+	a.cleanupWorker = worker.NewCleanupWorker(a.pasteRepo, a.storageService, a.cacheService, &worker.CleanupWorkerConfig{
+		Interval:    cleanupInterval,
+		BatchSize:   cfg.Cleanup.BatchSize,
+		DryRun:      cfg.Cleanup.DryRun,
+		MinAge:      cleanupMinAge,
+		MaxAttempts: cfg.Cleanup.MaxAttempts,
+		ScanLimit:   cfg.Cleanup.ScanLimit,
+	})
+	a.cleanupWorker.SetRevisionRepository(a.revisionRepo)
+
+	// Initialize the stale paste reporter (started only in worker mode)
+	if staleInterval := parseDuration("stale report interval", cfg.Cleanup.StaleReportInterval, worker.DefaultStaleReportInterval); staleInterval > 0 {
+		a.staleReporter = worker.NewStaleReporter(a.pasteService, cfg.Cleanup.StaleDays, staleInterval)
+	}
+
+	// Initialize the storage verifier (started only in worker mode)
+	if verifyInterval := parseDuration("verify interval", cfg.Cleanup.VerifyInterval, worker.DefaultVerifyInterval); verifyInterval > 0 {
+		a.storageVerifier = worker.NewStorageVerifier(a.pasteService, cfg.Cleanup.VerifySample, verifyInterval, cfg.Cleanup.VerifyQuarantine)
+	}
+
+	// Initialize the mailer of account emails and notification digests
+	if a.userRepo != nil && cfg.Mail.SMTPAddr != "" {
+		mailer, err := mail.NewSMTPMailer(cfg.Mail.SMTPAddr, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From)
+		if err != nil {
+			log.Printf("Invalid mail settings, emails disabled: %v", err)
+		} else {
+			a.mailer = mailer
+		}
+	}
+
+	// Initialize the notification digests (started only in worker mode)
+	if a.mailer != nil {
+		if digestInterval := parseDuration("digest interval", cfg.Mail.DigestInterval, worker.DefaultDigestInterval); digestInterval > 0 {
+			a.digestSender = worker.NewDigestSender(a.pasteService, a.mailer, digestInterval)
+		}
+	}
+
+	// Initialize the paste event publisher (started only in worker mode)
+	if cfg.ChangeStream.Enabled {
+		sink, err := worker.NewEventSink(cfg.ChangeStream.Sink, cfg.ChangeStream.WebhookURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize change stream sink: %v", err)
+		}
+		a.changeStreamPublisher = worker.NewChangeStreamPublisher(a.pasteRepo, repository.NewResumeTokenStore(mongoDB.Database), sink,
+			&worker.ChangeStreamPublisherConfig{Region: cfg.Server.Region})
+		log.Printf("Change stream publishing enabled (sink: %s)", cfg.ChangeStream.Sink)
+	}
+
+	return a
+}
+
+// close releases the Redis and MongoDB connections
+func (a *app) close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a.stopBackground()
+	if a.watchdog != nil {
+		a.watchdog.Stop()
+	}
+
+	// Deliver queued events while the connections their subscribers use are still open
+	a.eventBus.Close()
+	log.Println("Event bus closed")
+
+	// Close Redis connection
+	if err := a.redisClient.Close(); err != nil {
+		log.Printf("Error closing Redis connection: %v", err)
+	} else {
+		log.Println("Redis connection closed")
+	}
+
+	// Return the keys this replica buffered or leased and did not use to the pool
+	if released, err := a.kgs.ReleaseBuffer(ctx); err != nil {
+		log.Printf("Error releasing buffered keys: %v", err)
+	} else if released > 0 {
+		log.Printf("Released %d buffered keys", released)
+	}
+	if released, err := a.kgs.ReleaseLeases(ctx); err != nil {
+		log.Printf("Error releasing leased keys: %v", err)
+	} else if released > 0 {
+		log.Printf("Released %d leased keys", released)
+	}
+
+	if a.journal != nil {
+		if err := a.journal.Close(); err != nil {
+			log.Printf("Error closing journal: %v", err)
+		}
+	}
+
+	// Close MongoDB connection
+	if err := a.mongoDB.Close(ctx); err != nil {
+		log.Printf("Error closing MongoDB connection: %v", err)
+	} else {
+		log.Println("MongoDB connection closed")
+	}
+
+	// Flush the spans of the last requests
+	if err := a.shutdownTracing(ctx); err != nil {
+		log.Printf("Error flushing traces: %v", err)
+	}
+}
+
+// parseDuration parses a configured duration, falling back to def when it is invalid
+func parseDuration(name, value string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s '%s', using default %v", name, value, def)
+		return def
+	}
+	return d
+}
@@ -4,22 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/huylvt/gisty/internal/config"
-	"github.com/huylvt/gisty/internal/handler"
-	"github.com/huylvt/gisty/internal/middleware"
-	"github.com/huylvt/gisty/internal/repository"
-	"github.com/huylvt/gisty/internal/service"
-	"github.com/huylvt/gisty/internal/worker"
+	"github.com/huylvt/gisty/internal/version"
 
 	_ "github.com/huylvt/gisty/docs" // Swagger docs
 )
 
+const (
+	// modeAll runs the HTTP server and the background workers in one process
+	modeAll = "all"
+	// modeServe runs only the HTTP server
+	modeServe = "serve"
+	// modeWorker runs only the background workers
+	modeWorker = "worker"
+)
+
 // @title Gisty API
 // @version 1.0
 // @description Fast snippet sharing platform - API documentation
@@ -37,165 +41,114 @@ import (
 
 // @schemes http https
 
-func main() {
-	fmt.Println("Gisty Server")
-	fmt.Printf("Version: %s\n", "0.1.0")
-
-	if len(os.Args) > 1 && os.Args[1] == "--help" {
-		printHelp()
-		return
-	}
-
-	// Load configuration
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
-	}
-
-	log.Printf("Environment: %s", cfg.Server.Env)
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name X-Admin-Token
+// @description Admin token of the /admin routes (ADMIN_TOKEN)
 
-	// Connect to MongoDB
-	ctx := context.Background()
-	mongoDB, err := repository.NewMongoClient(ctx, cfg.MongoDB.URI, cfg.MongoDB.Database)
-	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
-	}
-	log.Println("Connected to MongoDB")
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Admin token, the S3 events token of /storage/events, or the session token of a signed-in user, sent as "Bearer <token>"
 
-	// Connect to Redis
-	redisClient, err := repository.NewRedisClient(ctx, cfg.Redis.URI)
-	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
-	}
-	log.Println("Connected to Redis")
-
-	// Connect to S3
-	s3Client, err := repository.NewS3Client(ctx, repository.S3Config{
-		BucketName:      cfg.S3.BucketName,
-		Region:          cfg.S3.Region,
-		AccessKeyID:     cfg.S3.AccessKeyID,
-		SecretAccessKey: cfg.S3.SecretAccessKey,
-		Endpoint:        cfg.S3.Endpoint,
-	})
-	if err != nil {
-		log.Fatalf("Failed to create S3 client: %v", err)
-	}
-	if err := s3Client.EnsureBucketExists(ctx); err != nil {
-		log.Fatalf("Failed to verify S3 bucket '%s': %v", cfg.S3.BucketName, err)
-	}
-	log.Printf("Connected to S3, bucket '%s' verified", cfg.S3.BucketName)
+// @securityDefinitions.apikey APIKey
+// @in header
+// @name X-API-Key
+// @description Optional API key (API_KEYS) raising rate limits and owning the pastes created with it; unknown keys are rejected with 401
 
-	// Initialize KGS (Key Generation Service)
-	kgs, err := service.NewKGS(mongoDB.Database)
-	if err != nil {
-		log.Fatalf("Failed to initialize KGS: %v", err)
-	}
-
-	// Start KGS background worker with cancellable context
-	kgsCtx, kgsCancel := context.WithCancel(context.Background())
-	go kgs.StartReplenishWorker(kgsCtx, service.DefaultWorkerConfig())
-
-	// Initialize services
-	storageService := service.NewStorage(s3Client)
-	cacheService := service.NewCache(redisClient)
-
-	// Initialize repositories
-	pasteRepo, err := repository.NewPasteRepository(mongoDB.Database)
-	if err != nil {
-		log.Fatalf("Failed to initialize paste repository: %v", err)
+func main() {
+	mode := modeAll
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "--version", "version":
+			fmt.Println(version.Get().String())
+			return
+		case "--help", "help":
+			printHelp()
+			return
+		case modeAll, modeServe, modeWorker:
+			mode = os.Args[1]
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", os.Args[1])
+			printHelp()
+			os.Exit(2)
+		}
 	}
 
-	// Initialize paste service
-	baseURL := fmt.Sprintf("http://localhost:%s", cfg.Server.Port)
-	if cfg.Server.Env == "production" {
-		baseURL = cfg.Server.BaseURL
-	}
-	pasteService := service.NewPasteService(kgs, storageService, cacheService, pasteRepo, baseURL)
+	fmt.Println("Gisty Server")
+	fmt.Printf("Version: %s\n", version.Get().String())
 
-	// Initialize and start cleanup worker
-	cleanupInterval, err := time.ParseDuration(cfg.Cleanup.Interval)
+	// Load configuration
+	cfg, err := config.Load()
 	if err != nil {
-		log.Printf("Invalid cleanup interval '%s', using default 5m", cfg.Cleanup.Interval)
-		cleanupInterval = 5 * time.Minute
-	}
-	cleanupWorker := worker.NewCleanupWorker(pasteRepo, storageService, cacheService, &worker.CleanupWorkerConfig{
-		Interval:  cleanupInterval,
-		BatchSize: cfg.Cleanup.BatchSize,
-	})
-	cleanupCtx, cleanupCancel := context.WithCancel(context.Background())
-	go cleanupWorker.Start(cleanupCtx)
-
-	// Initialize rate limiter
-	rateLimiter := middleware.NewRateLimiter(&middleware.RateLimitConfig{
-		RequestsPerMinute: cfg.RateLimit.RequestsPerMinute,
-		Enabled:           cfg.RateLimit.Enabled,
-	})
-	if cfg.RateLimit.Enabled {
-		log.Printf("Rate limiting enabled: %d requests/minute", cfg.RateLimit.RequestsPerMinute)
+		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Initialize handlers
-	pasteHandler := handler.NewPasteHandler(pasteService)
+	log.Printf("Environment: %s, mode: %s", cfg.Server.Env, mode)
 
-	// Setup router with dependencies
-	deps := &handler.RouterDeps{
-		PasteHandler: pasteHandler,
-		RateLimiter:  rateLimiter,
-		S3Client:     s3Client,
+	// Connect to dependencies and build shared services, serving health probes meanwhile
+	// when starting degraded
+	boot := newStartup(&cfg.Startup)
+	stopDegraded := func() {}
+	if cfg.Startup.Degraded {
+		stopDegraded = boot.serveDegraded(cfg)
 	}
-	router := handler.NewRouter(cfg, deps)
-
-	// Create HTTP server
-	srv := &http.Server{
-		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	a := newApp(cfg, boot)
+	stopDegraded()
+
+	// Anonymous usage reports, only when opted in
+	stopTelemetry := startTelemetry(a, mode)
+
+	stopWorkers := func(context.Context) {}
+	stopServer := func(context.Context) {}
+	switch mode {
+	case modeServe:
+		stopServer = runServer(a)
+	case modeWorker:
+		stopWorkers, stopServer = runWorkerMode(a)
+	default:
+		stopWorkers = startWorkers(a)
+		stopServer = runServer(a)
 	}
 
-	// Start server in a goroutine
-	go func() {
-		log.Printf("Server starting on port %s", cfg.Server.Port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
-		}
-	}()
-
-	// Wait for interrupt signal for graceful shutdown
+	// Wait for an interrupt signal, or for a drain requested with SIGUSR1 or the admin API
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Println("Shutting down server...")
-
-	// Stop KGS worker
-	kgsCancel()
-
-	// Stop Cleanup worker
-	cleanupCancel()
-
-	// Give outstanding requests 5 seconds to complete
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
-	}
+	drainSignal := make(chan os.Signal, 1)
+	signal.Notify(drainSignal, syscall.SIGUSR1)
+	go func() {
+		<-drainSignal
+		a.drain.Start()
+	}()
 
-	// Close Redis connection
-	if err := redisClient.Close(); err != nil {
-		log.Printf("Error closing Redis connection: %v", err)
-	} else {
-		log.Println("Redis connection closed")
+	var ctx context.Context
+	var cancel context.CancelFunc
+	select {
+	case <-quit:
+		a.drain.Start()
+		ctx, cancel = context.WithTimeout(context.Background(), a.shutdownTimeout)
+	case <-a.drain.Done():
+		// Workers stop taking new work right away, while the server keeps answering until load
+		// balancers notice the failing readiness probe; a signal cuts the wait short
+		log.Printf("Draining: readiness failing, shutting down in %v", a.drainDelay)
+		ctx, cancel = context.WithTimeout(context.Background(), a.drainDelay+a.shutdownTimeout)
+		go stopWorkers(ctx)
+		select {
+		case <-time.After(a.drainDelay):
+		case <-quit:
+		}
 	}
+	defer cancel()
 
-	// Close MongoDB connection
-	if err := mongoDB.Close(shutdownCtx); err != nil {
-		log.Printf("Error closing MongoDB connection: %v", err)
-	} else {
-		log.Println("MongoDB connection closed")
+	log.Println("Shutting down...")
+	stopWorkers(ctx)
+	stopServer(ctx)
+	// Burned and expired pastes are deleted in the background after the response
+	if err := a.pasteService.WaitDeletions(ctx); err != nil {
+		log.Printf("Background deletions still running at shutdown: %v", err)
 	}
+	stopTelemetry()
+	a.close()
 
 	log.Println("Server exited gracefully")
 }
@@ -204,24 +157,178 @@ func printHelp() {
 	fmt.Print(`Gisty - Fast snippet sharing platform
 
 Usage:
-  gisty [flags]
+  gisty [command] [flags]
+
+Commands:
+  serve      Run the HTTP API server only
+  worker     Run the background workers only (KGS replenish, cleanup)
+  all        Run the HTTP API server and the background workers (default)
 
 Flags:
-  --help    Show this help message
+  --help     Show this help message
+  --version  Print version information and exit
+
+Signals:
+  SIGINT, SIGTERM  Shut down, letting in-flight requests, worker runs and deletions finish
+  SIGUSR1          Drain: fail /readyz and stop the workers, then shut down after DRAIN_DELAY
 
 Environment Variables:
   PORT                 Server port (default: 8080)
   ENV                  Environment (development/production)
+  BASE_URL             Public base URL of the API in production
+  SHORT_URL_BASE       Base URL of share links on a separate short domain, e.g. https://g.ex.am
+  REGION               Region of this instance, sent in X-Gisty-Region
+  REGIONS              Regions listed by /regions as name=url pairs (e.g. eu-west-1=https://eu.gisty.co)
+  HTML_VIEW            Serve browsers a highlighted view on GET /:id, false redirects to the frontend (default: true)
+  EXPIRED_REDIRECT_URL  Page browsers are sent to for expired or burned pastes, with {id} and {reason} placeholders (disabled if empty)
+  DEFAULT_LANGUAGE     Fallback language of error messages: en, vi (default: en)
+  REQUEST_TIMEOUT      Time budget per request, 0 disables (default: 10s)
+  READINESS_TIMEOUT    Time each of MongoDB, Redis and S3 has to answer /readyz (default: 2s)
+  SLOW_REQUEST_THRESHOLD  Log requests slower than this with a phase breakdown (default: 1s)
+  SHUTDOWN_TIMEOUT     Time requests, worker runs and deletions in flight have to finish on shutdown (default: 30s)
+  DRAIN_DELAY          Time a draining instance keeps serving with /readyz failing before it exits (default: 10s)
   MONGO_URI            MongoDB connection string
   REDIS_URI            Redis connection string
+  CACHE_TTL            Time pastes stay in the content cache (default: 1h)
+  CACHE_HOT_TTL        Cache TTL of pastes read CACHE_HOT_VIEWS times, 0 disables (default: 6h)
+  CACHE_HOT_VIEWS      Views from which a paste is hot (default: 100)
+  CACHE_PERMANENT_TTL  Cache TTL of pastes that never expire, 0 disables (default: 24h)
+  CACHE_SMALL_TTL      Cache TTL of pastes of at most CACHE_SMALL_SIZE bytes, 0 disables (default: 24h)
+  CACHE_SMALL_SIZE     Size in bytes up to which a paste is small (default: 4096)
+  CACHE_MAX_SIZE       Size in bytes above which content is never cached, 0 disables (default: 262144)
+  CACHE_ADMIT_VIEWS    Reads before content above CACHE_SMALL_SIZE is cached, 0 or 1 disables (default: 2)
+  CACHE_ADMIT_WINDOW   Time within which those reads are counted (default: 1h)
+  CACHE_LOCAL_SIZE     Bytes of content cached in memory in front of Redis, 0 disables (default: 0)
+  CACHE_LOCAL_TTL      Time content stays in the in-memory cache (default: 2s)
   S3_BUCKET_NAME       S3 bucket name
   S3_REGION            S3 region
   S3_ACCESS_KEY_ID     S3 access key
   S3_SECRET_ACCESS_KEY S3 secret key
   S3_ENDPOINT          S3 endpoint URL
+  S3_EVENTS_TOKEN      Bearer token of S3 lifecycle event notifications (empty disables them)
+  S3_COMPRESSION       Codec new content is stored with: gzip or zstd, both read back (default: gzip)
+  S3_DOWNLOAD_URL_MIN_SIZE  Stored size in bytes from which delivery=url reads return a pre-signed URL, 0 disables (default: 0)
+  S3_DOWNLOAD_URL_EXPIRY    Lifetime of pre-signed download URLs (default: 5m)
   CLEANUP_INTERVAL     Cleanup worker interval (default: 5m)
   CLEANUP_BATCH_SIZE   Cleanup batch size (default: 100)
+  CLEANUP_DRY_RUN      Log orphaned objects without deleting them (default: false)
+  CLEANUP_MIN_AGE      Age below which stored objects are never orphaned (default: 1h)
+  CLEANUP_MAX_ATTEMPTS Failed deletes after which an orphaned object is abandoned with an alert (default: 5)
+  CLEANUP_SCAN_LIMIT   Stored objects checked per run, each run resuming where the last stopped (default: 10000)
+  CLEANUP_STALE_DAYS   Days without a read after which never-expiring pastes are stale (default: 180)
+  CLEANUP_STALE_REPORT_INTERVAL  Interval between stale paste reports, 0 disables (default: 24h)
+  CLEANUP_VERIFY_INTERVAL  Interval between checks of a sample of stored pastes for corruption, 0 disables (default: 1h)
+  CLEANUP_VERIFY_SAMPLE    Pastes re-downloaded and checked per run (default: 20)
+  CLEANUP_VERIFY_QUARANTINE  Stop serving pastes found corrupt until released by an admin (default: true)
   RATE_LIMIT_REQUESTS_PER_MINUTE  Rate limit per IP (default: 5)
   RATE_LIMIT_ENABLED   Enable rate limiting (default: true)
+  RATE_LIMIT_ALGORITHM fixed_window, sliding_window or token_bucket (default: fixed_window)
+  RATE_LIMIT_BURST     Token bucket size (default: RATE_LIMIT_REQUESTS_PER_MINUTE)
+  RATE_LIMIT_READ_ENABLED  Rate limit paste reads (default: true)
+  RATE_LIMIT_READ_REQUESTS_PER_MINUTE  Paste reads per IP (default: 300)
+  RATE_LIMIT_PASTE_READS_PER_MINUTE  Reads of a single paste per IP, 0 disables (default: 60)
+  RATE_LIMIT_DELETE_REQUESTS_PER_MINUTE  Paste deletes per IP (default: 30)
+  RATE_LIMIT_API_KEY_MULTIPLIER  Quota of API key clients as a multiple of the per-IP limits (default: 10)
+  API_KEYS             Comma-separated API keys accepted in X-API-Key (API keys disabled if empty)
+  AUTH_SESSION_SECRET  Secret signing session tokens of signed-in users, at least 32 characters (accounts disabled if empty)
+  AUTH_SESSION_TTL     Lifetime of session tokens (default: 720h)
+  AUTH_REDIRECT_URL    Page browsers are sent to after signing in (default: answer with the token as JSON)
+  AUTH_GITHUB_CLIENT_ID      GitHub OAuth app signing users in (callback: <BASE_URL>/auth/github/callback)
+  AUTH_GITHUB_CLIENT_SECRET  GitHub OAuth app secret
+  AUTH_GOOGLE_CLIENT_ID      Google OAuth client signing users in (callback: <BASE_URL>/auth/google/callback)
+  AUTH_GOOGLE_CLIENT_SECRET  Google OAuth client secret
+  AUTH_PASSWORD_LOGIN  Let users register and sign in with an email and a password, needs MAIL_SMTP_ADDR (default: false)
+  MAIL_SMTP_ADDR       host:port of the SMTP server email is sent through (email disabled if empty)
+  MAIL_SMTP_USERNAME   User authenticating with the SMTP server (default: no authentication)
+  MAIL_SMTP_PASSWORD   Password of the SMTP user
+  MAIL_FROM            Sender of the emails, e.g. "Gisty <noreply@example.com>"
+  MAIL_DIGEST_INTERVAL Time between emails of unread notifications to the users who turned digests on, 0 disables (default: 24h)
+  LOAD_SHED_ENABLED    Limit in-flight requests (default: true)
+  LOAD_SHED_READ_MAX_IN_FLIGHT   Concurrent read requests (default: 256)
+  LOAD_SHED_WRITE_MAX_IN_FLIGHT  Concurrent write requests (default: 64)
+  LOAD_SHED_QUEUE_SIZE Requests waiting for a slot per route class (default: 128)
+  LOAD_SHED_QUEUE_TIMEOUT  Max wait for a slot before 503 (default: 2s)
+  IP_DENY_ENABLED      Reject denied client IPs with 403, ahead of rate limiting (default: true)
+  IP_DENY_LIST         Comma-separated IPs and CIDRs always denied (e.g. 198.51.100.0/24,203.0.113.7)
+  IP_ALLOW_LIST        Comma-separated IPs and CIDRs never denied, e.g. monitoring probes
+  IP_DENY_FILE         File of one IP or CIDR per line, "#" starting a comment, reloaded without restart
+  IP_DENY_RELOAD_INTERVAL  How often the file and the blocks added with the admin API are reloaded (default: 30s)
+  HOTLINK_PROTECTION_ENABLED  Redirect third-party referrers of raw content to the paste view (default: false)
+  HOTLINK_ALLOWED_REFERRERS   Comma-separated hosts allowed to embed raw content (e.g. example.com,*.example.org)
+  TRENDING_ENABLED     Count views and serve /api/v1/trending (default: false)
+  TRENDING_HALF_LIFE   Time after which a view counts half as much (default: 6h)
+  CONTENT_MAX_LINE_LENGTH  Max bytes in a single line (default: 16384)
+  CONTENT_POLICY       Long lines and NUL bytes: reject, binary or truncate (default: truncate)
+  CONTENT_MAX_RESPONSE_BYTES  Default content cap of JSON reads, overridden by ?max_bytes= (default: 0, full content)
+  CONTENT_LINTERS      Linters annotating pastes: gofmt, jsonlint, yamllint (default: all; empty disables)
+  CONTENT_EXPIRATION_POLICIES  Max lifetime of new pastes by syntax type (default: dotenv=24h,ini=24h; empty disables)
+  UPLOAD_MAX_SIZE      Max size in bytes of direct uploads (default: 52428800)
+  UPLOAD_URL_EXPIRY    Lifetime of pre-signed upload URLs (default: 15m)
+  UPLOAD_MULTIPART_MAX_SIZE  Max size in bytes of resumable uploads (default: 536870912)
+  UPLOAD_PART_SIZE     Size in bytes of resumable upload parts (default: 8388608)
+  UPLOAD_SESSION_TTL   Time before unfinished resumable uploads expire (default: 24h)
+  EVENT_BUS_DRIVER     Paste event bus driver: memory, nats (default: memory)
+  EVENT_BUS_QUEUE_SIZE  Pending events kept per subscriber (default: 1024)
+  EVENT_BUS_NATS_URL   NATS server of the nats driver (default: nats://localhost:4222)
+  EVENT_BUS_SUBJECT_PREFIX  Prefix of NATS subjects, e.g., gisty.paste.created (default: gisty)
+  EVENT_BUS_WEBHOOK_URL  Endpoint receiving paste events as JSON POSTs (disabled if empty)
+  CHANGE_STREAM_ENABLED  Publish paste events from the MongoDB change stream in worker mode (default: false)
+  CHANGE_STREAM_SINK   Destination of paste events: log, webhook (default: log)
+  CHANGE_STREAM_WEBHOOK_URL  Endpoint receiving paste events as JSON POSTs
+  TRACING_ENABLED      Export OpenTelemetry spans with OTLP over HTTP (default: false)
+  TRACING_SAMPLE_RATIO Fraction of new traces recorded (default: 1.0)
+  SLO_ENABLED          Track error budget burn rates of the routes with objectives (default: true)
+  SLO_OBJECTIVES       Routes with availability and latency targets, e.g. GET /api/v1/pastes/:id=99.9%,500ms@99%;POST /api/v1/pastes=99.5%
+  OTEL_SERVICE_NAME    Service name of the spans (default: gisty)
+  OTEL_EXPORTER_OTLP_ENDPOINT  OTLP collector, e.g. http://otel-collector:4318 (default: http://localhost:4318)
+  TELEMETRY_ENABLED    Send anonymous aggregate usage statistics to TELEMETRY_ENDPOINT (default: false)
+  TELEMETRY_ENDPOINT   Endpoint receiving the usage reports as JSON POSTs
+  TELEMETRY_INTERVAL   Time between usage reports (default: 24h)
+  RUNNER_ENDPOINT      Sandbox service pastes are sent to by POST /api/v1/pastes/{id}/run (disabled if empty)
+  RUNNER_TOKEN         Bearer token sent to the sandbox
+  RUNNER_LANGUAGES     Syntax types allowed to run (default: python,javascript,go,bash,ruby)
+  RUNNER_TIMEOUT       Time limit of a program in the sandbox (default: 5s)
+  GIST_ENABLED         Import pastes from GitHub gists and export them as gists (default: true)
+  GIST_API_URL         GitHub REST API URL, for GitHub Enterprise (default: https://api.github.com)
+  GIST_TOKEN           GitHub token authenticating gist imports, for the higher rate limit
+  URL_IMPORT_ENABLED   Create pastes from URLs fetched by the server with POST /api/v1/pastes/from-url (default: false)
+  URL_IMPORT_TIMEOUT   Time limit of a URL fetch, redirects included (default: 10s)
+  URL_IMPORT_CONTENT_TYPES  Media types fetched, text/* matching any text type (default: text/* and common source types)
+  URL_IMPORT_DENY_LIST  IPs, CIDRs and host names never fetched from, besides non-public addresses
+  SCREENING_ENABLED    Screen new pastes for spam and abuse (default: false)
+  SCREENING_STRICTNESS  How suspicious a paste must be to be acted on: low, medium or high (default: medium)
+  SCREENING_MAX_ACTION  Harshest action on suspicious pastes: flag, quarantine or reject (default: reject)
+  SCREENING_TIMEOUT    Time limit of the screening of a paste, scanner included (default: 2s)
+  SCREENING_KEYWORDS   Keywords found suspicious, case-insensitively
+  SCREENING_DENY_DOMAINS  Domains whose links, subdomains included, are found suspicious
+  SCREENING_CREDENTIAL_LINES  email:password lines making a credential dump, 0 disables (default: 10)
+  SCREENING_SECRET_TOKENS  Distinct keys, tokens and passwords making a secrets dump, 0 disables (default: 10)
+  SCREENING_SCANNER_URL  External scanner new pastes are POSTed to
+  SCREENING_SCANNER_TOKEN  Bearer token sent to the scanner
+  ADMIN_TOKEN          Token for /api/v1/admin routes (admin API disabled if empty)
+  ADMIN_IP_HASH_KEY    Secret keying the creator IP hashes kept for incident review (not recorded if empty)
+  STARTUP_RETRY_TIMEOUT  Time to keep retrying MongoDB, Redis and S3 at boot, 0 fails at once (default: 2m)
+  STARTUP_RETRY_BACKOFF  Delay before the first retry, doubled after each (default: 1s)
+  STARTUP_RETRY_MAX_BACKOFF  Longest delay between retries (default: 30s)
+  STARTUP_DEGRADED     Serve health probes, /readyz failing, while connecting at boot (default: false)
+  STARTUP_PREWARM_KEYS  Keys generated before serving when the key pool is empty, 0 disables (default: 1000)
+  KGS_LEASE_BLOCK_SIZE Keys a replica leases at a time to take new IDs from, 0 disables (default: 0)
+  KGS_LEASE_TTL        Time a replica holds its leased keys, unused ones are then reclaimed (default: 10m)
+  KGS_BUFFER_SIZE      Keys a replica claims ahead of use and keeps in memory, 0 disables (default: 0)
+  KGS_KEY_CASING       Casing of generated IDs: mixed (base62) or lower (base36, safe to retype in any case) (default: mixed)
+  KGS_UNAMBIGUOUS_KEYS Leave characters that look alike (0/O/o, 1/l/I) out of generated IDs (default: false)
+  KGS_CASE_INSENSITIVE_IDS  Redirect IDs retyped in another case to the paste matching them, when a single one does (default: false)
+  KGS_CRITICAL_FLOOR   Unused keys below which an alert is logged and creates get 503 with Retry-After, 0 disables (default: 0)
+  KGS_EXHAUSTED_RETRY_AFTER  Retry-After hint while the unused keys are below the critical floor (default: 1m)
+  WATCHDOG_ENABLED     Probe MongoDB, Redis and S3 continuously, skipping the cache and rejecting writes with 503 while they are unhealthy (default: false)
+  WATCHDOG_INTERVAL    Time between dependency probes (default: 5s)
+  WATCHDOG_TIMEOUT     Time a dependency has to answer a probe (default: 2s)
+  WATCHDOG_FAILURE_THRESHOLD Failed probes in a row before a dependency is unhealthy (default: 3)
+  JOURNAL_PATH         Local file creates are journaled to before being acknowledged, replayed on startup (disabled if empty)
+  JOURNAL_INLINE_MAX_SIZE  Max bytes of content journaled inline, larger content by reference (default: 65536)
+  CDN_PROVIDER         CDN purged of rendered views on edits and deletions: fastly or cloudflare (disabled if empty)
+  CDN_PURGE_URL        Purge API endpoint, e.g. https://api.fastly.com/service/<id>/purge
+  CDN_PURGE_TOKEN      API token authenticating purges
+  CDN_CACHE_TTL        Time the CDN caches rendered views, which it must key on Accept (default: 1h)
 `)
 }
\ No newline at end of file
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/auth"
+	"github.com/huylvt/gisty/internal/handler"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+)
+
+// runServer starts the HTTP API server and returns a function that shuts it down
+func runServer(a *app) func(ctx context.Context) {
+	cfg := a.cfg
+
+	// Error messages follow Accept-Language, falling back to the configured language
+	if err := i18n.SetDefault(cfg.Server.Language); err != nil {
+		log.Printf("Invalid default language '%s', using %s: %v", cfg.Server.Language, i18n.DefaultLanguage, err)
+	}
+
+	// Clients authenticated with an API key get a multiple of the per-IP limits
+	var apiKeyAuth gin.HandlerFunc
+	apiKeyMultiplier := 0
+	if cfg.Auth.APIKeys != "" {
+		apiKeyAuth = middleware.APIKeyMiddleware(strings.Split(cfg.Auth.APIKeys, ","))
+		apiKeyMultiplier = max(cfg.RateLimit.APIKeyMultiplier, 1)
+		log.Printf("API keys enabled (quota multiplier: %d)", apiKeyMultiplier)
+	}
+
+	// Initialize rate limiters for creates and deletes
+	rateLimiter := middleware.NewRateLimiter(&middleware.RateLimitConfig{
+		RequestsPerMinute:       cfg.RateLimit.RequestsPerMinute,
+		Enabled:                 cfg.RateLimit.Enabled,
+		Algorithm:               cfg.RateLimit.Algorithm,
+		Burst:                   cfg.RateLimit.Burst,
+		APIKeyRequestsPerMinute: cfg.RateLimit.RequestsPerMinute * apiKeyMultiplier,
+	})
+	deleteRateLimiter := middleware.NewRateLimiter(&middleware.RateLimitConfig{
+		RequestsPerMinute:       cfg.RateLimit.DeleteRequestsPerMinute,
+		Enabled:                 cfg.RateLimit.Enabled,
+		Algorithm:               cfg.RateLimit.Algorithm,
+		Name:                    "delete",
+		APIKeyRequestsPerMinute: cfg.RateLimit.DeleteRequestsPerMinute * apiKeyMultiplier,
+	})
+	if cfg.RateLimit.Enabled {
+		log.Printf("Rate limiting enabled: %d creates/minute, %d deletes/minute (%s)",
+			cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.DeleteRequestsPerMinute, rateLimiter.GetConfig().Algorithm)
+	}
+
+	// Reads get a much higher limit, plus a per-paste limit against hotlinking and scraping
+	var readRateLimiter, pasteReadLimiter *middleware.RateLimiter
+	if cfg.RateLimit.ReadEnabled {
+		readRateLimiter = middleware.NewRateLimiter(&middleware.RateLimitConfig{
+			RequestsPerMinute:       cfg.RateLimit.ReadRequestsPerMinute,
+			Enabled:                 true,
+			Algorithm:               cfg.RateLimit.Algorithm,
+			Name:                    "read",
+			APIKeyRequestsPerMinute: cfg.RateLimit.ReadRequestsPerMinute * apiKeyMultiplier,
+		})
+		if cfg.RateLimit.PasteReadsPerMinute > 0 {
+			pasteReadLimiter = middleware.NewRateLimiter(&middleware.RateLimitConfig{
+				RequestsPerMinute: cfg.RateLimit.PasteReadsPerMinute,
+				Enabled:           true,
+				Algorithm:         cfg.RateLimit.Algorithm,
+				Name:              "paste_read",
+				KeyFunc:           middleware.PasteClientKey,
+			})
+		}
+		log.Printf("Read rate limiting enabled: %d requests/minute, %d reads/minute per paste",
+			cfg.RateLimit.ReadRequestsPerMinute, cfg.RateLimit.PasteReadsPerMinute)
+	}
+
+	// Bound in-flight requests so overload sheds excess requests instead of timing out
+	var readShedder, writeShedder *middleware.ConcurrencyLimiter
+	if cfg.LoadShed.Enabled {
+		queueTimeout, err := time.ParseDuration(cfg.LoadShed.QueueTimeout)
+		if err != nil {
+			log.Printf("Invalid load shed queue timeout '%s', using default 2s", cfg.LoadShed.QueueTimeout)
+			queueTimeout = middleware.DefaultQueueTimeout
+		}
+		readShedder = middleware.NewConcurrencyLimiter(&middleware.ConcurrencyLimitConfig{
+			Class:        middleware.RouteClassRead,
+			MaxInFlight:  cfg.LoadShed.ReadMaxInFlight,
+			QueueSize:    cfg.LoadShed.QueueSize,
+			QueueTimeout: queueTimeout,
+		})
+		writeShedder = middleware.NewConcurrencyLimiter(&middleware.ConcurrencyLimitConfig{
+			Class:        middleware.RouteClassWrite,
+			MaxInFlight:  cfg.LoadShed.WriteMaxInFlight,
+			QueueSize:    cfg.LoadShed.QueueSize,
+			QueueTimeout: queueTimeout,
+		})
+		log.Printf("Load shedding enabled: %d read / %d write requests in flight",
+			readShedder.GetConfig().MaxInFlight, writeShedder.GetConfig().MaxInFlight)
+	}
+
+	// Per-request time budget and slow request logging
+	requestTimeout, err := time.ParseDuration(cfg.Server.RequestTimeout)
+	if err != nil {
+		log.Printf("Invalid request timeout '%s', using default 10s", cfg.Server.RequestTimeout)
+		requestTimeout = 10 * time.Second
+	}
+	slowThreshold, err := time.ParseDuration(cfg.Server.SlowRequestThreshold)
+	if err != nil {
+		log.Printf("Invalid slow request threshold '%s', using default 1s", cfg.Server.SlowRequestThreshold)
+		slowThreshold = middleware.DefaultSlowRequestThreshold
+	}
+	requestTimer := middleware.NewRequestTimer(&middleware.RequestTimingConfig{
+		Timeout:       requestTimeout,
+		SlowThreshold: slowThreshold,
+	})
+
+	// Service level objectives of the main routes
+	var sloTracker *middleware.SLOTracker
+	if cfg.SLO.Enabled {
+		objectives, err := middleware.ParseSLOObjectives(cfg.SLO.Objectives)
+		if err != nil {
+			log.Printf("Invalid SLO objectives '%s', using defaults: %v", cfg.SLO.Objectives, err)
+			objectives, _ = middleware.ParseSLOObjectives(middleware.DefaultSLOObjectives)
+		}
+		sloTracker = middleware.NewSLOTracker(objectives)
+		log.Printf("SLO tracking enabled for %d routes", len(objectives))
+	}
+
+	// Referrer policy for raw content
+	// With a separate short-link domain, raw content is served away from the frontend:
+	// redirects must point back at the main host, and pages there may embed raw links
+	shortHost := ""
+	viewBaseURL := ""
+	if a.shortURLBase != a.baseURL {
+		u, err := url.Parse(a.shortURLBase)
+		if err != nil || u.Hostname() == "" {
+			log.Printf("Invalid short URL base '%s', serving share links from the API host", a.shortURLBase)
+		} else {
+			shortHost = u.Hostname()
+			viewBaseURL = a.baseURL
+		}
+	}
+
+	var hotlinkProtection gin.HandlerFunc
+	if cfg.Hotlink.Enabled {
+		allowed := strings.Split(cfg.Hotlink.AllowedReferrers, ",")
+		if u, err := url.Parse(viewBaseURL); err == nil && u.Hostname() != "" {
+			allowed = append(allowed, u.Hostname())
+		}
+		hotlinkProtection = middleware.HotlinkMiddleware(&middleware.HotlinkConfig{
+			AllowedReferrers: allowed,
+			ViewBaseURL:      viewBaseURL,
+		})
+		log.Printf("Hotlink protection enabled (allowed referrers: %q)", cfg.Hotlink.AllowedReferrers)
+	}
+
+	// Initialize handlers
+	pasteHandler := handler.NewPasteHandler(a.pasteService)
+	pasteHandler.SetViewBaseURL(viewBaseURL)
+	pasteHandler.SetHTMLView(cfg.Server.HTMLView)
+	pasteHandler.SetDefaultMaxBytes(cfg.Content.MaxResponseBytes)
+	pasteHandler.SetAdminToken(cfg.Admin.Token)
+	if a.cdnPurger != nil {
+		// Without purges, views of edited pastes would stay cached
+		pasteHandler.SetEdgeCacheTTL(parseDuration("CDN cache TTL", cfg.CDN.CacheTTL, time.Hour))
+	}
+	if cfg.Server.ExpiredRedirectURL != "" {
+		if u, err := url.Parse(cfg.Server.ExpiredRedirectURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			log.Printf("Invalid expired redirect URL '%s', answering expired pastes with an error", cfg.Server.ExpiredRedirectURL)
+		} else {
+			pasteHandler.SetExpiredRedirectURL(cfg.Server.ExpiredRedirectURL)
+			log.Printf("Redirecting browsers opening expired or burned pastes to %s", cfg.Server.ExpiredRedirectURL)
+		}
+	}
+	uploadHandler := handler.NewUploadHandler(a.uploadService)
+	adminHandler := handler.NewAdminHandler(a.cleanupWorker, a.pasteService, a.maintenanceService, a.cacheService, rateLimiter, a.bans)
+	adminHandler.SetStaleDays(cfg.Cleanup.StaleDays)
+	adminHandler.SetVerifyQuarantine(cfg.Cleanup.VerifyQuarantine)
+	adminHandler.SetDrain(a.drain)
+	adminHandler.SetCDNPurger(a.cdnPurger)
+	adminHandler.SetSLOTracker(sloTracker)
+	if a.ipDenyList != nil {
+		adminHandler.SetIPDenyList(a.ipDenyList)
+	}
+
+	// User accounts, signed in with the configured OAuth providers or a password
+	var userAuth gin.HandlerFunc
+	var authHandler *handler.AuthHandler
+	if cfg.Auth.SessionSecret != "" {
+		sessionTTL, err := time.ParseDuration(cfg.Auth.SessionTTL)
+		if err != nil {
+			log.Printf("Invalid session TTL '%s', using default 720h", cfg.Auth.SessionTTL)
+			sessionTTL = auth.DefaultSessionTTL
+		}
+		sessions, err := auth.NewSessions(cfg.Auth.SessionSecret, sessionTTL)
+		if err != nil {
+			log.Fatalf("Invalid session secret: %v", err)
+		}
+		authenticator := auth.NewAuthenticator(a.userRepo, sessions, a.baseURL)
+		if cfg.Auth.GitHubClientID != "" {
+			authenticator.AddProvider(auth.NewGitHubProvider(cfg.Auth.GitHubClientID, cfg.Auth.GitHubClientSecret))
+		}
+		if cfg.Auth.GoogleClientID != "" {
+			authenticator.AddProvider(auth.NewGoogleProvider(cfg.Auth.GoogleClientID, cfg.Auth.GoogleClientSecret))
+		}
+
+		if cfg.Auth.PasswordLogin {
+			// Passwords are not offered without emails verifying addresses and resetting them
+			if a.mailer == nil {
+				log.Printf("Password sign-in needs mail settings, password accounts disabled")
+			} else {
+				authenticator.EnablePasswords(a.redisClient.Client, a.mailer)
+			}
+		}
+
+		authenticator.EnableTOTP(a.redisClient.Client)
+		authenticator.EnableSessionStore(a.redisClient.Client)
+
+		providers := authenticator.Providers()
+		if authenticator.PasswordsEnabled() {
+			providers = append(providers, auth.PasswordProvider)
+		}
+		if len(providers) == 0 {
+			log.Printf("Session secret set without an OAuth provider or password sign-in, user accounts disabled")
+		} else {
+			userAuth = middleware.UserAuthMiddleware(authenticator)
+			authHandler = handler.NewAuthHandler(authenticator, a.pasteService, strings.HasPrefix(a.baseURL, "https://"))
+			authHandler.SetRedirectURL(cfg.Auth.RedirectURL)
+			log.Printf("User accounts enabled (providers: %s)", strings.Join(providers, ", "))
+		}
+	}
+
+	// Setup router with dependencies
+	deps := &handler.RouterDeps{
+		PasteHandler:      pasteHandler,
+		UploadHandler:     uploadHandler,
+		AdminHandler:      adminHandler,
+		RateLimiter:       rateLimiter,
+		DeleteRateLimiter: deleteRateLimiter,
+		APIKeyAuth:        apiKeyAuth,
+		UserAuth:          userAuth,
+		AuthHandler:       authHandler,
+		ReadRateLimiter:   readRateLimiter,
+		PasteReadLimiter:  pasteReadLimiter,
+		ReadShedder:       readShedder,
+		WriteShedder:      writeShedder,
+		Maintenance:       a.maintenanceService,
+		Bans:              a.bans,
+		S3Client:          a.s3Client,
+		MongoDB:           a.mongoDB,
+		Redis:             a.redisClient,
+		Drain:             a.drain,
+		RequestTimer:      requestTimer,
+		SLOTracker:        sloTracker,
+		HotlinkProtection: hotlinkProtection,
+		Tracing:           middleware.TracingMiddleware(cfg.Tracing.ServiceName),
+	}
+	// A nil deny list must not become a non-nil IPBlocker
+	if a.ipDenyList != nil {
+		deps.IPDenyList = a.ipDenyList
+	}
+	var router http.Handler = handler.NewRouter(cfg, deps)
+	if shortHost != "" {
+		router = handler.NewHostRouter(shortHost, handler.NewShortLinkRouter(cfg, deps), router)
+		log.Printf("Serving share links on short domain %s", shortHost)
+	}
+
+	return startHTTPServer(cfg.Server.Port, router)
+}
+
+// startHTTPServer serves the handler on the given port and returns a function that shuts it down,
+// letting outstanding requests complete until ctx is done
+func startHTTPServer(port string, h http.Handler) func(ctx context.Context) {
+	srv := &http.Server{
+		Addr:         ":" + port,
+		Handler:      h,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	return func(ctx context.Context) {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Server forced to shutdown: %v", err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/huylvt/gisty/internal/config"
+	"github.com/huylvt/gisty/internal/handler"
+)
+
+// errNotConnected is reported by the startup readiness probe for dependencies not tried yet
+var errNotConnected = errors.New("not connected yet")
+
+// startup connects to the dependencies at boot, retrying each with exponential backoff so that
+// the server waits for dependencies that start slower instead of crash-looping
+type startup struct {
+	timeout    time.Duration // 0 fails on the first error
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	mu   sync.Mutex
+	errs map[string]error // last error per dependency, nil once connected
+}
+
+// newStartup creates a startup from the configured retry policy
+func newStartup(cfg *config.StartupConfig) *startup {
+	return &startup{
+		timeout:    parseDuration("startup retry timeout", cfg.RetryTimeout, 2*time.Minute),
+		backoff:    parseDuration("startup retry backoff", cfg.RetryBackoff, time.Second),
+		maxBackoff: parseDuration("startup retry max backoff", cfg.RetryMaxBackoff, 30*time.Second),
+		errs:       make(map[string]error),
+	}
+}
+
+// connect calls fn until it succeeds, waiting twice as long after each failure, and exits the
+// process once the retry timeout has passed
+func (s *startup) connect(name string, fn func(ctx context.Context) error) {
+	s.setErr(name, errNotConnected)
+	deadline := time.Now().Add(s.timeout)
+	backoff := s.backoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn(context.Background())
+		s.setErr(name, err)
+		if err == nil {
+			return
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			log.Fatalf("Failed to connect to %s after %d attempts: %v", name, attempt, err)
+		}
+
+		log.Printf("Failed to connect to %s (attempt %d), retrying in %v: %v", name, attempt, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, s.maxBackoff)
+	}
+}
+
+// setErr records the result of the last attempt to connect to a dependency
+func (s *startup) setErr(name string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs[name] = err
+}
+
+// check returns a readiness check reporting whether the dependency is connected
+func (s *startup) check(name string) handler.DependencyCheck {
+	return func(context.Context) error {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if err, ok := s.errs[name]; !ok || err != nil {
+			return errNotConnected
+		}
+		return nil
+	}
+}
+
+// serveDegraded serves the health probes while the dependencies are connected, /readyz reporting
+// each of them, and returns a function that stops serving
+func (s *startup) serveDegraded(cfg *config.Config) func() {
+	health := handler.NewHealthHandler(nil)
+	for _, name := range []string{"mongodb", "redis", "s3"} {
+		health.AddDependency(name, s.check(name))
+	}
+	log.Println("Starting degraded: serving health probes until dependencies are connected")
+	stop := startHTTPServer(cfg.Server.Port, handler.NewStartupRouter(cfg, health))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stop(ctx)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/config"
+	"github.com/huylvt/gisty/internal/service"
+	"github.com/huylvt/gisty/internal/telemetry"
+)
+
+// startTelemetry subscribes the usage reporter to paste events and starts reporting when
+// telemetry is enabled. It returns a function that stops reporting.
+func startTelemetry(a *app, mode string) func() {
+	cfg := a.cfg.Telemetry
+	if !cfg.Enabled {
+		return func() {}
+	}
+	if cfg.Endpoint == "" {
+		log.Println("Telemetry enabled without TELEMETRY_ENDPOINT, not reporting")
+		return func() {}
+	}
+
+	interval, err := time.ParseDuration(cfg.Interval)
+	if err != nil {
+		log.Printf("Invalid telemetry interval '%s', using default %v", cfg.Interval, telemetry.DefaultInterval)
+		interval = telemetry.DefaultInterval
+	}
+	reporter, err := telemetry.NewReporter(a.pasteRepo, &telemetry.Config{
+		Endpoint:   cfg.Endpoint,
+		Interval:   interval,
+		InstanceID: telemetry.LoadInstanceID(context.Background(), a.redisClient.Client),
+		Mode:       mode,
+		Features:   enabledFeatures(a.cfg),
+	})
+	if err != nil {
+		log.Printf("Failed to initialize telemetry: %v", err)
+		return func() {}
+	}
+	a.eventBus.Subscribe("telemetry", reporter.Record)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go reporter.Start(ctx)
+	return cancel
+}
+
+// enabledFeatures names the optional features configured on this instance
+func enabledFeatures(cfg *config.Config) []string {
+	var features []string
+	for name, enabled := range map[string]bool{
+		"short_url_base":      cfg.Server.ShortURLBase != "",
+		"regions":             cfg.Server.Regions != "",
+		"html_view":           cfg.Server.HTMLView,
+		"rate_limit":          cfg.RateLimit.Enabled,
+		"load_shed":           cfg.LoadShed.Enabled,
+		"ip_deny_list":        cfg.IPDeny.Enabled,
+		"api_keys":            cfg.Auth.APIKeys != "",
+		"user_accounts":       cfg.Auth.SessionSecret != "",
+		"password_login":      cfg.Auth.PasswordLogin,
+		"admin_api":           cfg.Admin.Token != "",
+		"hotlink_protection":  cfg.Hotlink.Enabled,
+		"trending":            cfg.Trending.Enabled,
+		"linters":             cfg.Content.Linters != "",
+		"expiration_policies": cfg.Content.ExpirationPolicies != "",
+		"event_webhook":       cfg.Events.WebhookURL != "",
+		"event_bus_nats":      cfg.Events.Driver == "nats",
+		"change_stream":       cfg.ChangeStream.Enabled,
+		"tracing":             cfg.Tracing.Enabled,
+		"slo":                 cfg.SLO.Enabled,
+		"runner":              cfg.Runner.Endpoint != "",
+		"gist":                cfg.Gist.Enabled,
+		"url_import":          cfg.URLImport.Enabled,
+		"screening":           cfg.Screening.Enabled,
+		"reports":             cfg.Admin.Token != "",
+		"storage_events":      cfg.S3.EventsToken != "",
+		"local_cache":         cfg.Cache.LocalSize > 0,
+		"key_leasing":         cfg.KGS.LeaseBlockSize > 0,
+		"key_buffer":          cfg.KGS.BufferSize > 0,
+		"lowercase_ids":       cfg.KGS.KeyCasing == service.KeyCasingLower,
+		"unambiguous_ids":     cfg.KGS.UnambiguousKeys,
+		"id_case_folding":     cfg.KGS.CaseInsensitiveIDs,
+		"key_critical_floor":  cfg.KGS.CriticalFloor > 0,
+		"watchdog":            cfg.Watchdog.Enabled,
+		"stale_report":        cfg.Cleanup.StaleReportInterval != "0",
+		"storage_verify":      cfg.Cleanup.VerifyInterval != "0",
+		"journal":             cfg.Journal.Path != "",
+		"download_urls":       cfg.S3.DownloadURLMinSize > 0,
+		"cdn_purge":           cfg.CDN.Provider != "",
+		"email":               cfg.Mail.SMTPAddr != "",
+	} {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+	return features
+}
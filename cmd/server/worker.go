@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/huylvt/gisty/internal/handler"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// startWorkers starts the background workers and returns a function that stops them. Workers stop
+// taking new work at once and finish the runs in flight, which are aborted when ctx is done first.
+func startWorkers(a *app) func(ctx context.Context) {
+	// Start KGS background worker with cancellable context
+	kgsCtx, kgsCancel := context.WithCancel(context.Background())
+	go a.kgs.StartReplenishWorker(kgsCtx, service.DefaultWorkerConfig())
+
+	// Start cleanup worker
+	cleanupCtx, cleanupCancel := context.WithCancel(context.Background())
+	go a.cleanupWorker.Start(cleanupCtx)
+	if a.staleReporter != nil {
+		go a.staleReporter.Start(cleanupCtx)
+	}
+	if a.storageVerifier != nil {
+		go a.storageVerifier.Start(cleanupCtx)
+	}
+	if a.digestSender != nil {
+		go a.digestSender.Start(cleanupCtx)
+	}
+
+	// Start paste event publisher
+	publisherCtx, publisherCancel := context.WithCancel(context.Background())
+	publisherDone := make(chan struct{})
+	go func() {
+		defer close(publisherDone)
+		if a.changeStreamPublisher != nil {
+			a.changeStreamPublisher.Start(publisherCtx)
+		}
+	}()
+
+	var once sync.Once
+	return func(ctx context.Context) {
+		once.Do(func() {
+			// The publisher resumes from its saved position, so it can stop right away
+			publisherCancel()
+
+			// Stop the KGS and cleanup workers after their current run
+			stopped := make(chan struct{})
+			go func() {
+				a.kgs.StopReplenishWorker()
+				a.cleanupWorker.Stop()
+				if a.staleReporter != nil {
+					a.staleReporter.Stop()
+				}
+				if a.storageVerifier != nil {
+					a.storageVerifier.Stop()
+				}
+				if a.digestSender != nil {
+					a.digestSender.Stop()
+				}
+				<-publisherDone
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				log.Println("Workers stopped")
+			case <-ctx.Done():
+				log.Println("Workers did not finish in time, aborting their runs")
+			}
+			kgsCancel()
+			cleanupCancel()
+		})
+	}
+}
+
+// runWorkerMode starts the background workers together with a minimal HTTP listener
+// exposing health and metrics, and returns the functions that stop each
+func runWorkerMode(a *app) (stopWorkers, stopServer func(ctx context.Context)) {
+	stopWorkers = startWorkers(a)
+	stopServer = startHTTPServer(a.cfg.Server.Port, handler.NewWorkerRouter(a.cfg, a.mongoDB, a.redisClient, a.s3Client, a.drain))
+	return stopWorkers, stopServer
+}
@@ -24,59 +24,57 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/health": {
-            "get": {
-                "description": "Check if the service is running",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "health"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "200": {
-                        "description": "Service is healthy",
-                        "schema": {
-                            "$ref": "#/definitions/handler.HealthResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/pastes": {
+        "/": {
             "post": {
-                "description": "Create a new code/text snippet with optional expiration and syntax highlighting",
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Create a paste from the raw request body, of any content type, at the root of the server rather than under /api/v1,\nas in curl --data-binary @file. The paste expires as set by the X-Gisty-Expires header; the other options are at their defaults.",
                 "consumes": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "produces": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "tags": [
-                    "pastes"
+                    "raw"
                 ],
-                "summary": "Create a new paste",
+                "summary": "Create a paste from a raw body",
                 "parameters": [
                     {
-                        "description": "Paste content and options",
+                        "type": "string",
+                        "example": "3600",
+                        "description": "Expiration, in seconds or as expires_in",
+                        "name": "X-Gisty-Expires",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Content",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handler.CreatePasteRequest"
+                            "type": "string"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Paste created successfully",
+                        "description": "Share link of the paste, on one line; the delete token of anonymous pastes is in the X-Delete-Token header",
                         "schema": {
-                            "$ref": "#/definitions/handler.CreatePasteResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Invalid request (empty content, invalid syntax_type, invalid expires_in)",
+                        "description": "Empty content, invalid X-Gisty-Expires, line too long or NUL bytes when rejected by policy",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -94,7 +92,7 @@ const docTemplate = `{
                         }
                     },
                     "503": {
-                        "description": "Service temporarily unavailable",
+                        "description": "Service temporarily unavailable, or no short ID available",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -102,9 +100,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/pastes/{id}": {
+        "/admin/bans": {
             "get": {
-                "description": "Retrieve a paste's content and metadata by its short ID",
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the client IPs barred from creating, editing and deleting pastes, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List IP bans",
+                "responses": {
+                    "200": {
+                        "description": "Active bans",
+                        "schema": {
+                            "$ref": "#/definitions/handler.BanListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/bans/{ip}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Bar a client IP from creating, editing and deleting pastes; reads keep working.\nBanning an IP again replaces its ban.",
                 "consumes": [
                     "application/json"
                 ],
@@ -112,40 +158,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "pastes"
+                    "admin"
                 ],
-                "summary": "Get a paste by ID",
+                "summary": "Ban an IP",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "xK9a2B",
-                        "description": "Paste short ID",
-                        "name": "id",
+                        "example": "203.0.113.7",
+                        "description": "Client IP address",
+                        "name": "ip",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Ban reason and duration",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.BanRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Paste retrieved successfully",
+                        "description": "Ban",
                         "schema": {
-                            "$ref": "#/definitions/handler.GetPasteResponse"
+                            "$ref": "#/definitions/service.IPBan"
                         }
                     },
                     "400": {
-                        "description": "Missing paste ID",
+                        "description": "Invalid IP address, reason or duration",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Paste not found",
+                    "401": {
+                        "description": "Missing or invalid admin token",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "410": {
-                        "description": "Paste has expired",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -153,140 +207,9031 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete a paste by its short ID",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Allow a banned client IP to write again",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "pastes"
+                    "admin"
                 ],
-                "summary": "Delete a paste",
+                "summary": "Lift an IP ban",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "xK9a2B",
-                        "description": "Paste short ID",
-                        "name": "id",
+                        "example": "203.0.113.7",
+                        "description": "Client IP address",
+                        "name": "ip",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Paste deleted successfully"
+                    "200": {
+                        "description": "Whether a ban was lifted",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UnbanResponse"
+                        }
                     },
                     "400": {
-                        "description": "Missing paste ID",
+                        "description": "Invalid IP address",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Paste not found",
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handler.CreatePasteRequest": {
-            "type": "object",
-            "required": [
-                "content"
-            ],
-            "properties": {
-                "content": {
-                    "type": "string",
-                    "example": "console.log('Hello, World!')"
-                },
-                "expires_in": {
-                    "type": "string",
-                    "example": "1h"
-                },
-                "is_private": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "syntax_type": {
-                    "type": "string",
-                    "example": "javascript"
+        },
+        "/admin/cache": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove every cached paste from the cache namespace. Pastes are reloaded from storage on the next read.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Flush the content cache",
+                "responses": {
+                    "200": {
+                        "description": "Number of cache entries removed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CachePurgeResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handler.CreatePasteResponse": {
-            "type": "object",
-            "properties": {
-                "expires_at": {
-                    "type": "string",
-                    "example": "2024-01-15T15:00:00Z"
-                },
-                "short_id": {
-                    "type": "string",
-                    "example": "xK9a2B"
-                },
-                "url": {
-                    "type": "string",
-                    "example": "http://localhost:8080/xK9a2B"
+        "/admin/cache/stats": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report content cache hits, misses and bypasses counted by this instance since it started, and the hits served by its in-process cache",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "Cache statistics",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CacheStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handler.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "Paste not found"
-                },
-                "max_size": {
-                    "type": "string",
-                    "example": "1MB"
+        "/admin/cache/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the cached content of a single paste. The paste itself is kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Purge a cached paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Number of cache entries removed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CachePurgeResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
                 }
             }
         },
-        "handler.GetPasteResponse": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "type": "string",
-                    "example": "console.log('Hello, World!')"
-                },
-                "created_at": {
-                    "type": "string",
-                    "example": "2024-01-15T14:00:00Z"
-                },
-                "expires_at": {
-                    "type": "string",
-                    "example": "2024-01-15T15:00:00Z"
-                },
-                "short_id": {
-                    "type": "string",
+        "/admin/cdn": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the rendered views of all pastes from the CDN fronting the server, as after a change of the page template.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Purge every rendered view from the CDN",
+                "responses": {
+                    "200": {
+                        "description": "Surrogate keys purged",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CDNPurgeResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "CDN purge API failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cdn/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the rendered views of a paste from the CDN fronting the server, by surrogate key.\nEdits and deletions purge them automatically.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Purge a paste from the CDN",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Surrogate keys purged",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CDNPurgeResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "CDN purge API failed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cleanup": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report the last storage reconciliation run, the number of expired pastes awaiting removal by the MongoDB TTL monitor, and the orphaned objects whose delete failed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Cleanup worker status",
+                "responses": {
+                    "200": {
+                        "description": "Cleanup worker status",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CleanupStatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/denylist": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the IPs and CIDRs denied every request: those configured (config and file sources) first, then those blocked at runtime (redis source), newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List IP blocks",
+                "responses": {
+                    "200": {
+                        "description": "Blocks in force",
+                        "schema": {
+                            "$ref": "#/definitions/handler.IPBlockListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deny every request from an IP or a CIDR with 403, ahead of rate limiting, e.g., to stop an abusive crawler. Unlike a ban, reads are denied too.\nThe block is shared by all replicas through Redis: this one enforces it at once, the others within the reload interval. Blocking a CIDR again replaces its block.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Block an IP or CIDR",
+                "parameters": [
+                    {
+                        "description": "IP or CIDR, reason and duration",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.BlockIPRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Block",
+                        "schema": {
+                            "$ref": "#/definitions/service.IPBlock"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or too wide CIDR, reason or duration",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lift the block of an IP or CIDR added with the admin API. Configured blocks are lifted by changing IP_DENY_LIST or the deny list file.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Lift an IP block",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "198.51.100.0/24",
+                        "description": "Blocked IP or CIDR",
+                        "name": "cidr",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Whether a block was lifted",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UnblockIPResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IP or CIDR",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/drain": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report whether the instance serving the request is draining before it shuts down",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get drain state",
+                "responses": {
+                    "200": {
+                        "description": "Drain state",
+                        "schema": {
+                            "$ref": "#/definitions/handler.DrainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Drain the instance serving the request ahead of a rolling deploy, like SIGUSR1: /readyz starts failing, background workers stop taking new work and finish their runs, and after DRAIN_DELAY the server finishes in-flight requests and background deletions, then exits. Unlike maintenance mode this applies to one instance and cannot be undone. Draining an instance already draining has no effect.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Drain the instance",
+                "responses": {
+                    "202": {
+                        "description": "Instance draining",
+                        "schema": {
+                            "$ref": "#/definitions/handler.DrainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/lookup": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every readable paste whose content has the given SHA-256, newest first, whoever created it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Find pastes of a content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hex-encoded SHA-256 of the content",
+                        "name": "sha256",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of pastes (default 100, max 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching pastes",
+                        "schema": {
+                            "$ref": "#/definitions/service.LookupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sha256 or limit",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/maintenance": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report whether maintenance mode is active",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get maintenance mode",
+                "responses": {
+                    "200": {
+                        "description": "Maintenance mode state",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MaintenanceResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enable or disable maintenance mode. While enabled, writes return 503 with Retry-After and reads keep working.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Toggle maintenance mode",
+                "parameters": [
+                    {
+                        "description": "Maintenance mode settings",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.MaintenanceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Maintenance mode state",
+                        "schema": {
+                            "$ref": "#/definitions/handler.MaintenanceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pastes": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Enumerate the pastes created in [from, to), oldest first, for incident review.\nFilter by creator with ip (hashed server-side) or ip_hash, or by tag; format=csv exports the listing as CSV.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List pastes created in a time range",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "2024-01-15T00:00:00Z",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2024-01-16T00:00:00Z",
+                        "description": "End of the range (RFC 3339), defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "203.0.113.7",
+                        "description": "Creator IP address",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creator IP hash from an earlier listing",
+                        "name": "ip_hash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "go",
+                        "description": "Only pastes carrying this tag",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of pastes (default 100, max 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pastes created in the range",
+                        "schema": {
+                            "$ref": "#/definitions/service.ListPastesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time range, IP, limit or IP hashing disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/pastes/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete any paste whatever its state, including pending uploads and burned pastes, bypassing\nmaintenance mode and delete rate limits. The metadata of the deleted paste is returned for the moderation record.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Force-delete a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Deleted paste",
+                        "schema": {
+                            "$ref": "#/definitions/service.PasteSummary"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quarantine": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the pastes whose stored content failed verification, or that content screening quarantined, most recently quarantined first. They are not served until their content is restored from a backup or found sound and they are released, or they are deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List quarantined pastes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of pastes listed (default 100, max 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quarantined pastes",
+                        "schema": {
+                            "$ref": "#/definitions/service.QuarantineReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/quarantine/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Serve a quarantined paste again, once its content was restored or found sound. The paste is checked again when a later verification run samples it.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Release a quarantined paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Paste released"
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste not quarantined",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/ratelimit/{ip}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report the rate limit counters of a client IP without consuming a request.\nclient_key matches the client field of rate limiter log lines.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Inspect a client's rate limit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "203.0.113.7",
+                        "description": "Client IP address",
+                        "name": "ip",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rate limit counters",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RateLimitStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IP address",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Clear the rate limit counters of a client IP",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Reset a client's rate limit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "203.0.113.7",
+                        "description": "Client IP address",
+                        "name": "ip",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rate limit counters after the reset",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RateLimitStatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid IP address",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the abuse reports of pastes, newest first: the open ones awaiting review by default. Pass the next cursor of a page as before to get the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List abuse reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "open (default), resolved or all",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of reports (default 50, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next cursor of the previous page, or an RFC 3339 time to list the reports created before it",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reports",
+                        "schema": {
+                            "$ref": "#/definitions/service.ReportsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status, limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reports/{id}/resolve": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Resolve an open report: dismiss it and leave the paste as is, hide the paste or delete it.\nA hidden paste is quarantined: it answers 409 until released with DELETE /admin/quarantine/{id}. Hiding or deleting a paste resolves all its open reports.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Resolve an abuse report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Resolution",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ResolveReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report resolved",
+                        "schema": {
+                            "$ref": "#/definitions/service.ResolveReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown action, or note too long",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Report already resolved",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/screening": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the new pastes content screening flagged or quarantined as likely spam or abuse, most recent first, with the score and reasons of each.\nReview them, then delete them or dismiss their screening. Quarantined pastes are not served until dismissed or released.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List screened pastes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of pastes listed (default 100, max 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Screened pastes",
+                        "schema": {
+                            "$ref": "#/definitions/service.ScreeningReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/screening/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Clear the screening of a paste found legitimate on review. A paste quarantined by screening is served again.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Dismiss the screening of a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Screening dismissed"
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste not flagged or quarantined by screening",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/slo": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report how fast this instance spends the error budget of each service level objective configured with SLO_OBJECTIVES, over the last 5 minutes, 30 minutes, hour and 6 hours.\nAn objective is critical when its budget burns over 14.4 times too fast in the last hour and 5 minutes, and warning over 6 times in the last 6 hours and 30 minutes. The same burn rates are exported as the gisty_slo_burn_rate metric.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get SLO status",
+                "responses": {
+                    "200": {
+                        "description": "SLO status",
+                        "schema": {
+                            "$ref": "#/definitions/middleware.SLOReport"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/slo/rules": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Render Prometheus alerting rules for the configured objectives, ready to be saved as a rule file. They compute burn rates across every instance from the gisty_slo_requests_total and gisty_slo_errors_total counters, paging on a critical burn and opening a ticket on a warning.",
+                "produces": [
+                    "application/yaml"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Export SLO alert rules",
+                "responses": {
+                    "200": {
+                        "description": "Prometheus rule file",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stale": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the pastes that never expire and were not read for a number of days, oldest first, with the storage they take.\nPastes never read since read times were recorded count from their creation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Stale pastes report",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Days without a read (default from CLEANUP_STALE_DAYS, max 3650)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of pastes listed (default 100, max 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stale pastes",
+                        "schema": {
+                            "$ref": "#/definitions/service.StaleReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid number of days or limit",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stale/expire": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Expire at once every paste that never expires and was not read for a number of days, as listed by the stale report.\nThe pastes stop being served right away; their records and content are removed by the TTL monitor and the cleanup worker.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Expire stale pastes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Days without a read (default from CLEANUP_STALE_DAYS, max 3650)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Expired pastes",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ExpireStaleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid number of days",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stats": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report the number of stored pastes, the pre-generated short ID pool and the pastes created on each of the last days (UTC).\nDaily counts only include pastes still stored.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Paste and key pool statistics",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of days of creation counts, today included (default 30, max 366)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Statistics",
+                        "schema": {
+                            "$ref": "#/definitions/service.StatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid number of days",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/verify": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Re-download the content of a random sample of readable pastes now, as the storage verifier of the worker does every CLEANUP_VERIFY_INTERVAL, and check that it decompresses and matches its recorded SHA-256.\nCorrupt pastes are quarantined unless CLEANUP_VERIFY_QUARANTINE is false: they answer 409 until released.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Verify stored pastes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes checked (default 20, max 1000)",
+                        "name": "sample",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Verification results",
+                        "schema": {
+                            "$ref": "#/definitions/service.VerifyRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sample size",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Storage unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/email/verify": {
+            "get": {
+                "description": "Followed from the verification email: marks the email of the password account verified.\nRedirects to the configured page, or answers with the account when none is configured.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Verify an email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Verification token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email verified",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "302": {
+                        "description": "Email verified, redirect to the configured page"
+                    },
+                    "400": {
+                        "description": "Invalid, used or expired token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Password accounts not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/email/verify/resend": {
+            "post": {
+                "description": "Email another verification link to the password account of an email that is not verified yet, at most one a minute.\nThe answer is the same whether the email has an account or not.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Resend the verification email",
+                "parameters": [
+                    {
+                        "description": "Email of the account",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.EmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Email sent if the account exists and is not verified"
+                    },
+                    "400": {
+                        "description": "Invalid email",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Password accounts not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "description": "Revoke the session sending the request and clear the session cookie. API tokens are revoked from the session list instead.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Sign out",
+                "responses": {
+                    "204": {
+                        "description": "Signed out"
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Email a link to choose a new password, valid for an hour, to the password account of an email, at most one a minute.\nThe answer is the same whether the email has an account or not.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Request a password reset",
+                "parameters": [
+                    {
+                        "description": "Email of the account",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.EmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Email sent if the account exists"
+                    },
+                    "400": {
+                        "description": "Invalid email",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Password accounts not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/login": {
+            "post": {
+                "description": "Sign in to a verified password account and issue a session token, set in the gisty_session cookie.\n5 failed sign-ins lock the account, and 30 attempts throttle the client, for 15 minutes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Sign in with a password",
+                "parameters": [
+                    {
+                        "description": "Credentials",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.PasswordLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Wrong email or password",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email not verified",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Password accounts not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Account locked or client throttled, see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/register": {
+            "post": {
+                "description": "Create an account signing in with an email and a password, and email the link verifying the email.\nThe account signs in once the email is verified.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Create a password account",
+                "parameters": [
+                    {
+                        "description": "Account",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Account created, verification email sent",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid email, or password not between 10 and 72 bytes",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Password accounts not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Email has an account",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "get": {
+                "description": "Followed from the password reset email: a form to choose a new password, posted to the reset endpoint",
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Password reset page",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Reset token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reset form",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Password accounts not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Choose a new password with the token of a reset email, as JSON or from the reset page's form.\nThe token works once; the email is marked verified and the account unlocked.",
+                "consumes": [
+                    "application/json",
+                    "application/x-www-form-urlencoded"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset a password",
+                "parameters": [
+                    {
+                        "description": "Token and new password",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed, answered to the form in plain text",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "204": {
+                        "description": "Password changed"
+                    },
+                    "400": {
+                        "description": "Invalid, used or expired token, or password not between 10 and 72 bytes",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Password accounts not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/{provider}/callback": {
+            "get": {
+                "description": "Called by the provider after consent: records the user and issues a session token, set in the gisty_session cookie.\nRedirects to the configured page, or answers with the token when none is configured.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Complete a sign-in",
+                "parameters": [
+                    {
+                        "enum": [
+                            "github",
+                            "google"
+                        ],
+                        "type": "string",
+                        "description": "Provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Login state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LoginResponse"
+                        }
+                    },
+                    "302": {
+                        "description": "Signed in, redirect to the configured page"
+                    },
+                    "400": {
+                        "description": "Login expired or started in another browser",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Provider did not authorize the sign-in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Provider not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Provider unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/{provider}/login": {
+            "get": {
+                "description": "Redirect to the consent page of the provider (github or google), which sends the user back to the login callback",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Sign in with an OAuth provider",
+                "parameters": [
+                    {
+                        "enum": [
+                            "github",
+                            "google"
+                        ],
+                        "type": "string",
+                        "description": "Provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Redirect to the provider"
+                    },
+                    "404": {
+                        "description": "Provider not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/bundles": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Store each file of a bundle, such as a directory pushed with gisty push, as a paste, in path order. Each paste keeps the file's path as title, its base name as download filename, and the syntax type given or detected from its path and content; all get the description, expiration and privacy of the request. The pastes share a group_id, listed by GET /groups/{id} and shown as a tree in the HTML view. Bundles hold up to 100 files and 1MB of content in total, and are created entirely or not at all.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Create the pastes of a file tree",
+                "parameters": [
+                    {
+                        "description": "Files of the bundle",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateBundleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Pastes created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateBundleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request (no or too many files, invalid or duplicate path, empty content, invalid encoding, syntax_type or expires_in)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Content too large (max 1MB in total)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Storage or database unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groups/{id}": {
+            "get": {
+                "description": "List the pastes created together from a bundle or a multi-file gist, in path order, without their content. Reading the list does not count views.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "List the pastes of a group",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "Zk3q9XbW1pLm",
+                        "description": "Group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pastes of the group",
+                        "schema": {
+                            "$ref": "#/definitions/handler.GroupResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Group not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Check if the service is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "Service is healthy",
+                        "schema": {
+                            "$ref": "#/definitions/handler.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Report that the process is up and serving requests. Dependencies are not checked, so an outage of MongoDB, Redis or S3 does not get every instance restarted; use /readyz to gate traffic.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "Process is alive",
+                        "schema": {
+                            "$ref": "#/definitions/handler.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/import/gist": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Fetch a gist by ID or gist.github.com URL and store each of its files as a paste, in filename order. Each paste keeps the filename as title and download filename and the language GitHub detected as syntax type; all get the gist's description, the tag \"gist\" and the expiration and privacy of the request. The pastes of a multi-file gist share a group_id, so they can be exported again as one gist. Gists of more than 10 files or with a file over 1MB are rejected, and a gist is imported entirely or not at all.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Import a GitHub gist",
+                "parameters": [
+                    {
+                        "description": "Gist to import",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ImportGistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Pastes created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ImportGistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid gist reference or expiration",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Gist not found, or gist import disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Gist too large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "GitHub failed or unreachable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/lookup": {
+            "get": {
+                "security": [
+                    {
+                        "APIKey": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the caller's readable pastes whose content has the given SHA-256, newest first, to tell whether a file was already pasted.\nThe caller is identified by API key, signed-in user or anonymous session (X-Gisty-Session header).\nPastes created before content hashes were recorded are found once they have been read.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Find my pastes of a content",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Hex-encoded SHA-256 of the content",
+                        "name": "sha256",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of pastes (default 100, max 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Anonymous session token the pastes were created with",
+                        "name": "X-Gisty-Session",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching pastes",
+                        "schema": {
+                            "$ref": "#/definitions/service.LookupResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sha256 or limit",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Neither an API key, a session token nor a session was sent",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Return the signed-in user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "My account",
+                "responses": {
+                    "200": {
+                        "description": "Signed-in user",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/2fa": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Turn off the signed-in user's two-factor authentication and drop their recovery codes",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Turn off two-factor authentication",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Two-factor code or recovery code",
+                        "name": "X-Gisty-OTP",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Two-factor authentication off"
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or wrong two-factor code",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication not on",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Confirm the enrollment with a code of the authenticator app, and return the recovery codes.\nBulk deletes and API token creation then need a code, or a recovery code, in the X-Gisty-OTP header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Turn on two-factor authentication",
+                "parameters": [
+                    {
+                        "description": "Code of the authenticator app",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.TOTPCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor authentication on",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RecoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Wrong code",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication already on, or not enrolled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Start turning on two-factor authentication for the signed-in user: returns a secret for an authenticator app.\nTwo-factor authentication is on once a code of the app confirms the enrollment.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start two-factor authentication",
+                "responses": {
+                    "200": {
+                        "description": "Secret to enter in an authenticator app",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TOTPEnrollmentResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication already on",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the recovery codes of the signed-in user's two-factor authentication; the old ones stop working",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Replace my recovery codes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Two-factor code or recovery code",
+                        "name": "X-Gisty-OTP",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "New recovery codes",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RecoveryCodesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or wrong two-factor code",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Two-factor authentication not on",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the signed-in user's notifications of comments on their pastes and of comments mentioning them, newest first.\nNotifications are kept for 90 days. Pass the next cursor of a page as before to get the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "My notifications",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only unread notifications",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of notifications (default 50, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next cursor of the previous page, or an RFC 3339 time to list the notifications created before it",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notifications",
+                        "schema": {
+                            "$ref": "#/definitions/service.NotificationsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/notifications/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark the listed notifications of the signed-in user read, or all of them with all set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Mark my notifications read",
+                "parameters": [
+                    {
+                        "description": "Notifications to mark read",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.MarkReadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notifications marked read",
+                        "schema": {
+                            "$ref": "#/definitions/service.MarkReadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/panic": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Immediately expire and delete every unread burn-after-read or view-limited paste created\nwith the caller's API key or anonymous session (X-Gisty-Session header)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Destroy my unread secret pastes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Anonymous session token the pastes were created with",
+                        "name": "X-Gisty-Session",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pastes deleted",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PanicResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Neither an API key nor a session was sent",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/pastes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the pastes created while signed in, newest first. Pass the next cursor of a page as before to get the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "My pastes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes (default 100, max 10000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next cursor of the previous page, or an RFC 3339 time to list the pastes created before it",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pastes of the signed-in user",
+                        "schema": {
+                            "$ref": "#/definitions/service.UserPastesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/pastes/delete": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete up to 100 pastes created by the signed-in user. Pastes of others and unknown IDs are skipped.\nUsers with two-factor authentication send a code in the X-Gisty-OTP header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Delete my pastes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Two-factor code or recovery code, when two-factor authentication is on",
+                        "name": "X-Gisty-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Pastes to delete",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.BulkDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pastes deleted",
+                        "schema": {
+                            "$ref": "#/definitions/service.BulkDeleteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "No IDs, or more than 100",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or wrong two-factor code",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/pins": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the pastes pinned to the top of the signed-in user's profile, in the order they were pinned",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "My pinned pastes",
+                "responses": {
+                    "200": {
+                        "description": "Pinned pastes",
+                        "schema": {
+                            "$ref": "#/definitions/service.PinsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Pin one of the signed-in user's public pastes to the top of their profile, after those already pinned.\nUp to 6 pastes can be pinned; burn-after-read and view-limited pastes cannot. Pinning a pinned paste changes nothing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Pin a paste to my profile",
+                "parameters": [
+                    {
+                        "description": "Paste to pin",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.PinRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pinned pastes",
+                        "schema": {
+                            "$ref": "#/definitions/service.PinsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a public paste of the user",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "6 pastes are pinned already",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/pins/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unpin one of the signed-in user's pastes. Unpinning a paste that is not pinned changes nothing.",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Unpin a paste from my profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Paste unpinned"
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No paste of the user with this ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/profile": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Hide or show the signed-in user's public profile, and turn email digests of their unread notifications on or off.\nA hidden profile answers like an unknown username.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Change my profile settings",
+                "parameters": [
+                    {
+                        "description": "Profile settings",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ProfileSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Signed-in user",
+                        "schema": {
+                            "$ref": "#/definitions/model.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the signed-in user's sessions and API tokens, newest first, with the device they signed in from and their last use.\nThe one sending the request is marked current.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "My sessions and API tokens",
+                "responses": {
+                    "200": {
+                        "description": "Sessions and API tokens",
+                        "schema": {
+                            "$ref": "#/definitions/handler.SessionsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sign the signed-in user out of all their sessions, this one included. API tokens keep working.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Log out everywhere",
+                "responses": {
+                    "200": {
+                        "description": "Signed out everywhere",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RevokeSessionsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sign one of the signed-in user's sessions out, or revoke one of their API tokens; its token stops working at once",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Revoke a session or API token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Revoked"
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/me/tokens": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create an API token acting as the signed-in user, listed and revoked with their sessions. The token is shown once.\nUsers with two-factor authentication send a code in the X-Gisty-OTP header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Create an API token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Two-factor code or recovery code, when two-factor authentication is on",
+                        "name": "X-Gisty-OTP",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Token created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreateTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Empty name or longer than 64 characters, or invalid lifetime",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or wrong two-factor code",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "20 tokens already",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes, see Retry-After",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Create a new code/text snippet with optional expiration and syntax highlighting.\nThe instance may cap the lifetime of pastes of some syntax types, such as dotenv files; expiration_policy in the response reports the cap and whether it shortened the requested expiration.\nA text/plain body is the content itself, expiring as set by the X-Gisty-Expires header, with the other options at their defaults.",
+                "consumes": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Create a new paste",
+                "parameters": [
+                    {
+                        "description": "Paste content and options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreatePasteRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "example": "3600",
+                        "description": "Expiration of a text/plain paste, in seconds or as expires_in",
+                        "name": "X-Gisty-Expires",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Paste created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreatePasteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request (empty content, invalid encoding, invalid syntax_type, invalid expires_in, invalid delivery headers, invalid producer, live with burn-after-read, max_views or encryption, line too long or NUL bytes when rejected by policy)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Content too large (max 1MB)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "The output_of paste does not exist or has expired, or content screening rejected the paste as spam or abuse",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service temporarily unavailable, or no short ID available (with Retry-After while the key pool is below its critical floor)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/bulk-get": {
+            "post": {
+                "description": "Read up to 100 pastes in one round trip, for clients rendering many snippets at once. Each paste is read as by GET /pastes/{id}, counting a view; one that cannot be read does not fail the request but gets its own status and error code in place of the paste. Results follow the order of the IDs, each ID read once however often it is listed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Get several pastes",
+                "parameters": [
+                    {
+                        "description": "Pastes to read",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.BulkGetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Each paste, or why it could not be read",
+                        "schema": {
+                            "$ref": "#/definitions/handler.BulkGetResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "No IDs, more than 100, or invalid max_bytes",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/from-url": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Fetch the content of a public http or https URL on the server and store it as a paste, recording the URL as its source_url. Only text content is fetched: text/* and common source types such as application/json, as configured. The fetch follows up to 5 redirects and is bounded in time and to 1MB. URLs with credentials, and hosts that are denied or resolve to private, loopback, link-local or otherwise non-public addresses, are refused, including after redirects. The syntax type is detected from the URL's filename and the content unless given; the title defaults to the filename.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Create a paste from a URL",
+                "parameters": [
+                    {
+                        "description": "URL to fetch",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ImportURLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Paste created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreatePasteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL, syntax type or expiration",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Creating pastes from URLs is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Content too large (max 1MB)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "URL not allowed, or content of an unsupported type",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "URL unreachable or answered with an error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/init": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Reserve a short ID and get a pre-signed URL to upload large content directly to storage.\nSend the content with the returned method and headers, then call the complete endpoint.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Start a direct upload",
+                "parameters": [
+                    {
+                        "description": "Content size, SHA-256 checksum and paste options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.InitUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Pre-signed upload request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.InitUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request (empty size, invalid sha256, syntax_type or expires_in)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Content too large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service temporarily unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}": {
+            "get": {
+                "description": "Retrieve a paste's content and metadata by its short ID. Responses carry an ETag, except for\nburn-after-read and view-limited pastes, to revalidate with If-None-Match.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Get a paste by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cap the returned content at this many bytes (0 returns full content)",
+                        "name": "max_bytes",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "base64",
+                            "hex"
+                        ],
+                        "type": "string",
+                        "description": "Return the content base64 or hex encoded, byte for byte, instead of as a JSON string",
+                        "name": "encoding",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "url"
+                        ],
+                        "type": "string",
+                        "description": "Return a pre-signed storage URL in content_url instead of the content of pastes above the server's size threshold",
+                        "name": "delivery",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response; 304 is returned without counting a view when the content is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handler.GetPasteResponse"
+                        }
+                    },
+                    "304": {
+                        "description": "Content unchanged since the given ETag"
+                    },
+                    "307": {
+                        "description": "Redirect to the paste whose ID matches regardless of case, when enabled"
+                    },
+                    "400": {
+                        "description": "Missing paste ID, invalid max_bytes, invalid encoding or invalid delivery",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste content archived until restored",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Storage unavailable and the content not cached",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Replace the content of a paste. The previous version is kept as a revision.\nOnly the creator of the paste, proven as for DELETE /pastes/{id}, or an admin may edit it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Edit a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete token returned when the paste was created",
+                        "name": "X-Delete-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete token, when the header cannot be sent",
+                        "name": "delete_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin token, to edit any paste",
+                        "name": "X-Admin-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "New content",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdatePasteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UpdatePasteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request (empty content, invalid syntax_type, line too long or NUL bytes when rejected by policy)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or wrong delete token, not the owner and not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste was edited concurrently, or is a live paste",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Content too large (max 1MB)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Storage or database unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete a paste by its short ID. Pastes created anonymously are deleted with the delete token returned\nwhen they were created, others by the API key, signed-in user or anonymous session (X-Gisty-Session header) that created them.\nAdmins may delete any paste with the admin token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Delete a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete token returned when the paste was created",
+                        "name": "X-Delete-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete token, when the header cannot be sent",
+                        "name": "delete_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin token, to delete any paste",
+                        "name": "X-Admin-Token",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Paste deleted successfully"
+                    },
+                    "400": {
+                        "description": "Missing paste ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or wrong delete token, not the owner and not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/annotations": {
+            "get": {
+                "description": "Return the findings of the linters (gofmt, jsonlint, yamllint) for the current content. Pastes are linted in the background after each create and edit: status is pending until then, and unsupported when no linter applies to the syntax type or the content is encrypted, binary or burn-after-read.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Get the linter annotations of a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Linter annotations",
+                        "schema": {
+                            "$ref": "#/definitions/handler.AnnotationsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/append": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Append content to the end of a paste created with live=true. Concurrent appends are applied one after the other.\nOnly the creator of the paste, proven as for DELETE /pastes/{id}, or an admin may append to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Append to a live paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete token returned when the paste was created",
+                        "name": "X-Delete-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete token, when the header cannot be sent",
+                        "name": "delete_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin token, to append to any paste",
+                        "name": "X-Admin-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Content to append",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.AppendPasteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Content appended",
+                        "schema": {
+                            "$ref": "#/definitions/handler.AppendPasteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request (empty content, invalid encoding, line too long or NUL bytes when rejected by policy)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or wrong delete token, not the owner and not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Not a live paste, or other appends kept it busy",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Content too large (max 1MB in total)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Storage or database unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/comments": {
+            "get": {
+                "description": "List the comments on a paste, newest first. Pass the next cursor of a page as before to get the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "List comments on a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of comments (default 50, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next cursor of the previous page, or an RFC 3339 time to list the comments created before it",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Comments",
+                        "schema": {
+                            "$ref": "#/definitions/service.CommentsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Comment on a paste as the signed-in user. The user who created the paste and the users mentioned as @username are notified.\nBurn-after-read and view-limited pastes take no comments.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Comment on a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comment",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.CreateCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Comment posted",
+                        "schema": {
+                            "$ref": "#/definitions/model.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Empty comment or longer than 2000 characters",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Not signed in",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Paste takes no comments",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/complete": {
+            "post": {
+                "description": "Verify the uploaded content against the announced size and checksum and make the paste available",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Complete a direct upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreatePasteResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Content not uploaded yet, or upload already completed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Upload URL has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Uploaded content does not match the announced size or checksum, or exceeds decompression limits",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/download": {
+            "get": {
+                "description": "Download a paste as an attachment named after its download filename, or its short ID with the usual extension of its syntax type (e.g. xK9a2B.py). A paste of a bundle or multi-file gist downloads the files of its group as a zip archive named after the group, each file under its path; ?format=tar asks for a tar archive instead, and ?format= also archives a paste created alone. The ID may also be a group ID. Content is streamed from storage. Downloading a paste counts a view; downloading an archive does not, and leaves out burn-after-read and view-limited pastes.",
+                "produces": [
+                    "text/plain",
+                    "application/octet-stream",
+                    "application/zip",
+                    "application/x-tar"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Download a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID or group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "zip",
+                            "tar"
+                        ],
+                        "type": "string",
+                        "description": "Archive format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste content, or archive of the files of its group",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste or group not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste content archived until restored",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Storage unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/export/gist": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Create a gist holding the content of a paste, owned by the holder of the GitHub token sent in the body. The token needs the gist scope; it is used for this call only and never stored or logged. The file is named after the paste's download filename, or its short ID with the usual extension of its syntax type, unless a filename is given. A paste imported from a multi-file gist is exported with the other pastes of its group_id, as one file each. Exporting does not count a view; encrypted, binary, burn-after-read and view-limited pastes cannot be exported.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Export a paste to GitHub as a gist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "GitHub token and gist settings",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ExportGistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Gist created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ExportGistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing token, or invalid or duplicate filename",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "GitHub rejected the token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found, or gist export disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste content archived until restored",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Encrypted, binary, burn-after-read or view-limited paste, or group member",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "GitHub failed or unreachable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/fork": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Copy the content, syntax type, title, description and tags of a paste into a new paste owned by the caller, recorded as forked_from the source. The fork gets its own expiration and privacy from the request; the title can be overridden. Burn-after-read and view-limited pastes cannot be forked, and forking does not count a view of the source.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Fork a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Settings of the fork",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ForkPasteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Fork created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreatePasteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid expiration or title",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste content archived until restored",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Burn-after-read or view-limited paste",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/live/ws": {
+            "get": {
+                "description": "Upgrade to a WebSocket that pushes the content of a live paste from offset on, then each append as it lands,\nlike tail -f. Reads are not counted as views. A final message with ended=true is sent when the paste is\ndeleted or expires, then the connection is closed. Connections last up to an hour; reconnect with offset set\nto the size of the last message to resume.",
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Follow a live paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Byte offset to start from, the size of the last message when reconnecting",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "base64",
+                            "hex"
+                        ],
+                        "type": "string",
+                        "description": "Push content base64 or hex encoded, byte for byte",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching to WebSocket",
+                        "schema": {
+                            "$ref": "#/definitions/handler.LiveChunk"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid offset or encoding",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Not a live paste",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/outputs": {
+            "get": {
+                "description": "List the pastes created with output_of set to this paste, such as CI job logs or sandbox runs, newest first. Burn-after-read and view-limited outputs are never listed, and private outputs only when the paste itself is private.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "List the outputs of a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of outputs (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Outputs of the paste",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ListOutputsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/preview": {
+            "get": {
+                "description": "Return the metadata and first lines of a paste, for chat-app link unfurlers and readers checking a link before opening it.\nA preview never counts a view, burns a burn-after-read paste or shows in trending. Lines are omitted for\nburn-after-read, view-limited, encrypted and binary pastes. Also served on GET /{id}/preview of the short-link host.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Preview a paste without reading it",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of lines (default 10, max 50)",
+                        "name": "lines",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste preview",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PreviewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid number of lines",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/redact": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Replace line ranges of the current content and regular expression matches with a marker,\nin the current content and every revision. Lines redacted in the current content are also\nredacted wherever the same line appears in a revision. No copy of the previous content is kept.\nOnly the creator of the paste, proven as for DELETE /pastes/{id}, or an admin may redact it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Redact a paste and its history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete token returned when the paste was created",
+                        "name": "X-Delete-Token",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Delete token, when the header cannot be sent",
+                        "name": "delete_token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Admin token, to redact any paste",
+                        "name": "X-Admin-Token",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Lines and patterns to redact",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RedactRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste redacted",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RedactResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid line range, pattern or marker",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Missing or wrong delete token, not the owner and not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste was edited concurrently",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Encrypted or binary paste",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/report": {
+            "post": {
+                "description": "Report a paste for abuse: spam, malware, phishing, copyright infringement (DMCA), personal data, illegal content or another reason. Operators review reports and may hide or delete the paste.\nAnyone can report a paste; a reporter cannot report the same paste again while their report is open.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Report a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/service.ReportPasteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Report recorded",
+                        "schema": {
+                            "$ref": "#/definitions/model.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown reason, or details or contact too long",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found, or reports disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste already reported by this reporter",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/revisions": {
+            "get": {
+                "description": "List the previous versions of an edited paste, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "List the revisions of a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Revisions of the paste",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ListRevisionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing paste ID",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/run": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Send the content of a paste to the configured sandbox service, which runs it with a time limit, and store the output as a new paste linked to the source (output_of, with producer kind runner). The output paste has the privacy of its source and expires with it, after a week at the latest. Only syntax types in the instance's allow-list can be run; code is never executed by the Gisty server itself.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Run a paste in the sandbox",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Standard input of the program",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RunPasteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Output of the run",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RunPasteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "stdin too large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found, or running pastes is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste content archived until restored",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Syntax type not allowed, or encrypted, binary, burn-after-read or view-limited paste",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Sandbox failed or unreachable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/snippet": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Create a new paste holding a range of lines of a paste, recorded as derived_from the source and range, with the syntax type, title, description and tags of the source. The HTML view numbers its lines as in the source. The snippet gets its own expiration and privacy from the request; the title can be overridden. Burn-after-read, view-limited, encrypted and binary pastes cannot be cut, and cutting does not count a view of the source.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Share a range of lines of a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Lines and settings of the snippet",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.SnippetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Snippet created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreatePasteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid line range, expiration or title",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste content archived until restored",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Burn-after-read, view-limited, encrypted or binary paste",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/ttl": {
+            "get": {
+                "description": "Return the expiry time and server-computed remaining seconds of a paste without reading its content, so burn-after-read pastes are not consumed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Get the remaining lifetime of a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Remaining lifetime",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PasteTTLResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/pastes/{id}/ttl/ws": {
+            "get": {
+                "description": "Upgrade to a WebSocket that pushes the paste TTL (same shape as GET /pastes/{id}/ttl) on connect and every 10 seconds. A final message with expired=true is sent when the paste expires or is burned, then the connection is closed.",
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Watch the remaining lifetime of a paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching to WebSocket",
+                        "schema": {
+                            "$ref": "#/definitions/handler.PasteTTLResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/raw/{id}": {
+            "get": {
+                "description": "Download every file of the bundle or multi-file gist a paste belongs to as one archive, each file under its path in the bundle. The format is chosen with ?format=, or else with an Accept of application/zip or application/x-tar; multi-file pastes default to zip. Files are streamed from storage one at a time without counting views, so burn-after-read and view-limited pastes are left out, as are files deleted or expired since. The ID may also be a group ID. A paste created alone is served as raw content, as on its share link, unless a format is asked for.",
+                "produces": [
+                    "application/zip",
+                    "application/x-tar",
+                    "text/plain"
+                ],
+                "tags": [
+                    "raw"
+                ],
+                "summary": "Download the files of a multi-file paste",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID or group ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "zip",
+                            "tar"
+                        ],
+                        "type": "string",
+                        "description": "Archive format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/zip or application/x-tar, when no format is given",
+                        "name": "Accept",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Archive of the files",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid format",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste or group not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste content archived until restored",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Storage unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Ping MongoDB, Redis and S3 concurrently, each within a timeout, and report the status and latency of each. Answers 503 when any dependency is unavailable, so the instance is taken out of load balancing until it recovers, and with status \"draining\" and no dependencies once the instance drains before shutting down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "All dependencies are reachable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ReadinessResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "A dependency is unavailable, or the instance is draining",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ReadinessResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/regions": {
+            "get": {
+                "description": "Return the region that served the request and the regions clients can pin by calling their URL directly",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "List regions",
+                "responses": {
+                    "200": {
+                        "description": "Regions",
+                        "schema": {
+                            "$ref": "#/definitions/handler.RegionsResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/storage/events": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reconcile paste metadata with changes bucket lifecycle rules made to content, from an S3 (or MinIO webhook) event notification. Pastes whose content was removed (ObjectRemoved, LifecycleExpiration) are deleted; pastes whose content was moved to an archival storage class (LifecycleTransition, ObjectRestore:Delete) answer 409 until it is restored (ObjectRestore:Completed). Events about other buckets, revisions and unknown pastes are ignored. Only registered when S3_EVENTS_TOKEN is set; the token is sent as a bearer token or in X-Admin-Token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Receive S3 event notifications",
+                "parameters": [
+                    {
+                        "description": "S3 event notification",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pastes changed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.StorageEventsResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Not an S3 event notification",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid events token",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/trending": {
+            "get": {
+                "description": "List public pastes ranked by views over a decaying window: a view counts half as much after each half-life. Only served when trending is enabled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "List trending pastes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes (default 10, max 50)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Trending pastes",
+                        "schema": {
+                            "$ref": "#/definitions/handler.TrendingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Trending disabled",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/u/{username}": {
+            "get": {
+                "description": "Show a user's public profile: name, Gravatar avatar, totals, the pastes they pinned and a page of their other public pastes, newest first.\nPrivate, burn-after-read and view-limited pastes are never listed. Browsers get an HTML page, other clients JSON.\nProfiles hidden by their user are not found. Pass the next cursor of a page as before to get the following page.",
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Public profile of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "octocat",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes (default 30, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next cursor of the previous page, or an RFC 3339 time to list the pastes created before it",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Profile of the user",
+                        "schema": {
+                            "$ref": "#/definitions/service.ProfileResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No user with this username, or the profile is hidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "APIKey": []
+                    }
+                ],
+                "description": "Start an upload session for very large content, uploaded in fixed-size parts directly to storage.\nRequest a pre-signed URL per part, check the session to resume after an interruption, then call the complete endpoint.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Start a resumable upload",
+                "parameters": [
+                    {
+                        "description": "Content size, SHA-256 checksum and paste options",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.InitUploadRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Upload session created",
+                        "schema": {
+                            "$ref": "#/definitions/handler.InitResumableUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request (empty size, invalid sha256, syntax_type or expires_in)",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unknown API key",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Content too large",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service temporarily unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{id}": {
+            "get": {
+                "description": "Report which parts have been uploaded so an interrupted upload can be resumed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Get a resumable upload session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Upload session progress",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UploadSessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Not a resumable upload",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Upload session not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Upload already completed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Upload session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancel a pending upload and discard any content uploaded so far",
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Abort an upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Upload aborted"
+                    },
+                    "404": {
+                        "description": "Upload session not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Upload already completed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{id}/complete": {
+            "post": {
+                "description": "Verify the uploaded content against the announced size and checksum and make the paste available",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pastes"
+                ],
+                "summary": "Complete a direct upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Paste created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/handler.CreatePasteResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Content not uploaded yet, or upload already completed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Upload URL has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Uploaded content does not match the announced size or checksum, or exceeds decompression limits",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{id}/parts/{part}": {
+            "post": {
+                "description": "Get a pre-signed PUT request for one part of a resumable upload. The part's SHA-256 is verified by storage.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "uploads"
+                ],
+                "summary": "Get a pre-signed URL for one part",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Part number, starting at 1",
+                        "name": "part",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SHA-256 checksum of the part",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.UploadPartRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Pre-signed part upload request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.UploadPartResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid part number or sha256",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Upload session not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Upload already completed",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Upload session has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/pastes": {
+            "get": {
+                "description": "List the pastes on a user's public profile, newest first, without the profile itself.\nPass the next cursor of a page as before to get the following page.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Public pastes of a user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "octocat",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of pastes (default 30, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Next cursor of the previous page, or an RFC 3339 time to list the pastes created before it",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Public pastes of the user",
+                        "schema": {
+                            "$ref": "#/definitions/service.UserPastesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No user with this username, or the profile is hidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Return the version, commit and build date of the running server",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Build information",
+                "responses": {
+                    "200": {
+                        "description": "Build information",
+                        "schema": {
+                            "$ref": "#/definitions/version.Info"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}": {
+            "get": {
+                "description": "Serve a paste on its share link, at the root of the server (or of SHORT_URL_BASE) rather than under\n/api/v1. The representation follows the Accept header: JSON as GET /pastes/{id} for application/json,\nthe highlighted HTML view for text/html, the raw content otherwise. Raw content stored gzip compressed\nis sent as stored to clients accepting gzip. Chat apps and social networks unfurling the link (Slackbot,\nDiscordbot, Twitterbot...) get a minimal HTML page with OpenGraph and Twitter card tags (title, first lines,\nlanguage), built without counting a view or burning a burn-after-read paste; the HTML view carries the same tags.",
+                "produces": [
+                    "text/plain",
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "raw"
+                ],
+                "summary": "Read a paste by its share link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "xK9a2B",
+                        "description": "Paste short ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cap the returned content at this many bytes, for JSON responses",
+                        "name": "max_bytes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/json, text/html or any other type for the raw content",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response; 304 is returned without counting a view when the content is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Raw content, the JSON of GetPasteResponse or the HTML view",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "302": {
+                        "description": "Redirect to the frontend view, or to the expired paste page"
+                    },
+                    "304": {
+                        "description": "Content unchanged since the given ETag"
+                    },
+                    "307": {
+                        "description": "Redirect to the paste whose ID matches regardless of case, when enabled"
+                    },
+                    "400": {
+                        "description": "Invalid max_bytes",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Paste not found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Paste content archived until restored",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "410": {
+                        "description": "Paste has expired",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Storage unavailable and the content not cached",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "auth.Session": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_ip": {
+                    "description": "CreatedIP is the client IP the session signed in or the token was created from",
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "current": {
+                    "description": "Current marks the session or token sending the request",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f9c2a7b1e4d5c6a"
+                },
+                "kind": {
+                    "description": "Kind is \"session\" for a sign-in, \"token\" for an API token",
+                    "type": "string",
+                    "example": "session"
+                },
+                "last_ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name is the name given to an API token",
+                    "type": "string",
+                    "example": "CI uploads"
+                },
+                "user_agent": {
+                    "type": "string",
+                    "example": "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+                }
+            }
+        },
+        "handler.Annotation": {
+            "type": "object",
+            "properties": {
+                "column": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "line": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "linter": {
+                    "type": "string",
+                    "example": "yamllint"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "truthy value should be one of [false, true]"
+                },
+                "severity": {
+                    "type": "string",
+                    "enum": [
+                        "error",
+                        "warning"
+                    ],
+                    "example": "warning"
+                }
+            }
+        },
+        "handler.AnnotationsResponse": {
+            "type": "object",
+            "properties": {
+                "annotations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.Annotation"
+                    }
+                },
+                "linted_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:01Z"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "done",
+                        "pending",
+                        "unsupported"
+                    ],
+                    "example": "done"
+                }
+            }
+        },
+        "handler.AppendPasteRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "step 3/5 done"
+                },
+                "encoding": {
+                    "description": "Transfer encoding of content, for binary-ish content that JSON would mangle or bloat; stored decoded",
+                    "type": "string",
+                    "enum": [
+                        "base64",
+                        "hex"
+                    ],
+                    "example": "base64"
+                }
+            }
+        },
+        "handler.AppendPasteResponse": {
+            "type": "object",
+            "properties": {
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "size": {
+                    "description": "content size in bytes after the append",
+                    "type": "integer",
+                    "example": 1024
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                }
+            }
+        },
+        "handler.BanListResponse": {
+            "type": "object",
+            "properties": {
+                "bans": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.IPBan"
+                    }
+                }
+            }
+        },
+        "handler.BanRequest": {
+            "type": "object",
+            "properties": {
+                "duration_seconds": {
+                    "description": "0 bans until lifted",
+                    "type": "integer",
+                    "example": 86400
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "spam"
+                }
+            }
+        },
+        "handler.BlockIPRequest": {
+            "type": "object",
+            "required": [
+                "cidr"
+            ],
+            "properties": {
+                "cidr": {
+                    "description": "an IP or a CIDR",
+                    "type": "string",
+                    "example": "198.51.100.0/24"
+                },
+                "duration_seconds": {
+                    "description": "0 blocks until lifted",
+                    "type": "integer",
+                    "example": 86400
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "abusive crawler"
+                }
+            }
+        },
+        "handler.BulkGetItem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "paste_not_found"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Paste not found"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "paste": {
+                    "description": "set when the paste was read",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.GetPasteResponse"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "the status GET /pastes/{id} would have answered",
+                    "type": "integer",
+                    "example": 200
+                }
+            }
+        },
+        "handler.BulkGetResponse": {
+            "type": "object",
+            "properties": {
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BulkGetItem"
+                    }
+                }
+            }
+        },
+        "handler.BundleFile": {
+            "type": "object",
+            "required": [
+                "path"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "package main"
+                },
+                "encoding": {
+                    "description": "Transfer encoding of content, for binary-ish content that JSON would mangle or bloat; stored decoded",
+                    "type": "string",
+                    "enum": [
+                        "base64",
+                        "hex"
+                    ],
+                    "example": "base64"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "cmd/gisty/main.go"
+                },
+                "syntax_type": {
+                    "description": "detected from the path and content when omitted",
+                    "type": "string",
+                    "example": "go"
+                }
+            }
+        },
+        "handler.BundleFileResponse": {
+            "type": "object",
+            "properties": {
+                "delete_token": {
+                    "description": "DeleteToken is returned once, for files created without an API key or a sign-in; keep it to delete the paste",
+                    "type": "string",
+                    "example": "q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"
+                },
+                "path": {
+                    "type": "string",
+                    "example": "cmd/gisty/main.go"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/xK9a2B"
+                }
+            }
+        },
+        "handler.CDNPurgeResponse": {
+            "type": "object",
+            "properties": {
+                "purged_keys": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "paste-xK9a2B"
+                    ]
+                }
+            }
+        },
+        "handler.CachePurgeResponse": {
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handler.CacheStatsResponse": {
+            "type": "object",
+            "properties": {
+                "bypasses": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "hit_ratio": {
+                    "type": "number",
+                    "example": 0.95
+                },
+                "hits": {
+                    "type": "integer",
+                    "example": 950
+                },
+                "local_hits": {
+                    "description": "hits served by the in-process cache, included in hits",
+                    "type": "integer",
+                    "example": 600
+                },
+                "misses": {
+                    "type": "integer",
+                    "example": 50
+                }
+            }
+        },
+        "handler.CleanupRunResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer",
+                    "example": 118
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "failures": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "scanned": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "started_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                }
+            }
+        },
+        "handler.CleanupStatusResponse": {
+            "type": "object",
+            "properties": {
+                "abandoned": {
+                    "description": "orphaned objects given up on after the attempt cap",
+                    "type": "integer",
+                    "example": 0
+                },
+                "backlog": {
+                    "type": "integer",
+                    "example": 15
+                },
+                "dry_run": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "interval": {
+                    "type": "string",
+                    "example": "5m0s"
+                },
+                "last_run": {
+                    "$ref": "#/definitions/handler.CleanupRunResponse"
+                },
+                "min_age": {
+                    "type": "string",
+                    "example": "1h0m0s"
+                },
+                "retrying": {
+                    "description": "orphaned objects whose delete failed, retried on later runs",
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "handler.CreateBundleRequest": {
+            "type": "object",
+            "required": [
+                "files"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "gisty CLI sources"
+                },
+                "expires_in": {
+                    "type": "string",
+                    "example": "1w"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BundleFile"
+                    }
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.CreateBundleResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-22T14:00:00Z"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.BundleFileResponse"
+                    }
+                },
+                "group_id": {
+                    "type": "string",
+                    "example": "Zk3q9XbW1pLm"
+                }
+            }
+        },
+        "handler.CreatePasteRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "console.log('Hello, World!')"
+                },
+                "delivery": {
+                    "$ref": "#/definitions/handler.DeliveryHeaders"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Prints a greeting"
+                },
+                "encoding": {
+                    "description": "Transfer encoding of content, for binary-ish content that JSON would mangle or bloat; stored decoded",
+                    "type": "string",
+                    "enum": [
+                        "base64",
+                        "hex"
+                    ],
+                    "example": "base64"
+                },
+                "expires_in": {
+                    "type": "string",
+                    "example": "1h"
+                },
+                "is_encrypted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "live": {
+                    "description": "Live pastes are appended to by their owner with POST /pastes/{id}/append and followed with /pastes/{id}/live/ws",
+                    "type": "boolean",
+                    "example": false
+                },
+                "max_views": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "output_of": {
+                    "description": "Short ID of the paste this one holds the output of, listed by GET /pastes/{id}/outputs",
+                    "type": "string",
+                    "example": "aB3dE5"
+                },
+                "producer": {
+                    "$ref": "#/definitions/handler.Producer"
+                },
+                "schema": {
+                    "description": "JSON Schema to validate JSON or YAML content against; non-conforming content is accepted and the errors stored",
+                    "type": "object"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "javascript"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "javascript",
+                        "demo"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Hello world"
+                }
+            }
+        },
+        "handler.CreatePasteResponse": {
+            "type": "object",
+            "properties": {
+                "delete_token": {
+                    "description": "DeleteToken is returned once, for pastes created without an API key or a sign-in; keep it to delete the paste",
+                    "type": "string",
+                    "example": "q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"
+                },
+                "expiration_policy": {
+                    "$ref": "#/definitions/handler.ExpirationPolicyResult"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-15T15:00:00Z"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/xK9a2B"
+                },
+                "validation": {
+                    "$ref": "#/definitions/handler.SchemaValidation"
+                }
+            }
+        },
+        "handler.CreateTokenRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expires_in_days": {
+                    "description": "ExpiresInDays is the lifetime of the token (default 90, max 365)",
+                    "type": "integer",
+                    "maximum": 365,
+                    "minimum": 1,
+                    "example": 90
+                },
+                "name": {
+                    "description": "Name tells the token apart in the session list, up to 64 characters",
+                    "type": "string",
+                    "example": "CI uploads"
+                }
+            }
+        },
+        "handler.CreateTokenResponse": {
+            "type": "object",
+            "properties": {
+                "session": {
+                    "$ref": "#/definitions/auth.Session"
+                },
+                "token": {
+                    "description": "Token is sent as \"Authorization: Bearer \u003ctoken\u003e\"",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiIxIn0.c2ln"
+                }
+            }
+        },
+        "handler.DeliveryHeaders": {
+            "type": "object",
+            "properties": {
+                "cache_control": {
+                    "type": "string",
+                    "example": "public, max-age=300"
+                },
+                "content_type": {
+                    "type": "string",
+                    "example": "application/json"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "config.json"
+                }
+            }
+        },
+        "handler.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "latency_ms": {
+                    "type": "number",
+                    "example": 1.7
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "unavailable",
+                        "timeout"
+                    ],
+                    "example": "ok"
+                }
+            }
+        },
+        "handler.DerivedFrom": {
+            "type": "object",
+            "properties": {
+                "end_line": {
+                    "type": "integer",
+                    "example": 139
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "aB3dE5"
+                },
+                "start_line": {
+                    "type": "integer",
+                    "example": 120
+                }
+            }
+        },
+        "handler.DrainResponse": {
+            "type": "object",
+            "properties": {
+                "draining": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "since": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                }
+            }
+        },
+        "handler.EmailRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "octocat@example.com"
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "paste_not_found"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "Paste not found"
+                },
+                "max_size": {
+                    "type": "string",
+                    "example": "1MB"
+                }
+            }
+        },
+        "handler.ExpirationPolicyResult": {
+            "type": "object",
+            "properties": {
+                "capped": {
+                    "description": "the requested expiration was shortened to max_ttl",
+                    "type": "boolean",
+                    "example": true
+                },
+                "max_ttl": {
+                    "type": "string",
+                    "example": "24h0m0s"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "dotenv"
+                }
+            }
+        },
+        "handler.ExpireStaleResponse": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "example": 180
+                },
+                "expired": {
+                    "type": "integer",
+                    "example": 1200
+                }
+            }
+        },
+        "handler.ExportGistRequest": {
+            "type": "object",
+            "required": [
+                "token"
+            ],
+            "properties": {
+                "filename": {
+                    "type": "string",
+                    "example": "hello.py"
+                },
+                "public": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "token": {
+                    "type": "string",
+                    "example": "github_pat_11AB..."
+                }
+            }
+        },
+        "handler.ExportGistResponse": {
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "type": "string",
+                    "example": "hello.py"
+                },
+                "files": {
+                    "description": "Files lists the files of the gist, more than one when the paste was imported from a multi-file gist",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "hello.py",
+                        "README.md"
+                    ]
+                },
+                "gist_id": {
+                    "type": "string",
+                    "example": "aa5a315d61ae9438b18d"
+                },
+                "gist_url": {
+                    "type": "string",
+                    "example": "https://gist.github.com/aa5a315d61ae9438b18d"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                }
+            }
+        },
+        "handler.ForkPasteRequest": {
+            "type": "object",
+            "properties": {
+                "expires_in": {
+                    "type": "string",
+                    "example": "1w"
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "title": {
+                    "type": "string",
+                    "example": "My take on hello world"
+                }
+            }
+        },
+        "handler.GetPasteResponse": {
+            "type": "object",
+            "properties": {
+                "binary": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "content": {
+                    "type": "string",
+                    "example": "console.log('Hello, World!')"
+                },
+                "content_url": {
+                    "description": "Set instead of the content of large pastes read with delivery=url",
+                    "type": "string",
+                    "example": "https://s3.example.com/gisty/gisty/xK9a2B.gz?X-Amz-Signature=..."
+                },
+                "content_url_encoding": {
+                    "description": "codec of the downloaded bytes, also sent as Content-Encoding",
+                    "type": "string",
+                    "example": "gzip"
+                },
+                "content_url_expires_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:05:00Z"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "delivery": {
+                    "$ref": "#/definitions/handler.DeliveryHeaders"
+                },
+                "derived_from": {
+                    "description": "set on snippets of another paste",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handler.DerivedFrom"
+                        }
+                    ]
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Prints a greeting"
+                },
+                "encoding": {
+                    "description": "set when content was requested in an encoding",
+                    "type": "string",
+                    "example": "base64"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-15T15:00:00Z"
+                },
+                "forked_from": {
+                    "description": "set on pastes forked from another paste",
+                    "type": "string",
+                    "example": "aB3dE5"
+                },
+                "group_id": {
+                    "description": "set on the files of a bundle or a multi-file gist",
+                    "type": "string",
+                    "example": "Zk3q9XbW1pLm"
+                },
+                "is_encrypted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "live": {
+                    "description": "set on pastes appended to by their owner",
+                    "type": "boolean",
+                    "example": false
+                },
+                "max_views": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "output_of": {
+                    "description": "set on pastes holding the output of another paste",
+                    "type": "string",
+                    "example": "aB3dE5"
+                },
+                "path": {
+                    "description": "the file's path in its bundle, or its filename in a gist",
+                    "type": "string",
+                    "example": "cmd/gisty/main.go"
+                },
+                "preview": {
+                    "type": "string",
+                    "example": "console.log('Hello, World!')"
+                },
+                "producer": {
+                    "$ref": "#/definitions/handler.Producer"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 28
+                },
+                "source_url": {
+                    "description": "set on pastes created from a URL",
+                    "type": "string",
+                    "example": "https://example.com/hello.py"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "javascript"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "javascript",
+                        "demo"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Hello world"
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "validation": {
+                    "$ref": "#/definitions/handler.SchemaValidation"
+                },
+                "views": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "handler.GroupFile": {
+            "type": "object",
+            "properties": {
+                "path": {
+                    "description": "the file's path in its bundle, or its filename for gist imports",
+                    "type": "string",
+                    "example": "cmd/gisty/main.go"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/xK9a2B"
+                }
+            }
+        },
+        "handler.GroupResponse": {
+            "type": "object",
+            "properties": {
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.GroupFile"
+                    }
+                },
+                "group_id": {
+                    "type": "string",
+                    "example": "Zk3q9XbW1pLm"
+                }
+            }
+        },
+        "handler.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "build": {
+                    "$ref": "#/definitions/version.Info"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                }
+            }
+        },
+        "handler.IPBlockListResponse": {
+            "type": "object",
+            "properties": {
+                "blocks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.IPBlock"
+                    }
+                }
+            }
+        },
+        "handler.ImportGistRequest": {
+            "type": "object",
+            "required": [
+                "gist"
+            ],
+            "properties": {
+                "expires_in": {
+                    "type": "string",
+                    "example": "1w"
+                },
+                "gist": {
+                    "type": "string",
+                    "example": "https://gist.github.com/octocat/aa5a315d61ae9438b18d"
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.ImportGistResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-22T14:00:00Z"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.ImportedGistFile"
+                    }
+                },
+                "gist_id": {
+                    "type": "string",
+                    "example": "aa5a315d61ae9438b18d"
+                },
+                "group_id": {
+                    "description": "shared by the pastes of a multi-file gist",
+                    "type": "string",
+                    "example": "Zk3q9XbW1pLm"
+                }
+            }
+        },
+        "handler.ImportURLRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "expires_in": {
+                    "type": "string",
+                    "example": "1w"
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "syntax_type": {
+                    "description": "detected from the URL's filename and the content when omitted",
+                    "type": "string",
+                    "example": "python"
+                },
+                "title": {
+                    "description": "the URL's filename when omitted",
+                    "type": "string",
+                    "example": "hello.py"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://raw.githubusercontent.com/octocat/Hello-World/master/README"
+                }
+            }
+        },
+        "handler.ImportedGistFile": {
+            "type": "object",
+            "properties": {
+                "delete_token": {
+                    "description": "DeleteToken is returned once, for files imported without an API key or a sign-in; keep it to delete the paste",
+                    "type": "string",
+                    "example": "q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"
+                },
+                "filename": {
+                    "type": "string",
+                    "example": "hello_world.rb"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "ruby"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/xK9a2B"
+                }
+            }
+        },
+        "handler.InitResumableUploadResponse": {
+            "type": "object",
+            "properties": {
+                "delete_token": {
+                    "description": "DeleteToken is returned once, for uploads started without an API key or a sign-in; keep it to delete the paste",
+                    "type": "string",
+                    "example": "q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "part_count": {
+                    "type": "integer",
+                    "example": 13
+                },
+                "part_size": {
+                    "type": "integer",
+                    "example": 8388608
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                }
+            }
+        },
+        "handler.InitUploadRequest": {
+            "type": "object",
+            "required": [
+                "sha256",
+                "size"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "example": "Nightly build output"
+                },
+                "expires_in": {
+                    "type": "string",
+                    "example": "1d"
+                },
+                "is_encrypted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "plaintext"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ci",
+                        "logs"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Build log"
+                }
+            }
+        },
+        "handler.InitUploadResponse": {
+            "type": "object",
+            "properties": {
+                "delete_token": {
+                    "description": "DeleteToken is returned once, for uploads started without an API key or a sign-in; keep it to delete the paste",
+                    "type": "string",
+                    "example": "q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:15:00Z"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "Content-Type": "application/octet-stream",
+                        "X-Amz-Checksum-Sha256": "n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "example": "PUT"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "upload_url": {
+                    "type": "string",
+                    "example": "https://s3.amazonaws.com/gisty/gisty/xK9a2B.gz?X-Amz-Signature=..."
+                }
+            }
+        },
+        "handler.LineRange": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "start": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handler.ListOutputsResponse": {
+            "type": "object",
+            "properties": {
+                "outputs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.OutputSummary"
+                    }
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.ListRevisionsResponse": {
+            "type": "object",
+            "properties": {
+                "revision": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "revisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.RevisionResponse"
+                    }
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                }
+            }
+        },
+        "handler.LiveChunk": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "step 3/5 done"
+                },
+                "encoding": {
+                    "type": "string",
+                    "example": "base64"
+                },
+                "ended": {
+                    "description": "set on the last message, once the paste is gone",
+                    "type": "boolean",
+                    "example": false
+                },
+                "offset": {
+                    "description": "byte offset of content in the paste",
+                    "type": "integer",
+                    "example": 1000
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "size": {
+                    "description": "content size in bytes, the offset to reconnect from",
+                    "type": "integer",
+                    "example": 1014
+                }
+            }
+        },
+        "handler.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-02-14T14:00:00Z"
+                },
+                "token": {
+                    "description": "Token is the session token, sent as \"Authorization: Bearer \u003ctoken\u003e\" by API clients; browsers\nget it in the gisty_session cookie",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiIxIn0.c2ln"
+                },
+                "user": {
+                    "$ref": "#/definitions/model.User"
+                }
+            }
+        },
+        "handler.MaintenanceRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Database migration in progress"
+                },
+                "retry_after_seconds": {
+                    "type": "integer",
+                    "example": 300
+                }
+            }
+        },
+        "handler.MaintenanceResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Database migration in progress"
+                },
+                "retry_after_seconds": {
+                    "type": "integer",
+                    "example": 300
+                },
+                "since": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                }
+            }
+        },
+        "handler.OutputSummary": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:05:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-22T14:05:00Z"
+                },
+                "producer": {
+                    "$ref": "#/definitions/handler.Producer"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "pQ7rS9"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "plaintext"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Output of xK9a2B"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/pQ7rS9"
+                }
+            }
+        },
+        "handler.PanicResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "short_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "xK9a2B",
+                        "pQ7rT1"
+                    ]
+                }
+            }
+        },
+        "handler.PasswordLoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "octocat@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "correct horse battery staple"
+                }
+            }
+        },
+        "handler.PasteTTLResponse": {
+            "type": "object",
+            "properties": {
+                "burn_after_read": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "expired": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-15T15:00:00Z"
+                },
+                "remaining_seconds": {
+                    "type": "integer",
+                    "example": 3599
+                },
+                "remaining_views": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "server_time": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:01Z"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                }
+            }
+        },
+        "handler.PinRequest": {
+            "type": "object",
+            "required": [
+                "short_id"
+            ],
+            "properties": {
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                }
+            }
+        },
+        "handler.PreviewResponse": {
+            "type": "object",
+            "properties": {
+                "binary": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "burn_after_read": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "is_encrypted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "line_count": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "package main",
+                        "",
+                        "func main() {"
+                    ]
+                },
+                "max_views": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Deploy script"
+                },
+                "truncated": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handler.Producer": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "example": "ci"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "build #1234"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://ci.example.com/jobs/1234"
+                }
+            }
+        },
+        "handler.ProfileSettingsRequest": {
+            "type": "object",
+            "properties": {
+                "email_digest": {
+                    "description": "EmailDigest sends the user's unread notifications by email, when the server sends email",
+                    "type": "boolean",
+                    "example": true
+                },
+                "hidden": {
+                    "description": "Hidden disables the public profile, /u/{username}, and the listing of the user's pastes by username",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handler.RateLimitStatusResponse": {
+            "type": "object",
+            "properties": {
+                "client_key": {
+                    "type": "string",
+                    "example": "9f86d081884c7d65"
+                },
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "limited": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "remaining": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "reset": {
+                    "type": "string",
+                    "example": "2024-01-15T14:01:00Z"
+                }
+            }
+        },
+        "handler.ReadinessResponse": {
+            "type": "object",
+            "properties": {
+                "dependencies": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/handler.DependencyStatus"
+                    }
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ready",
+                        "not_ready",
+                        "draining"
+                    ],
+                    "example": "ready"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                }
+            }
+        },
+        "handler.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "k3x9q-pl2m7",
+                        "vzjbq-0s1tt"
+                    ]
+                }
+            }
+        },
+        "handler.RedactRequest": {
+            "type": "object",
+            "properties": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.LineRange"
+                    }
+                },
+                "marker": {
+                    "type": "string",
+                    "example": "[REDACTED]"
+                },
+                "patterns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "AKIA[0-9A-Z]{16}"
+                    ]
+                }
+            }
+        },
+        "handler.RedactResponse": {
+            "type": "object",
+            "properties": {
+                "redacted_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:30:00Z"
+                },
+                "redactions": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "revisions_rewritten": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                }
+            }
+        },
+        "handler.Region": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "ap-southeast-1"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://sg.gisty.co"
+                }
+            }
+        },
+        "handler.RegionsResponse": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "type": "string",
+                    "example": "ap-southeast-1"
+                },
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.Region"
+                    }
+                }
+            }
+        },
+        "handler.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "octocat@example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "The Octocat"
+                },
+                "password": {
+                    "description": "Password has 10 to 72 bytes",
+                    "type": "string",
+                    "example": "correct horse battery staple"
+                }
+            }
+        },
+        "handler.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "correct horse battery staple"
+                },
+                "token": {
+                    "description": "Token is the token of the link of the password reset email",
+                    "type": "string",
+                    "example": "Yk3x9QpL2m7VZJbq0s1TtR8cWnE4aHdF6uGiOyKxM5w"
+                }
+            }
+        },
+        "handler.RevisionResponse": {
+            "type": "object",
+            "properties": {
+                "binary": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "replaced_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:30:00Z"
+                },
+                "revision": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "javascript"
+                }
+            }
+        },
+        "handler.RevokeSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "revoked": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "handler.RunPasteRequest": {
+            "type": "object",
+            "properties": {
+                "stdin": {
+                    "type": "string",
+                    "example": "42"
+                }
+            }
+        },
+        "handler.RunPasteResponse": {
+            "type": "object",
+            "properties": {
+                "delete_token": {
+                    "description": "DeleteToken is returned once, for runs without an API key or a sign-in; keep it to delete the output paste",
+                    "type": "string",
+                    "example": "q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 412
+                },
+                "exit_code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-22T14:00:00Z"
+                },
+                "language": {
+                    "type": "string",
+                    "example": "python"
+                },
+                "output_id": {
+                    "type": "string",
+                    "example": "pQ7rT1"
+                },
+                "output_url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/pQ7rT1"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "stderr": {
+                    "type": "string",
+                    "example": ""
+                },
+                "stdout": {
+                    "type": "string",
+                    "example": "Hello, World!\n"
+                },
+                "timed_out": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "handler.SchemaValidation": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "/port: got string",
+                        " want integer"
+                    ]
+                },
+                "valid": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "validated_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                }
+            }
+        },
+        "handler.SessionsResponse": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/auth.Session"
+                    }
+                }
+            }
+        },
+        "handler.SnippetRequest": {
+            "type": "object",
+            "required": [
+                "start_line"
+            ],
+            "properties": {
+                "end_line": {
+                    "type": "integer",
+                    "example": 139
+                },
+                "expires_in": {
+                    "type": "string",
+                    "example": "1w"
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "start_line": {
+                    "type": "integer",
+                    "example": 120
+                },
+                "title": {
+                    "type": "string",
+                    "example": "The failing test"
+                }
+            }
+        },
+        "handler.StorageEventsResult": {
+            "type": "object",
+            "properties": {
+                "archived": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "deleted": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "ignored": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "restored": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "handler.TOTPCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "handler.TOTPEnrollmentResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+                },
+                "uri": {
+                    "description": "URI is the otpauth:// URI of the secret, shown as a QR code for authenticator apps to scan",
+                    "type": "string",
+                    "example": "otpauth://totp/Gisty:octocat?algorithm=SHA1\u0026digits=6\u0026issuer=Gisty\u0026period=30\u0026secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+                }
+            }
+        },
+        "handler.TrendingPaste": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "score": {
+                    "type": "number",
+                    "example": 42.5
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/xK9a2B"
+                }
+            }
+        },
+        "handler.TrendingResponse": {
+            "type": "object",
+            "properties": {
+                "half_life": {
+                    "type": "string",
+                    "example": "6h0m0s"
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handler.TrendingPaste"
+                    }
+                }
+            }
+        },
+        "handler.UnbanResponse": {
+            "type": "object",
+            "properties": {
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "lifted": {
+                    "description": "false when the IP was not banned",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handler.UnblockIPResponse": {
+            "type": "object",
+            "properties": {
+                "cidr": {
+                    "type": "string",
+                    "example": "198.51.100.0/24"
+                },
+                "lifted": {
+                    "description": "false when the CIDR was not blocked at runtime",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "handler.UpdatePasteRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "example": "console.log('Hello again!')"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "javascript"
+                }
+            }
+        },
+        "handler.UpdatePasteResponse": {
+            "type": "object",
+            "properties": {
+                "revision": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:30:00Z"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "http://localhost:8080/xK9a2B"
+                }
+            }
+        },
+        "handler.UploadPartRequest": {
+            "type": "object",
+            "required": [
+                "sha256"
+            ],
+            "properties": {
+                "sha256": {
+                    "type": "string",
+                    "example": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+                }
+            }
+        },
+        "handler.UploadPartResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:15:00Z"
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "X-Amz-Checksum-Sha256": "n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="
+                    }
+                },
+                "method": {
+                    "type": "string",
+                    "example": "PUT"
+                },
+                "part_number": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 8388608
+                },
+                "upload_url": {
+                    "type": "string",
+                    "example": "https://s3.amazonaws.com/gisty/gisty/xK9a2B.gz?partNumber=1\u0026uploadId=..."
+                }
+            }
+        },
+        "handler.UploadSessionResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "part_count": {
+                    "type": "integer",
+                    "example": 13
+                },
+                "part_size": {
+                    "type": "integer",
+                    "example": 8388608
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 104857600
+                },
+                "uploaded_parts": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                }
+            }
+        },
+        "middleware.SLOReport": {
+            "type": "object",
+            "properties": {
+                "objectives": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/middleware.SLOStatus"
+                    }
+                },
+                "since": {
+                    "description": "when this instance started tracking",
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "status": {
+                    "description": "Status is the worst status of the objectives: critical when the error budget burns over\n14.4 times too fast in the last hour and 5 minutes, warning over 6 times in the last 6 hours\nand 30 minutes",
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "warning",
+                        "critical"
+                    ],
+                    "example": "ok"
+                }
+            }
+        },
+        "middleware.SLOStatus": {
+            "type": "object",
+            "properties": {
+                "objective": {
+                    "type": "string",
+                    "example": "GET /api/v1/pastes/:id"
+                },
+                "slo": {
+                    "type": "string",
+                    "enum": [
+                        "availability",
+                        "latency"
+                    ],
+                    "example": "availability"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "ok",
+                        "warning",
+                        "critical"
+                    ],
+                    "example": "ok"
+                },
+                "target": {
+                    "type": "number",
+                    "example": 0.999
+                },
+                "threshold": {
+                    "description": "latency objectives only",
+                    "type": "string",
+                    "example": "500ms"
+                },
+                "windows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/middleware.SLOWindow"
+                    }
+                }
+            }
+        },
+        "middleware.SLOWindow": {
+            "type": "object",
+            "properties": {
+                "burn_rate": {
+                    "description": "BurnRate is how many times faster than sustainable the error budget is spent: 1 spends it\nexactly over the SLO period, 0 when no request was served",
+                    "type": "number",
+                    "example": 0.3
+                },
+                "errors": {
+                    "description": "requests failing the objective",
+                    "type": "integer",
+                    "example": 36
+                },
+                "requests": {
+                    "type": "integer",
+                    "example": 120000
+                },
+                "window": {
+                    "type": "string",
+                    "example": "1h"
+                }
+            }
+        },
+        "model.Comment": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "short_id": {
+                    "type": "string"
+                },
+                "username": {
+                    "description": "Username is the author's username when the comment was written",
+                    "type": "string"
+                }
+            }
+        },
+        "model.Notification": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "comment_id": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "excerpt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "read_at": {
+                    "description": "ReadAt is set once the user marks the notification read",
+                    "type": "string"
+                },
+                "short_id": {
+                    "description": "ShortID and CommentID locate the comment; Actor is its author's username and Excerpt its\nbeginning",
+                    "type": "string"
+                }
+            }
+        },
+        "model.Report": {
+            "type": "object",
+            "properties": {
+                "contact": {
+                    "description": "Contact is how the reporter can be reached, e.g., the email of a copyright holder",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "note": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resolution": {
+                    "description": "Resolution, Note and ResolvedAt are set once an operator resolves the report",
+                    "type": "string"
+                },
+                "resolved_at": {
+                    "type": "string"
+                },
+                "short_id": {
+                    "type": "string"
+                },
+                "source_ip_hash": {
+                    "description": "SourceIPHash is the keyed hash of the reporter's IP, when IP hashing is configured",
+                    "type": "string"
+                }
+            }
+        },
+        "model.User": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "email_digest": {
+                    "description": "EmailDigest opts the user in to emails listing their unread notifications",
+                    "type": "boolean"
+                },
+                "email_verified": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_login_at": {
+                    "type": "string"
+                },
+                "login": {
+                    "description": "Profile copied from the provider at each sign-in",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "profile_hidden": {
+                    "description": "ProfileHidden disables the public profile, which lists the user's public pastes",
+                    "type": "boolean"
+                },
+                "provider": {
+                    "description": "Provider and ProviderID identify the account at the OAuth provider the user signs in with",
+                    "type": "string"
+                },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
+                "username": {
+                    "description": "Username names the user's public profile, /u/{username}: unique and lowercase, chosen from\nthe provider login or email at the first sign-in",
+                    "type": "string"
+                }
+            }
+        },
+        "service.BulkDeleteRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "xK9a2B",
+                        "p7Qm3Z"
+                    ]
+                }
+            }
+        },
+        "service.BulkDeleteResponse": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "short_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "service.BulkGetRequest": {
+            "type": "object",
+            "required": [
+                "ids"
+            ],
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "xK9a2B",
+                        "p7Qm3Z"
+                    ]
+                },
+                "max_bytes": {
+                    "description": "MaxBytes caps the returned content of each paste at this many bytes (0 returns full\ncontent); the server's default cap applies when omitted",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 4096
+                }
+            }
+        },
+        "service.CommentsResponse": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Comment"
+                    }
+                },
+                "next": {
+                    "description": "Next is the before cursor of the next page; empty on the last page",
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"
+                }
+            }
+        },
+        "service.CorruptPaste": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "checksum mismatch"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                }
+            }
+        },
+        "service.CreateCommentRequest": {
+            "type": "object",
+            "required": [
+                "body"
+            ],
+            "properties": {
+                "body": {
+                    "description": "Body is the comment; @username mentions notify those users",
+                    "type": "string",
+                    "example": "Nice, but @octocat has a faster version"
+                }
+            }
+        },
+        "service.DailyCreations": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2024-01-15"
+                }
+            }
+        },
+        "service.IPBan": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "ip": {
+                    "type": "string",
+                    "example": "203.0.113.7"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "spam"
+                }
+            }
+        },
+        "service.IPBlock": {
+            "type": "object",
+            "properties": {
+                "cidr": {
+                    "type": "string",
+                    "example": "198.51.100.0/24"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "abusive crawler"
+                },
+                "source": {
+                    "description": "config, file or redis",
+                    "type": "string",
+                    "example": "redis"
+                }
+            }
+        },
+        "service.KeyPoolStats": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer",
+                    "example": 5000
+                },
+                "unused": {
+                    "type": "integer",
+                    "example": 4200
+                },
+                "used": {
+                    "type": "integer",
+                    "example": 800
+                }
+            }
+        },
+        "service.ListPastesResponse": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string",
+                    "example": "2024-01-15T00:00:00Z"
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PasteSummary"
+                    }
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2024-01-16T00:00:00Z"
+                },
+                "truncated": {
+                    "description": "Truncated is set when more pastes match than the limit allowed",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "service.LookupResponse": {
+            "type": "object",
+            "properties": {
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PasteSummary"
+                    }
+                },
+                "sha256": {
+                    "type": "string",
+                    "example": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+                },
+                "truncated": {
+                    "description": "Truncated is set when more pastes match than the limit allowed",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "service.MarkReadRequest": {
+            "type": "object",
+            "properties": {
+                "all": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "ids": {
+                    "description": "IDs lists the notifications to mark read; All marks every notification read instead",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "65a4f1c2e4b0a1b2c3d4e5f6"
+                    ]
+                }
+            }
+        },
+        "service.MarkReadResponse": {
+            "type": "object",
+            "properties": {
+                "marked": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "unread": {
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "service.NotificationsResponse": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "description": "Next is the before cursor of the next page; empty on the last page",
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"
+                },
+                "notifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Notification"
+                    }
+                },
+                "unread": {
+                    "description": "Unread counts all the unread notifications of the user",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "service.PasteSummary": {
+            "type": "object",
+            "properties": {
+                "burn_after_read": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "is_encrypted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "source_ip_hash": {
+                    "type": "string",
+                    "example": "5e884898da28047151d0e56f8dc62927"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "go",
+                        "ops"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Deploy script"
+                }
+            }
+        },
+        "service.PinsResponse": {
+            "type": "object",
+            "properties": {
+                "pinned": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PasteSummary"
+                    }
+                }
+            }
+        },
+        "service.ProfileResponse": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string",
+                    "example": "https://www.gravatar.com/avatar/84059b07d4be67b806386c0aad8070a23f18836bbaae342275dc0a83414c32ee?s=160\u0026d=identicon"
+                },
+                "gravatar_hash": {
+                    "description": "GravatarHash is the SHA-256 hash of the user's email, for Gravatar; the email stays private",
+                    "type": "string",
+                    "example": "84059b07d4be67b806386c0aad8070a23f18836bbaae342275dc0a83414c32ee"
+                },
+                "joined_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "The Octocat"
+                },
+                "next": {
+                    "description": "Next is the before cursor of the next page; empty on the last page",
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PasteSummary"
+                    }
+                },
+                "pinned": {
+                    "description": "Pinned lists the pastes the user pinned to the top of the profile, on its first page only",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PasteSummary"
+                    }
+                },
+                "totals": {
+                    "$ref": "#/definitions/service.ProfileTotals"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "octocat"
+                }
+            }
+        },
+        "service.ProfileTotals": {
+            "type": "object",
+            "properties": {
+                "pastes": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "views": {
+                    "type": "integer",
+                    "example": 1337
+                }
+            }
+        },
+        "service.QuarantineReport": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count is the number of quarantined pastes; Pastes lists up to the limit of them, most\nrecently quarantined first",
+                    "type": "integer",
+                    "example": 1
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.QuarantinedPaste"
+                    }
+                },
+                "truncated": {
+                    "description": "Truncated is set when more pastes are quarantined than the limit allowed",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "service.QuarantinedPaste": {
+            "type": "object",
+            "properties": {
+                "burn_after_read": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "is_encrypted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "quarantined_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "checksum mismatch"
+                },
+                "short_id": {
+                    "type": "string",
                     "example": "xK9a2B"
                 },
+                "source_ip_hash": {
+                    "type": "string",
+                    "example": "5e884898da28047151d0e56f8dc62927"
+                },
                 "syntax_type": {
                     "type": "string",
-                    "example": "javascript"
+                    "example": "go"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "go",
+                        "ops"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Deploy script"
                 }
             }
         },
-        "handler.HealthResponse": {
+        "service.ReportPasteRequest": {
             "type": "object",
+            "required": [
+                "reason"
+            ],
             "properties": {
-                "status": {
+                "contact": {
+                    "description": "Contact is how operators can reach the reporter, e.g., for copyright claims",
                     "type": "string",
-                    "example": "ok"
+                    "example": "legal@example.com"
                 },
-                "timestamp": {
+                "details": {
+                    "type": "string",
+                    "example": "This is my copyrighted code, published without permission."
+                },
+                "reason": {
+                    "description": "Reason is one of spam, malware, phishing, copyright, personal_data, illegal or other",
+                    "type": "string",
+                    "example": "copyright"
+                }
+            }
+        },
+        "service.ReportsResponse": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "description": "Next is the before cursor of the next page; empty on the last page",
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"
+                },
+                "open": {
+                    "description": "Open counts all the reports awaiting review",
+                    "type": "integer",
+                    "example": 3
+                },
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Report"
+                    }
+                }
+            }
+        },
+        "service.ResolveReportRequest": {
+            "type": "object",
+            "required": [
+                "action"
+            ],
+            "properties": {
+                "action": {
+                    "description": "Action is dismiss to leave the paste as is, hide to quarantine it or delete to delete it",
+                    "type": "string",
+                    "example": "hide"
+                },
+                "note": {
+                    "description": "Note records why, for the moderation record",
+                    "type": "string",
+                    "example": "Valid DMCA notice"
+                }
+            }
+        },
+        "service.ResolveReportResponse": {
+            "type": "object",
+            "properties": {
+                "report": {
+                    "$ref": "#/definitions/model.Report"
+                },
+                "resolved": {
+                    "description": "Resolved counts the reports resolved: hiding or deleting a paste resolves all its open reports",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "service.ScreenedPaste": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "flag"
+                },
+                "burn_after_read": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "is_encrypted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "reasons": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "domains: link to spam.example"
+                    ]
+                },
+                "score": {
+                    "type": "integer",
+                    "example": 50
+                },
+                "screened_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "source_ip_hash": {
+                    "type": "string",
+                    "example": "5e884898da28047151d0e56f8dc62927"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "go",
+                        "ops"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Deploy script"
+                }
+            }
+        },
+        "service.ScreeningReport": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count is the number of screened pastes awaiting review; Pastes lists up to the limit of\nthem, most recent first",
+                    "type": "integer",
+                    "example": 1
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ScreenedPaste"
+                    }
+                },
+                "truncated": {
+                    "description": "Truncated is set when more pastes await review than the limit allowed",
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "service.StalePaste": {
+            "type": "object",
+            "properties": {
+                "burn_after_read": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2024-01-16T14:00:00Z"
+                },
+                "is_encrypted": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_private": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "last_read_at": {
+                    "description": "LastReadAt is empty for pastes never read since read times were recorded",
+                    "type": "string",
+                    "example": "2023-06-01T10:00:00Z"
+                },
+                "short_id": {
+                    "type": "string",
+                    "example": "xK9a2B"
+                },
+                "size": {
+                    "description": "Size is the stored size of the current content in bytes, 0 when it could not be read",
+                    "type": "integer",
+                    "example": 2048
+                },
+                "source_ip_hash": {
+                    "type": "string",
+                    "example": "5e884898da28047151d0e56f8dc62927"
+                },
+                "syntax_type": {
+                    "type": "string",
+                    "example": "go"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "go",
+                        "ops"
+                    ]
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Deploy script"
+                }
+            }
+        },
+        "service.StaleReport": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count is the number of stale pastes; Pastes lists up to the limit of them, oldest first",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "cutoff": {
+                    "type": "string",
+                    "example": "2023-07-19T14:00:00Z"
+                },
+                "days": {
+                    "type": "integer",
+                    "example": 180
+                },
+                "estimated_total_size": {
+                    "description": "EstimatedTotalSize extrapolates TotalSize to all Count stale pastes from the average size of\nthe listed ones whose size was read; it equals TotalSize when all of them were",
+                    "type": "integer",
+                    "example": 29491200
+                },
+                "generated_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.StalePaste"
+                    }
+                },
+                "total_size": {
+                    "description": "TotalSize is the stored size of the listed pastes in bytes, without their revisions",
+                    "type": "integer",
+                    "example": 2457600
+                },
+                "truncated": {
+                    "description": "Truncated is set when more pastes are stale than the limit allowed",
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "service.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "created_per_day": {
+                    "description": "CreatedPerDay counts the stored pastes created on each of the last days, oldest first and\ntoday included; pastes deleted or expired since are not counted",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.DailyCreations"
+                    }
+                },
+                "expired_pending": {
+                    "description": "ExpiredPending counts expired pastes the MongoDB TTL monitor has not removed yet",
+                    "type": "integer",
+                    "example": 3
+                },
+                "key_pool": {
+                    "$ref": "#/definitions/service.KeyPoolStats"
+                },
+                "pastes_total": {
+                    "type": "integer",
+                    "example": 1234
+                }
+            }
+        },
+        "service.UserPastesResponse": {
+            "type": "object",
+            "properties": {
+                "next": {
+                    "description": "Next is the before cursor of the next page; empty on the last page",
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"
+                },
+                "pastes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PasteSummary"
+                    }
+                },
+                "pinned": {
+                    "description": "Pinned lists the pastes pinned to the top of a public profile, on its first page only; they\nare not repeated in pastes",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PasteSummary"
+                    }
+                }
+            }
+        },
+        "service.VerifyRun": {
+            "type": "object",
+            "properties": {
+                "corrupt": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.CorruptPaste"
+                    }
+                },
+                "failures": {
+                    "description": "Failures counts the pastes whose content could not be downloaded; they are checked again\nwhen sampled by a later run",
+                    "type": "integer",
+                    "example": 0
+                },
+                "quarantined": {
+                    "description": "whether the corrupt pastes were quarantined",
+                    "type": "boolean",
+                    "example": true
+                },
+                "sampled": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "started_at": {
+                    "type": "string",
+                    "example": "2024-01-15T14:00:00Z"
+                },
+                "verified": {
+                    "description": "pastes whose content decompressed and matched its checksum",
+                    "type": "integer",
+                    "example": 19
+                }
+            }
+        },
+        "version.Info": {
+            "type": "object",
+            "properties": {
+                "build_date": {
                     "type": "string",
                     "example": "2024-01-15T14:00:00Z"
+                },
+                "commit": {
+                    "type": "string",
+                    "example": "a1b2c3d"
+                },
+                "go_version": {
+                    "type": "string",
+                    "example": "go1.24.11"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.2.3"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "APIKey": {
+            "description": "Optional API key (API_KEYS) raising rate limits and owning the pastes created with it; unknown keys are rejected with 401",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "AdminToken": {
+            "description": "Admin token of the /admin routes (ADMIN_TOKEN)",
+            "type": "apiKey",
+            "name": "X-Admin-Token",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "Admin token, the S3 events token of /storage/events, or the session token of a signed-in user, sent as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
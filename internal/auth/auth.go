@@ -0,0 +1,161 @@
+// Package auth signs users in with OAuth providers (GitHub, Google) or with an email and a
+// password, issues the session tokens that identify them on later requests and checks the
+// two-factor codes of their destructive actions.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+// providerTimeout bounds each call to an OAuth provider
+const providerTimeout = 10 * time.Second
+
+var (
+	// ErrUnknownProvider is returned for a provider that is not configured
+	ErrUnknownProvider = errors.New("auth: unknown provider")
+	// ErrInvalidState is returned when the state of a login callback does not match the one the
+	// login started with, as for a forged callback
+	ErrInvalidState = errors.New("auth: invalid state")
+	// ErrCodeRejected is returned when the provider rejects the authorization code or its token
+	ErrCodeRejected = errors.New("auth: authorization rejected")
+	// ErrProviderUnavailable is returned when the provider fails or cannot be reached
+	ErrProviderUnavailable = errors.New("auth: provider unavailable")
+	// ErrInvalidSession is returned for a session token that was not issued by this instance
+	ErrInvalidSession = errors.New("auth: invalid session")
+	// ErrSessionExpired is returned for an expired session token
+	ErrSessionExpired = fmt.Errorf("%w: expired", ErrInvalidSession)
+	// ErrWeakSessionSecret is returned for a session signing secret too short to be safe
+	ErrWeakSessionSecret = errors.New("auth: session secret too short")
+)
+
+// Authenticator signs users in with the configured providers, records them in the users
+// collection and issues their session tokens
+type Authenticator struct {
+	providers map[string]*Provider
+	users     *repository.UserRepository
+	sessions  *Sessions
+	baseURL   string
+
+	// passwords is set when users also register and sign in with an email and a password
+	passwords *passwordAccounts
+	// totp counts wrong two-factor codes; set when users may turn on two-factor authentication
+	totp *loginThrottle
+	// store records sessions and API tokens; nil leaves session tokens stateless
+	store *sessionStore
+}
+
+// NewAuthenticator creates an Authenticator whose providers redirect back to the login
+// callbacks under baseURL, the public URL of the API server
+func NewAuthenticator(users *repository.UserRepository, sessions *Sessions, baseURL string) *Authenticator {
+	return &Authenticator{
+		providers: make(map[string]*Provider),
+		users:     users,
+		sessions:  sessions,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// AddProvider enables signing in with p
+func (a *Authenticator) AddProvider(p *Provider) {
+	a.providers[p.Name()] = p
+}
+
+// Providers returns the names of the configured providers, sorted
+func (a *Authenticator) Providers() []string {
+	names := make([]string, 0, len(a.providers))
+	for name := range a.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoginURL returns the URL of the provider's consent page, for a login started with state
+func (a *Authenticator) LoginURL(provider, state string) (string, error) {
+	p, ok := a.providers[provider]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+	return p.AuthCodeURL(state, a.callbackURL(provider)), nil
+}
+
+// CompleteLogin handles the callback of a login: it checks the state the callback carries
+// against the one the login started with, trades the code for the account, records the user and
+// returns it with a new session token, recorded as used from client, and the time the token
+// expires
+func (a *Authenticator) CompleteLogin(ctx context.Context, provider, code, state, expectedState string, client Client) (*model.User, string, time.Time, error) {
+	p, ok := a.providers[provider]
+	if !ok {
+		return nil, "", time.Time{}, fmt.Errorf("%w: %q", ErrUnknownProvider, provider)
+	}
+	if state == "" || expectedState == "" || state != expectedState {
+		return nil, "", time.Time{}, ErrInvalidState
+	}
+	if code == "" {
+		return nil, "", time.Time{}, fmt.Errorf("%w: no code", ErrCodeRejected)
+	}
+
+	accessToken, err := p.Exchange(ctx, code, a.callbackURL(provider))
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	account, err := p.Account(ctx, accessToken)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	user, err := a.users.UpsertByProvider(ctx, account, time.Now())
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("auth: failed to record user: %w", err)
+	}
+	if user.Username == "" {
+		// The user can sign in without one, and gets one at the next sign-in
+		if err := a.assignUsername(ctx, user); err != nil {
+			log.Printf("[Authenticator.CompleteLogin] Failed to assign a username to %s: %v", user.ID, err)
+		}
+	}
+
+	token, expiresAt, err := a.issueSession(ctx, user.ID, client)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("auth: failed to issue session: %w", err)
+	}
+	log.Printf("[Authenticator.CompleteLogin] User %s signed in with %s", user.ID, provider)
+	return user, token, expiresAt, nil
+}
+
+// User returns a signed-in user
+func (a *Authenticator) User(ctx context.Context, id string) (*model.User, error) {
+	return a.users.GetByID(ctx, id)
+}
+
+// UserByUsername returns the user with a username, as found in profile URLs
+func (a *Authenticator) UserByUsername(ctx context.Context, username string) (*model.User, error) {
+	return a.users.GetByUsername(ctx, strings.ToLower(username))
+}
+
+// UpdateSettings changes the profile and notification settings of a user given as non-nil
+func (a *Authenticator) UpdateSettings(ctx context.Context, id string, profileHidden, emailDigest *bool) (*model.User, error) {
+	return a.users.UpdateSettings(ctx, id, profileHidden, emailDigest)
+}
+
+// callbackURL returns the URL the provider sends the user back to after consent
+func (a *Authenticator) callbackURL(provider string) string {
+	return a.baseURL + "/auth/" + provider + "/callback"
+}
+
+// NewState returns a random login state, binding the callback to the browser that started the login
+func NewState() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,315 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	gistymail "github.com/huylvt/gisty/internal/mail"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordProvider is the provider of the accounts signing in with an email and a password
+	PasswordProvider = "password"
+	// MinPasswordLength and MaxPasswordLength bound passwords, in bytes; bcrypt reads at most 72
+	MinPasswordLength = 10
+	MaxPasswordLength = 72
+	// VerificationTTL is the time an email verification link stays valid
+	VerificationTTL = 24 * time.Hour
+	// PasswordResetTTL is the time a password reset link stays valid
+	PasswordResetTTL = time.Hour
+	// mailInterval spaces the verification and reset emails sent to an account
+	mailInterval = time.Minute
+
+	// Purposes of the one-time tokens sent by email
+	purposeVerify = "verify"
+	purposeReset  = "reset"
+)
+
+var (
+	// ErrPasswordsDisabled is returned when password accounts are not enabled
+	ErrPasswordsDisabled = errors.New("auth: password accounts disabled")
+	// ErrInvalidEmail is returned for a malformed email
+	ErrInvalidEmail = errors.New("auth: invalid email")
+	// ErrWeakPassword is returned for a password shorter than MinPasswordLength or longer than
+	// MaxPasswordLength
+	ErrWeakPassword = errors.New("auth: password too short or too long")
+	// ErrEmailTaken is returned when registering an email that has an account
+	ErrEmailTaken = errors.New("auth: email taken")
+	// ErrInvalidCredentials is returned for an unknown email or a wrong password; the two are
+	// not told apart
+	ErrInvalidCredentials = errors.New("auth: invalid email or password")
+	// ErrEmailNotVerified is returned when signing in before following the verification link
+	ErrEmailNotVerified = errors.New("auth: email not verified")
+)
+
+// dummyHash is compared against when signing in to an unknown email, so that the response time
+// does not tell which emails have an account
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("gisty-dummy-password"), bcrypt.DefaultCost)
+
+// passwordAccounts holds what password accounts need: the Redis store of the tokens sent by email
+// and of the sign-in counters, and the mailer sending the links
+type passwordAccounts struct {
+	client   *redis.Client
+	tokens   *tokenStore
+	throttle *loginThrottle
+	mailer   gistymail.Mailer
+}
+
+// EnablePasswords lets users register and sign in with an email and a password. Emails are
+// verified, and passwords reset, with links sent through mailer; tokens and sign-in counters are
+// kept in Redis.
+func (a *Authenticator) EnablePasswords(client *redis.Client, mailer gistymail.Mailer) {
+	a.passwords = &passwordAccounts{
+		client:   client,
+		tokens:   &tokenStore{client: client},
+		throttle: &loginThrottle{client: client},
+		mailer:   mailer,
+	}
+}
+
+// PasswordsEnabled reports whether users register and sign in with an email and a password
+func (a *Authenticator) PasswordsEnabled() bool {
+	return a.passwords != nil
+}
+
+// Register creates a password account and emails the link verifying its email. The account
+// signs in once the email is verified.
+func (a *Authenticator) Register(ctx context.Context, email, password, name string) (*model.User, error) {
+	if a.passwords == nil {
+		return nil, ErrPasswordsDisabled
+	}
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPassword(password); err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("auth: failed to hash password: %w", err)
+	}
+
+	user := &model.User{
+		Provider:     PasswordProvider,
+		ProviderID:   email,
+		Email:        email,
+		Name:         strings.TrimSpace(name),
+		PasswordHash: string(hash),
+	}
+	if err := a.users.Create(ctx, user, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrUserExists) {
+			return nil, ErrEmailTaken
+		}
+		return nil, fmt.Errorf("auth: failed to create user: %w", err)
+	}
+	if err := a.assignUsername(ctx, user); err != nil {
+		log.Printf("[Authenticator.Register] Failed to assign a username to %s: %v", user.ID, err)
+	}
+
+	// The account exists even when the email fails: the user asks for another one
+	if err := a.sendVerification(ctx, user); err != nil {
+		log.Printf("[Authenticator.Register] Failed to send the verification email of %s: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+// PasswordLogin signs a user in with an email and a password from client, returning the user
+// with a new session token and the time it expires. Failed sign-ins lock the account, and
+// attempts throttle the client, for LockoutDuration.
+func (a *Authenticator) PasswordLogin(ctx context.Context, email, password string, client Client) (*model.User, string, time.Time, error) {
+	if a.passwords == nil {
+		return nil, "", time.Time{}, ErrPasswordsDisabled
+	}
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, "", time.Time{}, ErrInvalidCredentials
+	}
+	if err := a.passwords.throttle.attempt(ctx, email, client.IP); err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	user, err := a.users.GetByProvider(ctx, PasswordProvider, email)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, "", time.Time{}, fmt.Errorf("auth: failed to get user: %w", err)
+	}
+	hash := dummyHash
+	if user != nil {
+		hash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || user == nil {
+		if err := a.passwords.throttle.fail(ctx, email); err != nil {
+			log.Printf("[Authenticator.PasswordLogin] Failed to count a failed sign-in: %v", err)
+		}
+		return nil, "", time.Time{}, ErrInvalidCredentials
+	}
+	if err := a.passwords.throttle.reset(ctx, email); err != nil {
+		log.Printf("[Authenticator.PasswordLogin] Failed to reset failed sign-ins: %v", err)
+	}
+	if !user.EmailVerified {
+		return nil, "", time.Time{}, ErrEmailNotVerified
+	}
+
+	now := time.Now()
+	if err := a.users.RecordLogin(ctx, user.ID, now); err != nil {
+		log.Printf("[Authenticator.PasswordLogin] Failed to record the sign-in of %s: %v", user.ID, err)
+	}
+	user.LastLoginAt = now
+	token, expiresAt, err := a.issueSession(ctx, user.ID, client)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("auth: failed to issue session: %w", err)
+	}
+	log.Printf("[Authenticator.PasswordLogin] User %s signed in with a password", user.ID)
+	return user, token, expiresAt, nil
+}
+
+// VerifyEmail marks verified the email of the account a verification token was sent for
+func (a *Authenticator) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	if a.passwords == nil {
+		return nil, ErrPasswordsDisabled
+	}
+	userID, err := a.passwords.tokens.consume(ctx, purposeVerify, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.users.SetEmailVerified(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("auth: failed to verify email: %w", err)
+	}
+	return a.users.GetByID(ctx, userID)
+}
+
+// ResendVerification emails another verification link to the password account of an email that
+// is not verified yet. Other emails are ignored, so the response does not tell which have an
+// account.
+func (a *Authenticator) ResendVerification(ctx context.Context, email string) error {
+	user, err := a.passwordUser(ctx, email)
+	if err != nil || user == nil || user.EmailVerified {
+		return err
+	}
+	return a.sendVerification(ctx, user)
+}
+
+// RequestPasswordReset emails a password reset link to the password account of an email. Other
+// emails are ignored, so the response does not tell which have an account.
+func (a *Authenticator) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := a.passwordUser(ctx, email)
+	if err != nil || user == nil {
+		return err
+	}
+	if ok, err := spaceMail(ctx, a.passwords.client, purposeReset, user.ID, mailInterval); err != nil || !ok {
+		return err
+	}
+
+	token, err := a.passwords.tokens.issue(ctx, purposeReset, user.ID, PasswordResetTTL)
+	if err != nil {
+		return fmt.Errorf("auth: failed to issue reset token: %w", err)
+	}
+	link := a.baseURL + "/auth/password/reset?token=" + url.QueryEscape(token)
+	body := "Someone asked to reset the password of your Gisty account.\n\n" +
+		"Choose a new password within an hour at:\n  " + link + "\n\n" +
+		"If it was not you, ignore this email: your password stays unchanged.\n"
+	return a.passwords.mailer.Send(ctx, user.Email, "Reset your Gisty password", body)
+}
+
+// ResetPassword sets a new password on the account a reset token was sent for. Following the
+// link proves the email, so it is marked verified, and the account is unlocked.
+func (a *Authenticator) ResetPassword(ctx context.Context, token, password string) error {
+	if a.passwords == nil {
+		return ErrPasswordsDisabled
+	}
+	if err := checkPassword(password); err != nil {
+		return err
+	}
+	userID, err := a.passwords.tokens.consume(ctx, purposeReset, token)
+	if err != nil {
+		return err
+	}
+	user, err := a.users.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return ErrInvalidToken
+		}
+		return fmt.Errorf("auth: failed to get user: %w", err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("auth: failed to hash password: %w", err)
+	}
+	if err := a.users.SetPasswordHash(ctx, userID, string(hash), true); err != nil {
+		return fmt.Errorf("auth: failed to set password: %w", err)
+	}
+	if err := a.passwords.throttle.reset(ctx, user.ProviderID); err != nil {
+		log.Printf("[Authenticator.ResetPassword] Failed to unlock %s: %v", userID, err)
+	}
+	log.Printf("[Authenticator.ResetPassword] User %s reset their password", userID)
+	return nil
+}
+
+// passwordUser returns the password account of an email, nil when there is none
+func (a *Authenticator) passwordUser(ctx context.Context, email string) (*model.User, error) {
+	if a.passwords == nil {
+		return nil, ErrPasswordsDisabled
+	}
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	user, err := a.users.GetByProvider(ctx, PasswordProvider, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("auth: failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// sendVerification emails the link verifying the email of a password account, at most one every
+// mailInterval
+func (a *Authenticator) sendVerification(ctx context.Context, user *model.User) error {
+	if ok, err := spaceMail(ctx, a.passwords.client, purposeVerify, user.ID, mailInterval); err != nil || !ok {
+		return err
+	}
+
+	token, err := a.passwords.tokens.issue(ctx, purposeVerify, user.ID, VerificationTTL)
+	if err != nil {
+		return fmt.Errorf("auth: failed to issue verification token: %w", err)
+	}
+	link := a.baseURL + "/auth/email/verify?token=" + url.QueryEscape(token)
+	body := "Welcome to Gisty!\n\nVerify your email within 24 hours to sign in:\n  " + link + "\n\n" +
+		"If you did not create an account, ignore this email.\n"
+	return a.passwords.mailer.Send(ctx, user.Email, "Verify your Gisty email", body)
+}
+
+// normalizeEmail returns an email lowercased, or ErrInvalidEmail when it is not a bare address
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// checkPassword returns ErrWeakPassword for a password outside the accepted lengths
+func checkPassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength || !utf8.ValidString(password) {
+		return ErrWeakPassword
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"octocat@example.com", "octocat@example.com", false},
+		{"  OctoCat@Example.COM ", "octocat@example.com", false},
+		{"The Octocat <octocat@example.com>", "", true},
+		{"octocat", "", true},
+		{"octocat@example.com\r\nBcc: x@example.com", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeEmail(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("normalizeEmail(%q) = %q, %v, want %q (error %v)", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{strings.Repeat("a", MinPasswordLength), false},
+		{strings.Repeat("a", MaxPasswordLength), false},
+		{strings.Repeat("a", MinPasswordLength-1), true},
+		{strings.Repeat("a", MaxPasswordLength+1), true},
+		{"\xff\xfe" + strings.Repeat("a", MinPasswordLength), true},
+	}
+
+	for _, tt := range tests {
+		if err := checkPassword(tt.password); (err != nil) != tt.wantErr {
+			t.Errorf("checkPassword(%q) error = %v, want error %v", tt.password, err, tt.wantErr)
+		}
+	}
+}
+
+func setupTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("Redis not available: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestTokenStore(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+	tokens := &tokenStore{client: client}
+
+	token, err := tokens.issue(ctx, purposeVerify, "user1", time.Minute)
+	if err != nil {
+		t.Fatalf("issue() error = %v", err)
+	}
+	// Tokens only work for their purpose
+	if _, err := tokens.consume(ctx, purposeReset, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("consume(reset) error = %v, want %v", err, ErrInvalidToken)
+	}
+	if userID, err := tokens.consume(ctx, purposeVerify, token); err != nil || userID != "user1" {
+		t.Errorf("consume() = %q, %v, want user1", userID, err)
+	}
+	// and once
+	if _, err := tokens.consume(ctx, purposeVerify, token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("consume() again error = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := tokens.consume(ctx, purposeVerify, ""); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("consume(empty) error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestLoginThrottle(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+	throttle := &loginThrottle{client: client}
+	account, ip := "throttle-test@example.com", "203.0.113.9"
+	cleanup := func() {
+		client.Del(ctx, loginKeyPrefix+"failures:"+account, loginKeyPrefix+"lock:"+account, loginKeyPrefix+"ip:"+ip)
+	}
+	cleanup()
+	defer cleanup()
+
+	for i := 0; i < MaxLoginFailures; i++ {
+		if err := throttle.attempt(ctx, account, ""); err != nil {
+			t.Fatalf("attempt() #%d error = %v", i+1, err)
+		}
+		if err := throttle.fail(ctx, account); err != nil {
+			t.Fatalf("fail() #%d error = %v", i+1, err)
+		}
+	}
+	var retry *RetryError
+	err := throttle.attempt(ctx, account, "")
+	if !errors.As(err, &retry) || !errors.Is(err, ErrAccountLocked) || retry.RetryAfter <= 0 || retry.RetryAfter > LockoutDuration {
+		t.Fatalf("attempt() after %d failures error = %v, want %v", MaxLoginFailures, err, ErrAccountLocked)
+	}
+
+	// Resetting the password unlocks the account
+	if err := throttle.reset(ctx, account); err != nil {
+		t.Fatalf("reset() error = %v", err)
+	}
+	if err := throttle.attempt(ctx, account, ""); err != nil {
+		t.Errorf("attempt() after reset error = %v", err)
+	}
+
+	// A client is throttled whichever accounts it tries
+	for i := 0; i < MaxClientLogins; i++ {
+		if err := throttle.attempt(ctx, "other@example.com", ip); err != nil {
+			t.Fatalf("attempt() from %s #%d error = %v", ip, i+1, err)
+		}
+	}
+	if err := throttle.attempt(ctx, account, ip); !errors.Is(err, ErrLoginThrottled) {
+		t.Errorf("attempt() from %s error = %v, want %v", ip, err, ErrLoginThrottled)
+	}
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+const (
+	// ProviderGitHub signs users in with their GitHub account
+	ProviderGitHub = "github"
+	// ProviderGoogle signs users in with their Google account
+	ProviderGoogle = "google"
+
+	// maxProviderResponseSize bounds the token and profile answers read from a provider
+	maxProviderResponseSize = 1 << 20
+)
+
+// Provider runs the OAuth2 authorization code flow with an identity provider and reads the
+// profile of the signed-in account
+type Provider struct {
+	name         string
+	clientID     string
+	clientSecret string
+	authURL      string
+	tokenURL     string
+	profileURL   string
+	scopes       []string
+	// profile reads the account from the provider's profile answer
+	profile func(data []byte) (*model.User, error)
+	client  *http.Client
+}
+
+// NewGitHubProvider creates the GitHub provider of an OAuth app
+func NewGitHubProvider(clientID, clientSecret string) *Provider {
+	return &Provider{
+		name:         ProviderGitHub,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		authURL:      "https://github.com/login/oauth/authorize",
+		tokenURL:     "https://github.com/login/oauth/access_token",
+		profileURL:   "https://api.github.com/user",
+		scopes:       []string{"read:user", "user:email"},
+		profile:      githubProfile,
+		client:       &http.Client{Timeout: providerTimeout},
+	}
+}
+
+// NewGoogleProvider creates the Google provider of an OAuth client
+func NewGoogleProvider(clientID, clientSecret string) *Provider {
+	return &Provider{
+		name:         ProviderGoogle,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		authURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		tokenURL:     "https://oauth2.googleapis.com/token",
+		profileURL:   "https://openidconnect.googleapis.com/v1/userinfo",
+		scopes:       []string{"openid", "email", "profile"},
+		profile:      googleProfile,
+		client:       &http.Client{Timeout: providerTimeout},
+	}
+}
+
+// Name returns the name of the provider, as used in the login routes
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the URL of the provider's consent page, which sends the user back to
+// redirectURI with state and an authorization code
+func (p *Provider) AuthCodeURL(state, redirectURI string) string {
+	query := url.Values{
+		"client_id":     {p.clientID},
+		"redirect_uri":  {redirectURI},
+		"response_type": {"code"},
+		"scope":         {strings.Join(p.scopes, " ")},
+		"state":         {state},
+	}
+	return p.authURL + "?" + query.Encode()
+}
+
+// Exchange trades an authorization code for an access token
+func (p *Provider) Exchange(ctx context.Context, code, redirectURI string) (string, error) {
+	form := url.Values{
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"grant_type":    {"authorization_code"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHub answers form-encoded without it
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.do(req, &token); err != nil {
+		return "", err
+	}
+	// GitHub answers errors with status 200
+	if token.Error != "" || token.AccessToken == "" {
+		return "", fmt.Errorf("%w: %s %s", ErrCodeRejected, token.Error, token.ErrorDescription)
+	}
+	return token.AccessToken, nil
+}
+
+// Account returns the account the access token was granted for, as a user not yet stored
+func (p *Provider) Account(ctx context.Context, accessToken string) (*model.User, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.profileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var data json.RawMessage
+	if err := p.do(req, &data); err != nil {
+		return nil, err
+	}
+	user, err := p.profile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid profile: %v", ErrProviderUnavailable, err)
+	}
+	if user.ProviderID == "" {
+		return nil, fmt.Errorf("%w: profile without an account ID", ErrProviderUnavailable)
+	}
+	user.Provider = p.name
+	return user, nil
+}
+
+// do sends a request to the provider and decodes its JSON answer into out
+func (p *Provider) do(req *http.Request, out any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("%w: %s answered status %d", ErrCodeRejected, p.name, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%w: %s answered status %d", ErrProviderUnavailable, p.name, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxProviderResponseSize)).Decode(out); err != nil {
+		return fmt.Errorf("%w: invalid answer: %v", ErrProviderUnavailable, err)
+	}
+	return nil
+}
+
+// githubProfile reads a GitHub user
+func githubProfile(data []byte) (*model.User, error) {
+	var profile struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, err
+	}
+	user := &model.User{Login: profile.Login, Name: profile.Name, Email: profile.Email, AvatarURL: profile.AvatarURL}
+	// The login can be renamed, the numeric ID cannot
+	if profile.ID != 0 {
+		user.ProviderID = strconv.FormatInt(profile.ID, 10)
+	}
+	return user, nil
+}
+
+// googleProfile reads an OpenID Connect userinfo answer
+func googleProfile(data []byte) (*model.User, error) {
+	var profile struct {
+		Subject       string `json:"sub"`
+		Name          string `json:"name"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Picture       string `json:"picture"`
+	}
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, err
+	}
+	user := &model.User{ProviderID: profile.Subject, Name: profile.Name, AvatarURL: profile.Picture}
+	if profile.EmailVerified {
+		user.Email = profile.Email
+	}
+	return user, nil
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newTestProvider returns the GitHub provider calling server instead of GitHub
+func newTestProvider(server *httptest.Server) *Provider {
+	p := NewGitHubProvider("client", "secret")
+	p.authURL = server.URL + "/login/oauth/authorize"
+	p.tokenURL = server.URL + "/login/oauth/access_token"
+	p.profileURL = server.URL + "/user"
+	return p
+}
+
+func TestProvider_Login(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login/oauth/access_token":
+			if r.FormValue("code") != "good" || r.FormValue("client_secret") != "secret" {
+				// GitHub answers errors with status 200
+				_, _ = w.Write([]byte(`{"error":"bad_verification_code"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer"}`))
+		case "/user":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":42,"login":"octocat","name":"The Octocat","avatar_url":"https://example.com/a.png"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+	p := newTestProvider(server)
+	ctx := context.Background()
+
+	consent, err := url.Parse(p.AuthCodeURL("state1", "https://gisty.example/auth/github/callback"))
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	query := consent.Query()
+	if query.Get("state") != "state1" || query.Get("client_id") != "client" || query.Get("redirect_uri") != "https://gisty.example/auth/github/callback" {
+		t.Errorf("AuthCodeURL() query = %v", query)
+	}
+
+	if _, err := p.Exchange(ctx, "bad", ""); !errors.Is(err, ErrCodeRejected) {
+		t.Errorf("Exchange(bad) error = %v, want %v", err, ErrCodeRejected)
+	}
+	token, err := p.Exchange(ctx, "good", "")
+	if err != nil || token != "token" {
+		t.Fatalf("Exchange(good) = %q, %v, want token, nil", token, err)
+	}
+
+	user, err := p.Account(ctx, token)
+	if err != nil {
+		t.Fatalf("Account() error = %v", err)
+	}
+	if user.Provider != ProviderGitHub || user.ProviderID != "42" || user.Login != "octocat" || user.Name != "The Octocat" {
+		t.Errorf("Account() = %+v", user)
+	}
+	if _, err := p.Account(ctx, "revoked"); !errors.Is(err, ErrCodeRejected) {
+		t.Errorf("Account() with a revoked token error = %v, want %v", err, ErrCodeRejected)
+	}
+
+	server.Close()
+	if _, err := p.Exchange(ctx, "good", ""); !errors.Is(err, ErrProviderUnavailable) {
+		t.Errorf("Exchange() with the provider down error = %v, want %v", err, ErrProviderUnavailable)
+	}
+}
+
+func TestGoogleProfile(t *testing.T) {
+	user, err := googleProfile([]byte(`{"sub":"1234","name":"Ann","email":"ann@example.com","email_verified":false}`))
+	if err != nil {
+		t.Fatalf("googleProfile() error = %v", err)
+	}
+	// Unverified addresses are not recorded
+	if user.ProviderID != "1234" || user.Name != "Ann" || user.Email != "" {
+		t.Errorf("googleProfile() = %+v", user)
+	}
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultSessionTTL is the lifetime of a session token
+	DefaultSessionTTL = 30 * 24 * time.Hour
+	// MinSessionSecretLength is the shortest accepted session signing secret
+	MinSessionSecretLength = 32
+
+	// sessionIssuer is the issuer claim of session tokens
+	sessionIssuer = "gisty"
+)
+
+// sessionHeader is the JOSE header of every session token: only HS256 is issued and accepted
+var sessionHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// sessionClaims are the claims of a session token
+type sessionClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	ID        string `json:"jti,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sessions issues and verifies session tokens: JWTs signed with HS256 whose subject is a user
+// ID and whose ID names the session's record in the session store, when there is one. Any
+// replica sharing the secret verifies them.
+type Sessions struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewSessions creates Sessions signing tokens valid for ttl, DefaultSessionTTL when not positive,
+// with secret
+func NewSessions(secret string, ttl time.Duration) (*Sessions, error) {
+	if len(secret) < MinSessionSecretLength {
+		return nil, fmt.Errorf("%w: at least %d characters required", ErrWeakSessionSecret, MinSessionSecretLength)
+	}
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &Sessions{secret: []byte(secret), ttl: ttl, now: time.Now}, nil
+}
+
+// TTL returns the lifetime of the tokens issued
+func (s *Sessions) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue returns a session token of the user for the session sessionID, valid for ttl or the
+// configured lifetime when ttl is not positive, and the time it expires
+func (s *Sessions) Issue(userID, sessionID string, ttl time.Duration) (string, time.Time, error) {
+	if ttl <= 0 {
+		ttl = s.ttl
+	}
+	now := s.now()
+	expiresAt := now.Add(ttl)
+	claims, err := json.Marshal(sessionClaims{
+		Issuer:    sessionIssuer,
+		Subject:   userID,
+		ID:        sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	signed := sessionHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return signed + "." + s.sign(signed), expiresAt, nil
+}
+
+// Verify returns the user ID and session ID of a session token issued by Issue and not yet
+// expired
+func (s *Sessions) Verify(token string) (string, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != sessionHeader {
+		return "", "", ErrInvalidSession
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[0]+"."+parts[1]))) {
+		return "", "", ErrInvalidSession
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", "", ErrInvalidSession
+	}
+	var claims sessionClaims
+	if err := json.Unmarshal(data, &claims); err != nil || claims.Issuer != sessionIssuer || claims.Subject == "" {
+		return "", "", ErrInvalidSession
+	}
+	if s.now().Unix() >= claims.ExpiresAt {
+		return "", "", ErrSessionExpired
+	}
+	return claims.Subject, claims.ID, nil
+}
+
+// sign returns the signature of the encoded header and claims
+func (s *Sessions) sign(signed string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,399 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// SessionKindBrowser is a session signed in through a login; SessionKindToken is an API token
+	// created by the user
+	SessionKindBrowser = "session"
+	SessionKindToken   = "token"
+
+	// DefaultTokenTTL and MaxTokenTTL bound the lifetime of API tokens
+	DefaultTokenTTL = 90 * 24 * time.Hour
+	MaxTokenTTL     = 365 * 24 * time.Hour
+	// MaxTokens is the number of API tokens a user holds at most
+	MaxTokens = 20
+	// MaxTokenNameLength bounds the names of API tokens, in characters
+	MaxTokenNameLength = 64
+
+	// sessionKeyPrefix prefixes the Redis keys of session records; userSessionsKeyPrefix the
+	// sorted sets indexing the sessions of each user by expiry
+	sessionKeyPrefix      = "gisty:session:"
+	userSessionsKeyPrefix = "gisty:sessions:"
+	// sessionTouchInterval spaces the writes recording the last use of a session
+	sessionTouchInterval = time.Minute
+	// maxUserAgentLength bounds the user agents kept as device metadata
+	maxUserAgentLength = 256
+)
+
+var (
+	// ErrSessionRevoked is returned for the token of a session revoked or signed out
+	ErrSessionRevoked = fmt.Errorf("%w: revoked", ErrInvalidSession)
+	// ErrSessionsDisabled is returned when sessions are not recorded, so cannot be listed or revoked
+	ErrSessionsDisabled = errors.New("auth: session store disabled")
+	// ErrSessionNotFound is returned when revoking a session the user does not have
+	ErrSessionNotFound = errors.New("auth: session not found")
+	// ErrInvalidTokenName is returned for an API token name empty or longer than MaxTokenNameLength
+	ErrInvalidTokenName = errors.New("auth: invalid token name")
+	// ErrTooManyTokens is returned when creating more than MaxTokens API tokens
+	ErrTooManyTokens = errors.New("auth: too many tokens")
+)
+
+// Client describes the device a session is used from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// Session is the record of a session or API token: its device metadata and last use
+type Session struct {
+	ID     string `json:"id" example:"3f9c2a7b1e4d5c6a"`
+	UserID string `json:"-"`
+	// Kind is "session" for a sign-in, "token" for an API token
+	Kind string `json:"kind" example:"session"`
+	// Name is the name given to an API token
+	Name      string `json:"name,omitempty" example:"CI uploads"`
+	UserAgent string `json:"user_agent,omitempty" example:"Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"`
+	// CreatedIP is the client IP the session signed in or the token was created from
+	CreatedIP  string    `json:"created_ip,omitempty" example:"203.0.113.7"`
+	LastIP     string    `json:"last_ip,omitempty" example:"203.0.113.7"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// Current marks the session or token sending the request
+	Current bool `json:"current,omitempty"`
+}
+
+// sessionStore keeps session records in Redis, shared by all replicas, each expiring with its
+// token. A token whose record is gone is revoked.
+type sessionStore struct {
+	client *redis.Client
+}
+
+// create records a new session, assigning its ID
+func (s *sessionStore) create(ctx context.Context, session *Session) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	session.ID = hex.EncodeToString(b)
+	data, err := json.Marshal(storedSession{Session: session, UserID: session.UserID})
+	if err != nil {
+		return err
+	}
+
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, sessionKeyPrefix+session.ID, data, time.Until(session.ExpiresAt))
+	pipe.ZAdd(ctx, userSessionsKeyPrefix+session.UserID, redis.Z{Score: float64(session.ExpiresAt.Unix()), Member: session.ID})
+	// The index lives as long as the longest session of the user
+	pipe.ExpireNX(ctx, userSessionsKeyPrefix+session.UserID, time.Until(session.ExpiresAt))
+	pipe.ExpireGT(ctx, userSessionsKeyPrefix+session.UserID, time.Until(session.ExpiresAt))
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// get returns a session, nil when it expired or was revoked
+func (s *sessionStore) get(ctx context.Context, id string) (*Session, error) {
+	data, err := s.client.Get(ctx, sessionKeyPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeSession(data)
+}
+
+// touch records a use of a session from clientIP, at most once every sessionTouchInterval
+// unless the IP changed
+func (s *sessionStore) touch(ctx context.Context, session *Session, clientIP string, now time.Time) error {
+	if now.Sub(session.LastUsedAt) < sessionTouchInterval && (clientIP == "" || clientIP == session.LastIP) {
+		return nil
+	}
+	session.LastUsedAt = now
+	if clientIP != "" {
+		session.LastIP = clientIP
+	}
+	data, err := json.Marshal(storedSession{Session: session, UserID: session.UserID})
+	if err != nil {
+		return err
+	}
+	// XX leaves a session revoked meanwhile revoked
+	return s.client.SetArgs(ctx, sessionKeyPrefix+session.ID, data, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+}
+
+// list returns the sessions of a user that have not expired nor been revoked, newest first
+func (s *sessionStore) list(ctx context.Context, userID string) ([]*Session, error) {
+	indexKey := userSessionsKeyPrefix + userID
+	if err := s.client.ZRemRangeByScore(ctx, indexKey, "-inf", strconv.FormatInt(time.Now().Unix(), 10)).Err(); err != nil {
+		return nil, err
+	}
+	ids, err := s.client.ZRange(ctx, indexKey, 0, -1).Result()
+	if err != nil || len(ids) == 0 {
+		return []*Session{}, err
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionKeyPrefix + id
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]*Session, 0, len(values))
+	var revoked []any
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			revoked = append(revoked, ids[i])
+			continue
+		}
+		session, err := decodeSession([]byte(data))
+		if err != nil {
+			log.Printf("[sessionStore.list] Skipping session %s: %v", ids[i], err)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	if len(revoked) > 0 {
+		s.client.ZRem(ctx, indexKey, revoked...)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// revoke deletes sessions of a user, reporting how many existed
+func (s *sessionStore) revoke(ctx context.Context, userID string, ids ...string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	keys := make([]string, len(ids))
+	members := make([]any, len(ids))
+	for i, id := range ids {
+		keys[i] = sessionKeyPrefix + id
+		members[i] = id
+	}
+	pipe := s.client.TxPipeline()
+	deleted := pipe.Del(ctx, keys...)
+	pipe.ZRem(ctx, userSessionsKeyPrefix+userID, members...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return deleted.Val(), nil
+}
+
+// storedSession is the JSON of a session record, which keeps the user ID the API leaves out
+type storedSession struct {
+	*Session
+	UserID string `json:"user_id"`
+}
+
+// decodeSession returns the session of a record
+func decodeSession(data []byte) (*Session, error) {
+	stored := storedSession{Session: &Session{}}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+	stored.Session.UserID = stored.UserID
+	return stored.Session, nil
+}
+
+// EnableSessionStore records sessions in Redis with the device they are used from, so that users
+// list and revoke them and create API tokens. Tokens then only verify while their record exists;
+// tokens issued without a store are rejected.
+func (a *Authenticator) EnableSessionStore(client *redis.Client) {
+	a.store = &sessionStore{client: client}
+}
+
+// issueSession records a new sign-in of a user from client, returning its session token and the
+// time it expires
+func (a *Authenticator) issueSession(ctx context.Context, userID string, client Client) (string, time.Time, error) {
+	if a.store == nil {
+		return a.sessions.Issue(userID, "", 0)
+	}
+	session, err := a.recordSession(ctx, userID, SessionKindBrowser, "", a.sessions.TTL(), client)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return a.sessions.Issue(userID, session.ID, a.sessions.TTL())
+}
+
+// recordSession stores a new session or API token of a user, valid for ttl
+func (a *Authenticator) recordSession(ctx context.Context, userID, kind, name string, ttl time.Duration, client Client) (*Session, error) {
+	userAgent := client.UserAgent
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgentLength], "")
+	}
+	now := time.Now()
+	session := &Session{
+		UserID:     userID,
+		Kind:       kind,
+		Name:       name,
+		UserAgent:  userAgent,
+		CreatedIP:  client.IP,
+		LastIP:     client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if err := a.store.create(ctx, session); err != nil {
+		return nil, fmt.Errorf("auth: failed to record session: %w", err)
+	}
+	return session, nil
+}
+
+// VerifySession returns the user ID and session ID of a session token or API token used from
+// clientIP, recording the use. Tokens of revoked sessions are rejected.
+func (a *Authenticator) VerifySession(ctx context.Context, token, clientIP string) (string, string, error) {
+	userID, sessionID, err := a.sessions.Verify(token)
+	if err != nil || a.store == nil {
+		return userID, sessionID, err
+	}
+	if sessionID == "" {
+		return "", "", ErrSessionRevoked
+	}
+	session, err := a.store.get(ctx, sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("auth: failed to get session: %w", err)
+	}
+	if session == nil || session.UserID != userID {
+		return "", "", ErrSessionRevoked
+	}
+	if err := a.store.touch(ctx, session, clientIP, time.Now()); err != nil {
+		log.Printf("[Authenticator.VerifySession] Failed to record the use of session %s: %v", sessionID, err)
+	}
+	return userID, sessionID, nil
+}
+
+// Sessions returns the sessions and API tokens of a user, newest first, marking currentID
+func (a *Authenticator) Sessions(ctx context.Context, userID, currentID string) ([]*Session, error) {
+	if a.store == nil {
+		return nil, ErrSessionsDisabled
+	}
+	sessions, err := a.store.list(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("auth: failed to list sessions: %w", err)
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs a session of a user out, or revokes an API token
+func (a *Authenticator) RevokeSession(ctx context.Context, userID, id string) error {
+	if a.store == nil {
+		return ErrSessionsDisabled
+	}
+	session, err := a.store.get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("auth: failed to get session: %w", err)
+	}
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+	if _, err := a.store.revoke(ctx, userID, id); err != nil {
+		return fmt.Errorf("auth: failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllSessions signs a user out everywhere, revoking all their sessions but not their API
+// tokens, and returns how many were revoked
+func (a *Authenticator) RevokeAllSessions(ctx context.Context, userID string) (int64, error) {
+	if a.store == nil {
+		return 0, ErrSessionsDisabled
+	}
+	sessions, err := a.store.list(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("auth: failed to list sessions: %w", err)
+	}
+	var ids []string
+	for _, session := range sessions {
+		if session.Kind == SessionKindBrowser {
+			ids = append(ids, session.ID)
+		}
+	}
+	revoked, err := a.store.revoke(ctx, userID, ids...)
+	if err != nil {
+		return 0, fmt.Errorf("auth: failed to revoke sessions: %w", err)
+	}
+	log.Printf("[Authenticator.RevokeAllSessions] User %s signed out of %d sessions", userID, revoked)
+	return revoked, nil
+}
+
+// CreateToken creates an API token of a user, named name and valid for ttl, DefaultTokenTTL
+// when not positive, returning the token, shown once, and its record
+func (a *Authenticator) CreateToken(ctx context.Context, userID, name string, ttl time.Duration, client Client) (string, *Session, error) {
+	if a.store == nil {
+		return "", nil, ErrSessionsDisabled
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxTokenNameLength {
+		return "", nil, ErrInvalidTokenName
+	}
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	ttl = min(ttl, MaxTokenTTL)
+
+	sessions, err := a.store.list(ctx, userID)
+	if err != nil {
+		return "", nil, fmt.Errorf("auth: failed to list sessions: %w", err)
+	}
+	tokens := 0
+	for _, session := range sessions {
+		if session.Kind == SessionKindToken {
+			tokens++
+		}
+	}
+	if tokens >= MaxTokens {
+		return "", nil, ErrTooManyTokens
+	}
+
+	session, err := a.recordSession(ctx, userID, SessionKindToken, name, ttl, client)
+	if err != nil {
+		return "", nil, err
+	}
+	token, _, err := a.sessions.Issue(userID, session.ID, ttl)
+	if err != nil {
+		return "", nil, fmt.Errorf("auth: failed to issue token: %w", err)
+	}
+	log.Printf("[Authenticator.CreateToken] User %s created API token %s", userID, session.ID)
+	return token, session, nil
+}
+
+// SignOut revokes the session of a user signing out; API tokens are only revoked explicitly
+func (a *Authenticator) SignOut(ctx context.Context, userID, sessionID string) error {
+	if a.store == nil || sessionID == "" {
+		return nil
+	}
+	session, err := a.store.get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("auth: failed to get session: %w", err)
+	}
+	if session == nil || session.UserID != userID || session.Kind != SessionKindBrowser {
+		return nil
+	}
+	_, err = a.store.revoke(ctx, userID, sessionID)
+	return err
+}
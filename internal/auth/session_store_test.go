@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthenticator_SessionStore(t *testing.T) {
+	client := setupTestRedis(t)
+	ctx := context.Background()
+	sessions, err := NewSessions(testSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSessions() error = %v", err)
+	}
+	a := NewAuthenticator(nil, sessions, "https://gisty.example.com")
+	a.EnableSessionStore(client)
+
+	userID := "session-store-test"
+	defer func() {
+		if sessions, err := a.store.list(ctx, userID); err == nil {
+			for _, session := range sessions {
+				_, _ = a.store.revoke(ctx, userID, session.ID)
+			}
+		}
+	}()
+
+	laptop, _, err := a.issueSession(ctx, userID, Client{IP: "203.0.113.7", UserAgent: "Firefox"})
+	if err != nil {
+		t.Fatalf("issueSession() error = %v", err)
+	}
+	phone, _, err := a.issueSession(ctx, userID, Client{IP: "198.51.100.2", UserAgent: strings.Repeat("x", 1000)})
+	if err != nil {
+		t.Fatalf("issueSession() error = %v", err)
+	}
+	apiToken, token, err := a.CreateToken(ctx, userID, " CI ", 0, Client{IP: "203.0.113.7"})
+	if err != nil || token.Name != "CI" || token.Kind != SessionKindToken {
+		t.Fatalf("CreateToken() = %+v, %v, want a token named CI", token, err)
+	}
+	if _, _, err := a.CreateToken(ctx, userID, "", 0, Client{}); !errors.Is(err, ErrInvalidTokenName) {
+		t.Errorf("CreateToken(empty name) error = %v, want %v", err, ErrInvalidTokenName)
+	}
+
+	gotUser, laptopID, err := a.VerifySession(ctx, laptop, "192.0.2.1")
+	if err != nil || gotUser != userID {
+		t.Fatalf("VerifySession(laptop) = %q, %v, want %s", gotUser, err, userID)
+	}
+	// Tokens issued without the store are rejected
+	stateless, _, _ := sessions.Issue(userID, "", 0)
+	if _, _, err := a.VerifySession(ctx, stateless, ""); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("VerifySession(stateless) error = %v, want %v", err, ErrSessionRevoked)
+	}
+
+	listed, err := a.Sessions(ctx, userID, laptopID)
+	if err != nil || len(listed) != 3 {
+		t.Fatalf("Sessions() = %d sessions, %v, want 3", len(listed), err)
+	}
+	if listed[0].ID != token.ID {
+		t.Errorf("Sessions()[0] = %s, want the newest, %s", listed[0].ID, token.ID)
+	}
+	for _, session := range listed {
+		if session.ID == laptopID && (!session.Current || session.LastIP != "192.0.2.1" || session.UserAgent != "Firefox") {
+			t.Errorf("laptop session = %+v, want current, last used from 192.0.2.1", session)
+		}
+		if len(session.UserAgent) > maxUserAgentLength {
+			t.Errorf("session %s user agent has %d bytes, want at most %d", session.ID, len(session.UserAgent), maxUserAgentLength)
+		}
+	}
+
+	// Others cannot revoke the user's sessions
+	if err := a.RevokeSession(ctx, "someone-else", laptopID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("RevokeSession(someone else) error = %v, want %v", err, ErrSessionNotFound)
+	}
+	if err := a.RevokeSession(ctx, userID, laptopID); err != nil {
+		t.Fatalf("RevokeSession() error = %v", err)
+	}
+	if _, _, err := a.VerifySession(ctx, laptop, ""); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("VerifySession(revoked) error = %v, want %v", err, ErrSessionRevoked)
+	}
+
+	// Logging out everywhere keeps API tokens
+	if revoked, err := a.RevokeAllSessions(ctx, userID); err != nil || revoked != 1 {
+		t.Errorf("RevokeAllSessions() = %d, %v, want 1", revoked, err)
+	}
+	if _, _, err := a.VerifySession(ctx, phone, ""); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("VerifySession(phone) error = %v, want %v", err, ErrSessionRevoked)
+	}
+	if _, _, err := a.VerifySession(ctx, apiToken, ""); err != nil {
+		t.Errorf("VerifySession(API token) error = %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestSessions_IssueAndVerify(t *testing.T) {
+	if _, err := NewSessions("short", time.Hour); !errors.Is(err, ErrWeakSessionSecret) {
+		t.Errorf("NewSessions(short) error = %v, want %v", err, ErrWeakSessionSecret)
+	}
+
+	sessions, err := NewSessions(testSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("NewSessions() error = %v", err)
+	}
+	now := time.Now()
+	sessions.now = func() time.Time { return now }
+
+	token, expiresAt, err := sessions.Issue("user1", "s1", 0)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Issue() expires at %v, want %v", expiresAt, now.Add(time.Hour))
+	}
+	if userID, sessionID, err := sessions.Verify(token); err != nil || userID != "user1" || sessionID != "s1" {
+		t.Errorf("Verify() = %q, %q, %v, want user1, s1, nil", userID, sessionID, err)
+	}
+
+	// Tokens may have their own lifetime, such as API tokens
+	long, longExpiresAt, err := sessions.Issue("user1", "s2", 24*time.Hour)
+	if err != nil || !longExpiresAt.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("Issue(24h) expires at %v, %v, want %v", longExpiresAt, err, now.Add(24*time.Hour))
+	}
+
+	// Tokens of another secret, tampered or unsigned tokens are rejected
+	other, _ := NewSessions(strings.Repeat("x", MinSessionSecretLength), time.Hour)
+	if _, _, err := other.Verify(token); !errors.Is(err, ErrInvalidSession) {
+		t.Errorf("Verify() with another secret error = %v, want %v", err, ErrInvalidSession)
+	}
+	parts := strings.Split(token, ".")
+	forged, _, _ := other.Issue("admin", "", 0)
+	forgedParts := strings.Split(forged, ".")
+	for name, bad := range map[string]string{
+		"swapped claims": parts[0] + "." + forgedParts[1] + "." + parts[2],
+		"unsigned":       `eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.` + parts[1] + ".",
+		"garbage":        "not-a-token",
+	} {
+		if _, _, err := sessions.Verify(bad); !errors.Is(err, ErrInvalidSession) {
+			t.Errorf("Verify(%s) error = %v, want %v", name, err, ErrInvalidSession)
+		}
+	}
+
+	sessions.now = func() time.Time { return now.Add(time.Hour) }
+	if _, _, err := sessions.Verify(token); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("Verify() of an expired token error = %v, want %v", err, ErrSessionExpired)
+	}
+	if _, _, err := sessions.Verify(long); err != nil {
+		t.Errorf("Verify() of a longer token error = %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// tokenKeyPrefix prefixes the Redis keys of the one-time tokens sent by email
+	tokenKeyPrefix = "gisty:auth:token:"
+	// loginKeyPrefix prefixes the Redis counters of failed password sign-ins and the lockouts
+	loginKeyPrefix = "gisty:auth:login:"
+	// mailKeyPrefix prefixes the Redis keys spacing the emails sent to an account
+	mailKeyPrefix = "gisty:auth:mail:"
+
+	// MaxLoginFailures is the number of failed sign-ins to an account within LockoutDuration that
+	// locks it for LockoutDuration
+	MaxLoginFailures = 5
+	// LockoutDuration is the window failed sign-ins are counted in and the time a locked account
+	// stays locked
+	LockoutDuration = 15 * time.Minute
+	// MaxClientLogins is the number of password sign-ins a client IP attempts within
+	// LockoutDuration before it is throttled, whichever accounts they are for
+	MaxClientLogins = 30
+)
+
+var (
+	// ErrInvalidToken is returned for an email verification or password reset token that was not
+	// issued, was used already or expired
+	ErrInvalidToken = errors.New("auth: invalid or expired token")
+	// ErrAccountLocked is returned when signing in to an account locked after MaxLoginFailures
+	ErrAccountLocked = errors.New("auth: account temporarily locked")
+	// ErrLoginThrottled is returned when a client attempted MaxClientLogins sign-ins
+	ErrLoginThrottled = errors.New("auth: too many sign-in attempts")
+)
+
+// RetryError is an ErrAccountLocked or ErrLoginThrottled error telling when to try again
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", e.Err, e.RetryAfter)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// tokenStore keeps the one-time tokens of email verification and password reset links in Redis,
+// expiring with their key. Only a hash of each token is stored.
+type tokenStore struct {
+	client *redis.Client
+}
+
+// issue returns a new token of the given purpose for a user, valid for ttl
+func (s *tokenStore) issue(ctx context.Context, purpose, userID string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	if err := s.client.Set(ctx, tokenKey(purpose, token), userID, ttl).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// consume returns the user of a token of the given purpose and invalidates the token
+func (s *tokenStore) consume(ctx context.Context, purpose, token string) (string, error) {
+	if token == "" {
+		return "", ErrInvalidToken
+	}
+	userID, err := s.client.GetDel(ctx, tokenKey(purpose, token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrInvalidToken
+	}
+	return userID, err
+}
+
+// tokenKey returns the Redis key of a token
+func tokenKey(purpose, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return tokenKeyPrefix + purpose + ":" + hex.EncodeToString(sum[:])
+}
+
+// loginThrottle counts password sign-ins in Redis, shared by all replicas: a client IP is
+// throttled after MaxClientLogins attempts, and an account is locked after MaxLoginFailures
+// failures, each for LockoutDuration
+type loginThrottle struct {
+	client *redis.Client
+}
+
+// attempt counts a sign-in attempt to account by clientIP, returning a RetryError when the client
+// is throttled or the account locked
+func (t *loginThrottle) attempt(ctx context.Context, account, clientIP string) error {
+	if clientIP != "" {
+		if ttl, throttled, err := t.count(ctx, loginKeyPrefix+"ip:"+clientIP, MaxClientLogins); err != nil {
+			return err
+		} else if throttled {
+			return &RetryError{Err: ErrLoginThrottled, RetryAfter: ttl}
+		}
+	}
+
+	ttl, err := t.client.TTL(ctx, loginKeyPrefix+"lock:"+account).Result()
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		return &RetryError{Err: ErrAccountLocked, RetryAfter: ttl}
+	}
+	return nil
+}
+
+// fail counts a failed sign-in to account, locking it after MaxLoginFailures
+func (t *loginThrottle) fail(ctx context.Context, account string) error {
+	failuresKey := loginKeyPrefix + "failures:" + account
+	if _, locked, err := t.count(ctx, failuresKey, MaxLoginFailures-1); err != nil || !locked {
+		return err
+	}
+	pipe := t.client.TxPipeline()
+	pipe.Set(ctx, loginKeyPrefix+"lock:"+account, 1, LockoutDuration)
+	pipe.Del(ctx, failuresKey)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// reset forgets the failed sign-ins to account and lifts its lockout
+func (t *loginThrottle) reset(ctx context.Context, account string) error {
+	return t.client.Del(ctx, loginKeyPrefix+"failures:"+account, loginKeyPrefix+"lock:"+account).Err()
+}
+
+// locked returns a RetryError of err when the counter at key reached MaxLoginFailures
+func (t *loginThrottle) locked(ctx context.Context, key string, err error) error {
+	failures, getErr := t.client.Get(ctx, key).Int64()
+	if errors.Is(getErr, redis.Nil) {
+		return nil
+	}
+	if getErr != nil {
+		return getErr
+	}
+	if failures < MaxLoginFailures {
+		return nil
+	}
+	ttl, ttlErr := t.client.TTL(ctx, key).Result()
+	if ttlErr != nil {
+		return ttlErr
+	}
+	return &RetryError{Err: err, RetryAfter: ttl}
+}
+
+// count increments the counter at key, started for LockoutDuration, and reports whether it went
+// over limit, with the time left until it resets
+func (t *loginThrottle) count(ctx context.Context, key string, limit int64) (time.Duration, bool, error) {
+	pipe := t.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, LockoutDuration)
+	ttl := pipe.TTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, false, err
+	}
+	return ttl.Val(), incr.Val() > limit, nil
+}
+
+// spaceMail reports whether an email of the given purpose may be sent to a user, at most one
+// every interval, so that the endpoints sending them cannot flood an inbox
+func spaceMail(ctx context.Context, client *redis.Client, purpose, userID string, interval time.Duration) (bool, error) {
+	return client.SetNX(ctx, mailKeyPrefix+purpose+":"+userID, 1, interval).Result()
+}
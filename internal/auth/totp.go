@@ -0,0 +1,299 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TOTPIssuer names the service in authenticator apps
+	TOTPIssuer = "Gisty"
+	// RecoveryCodeCount is the number of recovery codes issued at a time; each works once
+	RecoveryCodeCount = 10
+
+	// totpPeriod, totpDigits and totpSkew are the RFC 6238 parameters of the codes: 6 digits
+	// every 30 seconds, accepted one step early or late for clock drift
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	totpSkew   = 1
+)
+
+var (
+	// ErrTOTPDisabled is returned when two-factor authentication is not offered
+	ErrTOTPDisabled = errors.New("auth: two-factor authentication disabled")
+	// ErrTOTPEnabled is returned when enrolling a user whose two-factor authentication is on
+	ErrTOTPEnabled = errors.New("auth: two-factor authentication already enabled")
+	// ErrTOTPNotEnabled is returned when confirming without enrolling, or when changing the
+	// two-factor authentication of a user who has none
+	ErrTOTPNotEnabled = errors.New("auth: two-factor authentication not enabled")
+	// ErrSecondFactorRequired is returned when a user with two-factor authentication sends no code
+	ErrSecondFactorRequired = errors.New("auth: two-factor code required")
+	// ErrInvalidSecondFactor is returned for a wrong, used or expired code or recovery code
+	ErrInvalidSecondFactor = errors.New("auth: invalid two-factor code")
+	// ErrSecondFactorThrottled is returned after MaxLoginFailures wrong codes within
+	// LockoutDuration
+	ErrSecondFactorThrottled = errors.New("auth: too many wrong two-factor codes")
+)
+
+// EnableTOTP lets users turn on two-factor authentication with an authenticator app. Wrong
+// codes are counted in Redis, like failed sign-ins.
+func (a *Authenticator) EnableTOTP(client *redis.Client) {
+	a.totp = &loginThrottle{client: client}
+}
+
+// BeginTOTP starts the enrollment of a user in two-factor authentication, returning the secret
+// to enter in an authenticator app and its otpauth:// URI, shown as a QR code. Nothing is
+// required until ConfirmTOTP.
+func (a *Authenticator) BeginTOTP(ctx context.Context, userID string) (string, string, error) {
+	if a.totp == nil {
+		return "", "", ErrTOTPDisabled
+	}
+	user, err := a.users.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		return "", "", ErrTOTPEnabled
+	}
+
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+	if err := a.users.SetTOTP(ctx, userID, secret, false, nil); err != nil {
+		return "", "", fmt.Errorf("auth: failed to set two-factor secret: %w", err)
+	}
+	return secret, totpURI(user, secret), nil
+}
+
+// ConfirmTOTP turns on the two-factor authentication of a user with a first code of their
+// authenticator app, returning their recovery codes; they are not shown again
+func (a *Authenticator) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	if a.totp == nil {
+		return nil, ErrTOTPDisabled
+	}
+	user, err := a.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTOTPEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTOTPNotEnabled
+	}
+	if err := a.checkSecondFactor(ctx, user, code, false); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.users.SetTOTP(ctx, userID, user.TOTPSecret, true, hashes); err != nil {
+		return nil, fmt.Errorf("auth: failed to enable two-factor authentication: %w", err)
+	}
+	log.Printf("[Authenticator.ConfirmTOTP] User %s enabled two-factor authentication", userID)
+	return codes, nil
+}
+
+// VerifySecondFactor checks the code sent for a destructive action by a user: a code of their
+// authenticator app or one of their recovery codes, which is then used up. Users without
+// two-factor authentication need none.
+func (a *Authenticator) VerifySecondFactor(ctx context.Context, userID, code string) error {
+	user, err := a.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return nil
+	}
+	return a.checkSecondFactor(ctx, user, code, true)
+}
+
+// DisableTOTP turns off the two-factor authentication of a user, with a code of it
+func (a *Authenticator) DisableTOTP(ctx context.Context, userID, code string) error {
+	user, err := a.enabledTOTPUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := a.checkSecondFactor(ctx, user, code, true); err != nil {
+		return err
+	}
+	if err := a.users.SetTOTP(ctx, userID, "", false, nil); err != nil {
+		return fmt.Errorf("auth: failed to disable two-factor authentication: %w", err)
+	}
+	log.Printf("[Authenticator.DisableTOTP] User %s disabled two-factor authentication", userID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user, with a code of their
+// two-factor authentication, returning the new ones
+func (a *Authenticator) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := a.enabledTOTPUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.checkSecondFactor(ctx, user, code, true); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.users.SetRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("auth: failed to set recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// enabledTOTPUser returns a user whose two-factor authentication is on
+func (a *Authenticator) enabledTOTPUser(ctx context.Context, userID string) (*model.User, error) {
+	user, err := a.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTOTPNotEnabled
+	}
+	return user, nil
+}
+
+// checkSecondFactor checks a code of the user's authenticator app, or one of their recovery
+// codes when recovery is set, counting wrong ones
+func (a *Authenticator) checkSecondFactor(ctx context.Context, user *model.User, code string, recovery bool) error {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return ErrSecondFactorRequired
+	}
+	if a.totp == nil {
+		return ErrTOTPDisabled
+	}
+	key := loginKeyPrefix + "2fa:" + user.ID
+	if err := a.totp.locked(ctx, key, ErrSecondFactorThrottled); err != nil {
+		return err
+	}
+
+	ok, err := a.useSecondFactor(ctx, user, code, recovery)
+	if err != nil {
+		return fmt.Errorf("auth: failed to check two-factor code: %w", err)
+	}
+	if !ok {
+		if _, _, err := a.totp.count(ctx, key, MaxLoginFailures); err != nil {
+			log.Printf("[Authenticator.checkSecondFactor] Failed to count a wrong code: %v", err)
+		}
+		return ErrInvalidSecondFactor
+	}
+	return nil
+}
+
+// useSecondFactor reports whether code is a current code of the user's authenticator app, not
+// used yet, or one of their recovery codes when recovery is set, using it up
+func (a *Authenticator) useSecondFactor(ctx context.Context, user *model.User, code string, recovery bool) (bool, error) {
+	if len(code) == totpDigits {
+		step, ok := matchTOTP(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return a.users.UseTOTPStep(ctx, user.ID, step)
+	}
+	if !recovery {
+		return false, nil
+	}
+	return a.users.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+}
+
+// matchTOTP returns the time step of the code of secret matching code around now
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+	current := now.Unix() / int64(totpPeriod/time.Second)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode returns the code of key at a time step, per RFC 4226 and RFC 6238
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000)
+}
+
+// totpURI returns the otpauth:// URI of a secret, labelled with the user's username or email
+func totpURI(user *model.User, secret string) string {
+	account := user.Username
+	if account == "" {
+		account = user.Email
+	}
+	if account == "" {
+		account = user.ID
+	}
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", TOTPIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod/time.Second)))
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + TOTPIssuer + ":" + account, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// newRecoveryCodes returns RecoveryCodeCount new recovery codes, formatted as xxxxx-xxxxx, with
+// their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for range RecoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode returns the stored hash of a recovery code, ignoring case, spaces and dashes
+func hashRecoveryCode(code string) string {
+	code = strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, SHA-1, truncated to 6 digits
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/30); got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111111, 0)
+
+	if step, ok := matchTOTP(secret, "050471", now); !ok || step != 1111111111/30 {
+		t.Errorf("matchTOTP(current) = %d, %v, want step %d", step, ok, 1111111111/30)
+	}
+	// A code of the previous step is accepted for clock drift, older ones are not
+	if _, ok := matchTOTP(secret, "050471", now.Add(totpPeriod)); !ok {
+		t.Error("matchTOTP(previous step) = false, want true")
+	}
+	if _, ok := matchTOTP(secret, "050471", now.Add(2*totpPeriod)); ok {
+		t.Error("matchTOTP(two steps old) = true, want false")
+	}
+	if _, ok := matchTOTP(secret, "123456", now); ok {
+		t.Error("matchTOTP(wrong) = true, want false")
+	}
+	if _, ok := matchTOTP("not base32!", "050471", now); ok {
+		t.Error("matchTOTP(invalid secret) = true, want false")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := totpURI(&model.User{ID: "1", Username: "octocat"}, "JBSWY3DPEHPK3PXP")
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("totpURI() = %q, error = %v", uri, err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Gisty:octocat" {
+		t.Errorf("totpURI() = %q, want otpauth://totp/Gisty:octocat", uri)
+	}
+	if q := u.Query(); q.Get("secret") != "JBSWY3DPEHPK3PXP" || q.Get("issuer") != TOTPIssuer || q.Get("digits") != "6" || q.Get("period") != "30" {
+		t.Errorf("totpURI() query = %v", q)
+	}
+}
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatalf("newRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("newRecoveryCodes() = %d codes, %d hashes, want %d", len(codes), len(hashes), RecoveryCodeCount)
+	}
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 11 || code[5] != '-' || seen[code] {
+			t.Errorf("code %q, want a new xxxxx-xxxxx code", code)
+		}
+		seen[code] = true
+		if hashes[i] != hashRecoveryCode(code) {
+			t.Errorf("hash of %q = %s, want %s", code, hashes[i], hashRecoveryCode(code))
+		}
+		// Codes are typed without dashes or in capitals too
+		if typed := strings.ToUpper(strings.ReplaceAll(code, "-", " ")); hashRecoveryCode(typed) != hashes[i] {
+			t.Errorf("hashRecoveryCode(%q) differs from the hash of %q", typed, code)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// maxUsernameLength caps usernames, as GitHub does for logins
+	maxUsernameLength = 39
+	// usernameAttempts is how many usernames are tried for a user before giving up
+	usernameAttempts = 10
+)
+
+// assignUsername gives a user signing in for the first time, or before usernames existed, a
+// username derived from the provider login, the email or the name. A taken username gets a
+// numbered, then a random, suffix.
+func (a *Authenticator) assignUsername(ctx context.Context, user *model.User) error {
+	base := usernameBase(user)
+	for attempt := 1; attempt <= usernameAttempts; attempt++ {
+		username := base
+		switch {
+		case attempt == usernameAttempts:
+			b := make([]byte, 3)
+			_, _ = rand.Read(b)
+			username = withSuffix(base, hex.EncodeToString(b))
+		case attempt > 1:
+			username = withSuffix(base, fmt.Sprint(attempt))
+		}
+
+		err := a.users.SetUsername(ctx, user.ID, username)
+		if errors.Is(err, repository.ErrUsernameTaken) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		user.Username = username
+		return nil
+	}
+	return repository.ErrUsernameTaken
+}
+
+// usernameBase returns the username a user would like: their provider login, else the local part
+// of their email, else their name, reduced to lowercase letters, digits and single dashes
+func usernameBase(user *model.User) string {
+	for _, candidate := range []string{user.Login, strings.Split(user.Email, "@")[0], user.Name} {
+		if username := NormalizeUsername(candidate); username != "" {
+			return username
+		}
+	}
+	return "user"
+}
+
+// NormalizeUsername returns s as a username: lowercase ASCII letters and digits, with runs of
+// other characters turned into a single dash, no leading or trailing dash, and at most 39
+// characters. It returns "" when nothing is left.
+func NormalizeUsername(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	username := b.String()
+	if len(username) > maxUsernameLength {
+		username = strings.TrimRight(username[:maxUsernameLength], "-")
+	}
+	return username
+}
+
+// withSuffix appends "-suffix" to a username, shortening it to stay within the length limit
+func withSuffix(username, suffix string) string {
+	if n := maxUsernameLength - len(suffix) - 1; len(username) > n {
+		username = strings.TrimRight(username[:n], "-")
+	}
+	return username + "-" + suffix
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"octocat", "octocat"},
+		{"OctoCat", "octocat"},
+		{"john.doe", "john-doe"},
+		{"  Jane  Doe ", "jane-doe"},
+		{"--a__b--", "a-b"},
+		{"Nguyễn Văn A", "nguy-n-v-n-a"},
+		{"日本", ""},
+		{"", ""},
+		{strings.Repeat("a", 38) + "-b", strings.Repeat("a", 38)},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeUsername(tt.in); got != tt.want {
+			t.Errorf("NormalizeUsername(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUsernameBase(t *testing.T) {
+	tests := []struct {
+		user *model.User
+		want string
+	}{
+		{&model.User{Login: "Octocat", Email: "cat@github.com"}, "octocat"},
+		{&model.User{Email: "Jane.Doe@example.com", Name: "Jane"}, "jane-doe"},
+		{&model.User{Name: "Jane Doe"}, "jane-doe"},
+		{&model.User{Name: "日本"}, "user"},
+	}
+
+	for _, tt := range tests {
+		if got := usernameBase(tt.user); got != tt.want {
+			t.Errorf("usernameBase(%+v) = %q, want %q", tt.user, got, tt.want)
+		}
+	}
+	if got := withSuffix(strings.Repeat("a", 39), "2"); got != strings.Repeat("a", 37)+"-2" {
+		t.Errorf("withSuffix() = %q, want it within %d characters", got, maxUsernameLength)
+	}
+}
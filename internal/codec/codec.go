@@ -0,0 +1,85 @@
+// Package codec converts paste content to and from the transfer encodings of the API. JSON
+// payloads can only carry valid UTF-8, and escape control characters at up to six bytes each,
+// so binary-ish content travels better as base64 or hex. It is shared by the server and the CLI
+// and must not depend on anything but the standard library.
+package codec
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"unicode/utf8"
+)
+
+// Transfer encodings of paste content; None is the content as is
+const (
+	None   = ""
+	Base64 = "base64"
+	Hex    = "hex"
+)
+
+var (
+	// ErrUnknownEncoding is returned for encodings other than base64 and hex
+	ErrUnknownEncoding = errors.New("codec: unknown encoding")
+	// ErrInvalidEncoded is returned when content is not valid in its declared encoding
+	ErrInvalidEncoded = errors.New("codec: content does not match its encoding")
+)
+
+// Valid reports whether encoding is a supported transfer encoding, None included
+func Valid(encoding string) bool {
+	switch encoding {
+	case None, Base64, Hex:
+		return true
+	default:
+		return false
+	}
+}
+
+// Encode returns content in the given encoding
+func Encode(encoding, content string) (string, error) {
+	switch encoding {
+	case None:
+		return content, nil
+	case Base64:
+		return base64.StdEncoding.EncodeToString([]byte(content)), nil
+	case Hex:
+		return hex.EncodeToString([]byte(content)), nil
+	default:
+		return "", ErrUnknownEncoding
+	}
+}
+
+// Decode returns the content encoded in the given encoding
+func Decode(encoding, encoded string) (string, error) {
+	var content []byte
+	var err error
+	switch encoding {
+	case None:
+		return encoded, nil
+	case Base64:
+		content, err = base64.StdEncoding.DecodeString(encoded)
+	case Hex:
+		content, err = hex.DecodeString(encoded)
+	default:
+		return "", ErrUnknownEncoding
+	}
+	if err != nil {
+		return "", ErrInvalidEncoded
+	}
+	return string(content), nil
+}
+
+// NeedsEncoding reports whether content would not survive a JSON payload byte for byte, or would
+// grow much in it: it is not valid UTF-8 or holds control characters other than tab, newline and
+// carriage return
+func NeedsEncoding(content string) bool {
+	if !utf8.ValidString(content) {
+		return true
+	}
+	for i := 0; i < len(content); i++ {
+		if c := content[i]; (c < 0x20 && c != '\t' && c != '\n' && c != '\r') || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	content := "\x00\x01binary\xff\xfe\n"
+	for _, encoding := range []string{None, Base64, Hex} {
+		encoded, err := Encode(encoding, content)
+		if err != nil {
+			t.Fatalf("Encode(%q) error = %v", encoding, err)
+		}
+		decoded, err := Decode(encoding, encoded)
+		if err != nil || decoded != content {
+			t.Errorf("Decode(%q, %q) = %q, %v, want %q", encoding, encoded, decoded, err, content)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		encoding string
+		want     string
+	}{
+		{Base64, "aGk="},
+		{Hex, "6869"},
+		{None, "hi"},
+	}
+	for _, tt := range tests {
+		if got, err := Encode(tt.encoding, "hi"); err != nil || got != tt.want {
+			t.Errorf("Encode(%q, hi) = %q, %v, want %q", tt.encoding, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	if _, err := Decode(Base64, "not base64!"); !errors.Is(err, ErrInvalidEncoded) {
+		t.Errorf("Decode(base64) error = %v, want ErrInvalidEncoded", err)
+	}
+	if _, err := Decode(Hex, "abc"); !errors.Is(err, ErrInvalidEncoded) {
+		t.Errorf("Decode(hex) error = %v, want ErrInvalidEncoded", err)
+	}
+	if _, err := Decode("rot13", "uv"); !errors.Is(err, ErrUnknownEncoding) {
+		t.Errorf("Decode(rot13) error = %v, want ErrUnknownEncoding", err)
+	}
+	if Valid("rot13") || !Valid(Base64) || !Valid(None) {
+		t.Error("Valid() misreports the supported encodings")
+	}
+}
+
+func TestNeedsEncoding(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"package main\n\tfunc main() {}\r\n", false},
+		{"héllo wörld", false},
+		{"nul\x00byte", true},
+		{"escape \x1b[31m", true},
+		{"invalid \xff utf-8", true},
+	}
+	for _, tt := range tests {
+		if got := NeedsEncoding(tt.content); got != tt.want {
+			t.Errorf("NeedsEncoding(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Package event provides the bus through which the paste service announces paste lifecycle
+// events to consumers such as webhooks, stats, trending and replication, off the request path.
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+const (
+	// DriverMemory delivers events to in-process subscribers only
+	DriverMemory = "memory"
+	// DriverNATS additionally forwards every event to a NATS server
+	DriverNATS = "nats"
+)
+
+// ErrUnknownDriver is returned when the configured bus driver is not supported
+var ErrUnknownDriver = errors.New("event: unknown bus driver")
+
+// Handler consumes one event. A returned error is logged and counted; the event is not retried.
+type Handler func(ctx context.Context, event *model.PasteEvent) error
+
+// Bus delivers paste events to subscribers. Publish never blocks: each subscriber has a
+// bounded queue, and events that do not fit are dropped.
+type Bus interface {
+	Publish(event *model.PasteEvent)
+	Subscribe(name string, handler Handler)
+	Close()
+}
+
+// Config holds event bus configuration
+type Config struct {
+	Driver string
+	// QueueSize is the number of pending events kept per subscriber
+	QueueSize int
+	// Region is stamped on events that do not carry one
+	Region string
+
+	// NATSURL and SubjectPrefix configure the NATS driver; events are published
+	// on "<prefix>.paste.<type>", e.g., "gisty.paste.created"
+	NATSURL       string
+	SubjectPrefix string
+}
+
+// New creates the bus of the configured driver
+func New(config *Config) (Bus, error) {
+	bus := NewMemoryBus(config)
+
+	switch config.Driver {
+	case DriverMemory, "":
+	case DriverNATS:
+		publisher, err := NewNATSPublisher(config.NATSURL, config.SubjectPrefix)
+		if err != nil {
+			bus.Close()
+			return nil, err
+		}
+		bus.Subscribe(DriverNATS, publisher.Handle)
+		bus.onClose = publisher.Close
+	default:
+		bus.Close()
+		return nil, fmt.Errorf("%w: %q (supported: %s, %s)", ErrUnknownDriver, config.Driver, DriverMemory, DriverNATS)
+	}
+
+	return bus, nil
+}
+
+// newEventID returns a random event identifier
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
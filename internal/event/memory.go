@@ -0,0 +1,144 @@
+package event
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/model"
+)
+
+const (
+	// DefaultQueueSize is the default number of pending events kept per subscriber
+	DefaultQueueSize = 1024
+	// DefaultHandlerTimeout bounds the handling of one event by one subscriber
+	DefaultHandlerTimeout = 10 * time.Second
+)
+
+// MemoryBus is an in-process Bus. Every subscriber consumes its own queue on its own
+// goroutine, so a slow consumer only delays itself.
+type MemoryBus struct {
+	queueSize int
+	region    string
+
+	mu          sync.RWMutex
+	closed      bool
+	subscribers []*subscriber
+	wg          sync.WaitGroup
+
+	// onClose releases driver resources once the queues are drained
+	onClose func()
+}
+
+// subscriber is a named handler and its pending events
+type subscriber struct {
+	name    string
+	handler Handler
+	queue   chan *model.PasteEvent
+}
+
+// NewMemoryBus creates a new MemoryBus
+func NewMemoryBus(config *Config) *MemoryBus {
+	bus := &MemoryBus{queueSize: DefaultQueueSize}
+	if config != nil {
+		if config.QueueSize > 0 {
+			bus.queueSize = config.QueueSize
+		}
+		bus.region = config.Region
+	}
+	return bus
+}
+
+// Publish stamps the event and queues it for every subscriber
+func (b *MemoryBus) Publish(event *model.PasteEvent) {
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	if event.Region == "" {
+		event.Region = b.region
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	metrics.EventsPublished.WithLabelValues(string(event.Type)).Inc()
+	for _, sub := range b.subscribers {
+		select {
+		case sub.queue <- event:
+		default:
+			metrics.EventsDropped.WithLabelValues(sub.name).Inc()
+		}
+	}
+}
+
+// Subscribe registers a handler receiving the events published from now on
+func (b *MemoryBus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	sub := &subscriber{
+		name:    name,
+		handler: handler,
+		queue:   make(chan *model.PasteEvent, b.queueSize),
+	}
+	b.subscribers = append(b.subscribers, sub)
+
+	b.wg.Add(1)
+	go b.consume(sub)
+}
+
+// Close stops accepting events and waits for the subscribers to handle the queued ones
+func (b *MemoryBus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subscribers {
+		close(sub.queue)
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+	if b.onClose != nil {
+		b.onClose()
+	}
+}
+
+// consume hands the queued events of one subscriber to its handler
+func (b *MemoryBus) consume(sub *subscriber) {
+	defer b.wg.Done()
+	for event := range sub.queue {
+		b.handle(sub, event)
+	}
+}
+
+// handle runs the handler on one event, containing its errors and panics
+func (b *MemoryBus) handle(sub *subscriber, event *model.PasteEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHandlerTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.EventFailures.WithLabelValues(sub.name).Inc()
+			log.Printf("[MemoryBus.handle] Subscriber %s panicked on %s of %s: %v", sub.name, event.Type, event.ShortID, r)
+		}
+	}()
+
+	if err := sub.handler(ctx, event); err != nil {
+		metrics.EventFailures.WithLabelValues(sub.name).Inc()
+		log.Printf("[MemoryBus.handle] Subscriber %s failed on %s of %s: %v", sub.name, event.Type, event.ShortID, err)
+	}
+}
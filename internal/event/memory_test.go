@@ -0,0 +1,89 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestMemoryBus_Delivery(t *testing.T) {
+	bus := NewMemoryBus(&Config{Region: "eu-west"})
+
+	var mu sync.Mutex
+	got := map[string][]*model.PasteEvent{}
+	record := func(name string) Handler {
+		return func(_ context.Context, e *model.PasteEvent) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[name] = append(got[name], e)
+			return nil
+		}
+	}
+	bus.Subscribe("a", record("a"))
+	bus.Subscribe("b", record("b"))
+	bus.Subscribe("failing", func(context.Context, *model.PasteEvent) error { return errors.New("boom") })
+	bus.Subscribe("panicking", func(context.Context, *model.PasteEvent) error { panic("boom") })
+
+	bus.Publish(&model.PasteEvent{Type: model.PasteEventCreated, ShortID: "abc123"})
+	bus.Publish(&model.PasteEvent{Type: model.PasteEventRead, ShortID: "abc123", Region: "us-east"})
+	bus.Close()
+
+	for _, name := range []string{"a", "b"} {
+		events := got[name]
+		if len(events) != 2 {
+			t.Fatalf("subscriber %s got %d events, want 2", name, len(events))
+		}
+		if events[0].Type != model.PasteEventCreated || events[1].Type != model.PasteEventRead {
+			t.Errorf("subscriber %s got %s, %s", name, events[0].Type, events[1].Type)
+		}
+	}
+
+	first := got["a"][0]
+	if first.ID == "" || first.OccurredAt.IsZero() || first.Region != "eu-west" {
+		t.Errorf("event not stamped: %+v", first)
+	}
+	if got["a"][1].Region != "us-east" {
+		t.Errorf("Region = %q, want the publisher's us-east", got["a"][1].Region)
+	}
+
+	// Publishing after Close is a no-op
+	bus.Publish(&model.PasteEvent{Type: model.PasteEventDeleted, ShortID: "abc123"})
+}
+
+func TestMemoryBus_DropsWhenFull(t *testing.T) {
+	bus := NewMemoryBus(&Config{QueueSize: 1})
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var handled int
+	bus.Subscribe("slow", func(context.Context, *model.PasteEvent) error {
+		if handled == 0 {
+			close(started)
+			<-release
+		}
+		handled++
+		return nil
+	})
+
+	bus.Publish(&model.PasteEvent{Type: model.PasteEventRead})
+	<-started
+	// One event fits in the queue while the first is being handled; the rest are dropped
+	for i := 0; i < 5; i++ {
+		bus.Publish(&model.PasteEvent{Type: model.PasteEventRead})
+	}
+	close(release)
+	bus.Close()
+
+	if handled != 2 {
+		t.Errorf("handled %d events, want 2", handled)
+	}
+}
+
+func TestNew_UnknownDriver(t *testing.T) {
+	if _, err := New(&Config{Driver: "kafka"}); !errors.Is(err, ErrUnknownDriver) {
+		t.Errorf("New() error = %v, want ErrUnknownDriver", err)
+	}
+}
@@ -0,0 +1,225 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+const (
+	// DefaultNATSURL is the default address of the NATS server
+	DefaultNATSURL = "nats://localhost:4222"
+	// DefaultSubjectPrefix is the default prefix of NATS subjects
+	DefaultSubjectPrefix = "gisty"
+
+	natsDialTimeout = 5 * time.Second
+)
+
+var (
+	// ErrInvalidNATSURL is returned when the NATS URL cannot be used
+	ErrInvalidNATSURL = errors.New("event: invalid NATS URL")
+	// ErrInvalidSubjectPrefix is returned when the subject prefix is not a valid NATS subject
+	ErrInvalidSubjectPrefix = errors.New("event: invalid NATS subject prefix")
+	// ErrNATSHandshake is returned when the NATS server refuses the connection
+	ErrNATSHandshake = errors.New("event: NATS handshake failed")
+)
+
+// NATSPublisher forwards events to a NATS server using the core text protocol
+// (at-most-once, no JetStream acknowledgement). The connection is opened on first
+// use and reopened after failures, so an unavailable server never blocks startup.
+type NATSPublisher struct {
+	address string
+	connect []byte // CONNECT command carrying the client options and credentials
+	prefix  string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewNATSPublisher creates a NATSPublisher for a nats://[user[:password]@]host[:port] URL.
+// A user without password is sent as an authentication token.
+func NewNATSPublisher(rawURL, subjectPrefix string) (*NATSPublisher, error) {
+	if rawURL == "" {
+		rawURL = DefaultNATSURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "nats" || u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidNATSURL, rawURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "4222"
+	}
+
+	if subjectPrefix == "" {
+		subjectPrefix = DefaultSubjectPrefix
+	}
+	if strings.ContainsAny(subjectPrefix, " \t\r\n*>") || strings.HasPrefix(subjectPrefix, ".") || strings.HasSuffix(subjectPrefix, ".") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSubjectPrefix, subjectPrefix)
+	}
+
+	options := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "gisty",
+		"lang":     "go",
+		"protocol": 1,
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			options["user"] = u.User.Username()
+			options["pass"] = password
+		} else {
+			options["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(options)
+
+	return &NATSPublisher{
+		address: net.JoinHostPort(u.Hostname(), port),
+		connect: []byte("CONNECT " + string(connect) + "\r\n"),
+		prefix:  subjectPrefix,
+	}, nil
+}
+
+// Subject returns the subject events of the given type are published on
+func (p *NATSPublisher) Subject(eventType model.PasteEventType) string {
+	return p.prefix + "." + string(eventType)
+}
+
+// Handle publishes the event as JSON, reconnecting once if the connection was lost
+func (p *NATSPublisher) Handle(ctx context.Context, event *model.PasteEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Appendf(nil, "PUB %s %d\r\n", p.Subject(event.Type), len(payload))
+	msg = append(append(msg, payload...), '\r', '\n')
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if p.conn == nil {
+			if err = p.dial(ctx); err != nil {
+				return err
+			}
+		}
+
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(natsDialTimeout)
+		}
+		_ = p.conn.SetWriteDeadline(deadline)
+		if _, err = p.conn.Write(msg); err == nil {
+			return nil
+		}
+
+		p.conn.Close()
+		p.conn = nil
+		if attempt > 0 {
+			return err
+		}
+	}
+}
+
+// Close closes the connection to the server
+func (p *NATSPublisher) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
+
+// dial connects and completes the handshake; the caller holds p.mu
+func (p *NATSPublisher) dial(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: natsDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(natsDialTimeout))
+	reader := bufio.NewReader(conn)
+
+	// The server greets with INFO; a PING after CONNECT confirms the credentials were accepted
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("%w: unexpected greeting %q", ErrNATSHandshake, strings.TrimSpace(line))
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if err := json.Unmarshal([]byte(line[len("INFO "):]), &info); err == nil && info.TLSRequired {
+		conn.Close()
+		return fmt.Errorf("%w: server requires TLS", ErrNATSHandshake)
+	}
+
+	if _, err := conn.Write(append(p.connect, "PING\r\n"...)); err != nil {
+		conn.Close()
+		return err
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			conn.Close()
+			return fmt.Errorf("%w: %s", ErrNATSHandshake, line)
+		}
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	p.conn = conn
+	go p.readLoop(conn, reader)
+	return nil
+}
+
+// readLoop answers the server's keepalive pings and drops the connection when it breaks
+func (p *NATSPublisher) readLoop(conn net.Conn, reader *bufio.Reader) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			p.mu.Lock()
+			if p.conn == conn {
+				p.conn = nil
+			}
+			p.mu.Unlock()
+			conn.Close()
+			return
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PING":
+			p.mu.Lock()
+			_ = conn.SetWriteDeadline(time.Now().Add(natsDialTimeout))
+			_, _ = conn.Write([]byte("PONG\r\n"))
+			p.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			log.Printf("[NATSPublisher.readLoop] Server error: %s", line)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+// fakeNATSServer accepts one client, completes the handshake and reports the commands it receives
+func fakeNATSServer(t *testing.T, reply string) (string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	lines := make(chan string, 16)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		_, _ = conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				close(lines)
+				return
+			}
+			line = strings.TrimSpace(line)
+			lines <- line
+			if line == "PING" {
+				_, _ = conn.Write([]byte(reply))
+			}
+		}
+	}()
+
+	return "nats://token123@" + listener.Addr().String(), lines
+}
+
+func TestNATSPublisher_Handle(t *testing.T) {
+	url, lines := fakeNATSServer(t, "PONG\r\n")
+
+	publisher, err := NewNATSPublisher(url, "gisty")
+	if err != nil {
+		t.Fatalf("NewNATSPublisher() error = %v", err)
+	}
+	defer publisher.Close()
+
+	event := &model.PasteEvent{ID: "evt-1", Type: model.PasteEventCreated, ShortID: "abc123"}
+	if err := publisher.Handle(context.Background(), event); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for the client")
+			return ""
+		}
+	}
+
+	connect := next()
+	if !strings.HasPrefix(connect, "CONNECT ") || !strings.Contains(connect, `"auth_token":"token123"`) {
+		t.Errorf("CONNECT = %q", connect)
+	}
+	if ping := next(); ping != "PING" {
+		t.Errorf("expected PING after CONNECT, got %q", ping)
+	}
+
+	pub := next()
+	if !strings.HasPrefix(pub, "PUB gisty.paste.created ") {
+		t.Errorf("PUB = %q", pub)
+	}
+	var received model.PasteEvent
+	if err := json.Unmarshal([]byte(next()), &received); err != nil || received.ShortID != "abc123" {
+		t.Errorf("payload = %+v, %v", received, err)
+	}
+}
+
+func TestNATSPublisher_Rejected(t *testing.T) {
+	url, _ := fakeNATSServer(t, "-ERR 'Authorization Violation'\r\n")
+
+	publisher, err := NewNATSPublisher(url, "")
+	if err != nil {
+		t.Fatalf("NewNATSPublisher() error = %v", err)
+	}
+
+	err = publisher.Handle(context.Background(), &model.PasteEvent{Type: model.PasteEventRead})
+	if !errors.Is(err, ErrNATSHandshake) {
+		t.Errorf("Handle() error = %v, want ErrNATSHandshake", err)
+	}
+}
+
+func TestNewNATSPublisher_Invalid(t *testing.T) {
+	if _, err := NewNATSPublisher("http://localhost:4222", ""); !errors.Is(err, ErrInvalidNATSURL) {
+		t.Errorf("http URL error = %v, want ErrInvalidNATSURL", err)
+	}
+	if _, err := NewNATSPublisher("", "gisty.*"); !errors.Is(err, ErrInvalidSubjectPrefix) {
+		t.Errorf("wildcard prefix error = %v, want ErrInvalidSubjectPrefix", err)
+	}
+}
@@ -0,0 +1,992 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+	"github.com/huylvt/gisty/internal/worker"
+)
+
+// AdminHandler handles operator-facing admin requests
+type AdminHandler struct {
+	cleanupWorker *worker.CleanupWorker
+	pasteService  *service.PasteService
+	maintenance   *service.Maintenance
+	cache         *service.Cache
+	rateLimiter   *middleware.RateLimiter
+	bans          *service.Bans
+	ipDenyList    *service.IPDenyList
+	drain         *service.Drain
+	cdn           *service.CDNPurger
+	slo           *middleware.SLOTracker
+	// staleDays is the default number of days of the stale routes
+	staleDays int
+	// verifyQuarantine makes verification runs quarantine the corrupt pastes they find
+	verifyQuarantine bool
+}
+
+// NewAdminHandler creates a new AdminHandler
+func NewAdminHandler(cleanupWorker *worker.CleanupWorker, pasteService *service.PasteService, maintenance *service.Maintenance, cache *service.Cache, rateLimiter *middleware.RateLimiter, bans *service.Bans) *AdminHandler {
+	return &AdminHandler{
+		cleanupWorker: cleanupWorker,
+		pasteService:  pasteService,
+		maintenance:   maintenance,
+		cache:         cache,
+		rateLimiter:   rateLimiter,
+		bans:          bans,
+		staleDays:     service.DefaultStaleDays,
+
+		verifyQuarantine: true,
+	}
+}
+
+// SetVerifyQuarantine sets whether verification runs quarantine the corrupt pastes they find or
+// only report them
+func (h *AdminHandler) SetVerifyQuarantine(quarantine bool) {
+	h.verifyQuarantine = quarantine
+}
+
+// SetIPDenyList enables the admin routes blocking IPs and CIDRs
+func (h *AdminHandler) SetIPDenyList(ipDenyList *service.IPDenyList) {
+	h.ipDenyList = ipDenyList
+}
+
+// SetDrain sets the drain state of the instance, served by the drain routes
+func (h *AdminHandler) SetDrain(drain *service.Drain) {
+	h.drain = drain
+}
+
+// SetCDNPurger sets the purger of the CDN fronting the server, served by the CDN purge routes
+func (h *AdminHandler) SetCDNPurger(cdn *service.CDNPurger) {
+	h.cdn = cdn
+}
+
+// SetSLOTracker sets the tracker of the service level objectives, served by the SLO routes
+func (h *AdminHandler) SetSLOTracker(slo *middleware.SLOTracker) {
+	h.slo = slo
+}
+
+// SetStaleDays sets the default number of days without a read of the stale routes
+func (h *AdminHandler) SetStaleDays(days int) {
+	if days > 0 {
+		h.staleDays = days
+	}
+}
+
+// CleanupRunResponse represents the stats of a single cleanup run
+type CleanupRunResponse struct {
+	StartedAt  string `json:"started_at" example:"2024-01-15T14:00:00Z"`
+	DurationMs int64  `json:"duration_ms" example:"42"`
+	Scanned    int64  `json:"scanned" example:"120"`
+	Deleted    int64  `json:"deleted" example:"118"`
+	Failures   int64  `json:"failures" example:"2"`
+	DryRun     bool   `json:"dry_run" example:"false"`
+}
+
+// CleanupStatusResponse represents the cleanup worker status
+type CleanupStatusResponse struct {
+	Interval  string              `json:"interval" example:"5m0s"`
+	DryRun    bool                `json:"dry_run" example:"false"`
+	MinAge    string              `json:"min_age" example:"1h0m0s"`
+	Backlog   int64               `json:"backlog" example:"15"`
+	Retrying  int                 `json:"retrying" example:"0"`  // orphaned objects whose delete failed, retried on later runs
+	Abandoned int                 `json:"abandoned" example:"0"` // orphaned objects given up on after the attempt cap
+	LastRun   *CleanupRunResponse `json:"last_run,omitempty"`
+}
+
+// CleanupStatus godoc
+// @Summary Cleanup worker status
+// @Description Report the last storage reconciliation run, the number of expired pastes awaiting removal by the MongoDB TTL monitor, and the orphaned objects whose delete failed
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} CleanupStatusResponse "Cleanup worker status"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/cleanup [get]
+func (h *AdminHandler) CleanupStatus(c *gin.Context) {
+	status, err := h.cleanupWorker.Status(c.Request.Context())
+	if err != nil {
+		log.Printf("[CleanupStatus] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	response := CleanupStatusResponse{
+		Interval:  status.Interval.String(),
+		DryRun:    status.DryRun,
+		MinAge:    status.MinAge.String(),
+		Backlog:   status.Backlog,
+		Retrying:  status.Retrying,
+		Abandoned: status.Abandoned,
+	}
+
+	if status.LastRun != nil {
+		response.LastRun = &CleanupRunResponse{
+			StartedAt:  status.LastRun.StartedAt.UTC().Format(time.RFC3339),
+			DurationMs: status.LastRun.Duration.Milliseconds(),
+			Scanned:    status.LastRun.Scanned,
+			Deleted:    status.LastRun.Deleted,
+			Failures:   status.LastRun.Failures,
+			DryRun:     status.LastRun.DryRun,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListPastes godoc
+// @Summary List pastes created in a time range
+// @Description Enumerate the pastes created in [from, to), oldest first, for incident review.
+// @Description Filter by creator with ip (hashed server-side) or ip_hash, or by tag; format=csv exports the listing as CSV.
+// @Tags admin
+// @Produce json
+// @Produce text/csv
+// @Security AdminToken
+// @Security BearerAuth
+// @Param from query string true "Start of the range (RFC 3339)" example(2024-01-15T00:00:00Z)
+// @Param to query string false "End of the range (RFC 3339), defaults to now" example(2024-01-16T00:00:00Z)
+// @Param ip query string false "Creator IP address" example(203.0.113.7)
+// @Param ip_hash query string false "Creator IP hash from an earlier listing"
+// @Param tag query string false "Only pastes carrying this tag" example(go)
+// @Param limit query int false "Maximum number of pastes (default 100, max 10000)"
+// @Param format query string false "Response format" Enums(json, csv)
+// @Success 200 {object} service.ListPastesResponse "Pastes created in the range"
+// @Failure 400 {object} ErrorResponse "Invalid time range, IP, limit or IP hashing disabled"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/pastes [get]
+func (h *AdminHandler) ListPastes(c *gin.Context) {
+	query := &service.ListPastesQuery{
+		SourceIP:     c.Query("ip"),
+		SourceIPHash: c.Query("ip_hash"),
+		Tag:          c.Query("tag"),
+	}
+
+	var err error
+	if query.From, err = time.Parse(time.RFC3339, c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidTimeRange))
+		return
+	}
+	if to := c.Query("to"); to != "" {
+		if query.To, err = time.Parse(time.RFC3339, to); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidTimeRange))
+			return
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil || query.Limit <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return
+		}
+	}
+
+	response, err := h.pasteService.ListPastes(c.Request.Context(), query)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTimeRange):
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidTimeRange))
+		case errors.Is(err, service.ErrInvalidSourceIP):
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidIP))
+		case errors.Is(err, service.ErrSourceIPNotRecorded):
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeSourceIPNotRecorded))
+		default:
+			log.Printf("[ListPastes] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		}
+		return
+	}
+
+	log.Printf("[ListPastes] Listed %d pastes from %s to %s (ip filter: %v, format: %s)",
+		len(response.Pastes), response.From, response.To, query.SourceIP != "" || query.SourceIPHash != "", c.Query("format"))
+	if c.Query("format") == "csv" {
+		writePastesCSV(c, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// writePastesCSV sends a paste listing as a CSV attachment
+func writePastesCSV(c *gin.Context, response *service.ListPastesResponse) {
+	filename := fmt.Sprintf("pastes-%s-%s.csv", response.From, response.To)
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	if response.Truncated {
+		c.Header("X-Gisty-Truncated", "true")
+	}
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"short_id", "created_at", "expires_at", "syntax_type", "is_private", "burn_after_read", "is_encrypted", "source_ip_hash", "title", "tags"})
+	for _, paste := range response.Pastes {
+		expiresAt := ""
+		if paste.ExpiresAt != nil {
+			expiresAt = *paste.ExpiresAt
+		}
+		_ = w.Write([]string{
+			paste.ShortID,
+			paste.CreatedAt,
+			expiresAt,
+			paste.SyntaxType,
+			strconv.FormatBool(paste.IsPrivate),
+			strconv.FormatBool(paste.BurnAfterRead),
+			strconv.FormatBool(paste.Encrypted),
+			paste.SourceIPHash,
+			paste.Title,
+			strings.Join(paste.Tags, " "),
+		})
+	}
+	w.Flush()
+}
+
+// MaintenanceRequest represents the request body for toggling maintenance mode
+type MaintenanceRequest struct {
+	Enabled           bool   `json:"enabled" example:"true"`
+	Message           string `json:"message" example:"Database migration in progress"`
+	RetryAfterSeconds int    `json:"retry_after_seconds" example:"300"`
+}
+
+// MaintenanceResponse represents the maintenance mode state
+type MaintenanceResponse struct {
+	Enabled           bool    `json:"enabled" example:"true"`
+	Message           string  `json:"message,omitempty" example:"Database migration in progress"`
+	RetryAfterSeconds int     `json:"retry_after_seconds,omitempty" example:"300"`
+	Since             *string `json:"since,omitempty" example:"2024-01-15T14:00:00Z"`
+}
+
+// GetMaintenance godoc
+// @Summary Get maintenance mode
+// @Description Report whether maintenance mode is active
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} MaintenanceResponse "Maintenance mode state"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/maintenance [get]
+func (h *AdminHandler) GetMaintenance(c *gin.Context) {
+	state, err := h.maintenance.Get(c.Request.Context())
+	if err != nil {
+		log.Printf("[GetMaintenance] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, toMaintenanceResponse(state))
+}
+
+// SetMaintenance godoc
+// @Summary Toggle maintenance mode
+// @Description Enable or disable maintenance mode. While enabled, writes return 503 with Retry-After and reads keep working.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param request body MaintenanceRequest true "Maintenance mode settings"
+// @Success 200 {object} MaintenanceResponse "Maintenance mode state"
+// @Failure 400 {object} ErrorResponse "Invalid request body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/maintenance [put]
+func (h *AdminHandler) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RetryAfterSeconds < 0 {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	ctx := c.Request.Context()
+	state := &service.MaintenanceState{}
+	var err error
+	if req.Enabled {
+		state, err = h.maintenance.Enable(ctx, req.Message, time.Duration(req.RetryAfterSeconds)*time.Second)
+	} else {
+		err = h.maintenance.Disable(ctx)
+	}
+	if err != nil {
+		log.Printf("[SetMaintenance] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	log.Printf("[SetMaintenance] Maintenance mode enabled=%v", state.Enabled)
+	c.JSON(http.StatusOK, toMaintenanceResponse(state))
+}
+
+// toMaintenanceResponse converts a maintenance state to its API representation
+func toMaintenanceResponse(state *service.MaintenanceState) MaintenanceResponse {
+	response := MaintenanceResponse{
+		Enabled: state.Enabled,
+		Message: state.Message,
+	}
+	if state.Enabled {
+		response.RetryAfterSeconds = int(state.RetryAfter.Seconds())
+	}
+	if state.Since != nil {
+		formatted := state.Since.Format(time.RFC3339)
+		response.Since = &formatted
+	}
+	return response
+}
+
+// DrainResponse represents the drain state of the instance
+type DrainResponse struct {
+	Draining bool    `json:"draining" example:"true"`
+	Since    *string `json:"since,omitempty" example:"2024-01-15T14:00:00Z"`
+}
+
+// GetDrain godoc
+// @Summary Get drain state
+// @Description Report whether the instance serving the request is draining before it shuts down
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} DrainResponse "Drain state"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Router /admin/drain [get]
+func (h *AdminHandler) GetDrain(c *gin.Context) {
+	draining, since := h.drain.Draining()
+	c.JSON(http.StatusOK, toDrainResponse(draining, since))
+}
+
+// StartDrain godoc
+// @Summary Drain the instance
+// @Description Drain the instance serving the request ahead of a rolling deploy, like SIGUSR1: /readyz starts failing, background workers stop taking new work and finish their runs, and after DRAIN_DELAY the server finishes in-flight requests and background deletions, then exits. Unlike maintenance mode this applies to one instance and cannot be undone. Draining an instance already draining has no effect.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 202 {object} DrainResponse "Instance draining"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Router /admin/drain [post]
+func (h *AdminHandler) StartDrain(c *gin.Context) {
+	since := h.drain.Start()
+	log.Printf("[StartDrain] Draining since %s", since.Format(time.RFC3339))
+	c.JSON(http.StatusAccepted, toDrainResponse(true, since))
+}
+
+// toDrainResponse converts a drain state to its API representation
+func toDrainResponse(draining bool, since time.Time) DrainResponse {
+	response := DrainResponse{Draining: draining}
+	if draining {
+		formatted := since.Format(time.RFC3339)
+		response.Since = &formatted
+	}
+	return response
+}
+
+// CacheStatsResponse represents the content cache lookup counters
+type CacheStatsResponse struct {
+	Hits      int64   `json:"hits" example:"950"`
+	Misses    int64   `json:"misses" example:"50"`
+	Bypasses  int64   `json:"bypasses" example:"3"`
+	HitRatio  float64 `json:"hit_ratio" example:"0.95"`
+	LocalHits int64   `json:"local_hits" example:"600"` // hits served by the in-process cache, included in hits
+}
+
+// CachePurgeResponse represents the result of a cache purge or flush
+type CachePurgeResponse struct {
+	Purged int64 `json:"purged" example:"1"`
+}
+
+// CacheStats godoc
+// @Summary Cache statistics
+// @Description Report content cache hits, misses and bypasses counted by this instance since it started, and the hits served by its in-process cache
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} CacheStatsResponse "Cache statistics"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Router /admin/cache/stats [get]
+func (h *AdminHandler) CacheStats(c *gin.Context) {
+	stats := h.cache.Stats()
+
+	c.JSON(http.StatusOK, CacheStatsResponse{
+		Hits:      stats.Hits,
+		Misses:    stats.Misses,
+		Bypasses:  stats.Bypasses,
+		HitRatio:  stats.HitRatio(),
+		LocalHits: stats.LocalHits,
+	})
+}
+
+// PurgeCache godoc
+// @Summary Purge a cached paste
+// @Description Remove the cached content of a single paste. The paste itself is kept.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param id path string true "Paste short ID"
+// @Success 200 {object} CachePurgeResponse "Number of cache entries removed"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/cache/{id} [delete]
+func (h *AdminHandler) PurgeCache(c *gin.Context) {
+	shortID := c.Param("id")
+	ctx := c.Request.Context()
+
+	exists, err := h.cache.Exists(ctx, shortID)
+	if err == nil && exists {
+		err = h.cache.Delete(ctx, shortID)
+	}
+	if err != nil {
+		log.Printf("[PurgeCache] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	var purged int64
+	if exists {
+		purged = 1
+	}
+	log.Printf("[PurgeCache] Purged cache for %s: %d", shortID, purged)
+	c.JSON(http.StatusOK, CachePurgeResponse{Purged: purged})
+}
+
+// CDNPurgeResponse represents the result of a CDN purge
+type CDNPurgeResponse struct {
+	Keys []string `json:"purged_keys" example:"paste-xK9a2B"`
+}
+
+// PurgeCDN godoc
+// @Summary Purge a paste from the CDN
+// @Description Remove the rendered views of a paste from the CDN fronting the server, by surrogate key.
+// @Description Edits and deletions purge them automatically.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param id path string true "Paste short ID"
+// @Success 200 {object} CDNPurgeResponse "Surrogate keys purged"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 502 {object} ErrorResponse "CDN purge API failed"
+// @Router /admin/cdn/{id} [delete]
+func (h *AdminHandler) PurgeCDN(c *gin.Context) {
+	h.purgeCDN(c, service.SurrogateKey(c.Param("id")))
+}
+
+// FlushCDN godoc
+// @Summary Purge every rendered view from the CDN
+// @Description Remove the rendered views of all pastes from the CDN fronting the server, as after a change of the page template.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} CDNPurgeResponse "Surrogate keys purged"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 502 {object} ErrorResponse "CDN purge API failed"
+// @Router /admin/cdn [delete]
+func (h *AdminHandler) FlushCDN(c *gin.Context) {
+	h.purgeCDN(c, service.ViewsSurrogateKey)
+}
+
+// purgeCDN purges a surrogate key from the CDN
+func (h *AdminHandler) purgeCDN(c *gin.Context, key string) {
+	if err := h.cdn.Purge(c.Request.Context(), key); err != nil {
+		log.Printf("[PurgeCDN] Error purging %s: %v", key, err)
+		c.JSON(http.StatusBadGateway, middleware.ErrorBody(c, i18n.CodeCDNUnavailable))
+		return
+	}
+
+	log.Printf("[PurgeCDN] Purged %s", key)
+	c.JSON(http.StatusOK, CDNPurgeResponse{Keys: []string{key}})
+}
+
+// FlushCache godoc
+// @Summary Flush the content cache
+// @Description Remove every cached paste from the cache namespace. Pastes are reloaded from storage on the next read.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} CachePurgeResponse "Number of cache entries removed"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/cache [delete]
+func (h *AdminHandler) FlushCache(c *gin.Context) {
+	purged, err := h.cache.Flush(c.Request.Context())
+	if err != nil {
+		log.Printf("[FlushCache] Error after purging %d keys: %v", purged, err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	log.Printf("[FlushCache] Flushed %d cache entries", purged)
+	c.JSON(http.StatusOK, CachePurgeResponse{Purged: purged})
+}
+
+// RateLimitStatusResponse represents the rate limit counters of a client
+type RateLimitStatusResponse struct {
+	IP        string `json:"ip" example:"203.0.113.7"`
+	ClientKey string `json:"client_key" example:"9f86d081884c7d65"`
+	Enabled   bool   `json:"enabled" example:"true"`
+	Limit     int64  `json:"limit" example:"5"`
+	Remaining int64  `json:"remaining" example:"0"`
+	Reset     string `json:"reset" example:"2024-01-15T14:01:00Z"`
+	Limited   bool   `json:"limited" example:"true"`
+}
+
+// GetRateLimit godoc
+// @Summary Inspect a client's rate limit
+// @Description Report the rate limit counters of a client IP without consuming a request.
+// @Description client_key matches the client field of rate limiter log lines.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param ip path string true "Client IP address" example(203.0.113.7)
+// @Success 200 {object} RateLimitStatusResponse "Rate limit counters"
+// @Failure 400 {object} ErrorResponse "Invalid IP address"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/ratelimit/{ip} [get]
+func (h *AdminHandler) GetRateLimit(c *gin.Context) {
+	h.handleRateLimit(c, h.rateLimiter.Inspect)
+}
+
+// ResetRateLimit godoc
+// @Summary Reset a client's rate limit
+// @Description Clear the rate limit counters of a client IP
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param ip path string true "Client IP address" example(203.0.113.7)
+// @Success 200 {object} RateLimitStatusResponse "Rate limit counters after the reset"
+// @Failure 400 {object} ErrorResponse "Invalid IP address"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/ratelimit/{ip} [delete]
+func (h *AdminHandler) ResetRateLimit(c *gin.Context) {
+	h.handleRateLimit(c, h.rateLimiter.Reset)
+}
+
+// handleRateLimit validates the client IP, applies the rate limiter operation and writes the counters
+func (h *AdminHandler) handleRateLimit(c *gin.Context, op func(ctx context.Context, ip string) (*middleware.RateLimitStatus, error)) {
+	ip := c.Param("ip")
+	if net.ParseIP(ip) == nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidIP))
+		return
+	}
+
+	status, err := op(c.Request.Context(), ip)
+	if err != nil {
+		log.Printf("[RateLimit] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, RateLimitStatusResponse{
+		IP:        ip,
+		ClientKey: status.ClientKey,
+		Enabled:   h.rateLimiter.GetConfig().Enabled,
+		Limit:     status.Limit,
+		Remaining: status.Remaining,
+		Reset:     status.Reset.UTC().Format(time.RFC3339),
+		Limited:   status.Limited,
+	})
+}
+
+// Stats godoc
+// @Summary Paste and key pool statistics
+// @Description Report the number of stored pastes, the pre-generated short ID pool and the pastes created on each of the last days (UTC).
+// @Description Daily counts only include pastes still stored.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param days query int false "Number of days of creation counts, today included (default 30, max 366)"
+// @Success 200 {object} service.StatsResponse "Statistics"
+// @Failure 400 {object} ErrorResponse "Invalid number of days"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/stats [get]
+func (h *AdminHandler) Stats(c *gin.Context) {
+	days := 0
+	if raw := c.Query("days"); raw != "" {
+		var err error
+		if days, err = strconv.Atoi(raw); err != nil || days <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidStatsDays))
+			return
+		}
+	}
+
+	response, err := h.pasteService.Stats(c.Request.Context(), days)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidStatsDays) {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidStatsDays))
+			return
+		}
+		log.Printf("[Stats] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ForceDeletePaste godoc
+// @Summary Force-delete a paste
+// @Description Delete any paste whatever its state, including pending uploads and burned pastes, bypassing
+// @Description maintenance mode and delete rate limits. The metadata of the deleted paste is returned for the moderation record.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param id path string true "Paste short ID"
+// @Success 200 {object} service.PasteSummary "Deleted paste"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/pastes/{id} [delete]
+func (h *AdminHandler) ForceDeletePaste(c *gin.Context) {
+	shortID := c.Param("id")
+	summary, err := h.pasteService.ForceDeletePaste(c.Request.Context(), shortID)
+	if err != nil {
+		if errors.Is(err, service.ErrPasteNotFound) {
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodePasteNotFound))
+			return
+		}
+		log.Printf("[ForceDeletePaste] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	log.Printf("[ForceDeletePaste] Deleted %s", shortID)
+	c.JSON(http.StatusOK, summary)
+}
+
+// BanRequest represents the request body for banning an IP
+type BanRequest struct {
+	Reason          string `json:"reason" example:"spam"`
+	DurationSeconds int64  `json:"duration_seconds" example:"86400"` // 0 bans until lifted
+}
+
+// BanListResponse represents the active IP bans
+type BanListResponse struct {
+	Bans []service.IPBan `json:"bans"`
+}
+
+// UnbanResponse represents the result of lifting a ban
+type UnbanResponse struct {
+	IP     string `json:"ip" example:"203.0.113.7"`
+	Lifted bool   `json:"lifted" example:"true"` // false when the IP was not banned
+}
+
+// ListBans godoc
+// @Summary List IP bans
+// @Description List the client IPs barred from creating, editing and deleting pastes, newest first
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} BanListResponse "Active bans"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/bans [get]
+func (h *AdminHandler) ListBans(c *gin.Context) {
+	bans, err := h.bans.List(c.Request.Context())
+	if err != nil {
+		log.Printf("[ListBans] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, BanListResponse{Bans: bans})
+}
+
+// BanIP godoc
+// @Summary Ban an IP
+// @Description Bar a client IP from creating, editing and deleting pastes; reads keep working.
+// @Description Banning an IP again replaces its ban.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param ip path string true "Client IP address" example(203.0.113.7)
+// @Param request body BanRequest false "Ban reason and duration"
+// @Success 200 {object} service.IPBan "Ban"
+// @Failure 400 {object} ErrorResponse "Invalid IP address, reason or duration"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/bans/{ip} [put]
+func (h *AdminHandler) BanIP(c *gin.Context) {
+	var req BanRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+			return
+		}
+	}
+
+	ban, err := h.bans.Ban(c.Request.Context(), c.Param("ip"), req.Reason, time.Duration(req.DurationSeconds)*time.Second)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSourceIP):
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidIP))
+		case errors.Is(err, service.ErrInvalidBan):
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidBan))
+		default:
+			log.Printf("[BanIP] Error: %v", err)
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		}
+		return
+	}
+
+	log.Printf("[BanIP] Banned %s (duration: %ds)", ban.IP, req.DurationSeconds)
+	c.JSON(http.StatusOK, ban)
+}
+
+// UnbanIP godoc
+// @Summary Lift an IP ban
+// @Description Allow a banned client IP to write again
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param ip path string true "Client IP address" example(203.0.113.7)
+// @Success 200 {object} UnbanResponse "Whether a ban was lifted"
+// @Failure 400 {object} ErrorResponse "Invalid IP address"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/bans/{ip} [delete]
+func (h *AdminHandler) UnbanIP(c *gin.Context) {
+	ip := c.Param("ip")
+	lifted, err := h.bans.Unban(c.Request.Context(), ip)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSourceIP) {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidIP))
+			return
+		}
+		log.Printf("[UnbanIP] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	log.Printf("[UnbanIP] Lifted ban of %s: %v", ip, lifted)
+	c.JSON(http.StatusOK, UnbanResponse{IP: ip, Lifted: lifted})
+}
+
+// BlockIPRequest represents the request body for blocking an IP or CIDR
+type BlockIPRequest struct {
+	CIDR            string `json:"cidr" binding:"required" example:"198.51.100.0/24"` // an IP or a CIDR
+	Reason          string `json:"reason" example:"abusive crawler"`
+	DurationSeconds int64  `json:"duration_seconds" example:"86400"` // 0 blocks until lifted
+}
+
+// IPBlockListResponse represents the IPs and CIDRs denied every request
+type IPBlockListResponse struct {
+	Blocks []service.IPBlock `json:"blocks"`
+}
+
+// UnblockIPResponse represents the result of lifting a block
+type UnblockIPResponse struct {
+	CIDR   string `json:"cidr" example:"198.51.100.0/24"`
+	Lifted bool   `json:"lifted" example:"true"` // false when the CIDR was not blocked at runtime
+}
+
+// ListIPBlocks godoc
+// @Summary List IP blocks
+// @Description List the IPs and CIDRs denied every request: those configured (config and file sources) first, then those blocked at runtime (redis source), newest first
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} IPBlockListResponse "Blocks in force"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/denylist [get]
+func (h *AdminHandler) ListIPBlocks(c *gin.Context) {
+	blocks, err := h.ipDenyList.List(c.Request.Context())
+	if err != nil {
+		log.Printf("[ListIPBlocks] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, IPBlockListResponse{Blocks: blocks})
+}
+
+// BlockIP godoc
+// @Summary Block an IP or CIDR
+// @Description Deny every request from an IP or a CIDR with 403, ahead of rate limiting, e.g., to stop an abusive crawler. Unlike a ban, reads are denied too.
+// @Description The block is shared by all replicas through Redis: this one enforces it at once, the others within the reload interval. Blocking a CIDR again replaces its block.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param request body BlockIPRequest true "IP or CIDR, reason and duration"
+// @Success 200 {object} service.IPBlock "Block"
+// @Failure 400 {object} ErrorResponse "Invalid or too wide CIDR, reason or duration"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/denylist [post]
+func (h *AdminHandler) BlockIP(c *gin.Context) {
+	var req BlockIPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	block, err := h.ipDenyList.Block(c.Request.Context(), req.CIDR, req.Reason, time.Duration(req.DurationSeconds)*time.Second)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidIPBlock) {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidIPBlock))
+			return
+		}
+		log.Printf("[BlockIP] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	log.Printf("[BlockIP] Blocked %s (duration: %ds)", block.CIDR, req.DurationSeconds)
+	c.JSON(http.StatusOK, block)
+}
+
+// UnblockIP godoc
+// @Summary Lift an IP block
+// @Description Lift the block of an IP or CIDR added with the admin API. Configured blocks are lifted by changing IP_DENY_LIST or the deny list file.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param cidr query string true "Blocked IP or CIDR" example(198.51.100.0/24)
+// @Success 200 {object} UnblockIPResponse "Whether a block was lifted"
+// @Failure 400 {object} ErrorResponse "Invalid IP or CIDR"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/denylist [delete]
+func (h *AdminHandler) UnblockIP(c *gin.Context) {
+	cidr := c.Query("cidr")
+	lifted, err := h.ipDenyList.Unblock(c.Request.Context(), cidr)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidIPBlock) {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidIPBlock))
+			return
+		}
+		log.Printf("[UnblockIP] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	log.Printf("[UnblockIP] Lifted block of %s: %v", cidr, lifted)
+	c.JSON(http.StatusOK, UnblockIPResponse{CIDR: cidr, Lifted: lifted})
+}
+
+// ExpireStaleResponse represents the result of expiring stale pastes
+type ExpireStaleResponse struct {
+	Days    int   `json:"days" example:"180"`
+	Expired int64 `json:"expired" example:"1200"`
+}
+
+// StaleReport godoc
+// @Summary Stale pastes report
+// @Description List the pastes that never expire and were not read for a number of days, oldest first, with the storage they take.
+// @Description Pastes never read since read times were recorded count from their creation.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param days query int false "Days without a read (default from CLEANUP_STALE_DAYS, max 3650)"
+// @Param limit query int false "Maximum number of pastes listed (default 100, max 10000)"
+// @Success 200 {object} service.StaleReport "Stale pastes"
+// @Failure 400 {object} ErrorResponse "Invalid number of days or limit"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/stale [get]
+func (h *AdminHandler) StaleReport(c *gin.Context) {
+	days, ok := h.staleDaysQuery(c)
+	if !ok {
+		return
+	}
+	limit := 0
+	if raw, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return
+		}
+		limit = n
+	}
+
+	report, err := h.pasteService.StaleReport(c.Request.Context(), days, limit)
+	if err != nil {
+		h.handleStaleError(c, "StaleReport", err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ExpireStale godoc
+// @Summary Expire stale pastes
+// @Description Expire at once every paste that never expires and was not read for a number of days, as listed by the stale report.
+// @Description The pastes stop being served right away; their records and content are removed by the TTL monitor and the cleanup worker.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param days query int false "Days without a read (default from CLEANUP_STALE_DAYS, max 3650)"
+// @Success 200 {object} ExpireStaleResponse "Expired pastes"
+// @Failure 400 {object} ErrorResponse "Invalid number of days"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/stale/expire [post]
+func (h *AdminHandler) ExpireStale(c *gin.Context) {
+	days, ok := h.staleDaysQuery(c)
+	if !ok {
+		return
+	}
+
+	expired, err := h.pasteService.ExpireStalePastes(c.Request.Context(), days)
+	if err != nil {
+		h.handleStaleError(c, "ExpireStale", err)
+		return
+	}
+	log.Printf("[ExpireStale] Expired %d pastes not read for %d days", expired, days)
+	c.JSON(http.StatusOK, ExpireStaleResponse{Days: days, Expired: expired})
+}
+
+// staleDaysQuery reads the days of the stale routes, answering 400 when invalid
+func (h *AdminHandler) staleDaysQuery(c *gin.Context) (int, bool) {
+	raw := c.Query("days")
+	if raw == "" {
+		return h.staleDays, true
+	}
+	days, err := strconv.Atoi(raw)
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidStaleDays))
+		return 0, false
+	}
+	return days, true
+}
+
+// handleStaleError maps errors of the stale routes to HTTP responses
+func (h *AdminHandler) handleStaleError(c *gin.Context, op string, err error) {
+	if errors.Is(err, service.ErrInvalidStaleDays) {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidStaleDays))
+		return
+	}
+	log.Printf("[%s] Error: %v", op, err)
+	c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+}
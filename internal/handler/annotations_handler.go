@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Annotation is a linter finding on a line of a paste
+type Annotation struct {
+	Linter   string `json:"linter" example:"yamllint"`
+	Severity string `json:"severity" example:"warning" enums:"error,warning"`
+	Line     int    `json:"line" example:"3"`
+	Column   int    `json:"column,omitempty" example:"10"`
+	Message  string `json:"message" example:"truthy value should be one of [false, true]"`
+}
+
+// AnnotationsResponse represents the linter annotations of a paste
+type AnnotationsResponse struct {
+	ShortID     string       `json:"short_id" example:"xK9a2B"`
+	Status      string       `json:"status" example:"done" enums:"done,pending,unsupported"`
+	Annotations []Annotation `json:"annotations"`
+	LintedAt    *string      `json:"linted_at,omitempty" example:"2024-01-15T14:00:01Z"`
+}
+
+// GetAnnotations godoc
+// @Summary Get the linter annotations of a paste
+// @Description Return the findings of the linters (gofmt, jsonlint, yamllint) for the current content. Pastes are linted in the background after each create and edit: status is pending until then, and unsupported when no linter applies to the syntax type or the content is encrypted, binary or burn-after-read.
+// @Tags pastes
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Success 200 {object} AnnotationsResponse "Linter annotations"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/annotations [get]
+func (h *PasteHandler) GetAnnotations(c *gin.Context) {
+	response, err := h.pasteService.GetAnnotations(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
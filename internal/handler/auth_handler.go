@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/auth"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+const (
+	// loginStateCookie binds a login callback to the browser that started the login
+	loginStateCookie = "gisty_login_state"
+	// loginStateTTL is the time a user has to consent at the provider
+	loginStateTTL = 10 * time.Minute
+)
+
+// AuthHandler handles sign-in with OAuth providers or a password and the signed-in user's account
+type AuthHandler struct {
+	auth         *auth.Authenticator
+	pasteService *service.PasteService
+	// secureCookies marks cookies Secure, when the API is served over HTTPS
+	secureCookies bool
+	// redirectURL is where browsers are sent after signing in; empty answers with JSON
+	redirectURL string
+}
+
+// NewAuthHandler creates a new AuthHandler
+func NewAuthHandler(authenticator *auth.Authenticator, pasteService *service.PasteService, secureCookies bool) *AuthHandler {
+	return &AuthHandler{
+		auth:          authenticator,
+		pasteService:  pasteService,
+		secureCookies: secureCookies,
+	}
+}
+
+// SetRedirectURL sets where browsers are sent after signing in, such as the frontend; without
+// it the login callback answers with the session token as JSON
+func (h *AuthHandler) SetRedirectURL(url string) {
+	h.redirectURL = url
+}
+
+// LoginResponse represents a completed sign-in
+type LoginResponse struct {
+	// Token is the session token, sent as "Authorization: Bearer <token>" by API clients; browsers
+	// get it in the gisty_session cookie
+	Token     string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiIxIn0.c2ln"`
+	ExpiresAt string      `json:"expires_at" example:"2024-02-14T14:00:00Z"`
+	User      *model.User `json:"user"`
+}
+
+// Login godoc
+// @Summary Sign in with an OAuth provider
+// @Description Redirect to the consent page of the provider (github or google), which sends the user back to the login callback
+// @Tags auth
+// @Param provider path string true "Provider" Enums(github, google)
+// @Success 302 "Redirect to the provider"
+// @Failure 404 {object} ErrorResponse "Provider not enabled"
+// @Router /auth/{provider}/login [get]
+func (h *AuthHandler) Login(c *gin.Context) {
+	state := auth.NewState()
+	url, err := h.auth.LoginURL(c.Param("provider"), state)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.setCookie(c, loginStateCookie, state, loginStateTTL)
+	c.Redirect(http.StatusFound, url)
+}
+
+// Callback godoc
+// @Summary Complete a sign-in
+// @Description Called by the provider after consent: records the user and issues a session token, set in the gisty_session cookie.
+// @Description Redirects to the configured page, or answers with the token when none is configured.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider" Enums(github, google)
+// @Param code query string true "Authorization code"
+// @Param state query string true "Login state"
+// @Success 200 {object} LoginResponse "Signed in"
+// @Success 302 "Signed in, redirect to the configured page"
+// @Failure 400 {object} ErrorResponse "Login expired or started in another browser"
+// @Failure 401 {object} ErrorResponse "Provider did not authorize the sign-in"
+// @Failure 404 {object} ErrorResponse "Provider not enabled"
+// @Failure 502 {object} ErrorResponse "Provider unavailable"
+// @Router /auth/{provider}/callback [get]
+func (h *AuthHandler) Callback(c *gin.Context) {
+	expectedState, _ := c.Cookie(loginStateCookie)
+	h.setCookie(c, loginStateCookie, "", -1)
+
+	user, token, expiresAt, err := h.auth.CompleteLogin(c.Request.Context(), c.Param("provider"), c.Query("code"), c.Query("state"), expectedState, clientOf(c))
+	if err != nil {
+		log.Printf("[Callback] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	h.setCookie(c, middleware.SessionCookie, token, time.Until(expiresAt))
+	if h.redirectURL != "" {
+		c.Redirect(http.StatusFound, h.redirectURL)
+		return
+	}
+	c.JSON(http.StatusOK, LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		User:      user,
+	})
+}
+
+// Logout godoc
+// @Summary Sign out
+// @Description Revoke the session sending the request and clear the session cookie. API tokens are revoked from the session list instead.
+// @Tags auth
+// @Success 204 "Signed out"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.auth.SignOut(c.Request.Context(), middleware.UserID(c), middleware.SessionID(c)); err != nil {
+		// The cookie is cleared anyway
+		log.Printf("[Logout] Failed to revoke the session: %v", err)
+	}
+	h.setCookie(c, middleware.SessionCookie, "", -1)
+	c.Status(http.StatusNoContent)
+}
+
+// Me godoc
+// @Summary My account
+// @Description Return the signed-in user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.User "Signed-in user"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Router /me [get]
+func (h *AuthHandler) Me(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		h.handleError(c, service.ErrSignInRequired)
+		return
+	}
+
+	user, err := h.auth.User(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[Me] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ListMyPastes godoc
+// @Summary My pastes
+// @Description List the pastes created while signed in, newest first. Pass the next cursor of a page as before to get the following page.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of pastes (default 100, max 10000)"
+// @Param before query string false "Next cursor of the previous page, or an RFC 3339 time to list the pastes created before it"
+// @Success 200 {object} service.UserPastesResponse "Pastes of the signed-in user"
+// @Failure 400 {object} ErrorResponse "Invalid limit or cursor"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Router /me/pastes [get]
+func (h *AuthHandler) ListMyPastes(c *gin.Context) {
+	before, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.pasteService.ListUserPastes(c.Request.Context(), middleware.UserID(c), before, limit)
+	if err != nil {
+		log.Printf("[ListMyPastes] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// setCookie sets an HTTP-only cookie on the API host; a negative maxAge deletes it
+func (h *AuthHandler) setCookie(c *gin.Context, name, value string, maxAge time.Duration) {
+	seconds := int(maxAge.Seconds())
+	if maxAge < 0 {
+		seconds = -1
+	}
+	// Lax lets the cookie through the top-level redirect back from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, seconds, "/", "", h.secureCookies, true)
+}
+
+// handleError maps sign-in and account errors to HTTP responses
+func (h *AuthHandler) handleError(c *gin.Context, err error) {
+	var retry *auth.RetryError
+	switch {
+	case errors.As(err, &retry):
+		setRetryAfter(c, retry.RetryAfter)
+		code := i18n.CodeLoginThrottled
+		if errors.Is(err, auth.ErrAccountLocked) {
+			code = i18n.CodeAccountLocked
+		} else if errors.Is(err, auth.ErrSecondFactorThrottled) {
+			code = i18n.CodeSecondFactorThrottled
+		}
+		c.JSON(http.StatusTooManyRequests, middleware.ErrorBody(c, code))
+	case errors.Is(err, auth.ErrUnknownProvider), errors.Is(err, auth.ErrPasswordsDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeUnknownProvider))
+	case errors.Is(err, auth.ErrInvalidState):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLoginState))
+	case errors.Is(err, auth.ErrCodeRejected):
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, i18n.CodeLoginRejected))
+	case errors.Is(err, auth.ErrProviderUnavailable):
+		c.JSON(http.StatusBadGateway, middleware.ErrorBody(c, i18n.CodeProviderUnavailable))
+	case errors.Is(err, auth.ErrInvalidEmail):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidEmail))
+	case errors.Is(err, auth.ErrWeakPassword):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeWeakPassword))
+	case errors.Is(err, auth.ErrEmailTaken):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeEmailTaken))
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, i18n.CodeInvalidCredentials))
+	case errors.Is(err, auth.ErrEmailNotVerified):
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, i18n.CodeEmailNotVerified))
+	case errors.Is(err, auth.ErrInvalidToken):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidToken))
+	case errors.Is(err, auth.ErrTOTPDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeTOTPDisabled))
+	case errors.Is(err, auth.ErrTOTPEnabled):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeTOTPEnabled))
+	case errors.Is(err, auth.ErrTOTPNotEnabled):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeTOTPNotEnabled))
+	case errors.Is(err, auth.ErrSecondFactorRequired):
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, i18n.CodeSecondFactorRequired))
+	case errors.Is(err, auth.ErrInvalidSecondFactor):
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, i18n.CodeInvalidSecondFactor))
+	case errors.Is(err, auth.ErrSessionsDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeSessionsDisabled))
+	case errors.Is(err, auth.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeSessionNotFound))
+	case errors.Is(err, auth.ErrInvalidTokenName):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidTokenName))
+	case errors.Is(err, auth.ErrTooManyTokens):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeTooManyTokens))
+	case errors.Is(err, service.ErrPasteNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodePasteNotFound))
+	case errors.Is(err, service.ErrPasteExpired):
+		c.JSON(http.StatusGone, middleware.ErrorBody(c, i18n.CodePasteExpired))
+	case errors.Is(err, service.ErrNotPinnable):
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, i18n.CodePinForbidden))
+	case errors.Is(err, service.ErrTooManyPins):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeTooManyPins))
+	case errors.Is(err, service.ErrCommentsDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeCommentsDisabled))
+	case errors.Is(err, service.ErrInvalidComment):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidComment))
+	case errors.Is(err, service.ErrCommentsClosed):
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, i18n.CodeCommentsClosed))
+	case errors.Is(err, service.ErrSignInRequired), errors.Is(err, repository.ErrUserNotFound):
+		// A session of a removed user is no longer a sign-in
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, i18n.CodeSignInRequired))
+	default:
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+	}
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// BundleFile represents a file of a bundle
+type BundleFile struct {
+	Path    string `json:"path" binding:"required" example:"cmd/gisty/main.go"`
+	Content string `json:"content" example:"package main"`
+	// Transfer encoding of content, for binary-ish content that JSON would mangle or bloat; stored decoded
+	Encoding   string `json:"encoding,omitempty" example:"base64" enums:"base64,hex"`
+	SyntaxType string `json:"syntax_type,omitempty" example:"go"` // detected from the path and content when omitted
+}
+
+// CreateBundleRequest represents the request body for creating the pastes of a file tree
+type CreateBundleRequest struct {
+	Files       []BundleFile `json:"files" binding:"required"`
+	ExpiresIn   string       `json:"expires_in,omitempty" example:"1w"`
+	IsPrivate   bool         `json:"is_private,omitempty" example:"false"`
+	Description string       `json:"description,omitempty" example:"gisty CLI sources"`
+}
+
+// BundleFileResponse represents a file of a bundle and the paste it was stored as
+type BundleFileResponse struct {
+	Path       string `json:"path" example:"cmd/gisty/main.go"`
+	SyntaxType string `json:"syntax_type" example:"go"`
+	ShortID    string `json:"short_id" example:"xK9a2B"`
+	URL        string `json:"url" example:"http://localhost:8080/xK9a2B"`
+	// DeleteToken is returned once, for files created without an API key or a sign-in; keep it to delete the paste
+	DeleteToken string `json:"delete_token,omitempty" example:"q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"`
+}
+
+// CreateBundleResponse represents the pastes created from a bundle
+type CreateBundleResponse struct {
+	GroupID   string               `json:"group_id" example:"Zk3q9XbW1pLm"`
+	Files     []BundleFileResponse `json:"files"`
+	ExpiresAt *string              `json:"expires_at,omitempty" example:"2024-01-22T14:00:00Z"`
+}
+
+// GroupFile represents a paste of a group
+type GroupFile struct {
+	Path       string `json:"path" example:"cmd/gisty/main.go"` // the file's path in its bundle, or its filename for gist imports
+	ShortID    string `json:"short_id" example:"xK9a2B"`
+	URL        string `json:"url" example:"http://localhost:8080/xK9a2B"`
+	SyntaxType string `json:"syntax_type" example:"go"`
+}
+
+// GroupResponse represents the pastes of a group
+type GroupResponse struct {
+	GroupID string      `json:"group_id" example:"Zk3q9XbW1pLm"`
+	Files   []GroupFile `json:"files"`
+}
+
+// CreateBundle godoc
+// @Summary Create the pastes of a file tree
+// @Description Store each file of a bundle, such as a directory pushed with gisty push, as a paste, in path order. Each paste keeps the file's path as title, its base name as download filename, and the syntax type given or detected from its path and content; all get the description, expiration and privacy of the request. The pastes share a group_id, listed by GET /groups/{id} and shown as a tree in the HTML view. Bundles hold up to 100 files and 1MB of content in total, and are created entirely or not at all.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param request body CreateBundleRequest true "Files of the bundle"
+// @Success 201 {object} CreateBundleResponse "Pastes created"
+// @Failure 400 {object} ErrorResponse "Invalid request (no or too many files, invalid or duplicate path, empty content, invalid encoding, syntax_type or expires_in)"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 413 {object} ErrorResponse "Content too large (max 1MB in total)"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} ErrorResponse "Storage or database unavailable"
+// @Router /bundles [post]
+func (h *PasteHandler) CreateBundle(c *gin.Context) {
+	var req service.CreateBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreateBundle] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.SourceIP = c.ClientIP()
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
+
+	response, err := h.pasteService.CreateBundle(c.Request.Context(), &req)
+	if err != nil {
+		log.Printf("[CreateBundle] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetGroup godoc
+// @Summary List the pastes of a group
+// @Description List the pastes created together from a bundle or a multi-file gist, in path order, without their content. Reading the list does not count views.
+// @Tags pastes
+// @Produce json
+// @Param id path string true "Group ID" example(Zk3q9XbW1pLm)
+// @Success 200 {object} GroupResponse "Pastes of the group"
+// @Failure 404 {object} ErrorResponse "Group not found"
+// @Router /groups/{id} [get]
+func (h *PasteHandler) GetGroup(c *gin.Context) {
+	response, err := h.pasteService.GetGroup(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RawArchive godoc
+// @Summary Download the files of a multi-file paste
+// @Description Download every file of the bundle or multi-file gist a paste belongs to as one archive, each file under its path in the bundle. The format is chosen with ?format=, or else with an Accept of application/zip or application/x-tar; multi-file pastes default to zip. Files are streamed from storage one at a time without counting views, so burn-after-read and view-limited pastes are left out, as are files deleted or expired since. The ID may also be a group ID. A paste created alone is served as raw content, as on its share link, unless a format is asked for.
+// @Tags raw
+// @Produce application/zip,application/x-tar,plain
+// @Param id path string true "Paste short ID or group ID" example(xK9a2B)
+// @Param format query string false "Archive format" Enums(zip, tar)
+// @Param Accept header string false "application/zip or application/x-tar, when no format is given"
+// @Success 200 {file} file "Archive of the files"
+// @Failure 400 {object} ErrorResponse "Invalid format"
+// @Failure 404 {object} ErrorResponse "Paste or group not found"
+// @Failure 409 {object} ErrorResponse "Paste content archived until restored"
+// @Failure 503 {object} ErrorResponse "Storage unavailable"
+// @Router /raw/{id} [get]
+func (h *PasteHandler) RawArchive(c *gin.Context) {
+	format, ok := archiveFormat(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidArchiveFormat))
+		return
+	}
+	c.Writer.Header().Add("Vary", "Accept")
+
+	id := c.Param("id")
+	group, err := h.pasteService.GetPasteGroup(c.Request.Context(), id)
+	if err != nil {
+		h.handleShortURLError(c, err)
+		return
+	}
+	if format == "" {
+		if group.GroupID == "" {
+			h.ShortURL(c)
+			return
+		}
+		format = service.ArchiveZip
+	}
+
+	h.serveArchive(c, group, id, format, h.handleShortURLError)
+}
+
+// DownloadPaste godoc
+// @Summary Download a paste
+// @Description Download a paste as an attachment named after its download filename, or its short ID with the usual extension of its syntax type (e.g. xK9a2B.py). A paste of a bundle or multi-file gist downloads the files of its group as a zip archive named after the group, each file under its path; ?format=tar asks for a tar archive instead, and ?format= also archives a paste created alone. The ID may also be a group ID. Content is streamed from storage. Downloading a paste counts a view; downloading an archive does not, and leaves out burn-after-read and view-limited pastes.
+// @Tags pastes
+// @Produce plain,application/octet-stream,application/zip,application/x-tar
+// @Param id path string true "Paste short ID or group ID" example(xK9a2B)
+// @Param format query string false "Archive format" Enums(zip, tar)
+// @Success 200 {file} file "Paste content, or archive of the files of its group"
+// @Failure 400 {object} ErrorResponse "Invalid format"
+// @Failure 404 {object} ErrorResponse "Paste or group not found"
+// @Failure 409 {object} ErrorResponse "Paste content archived until restored"
+// @Failure 410 {object} ErrorResponse "Paste expired"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} ErrorResponse "Storage unavailable"
+// @Router /pastes/{id}/download [get]
+func (h *PasteHandler) DownloadPaste(c *gin.Context) {
+	format := strings.ToLower(c.Query("format"))
+	if format != "" && format != service.ArchiveZip && format != service.ArchiveTar {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidArchiveFormat))
+		return
+	}
+
+	id := c.Param("id")
+	group, err := h.pasteService.GetPasteGroup(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if format == "" && group.GroupID != "" {
+		format = service.ArchiveZip
+	}
+	if format != "" {
+		h.serveArchive(c, group, id, format, h.handleError)
+		return
+	}
+
+	response, content, err := h.pasteService.OpenPaste(c.Request.Context(), group.Files[0].ShortID, acceptsGzip(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	defer content.Close()
+
+	var extraHeaders map[string]string
+	if response.ContentEncoding != "" {
+		extraHeaders = map[string]string{"Content-Encoding": response.ContentEncoding}
+	}
+	contentType := "text/plain; charset=utf-8"
+	if response.Binary || response.Encrypted {
+		contentType = "application/octet-stream"
+	} else if response.Delivery != nil && response.Delivery.ContentType != "" {
+		contentType = response.Delivery.ContentType
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": response.DownloadFilename()}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	extendWriteDeadline(c)
+	c.DataFromReader(http.StatusOK, -1, contentType, content, extraHeaders)
+}
+
+// serveArchive streams the files of a group as an archive attachment named after the group, or
+// after id for a paste created alone. Errors before anything is written are answered with
+// handleErr; later ones cut the archive short.
+func (h *PasteHandler) serveArchive(c *gin.Context, group *service.GroupResponse, id, format string, handleErr func(*gin.Context, error)) {
+	name := group.GroupID
+	if name == "" {
+		name = id
+	}
+	contentType := "application/zip"
+	if format == service.ArchiveTar {
+		contentType = "application/x-tar"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name + "." + format}))
+	c.Status(http.StatusOK)
+
+	extendWriteDeadline(c)
+	if err := h.pasteService.WriteArchive(c.Request.Context(), c.Writer, group, format); err != nil {
+		log.Printf("[serveArchive] Error: %v", err)
+		if c.Writer.Written() {
+			// The archive is cut short; clients see it truncated
+			c.Abort()
+			return
+		}
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		handleErr(c, err)
+	}
+}
+
+// archiveFormat returns the archive format asked for with ?format=, or else with the Accept
+// header; it is empty when none is. It reports false for an unknown format.
+func archiveFormat(c *gin.Context) (string, bool) {
+	if format, present := c.GetQuery("format"); present {
+		format = strings.ToLower(format)
+		return format, format == service.ArchiveZip || format == service.ArchiveTar
+	}
+	accept := c.GetHeader("Accept")
+	switch {
+	case strings.Contains(accept, "application/zip"):
+		return service.ArchiveZip, true
+	case strings.Contains(accept, "application/x-tar"):
+		return service.ArchiveTar, true
+	}
+	return "", true
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/service"
+)
+
+func TestBuildTree(t *testing.T) {
+	files := []service.GroupFile{
+		{Path: "README.md", ShortID: "aaaaaa"},
+		{Path: "cmd/gisty/main.go", ShortID: "bbbbbb"},
+		{Path: "cmd/gisty/push.go", ShortID: "cccccc"},
+		{Path: "docs/api.md", ShortID: "dddddd"},
+	}
+
+	tree := buildTree(files, "cccccc")
+	if len(tree) != 3 {
+		t.Fatalf("buildTree() has %d top-level nodes, want 3", len(tree))
+	}
+	if tree[0].Name != "README.md" || tree[0].Children != nil || tree[0].ShortID != "aaaaaa" {
+		t.Errorf("first node = %+v, want the README.md file", tree[0])
+	}
+
+	cmd := tree[1]
+	if cmd.Name != "cmd" || len(cmd.Children) != 1 || cmd.Children[0].Name != "gisty" {
+		t.Fatalf("second node = %+v, want the cmd/gisty directory", cmd)
+	}
+	gisty := cmd.Children[0].Children
+	if len(gisty) != 2 || gisty[0].Name != "main.go" || gisty[1].Name != "push.go" {
+		t.Fatalf("cmd/gisty = %+v, want main.go and push.go", gisty)
+	}
+	if gisty[0].Current || !gisty[1].Current {
+		t.Errorf("current file = main.go %v, push.go %v, want push.go only", gisty[0].Current, gisty[1].Current)
+	}
+
+	if docs := tree[2]; docs.Name != "docs" || len(docs.Children) != 1 || docs.Children[0].ShortID != "dddddd" {
+		t.Errorf("third node = %+v, want the docs directory", docs)
+	}
+}
+
+func TestPasteHandler_Bundle(t *testing.T) {
+	pasteService, _, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	pasteHandler := NewPasteHandler(pasteService)
+	pasteHandler.SetHTMLView(true)
+	router := newTestRouter(nil, &RouterDeps{PasteHandler: pasteHandler})
+
+	w := serve(router, http.MethodPost, "/api/v1/bundles", `{"files":[
+		{"path":"cmd/demo/main.go","content":"package main\n"},
+		{"path":"README.md","content":"# Demo\n"}
+	]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /bundles status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	var bundle service.CreateBundleResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &bundle); err != nil || bundle.GroupID == "" || len(bundle.Files) != 2 {
+		t.Fatalf("POST /bundles response = %s, want a group of 2 files", w.Body)
+	}
+
+	// The group lists its files in path order
+	w = serve(router, http.MethodGet, "/api/v1/groups/"+bundle.GroupID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /groups status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var group service.GroupResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &group); err != nil || len(group.Files) != 2 {
+		t.Fatalf("GET /groups response = %s, want 2 files", w.Body)
+	}
+	if group.Files[0].Path != "README.md" || group.Files[1].Path != "cmd/demo/main.go" {
+		t.Errorf("GET /groups paths = %q, %q, want path order", group.Files[0].Path, group.Files[1].Path)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/groups/missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET /groups of a missing group status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// The HTML view of a file shows the tree of the bundle, linking to the other files
+	main, readme := group.Files[1], group.Files[0]
+	w = serve(router, http.MethodGet, "/"+main.ShortID, "", "Accept", "text/html")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /:id view status = %d, want %d", w.Code, http.StatusOK)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		`<nav class="tree"`,
+		`<span class="dir">cmd/</span>`,
+		`<span class="current">main.go</span>`,
+		`<a href="/` + readme.ShortID + `">README.md</a>`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("HTML view does not contain %s", want)
+		}
+	}
+}
+
+func TestPasteHandler_ArchiveInvalidFormat(t *testing.T) {
+	// The format is checked before anything is looked up
+	pasteHandler := NewPasteHandler(service.NewPasteService(nil, nil, nil, nil, "http://localhost:8080"))
+	router := newTestRouter(nil, &RouterDeps{PasteHandler: pasteHandler})
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"raw", "/raw/xK9a2B?format=rar"},
+		{"download", "/api/v1/pastes/xK9a2B/download?format=7z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(router, http.MethodGet, tt.target, ""); w.Code != http.StatusBadRequest {
+				t.Errorf("GET %s status = %d, want %d", tt.target, w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestPasteHandler_Archive(t *testing.T) {
+	pasteService, _, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	router := newTestRouter(nil, &RouterDeps{PasteHandler: NewPasteHandler(pasteService)})
+
+	w := serve(router, http.MethodPost, "/api/v1/bundles", `{"files":[
+		{"path":"README.md","content":"# Demo\n"},
+		{"path":"cmd/demo/main.go","content":"package main\n"}
+	]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /bundles status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	var bundle service.CreateBundleResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &bundle); err != nil || len(bundle.Files) != 2 {
+		t.Fatalf("POST /bundles response = %s, want a group of 2 files", w.Body)
+	}
+	want := map[string]string{"README.md": "# Demo\n", "cmd/demo/main.go": "package main\n"}
+
+	// A file of a bundle gives a zip of the bundle by default
+	w = serve(router, http.MethodGet, "/raw/"+bundle.Files[0].ShortID, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("GET /raw status = %d, Content-Type = %q, want a zip", w.Code, w.Header().Get("Content-Type"))
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `attachment; filename=`+bundle.GroupID+`.zip` {
+		t.Errorf("GET /raw Content-Disposition = %q, want the group's zip", got)
+	}
+	if got := unzip(t, w.Body.Bytes()); !equalFiles(got, want) {
+		t.Errorf("GET /raw zip = %v, want %v", got, want)
+	}
+
+	// Tar is asked for with the format or the Accept header
+	for _, headers := range [][]string{{}, {"Accept", "application/x-tar"}} {
+		target := "/raw/" + bundle.GroupID
+		if len(headers) == 0 {
+			target += "?format=tar"
+		}
+		w = serve(router, http.MethodGet, target, "", headers...)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/x-tar" {
+			t.Fatalf("GET %s status = %d, Content-Type = %q, want a tar", target, w.Code, w.Header().Get("Content-Type"))
+		}
+		if got := untar(t, w.Body.Bytes()); !equalFiles(got, want) {
+			t.Errorf("GET %s tar = %v, want %v", target, got, want)
+		}
+	}
+
+	// Downloading a file of a bundle gives the zip too
+	w = serve(router, http.MethodGet, "/api/v1/pastes/"+bundle.Files[1].ShortID+"/download", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Errorf("GET /download status = %d, Content-Type = %q, want a zip", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// A paste created alone is raw content unless a format is asked for
+	solo, err := pasteService.CreatePaste(context.Background(), &service.CreatePasteRequest{Content: "hello"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	if w := serve(router, http.MethodGet, "/raw/"+solo.ShortID, ""); w.Code != http.StatusOK || w.Body.String() != "hello" {
+		t.Errorf("GET /raw of a single paste = %d %q, want its content", w.Code, w.Body)
+	}
+	w = serve(router, http.MethodGet, "/raw/"+solo.ShortID+"?format=zip", "")
+	if got := unzip(t, w.Body.Bytes()); !equalFiles(got, map[string]string{solo.ShortID: "hello"}) {
+		t.Errorf("GET /raw?format=zip of a single paste = %v, want the paste under its ID", got)
+	}
+
+	if w := serve(router, http.MethodGet, "/raw/missing?format=zip", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET /raw of a missing paste status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// unzip returns the files of a zip archive by name
+func unzip(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+// untar returns the files of a tar archive by name
+func untar(t *testing.T, archive []byte) map[string]string {
+	t.Helper()
+	tr := tar.NewReader(bytes.NewReader(archive))
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatalf("tar Next() error = %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		files[header.Name] = string(content)
+	}
+}
+
+// equalFiles reports whether two sets of files by name hold the same files
+func equalFiles(got, want map[string]string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for name, content := range want {
+		if got[name] != content {
+			return false
+		}
+	}
+	return true
+}
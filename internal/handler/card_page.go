@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// cardLines is the number of lines of content shown in a link card
+	cardLines = 5
+	// maxCardDescription caps the description of a link card in bytes
+	maxCardDescription = 300
+)
+
+// unfurlBots are substrings of the user agents of chat apps and social networks fetching shared
+// links to show a card. They are served the card without the paste being read.
+var unfurlBots = []string{
+	"slackbot",
+	"slack-imgproxy",
+	"discordbot",
+	"twitterbot",
+	"facebookexternalhit",
+	"linkedinbot",
+	"telegrambot",
+	"whatsapp",
+	"mattermost",
+	"skypeuripreview",
+	"microsoft teams",
+	"redditbot",
+	"embedly",
+	"iframely",
+}
+
+// isUnfurlBot reports whether a user agent fetches links to unfurl them
+func isUnfurlBot(userAgent string) bool {
+	userAgent = strings.ToLower(userAgent)
+	for _, bot := range unfurlBots {
+		if strings.Contains(userAgent, bot) {
+			return true
+		}
+	}
+	return false
+}
+
+// cardMetaTemplate renders the OpenGraph and Twitter card tags of a paste, in the head of the
+// card and view pages
+const cardMetaTemplate = `<meta name="description" content="{{.Description}}">
+<meta property="og:site_name" content="gisty">
+<meta property="og:type" content="article">
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:description" content="{{.Description}}">
+<meta name="twitter:card" content="summary">
+<meta name="twitter:title" content="{{.Title}}">
+<meta name="twitter:description" content="{{.Description}}">
+<meta name="twitter:label1" content="Language">
+<meta name="twitter:data1" content="{{.Language}}">
+{{if .LineCount}}<meta name="twitter:label2" content="Lines">
+<meta name="twitter:data2" content="{{.LineCount}}">
+{{end}}`
+
+// cardPage is the minimal page served to link unfurlers on GET /:id: the card tags and the
+// first lines, without styles or scripts
+var cardPage = withCardMeta(template.Must(template.New("card").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>{{.Title}} · gisty</title>
+{{template "meta" .}}</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Language}}{{if .LineCount}} · {{.LineCount}} lines{{end}}</p>
+{{if .Lines}}<pre>{{range .Lines}}{{.}}
+{{end}}</pre>{{else}}<p>{{.Description}}</p>{{end}}
+<p><a href="/{{.ShortID}}">Open the paste</a></p>
+</body>
+</html>`)))
+
+// withCardMeta adds the card tags to a page template, as its "meta" template
+func withCardMeta(page *template.Template) *template.Template {
+	template.Must(page.New("meta").Parse(cardMetaTemplate))
+	return page
+}
+
+// cardData is the data of a link card
+type cardData struct {
+	ShortID     string
+	Title       string
+	Language    string
+	Description string
+	Lines       []string // first lines of content; empty when the card must not reveal it
+	LineCount   int
+}
+
+// newCard builds the card of a paste from its first lines. Cards of pastes whose content must
+// not be revealed before they are opened describe the paste instead.
+func newCard(shortID, title, syntaxType string, lines []string, lineCount int, notice string) cardData {
+	card := cardData{
+		ShortID:   shortID,
+		Title:     title,
+		Language:  syntaxType,
+		Lines:     lines,
+		LineCount: lineCount,
+	}
+	if card.Title == "" {
+		card.Title = shortID
+	}
+	if card.Language == "" {
+		card.Language = "plaintext"
+	}
+	if notice != "" {
+		card.Lines = nil
+		card.Description = notice
+		return card
+	}
+
+	description := strings.Join(lines, "\n")
+	if len(description) > maxCardDescription {
+		// Cut on a rune boundary
+		cut := maxCardDescription
+		for cut > 0 && !utf8.RuneStart(description[cut]) {
+			cut--
+		}
+		description = description[:cut] + "…"
+	}
+	card.Description = description
+	if card.Description == "" {
+		card.Description = "Empty " + card.Language + " paste"
+	}
+	return card
+}
+
+// cardNotice describes a paste whose content a card must not reveal, or returns "" if its
+// first lines may be shown
+func cardNotice(burnAfterRead bool, maxViews int, encrypted, binary bool) string {
+	switch {
+	case burnAfterRead:
+		return "Burn after reading: this paste can be opened once."
+	case maxViews > 0:
+		return "This paste can be opened " + strconv.Itoa(maxViews) + " times."
+	case encrypted:
+		return "This paste is encrypted in the browser."
+	case binary:
+		return "This paste holds binary content."
+	default:
+		return ""
+	}
+}
+
+// renderCard serves link unfurlers a card of the paste built from its preview: it never counts
+// a view or burns a burn-after-read paste, so sharing a link in a chat does not use it up
+func (h *PasteHandler) renderCard(c *gin.Context, shortID string) {
+	preview, err := h.pasteService.GetPreview(c.Request.Context(), shortID, cardLines)
+	if err != nil {
+		h.handleShortURLError(c, err)
+		return
+	}
+
+	notice := cardNotice(preview.BurnAfterRead, preview.MaxViews, preview.Encrypted, preview.Binary)
+	if notice == "" && len(preview.Lines) == 0 {
+		// Archived and quarantined pastes are previewed without lines
+		notice = "Open the link to view this paste."
+	}
+	card := newCard(preview.ShortID, preview.Title, preview.SyntaxType, preview.Lines, preview.LineCount, notice)
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Security-Policy", "default-src 'none'; base-uri 'none'; form-action 'none'")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := cardPage.Execute(c.Writer, card); err != nil {
+		log.Printf("[PasteHandler.renderCard] Failed to render %s: %v", shortID, err)
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/service"
+)
+
+func TestIsUnfurlBot(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      bool
+	}{
+		{"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true},
+		{"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"TelegramBot (like TwitterBot)", true},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", false},
+		{"curl/8.5.0", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isUnfurlBot(tt.userAgent); got != tt.want {
+			t.Errorf("isUnfurlBot(%q) = %v, want %v", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+func TestNewCard(t *testing.T) {
+	card := newCard("xK9a2B", "", "", []string{"print('hello')", "print('world')"}, 2, "")
+	if card.Title != "xK9a2B" || card.Language != "plaintext" {
+		t.Errorf("newCard() title, language = %q, %q, want the short ID and plaintext", card.Title, card.Language)
+	}
+	if card.Description != "print('hello')\nprint('world')" {
+		t.Errorf("newCard() description = %q, want the first lines", card.Description)
+	}
+
+	// A notice replaces the lines of pastes that must not be revealed
+	card = newCard("xK9a2B", "secret.txt", "text", []string{"password"}, 1, cardNotice(true, 0, false, false))
+	if card.Lines != nil || strings.Contains(card.Description, "password") {
+		t.Errorf("newCard() with a notice = %+v, want no content", card)
+	}
+
+	// Long descriptions are cut on a rune boundary
+	card = newCard("xK9a2B", "", "", []string{strings.Repeat("é", maxCardDescription)}, 1, "")
+	if len(card.Description) > maxCardDescription+len("…") || !strings.HasSuffix(card.Description, "…") {
+		t.Errorf("newCard() description of %d bytes, want at most %d ending with an ellipsis", len(card.Description), maxCardDescription)
+	}
+	if !strings.HasPrefix(card.Description, "é") || strings.ContainsRune(card.Description, '�') {
+		t.Errorf("newCard() description cut inside a rune: %q", card.Description)
+	}
+}
+
+func TestPasteHandler_UnfurlCard(t *testing.T) {
+	pasteService, _, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	router := newTestRouter(nil, &RouterDeps{PasteHandler: NewPasteHandler(pasteService)})
+	ctx := context.Background()
+
+	paste, err := pasteService.CreatePaste(ctx, &service.CreatePasteRequest{Content: "print('hello')\n", Title: "hello.py", SyntaxType: "python"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	secret, err := pasteService.CreatePaste(ctx, &service.CreatePasteRequest{Content: "password", ExpiresIn: "burn"})
+	if err != nil {
+		t.Fatalf("CreatePaste(burn) error = %v", err)
+	}
+
+	// Unfurlers get a card, whatever they accept
+	w := serve(router, http.MethodGet, "/"+paste.ShortID, "", "User-Agent", "Slackbot-LinkExpanding 1.0", "Accept", "*/*")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("GET /:id as Slackbot = %d %q, want an HTML card", w.Code, w.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`<meta property="og:title" content="hello.py">`,
+		`<meta property="og:description" content="print(&#39;hello&#39;)">`,
+		`<meta name="twitter:data1" content="python">`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("card does not contain %s", want)
+		}
+	}
+
+	// Other clients get the content, and the card did not count a view
+	if w := serve(router, http.MethodGet, "/"+paste.ShortID, "", "User-Agent", "curl/8.5.0"); w.Body.String() != "print('hello')\n" {
+		t.Errorf("GET /:id as curl = %q, want the content", w.Body)
+	}
+	w = serve(router, http.MethodGet, "/api/v1/pastes/"+paste.ShortID, "")
+	var read service.GetPasteResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &read); err != nil || read.Views != 2 {
+		t.Errorf("GET /pastes/:id views = %d, %v, want 2 reads and no card", read.Views, err)
+	}
+
+	// The card of a burn-after-read paste does not reveal or burn it
+	w = serve(router, http.MethodGet, "/"+secret.ShortID, "", "User-Agent", "Discordbot/2.0")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "password") {
+		t.Errorf("GET /:id of a burn-after-read paste as Discordbot = %d, revealing the content", w.Code)
+	}
+	if w := serve(router, http.MethodGet, "/"+secret.ShortID, ""); w.Code != http.StatusOK || w.Body.String() != "password" {
+		t.Errorf("GET /:id after the card = %d %q, want the content", w.Code, w.Body)
+	}
+}
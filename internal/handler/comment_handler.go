@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// ListComments godoc
+// @Summary List comments on a paste
+// @Description List the comments on a paste, newest first. Pass the next cursor of a page as before to get the following page.
+// @Tags comments
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param limit query int false "Number of comments (default 50, max 100)"
+// @Param before query string false "Next cursor of the previous page, or an RFC 3339 time to list the comments created before it"
+// @Success 200 {object} service.CommentsResponse "Comments"
+// @Failure 400 {object} ErrorResponse "Invalid limit or cursor"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/comments [get]
+func (h *AuthHandler) ListComments(c *gin.Context) {
+	before, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.pasteService.ListComments(c.Request.Context(), c.Param("id"), before, limit)
+	if err != nil {
+		log.Printf("[ListComments] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateComment godoc
+// @Summary Comment on a paste
+// @Description Comment on a paste as the signed-in user. The user who created the paste and the users mentioned as @username are notified.
+// @Description Burn-after-read and view-limited pastes take no comments.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param request body service.CreateCommentRequest true "Comment"
+// @Success 201 {object} model.Comment "Comment posted"
+// @Failure 400 {object} ErrorResponse "Empty comment or longer than 2000 characters"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 403 {object} ErrorResponse "Paste takes no comments"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/comments [post]
+func (h *AuthHandler) CreateComment(c *gin.Context) {
+	var req service.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	comment, err := h.pasteService.CreateComment(c.Request.Context(), middleware.UserID(c), c.Param("id"), &req)
+	if err != nil {
+		log.Printf("[CreateComment] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, comment)
+}
+
+// ListNotifications godoc
+// @Summary My notifications
+// @Description List the signed-in user's notifications of comments on their pastes and of comments mentioning them, newest first.
+// @Description Notifications are kept for 90 days. Pass the next cursor of a page as before to get the following page.
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param limit query int false "Number of notifications (default 50, max 100)"
+// @Param before query string false "Next cursor of the previous page, or an RFC 3339 time to list the notifications created before it"
+// @Success 200 {object} service.NotificationsResponse "Notifications"
+// @Failure 400 {object} ErrorResponse "Invalid limit or cursor"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Router /me/notifications [get]
+func (h *AuthHandler) ListNotifications(c *gin.Context) {
+	before, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	unread, _ := strconv.ParseBool(c.Query("unread"))
+
+	response, err := h.pasteService.ListNotifications(c.Request.Context(), middleware.UserID(c), unread, before, limit)
+	if err != nil {
+		log.Printf("[ListNotifications] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// MarkNotificationsRead godoc
+// @Summary Mark my notifications read
+// @Description Mark the listed notifications of the signed-in user read, or all of them with all set
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.MarkReadRequest true "Notifications to mark read"
+// @Success 200 {object} service.MarkReadResponse "Notifications marked read"
+// @Failure 400 {object} ErrorResponse "Invalid request body"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Router /me/notifications/read [post]
+func (h *AuthHandler) MarkNotificationsRead(c *gin.Context) {
+	var req service.MarkReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.IDs) > service.MaxCommentLimit {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	response, err := h.pasteService.MarkNotificationsRead(c.Request.Context(), middleware.UserID(c), &req)
+	if err != nil {
+		log.Printf("[MarkNotificationsRead] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,387 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/middleware"
+)
+
+// ConnectService is the fully-qualified name of the typed paste API served with the Connect
+// protocol and gRPC-Web, at POST /gisty.v1.PasteService/<procedure>
+const ConnectService = "gisty.v1.PasteService"
+
+const (
+	// connectJSON is the content type of Connect unary requests and responses
+	connectJSON = "application/json"
+	// grpcWebJSON is the content type of gRPC-Web requests and responses with JSON messages
+	grpcWebJSON = "application/grpc-web+json"
+	// grpcWebTrailer flags the frame carrying the status of a gRPC-Web response
+	grpcWebTrailer = 0x80
+)
+
+// connectProcedure maps a procedure of the typed API onto the REST route serving it, so both
+// share one definition: the messages are the JSON request and response bodies of the route.
+// The id field of a request fills the :id path parameter and the query fields its query
+// parameters.
+type connectProcedure struct {
+	method string
+	path   string
+	query  []string
+}
+
+var connectProcedures = map[string]connectProcedure{
+	"CreatePaste":   {method: http.MethodPost, path: "/api/v1/pastes"},
+	"GetPaste":      {method: http.MethodGet, path: "/api/v1/pastes/:id", query: []string{"max_bytes", "encoding", "delivery"}},
+	"GetPastes":     {method: http.MethodPost, path: "/api/v1/pastes/bulk-get"},
+	"UpdatePaste":   {method: http.MethodPut, path: "/api/v1/pastes/:id", query: []string{"delete_token"}},
+	"DeletePaste":   {method: http.MethodDelete, path: "/api/v1/pastes/:id", query: []string{"delete_token"}},
+	"ListRevisions": {method: http.MethodGet, path: "/api/v1/pastes/:id/revisions"},
+	"ForkPaste":     {method: http.MethodPost, path: "/api/v1/pastes/:id/fork"},
+	"CreateBundle":  {method: http.MethodPost, path: "/api/v1/bundles"},
+	"GetGroup":      {method: http.MethodGet, path: "/api/v1/groups/:id"},
+}
+
+// connectCode is a Connect error code with the matching gRPC status and the HTTP status of
+// Connect error responses
+type connectCode struct {
+	name   string
+	grpc   int
+	status int
+}
+
+var (
+	codeInvalidArgument    = connectCode{"invalid_argument", 3, http.StatusBadRequest}
+	codeDeadlineExceeded   = connectCode{"deadline_exceeded", 4, http.StatusGatewayTimeout}
+	codeNotFound           = connectCode{"not_found", 5, http.StatusNotFound}
+	codePermissionDenied   = connectCode{"permission_denied", 7, http.StatusForbidden}
+	codeResourceExhausted  = connectCode{"resource_exhausted", 8, http.StatusTooManyRequests}
+	codeFailedPrecondition = connectCode{"failed_precondition", 9, http.StatusBadRequest}
+	codeAborted            = connectCode{"aborted", 10, http.StatusConflict}
+	codeUnimplemented      = connectCode{"unimplemented", 12, http.StatusNotImplemented}
+	codeInternal           = connectCode{"internal", 13, http.StatusInternalServerError}
+	codeUnavailable        = connectCode{"unavailable", 14, http.StatusServiceUnavailable}
+	codeUnauthenticated    = connectCode{"unauthenticated", 16, http.StatusUnauthorized}
+	codeUnknown            = connectCode{"unknown", 2, http.StatusInternalServerError}
+)
+
+// connectCodeOf returns the Connect error code of the HTTP status of a REST error
+func connectCodeOf(status int) connectCode {
+	switch status {
+	case http.StatusBadRequest:
+		return codeInvalidArgument
+	case http.StatusUnauthorized:
+		return codeUnauthenticated
+	case http.StatusForbidden:
+		return codePermissionDenied
+	case http.StatusNotFound, http.StatusGone:
+		return codeNotFound
+	case http.StatusConflict:
+		return codeAborted
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codeResourceExhausted
+	case http.StatusUnprocessableEntity:
+		return codeFailedPrecondition
+	case http.StatusNotImplemented:
+		return codeUnimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codeUnavailable
+	case http.StatusGatewayTimeout:
+		return codeDeadlineExceeded
+	case http.StatusInternalServerError:
+		return codeInternal
+	}
+	return codeUnknown
+}
+
+// connectRequestHeaders are protocol headers of Connect and gRPC-Web requests, or headers that
+// would change the REST response, dropped before a request is served by its REST route
+var connectRequestHeaders = []string{
+	"Content-Type", "Content-Length", "Content-Encoding", "Accept-Encoding", "If-None-Match",
+	"Connect-Protocol-Version", "Connect-Timeout-Ms", "Connect-Content-Encoding", "Connect-Accept-Encoding",
+	"Grpc-Timeout", "Grpc-Encoding", "Grpc-Accept-Encoding", "X-Grpc-Web", "X-User-Agent",
+}
+
+// ConnectHandler serves the typed paste API to Connect and gRPC-Web clients, such as browsers
+// and curl, on the port of the REST API. Each procedure is served by its REST route, with the
+// same authentication, rate limits and errors.
+type ConnectHandler struct {
+	router http.Handler
+}
+
+// NewConnectHandler creates a ConnectHandler serving procedures with the REST routes of router
+func NewConnectHandler(router http.Handler) *ConnectHandler {
+	return &ConnectHandler{router: router}
+}
+
+// Serve handles POST /gisty.v1.PasteService/:method, choosing the protocol by the content type:
+// application/json for Connect unary calls and application/grpc-web+json for gRPC-Web.
+// Protobuf-encoded messages and native gRPC are not supported.
+func (h *ConnectHandler) Serve(c *gin.Context) {
+	var grpcWeb bool
+	switch c.ContentType() {
+	case connectJSON:
+	case grpcWebJSON:
+		grpcWeb = true
+	default:
+		c.Header("Accept-Post", connectJSON+", "+grpcWebJSON)
+		c.Status(http.StatusUnsupportedMediaType)
+		return
+	}
+
+	procedure, ok := connectProcedures[c.Param("method")]
+	if !ok {
+		h.writeError(c, grpcWeb, codeUnimplemented, "unknown procedure "+ConnectService+"/"+c.Param("method"))
+		return
+	}
+	if encoding := c.GetHeader("Connect-Content-Encoding"); encoding != "" && encoding != "identity" {
+		h.writeError(c, grpcWeb, codeUnimplemented, "unsupported compression "+encoding)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, middleware.MaxRequestBodySize+1))
+	if err != nil {
+		h.writeError(c, grpcWeb, codeInvalidArgument, "failed to read the request")
+		return
+	}
+	message := body
+	if grpcWeb {
+		var compressed bool
+		if message, compressed, ok = grpcWebMessage(body); !ok {
+			h.writeError(c, grpcWeb, codeInvalidArgument, "malformed gRPC-Web request")
+			return
+		}
+		if compressed {
+			h.writeError(c, grpcWeb, codeUnimplemented, "compressed messages are not supported")
+			return
+		}
+	}
+
+	req, err := h.restRequest(c, procedure, message)
+	if err != nil {
+		h.writeError(c, grpcWeb, codeInvalidArgument, err.Error())
+		return
+	}
+	ctx := req.Context()
+	if timeout, ok := connectTimeout(c.Request.Header); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	recorder := &connectRecorder{header: http.Header{}}
+	h.router.ServeHTTP(recorder, req.WithContext(ctx))
+
+	for key, values := range recorder.header {
+		switch key {
+		case "Content-Type", "Content-Length", "Content-Encoding":
+			continue
+		}
+		c.Writer.Header()[key] = values
+	}
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	if recorder.status < 200 || recorder.status > 299 {
+		h.writeError(c, grpcWeb, connectCodeOf(recorder.status), restErrorMessage(recorder.status, recorder.body.Bytes()))
+		return
+	}
+
+	response := recorder.body.Bytes()
+	if len(response) == 0 {
+		response = []byte("{}")
+	}
+	if !grpcWeb {
+		c.Data(http.StatusOK, connectJSON, response)
+		return
+	}
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", grpcWebJSON)
+	c.Writer.Write(grpcWebFrame(0, response))
+	c.Writer.Write(grpcWebFrame(grpcWebTrailer, []byte("grpc-status: 0\r\n")))
+}
+
+// restRequest builds the request to the REST route of a procedure from its message
+func (h *ConnectHandler) restRequest(c *gin.Context, procedure connectProcedure, message []byte) (*http.Request, error) {
+	fields := map[string]json.RawMessage{}
+	if len(bytes.TrimSpace(message)) > 0 {
+		if err := json.Unmarshal(message, &fields); err != nil {
+			return nil, errConnectMessage("the message is not a JSON object")
+		}
+	}
+
+	path := procedure.path
+	if strings.Contains(path, ":id") {
+		var id string
+		if err := json.Unmarshal(fields["id"], &id); err != nil || id == "" {
+			return nil, errConnectMessage("id is required")
+		}
+		delete(fields, "id")
+		path = strings.Replace(path, ":id", url.PathEscape(id), 1)
+	}
+	query := url.Values{}
+	for _, name := range procedure.query {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			value = string(raw)
+		}
+		query.Set(name, value)
+		delete(fields, name)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var body []byte
+	if procedure.method != http.MethodGet && procedure.method != http.MethodDelete {
+		var err error
+		if body, err = json.Marshal(fields); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(c.Request.Context(), procedure.method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = c.Request.Header.Clone()
+	for _, name := range connectRequestHeaders {
+		req.Header.Del(name)
+	}
+	req.Header.Set("Accept", connectJSON)
+	if body != nil {
+		req.Header.Set("Content-Type", connectJSON)
+	}
+	req.RemoteAddr = c.Request.RemoteAddr
+	req.Host = c.Request.Host
+	return req, nil
+}
+
+// writeError answers a call with an error: a JSON error for Connect, a trailers-only response,
+// with the status in the headers, for gRPC-Web
+func (h *ConnectHandler) writeError(c *gin.Context, grpcWeb bool, code connectCode, message string) {
+	if !grpcWeb {
+		c.JSON(code.status, gin.H{"code": code.name, "message": message})
+		return
+	}
+	c.Header("Content-Type", grpcWebJSON)
+	c.Header("Grpc-Status", strconv.Itoa(code.grpc))
+	c.Header("Grpc-Message", grpcMessageEscape(message))
+	c.Status(http.StatusOK)
+}
+
+// errConnectMessage is an invalid message of a call
+type errConnectMessage string
+
+func (e errConnectMessage) Error() string { return string(e) }
+
+// restErrorMessage returns the message of a REST error response
+func restErrorMessage(status int, body []byte) string {
+	var response ErrorResponse
+	if err := json.Unmarshal(body, &response); err == nil && response.Error != "" {
+		return response.Error
+	}
+	return http.StatusText(status)
+}
+
+// grpcWebMessage returns the first message frame of a gRPC-Web request body, and whether it is
+// compressed
+func grpcWebMessage(body []byte) ([]byte, bool, bool) {
+	if len(body) == 0 {
+		return nil, false, true
+	}
+	if len(body) < 5 || body[0]&grpcWebTrailer != 0 {
+		return nil, false, false
+	}
+	length := binary.BigEndian.Uint32(body[1:5])
+	if uint64(len(body)-5) < uint64(length) {
+		return nil, false, false
+	}
+	return body[5 : 5+length], body[0]&0x01 != 0, true
+}
+
+// grpcWebFrame returns a gRPC-Web frame: a flag byte and the big-endian length of the payload
+func grpcWebFrame(flags byte, payload []byte) []byte {
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = flags
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+// grpcMessageEscape percent-encodes a grpc-message value, as gRPC requires for bytes outside
+// printable ASCII and for '%'
+func grpcMessageEscape(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		ch := message[i]
+		if ch < ' ' || ch > '~' || ch == '%' {
+			fmt.Fprintf(&b, "%%%02X", ch)
+			continue
+		}
+		b.WriteByte(ch)
+	}
+	return b.String()
+}
+
+// connectTimeout returns the deadline a client set on a call, in the Connect-Timeout-Ms header
+// or the grpc-timeout header of gRPC-Web
+func connectTimeout(header http.Header) (time.Duration, bool) {
+	if ms := header.Get("Connect-Timeout-Ms"); ms != "" {
+		n, err := strconv.ParseInt(ms, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, false
+		}
+		return time.Duration(n) * time.Millisecond, true
+	}
+	value := header.Get("Grpc-Timeout")
+	if len(value) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second, 'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// connectRecorder buffers the REST response of a call
+type connectRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *connectRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *connectRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *connectRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contentETag returns the entity tag of a representation of content with the given hash. JSON
+// representations carry metadata such as the view count besides the content, so their tag is
+// weak and distinct from the one of the raw content served on the same URL.
+func contentETag(hash string, asJSON bool) string {
+	if asJSON {
+		return `W/"` + hash + `.json"`
+	}
+	return `"` + hash + `"`
+}
+
+// gzipETag returns the entity tag of raw content with the given hash sent gzip compressed, which
+// differs from the one of the content sent as-is since the bytes do
+func gzipETag(hash string) string {
+	return `"` + hash + `-gzip"`
+}
+
+// etagMatches reports whether an If-None-Match header lists the entity tag, using the weak
+// comparison required for If-None-Match
+func etagMatches(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setETag sets the ETag header of a paste response whose content hash is known
+func setETag(c *gin.Context, hash string, asJSON bool) {
+	if hash != "" {
+		c.Header("ETag", contentETag(hash, asJSON))
+	}
+}
+
+// acceptsGzip reports whether the client accepts gzip compressed responses
+func acceptsGzip(c *gin.Context) bool {
+	for _, coding := range strings.Split(c.GetHeader("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "x-gzip" {
+			continue
+		}
+		// A zero quality value refuses the coding
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err != nil || weight == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// notModified answers a conditional read with 304 Not Modified when the client already holds the
+// current content, without reading the content or counting a view. It reports whether it did;
+// otherwise the paste is read as usual.
+func (h *PasteHandler) notModified(c *gin.Context, shortID string, asJSON bool) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	hash, err := h.pasteService.GetContentHash(c.Request.Context(), shortID)
+	if err != nil || hash == "" {
+		return false
+	}
+	etag := contentETag(hash, asJSON)
+	if !etagMatches(header, etag) {
+		// Raw content may have been sent gzip compressed
+		if asJSON || !etagMatches(header, gzipETag(hash)) {
+			return false
+		}
+		etag = gzipETag(hash)
+	}
+
+	c.Header("ETag", etag)
+	c.Status(http.StatusNotModified)
+	return true
+}
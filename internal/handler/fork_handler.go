@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// ForkPasteRequest represents the request body for forking a paste
+type ForkPasteRequest struct {
+	ExpiresIn string `json:"expires_in,omitempty" example:"1w"`
+	IsPrivate bool   `json:"is_private,omitempty" example:"false"`
+	Title     string `json:"title,omitempty" example:"My take on hello world"`
+}
+
+// ForkPaste godoc
+// @Summary Fork a paste
+// @Description Copy the content, syntax type, title, description and tags of a paste into a new paste owned by the caller, recorded as forked_from the source. The fork gets its own expiration and privacy from the request; the title can be overridden. Burn-after-read and view-limited pastes cannot be forked, and forking does not count a view of the source.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param request body ForkPasteRequest false "Settings of the fork"
+// @Success 201 {object} CreatePasteResponse "Fork created"
+// @Failure 400 {object} ErrorResponse "Invalid expiration or title"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Paste content archived until restored"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 422 {object} ErrorResponse "Burn-after-read or view-limited paste"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /pastes/{id}/fork [post]
+func (h *PasteHandler) ForkPaste(c *gin.Context) {
+	shortID := c.Param("id")
+	if shortID == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	var req service.ForkPasteRequest
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[ForkPaste] Failed to bind JSON: %v", err)
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+			return
+		}
+	}
+	req.SourceIP = c.ClientIP()
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
+
+	response, err := h.pasteService.ForkPaste(c.Request.Context(), shortID, &req)
+	if err != nil {
+		log.Printf("[ForkPaste] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
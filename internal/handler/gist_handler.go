@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// ImportGistRequest represents the request body for importing a gist
+type ImportGistRequest struct {
+	Gist      string `json:"gist" binding:"required" example:"https://gist.github.com/octocat/aa5a315d61ae9438b18d"`
+	ExpiresIn string `json:"expires_in,omitempty" example:"1w"`
+	IsPrivate bool   `json:"is_private,omitempty" example:"false"`
+}
+
+// ImportedGistFile represents a gist file and the paste it was stored as
+type ImportedGistFile struct {
+	Filename   string `json:"filename" example:"hello_world.rb"`
+	SyntaxType string `json:"syntax_type" example:"ruby"`
+	ShortID    string `json:"short_id" example:"xK9a2B"`
+	URL        string `json:"url" example:"http://localhost:8080/xK9a2B"`
+	// DeleteToken is returned once, for files imported without an API key or a sign-in; keep it to delete the paste
+	DeleteToken string `json:"delete_token,omitempty" example:"q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"`
+}
+
+// ImportGistResponse represents the pastes created from a gist
+type ImportGistResponse struct {
+	GistID    string             `json:"gist_id" example:"aa5a315d61ae9438b18d"`
+	GroupID   string             `json:"group_id,omitempty" example:"Zk3q9XbW1pLm"` // shared by the pastes of a multi-file gist
+	Files     []ImportedGistFile `json:"files"`
+	ExpiresAt *string            `json:"expires_at,omitempty" example:"2024-01-22T14:00:00Z"`
+}
+
+// ExportGistRequest represents the request body for exporting a paste as a gist
+type ExportGistRequest struct {
+	Token    string `json:"token" binding:"required" example:"github_pat_11AB..."`
+	Public   bool   `json:"public,omitempty" example:"false"`
+	Filename string `json:"filename,omitempty" example:"hello.py"`
+}
+
+// ExportGistResponse represents the gist created from a paste
+type ExportGistResponse struct {
+	ShortID  string `json:"short_id" example:"xK9a2B"`
+	GistID   string `json:"gist_id" example:"aa5a315d61ae9438b18d"`
+	GistURL  string `json:"gist_url" example:"https://gist.github.com/aa5a315d61ae9438b18d"`
+	Filename string `json:"filename" example:"hello.py"`
+	// Files lists the files of the gist, more than one when the paste was imported from a multi-file gist
+	Files []string `json:"files" example:"hello.py,README.md"`
+}
+
+// ImportGist godoc
+// @Summary Import a GitHub gist
+// @Description Fetch a gist by ID or gist.github.com URL and store each of its files as a paste, in filename order. Each paste keeps the filename as title and download filename and the language GitHub detected as syntax type; all get the gist's description, the tag "gist" and the expiration and privacy of the request. The pastes of a multi-file gist share a group_id, so they can be exported again as one gist. Gists of more than 10 files or with a file over 1MB are rejected, and a gist is imported entirely or not at all.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param request body ImportGistRequest true "Gist to import"
+// @Success 201 {object} ImportGistResponse "Pastes created"
+// @Failure 400 {object} ErrorResponse "Invalid gist reference or expiration"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 404 {object} ErrorResponse "Gist not found, or gist import disabled"
+// @Failure 422 {object} ErrorResponse "Gist too large"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 502 {object} ErrorResponse "GitHub failed or unreachable"
+// @Router /import/gist [post]
+func (h *PasteHandler) ImportGist(c *gin.Context) {
+	var req service.ImportGistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[ImportGist] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.SourceIP = c.ClientIP()
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
+
+	response, err := h.pasteService.ImportGist(c.Request.Context(), &req)
+	if err != nil {
+		log.Printf("[ImportGist] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// ExportGist godoc
+// @Summary Export a paste to GitHub as a gist
+// @Description Create a gist holding the content of a paste, owned by the holder of the GitHub token sent in the body. The token needs the gist scope; it is used for this call only and never stored or logged. The file is named after the paste's download filename, or its short ID with the usual extension of its syntax type, unless a filename is given. A paste imported from a multi-file gist is exported with the other pastes of its group_id, as one file each. Exporting does not count a view; encrypted, binary, burn-after-read and view-limited pastes cannot be exported.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param request body ExportGistRequest true "GitHub token and gist settings"
+// @Success 201 {object} ExportGistResponse "Gist created"
+// @Failure 400 {object} ErrorResponse "Missing token, or invalid or duplicate filename"
+// @Failure 401 {object} ErrorResponse "GitHub rejected the token"
+// @Failure 404 {object} ErrorResponse "Paste not found, or gist export disabled"
+// @Failure 409 {object} ErrorResponse "Paste content archived until restored"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 422 {object} ErrorResponse "Encrypted, binary, burn-after-read or view-limited paste, or group member"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 502 {object} ErrorResponse "GitHub failed or unreachable"
+// @Router /pastes/{id}/export/gist [post]
+func (h *PasteHandler) ExportGist(c *gin.Context) {
+	shortID := c.Param("id")
+	if shortID == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	var req service.ExportGistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[ExportGist] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	response, err := h.pasteService.ExportGist(c.Request.Context(), shortID, &req)
+	if err != nil {
+		log.Printf("[ExportGist] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
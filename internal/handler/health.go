@@ -2,33 +2,88 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
 	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/service"
+	"github.com/huylvt/gisty/internal/version"
 )
 
+// DefaultProbeTimeout bounds each dependency ping of the readiness probe
+const DefaultProbeTimeout = 2 * time.Second
+
+// DependencyCheck pings a dependency; a nil error means it is usable
+type DependencyCheck func(ctx context.Context) error
+
+// dependency is a named DependencyCheck
+type dependency struct {
+	name  string
+	check DependencyCheck
+}
+
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	s3Client *repository.S3
+	s3Client     *repository.S3
+	dependencies []dependency
+	probeTimeout time.Duration
+	drain        *service.Drain
 }
 
 // NewHealthHandler creates a new HealthHandler
 func NewHealthHandler(s3Client *repository.S3) *HealthHandler {
 	return &HealthHandler{
-		s3Client: s3Client,
+		s3Client:     s3Client,
+		probeTimeout: DefaultProbeTimeout,
 	}
 }
 
+// NewDependencyHealthHandler creates a HealthHandler whose readiness probe pings MongoDB, Redis
+// and S3. Nil clients are not checked.
+func NewDependencyHealthHandler(mongoDB *repository.MongoDB, redis *repository.Redis, s3Client *repository.S3) *HealthHandler {
+	h := NewHealthHandler(s3Client)
+	if mongoDB != nil {
+		h.AddDependency("mongodb", mongoDB.Ping)
+	}
+	if redis != nil {
+		h.AddDependency("redis", redis.Ping)
+	}
+	if s3Client != nil {
+		h.AddDependency("s3", s3Client.HealthCheck)
+	}
+	return h
+}
+
+// AddDependency adds a dependency to the readiness probe
+func (h *HealthHandler) AddDependency(name string, check DependencyCheck) {
+	h.dependencies = append(h.dependencies, dependency{name: name, check: check})
+}
+
+// SetProbeTimeout sets the time each dependency has to answer the readiness probe
+func (h *HealthHandler) SetProbeTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		h.probeTimeout = timeout
+	}
+}
+
+// SetDrain makes the readiness probe fail once the instance drains
+func (h *HealthHandler) SetDrain(drain *service.Drain) {
+	h.drain = drain
+}
+
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status    string `json:"status" example:"ok"`
-	Timestamp string `json:"timestamp" example:"2024-01-15T14:00:00Z"`
+	Status    string       `json:"status" example:"ok"`
+	Timestamp string       `json:"timestamp" example:"2024-01-15T14:00:00Z"`
+	Build     version.Info `json:"build"`
 }
 
 // Health godoc
@@ -42,10 +97,116 @@ func (h *HealthHandler) Health(c *gin.Context) {
 	response := HealthResponse{
 		Status:    "ok",
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Build:     version.Get(),
 	}
 	c.JSON(http.StatusOK, response)
 }
 
+// Healthz godoc
+// @Summary Liveness probe
+// @Description Report that the process is up and serving requests. Dependencies are not checked, so an outage of MongoDB, Redis or S3 does not get every instance restarted; use /readyz to gate traffic.
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse "Process is alive"
+// @Router /healthz [get]
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	h.Health(c)
+}
+
+// DependencyStatus is the result of pinging one dependency
+type DependencyStatus struct {
+	Status    string  `json:"status" example:"ok" enums:"ok,unavailable,timeout"`
+	LatencyMS float64 `json:"latency_ms" example:"1.7"`
+}
+
+// ReadinessResponse represents the readiness probe response
+type ReadinessResponse struct {
+	Status       string                      `json:"status" example:"ready" enums:"ready,not_ready,draining"`
+	Timestamp    string                      `json:"timestamp" example:"2024-01-15T14:00:00Z"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// Readyz godoc
+// @Summary Readiness probe
+// @Description Ping MongoDB, Redis and S3 concurrently, each within a timeout, and report the status and latency of each. Answers 503 when any dependency is unavailable, so the instance is taken out of load balancing until it recovers, and with status "draining" and no dependencies once the instance drains before shutting down.
+// @Tags health
+// @Produce json
+// @Success 200 {object} ReadinessResponse "All dependencies are reachable"
+// @Failure 503 {object} ReadinessResponse "A dependency is unavailable, or the instance is draining"
+// @Router /readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	response := ReadinessResponse{
+		Status:       "ready",
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Dependencies: make(map[string]DependencyStatus, len(h.dependencies)),
+	}
+	c.Header("Cache-Control", "no-store")
+
+	if h.drain != nil {
+		if draining, _ := h.drain.Draining(); draining {
+			response.Status = "draining"
+			c.JSON(http.StatusServiceUnavailable, response)
+			return
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, dep := range h.dependencies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := h.probe(c.Request.Context(), dep)
+			mu.Lock()
+			response.Dependencies[dep.name] = status
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	for _, status := range response.Dependencies {
+		if status.Status != "ok" {
+			response.Status = "not_ready"
+			code = http.StatusServiceUnavailable
+		}
+	}
+	c.JSON(code, response)
+}
+
+// probe pings one dependency within the probe timeout. Errors are logged rather than
+// returned, as they can name internal hosts.
+func (h *HealthHandler) probe(ctx context.Context, dep dependency) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, h.probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := dep.check(ctx)
+	status := DependencyStatus{
+		Status:    "ok",
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = "unavailable"
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			status.Status = "timeout"
+		}
+		log.Printf("[HealthHandler.Readyz] %s %s after %.1fms: %v", dep.name, status.Status, status.LatencyMS, err)
+	}
+	return status
+}
+
+// Version godoc
+// @Summary Build information
+// @Description Return the version, commit and build date of the running server
+// @Tags health
+// @Produce json
+// @Success 200 {object} version.Info "Build information"
+// @Router /version [get]
+func (h *HealthHandler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
+
 // DebugS3Response represents the S3 debug response
 type DebugS3Response struct {
 	Bucket      string `json:"bucket"`
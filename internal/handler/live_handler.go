@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/codec"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// livePollInterval is how often a follower's connection checks the paste for appends
+	livePollInterval = time.Second
+	// livePushMaxLifetime bounds a follow connection; clients reconnect from the offset they reached
+	livePushMaxLifetime = time.Hour
+	// livePushWriteTimeout bounds a single chunk write
+	livePushWriteTimeout = 10 * time.Second
+)
+
+// AppendPasteRequest represents the request body for appending to a live paste
+type AppendPasteRequest struct {
+	Content string `json:"content" binding:"required" example:"step 3/5 done"`
+	// Transfer encoding of content, for binary-ish content that JSON would mangle or bloat; stored decoded
+	Encoding string `json:"encoding,omitempty" example:"base64" enums:"base64,hex"`
+}
+
+// AppendPasteResponse represents the response after appending to a live paste
+type AppendPasteResponse struct {
+	ShortID   string `json:"short_id" example:"xK9a2B"`
+	Size      int    `json:"size" example:"1024"` // content size in bytes after the append
+	UpdatedAt string `json:"updated_at" example:"2024-01-15T14:00:00Z"`
+}
+
+// LiveChunk represents the content appended to a live paste, pushed to its followers
+type LiveChunk struct {
+	ShortID  string `json:"short_id" example:"xK9a2B"`
+	Offset   int    `json:"offset" example:"1000"` // byte offset of content in the paste
+	Content  string `json:"content" example:"step 3/5 done"`
+	Size     int    `json:"size" example:"1014"` // content size in bytes, the offset to reconnect from
+	Encoding string `json:"encoding,omitempty" example:"base64"`
+	Ended    bool   `json:"ended,omitempty" example:"false"` // set on the last message, once the paste is gone
+}
+
+// AppendPaste godoc
+// @Summary Append to a live paste
+// @Description Append content to the end of a paste created with live=true. Concurrent appends are applied one after the other.
+// @Description Only the creator of the paste, proven as for DELETE /pastes/{id}, or an admin may append to it.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param X-Delete-Token header string false "Delete token returned when the paste was created"
+// @Param delete_token query string false "Delete token, when the header cannot be sent"
+// @Param X-Admin-Token header string false "Admin token, to append to any paste"
+// @Param request body AppendPasteRequest true "Content to append"
+// @Success 200 {object} AppendPasteResponse "Content appended"
+// @Failure 400 {object} ErrorResponse "Invalid request (empty content, invalid encoding, line too long or NUL bytes when rejected by policy)"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 403 {object} ErrorResponse "Missing or wrong delete token, not the owner and not an admin"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Not a live paste, or other appends kept it busy"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 413 {object} ErrorResponse "Content too large (max 1MB in total)"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} ErrorResponse "Storage or database unavailable"
+// @Router /pastes/{id}/append [post]
+func (h *PasteHandler) AppendPaste(c *gin.Context) {
+	shortID := c.Param("id")
+	if shortID == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	var req service.AppendPasteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[AppendPaste] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	if !codec.Valid(req.Encoding) {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidEncoding))
+		return
+	}
+	req.Caller = h.caller(c)
+
+	response, err := h.pasteService.AppendPaste(c.Request.Context(), shortID, &req)
+	if err != nil {
+		log.Printf("[AppendPaste] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// WatchLivePaste godoc
+// @Summary Follow a live paste
+// @Description Upgrade to a WebSocket that pushes the content of a live paste from offset on, then each append as it lands,
+// @Description like tail -f. Reads are not counted as views. A final message with ended=true is sent when the paste is
+// @Description deleted or expires, then the connection is closed. Connections last up to an hour; reconnect with offset set
+// @Description to the size of the last message to resume.
+// @Tags pastes
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param offset query int false "Byte offset to start from, the size of the last message when reconnecting"
+// @Param encoding query string false "Push content base64 or hex encoded, byte for byte" Enums(base64, hex)
+// @Success 101 {object} LiveChunk "Switching to WebSocket"
+// @Failure 400 {object} ErrorResponse "Invalid offset or encoding"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Not a live paste"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/live/ws [get]
+func (h *PasteHandler) WatchLivePaste(c *gin.Context) {
+	shortID := c.Param("id")
+
+	offset := 0
+	if raw := c.Query("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidOffset))
+			return
+		}
+		offset = n
+	}
+	encoding := c.Query("encoding")
+	if !codec.Valid(encoding) {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidEncoding))
+		return
+	}
+
+	// Report a missing or non-live paste as a plain HTTP error before upgrading
+	chunk, err := h.pasteService.ReadLive(c.Request.Context(), shortID, offset, time.Time{})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		h.pushLive(ws, chunk, encoding)
+	}).ServeHTTP(c.Writer, c.Request)
+}
+
+// pushLive sends chunk, then each append, until the paste is gone, the client disconnects or the
+// lifetime is reached
+func (h *PasteHandler) pushLive(ws *websocket.Conn, chunk *service.LiveChunk, encoding string) {
+	// The connection outlives the request time budget and server timeouts
+	ctx, cancel := context.WithTimeout(context.Background(), livePushMaxLifetime)
+	defer cancel()
+	_ = ws.SetDeadline(time.Time{})
+
+	// Clients do not send anything; a read error means they went away
+	go func() {
+		var discard []byte
+		for {
+			if err := websocket.Message.Receive(ws, &discard); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	shortID, offset, since := chunk.ShortID, chunk.Size, chunk.UpdatedAt
+	for {
+		if chunk != nil && (chunk.Content != "" || chunk.Ended) {
+			if encoding != codec.None {
+				// The encoding was checked before upgrading
+				chunk.Content, _ = codec.Encode(encoding, chunk.Content)
+				chunk.Encoding = encoding
+			}
+			_ = ws.SetWriteDeadline(time.Now().Add(livePushWriteTimeout))
+			if err := websocket.JSON.Send(ws, chunk); err != nil || chunk.Ended {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(livePollInterval):
+		}
+
+		var err error
+		chunk, err = h.pasteService.ReadLive(ctx, shortID, offset, since)
+		if err != nil && ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, service.ErrPasteNotFound) || errors.Is(err, service.ErrPasteExpired) {
+			chunk = &service.LiveChunk{ShortID: shortID, Offset: offset, Size: offset, Ended: true}
+		} else if err != nil {
+			log.Printf("[WatchLivePaste] Failed to read %s: %v", shortID, err)
+			return
+		} else if chunk != nil {
+			offset, since = chunk.Size, chunk.UpdatedAt
+		}
+	}
+}
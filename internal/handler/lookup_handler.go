@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// Lookup godoc
+// @Summary Find my pastes of a content
+// @Description List the caller's readable pastes whose content has the given SHA-256, newest first, to tell whether a file was already pasted.
+// @Description The caller is identified by API key, signed-in user or anonymous session (X-Gisty-Session header).
+// @Description Pastes created before content hashes were recorded are found once they have been read.
+// @Tags pastes
+// @Produce json
+// @Security APIKey
+// @Security BearerAuth
+// @Param sha256 query string true "Hex-encoded SHA-256 of the content"
+// @Param limit query int false "Maximum number of pastes (default 100, max 10000)"
+// @Param X-Gisty-Session header string false "Anonymous session token the pastes were created with"
+// @Success 200 {object} service.LookupResponse "Matching pastes"
+// @Failure 400 {object} ErrorResponse "Invalid sha256 or limit"
+// @Failure 401 {object} ErrorResponse "Neither an API key, a session token nor a session was sent"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /lookup [get]
+func (h *PasteHandler) Lookup(c *gin.Context) {
+	query, ok := lookupQuery(c)
+	if !ok {
+		return
+	}
+	query.OwnerID = middleware.OwnerID(c)
+	query.UserID = middleware.UserID(c)
+
+	response, err := h.pasteService.LookupContentHash(c.Request.Context(), query)
+	if err != nil {
+		log.Printf("[Lookup] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// Lookup godoc
+// @Summary Find pastes of a content
+// @Description List every readable paste whose content has the given SHA-256, newest first, whoever created it
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param sha256 query string true "Hex-encoded SHA-256 of the content"
+// @Param limit query int false "Maximum number of pastes (default 100, max 10000)"
+// @Success 200 {object} service.LookupResponse "Matching pastes"
+// @Failure 400 {object} ErrorResponse "Invalid sha256 or limit"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/lookup [get]
+func (h *AdminHandler) Lookup(c *gin.Context) {
+	query, ok := lookupQuery(c)
+	if !ok {
+		return
+	}
+	query.All = true
+
+	response, err := h.pasteService.LookupContentHash(c.Request.Context(), query)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidChecksum) {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidChecksum))
+			return
+		}
+		log.Printf("[Lookup] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// lookupQuery parses the query of a content lookup; it answers the request and reports false
+// when the limit is invalid
+func lookupQuery(c *gin.Context) (*service.LookupQuery, bool) {
+	query := &service.LookupQuery{SHA256: c.Query("sha256")}
+	if raw, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return nil, false
+		}
+		query.Limit = n
+	}
+	return query, true
+}
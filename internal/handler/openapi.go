@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/swaggo/swag"
+)
+
+// OpenAPISpec serves the API description generated from the handler annotations (Swagger 2.0)
+// as JSON, for client generators. It is the document the /docs UI renders.
+func OpenAPISpec(c *gin.Context) {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		log.Printf("[OpenAPISpec] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", []byte(doc))
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+)
+
+// OutputSummary is the metadata of a paste holding the output of another paste
+type OutputSummary struct {
+	ShortID    string    `json:"short_id" example:"pQ7rS9"`
+	URL        string    `json:"url" example:"http://localhost:8080/pQ7rS9"`
+	Title      string    `json:"title,omitempty" example:"Output of xK9a2B"`
+	SyntaxType string    `json:"syntax_type" example:"plaintext"`
+	CreatedAt  string    `json:"created_at" example:"2024-01-15T14:05:00Z"`
+	ExpiresAt  *string   `json:"expires_at,omitempty" example:"2024-01-22T14:05:00Z"`
+	Producer   *Producer `json:"producer,omitempty"`
+}
+
+// ListOutputsResponse represents the outputs of a paste, newest first
+type ListOutputsResponse struct {
+	ShortID   string          `json:"short_id" example:"xK9a2B"`
+	Outputs   []OutputSummary `json:"outputs"`
+	Truncated bool            `json:"truncated" example:"false"`
+}
+
+// ListOutputs godoc
+// @Summary List the outputs of a paste
+// @Description List the pastes created with output_of set to this paste, such as CI job logs or sandbox runs, newest first. Burn-after-read and view-limited outputs are never listed, and private outputs only when the paste itself is private.
+// @Tags pastes
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param limit query int false "Number of outputs (default 20, max 100)"
+// @Success 200 {object} ListOutputsResponse "Outputs of the paste"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/outputs [get]
+func (h *PasteHandler) ListOutputs(c *gin.Context) {
+	limit := 0
+	if raw, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return
+		}
+		limit = n
+	}
+
+	response, err := h.pasteService.ListOutputs(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
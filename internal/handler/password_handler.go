@@ -0,0 +1,302 @@
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/auth"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+)
+
+// RegisterRequest represents the request body for creating a password account
+type RegisterRequest struct {
+	Email string `json:"email" binding:"required" example:"octocat@example.com"`
+	// Password has 10 to 72 bytes
+	Password string `json:"password" binding:"required" example:"correct horse battery staple"`
+	Name     string `json:"name,omitempty" example:"The Octocat"`
+}
+
+// PasswordLoginRequest represents the request body for signing in with a password
+type PasswordLoginRequest struct {
+	Email    string `json:"email" binding:"required" example:"octocat@example.com"`
+	Password string `json:"password" binding:"required" example:"correct horse battery staple"`
+}
+
+// EmailRequest represents the request body naming the email of a password account
+type EmailRequest struct {
+	Email string `json:"email" binding:"required" example:"octocat@example.com"`
+}
+
+// ResetPasswordRequest represents the request body for choosing a new password, as JSON or from
+// the reset page's form
+type ResetPasswordRequest struct {
+	// Token is the token of the link of the password reset email
+	Token    string `json:"token" form:"token" binding:"required" example:"Yk3x9QpL2m7VZJbq0s1TtR8cWnE4aHdF6uGiOyKxM5w"`
+	Password string `json:"password" form:"password" binding:"required" example:"correct horse battery staple"`
+}
+
+// Register godoc
+// @Summary Create a password account
+// @Description Create an account signing in with an email and a password, and email the link verifying the email.
+// @Description The account signs in once the email is verified.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RegisterRequest true "Account"
+// @Success 201 {object} model.User "Account created, verification email sent"
+// @Failure 400 {object} ErrorResponse "Invalid email, or password not between 10 and 72 bytes"
+// @Failure 404 {object} ErrorResponse "Password accounts not enabled"
+// @Failure 409 {object} ErrorResponse "Email has an account"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /auth/password/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	user, err := h.auth.Register(c.Request.Context(), req.Email, req.Password, req.Name)
+	if err != nil {
+		log.Printf("[Register] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+// PasswordLogin godoc
+// @Summary Sign in with a password
+// @Description Sign in to a verified password account and issue a session token, set in the gisty_session cookie.
+// @Description 5 failed sign-ins lock the account, and 30 attempts throttle the client, for 15 minutes.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body PasswordLoginRequest true "Credentials"
+// @Success 200 {object} LoginResponse "Signed in"
+// @Failure 400 {object} ErrorResponse "Invalid request body"
+// @Failure 401 {object} ErrorResponse "Wrong email or password"
+// @Failure 403 {object} ErrorResponse "Email not verified"
+// @Failure 404 {object} ErrorResponse "Password accounts not enabled"
+// @Failure 429 {object} ErrorResponse "Account locked or client throttled, see Retry-After"
+// @Router /auth/password/login [post]
+func (h *AuthHandler) PasswordLogin(c *gin.Context) {
+	var req PasswordLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	user, token, expiresAt, err := h.auth.PasswordLogin(c.Request.Context(), req.Email, req.Password, clientOf(c))
+	if err != nil {
+		log.Printf("[PasswordLogin] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	h.setCookie(c, middleware.SessionCookie, token, time.Until(expiresAt))
+	c.JSON(http.StatusOK, LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		User:      user,
+	})
+}
+
+// VerifyEmail godoc
+// @Summary Verify an email
+// @Description Followed from the verification email: marks the email of the password account verified.
+// @Description Redirects to the configured page, or answers with the account when none is configured.
+// @Tags auth
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} model.User "Email verified"
+// @Success 302 "Email verified, redirect to the configured page"
+// @Failure 400 {object} ErrorResponse "Invalid, used or expired token"
+// @Failure 404 {object} ErrorResponse "Password accounts not enabled"
+// @Router /auth/email/verify [get]
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	user, err := h.auth.VerifyEmail(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		log.Printf("[VerifyEmail] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	if h.redirectURL != "" {
+		c.Redirect(http.StatusFound, h.redirectURL)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Email another verification link to the password account of an email that is not verified yet, at most one a minute.
+// @Description The answer is the same whether the email has an account or not.
+// @Tags auth
+// @Accept json
+// @Param request body EmailRequest true "Email of the account"
+// @Success 202 "Email sent if the account exists and is not verified"
+// @Failure 400 {object} ErrorResponse "Invalid email"
+// @Failure 404 {object} ErrorResponse "Password accounts not enabled"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /auth/email/verify/resend [post]
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	if err := h.auth.ResendVerification(c.Request.Context(), req.Email); err != nil {
+		log.Printf("[ResendVerification] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a link to choose a new password, valid for an hour, to the password account of an email, at most one a minute.
+// @Description The answer is the same whether the email has an account or not.
+// @Tags auth
+// @Accept json
+// @Param request body EmailRequest true "Email of the account"
+// @Success 202 "Email sent if the account exists"
+// @Failure 400 {object} ErrorResponse "Invalid email"
+// @Failure 404 {object} ErrorResponse "Password accounts not enabled"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	if err := h.auth.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		log.Printf("[ForgotPassword] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// ResetPasswordPage godoc
+// @Summary Password reset page
+// @Description Followed from the password reset email: a form to choose a new password, posted to the reset endpoint
+// @Tags auth
+// @Produce html
+// @Param token query string true "Reset token"
+// @Success 200 {string} string "Reset form"
+// @Failure 404 {object} ErrorResponse "Password accounts not enabled"
+// @Router /auth/password/reset [get]
+func (h *AuthHandler) ResetPasswordPage(c *gin.Context) {
+	if !h.auth.PasswordsEnabled() {
+		h.handleError(c, auth.ErrPasswordsDisabled)
+		return
+	}
+	nonce := newNonce()
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'nonce-"+nonce+"'; base-uri 'none'; form-action 'self'")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-store")
+	// The token must not leak to other sites through the referrer
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := resetPasswordPage.Execute(c.Writer, resetPasswordPageData{Token: c.Query("token"), Nonce: nonce}); err != nil {
+		log.Printf("[ResetPasswordPage] Failed to render: %v", err)
+	}
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Choose a new password with the token of a reset email, as JSON or from the reset page's form.
+// @Description The token works once; the email is marked verified and the account unlocked.
+// @Tags auth
+// @Accept json,x-www-form-urlencoded
+// @Param request body ResetPasswordRequest true "Token and new password"
+// @Success 204 "Password changed"
+// @Success 200 {string} string "Password changed, answered to the form in plain text"
+// @Failure 400 {object} ErrorResponse "Invalid, used or expired token, or password not between 10 and 72 bytes"
+// @Failure 404 {object} ErrorResponse "Password accounts not enabled"
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	fromForm := c.ContentType() == "application/x-www-form-urlencoded"
+	var req ResetPasswordRequest
+	if err := c.ShouldBind(&req); err != nil {
+		if fromForm {
+			c.String(http.StatusBadRequest, middleware.ErrorText(c, i18n.CodeInvalidRequestBody))
+			return
+		}
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	if err := h.auth.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		log.Printf("[ResetPassword] Error: %v", err)
+		// The page's form is answered in text for the errors the user can act on
+		switch {
+		case fromForm && errors.Is(err, auth.ErrWeakPassword):
+			c.String(http.StatusBadRequest, middleware.ErrorText(c, i18n.CodeWeakPassword))
+		case fromForm && errors.Is(err, auth.ErrInvalidToken):
+			c.String(http.StatusBadRequest, middleware.ErrorText(c, i18n.CodeInvalidToken))
+		default:
+			h.handleError(c, err)
+		}
+		return
+	}
+	if fromForm {
+		c.String(http.StatusOK, "Your password was changed. You can sign in with it now.")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// setRetryAfter sets the Retry-After header in whole seconds, rounded up
+func setRetryAfter(c *gin.Context, d time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
+
+// resetPasswordPage is the form choosing a new password, served on GET /auth/password/reset
+var resetPasswordPage = template.Must(template.New("reset").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Reset your password · gisty</title>
+<style nonce="{{.Nonce}}">
+body{margin:0;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;background:#f6f8fa;color:#1f2328}
+main{max-width:24rem;margin:3rem auto;padding:1.5rem;background:#fff;border:1px solid #d0d7de;border-radius:6px}
+h1{margin:0 0 1rem;font-size:1.25rem}
+label{display:block;margin-bottom:.5rem;font-size:.875rem}
+input[type=password]{box-sizing:border-box;width:100%;padding:.5rem;margin-bottom:1rem;border:1px solid #d0d7de;border-radius:6px}
+button{padding:.5rem 1rem;border:0;border-radius:6px;background:#1f883d;color:#fff}
+</style>
+</head>
+<body>
+<main>
+<h1>Choose a new password</h1>
+<form method="post" action="/auth/password/reset">
+<input type="hidden" name="token" value="{{.Token}}">
+<label for="password">New password, 10 to 72 characters</label>
+<input type="password" id="password" name="password" minlength="10" maxlength="72" autocomplete="new-password" required>
+<button type="submit">Change password</button>
+</form>
+</main>
+</body>
+</html>
+`))
+
+// resetPasswordPageData is the data of resetPasswordPage
+type resetPasswordPageData struct {
+	Token string
+	Nonce string
+}
@@ -2,17 +2,49 @@ package handler
 
 import (
 	"errors"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/codec"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
 	"github.com/huylvt/gisty/internal/service"
 )
 
+// DeleteTokenHeader carries the delete token returned when a paste was created anonymously
+const DeleteTokenHeader = "X-Delete-Token"
+
+// ExpiresHeader sets the expiration of a paste created from a plain-text body, in seconds or as
+// an expires_in value such as 1d
+const ExpiresHeader = "X-Gisty-Expires"
+
+// streamWriteTimeout bounds the write of streamed content and archives, which can take longer on
+// slow connections than the server's write timeout allows
+const streamWriteTimeout = 10 * time.Minute
+
 // PasteHandler handles paste-related HTTP requests
 type PasteHandler struct {
 	pasteService *service.PasteService
+	viewBaseURL  string // prefix of /view redirects; empty keeps them relative to the serving host
+	maxBytes     int    // default content cap of JSON reads; 0 returns full content
+	htmlView     bool   // serve browsers the server-rendered view instead of redirecting to the frontend
+
+	// expiredRedirectURL is the template of the page browsers are sent to for expired and burned
+	// pastes; empty answers with the error
+	expiredRedirectURL string
+
+	// edgeTTL is how long a CDN may cache rendered views; 0 leaves them uncached
+	edgeTTL time.Duration
+
+	// adminToken lets admins delete, edit and redact any paste; empty disables it
+	adminToken string
 }
 
 // NewPasteHandler creates a new PasteHandler
@@ -22,12 +54,81 @@ func NewPasteHandler(pasteService *service.PasteService) *PasteHandler {
 	}
 }
 
+// SetViewBaseURL makes browser redirects point at the paste view on another host.
+// It is needed when share links are served from a short domain without the frontend.
+func (h *PasteHandler) SetViewBaseURL(baseURL string) {
+	h.viewBaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// SetHTMLView makes GET /:id answer browsers with a server-rendered, syntax-highlighted page
+func (h *PasteHandler) SetHTMLView(enabled bool) {
+	h.htmlView = enabled
+}
+
+// SetExpiredRedirectURL sends browsers opening an expired or burned paste to a page of the
+// operator's, such as a form to ask the author to share it again. In the template, {id} is
+// replaced by the paste ID and {reason} by "expired" or "burned", both query-escaped.
+// Pastes already removed by the TTL monitor are indistinguishable from unknown IDs and get 404.
+func (h *PasteHandler) SetExpiredRedirectURL(template string) {
+	h.expiredRedirectURL = template
+}
+
+// SetEdgeCacheTTL lets a CDN cache the rendered views of pastes for ttl, or until they expire.
+// It must only be set when edits and deletions purge the CDN.
+func (h *PasteHandler) SetEdgeCacheTTL(ttl time.Duration) {
+	h.edgeTTL = max(ttl, 0)
+}
+
+// SetAdminToken lets requests carrying the admin token delete, edit and redact any paste
+func (h *PasteHandler) SetAdminToken(token string) {
+	h.adminToken = token
+}
+
+// SetDefaultMaxBytes caps the content of JSON reads that do not pass ?max_bytes=
+func (h *PasteHandler) SetDefaultMaxBytes(maxBytes int) {
+	h.maxBytes = max(maxBytes, 0)
+}
+
 // CreatePasteRequest represents the request body for creating a paste
 type CreatePasteRequest struct {
 	Content    string `json:"content" binding:"required" example:"console.log('Hello, World!')"`
 	SyntaxType string `json:"syntax_type" example:"javascript"`
 	ExpiresIn  string `json:"expires_in" example:"1h"`
 	IsPrivate  bool   `json:"is_private" example:"false"`
+	Encrypted  bool   `json:"is_encrypted" example:"false"`
+	MaxViews   int    `json:"max_views,omitempty" example:"5"`
+	// Live pastes are appended to by their owner with POST /pastes/{id}/append and followed with /pastes/{id}/live/ws
+	Live bool `json:"live,omitempty" example:"false"`
+	// Transfer encoding of content, for binary-ish content that JSON would mangle or bloat; stored decoded
+	Encoding string `json:"encoding,omitempty" example:"base64" enums:"base64,hex"`
+
+	Title       string   `json:"title,omitempty" example:"Hello world"`
+	Description string   `json:"description,omitempty" example:"Prints a greeting"`
+	Tags        []string `json:"tags,omitempty" example:"javascript,demo"`
+
+	Delivery *DeliveryHeaders `json:"delivery,omitempty"`
+
+	// JSON Schema to validate JSON or YAML content against; non-conforming content is accepted and the errors stored
+	Schema map[string]interface{} `json:"schema,omitempty" swaggertype:"object"`
+
+	// Short ID of the paste this one holds the output of, listed by GET /pastes/{id}/outputs
+	OutputOf string    `json:"output_of,omitempty" example:"aB3dE5"`
+	Producer *Producer `json:"producer,omitempty"`
+}
+
+// Producer describes the job or tool that produced the content of a paste
+type Producer struct {
+	Kind string `json:"kind" example:"ci"`
+	Name string `json:"name,omitempty" example:"build #1234"`
+	URL  string `json:"url,omitempty" example:"https://ci.example.com/jobs/1234"`
+}
+
+// DeliveryHeaders overrides the headers of raw delivery (GET /{id}) for tooling consuming the paste directly.
+// Content types that browsers render as active content are rejected.
+type DeliveryHeaders struct {
+	ContentType  string `json:"content_type,omitempty" example:"application/json"`
+	CacheControl string `json:"cache_control,omitempty" example:"public, max-age=300"`
+	Filename     string `json:"filename,omitempty" example:"config.json"`
 }
 
 // CreatePasteResponse represents the response after creating a paste
@@ -35,6 +136,25 @@ type CreatePasteResponse struct {
 	ShortID   string  `json:"short_id" example:"xK9a2B"`
 	URL       string  `json:"url" example:"http://localhost:8080/xK9a2B"`
 	ExpiresAt *string `json:"expires_at,omitempty" example:"2024-01-15T15:00:00Z"`
+	// DeleteToken is returned once, for pastes created without an API key or a sign-in; keep it to delete the paste
+	DeleteToken string `json:"delete_token,omitempty" example:"q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"`
+
+	Validation       *SchemaValidation       `json:"validation,omitempty"`
+	ExpirationPolicy *ExpirationPolicyResult `json:"expiration_policy,omitempty"`
+}
+
+// ExpirationPolicyResult reports the maximum lifetime the instance enforces for the syntax type of a new paste
+type ExpirationPolicyResult struct {
+	SyntaxType string `json:"syntax_type" example:"dotenv"`
+	MaxTTL     string `json:"max_ttl" example:"24h0m0s"`
+	Capped     bool   `json:"capped" example:"true"` // the requested expiration was shortened to max_ttl
+}
+
+// SchemaValidation is the result of validating a paste against the JSON Schema supplied when it was created
+type SchemaValidation struct {
+	Valid       bool     `json:"valid" example:"false"`
+	Errors      []string `json:"errors,omitempty" example:"/port: got string, want integer"`
+	ValidatedAt string   `json:"validated_at" example:"2024-01-15T14:00:00Z"`
 }
 
 // GetPasteResponse represents the response when retrieving a paste
@@ -44,73 +164,477 @@ type GetPasteResponse struct {
 	SyntaxType string  `json:"syntax_type" example:"javascript"`
 	CreatedAt  string  `json:"created_at" example:"2024-01-15T14:00:00Z"`
 	ExpiresAt  *string `json:"expires_at,omitempty" example:"2024-01-15T15:00:00Z"`
+	Binary     bool    `json:"binary,omitempty" example:"false"`
+	Encrypted  bool    `json:"is_encrypted" example:"false"`
+	Preview    string  `json:"preview,omitempty" example:"console.log('Hello, World!')"`
+	Size       int     `json:"size" example:"28"`
+	Truncated  bool    `json:"truncated,omitempty" example:"false"`
+	Views      int64   `json:"views" example:"1"`
+	MaxViews   int     `json:"max_views,omitempty" example:"5"`
+	Encoding   string  `json:"encoding,omitempty" example:"base64"` // set when content was requested in an encoding
+
+	Title       string   `json:"title,omitempty" example:"Hello world"`
+	Description string   `json:"description,omitempty" example:"Prints a greeting"`
+	Tags        []string `json:"tags,omitempty" example:"javascript,demo"`
+
+	Delivery   *DeliveryHeaders  `json:"delivery,omitempty"`
+	Validation *SchemaValidation `json:"validation,omitempty"`
+	OutputOf   string            `json:"output_of,omitempty" example:"aB3dE5"` // set on pastes holding the output of another paste
+	Producer   *Producer         `json:"producer,omitempty"`
+	ForkedFrom string            `json:"forked_from,omitempty" example:"aB3dE5"`                      // set on pastes forked from another paste
+	SourceURL  string            `json:"source_url,omitempty" example:"https://example.com/hello.py"` // set on pastes created from a URL
+
+	DerivedFrom *DerivedFrom `json:"derived_from,omitempty"`                     // set on snippets of another paste
+	GroupID     string       `json:"group_id,omitempty" example:"Zk3q9XbW1pLm"`  // set on the files of a bundle or a multi-file gist
+	Path        string       `json:"path,omitempty" example:"cmd/gisty/main.go"` // the file's path in its bundle, or its filename in a gist
+	Live        bool         `json:"live,omitempty" example:"false"`             // set on pastes appended to by their owner
+
+	// Set instead of the content of large pastes read with delivery=url
+	ContentURL          string  `json:"content_url,omitempty" example:"https://s3.example.com/gisty/gisty/xK9a2B.gz?X-Amz-Signature=..."`
+	ContentURLExpiresAt *string `json:"content_url_expires_at,omitempty" example:"2024-01-15T14:05:00Z"`
+	ContentURLEncoding  string  `json:"content_url_encoding,omitempty" example:"gzip"` // codec of the downloaded bytes, also sent as Content-Encoding
+}
+
+// UpdatePasteRequest represents the request body for editing a paste
+type UpdatePasteRequest struct {
+	Content    string `json:"content" binding:"required" example:"console.log('Hello again!')"`
+	SyntaxType string `json:"syntax_type" example:"javascript"`
+}
+
+// UpdatePasteResponse represents the response after editing a paste
+type UpdatePasteResponse struct {
+	ShortID   string `json:"short_id" example:"xK9a2B"`
+	URL       string `json:"url" example:"http://localhost:8080/xK9a2B"`
+	Revision  int    `json:"revision" example:"1"`
+	UpdatedAt string `json:"updated_at" example:"2024-01-15T14:30:00Z"`
+}
+
+// RedactRequest represents the request body for redacting a paste and its history
+type RedactRequest struct {
+	Lines    []LineRange `json:"lines"`
+	Patterns []string    `json:"patterns" example:"AKIA[0-9A-Z]{16}"`
+	Marker   string      `json:"marker,omitempty" example:"[REDACTED]"`
+}
+
+// LineRange is an inclusive range of 1-based line numbers of the current content
+type LineRange struct {
+	Start int `json:"start" example:"3"`
+	End   int `json:"end,omitempty" example:"4"`
+}
+
+// RedactResponse represents the response after redacting a paste
+type RedactResponse struct {
+	ShortID            string `json:"short_id" example:"xK9a2B"`
+	Redactions         int    `json:"redactions" example:"3"`
+	RevisionsRewritten int    `json:"revisions_rewritten" example:"1"`
+	RedactedAt         string `json:"redacted_at" example:"2024-01-15T14:30:00Z"`
+}
+
+// PanicResponse lists the pastes destroyed by POST /me/panic
+type PanicResponse struct {
+	Deleted  int      `json:"deleted" example:"2"`
+	ShortIDs []string `json:"short_ids" example:"xK9a2B,pQ7rT1"`
+}
+
+// RevisionResponse describes a previous version of a paste
+type RevisionResponse struct {
+	Revision   int    `json:"revision" example:"1"`
+	SyntaxType string `json:"syntax_type" example:"javascript"`
+	Binary     bool   `json:"binary,omitempty" example:"false"`
+	CreatedAt  string `json:"created_at" example:"2024-01-15T14:00:00Z"`
+	ReplacedAt string `json:"replaced_at" example:"2024-01-15T14:30:00Z"`
+}
+
+// ListRevisionsResponse lists the previous versions of a paste, newest first
+type ListRevisionsResponse struct {
+	ShortID   string             `json:"short_id" example:"xK9a2B"`
+	Revision  int                `json:"revision" example:"1"`
+	Revisions []RevisionResponse `json:"revisions"`
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string `json:"error" example:"Paste not found"`
+	Code    string `json:"code" example:"paste_not_found"`
 	MaxSize string `json:"max_size,omitempty" example:"1MB"`
 }
 
 // CreatePaste godoc
 // @Summary Create a new paste
-// @Description Create a new code/text snippet with optional expiration and syntax highlighting
+// @Description Create a new code/text snippet with optional expiration and syntax highlighting.
+// @Description The instance may cap the lifetime of pastes of some syntax types, such as dotenv files; expiration_policy in the response reports the cap and whether it shortened the requested expiration.
+// @Description A text/plain body is the content itself, expiring as set by the X-Gisty-Expires header, with the other options at their defaults.
 // @Tags pastes
-// @Accept json
+// @Accept json,plain
 // @Produce json
+// @Security APIKey
 // @Param request body CreatePasteRequest true "Paste content and options"
+// @Param X-Gisty-Expires header string false "Expiration of a text/plain paste, in seconds or as expires_in" example(3600)
 // @Success 201 {object} CreatePasteResponse "Paste created successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request (empty content, invalid syntax_type, invalid expires_in)"
+// @Failure 400 {object} ErrorResponse "Invalid request (empty content, invalid encoding, invalid syntax_type, invalid expires_in, invalid delivery headers, invalid producer, live with burn-after-read, max_views or encryption, line too long or NUL bytes when rejected by policy)"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
 // @Failure 413 {object} ErrorResponse "Content too large (max 1MB)"
+// @Failure 422 {object} ErrorResponse "The output_of paste does not exist or has expired, or content screening rejected the paste as spam or abuse"
 // @Failure 429 {object} ErrorResponse "Rate limit exceeded"
-// @Failure 503 {object} ErrorResponse "Service temporarily unavailable"
+// @Failure 503 {object} ErrorResponse "Service temporarily unavailable, or no short ID available (with Retry-After while the key pool is below its critical floor)"
 // @Router /pastes [post]
 func (h *PasteHandler) CreatePaste(c *gin.Context) {
 	var req service.CreatePasteRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if c.ContentType() == "text/plain" {
+		if !h.bindPlainText(c, &req) {
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("[CreatePaste] Failed to bind JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request body",
-		})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	response, ok := h.createPaste(c, &req)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// QuickCreate godoc
+// @Summary Create a paste from a raw body
+// @Description Create a paste from the raw request body, of any content type, at the root of the server rather than under /api/v1,
+// @Description as in curl --data-binary @file. The paste expires as set by the X-Gisty-Expires header; the other options are at their defaults.
+// @Tags raw
+// @Accept plain
+// @Produce plain
+// @Security APIKey
+// @Param X-Gisty-Expires header string false "Expiration, in seconds or as expires_in" example(3600)
+// @Param request body string true "Content"
+// @Success 201 {string} string "Share link of the paste, on one line; the delete token of anonymous pastes is in the X-Delete-Token header"
+// @Failure 400 {object} ErrorResponse "Empty content, invalid X-Gisty-Expires, line too long or NUL bytes when rejected by policy"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 413 {object} ErrorResponse "Content too large (max 1MB)"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} ErrorResponse "Service temporarily unavailable, or no short ID available"
+// @Router / [post]
+func (h *PasteHandler) QuickCreate(c *gin.Context) {
+	var req service.CreatePasteRequest
+	if !h.bindPlainText(c, &req) {
+		return
+	}
+
+	response, ok := h.createPaste(c, &req)
+	if !ok {
 		return
 	}
+	if response.DeleteToken != "" {
+		c.Header(DeleteTokenHeader, response.DeleteToken)
+	}
+	c.String(http.StatusCreated, "%s\n", response.URL)
+}
+
+// bindPlainText reads a paste created from a plain-text body: the body is the content and the
+// X-Gisty-Expires header its expiration. It answers the request when the body cannot be read.
+func (h *PasteHandler) bindPlainText(c *gin.Context, req *service.CreatePasteRequest) bool {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.handleError(c, service.ErrContentTooLarge)
+			return false
+		}
+		log.Printf("[CreatePaste] Failed to read body: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return false
+	}
+	req.Content = string(body)
+	req.ExpiresIn = strings.TrimSpace(c.GetHeader(ExpiresHeader))
+	return true
+}
+
+// createPaste creates a paste for the client of the request, answering it on failure
+func (h *PasteHandler) createPaste(c *gin.Context, req *service.CreatePasteRequest) (*service.CreatePasteResponse, bool) {
+	req.SourceIP = c.ClientIP()
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
 
 	log.Printf("[CreatePaste] Request: syntax_type=%s, expires_in=%s, content_length=%d",
 		req.SyntaxType, req.ExpiresIn, len(req.Content))
 
-	response, err := h.pasteService.CreatePaste(c.Request.Context(), &req)
+	response, err := h.pasteService.CreatePaste(c.Request.Context(), req)
 	if err != nil {
 		log.Printf("[CreatePaste] Error: %v", err)
 		h.handleError(c, err)
-		return
+		return nil, false
 	}
 
 	log.Printf("[CreatePaste] Success: short_id=%s", response.ShortID)
-	c.JSON(http.StatusCreated, response)
+	return response, true
 }
 
 // GetPaste godoc
 // @Summary Get a paste by ID
-// @Description Retrieve a paste's content and metadata by its short ID
+// @Description Retrieve a paste's content and metadata by its short ID. Responses carry an ETag, except for
+// @Description burn-after-read and view-limited pastes, to revalidate with If-None-Match.
 // @Tags pastes
 // @Accept json
 // @Produce json
 // @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param max_bytes query int false "Cap the returned content at this many bytes (0 returns full content)"
+// @Param encoding query string false "Return the content base64 or hex encoded, byte for byte, instead of as a JSON string" Enums(base64, hex)
+// @Param delivery query string false "Return a pre-signed storage URL in content_url instead of the content of pastes above the server's size threshold" Enums(url)
+// @Param If-None-Match header string false "ETag of a previous response; 304 is returned without counting a view when the content is unchanged"
 // @Success 200 {object} GetPasteResponse "Paste retrieved successfully"
-// @Failure 400 {object} ErrorResponse "Missing paste ID"
+// @Success 304 "Content unchanged since the given ETag"
+// @Success 307 "Redirect to the paste whose ID matches regardless of case, when enabled"
+// @Failure 400 {object} ErrorResponse "Missing paste ID, invalid max_bytes, invalid encoding or invalid delivery"
 // @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Paste content archived until restored"
 // @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 503 {object} ErrorResponse "Storage unavailable and the content not cached"
 // @Router /pastes/{id} [get]
 func (h *PasteHandler) GetPaste(c *gin.Context) {
 	shortID := c.Param("id")
 	if shortID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Missing paste ID",
-		})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	maxBytes, ok := h.maxBytesParam(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidMaxBytes))
+		return
+	}
+	encoding := c.Query("encoding")
+	if !codec.Valid(encoding) {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidEncoding))
+		return
+	}
+	delivery := c.Query("delivery")
+	if delivery != "" && delivery != "url" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidDelivery))
 		return
 	}
 
-	response, err := h.pasteService.GetPaste(c.Request.Context(), shortID)
+	if h.notModified(c, shortID, true) {
+		return
+	}
+
+	getPaste := h.pasteService.GetPaste
+	if delivery == "url" {
+		getPaste = h.pasteService.GetPasteWithURL
+	}
+	response, err := getPaste(c.Request.Context(), shortID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	response.Truncate(maxBytes)
+	if err := response.Encode(encoding); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	setETag(c, response.ContentHash, true)
+	c.JSON(http.StatusOK, response)
+}
+
+// BulkGetItem represents the outcome of reading one paste of a bulk read
+type BulkGetItem struct {
+	ID     string            `json:"id" example:"xK9a2B"`
+	Status int               `json:"status" example:"200"` // the status GET /pastes/{id} would have answered
+	Paste  *GetPasteResponse `json:"paste,omitempty"`      // set when the paste was read
+	Error  string            `json:"error,omitempty" example:"Paste not found"`
+	Code   string            `json:"code,omitempty" example:"paste_not_found"`
+}
+
+// BulkGetResponse represents the pastes of a bulk read, in the order of the request
+type BulkGetResponse struct {
+	Pastes []BulkGetItem `json:"pastes"`
+}
+
+// bulkGetItem is the BulkGetItem sent for each paste
+type bulkGetItem struct {
+	ID     string                    `json:"id"`
+	Status int                       `json:"status"`
+	Paste  *service.GetPasteResponse `json:"paste,omitempty"`
+	Error  string                    `json:"error,omitempty"`
+	Code   string                    `json:"code,omitempty"`
+}
+
+// BulkGetPastes godoc
+// @Summary Get several pastes
+// @Description Read up to 100 pastes in one round trip, for clients rendering many snippets at once. Each paste is read as by GET /pastes/{id}, counting a view; one that cannot be read does not fail the request but gets its own status and error code in place of the paste. Results follow the order of the IDs, each ID read once however often it is listed.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Param request body service.BulkGetRequest true "Pastes to read"
+// @Success 200 {object} BulkGetResponse "Each paste, or why it could not be read"
+// @Failure 400 {object} ErrorResponse "No IDs, more than 100, or invalid max_bytes"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /pastes/bulk-get [post]
+func (h *PasteHandler) BulkGetPastes(c *gin.Context) {
+	var req service.BulkGetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[BulkGetPastes] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	maxBytes := h.maxBytes
+	if req.MaxBytes != nil {
+		maxBytes = *req.MaxBytes
+	}
+
+	results := h.pasteService.GetPastes(c.Request.Context(), req.IDs)
+	items := make([]bulkGetItem, 0, len(results))
+	for _, result := range results {
+		item := bulkGetItem{ID: result.ShortID, Status: http.StatusOK, Paste: result.Paste}
+		if result.Err != nil {
+			item.Status, item.Code = bulkGetError(result.Err)
+			item.Error = middleware.ErrorText(c, item.Code)
+		} else {
+			result.Paste.Truncate(maxBytes)
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pastes": items})
+}
+
+// bulkGetError returns the status and error code GET /pastes/{id} answers a read error with
+func bulkGetError(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrPasteNotFound):
+		return http.StatusNotFound, i18n.CodePasteNotFound
+	case errors.Is(err, service.ErrPasteExpired):
+		return http.StatusGone, i18n.CodePasteExpired
+	case errors.Is(err, service.ErrPasteArchived):
+		return http.StatusConflict, i18n.CodePasteArchived
+	case errors.Is(err, service.ErrPasteQuarantined):
+		return http.StatusConflict, i18n.CodePasteQuarantined
+	case errors.Is(err, service.ErrDependencyUnavailable):
+		return http.StatusServiceUnavailable, i18n.CodeServiceUnavailable
+	default:
+		log.Printf("[BulkGetPastes] Error: %v", err)
+		return http.StatusInternalServerError, i18n.CodeInternalError
+	}
+}
+
+// UpdatePaste godoc
+// @Summary Edit a paste
+// @Description Replace the content of a paste. The previous version is kept as a revision.
+// @Description Only the creator of the paste, proven as for DELETE /pastes/{id}, or an admin may edit it.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param X-Delete-Token header string false "Delete token returned when the paste was created"
+// @Param delete_token query string false "Delete token, when the header cannot be sent"
+// @Param X-Admin-Token header string false "Admin token, to edit any paste"
+// @Param request body UpdatePasteRequest true "New content"
+// @Success 200 {object} UpdatePasteResponse "Paste updated successfully"
+// @Failure 400 {object} ErrorResponse "Invalid request (empty content, invalid syntax_type, line too long or NUL bytes when rejected by policy)"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 403 {object} ErrorResponse "Missing or wrong delete token, not the owner and not an admin"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Paste was edited concurrently, or is a live paste"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 413 {object} ErrorResponse "Content too large (max 1MB)"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} ErrorResponse "Storage or database unavailable"
+// @Router /pastes/{id} [put]
+func (h *PasteHandler) UpdatePaste(c *gin.Context) {
+	shortID := c.Param("id")
+	if shortID == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	var req service.UpdatePasteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[UpdatePaste] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.Caller = h.caller(c)
+
+	response, err := h.pasteService.UpdatePaste(c.Request.Context(), shortID, &req)
+	if err != nil {
+		log.Printf("[UpdatePaste] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RedactPaste godoc
+// @Summary Redact a paste and its history
+// @Description Replace line ranges of the current content and regular expression matches with a marker,
+// @Description in the current content and every revision. Lines redacted in the current content are also
+// @Description redacted wherever the same line appears in a revision. No copy of the previous content is kept.
+// @Description Only the creator of the paste, proven as for DELETE /pastes/{id}, or an admin may redact it.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param X-Delete-Token header string false "Delete token returned when the paste was created"
+// @Param delete_token query string false "Delete token, when the header cannot be sent"
+// @Param X-Admin-Token header string false "Admin token, to redact any paste"
+// @Param request body RedactRequest true "Lines and patterns to redact"
+// @Success 200 {object} RedactResponse "Paste redacted"
+// @Failure 400 {object} ErrorResponse "Invalid line range, pattern or marker"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 403 {object} ErrorResponse "Missing or wrong delete token, not the owner and not an admin"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Paste was edited concurrently"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 422 {object} ErrorResponse "Encrypted or binary paste"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /pastes/{id}/redact [post]
+func (h *PasteHandler) RedactPaste(c *gin.Context) {
+	shortID := c.Param("id")
+	if shortID == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	var req service.RedactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[RedactPaste] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.Caller = h.caller(c)
+
+	response, err := h.pasteService.RedactPaste(c.Request.Context(), shortID, &req)
+	if err != nil {
+		log.Printf("[RedactPaste] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListRevisions godoc
+// @Summary List the revisions of a paste
+// @Description List the previous versions of an edited paste, newest first
+// @Tags pastes
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Success 200 {object} ListRevisionsResponse "Revisions of the paste"
+// @Failure 400 {object} ErrorResponse "Missing paste ID"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/revisions [get]
+func (h *PasteHandler) ListRevisions(c *gin.Context) {
+	shortID := c.Param("id")
+	if shortID == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	response, err := h.pasteService.ListRevisions(c.Request.Context(), shortID)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -121,25 +645,29 @@ func (h *PasteHandler) GetPaste(c *gin.Context) {
 
 // DeletePaste godoc
 // @Summary Delete a paste
-// @Description Delete a paste by its short ID
+// @Description Delete a paste by its short ID. Pastes created anonymously are deleted with the delete token returned
+// @Description when they were created, others by the API key, signed-in user or anonymous session (X-Gisty-Session header) that created them.
+// @Description Admins may delete any paste with the admin token.
 // @Tags pastes
 // @Accept json
 // @Produce json
 // @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param X-Delete-Token header string false "Delete token returned when the paste was created"
+// @Param delete_token query string false "Delete token, when the header cannot be sent"
+// @Param X-Admin-Token header string false "Admin token, to delete any paste"
 // @Success 204 "Paste deleted successfully"
 // @Failure 400 {object} ErrorResponse "Missing paste ID"
+// @Failure 403 {object} ErrorResponse "Missing or wrong delete token, not the owner and not an admin"
 // @Failure 404 {object} ErrorResponse "Paste not found"
 // @Router /pastes/{id} [delete]
 func (h *PasteHandler) DeletePaste(c *gin.Context) {
 	shortID := c.Param("id")
 	if shortID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Missing paste ID",
-		})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
 		return
 	}
 
-	err := h.pasteService.DeletePaste(c.Request.Context(), shortID)
+	err := h.pasteService.DeletePaste(c.Request.Context(), shortID, h.caller(c))
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -148,107 +676,426 @@ func (h *PasteHandler) DeletePaste(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// caller identifies who deletes or edits a paste: by the delete token, sent in the X-Delete-Token
+// header or the delete_token query parameter, by the API key, user or session of the request, and
+// by the admin token
+func (h *PasteHandler) caller(c *gin.Context) *service.DeletePasteRequest {
+	req := &service.DeletePasteRequest{
+		DeleteToken: c.GetHeader(DeleteTokenHeader),
+		OwnerID:     middleware.OwnerID(c),
+		UserID:      middleware.UserID(c),
+		Admin:       middleware.IsAdmin(c, h.adminToken),
+	}
+	if req.DeleteToken == "" {
+		req.DeleteToken = c.Query("delete_token")
+	}
+	return req
+}
+
 // ShortURL handles GET /:id with content negotiation
-// Returns JSON for Accept: application/json, redirects to frontend for text/html, plain text otherwise
+// Returns JSON for Accept: application/json, the HTML view (or a redirect to the frontend) for text/html,
+// plain text otherwise. Link unfurlers (Slackbot, Discordbot...) get an HTML card with OpenGraph tags.
+// Browsers may be redirected to a configured page for expired and burned pastes.
+// JSON and plain text responses carry an ETag and honor If-None-Match. Plain text stored gzip
+// compressed is sent as stored to clients accepting gzip.
+//
+// @Summary Read a paste by its share link
+// @Description Serve a paste on its share link, at the root of the server (or of SHORT_URL_BASE) rather than under
+// @Description /api/v1. The representation follows the Accept header: JSON as GET /pastes/{id} for application/json,
+// @Description the highlighted HTML view for text/html, the raw content otherwise. Raw content stored gzip compressed
+// @Description is sent as stored to clients accepting gzip. Chat apps and social networks unfurling the link (Slackbot,
+// @Description Discordbot, Twitterbot...) get a minimal HTML page with OpenGraph and Twitter card tags (title, first lines,
+// @Description language), built without counting a view or burning a burn-after-read paste; the HTML view carries the same tags.
+// @Tags raw
+// @Produce plain,json,html
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param max_bytes query int false "Cap the returned content at this many bytes, for JSON responses"
+// @Param Accept header string false "application/json, text/html or any other type for the raw content"
+// @Param If-None-Match header string false "ETag of a previous response; 304 is returned without counting a view when the content is unchanged"
+// @Success 200 {string} string "Raw content, the JSON of GetPasteResponse or the HTML view"
+// @Success 302 "Redirect to the frontend view, or to the expired paste page"
+// @Success 304 "Content unchanged since the given ETag"
+// @Success 307 "Redirect to the paste whose ID matches regardless of case, when enabled"
+// @Failure 400 {object} ErrorResponse "Invalid max_bytes"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Paste content archived until restored"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 503 {object} ErrorResponse "Storage unavailable and the content not cached"
+// @Router /{id} [get]
 func (h *PasteHandler) ShortURL(c *gin.Context) {
 	shortID := c.Param("id")
 	if shortID == "" {
-		c.String(http.StatusBadRequest, "Missing paste ID")
+		c.String(http.StatusBadRequest, middleware.ErrorText(c, i18n.CodeMissingPasteID))
 		return
 	}
 
-	// Content negotiation based on Accept header
+	// Content negotiation based on Accept header. The view, JSON and plain text, as-is or gzip
+	// compressed, are served on the same URL, so caches must key them apart
 	accept := c.GetHeader("Accept")
+	c.Writer.Header().Add("Vary", "Accept, Accept-Encoding")
+
+	// Chat apps unfurling a shared link get a card with the paste's first lines. It is served
+	// from a preview, whatever they accept, so unfurling never burns or counts a view of the paste.
+	// The card is not cached; cached HTML views carry the same tags, so Vary leaves out User-Agent.
+	if isUnfurlBot(c.Request.UserAgent()) {
+		h.renderCard(c, shortID)
+		return
+	}
 
-	// Browser request (text/html) - redirect to frontend for SPA rendering
+	// Browser request (text/html) - render the highlighted view, or redirect to frontend for SPA rendering
 	if strings.Contains(accept, "text/html") {
-		c.Redirect(http.StatusFound, "/view/"+shortID)
+		if !h.htmlView {
+			c.Redirect(http.StatusFound, h.viewBaseURL+"/view/"+shortID)
+			return
+		}
+		response, err := h.pasteService.GetPaste(c.Request.Context(), shortID)
+		if err != nil {
+			if target, ok := h.expiredRedirect(shortID, err); ok {
+				c.Header("Cache-Control", "no-store")
+				c.Redirect(http.StatusFound, target)
+				return
+			}
+			h.handleShortURLError(c, err)
+			return
+		}
+		h.renderHTMLView(c, response)
 		return
 	}
 
-	response, err := h.pasteService.GetPaste(c.Request.Context(), shortID)
-	if err != nil {
-		h.handleShortURLError(c, err)
+	// JSON response for API clients, optionally truncated
+	useJSON := strings.Contains(accept, "application/json")
+	maxBytes, ok := h.maxBytesParam(c)
+	if useJSON && !ok {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidMaxBytes))
+		return
+	}
+
+	if h.notModified(c, shortID, useJSON) {
 		return
 	}
 
-	// JSON response for API clients
-	if strings.Contains(accept, "application/json") {
+	if useJSON {
+		response, err := h.pasteService.GetPaste(c.Request.Context(), shortID)
+		if err != nil {
+			h.handleShortURLError(c, err)
+			return
+		}
+		response.Truncate(maxBytes)
+		setETag(c, response.ContentHash, true)
 		c.JSON(http.StatusOK, response)
 		return
 	}
 
-	// Default: return plain text content (curl, wget, etc.)
+	// Default: stream plain text content (curl, wget, etc.) without holding large pastes in memory.
+	// Clients accepting gzip get the content as stored, sparing its decompression.
+	response, content, err := h.pasteService.OpenPaste(c.Request.Context(), shortID, acceptsGzip(c))
+	if err != nil {
+		h.handleShortURLError(c, err)
+		return
+	}
+	defer content.Close()
+
+	var extraHeaders map[string]string
+	if response.ContentEncoding != "" {
+		extraHeaders = map[string]string{"Content-Encoding": response.ContentEncoding}
+		if response.ContentHash != "" {
+			c.Header("ETag", gzipETag(response.ContentHash))
+		}
+	} else {
+		setETag(c, response.ContentHash, false)
+	}
+
 	c.Header("X-Syntax-Type", response.SyntaxType)
 	c.Header("X-Created-At", response.CreatedAt)
 	if response.ExpiresAt != nil {
 		c.Header("X-Expires-At", *response.ExpiresAt)
 	}
-	c.String(http.StatusOK, response.Content)
+	if response.Encrypted {
+		// Ciphertext is returned as stored; the client decrypts it
+		c.Header("X-Gisty-Encrypted", "true")
+	}
+
+	contentType := "text/plain; charset=utf-8"
+	if response.Binary {
+		contentType = "application/octet-stream"
+	}
+	if d := response.Delivery; d != nil {
+		// Owner overrides; binary pastes keep their content type
+		if d.ContentType != "" && !response.Binary {
+			contentType = d.ContentType
+		}
+		if d.CacheControl != "" {
+			c.Header("Cache-Control", d.CacheControl)
+		}
+		if d.Filename != "" {
+			c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": d.Filename}))
+		}
+		c.Header("X-Content-Type-Options", "nosniff")
+	}
+	extendWriteDeadline(c)
+	c.DataFromReader(http.StatusOK, -1, contentType, content, extraHeaders)
+}
+
+// extendWriteDeadline gives a streamed response streamWriteTimeout to be written in place of the
+// server's write timeout, which is sized for small responses. Writers that cannot set a deadline,
+// such as test recorders, are left as they are.
+func extendWriteDeadline(c *gin.Context) {
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+}
+
+// Panic godoc
+// @Summary Destroy my unread secret pastes
+// @Description Immediately expire and delete every unread burn-after-read or view-limited paste created
+// @Description with the caller's API key or anonymous session (X-Gisty-Session header)
+// @Tags pastes
+// @Produce json
+// @Security APIKey
+// @Param X-Gisty-Session header string false "Anonymous session token the pastes were created with"
+// @Success 200 {object} PanicResponse "Pastes deleted"
+// @Failure 401 {object} ErrorResponse "Neither an API key nor a session was sent"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /me/panic [post]
+func (h *PasteHandler) Panic(c *gin.Context) {
+	response, err := h.pasteService.Panic(c.Request.Context(), middleware.OwnerID(c))
+	if err != nil {
+		log.Printf("[Panic] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// maxBytesParam returns the content cap requested with ?max_bytes=, or the configured default.
+// It reports false when the parameter is not a non-negative integer.
+func (h *PasteHandler) maxBytesParam(c *gin.Context) (int, bool) {
+	raw, present := c.GetQuery("max_bytes")
+	if !present {
+		return h.maxBytes, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// expiredRedirect returns the URL a browser is redirected to when err reports an expired or
+// burned paste and a redirect template is configured
+func (h *PasteHandler) expiredRedirect(shortID string, err error) (string, bool) {
+	if h.expiredRedirectURL == "" {
+		return "", false
+	}
+	var reason string
+	switch {
+	case errors.Is(err, service.ErrPasteExpired):
+		reason = "expired"
+	case errors.Is(err, service.ErrPasteBurned):
+		reason = "burned"
+	default:
+		return "", false
+	}
+	return strings.NewReplacer(
+		"{id}", url.QueryEscape(shortID),
+		"{reason}", reason,
+	).Replace(h.expiredRedirectURL), true
+}
+
+// redirectToCanonicalID redirects requests for a paste that was not found to the same URL with
+// the short ID of the paste matching it regardless of case, and reports whether it did
+func (h *PasteHandler) redirectToCanonicalID(c *gin.Context, err error) bool {
+	shortID := c.Param("id")
+	if shortID == "" || !errors.Is(err, service.ErrPasteNotFound) || errors.Is(err, service.ErrPasteBurned) {
+		return false
+	}
+	canonical, err := h.pasteService.ResolveShortID(c.Request.Context(), shortID)
+	if err != nil || canonical == shortID {
+		return false
+	}
+
+	// Replace the last path segment holding the ID, which comes after any route prefix
+	segments := strings.Split(c.Request.URL.Path, "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if segments[i] == shortID {
+			segments[i] = canonical
+			break
+		}
+	}
+	location := url.URL{Path: strings.Join(segments, "/"), RawQuery: c.Request.URL.RawQuery}
+	c.Redirect(http.StatusTemporaryRedirect, location.String())
+	return true
 }
 
 // handleShortURLError handles errors for short URL endpoint (plain text responses)
 func (h *PasteHandler) handleShortURLError(c *gin.Context, err error) {
+	if h.redirectToCanonicalID(c, err) {
+		return
+	}
 	accept := c.GetHeader("Accept")
 	useJSON := strings.Contains(accept, "application/json")
 
 	switch {
 	case errors.Is(err, service.ErrPasteNotFound):
 		if useJSON {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Paste not found"})
+			c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodePasteNotFound))
 		} else {
-			c.String(http.StatusNotFound, "Paste not found")
+			c.String(http.StatusNotFound, middleware.ErrorText(c, i18n.CodePasteNotFound))
 		}
 	case errors.Is(err, service.ErrPasteExpired):
 		if useJSON {
-			c.JSON(http.StatusGone, gin.H{"error": "Paste has expired"})
+			c.JSON(http.StatusGone, middleware.ErrorBody(c, i18n.CodePasteExpired))
+		} else {
+			c.String(http.StatusGone, middleware.ErrorText(c, i18n.CodePasteExpired))
+		}
+	case errors.Is(err, service.ErrPasteArchived):
+		if useJSON {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodePasteArchived))
+		} else {
+			c.String(http.StatusConflict, middleware.ErrorText(c, i18n.CodePasteArchived))
+		}
+	case errors.Is(err, service.ErrPasteQuarantined):
+		if useJSON {
+			c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodePasteQuarantined))
+		} else {
+			c.String(http.StatusConflict, middleware.ErrorText(c, i18n.CodePasteQuarantined))
+		}
+	case errors.Is(err, service.ErrDependencyUnavailable):
+		if useJSON {
+			c.JSON(http.StatusServiceUnavailable, middleware.ErrorBody(c, i18n.CodeServiceUnavailable))
 		} else {
-			c.String(http.StatusGone, "Paste has expired")
+			c.String(http.StatusServiceUnavailable, middleware.ErrorText(c, i18n.CodeServiceUnavailable))
 		}
 	default:
 		if useJSON {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
 		} else {
-			c.String(http.StatusInternalServerError, "Internal server error")
+			c.String(http.StatusInternalServerError, middleware.ErrorText(c, i18n.CodeInternalError))
 		}
 	}
 }
 
 // handleError maps service errors to HTTP responses
 func (h *PasteHandler) handleError(c *gin.Context, err error) {
+	if h.redirectToCanonicalID(c, err) {
+		return
+	}
 	switch {
 	case errors.Is(err, service.ErrEmptyContent):
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Content cannot be empty",
-		})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeContentEmpty))
 	case errors.Is(err, service.ErrContentTooLarge):
-		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
-			"error":    "Content too large",
+		c.JSON(http.StatusRequestEntityTooLarge, middleware.ErrorBody(c, i18n.CodeContentTooLarge, gin.H{
 			"max_size": "1MB",
-		})
+		}))
 	case errors.Is(err, service.ErrInvalidExpiresIn):
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid expires_in value",
-		})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidExpiresIn))
 	case errors.Is(err, service.ErrInvalidSyntaxType):
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid syntax_type value",
-		})
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidSyntaxType))
+	case errors.Is(err, service.ErrInvalidMaxViews):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidMaxViews))
+	case errors.Is(err, service.ErrInvalidBundle):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidBundle))
+	case errors.Is(err, service.ErrInvalidLive):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLive))
+	case errors.Is(err, service.ErrInvalidEncoding):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidEncoding))
+	case errors.Is(err, service.ErrInvalidMetadata):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidMetadata))
+	case errors.Is(err, service.ErrInvalidSchema):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidSchema))
+	case errors.Is(err, service.ErrInvalidDeliveryHeaders):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidDeliveryHeaders))
+	case errors.Is(err, service.ErrInvalidProducer):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidProducer))
+	case errors.Is(err, service.ErrInputNotFound):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeInputNotFound))
+	case errors.Is(err, service.ErrLineTooLong):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeLineTooLong))
+	case errors.Is(err, service.ErrBinaryContent):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeBinaryContent))
+	case errors.Is(err, service.ErrContentRejected):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeContentRejected))
+	case errors.Is(err, service.ErrInvalidReport):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidReport))
+	case errors.Is(err, service.ErrAlreadyReported):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeAlreadyReported))
+	case errors.Is(err, service.ErrReportsDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeReportsDisabled))
 	case errors.Is(err, service.ErrNoKeysAvailable):
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "Service temporarily unavailable",
-		})
+		if retryAfter := h.pasteService.KeysRetryAfter(); retryAfter > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		}
+		c.JSON(http.StatusServiceUnavailable, middleware.ErrorBody(c, i18n.CodeServiceUnavailable))
+	case errors.Is(err, service.ErrDependencyUnavailable):
+		c.JSON(http.StatusServiceUnavailable, middleware.ErrorBody(c, i18n.CodeServiceUnavailable))
 	case errors.Is(err, service.ErrPasteNotFound):
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Paste not found",
-		})
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodePasteNotFound))
 	case errors.Is(err, service.ErrPasteExpired):
-		c.JSON(http.StatusGone, gin.H{
-			"error": "Paste has expired",
-		})
+		c.JSON(http.StatusGone, middleware.ErrorBody(c, i18n.CodePasteExpired))
+	case errors.Is(err, service.ErrPasteArchived):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodePasteArchived))
+	case errors.Is(err, service.ErrPasteQuarantined):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodePasteQuarantined))
+	case errors.Is(err, service.ErrTrendingDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeTrendingDisabled))
+	case errors.Is(err, service.ErrRunnerDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeRunnerDisabled))
+	case errors.Is(err, service.ErrLanguageNotAllowed):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeLanguageNotAllowed))
+	case errors.Is(err, service.ErrNotRunnable):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeNotRunnable))
+	case errors.Is(err, service.ErrNotForkable):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeNotForkable))
+	case errors.Is(err, service.ErrNotSnippable):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeNotSnippable))
+	case errors.Is(err, service.ErrInvalidLineRange):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLineRange))
+	case errors.Is(err, service.ErrGistDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeGistDisabled))
+	case errors.Is(err, service.ErrInvalidGist):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidGist))
+	case errors.Is(err, service.ErrGistNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeGistNotFound))
+	case errors.Is(err, service.ErrGistTooLarge):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeGistTooLarge))
+	case errors.Is(err, service.ErrNotExportable):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeNotExportable))
+	case errors.Is(err, service.ErrGitHubUnauthorized):
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, i18n.CodeGitHubUnauthorized))
+	case errors.Is(err, service.ErrGitHubUnavailable):
+		c.JSON(http.StatusBadGateway, middleware.ErrorBody(c, i18n.CodeGitHubUnavailable))
+	case errors.Is(err, service.ErrURLImportDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeURLImportDisabled))
+	case errors.Is(err, service.ErrInvalidSourceURL):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidSourceURL))
+	case errors.Is(err, service.ErrSourceURLForbidden):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeSourceURLForbidden))
+	case errors.Is(err, service.ErrUnsupportedSourceType):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeUnsupportedSourceType))
+	case errors.Is(err, service.ErrSourceUnavailable):
+		c.JSON(http.StatusBadGateway, middleware.ErrorBody(c, i18n.CodeSourceUnavailable))
+	case errors.Is(err, service.ErrInvalidRunRequest):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRunRequest))
+	case errors.Is(err, service.ErrRunnerUnavailable):
+		c.JSON(http.StatusBadGateway, middleware.ErrorBody(c, i18n.CodeRunnerUnavailable))
+	case errors.Is(err, service.ErrEditConflict):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeEditConflict))
+	case errors.Is(err, service.ErrNotLive):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeNotLive))
+	case errors.Is(err, service.ErrLivePaste):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeLivePaste))
+	case errors.Is(err, service.ErrInvalidRedaction):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRedaction))
+	case errors.Is(err, service.ErrNotRedactable):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeNotRedactable))
+	case errors.Is(err, service.ErrOwnerRequired):
+		c.JSON(http.StatusUnauthorized, middleware.ErrorBody(c, i18n.CodeOwnerRequired))
+	case errors.Is(err, service.ErrDeleteForbidden):
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, i18n.CodeDeleteForbidden))
+	case errors.Is(err, service.ErrEditForbidden):
+		c.JSON(http.StatusForbidden, middleware.ErrorBody(c, i18n.CodeEditForbidden))
+	case errors.Is(err, service.ErrInvalidChecksum):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidChecksum))
 	default:
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Internal server error",
-		})
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
 	}
 }
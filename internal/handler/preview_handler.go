@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+)
+
+// PreviewResponse represents the metadata and first lines of a paste
+type PreviewResponse struct {
+	ShortID       string   `json:"short_id" example:"xK9a2B"`
+	Title         string   `json:"title,omitempty" example:"Deploy script"`
+	SyntaxType    string   `json:"syntax_type" example:"go"`
+	CreatedAt     string   `json:"created_at" example:"2024-01-15T14:00:00Z"`
+	ExpiresAt     *string  `json:"expires_at,omitempty" example:"2024-01-16T14:00:00Z"`
+	BurnAfterRead bool     `json:"burn_after_read" example:"false"`
+	MaxViews      int      `json:"max_views,omitempty" example:"0"`
+	Encrypted     bool     `json:"is_encrypted" example:"false"`
+	Binary        bool     `json:"binary,omitempty" example:"false"`
+	Lines         []string `json:"lines,omitempty" example:"package main,,func main() {"`
+	LineCount     int      `json:"line_count,omitempty" example:"42"`
+	Truncated     bool     `json:"truncated" example:"true"`
+}
+
+// GetPreview godoc
+// @Summary Preview a paste without reading it
+// @Description Return the metadata and first lines of a paste, for chat-app link unfurlers and readers checking a link before opening it.
+// @Description A preview never counts a view, burns a burn-after-read paste or shows in trending. Lines are omitted for
+// @Description burn-after-read, view-limited, encrypted and binary pastes. Also served on GET /{id}/preview of the short-link host.
+// @Tags pastes
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param lines query int false "Number of lines (default 10, max 50)"
+// @Success 200 {object} PreviewResponse "Paste preview"
+// @Failure 400 {object} ErrorResponse "Invalid number of lines"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/preview [get]
+func (h *PasteHandler) GetPreview(c *gin.Context) {
+	lines := 0
+	if raw, ok := c.GetQuery("lines"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidPreviewLines))
+			return
+		}
+		lines = n
+	}
+
+	response, err := h.pasteService.GetPreview(c.Request.Context(), c.Param("id"), lines)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// Previews are fetched by bots: keep them out of search results and shared caches
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// ProfileSettingsRequest represents the request body for changing the signed-in user's settings;
+// the settings left out are unchanged
+type ProfileSettingsRequest struct {
+	// Hidden disables the public profile, /u/{username}, and the listing of the user's pastes by username
+	Hidden *bool `json:"hidden,omitempty" example:"true"`
+	// EmailDigest sends the user's unread notifications by email, when the server sends email
+	EmailDigest *bool `json:"email_digest,omitempty" example:"true"`
+}
+
+// PinRequest represents the request body for pinning a paste to the signed-in user's profile
+type PinRequest struct {
+	ShortID string `json:"short_id" binding:"required" example:"xK9a2B"`
+}
+
+// GetProfile godoc
+// @Summary Public profile of a user
+// @Description Show a user's public profile: name, Gravatar avatar, totals, the pastes they pinned and a page of their other public pastes, newest first.
+// @Description Private, burn-after-read and view-limited pastes are never listed. Browsers get an HTML page, other clients JSON.
+// @Description Profiles hidden by their user are not found. Pass the next cursor of a page as before to get the following page.
+// @Tags users
+// @Produce json,html
+// @Param username path string true "Username" example(octocat)
+// @Param limit query int false "Number of pastes (default 30, max 100)"
+// @Param before query string false "Next cursor of the previous page, or an RFC 3339 time to list the pastes created before it"
+// @Success 200 {object} service.ProfileResponse "Profile of the user"
+// @Failure 400 {object} ErrorResponse "Invalid limit or cursor"
+// @Failure 404 {object} ErrorResponse "No user with this username, or the profile is hidden"
+// @Router /u/{username} [get]
+func (h *AuthHandler) GetProfile(c *gin.Context) {
+	before, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	user, ok := h.profileUser(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.pasteService.GetProfile(c.Request.Context(), user, before, limit)
+	if err != nil {
+		h.handleProfileError(c, err)
+		return
+	}
+	if strings.Contains(c.GetHeader("Accept"), "text/html") {
+		renderProfilePage(c, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ListUserPastes godoc
+// @Summary Public pastes of a user
+// @Description List the pastes on a user's public profile, newest first, without the profile itself.
+// @Description Pass the next cursor of a page as before to get the following page.
+// @Tags users
+// @Produce json
+// @Param username path string true "Username" example(octocat)
+// @Param limit query int false "Number of pastes (default 30, max 100)"
+// @Param before query string false "Next cursor of the previous page, or an RFC 3339 time to list the pastes created before it"
+// @Success 200 {object} service.UserPastesResponse "Public pastes of the user"
+// @Failure 400 {object} ErrorResponse "Invalid limit or cursor"
+// @Failure 404 {object} ErrorResponse "No user with this username, or the profile is hidden"
+// @Router /users/{username}/pastes [get]
+func (h *AuthHandler) ListUserPastes(c *gin.Context) {
+	before, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	user, ok := h.profileUser(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.pasteService.ListPublicUserPastes(c.Request.Context(), user, before, limit)
+	if err != nil {
+		h.handleProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateProfile godoc
+// @Summary Change my profile settings
+// @Description Hide or show the signed-in user's public profile, and turn email digests of their unread notifications on or off.
+// @Description A hidden profile answers like an unknown username.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ProfileSettingsRequest true "Profile settings"
+// @Success 200 {object} model.User "Signed-in user"
+// @Failure 400 {object} ErrorResponse "Invalid request body"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Router /me/profile [put]
+func (h *AuthHandler) UpdateProfile(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		h.handleError(c, service.ErrSignInRequired)
+		return
+	}
+	var req ProfileSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Hidden == nil && req.EmailDigest == nil) {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	user, err := h.auth.UpdateSettings(c.Request.Context(), userID, req.Hidden, req.EmailDigest)
+	if err != nil {
+		log.Printf("[UpdateProfile] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ListPins godoc
+// @Summary My pinned pastes
+// @Description List the pastes pinned to the top of the signed-in user's profile, in the order they were pinned
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} service.PinsResponse "Pinned pastes"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Router /me/pins [get]
+func (h *AuthHandler) ListPins(c *gin.Context) {
+	response, err := h.pasteService.ListPins(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		log.Printf("[ListPins] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PinPaste godoc
+// @Summary Pin a paste to my profile
+// @Description Pin one of the signed-in user's public pastes to the top of their profile, after those already pinned.
+// @Description Up to 6 pastes can be pinned; burn-after-read and view-limited pastes cannot. Pinning a pinned paste changes nothing.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body PinRequest true "Paste to pin"
+// @Success 200 {object} service.PinsResponse "Pinned pastes"
+// @Failure 400 {object} ErrorResponse "Invalid request body"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 403 {object} ErrorResponse "Not a public paste of the user"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "6 pastes are pinned already"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /me/pins [post]
+func (h *AuthHandler) PinPaste(c *gin.Context) {
+	var req PinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	response, err := h.pasteService.PinPaste(c.Request.Context(), middleware.UserID(c), req.ShortID)
+	if err != nil {
+		log.Printf("[PinPaste] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// UnpinPaste godoc
+// @Summary Unpin a paste from my profile
+// @Description Unpin one of the signed-in user's pastes. Unpinning a paste that is not pinned changes nothing.
+// @Tags auth
+// @Security BearerAuth
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Success 204 "Paste unpinned"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 404 {object} ErrorResponse "No paste of the user with this ID"
+// @Router /me/pins/{id} [delete]
+func (h *AuthHandler) UnpinPaste(c *gin.Context) {
+	if err := h.pasteService.UnpinPaste(c.Request.Context(), middleware.UserID(c), c.Param("id")); err != nil {
+		log.Printf("[UnpinPaste] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// profileUser looks up the user named in the path, answering 404 when there is none
+func (h *AuthHandler) profileUser(c *gin.Context) (*model.User, bool) {
+	user, err := h.auth.UserByUsername(c.Request.Context(), c.Param("username"))
+	if errors.Is(err, repository.ErrUserNotFound) {
+		err = service.ErrProfileNotFound
+	}
+	if err != nil {
+		h.handleProfileError(c, err)
+		return nil, false
+	}
+	return user, true
+}
+
+// handleProfileError maps profile errors to HTTP responses, in plain text for browsers
+func (h *AuthHandler) handleProfileError(c *gin.Context, err error) {
+	if !errors.Is(err, service.ErrProfileNotFound) {
+		log.Printf("[Profile] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	if strings.Contains(c.GetHeader("Accept"), "text/html") {
+		c.String(http.StatusNotFound, middleware.ErrorText(c, i18n.CodeProfileNotFound))
+		return
+	}
+	c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeProfileNotFound))
+}
+
+// pageQuery parses the limit and before query parameters of a listing, answering 400 when they
+// are invalid. before is the next cursor of the previous page, or an RFC 3339 time.
+func pageQuery(c *gin.Context) (repository.PageCursor, int, bool) {
+	limit := 0
+	if raw, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return repository.PageCursor{}, 0, false
+		}
+		limit = n
+	}
+	var before repository.PageCursor
+	if raw := c.Query("before"); raw != "" {
+		cursor, err := repository.ParsePageCursor(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidCursor))
+			return repository.PageCursor{}, 0, false
+		}
+		before = cursor
+	}
+	return before, limit, true
+}
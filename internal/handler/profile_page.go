@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// profilePage is the server-rendered public profile served to browsers on GET /u/:username
+var profilePage = template.Must(template.New("profile").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Username}} · gisty</title>
+<style nonce="{{.Nonce}}">
+body{margin:0;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;background:#f6f8fa;color:#1f2328}
+header{display:flex;align-items:center;gap:1rem;padding:1rem;background:#fff;border-bottom:1px solid #d0d7de}
+header img{width:80px;height:80px;border-radius:50%}
+header h1{margin:0;font-size:1.25rem}
+header .meta{color:#656d76;font-size:.875rem}
+main,section{margin:1rem;background:#fff;border:1px solid #d0d7de;border-radius:6px}
+ul{margin:0;padding:0;list-style:none}
+li{display:flex;gap:1rem;padding:.5rem 1rem;border-bottom:1px solid #d0d7de;font-size:.875rem}
+li:last-child{border-bottom:0}
+li .syntax,li time{color:#656d76}
+li time{margin-left:auto}
+h2{margin:0;padding:.5rem 1rem;border-bottom:1px solid #d0d7de;font-size:.875rem}
+.notice{padding:1rem}
+nav{margin:0 1rem 1rem;font-size:.875rem}
+</style>
+</head>
+<body>
+<header><img src="{{.AvatarURL}}" alt="">
+<div><h1>{{with .Name}}{{.}} <span class="meta">{{$.Username}}</span>{{else}}{{.Username}}{{end}}</h1>
+<div class="meta">{{.Totals.Pastes}} public pastes · {{.Totals.Views}} views · joined <time datetime="{{.JoinedAt}}">{{.JoinedAt}}</time></div></div></header>
+{{with .Pinned}}<section><h2>Pinned</h2><ul>{{range .}}<li><a href="/{{.ShortID}}">{{with .Title}}{{.}}{{else}}{{.ShortID}}{{end}}</a> <span class="syntax">{{.SyntaxType}}</span> <time datetime="{{.CreatedAt}}">{{.CreatedAt}}</time></li>{{end}}</ul></section>
+{{end}}<main>
+{{with .Pastes}}<ul>{{range .}}<li><a href="/{{.ShortID}}">{{with .Title}}{{.}}{{else}}{{.ShortID}}{{end}}</a> <span class="syntax">{{.SyntaxType}}</span> <time datetime="{{.CreatedAt}}">{{.CreatedAt}}</time></li>{{end}}</ul>
+{{else}}{{if not .Pinned}}<p class="notice">No public pastes.</p>{{end}}
+{{end}}</main>
+{{with .NextURL}}<nav><a href="{{.}}">Older pastes</a></nav>
+{{end}}</body>
+</html>
+`))
+
+// profilePageData is the data of profilePage
+type profilePageData struct {
+	*service.ProfileResponse
+	Nonce   string
+	NextURL string
+}
+
+// renderProfilePage writes the HTML page of a public profile
+func renderProfilePage(c *gin.Context, response *service.ProfileResponse) {
+	data := profilePageData{ProfileResponse: response, Nonce: newNonce()}
+	if response.Next != "" {
+		query := url.Values{"before": {response.Next}}
+		if limit := c.Query("limit"); limit != "" {
+			query.Set("limit", limit)
+		}
+		data.NextURL = "/u/" + url.PathEscape(response.Username) + "?" + query.Encode()
+	}
+
+	// Only the page's own style and the avatar may load
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'nonce-"+data.Nonce+"'; img-src https://www.gravatar.com; base-uri 'none'; form-action 'none'")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := profilePage.Execute(c.Writer, data); err != nil {
+		log.Printf("[AuthHandler.renderProfilePage] Failed to render %s: %v", response.Username, err)
+	}
+}
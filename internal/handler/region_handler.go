@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrInvalidRegions is returned when the region list is malformed
+var ErrInvalidRegions = errors.New("region: invalid region list")
+
+// Region describes a deployment region clients can pin
+type Region struct {
+	Name string `json:"name" example:"ap-southeast-1"`
+	URL  string `json:"url" example:"https://sg.gisty.co"`
+}
+
+// RegionsResponse represents the region discovery response
+type RegionsResponse struct {
+	Current string   `json:"current" example:"ap-southeast-1"`
+	Regions []Region `json:"regions"`
+}
+
+// RegionHandler serves region discovery for multi-region deployments
+type RegionHandler struct {
+	current string
+	regions []Region
+}
+
+// NewRegionHandler creates a new RegionHandler
+func NewRegionHandler(current string, regions []Region) *RegionHandler {
+	if regions == nil {
+		regions = []Region{}
+	}
+	return &RegionHandler{
+		current: current,
+		regions: regions,
+	}
+}
+
+// ParseRegions parses a comma-separated list of name=url pairs,
+// e.g. "ap-southeast-1=https://sg.gisty.co,eu-west-1=https://eu.gisty.co"
+func ParseRegions(spec string) ([]Region, error) {
+	var regions []Region
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, rawURL, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		rawURL = strings.TrimRight(strings.TrimSpace(rawURL), "/")
+		if !ok || name == "" {
+			return nil, ErrInvalidRegions
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, ErrInvalidRegions
+		}
+		regions = append(regions, Region{Name: name, URL: rawURL})
+	}
+	return regions, nil
+}
+
+// Regions godoc
+// @Summary List regions
+// @Description Return the region that served the request and the regions clients can pin by calling their URL directly
+// @Tags health
+// @Produce json
+// @Success 200 {object} RegionsResponse "Regions"
+// @Router /regions [get]
+func (h *RegionHandler) Regions(c *gin.Context) {
+	c.JSON(http.StatusOK, RegionsResponse{
+		Current: h.current,
+		Regions: h.regions,
+	})
+}
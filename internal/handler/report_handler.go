@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// ReportPaste godoc
+// @Summary Report a paste
+// @Description Report a paste for abuse: spam, malware, phishing, copyright infringement (DMCA), personal data, illegal content or another reason. Operators review reports and may hide or delete the paste.
+// @Description Anyone can report a paste; a reporter cannot report the same paste again while their report is open.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param request body service.ReportPasteRequest true "Report"
+// @Success 201 {object} model.Report "Report recorded"
+// @Failure 400 {object} ErrorResponse "Unknown reason, or details or contact too long"
+// @Failure 404 {object} ErrorResponse "Paste not found, or reports disabled"
+// @Failure 409 {object} ErrorResponse "Paste already reported by this reporter"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /pastes/{id}/report [post]
+func (h *PasteHandler) ReportPaste(c *gin.Context) {
+	var req service.ReportPasteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.SourceIP = c.ClientIP()
+
+	report, err := h.pasteService.ReportPaste(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		log.Printf("[ReportPaste] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// ListReports godoc
+// @Summary List abuse reports
+// @Description List the abuse reports of pastes, newest first: the open ones awaiting review by default. Pass the next cursor of a page as before to get the following page.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param status query string false "open (default), resolved or all"
+// @Param limit query int false "Number of reports (default 50, max 100)"
+// @Param before query string false "Next cursor of the previous page, or an RFC 3339 time to list the reports created before it"
+// @Success 200 {object} service.ReportsResponse "Reports"
+// @Failure 400 {object} ErrorResponse "Invalid status, limit or cursor"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/reports [get]
+func (h *AdminHandler) ListReports(c *gin.Context) {
+	before, limit, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.pasteService.ListReports(c.Request.Context(), c.Query("status"), before, limit)
+	switch {
+	case errors.Is(err, service.ErrInvalidReportStatus):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidReportStatus))
+	case errors.Is(err, service.ErrReportsDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeReportsDisabled))
+	case err != nil:
+		log.Printf("[ListReports] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+	default:
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ResolveReport godoc
+// @Summary Resolve an abuse report
+// @Description Resolve an open report: dismiss it and leave the paste as is, hide the paste or delete it.
+// @Description A hidden paste is quarantined: it answers 409 until released with DELETE /admin/quarantine/{id}. Hiding or deleting a paste resolves all its open reports.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param id path string true "Report ID"
+// @Param request body service.ResolveReportRequest true "Resolution"
+// @Success 200 {object} service.ResolveReportResponse "Report resolved"
+// @Failure 400 {object} ErrorResponse "Unknown action, or note too long"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 404 {object} ErrorResponse "Report not found"
+// @Failure 409 {object} ErrorResponse "Report already resolved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/reports/{id}/resolve [post]
+func (h *AdminHandler) ResolveReport(c *gin.Context) {
+	var req service.ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	response, err := h.pasteService.ResolveReport(c.Request.Context(), c.Param("id"), &req)
+	switch {
+	case errors.Is(err, service.ErrInvalidResolution):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidResolution))
+	case errors.Is(err, service.ErrReportNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeReportNotFound))
+	case errors.Is(err, service.ErrReportResolved):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeReportResolved))
+	case errors.Is(err, service.ErrReportsDisabled):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodeReportsDisabled))
+	case err != nil:
+		log.Printf("[ResolveReport] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+	default:
+		log.Printf("[ResolveReport] Resolved report %s: %s", response.Report.ID, response.Report.Resolution)
+		c.JSON(http.StatusOK, response)
+	}
+}
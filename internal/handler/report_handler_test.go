@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/config"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+func TestReportRoutes_AdminToken(t *testing.T) {
+	// The admin token is checked before anything is looked up
+	pasteService := service.NewPasteService(nil, nil, nil, nil, "http://localhost:8080")
+	deps := &RouterDeps{
+		PasteHandler: NewPasteHandler(pasteService),
+		AdminHandler: NewAdminHandler(nil, pasteService, nil, nil, nil, nil),
+	}
+	router := newTestRouter(&config.Config{Admin: config.AdminConfig{Token: "s3cret"}}, deps)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		headers []string
+	}{
+		{"list without a token", http.MethodGet, "/api/v1/admin/reports", nil},
+		{"list with a wrong token", http.MethodGet, "/api/v1/admin/reports", []string{"X-Admin-Token", "guess"}},
+		{"takedown without a token", http.MethodPost, "/api/v1/admin/reports/r1/resolve", nil},
+		{"takedown with a wrong token", http.MethodPost, "/api/v1/admin/reports/r1/resolve", []string{"X-Admin-Token", "s3cre"}},
+		{"takedown with a wrong bearer token", http.MethodPost, "/api/v1/admin/reports/r1/resolve", []string{"Authorization", "Bearer s3cret2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(router, tt.method, tt.target, `{"action":"delete"}`, tt.headers...)
+			if w.Code != http.StatusUnauthorized || errorCode(w) != i18n.CodeUnauthorized {
+				t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.target, w.Code, errorCode(w), http.StatusUnauthorized, i18n.CodeUnauthorized)
+			}
+		})
+	}
+
+	// Without an admin token nobody could review reports, so reporting is off
+	router = newTestRouter(nil, deps)
+	if w := serve(router, http.MethodPost, "/api/v1/pastes/xK9a2B/report", `{"reason":"spam"}`); w.Code != http.StatusNotFound {
+		t.Errorf("POST /report without an admin token status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := serve(router, http.MethodGet, "/api/v1/admin/reports", ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET /admin/reports without an admin token status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestPasteHandler_Reports(t *testing.T) {
+	pasteService, db, cleanup := setupHandlerTest(t)
+	defer cleanup()
+
+	reportRepo, err := repository.NewReportRepository(db)
+	if err != nil {
+		t.Fatalf("NewReportRepository() error = %v", err)
+	}
+	pasteService.SetReports(reportRepo)
+	pasteService.SetSourceIPHashKey("test-key")
+
+	deps := &RouterDeps{
+		PasteHandler: NewPasteHandler(pasteService),
+		AdminHandler: NewAdminHandler(nil, pasteService, nil, nil, nil, nil),
+	}
+	router := newTestRouter(&config.Config{Admin: config.AdminConfig{Token: "s3cret"}}, deps)
+
+	paste, err := pasteService.CreatePaste(context.Background(), &service.CreatePasteRequest{Content: "buy cheap watches"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	// Anyone can report a paste, once while their report is open
+	report := `{"reason":"spam","details":"Advertising"}`
+	w := serve(router, http.MethodPost, "/api/v1/pastes/"+paste.ShortID+"/report", report)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /report status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	var created model.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || created.ID == "" || created.Reason != model.ReportSpam {
+		t.Fatalf("POST /report response = %s, want the report", w.Body)
+	}
+	if w := serve(router, http.MethodPost, "/api/v1/pastes/"+paste.ShortID+"/report", report); w.Code != http.StatusConflict {
+		t.Errorf("POST /report again status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w := serve(router, http.MethodPost, "/api/v1/pastes/"+paste.ShortID+"/report", `{"reason":"boring"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("POST /report with an unknown reason status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := serve(router, http.MethodPost, "/api/v1/pastes/missing/report", report); w.Code != http.StatusNotFound {
+		t.Errorf("POST /report of a missing paste status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// Admins list the open reports
+	w = serve(router, http.MethodGet, "/api/v1/admin/reports", "", "X-Admin-Token", "s3cret")
+	var open service.ReportsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &open); err != nil || w.Code != http.StatusOK || open.Open != 1 || len(open.Reports) != 1 {
+		t.Fatalf("GET /admin/reports = %d %s, want the open report", w.Code, w.Body)
+	}
+
+	// Taking the paste down hides it until it is released
+	resolve := "/api/v1/admin/reports/" + created.ID + "/resolve"
+	w = serve(router, http.MethodPost, resolve, `{"action":"hide","note":"Spam"}`, "Authorization", "Bearer s3cret")
+	var resolved service.ResolveReportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resolved); err != nil || w.Code != http.StatusOK || resolved.Report.Resolution != model.ResolutionHidden {
+		t.Fatalf("POST /resolve = %d %s, want the report resolved as hidden", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodGet, "/"+paste.ShortID, ""); w.Code != http.StatusConflict {
+		t.Errorf("GET /:id of a hidden paste status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w := serve(router, http.MethodPost, resolve, `{"action":"delete"}`, "X-Admin-Token", "s3cret"); w.Code != http.StatusConflict {
+		t.Errorf("POST /resolve of a resolved report status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if w := serve(router, http.MethodPost, "/api/v1/admin/reports/missing/resolve", `{"action":"dismiss"}`, "X-Admin-Token", "s3cret"); w.Code != http.StatusNotFound {
+		t.Errorf("POST /resolve of a missing report status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -1,20 +1,57 @@
 package handler
 
 import (
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/huylvt/gisty/internal/config"
+	"github.com/huylvt/gisty/internal/metrics"
 	"github.com/huylvt/gisty/internal/middleware"
 	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/service"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // RouterDeps contains dependencies for the router
 type RouterDeps struct {
-	PasteHandler *PasteHandler
-	RateLimiter  *middleware.RateLimiter
-	S3Client     *repository.S3
+	PasteHandler  *PasteHandler
+	UploadHandler *UploadHandler
+	AdminHandler  *AdminHandler
+	RateLimiter   *middleware.RateLimiter // limits creates
+	Maintenance   middleware.MaintenanceChecker
+	Bans          middleware.BanChecker // bars banned client IPs from writes; nil disables bans
+	IPDenyList    middleware.IPBlocker  // denies every request of blocked client IPs and CIDRs; nil disables it
+	S3Client      *repository.S3
+	// MongoDB and Redis are pinged by the readiness probe with S3Client; nil skips the check
+	MongoDB      *repository.MongoDB
+	Redis        *repository.Redis
+	RequestTimer *middleware.RequestTimer
+	// SLOTracker measures the routes with service level objectives; nil disables it
+	SLOTracker *middleware.SLOTracker
+	// ReadRateLimiter limits paste reads per client; PasteReadLimiter limits reads of one paste per client
+	ReadRateLimiter   *middleware.RateLimiter
+	PasteReadLimiter  *middleware.RateLimiter
+	DeleteRateLimiter *middleware.RateLimiter
+	// APIKeyAuth authenticates API key clients, who get higher rate limits; nil disables API keys
+	APIKeyAuth gin.HandlerFunc
+	// UserAuth identifies signed-in users and AuthHandler serves sign-in; nil disables accounts
+	UserAuth    gin.HandlerFunc
+	AuthHandler *AuthHandler
+	// ReadShedder and WriteShedder bound in-flight paste reads and writes
+	ReadShedder  *middleware.ConcurrencyLimiter
+	WriteShedder *middleware.ConcurrencyLimiter
+	// HotlinkProtection applies the referrer policy to raw content; nil disables it
+	HotlinkProtection gin.HandlerFunc
+	// Tracing starts a server span per request; nil disables it
+	Tracing gin.HandlerFunc
+	// Drain fails the readiness probe once the instance drains; nil never drains
+	Drain *service.Drain
 }
 
 // NewRouter creates and configures a new Gin router
@@ -29,56 +66,521 @@ func NewRouter(cfg *config.Config, deps *RouterDeps) *gin.Engine {
 	// Middleware
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	if deps != nil && deps.IPDenyList != nil {
+		router.Use(middleware.IPDenyMiddleware(deps.IPDenyList))
+	}
 	router.Use(corsMiddleware())
+	router.Use(middleware.VersionHeaderMiddleware())
+	if cfg.Server.Region != "" {
+		router.Use(middleware.RegionHeaderMiddleware(cfg.Server.Region))
+	}
+	if deps != nil && deps.Tracing != nil {
+		router.Use(deps.Tracing)
+	}
+	if deps != nil && deps.RequestTimer != nil {
+		router.Use(deps.RequestTimer.Middleware())
+	}
+	if deps != nil && deps.SLOTracker != nil {
+		router.Use(deps.SLOTracker.Middleware())
+	}
+	if deps != nil && deps.APIKeyAuth != nil {
+		router.Use(deps.APIKeyAuth)
+	}
+	if deps != nil && deps.UserAuth != nil {
+		router.Use(deps.UserAuth)
+	}
 
-	// Swagger documentation
+	// Swagger documentation, and the description it renders for client generators
 	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/openapi.json", OpenAPISpec)
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// Health check and API routes (require deps)
 	if deps != nil {
 		// Health check
-		healthHandler := NewHealthHandler(deps.S3Client)
+		healthHandler := NewDependencyHealthHandler(deps.MongoDB, deps.Redis, deps.S3Client)
+		healthHandler.SetProbeTimeout(readinessTimeout(cfg))
+		healthHandler.SetDrain(deps.Drain)
 		router.GET("/health", healthHandler.Health)
+		router.GET("/healthz", healthHandler.Healthz)
+		router.GET("/readyz", healthHandler.Readyz)
+		router.GET("/version", healthHandler.Version)
 		router.GET("/debug/s3", healthHandler.DebugS3)
 	}
 
+	// Region discovery for multi-region deployments
+	regions, err := ParseRegions(cfg.Server.Regions)
+	if err != nil {
+		log.Printf("Invalid regions '%s', listing none: %v", cfg.Server.Regions, err)
+	}
+	router.GET("/regions", NewRegionHandler(cfg.Server.Region, regions).Regions)
+
+	// Sign-in with OAuth providers or a password
+	if deps != nil && deps.AuthHandler != nil {
+		router.GET("/auth/:provider/login", deps.AuthHandler.Login)
+		router.GET("/auth/:provider/callback", deps.AuthHandler.Callback)
+		router.POST("/auth/logout", deps.AuthHandler.Logout)
+
+		// Password accounts; the endpoints sending emails are limited like a create, and sign-ins
+		// are throttled per account and client by the authenticator
+		var mailMiddlewares []gin.HandlerFunc
+		if deps.RateLimiter != nil {
+			mailMiddlewares = append(mailMiddlewares, deps.RateLimiter.Middleware())
+		}
+		router.POST("/auth/password/register", append(mailMiddlewares, deps.AuthHandler.Register)...)
+		router.POST("/auth/password/login", deps.AuthHandler.PasswordLogin)
+		router.POST("/auth/password/forgot", append(mailMiddlewares, deps.AuthHandler.ForgotPassword)...)
+		router.GET("/auth/password/reset", deps.AuthHandler.ResetPasswordPage)
+		router.POST("/auth/password/reset", deps.AuthHandler.ResetPassword)
+		router.GET("/auth/email/verify", deps.AuthHandler.VerifyEmail)
+		router.POST("/auth/email/verify/resend", append(mailMiddlewares, deps.AuthHandler.ResendVerification)...)
+
+		// Public profiles, as HTML for browsers
+		var profileMiddlewares []gin.HandlerFunc
+		if deps.ReadRateLimiter != nil {
+			profileMiddlewares = append(profileMiddlewares, deps.ReadRateLimiter.Middleware())
+		}
+		router.GET("/u/:username", append(profileMiddlewares, deps.AuthHandler.GetProfile)...)
+	}
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
 		// Paste routes
 		if deps != nil && deps.PasteHandler != nil {
-			// Apply content size limit and rate limiting to POST endpoint
-			postMiddlewares := []gin.HandlerFunc{
-				middleware.ContentSizeMiddleware(),
+			// Writes are rejected from banned clients and while maintenance mode is on
+			var writeMiddlewares []gin.HandlerFunc
+			if deps.Bans != nil {
+				writeMiddlewares = append(writeMiddlewares, middleware.BanMiddleware(deps.Bans))
 			}
+			if deps.Maintenance != nil {
+				writeMiddlewares = append(writeMiddlewares, middleware.MaintenanceMiddleware(deps.Maintenance))
+			}
+			if deps.WriteShedder != nil {
+				writeMiddlewares = append(writeMiddlewares, deps.WriteShedder.Middleware())
+			}
+
+			// Apply content size limit and rate limiting to POST endpoint
+			postMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			postMiddlewares = append(postMiddlewares, middleware.ContentSizeMiddleware())
 			if deps.RateLimiter != nil {
 				postMiddlewares = append(postMiddlewares, deps.RateLimiter.Middleware())
 			}
-			postMiddlewares = append(postMiddlewares, deps.PasteHandler.CreatePaste)
-			v1.POST("/pastes", postMiddlewares...)
+			v1.POST("/pastes", append(postMiddlewares, deps.PasteHandler.CreatePaste)...)
+			// Quick create from a raw body, at the root for curl
+			router.POST("/", append(postMiddlewares, deps.PasteHandler.QuickCreate)...)
+
+			v1.GET("/pastes/:id", readMiddlewares(deps, deps.PasteHandler.GetPaste)...)
+			v1.GET("/pastes/:id/download", readMiddlewares(deps, deps.PasteHandler.DownloadPaste)...)
+			// Bulk reads have no single paste for the per-paste read limit to key on
+			v1.POST("/pastes/bulk-get", previewMiddlewares(deps, deps.PasteHandler.BulkGetPastes)...)
 
-			v1.GET("/pastes/:id", deps.PasteHandler.GetPaste)
-			v1.DELETE("/pastes/:id", deps.PasteHandler.DeletePaste)
+			// Expiry countdown and revisions: metadata only, so neither the per-paste read limit nor load shedding applies
+			var ttlMiddlewares []gin.HandlerFunc
+			if deps.ReadRateLimiter != nil {
+				ttlMiddlewares = append(ttlMiddlewares, deps.ReadRateLimiter.Middleware())
+			}
+			v1.GET("/pastes/:id/ttl", append(ttlMiddlewares, deps.PasteHandler.GetPasteTTL)...)
+			v1.GET("/pastes/:id/ttl/ws", append(ttlMiddlewares, deps.PasteHandler.WatchPasteTTL)...)
+			// Following a live paste does not count views either
+			v1.GET("/pastes/:id/live/ws", append(ttlMiddlewares, deps.PasteHandler.WatchLivePaste)...)
+
+			// Edits replace content, so they are limited like creates
+			putMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			putMiddlewares = append(putMiddlewares, middleware.ContentSizeMiddleware())
+			if deps.RateLimiter != nil {
+				putMiddlewares = append(putMiddlewares, deps.RateLimiter.Middleware())
+			}
+			putMiddlewares = append(putMiddlewares, deps.PasteHandler.UpdatePaste)
+			v1.PUT("/pastes/:id", putMiddlewares...)
+
+			// Appends to live pastes come in bursts from a running job, so the per-client read limit
+			// applies rather than the create limit
+			appendMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			appendMiddlewares = append(appendMiddlewares, middleware.ContentSizeMiddleware())
+			if deps.ReadRateLimiter != nil {
+				appendMiddlewares = append(appendMiddlewares, deps.ReadRateLimiter.Middleware())
+			}
+			v1.POST("/pastes/:id/append", append(appendMiddlewares, deps.PasteHandler.AppendPaste)...)
+
+			// A bundle is limited like one create: its files together carry at most the content of one paste
+			bundleMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			bundleMiddlewares = append(bundleMiddlewares, middleware.ContentSizeMiddleware())
+			if deps.RateLimiter != nil {
+				bundleMiddlewares = append(bundleMiddlewares, deps.RateLimiter.Middleware())
+			}
+			v1.POST("/bundles", append(bundleMiddlewares, deps.PasteHandler.CreateBundle)...)
+			v1.GET("/groups/:id", append(ttlMiddlewares, deps.PasteHandler.GetGroup)...)
+
+			// Redaction rewrites the content and history, so it is limited like edits
+			redactMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			if deps.RateLimiter != nil {
+				redactMiddlewares = append(redactMiddlewares, deps.RateLimiter.Middleware())
+			}
+			redactMiddlewares = append(redactMiddlewares, deps.PasteHandler.RedactPaste)
+			v1.POST("/pastes/:id/redact", redactMiddlewares...)
+
+			// A run stores its output as a new paste, so it is limited like creates
+			runMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			if deps.RateLimiter != nil {
+				runMiddlewares = append(runMiddlewares, deps.RateLimiter.Middleware())
+			}
+			runMiddlewares = append(runMiddlewares, deps.PasteHandler.RunPaste)
+			v1.POST("/pastes/:id/run", runMiddlewares...)
+
+			// A fork is a new paste, so it is limited like creates
+			forkMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			if deps.RateLimiter != nil {
+				forkMiddlewares = append(forkMiddlewares, deps.RateLimiter.Middleware())
+			}
+			forkMiddlewares = append(forkMiddlewares, deps.PasteHandler.ForkPaste)
+			v1.POST("/pastes/:id/fork", forkMiddlewares...)
+
+			// So is a snippet
+			snippetMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			if deps.RateLimiter != nil {
+				snippetMiddlewares = append(snippetMiddlewares, deps.RateLimiter.Middleware())
+			}
+			snippetMiddlewares = append(snippetMiddlewares, deps.PasteHandler.CreateSnippet)
+			v1.POST("/pastes/:id/snippet", snippetMiddlewares...)
+
+			// Abuse reports are reviewed with the admin API; reporting is limited like a create
+			if cfg.Admin.Token != "" {
+				reportMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+				if deps.RateLimiter != nil {
+					reportMiddlewares = append(reportMiddlewares, deps.RateLimiter.Middleware())
+				}
+				v1.POST("/pastes/:id/report", append(reportMiddlewares, deps.PasteHandler.ReportPaste)...)
+			}
+
+			// Imports create pastes from gists and URLs and exports call GitHub, so all are limited like creates
+			importMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			exportMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			if deps.RateLimiter != nil {
+				importMiddlewares = append(importMiddlewares, deps.RateLimiter.Middleware())
+				exportMiddlewares = append(exportMiddlewares, deps.RateLimiter.Middleware())
+			}
+			v1.POST("/import/gist", append(importMiddlewares, deps.PasteHandler.ImportGist)...)
+			v1.POST("/pastes/from-url", append(importMiddlewares, deps.PasteHandler.CreatePasteFromURL)...)
+			exportMiddlewares = append(exportMiddlewares, deps.PasteHandler.ExportGist)
+			v1.POST("/pastes/:id/export/gist", exportMiddlewares...)
+			v1.GET("/pastes/:id/revisions", append(ttlMiddlewares, deps.PasteHandler.ListRevisions)...)
+			v1.GET("/pastes/:id/annotations", append(ttlMiddlewares, deps.PasteHandler.GetAnnotations)...)
+			v1.GET("/pastes/:id/outputs", append(ttlMiddlewares, deps.PasteHandler.ListOutputs)...)
+			v1.GET("/pastes/:id/preview", previewMiddlewares(deps, deps.PasteHandler.GetPreview)...)
+			v1.GET("/lookup", append(ttlMiddlewares, deps.PasteHandler.Lookup)...)
+			if cfg.Trending.Enabled {
+				v1.GET("/trending", append(ttlMiddlewares, deps.PasteHandler.GetTrending)...)
+			}
+
+			deleteMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+			if deps.DeleteRateLimiter != nil {
+				deleteMiddlewares = append(deleteMiddlewares, deps.DeleteRateLimiter.Middleware())
+			}
+			deleteMiddlewares = append(deleteMiddlewares, deps.PasteHandler.DeletePaste)
+			v1.DELETE("/pastes/:id", deleteMiddlewares...)
+
+			// Panic deletes the caller's secret pastes, and bulk deletes the signed-in user's pastes; they
+			// stay available in maintenance mode
+			var panicMiddlewares []gin.HandlerFunc
+			if deps.DeleteRateLimiter != nil {
+				panicMiddlewares = append(panicMiddlewares, deps.DeleteRateLimiter.Middleware())
+			}
+			v1.POST("/me/panic", append(panicMiddlewares, deps.PasteHandler.Panic)...)
+			if deps.AuthHandler != nil {
+				v1.POST("/me/pastes/delete", append(panicMiddlewares, deps.AuthHandler.DeleteMyPastes)...)
+			}
+
+			// The signed-in user's account
+			if deps.AuthHandler != nil {
+				v1.GET("/me", deps.AuthHandler.Me)
+				v1.GET("/me/pastes", append(ttlMiddlewares, deps.AuthHandler.ListMyPastes)...)
+				v1.PUT("/me/profile", deps.AuthHandler.UpdateProfile)
+				v1.GET("/me/pins", deps.AuthHandler.ListPins)
+				v1.POST("/me/pins", deps.AuthHandler.PinPaste)
+				v1.DELETE("/me/pins/:id", deps.AuthHandler.UnpinPaste)
+				v1.GET("/me/notifications", deps.AuthHandler.ListNotifications)
+				v1.POST("/me/notifications/read", deps.AuthHandler.MarkNotificationsRead)
+				v1.POST("/me/2fa/enroll", deps.AuthHandler.EnrollTOTP)
+				v1.POST("/me/2fa/confirm", deps.AuthHandler.ConfirmTOTP)
+				v1.POST("/me/2fa/recovery-codes", deps.AuthHandler.RegenerateRecoveryCodes)
+				v1.DELETE("/me/2fa", deps.AuthHandler.DisableTOTP)
+				v1.GET("/me/sessions", deps.AuthHandler.ListSessions)
+				v1.DELETE("/me/sessions", deps.AuthHandler.RevokeAllSessions)
+				v1.DELETE("/me/sessions/:id", deps.AuthHandler.RevokeSession)
+				v1.POST("/me/tokens", deps.AuthHandler.CreateToken)
+				v1.GET("/users/:username/pastes", append(ttlMiddlewares, deps.AuthHandler.ListUserPastes)...)
+			}
+
+			// Comments need accounts; posting one is limited like a create
+			if deps.AuthHandler != nil {
+				commentMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+				if deps.RateLimiter != nil {
+					commentMiddlewares = append(commentMiddlewares, deps.RateLimiter.Middleware())
+				}
+				v1.POST("/pastes/:id/comments", append(commentMiddlewares, deps.AuthHandler.CreateComment)...)
+				v1.GET("/pastes/:id/comments", append(ttlMiddlewares, deps.AuthHandler.ListComments)...)
+			}
+
+			// Direct-to-storage uploads for large content
+			if deps.UploadHandler != nil {
+				initMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+				if deps.RateLimiter != nil {
+					initMiddlewares = append(initMiddlewares, deps.RateLimiter.Middleware())
+				}
+				initMiddlewares = append(initMiddlewares, deps.UploadHandler.InitUpload)
+				v1.POST("/pastes/init", initMiddlewares...)
+
+				completeMiddlewares := append([]gin.HandlerFunc{}, writeMiddlewares...)
+				completeMiddlewares = append(completeMiddlewares, deps.UploadHandler.CompleteUpload)
+				v1.POST("/pastes/:id/complete", completeMiddlewares...)
+
+				// Resumable upload sessions (S3 multipart)
+				uploads := v1.Group("/uploads", writeMiddlewares...)
+				sessionMiddlewares := []gin.HandlerFunc{}
+				if deps.RateLimiter != nil {
+					sessionMiddlewares = append(sessionMiddlewares, deps.RateLimiter.Middleware())
+				}
+				sessionMiddlewares = append(sessionMiddlewares, deps.UploadHandler.InitResumableUpload)
+				uploads.POST("", sessionMiddlewares...)
+				uploads.GET("/:id", deps.UploadHandler.GetUploadSession)
+				uploads.POST("/:id/parts/:part", deps.UploadHandler.UploadPart)
+				uploads.POST("/:id/complete", deps.UploadHandler.CompleteUpload)
+				uploads.DELETE("/:id", deps.UploadHandler.AbortUpload)
+			}
+		}
+
+		// S3 event notifications (only registered when an events token is configured)
+		if deps != nil && deps.PasteHandler != nil && cfg.S3.EventsToken != "" {
+			v1.POST("/storage/events", middleware.AdminAuthMiddleware(cfg.S3.EventsToken), deps.PasteHandler.StorageEvents)
 		}
+
+		// Admin routes (only registered when an admin token is configured)
+		if deps != nil && deps.AdminHandler != nil && cfg.Admin.Token != "" {
+			admin := v1.Group("/admin", middleware.AdminAuthMiddleware(cfg.Admin.Token))
+			admin.GET("/cleanup", deps.AdminHandler.CleanupStatus)
+			admin.GET("/stats", deps.AdminHandler.Stats)
+			admin.GET("/pastes", deps.AdminHandler.ListPastes)
+			admin.GET("/lookup", deps.AdminHandler.Lookup)
+			admin.GET("/stale", deps.AdminHandler.StaleReport)
+			admin.POST("/stale/expire", deps.AdminHandler.ExpireStale)
+			admin.DELETE("/pastes/:id", deps.AdminHandler.ForceDeletePaste)
+			admin.POST("/verify", deps.AdminHandler.VerifyStorage)
+			admin.GET("/quarantine", deps.AdminHandler.ListQuarantined)
+			admin.DELETE("/quarantine/:id", deps.AdminHandler.ReleaseQuarantine)
+			admin.GET("/screening", deps.AdminHandler.ListScreened)
+			admin.DELETE("/screening/:id", deps.AdminHandler.DismissScreening)
+			admin.GET("/reports", deps.AdminHandler.ListReports)
+			admin.POST("/reports/:id/resolve", deps.AdminHandler.ResolveReport)
+			admin.GET("/maintenance", deps.AdminHandler.GetMaintenance)
+			admin.PUT("/maintenance", deps.AdminHandler.SetMaintenance)
+			if deps.Drain != nil {
+				admin.GET("/drain", deps.AdminHandler.GetDrain)
+				admin.POST("/drain", deps.AdminHandler.StartDrain)
+			}
+			admin.GET("/cache/stats", deps.AdminHandler.CacheStats)
+			admin.DELETE("/cache", deps.AdminHandler.FlushCache)
+			admin.DELETE("/cache/:id", deps.AdminHandler.PurgeCache)
+			if deps.AdminHandler.cdn != nil {
+				admin.DELETE("/cdn", deps.AdminHandler.FlushCDN)
+				admin.DELETE("/cdn/:id", deps.AdminHandler.PurgeCDN)
+			}
+			admin.GET("/ratelimit/:ip", deps.AdminHandler.GetRateLimit)
+			admin.DELETE("/ratelimit/:ip", deps.AdminHandler.ResetRateLimit)
+			if deps.AdminHandler.slo != nil {
+				admin.GET("/slo", deps.AdminHandler.SLOStatus)
+				admin.GET("/slo/rules", deps.AdminHandler.SLOAlertRules)
+			}
+			admin.GET("/bans", deps.AdminHandler.ListBans)
+			admin.PUT("/bans/:ip", deps.AdminHandler.BanIP)
+			admin.DELETE("/bans/:ip", deps.AdminHandler.UnbanIP)
+			if deps.IPDenyList != nil {
+				admin.GET("/denylist", deps.AdminHandler.ListIPBlocks)
+				admin.POST("/denylist", deps.AdminHandler.BlockIP)
+				admin.DELETE("/denylist", deps.AdminHandler.UnblockIP)
+			}
+		}
+	}
+
+	// Short URL routes (must be after API routes to avoid conflicts)
+	if deps != nil && deps.PasteHandler != nil {
+		router.GET("/:id", rawMiddlewares(deps, deps.PasteHandler.ShortURL)...)
+		router.GET("/:id/preview", previewMiddlewares(deps, deps.PasteHandler.GetPreview)...)
+		// The files of a multi-file paste as one archive
+		router.GET("/raw/:id", rawMiddlewares(deps, deps.PasteHandler.RawArchive)...)
+
+		// The paste API for Connect and gRPC-Web clients, by content type, served by the API routes above
+		router.POST("/"+ConnectService+"/:method", NewConnectHandler(router).Serve)
+	}
+
+	return router
+}
+
+// readMiddlewares prepends the configured read rate limiters and load shedder to a paste read handler
+func readMiddlewares(deps *RouterDeps, h gin.HandlerFunc) []gin.HandlerFunc {
+	var middlewares []gin.HandlerFunc
+	if deps.ReadRateLimiter != nil {
+		middlewares = append(middlewares, deps.ReadRateLimiter.Middleware())
+	}
+	if deps.PasteReadLimiter != nil {
+		middlewares = append(middlewares, deps.PasteReadLimiter.Middleware())
+	}
+	if deps.ReadShedder != nil {
+		middlewares = append(middlewares, deps.ReadShedder.Middleware())
+	}
+	return append(middlewares, h)
+}
+
+// previewMiddlewares returns the handler chain of paste previews and bulk reads. A preview is
+// not a read of the paste, so the per-paste read limit and the referrer policy do not apply.
+func previewMiddlewares(deps *RouterDeps, h gin.HandlerFunc) []gin.HandlerFunc {
+	var middlewares []gin.HandlerFunc
+	if deps.ReadRateLimiter != nil {
+		middlewares = append(middlewares, deps.ReadRateLimiter.Middleware())
+	}
+	if deps.ReadShedder != nil {
+		middlewares = append(middlewares, deps.ReadShedder.Middleware())
+	}
+	return append(middlewares, h)
+}
+
+// rawMiddlewares returns the handler chain of raw content, as GET /:id: referrer policy, read
+// limits and the raw handler h
+func rawMiddlewares(deps *RouterDeps, h gin.HandlerFunc) []gin.HandlerFunc {
+	middlewares := readMiddlewares(deps, h)
+	if deps.HotlinkProtection != nil {
+		middlewares = append([]gin.HandlerFunc{deps.HotlinkProtection}, middlewares...)
+	}
+	return middlewares
+}
+
+// NewShortLinkRouter creates a trimmed router for the short-link domain exposing only GET /:id
+// (raw content with the same negotiation, limits and referrer policy as the main router) and
+// GET /:id/preview
+func NewShortLinkRouter(cfg *config.Config, deps *RouterDeps) *gin.Engine {
+	if cfg.Server.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.Use(gin.Logger())
+	router.Use(gin.Recovery())
+	if deps != nil && deps.IPDenyList != nil {
+		router.Use(middleware.IPDenyMiddleware(deps.IPDenyList))
+	}
+	router.Use(middleware.VersionHeaderMiddleware())
+	if cfg.Server.Region != "" {
+		router.Use(middleware.RegionHeaderMiddleware(cfg.Server.Region))
+	}
+	if deps != nil && deps.Tracing != nil {
+		router.Use(deps.Tracing)
+	}
+	if deps != nil && deps.RequestTimer != nil {
+		router.Use(deps.RequestTimer.Middleware())
+	}
+	if deps != nil && deps.SLOTracker != nil {
+		router.Use(deps.SLOTracker.Middleware())
+	}
+	if deps != nil && deps.APIKeyAuth != nil {
+		router.Use(deps.APIKeyAuth)
 	}
 
-	// Short URL route (must be after API routes to avoid conflicts)
 	if deps != nil && deps.PasteHandler != nil {
-		router.GET("/:id", deps.PasteHandler.ShortURL)
+		router.GET("/:id", rawMiddlewares(deps, deps.PasteHandler.ShortURL)...)
+		router.GET("/:id/preview", previewMiddlewares(deps, deps.PasteHandler.GetPreview)...)
+	}
+
+	return router
+}
+
+// NewHostRouter dispatches requests for shortHost to the short-link router and everything else
+// to the main router, so both domains can be served from one listener
+func NewHostRouter(shortHost string, short, main http.Handler) http.Handler {
+	shortHost = strings.ToLower(shortHost)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if strings.EqualFold(host, shortHost) {
+			short.ServeHTTP(w, r)
+			return
+		}
+		main.ServeHTTP(w, r)
+	})
+}
+
+// NewWorkerRouter creates a minimal router for worker processes exposing only health, version and metrics
+func NewWorkerRouter(cfg *config.Config, mongoDB *repository.MongoDB, redis *repository.Redis, s3Client *repository.S3, drain *service.Drain) *gin.Engine {
+	if cfg.Server.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(middleware.VersionHeaderMiddleware())
+	if cfg.Server.Region != "" {
+		router.Use(middleware.RegionHeaderMiddleware(cfg.Server.Region))
+	}
+
+	healthHandler := NewDependencyHealthHandler(mongoDB, redis, s3Client)
+	healthHandler.SetProbeTimeout(readinessTimeout(cfg))
+	healthHandler.SetDrain(drain)
+	router.GET("/health", healthHandler.Health)
+	router.GET("/healthz", healthHandler.Healthz)
+	router.GET("/readyz", healthHandler.Readyz)
+	router.GET("/version", healthHandler.Version)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	return router
+}
+
+// NewStartupRouter creates the router served while a process starting degraded connects to its
+// dependencies: health, version and metrics, with health reporting the connections
+func NewStartupRouter(cfg *config.Config, health *HealthHandler) *gin.Engine {
+	if cfg.Server.Env == "production" {
+		gin.SetMode(gin.ReleaseMode)
 	}
 
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(middleware.VersionHeaderMiddleware())
+	if cfg.Server.Region != "" {
+		router.Use(middleware.RegionHeaderMiddleware(cfg.Server.Region))
+	}
+
+	router.GET("/health", health.Health)
+	router.GET("/healthz", health.Healthz)
+	router.GET("/readyz", health.Readyz)
+	router.GET("/version", health.Version)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	return router
 }
 
+// readinessTimeout parses the configured time each dependency has to answer the readiness probe
+func readinessTimeout(cfg *config.Config) time.Duration {
+	timeout, err := time.ParseDuration(cfg.Server.ReadinessTimeout)
+	if err != nil || timeout <= 0 {
+		if cfg.Server.ReadinessTimeout != "" {
+			log.Printf("Invalid readiness timeout '%s', using default %v", cfg.Server.ReadinessTimeout, DefaultProbeTimeout)
+		}
+		return DefaultProbeTimeout
+	}
+	return timeout
+}
+
 // corsMiddleware returns a configured CORS middleware
 func corsMiddleware() gin.HandlerFunc {
 	config := cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length", "X-Syntax-Type", "X-Created-At", "X-Expires-At", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.APIKeyHeader, middleware.SessionHeader, DeleteTokenHeader, SecondFactorHeader, ExpiresHeader, "Connect-Protocol-Version", "Connect-Timeout-Ms", "Grpc-Timeout", "X-Grpc-Web", "X-User-Agent"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Disposition", "X-Syntax-Type", "X-Created-At", "X-Expires-At", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Gisty-Version", "X-Gisty-Region", "X-Gisty-Encrypted", "Retry-After", DeleteTokenHeader, "Grpc-Status", "Grpc-Message"},
 		AllowCredentials: false,
 		MaxAge:           12 * 60 * 60, // 12 hours
 	}
 	return cors.New(config)
-}
\ No newline at end of file
+}
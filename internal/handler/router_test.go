@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/config"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+func TestRouter_IPDenyBeforeRateLimit(t *testing.T) {
+	denyList, err := service.NewIPDenyList(&repository.Redis{}, service.IPDenyListConfig{
+		DenyList:  []string{"203.0.113.0/24"},
+		AllowList: []string{"203.0.113.200"},
+	})
+	if err != nil {
+		t.Fatalf("NewIPDenyList() error = %v", err)
+	}
+	createLimiter := middleware.NewRateLimiter(&middleware.RateLimitConfig{RequestsPerMinute: 5, Enabled: true})
+	readLimiter := middleware.NewRateLimiter(&middleware.RateLimitConfig{RequestsPerMinute: 5, Enabled: true})
+
+	// Requests that get through are turned down by the handler before reaching the service
+	deps := &RouterDeps{
+		PasteHandler:    NewPasteHandler(service.NewPasteService(nil, nil, nil, nil, "http://localhost:8080")),
+		RateLimiter:     createLimiter,
+		ReadRateLimiter: readLimiter,
+		IPDenyList:      denyList,
+	}
+	routers := map[string]http.Handler{
+		"main":       newTestRouter(nil, deps),
+		"short link": NewShortLinkRouter(&config.Config{}, deps),
+	}
+
+	request := func(router http.Handler, method, target, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader("{"))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	remaining := func(limiter *middleware.RateLimiter, ip string) int64 {
+		status, err := limiter.Inspect(context.Background(), ip)
+		if err != nil {
+			t.Fatalf("Inspect(%s) error = %v", ip, err)
+		}
+		return status.Remaining
+	}
+
+	// Blocked clients are denied more often than any limit allows, without using it up
+	for i := 0; i < 10; i++ {
+		w := request(routers["main"], http.MethodPost, "/api/v1/pastes", "203.0.113.9:1234")
+		if w.Code != http.StatusForbidden || errorCode(w) != i18n.CodeIPDenied {
+			t.Fatalf("POST /pastes #%d from a blocked range = %d %s, want %d %s", i+1, w.Code, errorCode(w), http.StatusForbidden, i18n.CodeIPDenied)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "" {
+			t.Fatalf("POST /pastes from a blocked range carries rate limit headers")
+		}
+	}
+	for name, router := range routers {
+		if w := request(router, http.MethodGet, "/xK9a2B", "203.0.113.9:1234"); w.Code != http.StatusForbidden {
+			t.Errorf("GET /:id on the %s router from a blocked range status = %d, want %d", name, w.Code, http.StatusForbidden)
+		}
+	}
+	if got := remaining(createLimiter, "203.0.113.9"); got != 5 {
+		t.Errorf("create limit remaining for a blocked client = %d, want 5", got)
+	}
+	if got := remaining(readLimiter, "203.0.113.9"); got != 5 {
+		t.Errorf("read limit remaining for a blocked client = %d, want 5", got)
+	}
+
+	// Other clients, and allowed ones inside a blocked range, are rate limited as usual
+	for _, ip := range []string{"198.51.100.1", "203.0.113.200"} {
+		if w := request(routers["main"], http.MethodPost, "/api/v1/pastes", ip+":1234"); w.Code != http.StatusBadRequest {
+			t.Errorf("POST /pastes from %s status = %d, want %d", ip, w.Code, http.StatusBadRequest)
+		}
+		if got := remaining(createLimiter, ip); got != 4 {
+			t.Errorf("create limit remaining for %s = %d, want 4", ip, got)
+		}
+	}
+}
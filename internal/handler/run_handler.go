@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// RunPasteRequest represents the request body for running a paste
+type RunPasteRequest struct {
+	Stdin string `json:"stdin,omitempty" example:"42"`
+}
+
+// RunPasteResponse represents the output of a paste run
+type RunPasteResponse struct {
+	ShortID    string  `json:"short_id" example:"xK9a2B"`
+	OutputID   string  `json:"output_id" example:"pQ7rT1"`
+	OutputURL  string  `json:"output_url" example:"http://localhost:8080/pQ7rT1"`
+	ExpiresAt  *string `json:"expires_at,omitempty" example:"2024-01-22T14:00:00Z"`
+	Language   string  `json:"language" example:"python"`
+	Stdout     string  `json:"stdout" example:"Hello, World!\n"`
+	Stderr     string  `json:"stderr" example:""`
+	ExitCode   int     `json:"exit_code" example:"0"`
+	TimedOut   bool    `json:"timed_out" example:"false"`
+	DurationMS int64   `json:"duration_ms" example:"412"`
+	// DeleteToken is returned once, for runs without an API key or a sign-in; keep it to delete the output paste
+	DeleteToken string `json:"delete_token,omitempty" example:"q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"`
+}
+
+// RunPaste godoc
+// @Summary Run a paste in the sandbox
+// @Description Send the content of a paste to the configured sandbox service, which runs it with a time limit, and store the output as a new paste linked to the source (output_of, with producer kind runner). The output paste has the privacy of its source and expires with it, after a week at the latest. Only syntax types in the instance's allow-list can be run; code is never executed by the Gisty server itself.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param request body RunPasteRequest false "Standard input of the program"
+// @Success 201 {object} RunPasteResponse "Output of the run"
+// @Failure 400 {object} ErrorResponse "stdin too large"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 404 {object} ErrorResponse "Paste not found, or running pastes is disabled"
+// @Failure 409 {object} ErrorResponse "Paste content archived until restored"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 422 {object} ErrorResponse "Syntax type not allowed, or encrypted, binary, burn-after-read or view-limited paste"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 502 {object} ErrorResponse "Sandbox failed or unreachable"
+// @Router /pastes/{id}/run [post]
+func (h *PasteHandler) RunPaste(c *gin.Context) {
+	shortID := c.Param("id")
+	if shortID == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	var req service.RunPasteRequest
+	// The body is optional
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[RunPaste] Failed to bind JSON: %v", err)
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+			return
+		}
+	}
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
+
+	response, err := h.pasteService.RunPaste(c.Request.Context(), shortID, &req)
+	if err != nil {
+		log.Printf("[RunPaste] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
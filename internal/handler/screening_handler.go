@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// ListScreened godoc
+// @Summary List screened pastes
+// @Description List the new pastes content screening flagged or quarantined as likely spam or abuse, most recent first, with the score and reasons of each.
+// @Description Review them, then delete them or dismiss their screening. Quarantined pastes are not served until dismissed or released.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of pastes listed (default 100, max 10000)"
+// @Success 200 {object} service.ScreeningReport "Screened pastes"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/screening [get]
+func (h *AdminHandler) ListScreened(c *gin.Context) {
+	limit := 0
+	if raw, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return
+		}
+		limit = n
+	}
+
+	report, err := h.pasteService.ListScreened(c.Request.Context(), limit)
+	if err != nil {
+		log.Printf("[ListScreened] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// DismissScreening godoc
+// @Summary Dismiss the screening of a paste
+// @Description Clear the screening of a paste found legitimate on review. A paste quarantined by screening is served again.
+// @Tags admin
+// @Security AdminToken
+// @Security BearerAuth
+// @Param id path string true "Paste short ID"
+// @Success 204 "Screening dismissed"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Paste not flagged or quarantined by screening"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/screening/{id} [delete]
+func (h *AdminHandler) DismissScreening(c *gin.Context) {
+	shortID := c.Param("id")
+	err := h.pasteService.DismissScreening(c.Request.Context(), shortID)
+	switch {
+	case errors.Is(err, service.ErrPasteNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodePasteNotFound))
+	case errors.Is(err, service.ErrPasteNotScreened):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodePasteNotScreened))
+	case err != nil:
+		log.Printf("[DismissScreening] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/auth"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// SessionsResponse lists the sessions and API tokens of the signed-in user
+type SessionsResponse struct {
+	Sessions []*auth.Session `json:"sessions"`
+}
+
+// RevokeSessionsResponse counts the sessions signed out by "log out everywhere"
+type RevokeSessionsResponse struct {
+	Revoked int64 `json:"revoked" example:"3"`
+}
+
+// CreateTokenRequest represents the request body for creating an API token
+type CreateTokenRequest struct {
+	// Name tells the token apart in the session list, up to 64 characters
+	Name string `json:"name" binding:"required" example:"CI uploads"`
+	// ExpiresInDays is the lifetime of the token (default 90, max 365)
+	ExpiresInDays int `json:"expires_in_days,omitempty" binding:"omitempty,min=1,max=365" example:"90"`
+}
+
+// CreateTokenResponse is a new API token; the token is not shown again
+type CreateTokenResponse struct {
+	// Token is sent as "Authorization: Bearer <token>"
+	Token   string        `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzdWIiOiIxIn0.c2ln"`
+	Session *auth.Session `json:"session"`
+}
+
+// ListSessions godoc
+// @Summary My sessions and API tokens
+// @Description List the signed-in user's sessions and API tokens, newest first, with the device they signed in from and their last use.
+// @Description The one sending the request is marked current.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SessionsResponse "Sessions and API tokens"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Router /me/sessions [get]
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		h.handleError(c, service.ErrSignInRequired)
+		return
+	}
+
+	sessions, err := h.auth.Sessions(c.Request.Context(), userID, middleware.SessionID(c))
+	if err != nil {
+		log.Printf("[ListSessions] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, SessionsResponse{Sessions: sessions})
+}
+
+// RevokeSession godoc
+// @Summary Revoke a session or API token
+// @Description Sign one of the signed-in user's sessions out, or revoke one of their API tokens; its token stops working at once
+// @Tags auth
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 204 "Revoked"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 404 {object} ErrorResponse "Session not found"
+// @Router /me/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		h.handleError(c, service.ErrSignInRequired)
+		return
+	}
+
+	if err := h.auth.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		log.Printf("[RevokeSession] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	if c.Param("id") == middleware.SessionID(c) {
+		h.setCookie(c, middleware.SessionCookie, "", -1)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RevokeAllSessions godoc
+// @Summary Log out everywhere
+// @Description Sign the signed-in user out of all their sessions, this one included. API tokens keep working.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} RevokeSessionsResponse "Signed out everywhere"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Router /me/sessions [delete]
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	userID := middleware.UserID(c)
+	if userID == "" {
+		h.handleError(c, service.ErrSignInRequired)
+		return
+	}
+
+	revoked, err := h.auth.RevokeAllSessions(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("[RevokeAllSessions] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	h.setCookie(c, middleware.SessionCookie, "", -1)
+	c.JSON(http.StatusOK, RevokeSessionsResponse{Revoked: revoked})
+}
+
+// CreateToken godoc
+// @Summary Create an API token
+// @Description Create an API token acting as the signed-in user, listed and revoked with their sessions. The token is shown once.
+// @Description Users with two-factor authentication send a code in the X-Gisty-OTP header.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Gisty-OTP header string false "Two-factor code or recovery code, when two-factor authentication is on"
+// @Param request body CreateTokenRequest true "Token"
+// @Success 201 {object} CreateTokenResponse "Token created"
+// @Failure 400 {object} ErrorResponse "Empty name or longer than 64 characters, or invalid lifetime"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 403 {object} ErrorResponse "Missing or wrong two-factor code"
+// @Failure 409 {object} ErrorResponse "20 tokens already"
+// @Failure 429 {object} ErrorResponse "Too many wrong codes, see Retry-After"
+// @Router /me/tokens [post]
+func (h *AuthHandler) CreateToken(c *gin.Context) {
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	userID := middleware.UserID(c)
+	if !h.verifySecondFactor(c, userID) {
+		return
+	}
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	token, session, err := h.auth.CreateToken(c.Request.Context(), userID, req.Name, ttl, clientOf(c))
+	if err != nil {
+		log.Printf("[CreateToken] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, CreateTokenResponse{Token: token, Session: session})
+}
+
+// clientOf returns the device metadata of the request, recorded with the sessions it creates
+func clientOf(c *gin.Context) auth.Client {
+	return auth.Client{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+)
+
+// SLOStatus godoc
+// @Summary Get SLO status
+// @Description Report how fast this instance spends the error budget of each service level objective configured with SLO_OBJECTIVES, over the last 5 minutes, 30 minutes, hour and 6 hours.
+// @Description An objective is critical when its budget burns over 14.4 times too fast in the last hour and 5 minutes, and warning over 6 times in the last 6 hours and 30 minutes. The same burn rates are exported as the gisty_slo_burn_rate metric.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {object} middleware.SLOReport "SLO status"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Router /admin/slo [get]
+func (h *AdminHandler) SLOStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.slo.Report())
+}
+
+// SLOAlertRules godoc
+// @Summary Export SLO alert rules
+// @Description Render Prometheus alerting rules for the configured objectives, ready to be saved as a rule file. They compute burn rates across every instance from the gisty_slo_requests_total and gisty_slo_errors_total counters, paging on a critical burn and opening a ticket on a warning.
+// @Tags admin
+// @Produce application/yaml
+// @Security AdminToken
+// @Security BearerAuth
+// @Success 200 {string} string "Prometheus rule file"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/slo/rules [get]
+func (h *AdminHandler) SLOAlertRules(c *gin.Context) {
+	rules, err := h.slo.AlertRules()
+	if err != nil {
+		log.Printf("[SLOAlertRules] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", rules)
+}
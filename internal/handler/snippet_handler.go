@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// SnippetRequest represents the request body for cutting a snippet out of a paste
+type SnippetRequest struct {
+	StartLine int    `json:"start_line" binding:"required" example:"120"`
+	EndLine   int    `json:"end_line,omitempty" example:"139"`
+	ExpiresIn string `json:"expires_in,omitempty" example:"1w"`
+	IsPrivate bool   `json:"is_private,omitempty" example:"false"`
+	Title     string `json:"title,omitempty" example:"The failing test"`
+}
+
+// DerivedFrom records the paste and the inclusive range of lines a snippet was taken from
+type DerivedFrom struct {
+	ShortID   string `json:"short_id" example:"aB3dE5"`
+	StartLine int    `json:"start_line" example:"120"`
+	EndLine   int    `json:"end_line" example:"139"`
+}
+
+// CreateSnippet godoc
+// @Summary Share a range of lines of a paste
+// @Description Create a new paste holding a range of lines of a paste, recorded as derived_from the source and range, with the syntax type, title, description and tags of the source. The HTML view numbers its lines as in the source. The snippet gets its own expiration and privacy from the request; the title can be overridden. Burn-after-read, view-limited, encrypted and binary pastes cannot be cut, and cutting does not count a view of the source.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param request body SnippetRequest true "Lines and settings of the snippet"
+// @Success 201 {object} CreatePasteResponse "Snippet created"
+// @Failure 400 {object} ErrorResponse "Invalid line range, expiration or title"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Paste content archived until restored"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Failure 422 {object} ErrorResponse "Burn-after-read, view-limited, encrypted or binary paste"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Router /pastes/{id}/snippet [post]
+func (h *PasteHandler) CreateSnippet(c *gin.Context) {
+	shortID := c.Param("id")
+	if shortID == "" {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeMissingPasteID))
+		return
+	}
+
+	var req service.SnippetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreateSnippet] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.SourceIP = c.ClientIP()
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
+
+	response, err := h.pasteService.CreateSnippet(c.Request.Context(), shortID, &req)
+	if err != nil {
+		log.Printf("[CreateSnippet] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
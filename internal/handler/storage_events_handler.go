@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// maxStorageEventsBody caps the size of an S3 event notification
+const maxStorageEventsBody = 1 << 20
+
+// StorageEventsResult counts the pastes a batch of storage events changed
+type StorageEventsResult struct {
+	Deleted  int `json:"deleted" example:"2"`
+	Archived int `json:"archived" example:"1"`
+	Restored int `json:"restored" example:"0"`
+	Ignored  int `json:"ignored" example:"3"`
+}
+
+// StorageEvents godoc
+// @Summary Receive S3 event notifications
+// @Description Reconcile paste metadata with changes bucket lifecycle rules made to content, from an S3 (or MinIO webhook) event notification. Pastes whose content was removed (ObjectRemoved, LifecycleExpiration) are deleted; pastes whose content was moved to an archival storage class (LifecycleTransition, ObjectRestore:Delete) answer 409 until it is restored (ObjectRestore:Completed). Events about other buckets, revisions and unknown pastes are ignored. Only registered when S3_EVENTS_TOKEN is set; the token is sent as a bearer token or in X-Admin-Token.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param request body object true "S3 event notification"
+// @Success 200 {object} StorageEventsResult "Pastes changed"
+// @Failure 400 {object} ErrorResponse "Not an S3 event notification"
+// @Failure 401 {object} ErrorResponse "Missing or invalid events token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /storage/events [post]
+func (h *PasteHandler) StorageEvents(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxStorageEventsBody))
+	if err != nil {
+		log.Printf("[StorageEvents] Failed to read body: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidStorageEvents))
+		return
+	}
+
+	events, err := service.ParseStorageEvents(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidStorageEvents))
+		return
+	}
+
+	result, err := h.pasteService.ReconcileStorageEvents(c.Request.Context(), events)
+	if err != nil {
+		log.Printf("[StorageEvents] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+
+	c.JSON(http.StatusOK, StorageEventsResult{
+		Deleted:  result.Deleted,
+		Archived: result.Archived,
+		Restored: result.Restored,
+		Ignored:  result.Ignored,
+	})
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// SecondFactorHeader carries a code of the user's authenticator app, or a recovery code, on
+// destructive actions of users who turned on two-factor authentication
+const SecondFactorHeader = "X-Gisty-OTP"
+
+// TOTPEnrollmentResponse is the secret of a two-factor enrollment, to enter in an authenticator app
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	// URI is the otpauth:// URI of the secret, shown as a QR code for authenticator apps to scan
+	URI string `json:"uri" example:"otpauth://totp/Gisty:octocat?algorithm=SHA1&digits=6&issuer=Gisty&period=30&secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+}
+
+// TOTPCodeRequest represents the request body confirming a two-factor enrollment
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// RecoveryCodesResponse lists recovery codes, each working once in place of a two-factor code;
+// they are not shown again
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k3x9q-pl2m7,vzjbq-0s1tt"`
+}
+
+// EnrollTOTP godoc
+// @Summary Start two-factor authentication
+// @Description Start turning on two-factor authentication for the signed-in user: returns a secret for an authenticator app.
+// @Description Two-factor authentication is on once a code of the app confirms the enrollment.
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TOTPEnrollmentResponse "Secret to enter in an authenticator app"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 409 {object} ErrorResponse "Two-factor authentication already on"
+// @Router /me/2fa/enroll [post]
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	secret, uri, err := h.auth.BeginTOTP(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		log.Printf("[EnrollTOTP] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, TOTPEnrollmentResponse{Secret: secret, URI: uri})
+}
+
+// ConfirmTOTP godoc
+// @Summary Turn on two-factor authentication
+// @Description Confirm the enrollment with a code of the authenticator app, and return the recovery codes.
+// @Description Bulk deletes and API token creation then need a code, or a recovery code, in the X-Gisty-OTP header.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TOTPCodeRequest true "Code of the authenticator app"
+// @Success 200 {object} RecoveryCodesResponse "Two-factor authentication on"
+// @Failure 400 {object} ErrorResponse "Invalid request body"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 403 {object} ErrorResponse "Wrong code"
+// @Failure 409 {object} ErrorResponse "Two-factor authentication already on, or not enrolled"
+// @Failure 429 {object} ErrorResponse "Too many wrong codes, see Retry-After"
+// @Router /me/2fa/confirm [post]
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	codes, err := h.auth.ConfirmTOTP(c.Request.Context(), middleware.UserID(c), req.Code)
+	if err != nil {
+		log.Printf("[ConfirmTOTP] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace my recovery codes
+// @Description Replace the recovery codes of the signed-in user's two-factor authentication; the old ones stop working
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param X-Gisty-OTP header string true "Two-factor code or recovery code"
+// @Success 200 {object} RecoveryCodesResponse "New recovery codes"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 403 {object} ErrorResponse "Missing or wrong two-factor code"
+// @Failure 409 {object} ErrorResponse "Two-factor authentication not on"
+// @Failure 429 {object} ErrorResponse "Too many wrong codes, see Retry-After"
+// @Router /me/2fa/recovery-codes [post]
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	codes, err := h.auth.RegenerateRecoveryCodes(c.Request.Context(), middleware.UserID(c), c.GetHeader(SecondFactorHeader))
+	if err != nil {
+		log.Printf("[RegenerateRecoveryCodes] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP godoc
+// @Summary Turn off two-factor authentication
+// @Description Turn off the signed-in user's two-factor authentication and drop their recovery codes
+// @Tags auth
+// @Security BearerAuth
+// @Param X-Gisty-OTP header string true "Two-factor code or recovery code"
+// @Success 204 "Two-factor authentication off"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 403 {object} ErrorResponse "Missing or wrong two-factor code"
+// @Failure 409 {object} ErrorResponse "Two-factor authentication not on"
+// @Failure 429 {object} ErrorResponse "Too many wrong codes, see Retry-After"
+// @Router /me/2fa [delete]
+func (h *AuthHandler) DisableTOTP(c *gin.Context) {
+	if err := h.auth.DisableTOTP(c.Request.Context(), middleware.UserID(c), c.GetHeader(SecondFactorHeader)); err != nil {
+		log.Printf("[DisableTOTP] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteMyPastes godoc
+// @Summary Delete my pastes
+// @Description Delete up to 100 pastes created by the signed-in user. Pastes of others and unknown IDs are skipped.
+// @Description Users with two-factor authentication send a code in the X-Gisty-OTP header.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param X-Gisty-OTP header string false "Two-factor code or recovery code, when two-factor authentication is on"
+// @Param request body service.BulkDeleteRequest true "Pastes to delete"
+// @Success 200 {object} service.BulkDeleteResponse "Pastes deleted"
+// @Failure 400 {object} ErrorResponse "No IDs, or more than 100"
+// @Failure 401 {object} ErrorResponse "Not signed in"
+// @Failure 403 {object} ErrorResponse "Missing or wrong two-factor code"
+// @Failure 429 {object} ErrorResponse "Too many wrong codes, see Retry-After"
+// @Router /me/pastes/delete [post]
+func (h *AuthHandler) DeleteMyPastes(c *gin.Context) {
+	var req service.BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	userID := middleware.UserID(c)
+	if !h.verifySecondFactor(c, userID) {
+		return
+	}
+	response, err := h.pasteService.DeleteUserPastes(c.Request.Context(), userID, req.IDs)
+	if err != nil {
+		log.Printf("[DeleteMyPastes] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// verifySecondFactor checks the two-factor code of a destructive action, answering the request
+// when it is missing or wrong
+func (h *AuthHandler) verifySecondFactor(c *gin.Context, userID string) bool {
+	if userID == "" {
+		h.handleError(c, service.ErrSignInRequired)
+		return false
+	}
+	if err := h.auth.VerifySecondFactor(c.Request.Context(), userID, c.GetHeader(SecondFactorHeader)); err != nil {
+		log.Printf("[verifySecondFactor] Error: %v", err)
+		h.handleError(c, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+)
+
+// TrendingPaste represents a public paste ranked by recent views
+type TrendingPaste struct {
+	ShortID    string  `json:"short_id" example:"xK9a2B"`
+	URL        string  `json:"url" example:"http://localhost:8080/xK9a2B"`
+	SyntaxType string  `json:"syntax_type" example:"go"`
+	CreatedAt  string  `json:"created_at" example:"2024-01-15T14:00:00Z"`
+	Score      float64 `json:"score" example:"42.5"`
+}
+
+// TrendingResponse represents the trending pastes, highest score first
+type TrendingResponse struct {
+	Pastes   []TrendingPaste `json:"pastes"`
+	HalfLife string          `json:"half_life" example:"6h0m0s"`
+}
+
+// GetTrending godoc
+// @Summary List trending pastes
+// @Description List public pastes ranked by views over a decaying window: a view counts half as much after each half-life. Only served when trending is enabled.
+// @Tags pastes
+// @Produce json
+// @Param limit query int false "Number of pastes (default 10, max 50)"
+// @Success 200 {object} TrendingResponse "Trending pastes"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 404 {object} ErrorResponse "Trending disabled"
+// @Router /trending [get]
+func (h *PasteHandler) GetTrending(c *gin.Context) {
+	limit := 0
+	if raw, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return
+		}
+		limit = n
+	}
+
+	response, err := h.pasteService.GetTrending(c.Request.Context(), limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
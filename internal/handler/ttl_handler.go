@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/service"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// ttlPushInterval is how often the push channel resends the server-computed remaining time.
+	// Clients count down locally between updates.
+	ttlPushInterval = 10 * time.Second
+	// ttlPushMaxLifetime bounds a push connection; clients reconnect to keep watching
+	ttlPushMaxLifetime = time.Hour
+	// ttlPushWriteTimeout bounds a single push message write
+	ttlPushWriteTimeout = 10 * time.Second
+)
+
+// PasteTTLResponse represents the remaining lifetime of a paste
+type PasteTTLResponse struct {
+	ShortID          string  `json:"short_id" example:"xK9a2B"`
+	ExpiresAt        *string `json:"expires_at,omitempty" example:"2024-01-15T15:00:00Z"`
+	RemainingSeconds *int64  `json:"remaining_seconds,omitempty" example:"3599"`
+	BurnAfterRead    bool    `json:"burn_after_read" example:"false"`
+	RemainingViews   *int64  `json:"remaining_views,omitempty" example:"4"`
+	Expired          bool    `json:"expired" example:"false"`
+	ServerTime       string  `json:"server_time" example:"2024-01-15T14:00:01Z"`
+}
+
+// GetPasteTTL godoc
+// @Summary Get the remaining lifetime of a paste
+// @Description Return the expiry time and server-computed remaining seconds of a paste without reading its content, so burn-after-read pastes are not consumed
+// @Tags pastes
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Success 200 {object} PasteTTLResponse "Remaining lifetime"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/ttl [get]
+func (h *PasteHandler) GetPasteTTL(c *gin.Context) {
+	response, err := h.pasteService.GetTTL(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// WatchPasteTTL godoc
+// @Summary Watch the remaining lifetime of a paste
+// @Description Upgrade to a WebSocket that pushes the paste TTL (same shape as GET /pastes/{id}/ttl) on connect and every 10 seconds. A final message with expired=true is sent when the paste expires or is burned, then the connection is closed.
+// @Tags pastes
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Success 101 {object} PasteTTLResponse "Switching to WebSocket"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 410 {object} ErrorResponse "Paste has expired"
+// @Router /pastes/{id}/ttl/ws [get]
+func (h *PasteHandler) WatchPasteTTL(c *gin.Context) {
+	shortID := c.Param("id")
+
+	// Report a missing paste as a plain HTTP error before upgrading
+	if _, err := h.pasteService.GetTTL(c.Request.Context(), shortID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		h.pushTTL(ws, shortID)
+	}).ServeHTTP(c.Writer, c.Request)
+}
+
+// pushTTL sends TTL updates until the paste is gone, the client disconnects or the lifetime is reached
+func (h *PasteHandler) pushTTL(ws *websocket.Conn, shortID string) {
+	// The connection outlives the request time budget and server timeouts
+	ctx, cancel := context.WithTimeout(context.Background(), ttlPushMaxLifetime)
+	defer cancel()
+	_ = ws.SetDeadline(time.Time{})
+
+	// Clients do not send anything; a read error means they went away
+	go func() {
+		var discard []byte
+		for {
+			if err := websocket.Message.Receive(ws, &discard); err != nil {
+				cancel()
+				return
+			}
+		}
+	}()
+
+	for {
+		response, err := h.pasteService.GetTTL(ctx, shortID)
+		if err != nil && ctx.Err() != nil {
+			return
+		}
+		message := response
+		if errors.Is(err, service.ErrPasteNotFound) || errors.Is(err, service.ErrPasteExpired) {
+			message = &service.PasteTTLResponse{
+				ShortID:    shortID,
+				Expired:    true,
+				ServerTime: time.Now().UTC().Format(time.RFC3339),
+			}
+		} else if err != nil {
+			log.Printf("[WatchPasteTTL] Failed to get TTL of %s: %v", shortID, err)
+			return
+		}
+
+		_ = ws.SetWriteDeadline(time.Now().Add(ttlPushWriteTimeout))
+		if err := websocket.JSON.Send(ws, message); err != nil || message.Expired {
+			return
+		}
+
+		// Wake up right when the paste expires rather than on the next update
+		next := ttlPushInterval
+		if message.RemainingSeconds != nil {
+			next = min(next, time.Duration(*message.RemainingSeconds+1)*time.Second)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next):
+		}
+	}
+}
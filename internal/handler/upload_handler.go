@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// UploadHandler handles pastes whose content is uploaded directly to storage
+type UploadHandler struct {
+	uploadService *service.UploadService
+}
+
+// NewUploadHandler creates a new UploadHandler
+func NewUploadHandler(uploadService *service.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// InitUploadRequest represents the request body for starting a direct upload
+type InitUploadRequest struct {
+	Size       int64  `json:"size" binding:"required" example:"10485760"`
+	SHA256     string `json:"sha256" binding:"required" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+	SyntaxType string `json:"syntax_type" example:"plaintext"`
+	ExpiresIn  string `json:"expires_in" example:"1d"`
+	IsPrivate  bool   `json:"is_private" example:"false"`
+	Encrypted  bool   `json:"is_encrypted" example:"false"`
+
+	Title       string   `json:"title,omitempty" example:"Build log"`
+	Description string   `json:"description,omitempty" example:"Nightly build output"`
+	Tags        []string `json:"tags,omitempty" example:"ci,logs"`
+}
+
+// InitUploadResponse represents the pre-signed request for uploading content
+type InitUploadResponse struct {
+	ShortID   string            `json:"short_id" example:"xK9a2B"`
+	UploadURL string            `json:"upload_url" example:"https://s3.amazonaws.com/gisty/gisty/xK9a2B.gz?X-Amz-Signature=..."`
+	Method    string            `json:"method" example:"PUT"`
+	Headers   map[string]string `json:"headers" example:"Content-Type:application/octet-stream,X-Amz-Checksum-Sha256:n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="`
+	ExpiresAt string            `json:"expires_at" example:"2024-01-15T14:15:00Z"`
+	// DeleteToken is returned once, for uploads started without an API key or a sign-in; keep it to delete the paste
+	DeleteToken string `json:"delete_token,omitempty" example:"q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"`
+}
+
+// InitResumableUploadResponse represents a new resumable upload session
+type InitResumableUploadResponse struct {
+	ShortID   string `json:"short_id" example:"xK9a2B"`
+	PartSize  int64  `json:"part_size" example:"8388608"`
+	PartCount int    `json:"part_count" example:"13"`
+	ExpiresAt string `json:"expires_at" example:"2024-01-16T14:00:00Z"`
+	// DeleteToken is returned once, for uploads started without an API key or a sign-in; keep it to delete the paste
+	DeleteToken string `json:"delete_token,omitempty" example:"q8VdX2n0b3JtT1c4ZkRzWmJqR0h5UEtM"`
+}
+
+// UploadSessionResponse represents the progress of a resumable upload session
+type UploadSessionResponse struct {
+	ShortID       string  `json:"short_id" example:"xK9a2B"`
+	Size          int64   `json:"size" example:"104857600"`
+	PartSize      int64   `json:"part_size" example:"8388608"`
+	PartCount     int     `json:"part_count" example:"13"`
+	UploadedParts []int32 `json:"uploaded_parts" example:"1,2,3"`
+	ExpiresAt     string  `json:"expires_at" example:"2024-01-16T14:00:00Z"`
+}
+
+// UploadPartRequest represents the request body for a pre-signed part upload URL
+type UploadPartRequest struct {
+	SHA256 string `json:"sha256" binding:"required" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+}
+
+// UploadPartResponse represents the pre-signed request for uploading one part
+type UploadPartResponse struct {
+	PartNumber int               `json:"part_number" example:"1"`
+	Size       int64             `json:"size" example:"8388608"`
+	UploadURL  string            `json:"upload_url" example:"https://s3.amazonaws.com/gisty/gisty/xK9a2B.gz?partNumber=1&uploadId=..."`
+	Method     string            `json:"method" example:"PUT"`
+	Headers    map[string]string `json:"headers" example:"X-Amz-Checksum-Sha256:n4bQgYhMfWWaL+qgxVrQFaO/TxsrC4Is0V1sFbDwCgg="`
+	ExpiresAt  string            `json:"expires_at" example:"2024-01-15T14:15:00Z"`
+}
+
+// InitUpload godoc
+// @Summary Start a direct upload
+// @Description Reserve a short ID and get a pre-signed URL to upload large content directly to storage.
+// @Description Send the content with the returned method and headers, then call the complete endpoint.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param request body InitUploadRequest true "Content size, SHA-256 checksum and paste options"
+// @Success 201 {object} InitUploadResponse "Pre-signed upload request"
+// @Failure 400 {object} ErrorResponse "Invalid request (empty size, invalid sha256, syntax_type or expires_in)"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 413 {object} ErrorResponse "Content too large"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} ErrorResponse "Service temporarily unavailable"
+// @Router /pastes/init [post]
+func (h *UploadHandler) InitUpload(c *gin.Context) {
+	var req service.InitUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[InitUpload] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.SourceIP = c.ClientIP()
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
+
+	response, err := h.uploadService.InitUpload(c.Request.Context(), &req)
+	if err != nil {
+		log.Printf("[InitUpload] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// CompleteUpload godoc
+// @Summary Complete a direct upload
+// @Description Verify the uploaded content against the announced size and checksum and make the paste available
+// @Tags pastes
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Success 200 {object} CreatePasteResponse "Paste created successfully"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Content not uploaded yet, or upload already completed"
+// @Failure 410 {object} ErrorResponse "Upload URL has expired"
+// @Failure 422 {object} ErrorResponse "Uploaded content does not match the announced size or checksum, or exceeds decompression limits"
+// @Router /pastes/{id}/complete [post]
+// @Router /uploads/{id}/complete [post]
+func (h *UploadHandler) CompleteUpload(c *gin.Context) {
+	shortID := c.Param("id")
+
+	response, err := h.uploadService.CompleteUpload(c.Request.Context(), shortID)
+	if err != nil {
+		log.Printf("[CompleteUpload] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// InitResumableUpload godoc
+// @Summary Start a resumable upload
+// @Description Start an upload session for very large content, uploaded in fixed-size parts directly to storage.
+// @Description Request a pre-signed URL per part, check the session to resume after an interruption, then call the complete endpoint.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param request body InitUploadRequest true "Content size, SHA-256 checksum and paste options"
+// @Success 201 {object} InitResumableUploadResponse "Upload session created"
+// @Failure 400 {object} ErrorResponse "Invalid request (empty size, invalid sha256, syntax_type or expires_in)"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 413 {object} ErrorResponse "Content too large"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 503 {object} ErrorResponse "Service temporarily unavailable"
+// @Router /uploads [post]
+func (h *UploadHandler) InitResumableUpload(c *gin.Context) {
+	var req service.InitUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[InitResumableUpload] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.SourceIP = c.ClientIP()
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
+
+	response, err := h.uploadService.InitResumableUpload(c.Request.Context(), &req)
+	if err != nil {
+		log.Printf("[InitResumableUpload] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetUploadSession godoc
+// @Summary Get a resumable upload session
+// @Description Report which parts have been uploaded so an interrupted upload can be resumed
+// @Tags uploads
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Success 200 {object} UploadSessionResponse "Upload session progress"
+// @Failure 400 {object} ErrorResponse "Not a resumable upload"
+// @Failure 404 {object} ErrorResponse "Upload session not found"
+// @Failure 409 {object} ErrorResponse "Upload already completed"
+// @Failure 410 {object} ErrorResponse "Upload session has expired"
+// @Router /uploads/{id} [get]
+func (h *UploadHandler) GetUploadSession(c *gin.Context) {
+	response, err := h.uploadService.GetUploadSession(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UploadPart godoc
+// @Summary Get a pre-signed URL for one part
+// @Description Get a pre-signed PUT request for one part of a resumable upload. The part's SHA-256 is verified by storage.
+// @Tags uploads
+// @Accept json
+// @Produce json
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Param part path int true "Part number, starting at 1" example(1)
+// @Param request body UploadPartRequest true "SHA-256 checksum of the part"
+// @Success 200 {object} UploadPartResponse "Pre-signed part upload request"
+// @Failure 400 {object} ErrorResponse "Invalid part number or sha256"
+// @Failure 404 {object} ErrorResponse "Upload session not found"
+// @Failure 409 {object} ErrorResponse "Upload already completed"
+// @Failure 410 {object} ErrorResponse "Upload session has expired"
+// @Router /uploads/{id}/parts/{part} [post]
+func (h *UploadHandler) UploadPart(c *gin.Context) {
+	partNumber, err := strconv.Atoi(c.Param("part"))
+	if err != nil {
+		h.handleError(c, service.ErrInvalidPartNumber)
+		return
+	}
+
+	var req service.UploadPartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+
+	response, err := h.uploadService.PresignPart(c.Request.Context(), c.Param("id"), partNumber, &req)
+	if err != nil {
+		log.Printf("[UploadPart] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// AbortUpload godoc
+// @Summary Abort an upload
+// @Description Cancel a pending upload and discard any content uploaded so far
+// @Tags uploads
+// @Param id path string true "Paste short ID" example(xK9a2B)
+// @Success 204 "Upload aborted"
+// @Failure 404 {object} ErrorResponse "Upload session not found"
+// @Failure 409 {object} ErrorResponse "Upload already completed"
+// @Router /uploads/{id} [delete]
+func (h *UploadHandler) AbortUpload(c *gin.Context) {
+	if err := h.uploadService.AbortUpload(c.Request.Context(), c.Param("id")); err != nil {
+		log.Printf("[AbortUpload] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleError maps upload service errors to HTTP responses
+func (h *UploadHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrEmptyContent):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeContentEmpty))
+	case errors.Is(err, service.ErrUploadTooLarge):
+		maxSize := h.uploadService.MaxSize()
+		if strings.HasPrefix(c.FullPath(), "/api/v1/uploads") {
+			maxSize = h.uploadService.MultipartMaxSize()
+		}
+		c.JSON(http.StatusRequestEntityTooLarge, middleware.ErrorBody(c, i18n.CodeContentTooLarge, gin.H{
+			"max_size": fmt.Sprintf("%dMB", maxSize/(1024*1024)),
+		}))
+	case errors.Is(err, service.ErrInvalidChecksum):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidChecksum))
+	case errors.Is(err, service.ErrInvalidPartNumber):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidPartNumber))
+	case errors.Is(err, service.ErrNotResumableUpload):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeNotResumableUpload))
+	case errors.Is(err, service.ErrInvalidExpiresIn):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidExpiresIn))
+	case errors.Is(err, service.ErrInvalidSyntaxType):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidSyntaxType))
+	case errors.Is(err, service.ErrInvalidMetadata):
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidMetadata))
+	case errors.Is(err, service.ErrNoKeysAvailable):
+		c.JSON(http.StatusServiceUnavailable, middleware.ErrorBody(c, i18n.CodeServiceUnavailable))
+	case errors.Is(err, service.ErrPasteNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodePasteNotFound))
+	case errors.Is(err, service.ErrPasteExpired):
+		c.JSON(http.StatusGone, middleware.ErrorBody(c, i18n.CodeUploadExpired))
+	case errors.Is(err, service.ErrUploadIncomplete):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeUploadIncomplete))
+	case errors.Is(err, service.ErrUploadAlreadyCompleted):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodeUploadAlreadyCompleted))
+	case errors.Is(err, service.ErrUploadMismatch):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeUploadMismatch))
+	case errors.Is(err, service.ErrDecompressionBomb):
+		c.JSON(http.StatusUnprocessableEntity, middleware.ErrorBody(c, i18n.CodeDecompressionLimit))
+	default:
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+	}
+}
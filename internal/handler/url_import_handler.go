@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// ImportURLRequest represents the request body for creating a paste from a URL
+type ImportURLRequest struct {
+	URL        string `json:"url" binding:"required" example:"https://raw.githubusercontent.com/octocat/Hello-World/master/README"`
+	ExpiresIn  string `json:"expires_in,omitempty" example:"1w"`
+	IsPrivate  bool   `json:"is_private,omitempty" example:"false"`
+	SyntaxType string `json:"syntax_type,omitempty" example:"python"` // detected from the URL's filename and the content when omitted
+	Title      string `json:"title,omitempty" example:"hello.py"`     // the URL's filename when omitted
+}
+
+// CreatePasteFromURL godoc
+// @Summary Create a paste from a URL
+// @Description Fetch the content of a public http or https URL on the server and store it as a paste, recording the URL as its source_url. Only text content is fetched: text/* and common source types such as application/json, as configured. The fetch follows up to 5 redirects and is bounded in time and to 1MB. URLs with credentials, and hosts that are denied or resolve to private, loopback, link-local or otherwise non-public addresses, are refused, including after redirects. The syntax type is detected from the URL's filename and the content unless given; the title defaults to the filename.
+// @Tags pastes
+// @Accept json
+// @Produce json
+// @Security APIKey
+// @Param request body ImportURLRequest true "URL to fetch"
+// @Success 201 {object} CreatePasteResponse "Paste created"
+// @Failure 400 {object} ErrorResponse "Invalid URL, syntax type or expiration"
+// @Failure 401 {object} ErrorResponse "Unknown API key"
+// @Failure 404 {object} ErrorResponse "Creating pastes from URLs is disabled"
+// @Failure 413 {object} ErrorResponse "Content too large (max 1MB)"
+// @Failure 422 {object} ErrorResponse "URL not allowed, or content of an unsupported type"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded"
+// @Failure 502 {object} ErrorResponse "URL unreachable or answered with an error"
+// @Router /pastes/from-url [post]
+func (h *PasteHandler) CreatePasteFromURL(c *gin.Context) {
+	var req service.ImportURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Printf("[CreatePasteFromURL] Failed to bind JSON: %v", err)
+		c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidRequestBody))
+		return
+	}
+	req.SourceIP = c.ClientIP()
+	req.OwnerID = middleware.OwnerID(c)
+	req.UserID = middleware.UserID(c)
+
+	response, err := h.pasteService.ImportURL(c.Request.Context(), &req)
+	if err != nil {
+		log.Printf("[CreatePasteFromURL] Error: %v", err)
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
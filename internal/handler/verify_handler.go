@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/middleware"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// VerifyStorage godoc
+// @Summary Verify stored pastes
+// @Description Re-download the content of a random sample of readable pastes now, as the storage verifier of the worker does every CLEANUP_VERIFY_INTERVAL, and check that it decompresses and matches its recorded SHA-256.
+// @Description Corrupt pastes are quarantined unless CLEANUP_VERIFY_QUARANTINE is false: they answer 409 until released.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param sample query int false "Number of pastes checked (default 20, max 1000)"
+// @Success 200 {object} service.VerifyRun "Verification results"
+// @Failure 400 {object} ErrorResponse "Invalid sample size"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Storage unavailable"
+// @Router /admin/verify [post]
+func (h *AdminHandler) VerifyStorage(c *gin.Context) {
+	sample := 0
+	if raw, ok := c.GetQuery("sample"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return
+		}
+		sample = n
+	}
+
+	run, err := h.pasteService.VerifyStoredPastes(c.Request.Context(), sample, h.verifyQuarantine)
+	if err != nil {
+		if errors.Is(err, service.ErrDependencyUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, middleware.ErrorBody(c, i18n.CodeServiceUnavailable))
+			return
+		}
+		log.Printf("[VerifyStorage] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+// ListQuarantined godoc
+// @Summary List quarantined pastes
+// @Description List the pastes whose stored content failed verification, or that content screening quarantined, most recently quarantined first. They are not served until their content is restored from a backup or found sound and they are released, or they are deleted.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Security BearerAuth
+// @Param limit query int false "Maximum number of pastes listed (default 100, max 10000)"
+// @Success 200 {object} service.QuarantineReport "Quarantined pastes"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/quarantine [get]
+func (h *AdminHandler) ListQuarantined(c *gin.Context) {
+	limit := 0
+	if raw, ok := c.GetQuery("limit"); ok {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, middleware.ErrorBody(c, i18n.CodeInvalidLimit))
+			return
+		}
+		limit = n
+	}
+
+	report, err := h.pasteService.ListQuarantined(c.Request.Context(), limit)
+	if err != nil {
+		log.Printf("[ListQuarantined] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ReleaseQuarantine godoc
+// @Summary Release a quarantined paste
+// @Description Serve a quarantined paste again, once its content was restored or found sound. The paste is checked again when a later verification run samples it.
+// @Tags admin
+// @Security AdminToken
+// @Security BearerAuth
+// @Param id path string true "Paste short ID"
+// @Success 204 "Paste released"
+// @Failure 401 {object} ErrorResponse "Missing or invalid admin token"
+// @Failure 404 {object} ErrorResponse "Paste not found"
+// @Failure 409 {object} ErrorResponse "Paste not quarantined"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/quarantine/{id} [delete]
+func (h *AdminHandler) ReleaseQuarantine(c *gin.Context) {
+	shortID := c.Param("id")
+	err := h.pasteService.ReleaseQuarantine(c.Request.Context(), shortID)
+	switch {
+	case errors.Is(err, service.ErrPasteNotFound):
+		c.JSON(http.StatusNotFound, middleware.ErrorBody(c, i18n.CodePasteNotFound))
+	case errors.Is(err, service.ErrPasteNotQuarantined):
+		c.JSON(http.StatusConflict, middleware.ErrorBody(c, i18n.CodePasteNotQuarantined))
+	case err != nil:
+		log.Printf("[ReleaseQuarantine] Error: %v", err)
+		c.JSON(http.StatusInternalServerError, middleware.ErrorBody(c, i18n.CodeInternalError))
+	default:
+		c.Status(http.StatusNoContent)
+	}
+}
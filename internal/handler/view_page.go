@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// viewPage is the server-rendered paste view served to browsers on GET /:id
+var viewPage = withCardMeta(template.Must(template.New("view").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{with .Title}}{{.}}{{else}}{{.ShortID}}{{end}} · gisty</title>
+{{template "meta" .Card}}<style nonce="{{.Nonce}}">
+body{margin:0;font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;background:#f6f8fa;color:#1f2328}
+header{display:flex;align-items:center;gap:1rem;padding:.75rem 1rem;background:#fff;border-bottom:1px solid #d0d7de;font-size:.875rem}
+header .id{font-weight:600}
+header .meta{color:#656d76}
+header button{margin-left:auto;padding:.25rem .75rem;border:1px solid #d0d7de;border-radius:6px;background:#f6f8fa;cursor:pointer}
+main{margin:1rem;background:#fff;border:1px solid #d0d7de;border-radius:6px;overflow:auto}
+main pre{margin:0;padding:.5rem 0;font-size:.8125rem;line-height:1.45}
+main .lnt a{color:inherit;text-decoration:none}
+.notice{padding:1rem}
+.validation{margin:1rem 1rem 0;padding:.5rem 1rem;border:1px solid;border-radius:6px;font-size:.875rem}
+.validation.valid{border-color:#1a7f37;background:#dafbe1}
+.validation.invalid{border-color:#cf222e;background:#ffebe9}
+.annotations{margin:1rem 1rem 0;padding:.5rem 1rem;border:1px solid #d4a72c;border-radius:6px;background:#fff8c5;font-size:.875rem}
+.annotations ul,.validation ul{margin:.25rem 0 0;padding-left:1.25rem;font-family:ui-monospace,SFMono-Regular,Menlo,monospace;font-size:.8125rem}
+.annotations .error{color:#cf222e}
+.annotations a{color:inherit}
+.tree{margin:1rem 1rem 0;padding:.5rem 1rem;background:#fff;border:1px solid #d0d7de;border-radius:6px;font-family:ui-monospace,SFMono-Regular,Menlo,monospace;font-size:.8125rem}
+.tree ul{margin:0;padding-left:1.25rem;list-style:none}
+.tree>ul{padding-left:0}
+.tree .dir{color:#656d76}
+.tree .current{font-weight:600}
+#raw{position:absolute;left:-9999px}
+{{.CSS}}
+</style>
+</head>
+<body>
+<header>
+<span class="id">{{with .Title}}{{.}}{{else}}{{.ShortID}}{{end}}</span>
+<span class="meta">{{.SyntaxType}} · {{.Size}} bytes · created {{.CreatedAt}}{{if .ExpiresAt}} · expires {{.ExpiresAt}}{{end}}{{if .MaxViews}} · view {{.Views}} of {{.MaxViews}}{{end}}{{if .OutputOf}} · output of <a href="/{{.OutputOf}}">{{.OutputOf}}</a>{{end}}{{if .ForkedFrom}} · fork of <a href="/{{.ForkedFrom}}">{{.ForkedFrom}}</a>{{end}}{{with .SourceURL}} · from <a href="{{.}}" rel="noopener nofollow">{{.}}</a>{{end}}{{with .DerivedFrom}} · lines {{.StartLine}}–{{.EndLine}} of <a href="/{{.ShortID}}#L{{.StartLine}}">{{.ShortID}}</a>{{end}}{{with .Producer}} · by {{with .URL}}<a href="{{.}}" rel="noopener nofollow">{{end}}{{.Kind}}{{with .Name}} {{.}}{{end}}{{if .URL}}</a>{{end}}{{end}}</span>
+{{if .Code}}<button id="copy" type="button">Copy</button>{{end}}
+</header>
+{{with .Validation}}{{if .Valid}}<div class="validation valid">Valid against the schema supplied with this paste</div>
+{{else}}<div class="validation invalid">Does not match the schema supplied with this paste
+<ul>{{range .Errors}}<li>{{.}}</li>{{end}}</ul></div>
+{{end}}{{end}}{{with .Annotations}}<div class="annotations">Linter findings
+<ul>{{range .}}<li class="{{.Severity}}"><a href="#L{{.Line}}">line {{.Line}}{{if .Column}}:{{.Column}}{{end}}</a> {{.Severity}} [{{.Linter}}] {{.Message}}</li>{{end}}</ul></div>
+{{end}}{{with .Tree}}<nav class="tree" aria-label="Files">{{template "tree" .}}</nav>
+{{end}}<main>
+{{if .Encrypted}}<p class="notice">This paste is encrypted in the browser. Open it in the <a id="web-view" href="{{.WebViewURL}}">web view</a> with its full link to decrypt it.</p>
+{{else if .Binary}}<p class="notice">This paste holds binary content and cannot be displayed.</p>
+{{else}}{{.Code}}{{end}}
+</main>
+{{if .Code}}<textarea id="raw" readonly aria-hidden="true">{{.Content}}</textarea>{{end}}
+<script nonce="{{.Nonce}}">
+(function(){
+var copy=document.getElementById("copy");
+if(copy){copy.addEventListener("click",function(){
+var raw=document.getElementById("raw").value;
+var done=function(){copy.textContent="Copied";setTimeout(function(){copy.textContent="Copy"},1500)};
+if(navigator.clipboard){navigator.clipboard.writeText(raw).then(done)}else{var t=document.getElementById("raw");t.select();document.execCommand("copy");done()}
+})}
+var web=document.getElementById("web-view");
+if(web&&location.hash){web.href+=location.hash}
+})();
+</script>
+</body>
+</html>
+{{define "tree"}}<ul>{{range .}}<li>{{if .Children}}<span class="dir">{{.Name}}/</span>{{template "tree" .Children}}{{else if .Current}}<span class="current">{{.Name}}</span>{{else}}<a href="/{{.ShortID}}">{{.Name}}</a>{{end}}</li>{{end}}</ul>{{end}}`)))
+
+// viewPageData is the data of viewPage
+type viewPageData struct {
+	*service.GetPasteResponse
+	Nonce      string
+	CSS        template.CSS
+	Code       template.HTML
+	WebViewURL string
+	// Card holds the link card tags, for links unfurled by clients not known to be unfurlers
+	Card cardData
+	// Tree lists the files of the paste's bundle or gist import, the paste among them
+	Tree []*treeNode
+}
+
+// treeNode is a directory or a file of the tree of a group's paths
+type treeNode struct {
+	Name     string
+	ShortID  string
+	Current  bool
+	Children []*treeNode
+}
+
+// buildTree arranges the files of a group, in path order, as a tree of their paths
+func buildTree(files []service.GroupFile, current string) []*treeNode {
+	var root []*treeNode
+	for _, file := range files {
+		level := &root
+		parts := strings.Split(file.Path, "/")
+		for _, dir := range parts[:len(parts)-1] {
+			var node *treeNode
+			if n := len(*level); n > 0 && (*level)[n-1].Name == dir && (*level)[n-1].Children != nil {
+				node = (*level)[n-1]
+			} else {
+				node = &treeNode{Name: dir, Children: []*treeNode{}}
+				*level = append(*level, node)
+			}
+			level = &node.Children
+		}
+		*level = append(*level, &treeNode{Name: parts[len(parts)-1], ShortID: file.ShortID, Current: file.ShortID == current})
+	}
+	return root
+}
+
+// renderHTMLView writes the syntax-highlighted HTML view of a paste
+func (h *PasteHandler) renderHTMLView(c *gin.Context, response *service.GetPasteResponse) {
+	data := viewPageData{
+		GetPasteResponse: response,
+		Nonce:            newNonce(),
+		CSS:              template.CSS(service.HighlightCSS()),
+		WebViewURL:       h.viewBaseURL + "/view/" + response.ShortID,
+		Card:             viewCard(response),
+	}
+	if response.GroupID != "" {
+		// The view works without the tree, should the group not be listed
+		if group, err := h.pasteService.GetGroup(c.Request.Context(), response.GroupID); err == nil && len(group.Files) > 1 {
+			data.Tree = buildTree(group.Files, response.ShortID)
+		}
+	}
+	if !response.Encrypted && !response.Binary {
+		// Snippets keep the line numbers of their source, and so do their linter findings
+		firstLine := 1
+		if response.DerivedFrom != nil {
+			firstLine = response.DerivedFrom.StartLine
+			for i := range response.Annotations {
+				response.Annotations[i].Line += firstLine - 1
+			}
+		}
+		code, err := h.pasteService.HighlightHTMLFrom(response.Content, response.SyntaxType, firstLine)
+		if err != nil {
+			// Fall back to the escaped plain text
+			log.Printf("[PasteHandler.renderHTMLView] Failed to highlight %s: %v", response.ShortID, err)
+			code = "<pre>" + template.HTMLEscapeString(response.Content) + "</pre>"
+		}
+		data.Code = template.HTML(code)
+	}
+
+	// Only the page's own style and script may run; the paste content is inert
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'nonce-"+data.Nonce+"'; script-src 'nonce-"+data.Nonce+"'; base-uri 'none'; form-action 'none'")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-store")
+	h.setEdgeCacheHeaders(c, response)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := viewPage.Execute(c.Writer, data); err != nil {
+		log.Printf("[PasteHandler.renderHTMLView] Failed to render %s: %v", response.ShortID, err)
+	}
+}
+
+// viewCard builds the card of a paste read for its view
+func viewCard(response *service.GetPasteResponse) cardData {
+	// The content was read: a burn-after-read paste is burned already
+	notice := cardNotice(false, response.MaxViews, response.Encrypted, response.Binary)
+	content := strings.TrimSuffix(response.Content, "\n")
+	lines := strings.SplitN(content, "\n", cardLines+1)
+	lineCount := 0
+	if !response.Truncated && content != "" {
+		lineCount = strings.Count(content, "\n") + 1
+	}
+	return newCard(response.ShortID, response.Title, response.SyntaxType, lines[:min(cardLines, len(lines))], lineCount, notice)
+}
+
+// setEdgeCacheHeaders tags the view of a paste with its surrogate keys, for CDNs to purge it when
+// the paste changes, and lets them cache it when an edge TTL is set. Browsers still revalidate
+// with the server. Views of burn-after-read and view-limited pastes, which have no content hash,
+// are never cached: each of their reads must reach the server.
+func (h *PasteHandler) setEdgeCacheHeaders(c *gin.Context, response *service.GetPasteResponse) {
+	if response.ContentHash == "" {
+		return
+	}
+	// Fastly reads space-separated keys, Cloudflare comma-separated tags
+	key := service.SurrogateKey(response.ShortID)
+	c.Header("Surrogate-Key", key+" "+service.ViewsSurrogateKey)
+	c.Header("Cache-Tag", key+","+service.ViewsSurrogateKey)
+
+	ttl := h.edgeTTL
+	if response.ExpiresAt != nil {
+		if expiresAt, err := time.Parse(time.RFC3339, *response.ExpiresAt); err == nil {
+			ttl = min(ttl, time.Until(expiresAt))
+		}
+	}
+	if ttl >= time.Second {
+		maxAge := fmt.Sprintf("max-age=%d", int(ttl.Seconds()))
+		c.Header("Surrogate-Control", maxAge)
+		c.Header("CDN-Cache-Control", maxAge)
+	}
+}
+
+// newNonce returns a random Content-Security-Policy nonce
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
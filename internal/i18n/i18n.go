@@ -0,0 +1,289 @@
+// Package i18n localizes user-facing messages. Messages are looked up by stable codes, which are
+// also returned to clients for machine consumption, so only the human-readable text varies by language.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage is the language used when a request does not ask for a supported one
+const DefaultLanguage = "en"
+
+// Message codes. They are part of the API and must not change.
+const (
+	CodeInvalidRequestBody     = "invalid_request_body"
+	CodeMissingPasteID         = "missing_paste_id"
+	CodePasteNotFound          = "paste_not_found"
+	CodePasteExpired           = "paste_expired"
+	CodeContentEmpty           = "content_empty"
+	CodeContentTooLarge        = "content_too_large"
+	CodeInvalidSyntaxType      = "invalid_syntax_type"
+	CodeInvalidExpiresIn       = "invalid_expires_in"
+	CodeInvalidMaxBytes        = "invalid_max_bytes"
+	CodeInvalidOffset          = "invalid_offset"
+	CodeInvalidLive            = "invalid_live"
+	CodeInvalidBundle          = "invalid_bundle"
+	CodeInvalidMaxViews        = "invalid_max_views"
+	CodeInvalidPreviewLines    = "invalid_preview_lines"
+	CodeInvalidMetadata        = "invalid_metadata"
+	CodeInvalidSchema          = "invalid_schema"
+	CodeInvalidDeliveryHeaders = "invalid_delivery_headers"
+	CodeEditConflict           = "edit_conflict"
+	CodeNotLive                = "paste_not_live"
+	CodeLivePaste              = "paste_live"
+	CodeInvalidRedaction       = "invalid_redaction"
+	CodeNotRedactable          = "paste_not_redactable"
+	CodeOwnerRequired          = "owner_required"
+	CodeDeleteForbidden        = "delete_forbidden"
+	CodeEditForbidden          = "edit_forbidden"
+	CodeInvalidLimit           = "invalid_limit"
+	CodeInvalidCursor          = "invalid_cursor"
+	CodeTrendingDisabled       = "trending_disabled"
+	CodeRunnerDisabled         = "runner_disabled"
+	CodeLanguageNotAllowed     = "language_not_allowed"
+	CodeNotRunnable            = "paste_not_runnable"
+	CodeNotForkable            = "paste_not_forkable"
+	CodeNotSnippable           = "paste_not_snippable"
+	CodeInvalidLineRange       = "invalid_line_range"
+	CodeInvalidRunRequest      = "invalid_run_request"
+	CodeRunnerUnavailable      = "runner_unavailable"
+	CodeGistDisabled           = "gist_disabled"
+	CodeInvalidGist            = "invalid_gist"
+	CodeGistNotFound           = "gist_not_found"
+	CodeGistTooLarge           = "gist_too_large"
+	CodeNotExportable          = "paste_not_exportable"
+	CodeGitHubUnauthorized     = "github_unauthorized"
+	CodeGitHubUnavailable      = "github_unavailable"
+	CodeCDNUnavailable         = "cdn_unavailable"
+	CodePasteArchived          = "paste_archived"
+	CodePasteQuarantined       = "paste_quarantined"
+	CodeInvalidStorageEvents   = "invalid_storage_events"
+	CodeInvalidEncoding        = "invalid_encoding"
+	CodeInvalidDelivery        = "invalid_delivery"
+	CodeInvalidProducer        = "invalid_producer"
+	CodeInputNotFound          = "input_not_found"
+	CodeLineTooLong            = "line_too_long"
+	CodeBinaryContent          = "binary_content"
+	CodeContentRejected        = "content_rejected"
+	CodeInvalidChecksum        = "invalid_checksum"
+	CodeInvalidPartNumber      = "invalid_part_number"
+	CodeNotResumableUpload     = "not_resumable_upload"
+	CodeUploadExpired          = "upload_expired"
+	CodeUploadIncomplete       = "upload_incomplete"
+	CodeUploadAlreadyCompleted = "upload_already_completed"
+	CodeUploadMismatch         = "upload_mismatch"
+	CodeDecompressionLimit     = "decompression_limit"
+	CodeInvalidIP              = "invalid_ip"
+	CodeInvalidTimeRange       = "invalid_time_range"
+	CodeSourceIPNotRecorded    = "source_ip_not_recorded"
+	CodeInvalidStatsDays       = "invalid_stats_days"
+	CodeInvalidStaleDays       = "invalid_stale_days"
+	CodeInvalidBan             = "invalid_ban"
+	CodeInvalidIPBlock         = "invalid_ip_block"
+	CodeUnauthorized           = "unauthorized"
+	CodeSignInRequired         = "sign_in_required"
+	CodeUnknownProvider        = "unknown_provider"
+	CodeProfileNotFound        = "profile_not_found"
+	CodePinForbidden           = "pin_forbidden"
+	CodeTooManyPins            = "too_many_pins"
+	CodeCommentsDisabled       = "comments_disabled"
+	CodeInvalidComment         = "invalid_comment"
+	CodeCommentsClosed         = "comments_closed"
+	CodeReportsDisabled        = "reports_disabled"
+	CodeInvalidReport          = "invalid_report"
+	CodeInvalidReportStatus    = "invalid_report_status"
+	CodeAlreadyReported        = "already_reported"
+	CodeReportNotFound         = "report_not_found"
+	CodeReportResolved         = "report_resolved"
+	CodeInvalidResolution      = "invalid_resolution"
+	CodeInvalidLoginState      = "invalid_login_state"
+	CodeLoginRejected          = "login_rejected"
+	CodeProviderUnavailable    = "provider_unavailable"
+	CodeInvalidEmail           = "invalid_email"
+	CodeWeakPassword           = "weak_password"
+	CodeEmailTaken             = "email_taken"
+	CodeInvalidCredentials     = "invalid_credentials"
+	CodeEmailNotVerified       = "email_not_verified"
+	CodeInvalidToken           = "invalid_token"
+	CodeAccountLocked          = "account_locked"
+	CodeLoginThrottled         = "login_throttled"
+	CodeTOTPDisabled           = "totp_disabled"
+	CodeTOTPEnabled            = "totp_enabled"
+	CodeTOTPNotEnabled         = "totp_not_enabled"
+	CodeSecondFactorRequired   = "second_factor_required"
+	CodeInvalidSecondFactor    = "invalid_second_factor"
+	CodeSecondFactorThrottled  = "second_factor_throttled"
+	CodeSessionsDisabled       = "sessions_disabled"
+	CodeSessionNotFound        = "session_not_found"
+	CodeInvalidTokenName       = "invalid_token_name"
+	CodeTooManyTokens          = "too_many_tokens"
+	CodeInvalidArchiveFormat   = "invalid_archive_format"
+	CodeURLImportDisabled      = "url_import_disabled"
+	CodeInvalidSourceURL       = "invalid_source_url"
+	CodeSourceURLForbidden     = "source_url_forbidden"
+	CodeUnsupportedSourceType  = "unsupported_source_type"
+	CodeSourceUnavailable      = "source_unavailable"
+	CodePasteNotQuarantined    = "paste_not_quarantined"
+	CodePasteNotScreened       = "paste_not_screened"
+	CodeIPBanned               = "ip_banned"
+	CodeIPDenied               = "ip_denied"
+	CodeRateLimited            = "rate_limited"
+	CodeRateLimiterError       = "rate_limiter_error"
+	CodeOverloaded             = "overloaded"
+	CodeMaintenance            = "maintenance"
+	CodeServiceUnavailable     = "service_unavailable"
+	CodeInternalError          = "internal_error"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Catalogs holds message catalogs by language
+type Catalogs struct {
+	mu          sync.RWMutex
+	messages    map[string]map[string]string
+	defaultLang string
+	matcher     language.Matcher
+	languages   []string // in matcher order
+}
+
+var defaultCatalogs = mustLoad()
+
+// mustLoad loads the embedded catalogs; a broken catalog is a build error
+func mustLoad() *Catalogs {
+	c, err := Load(localeFiles, "locales")
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Load reads every <language>.json catalog in dir
+func Load(fsys fs.FS, dir string) (*Catalogs, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("i18n: failed to read catalogs: %w", err)
+	}
+
+	c := &Catalogs{
+		messages:    make(map[string]map[string]string),
+		defaultLang: DefaultLanguage,
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".json" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("i18n: failed to read %s: %w", name, err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("i18n: invalid catalog %s: %w", name, err)
+		}
+		c.messages[strings.TrimSuffix(name, ".json")] = messages
+	}
+	if _, ok := c.messages[DefaultLanguage]; !ok {
+		return nil, fmt.Errorf("i18n: missing %s catalog", DefaultLanguage)
+	}
+
+	c.buildMatcher()
+	return c, nil
+}
+
+// buildMatcher prepares Accept-Language matching with the default language preferred on ties
+func (c *Catalogs) buildMatcher() {
+	c.languages = []string{c.defaultLang}
+	others := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		if lang != c.defaultLang {
+			others = append(others, lang)
+		}
+	}
+	sort.Strings(others)
+	c.languages = append(c.languages, others...)
+
+	tags := make([]language.Tag, len(c.languages))
+	for i, lang := range c.languages {
+		tags[i] = language.Make(lang)
+	}
+	c.matcher = language.NewMatcher(tags)
+}
+
+// SetDefault changes the fallback language; it must have a catalog
+func (c *Catalogs) SetDefault(lang string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.messages[lang]; !ok {
+		return fmt.Errorf("i18n: no catalog for language %q", lang)
+	}
+	c.defaultLang = lang
+	c.buildMatcher()
+	return nil
+}
+
+// Languages returns the supported languages, default first
+func (c *Catalogs) Languages() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]string(nil), c.languages...)
+}
+
+// Negotiate picks the supported language best matching an Accept-Language header
+func (c *Catalogs) Negotiate(acceptLanguage string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	prefs, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(prefs) == 0 {
+		return c.defaultLang
+	}
+	_, index, confidence := c.matcher.Match(prefs...)
+	if confidence == language.No {
+		return c.defaultLang
+	}
+	return c.languages[index]
+}
+
+// T returns the message for code in lang, falling back to the default language and then to the code itself
+func (c *Catalogs) T(lang, code string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if msg, ok := c.messages[lang][code]; ok {
+		return msg
+	}
+	if msg, ok := c.messages[c.defaultLang][code]; ok {
+		return msg
+	}
+	if msg, ok := c.messages[DefaultLanguage][code]; ok {
+		return msg
+	}
+	return code
+}
+
+// SetDefault changes the fallback language of the built-in catalogs
+func SetDefault(lang string) error {
+	return defaultCatalogs.SetDefault(lang)
+}
+
+// Negotiate picks the built-in language best matching an Accept-Language header
+func Negotiate(acceptLanguage string) string {
+	return defaultCatalogs.Negotiate(acceptLanguage)
+}
+
+// T returns the built-in message for code in lang
+func T(lang, code string) string {
+	return defaultCatalogs.T(lang, code)
+}
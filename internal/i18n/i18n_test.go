@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "en"},
+		{"vi", "vi"},
+		{"vi-VN,vi;q=0.9,en;q=0.8", "vi"},
+		{"fr-FR,fr;q=0.9", "en"},
+		{"fr;q=0.9,vi;q=0.5", "vi"},
+		{"en-US,en;q=0.9,vi;q=0.8", "en"},
+		{"not a header!!", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			if got := Negotiate(tt.acceptLanguage); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("vi", CodePasteNotFound); got != "Không tìm thấy paste" {
+		t.Errorf("T(vi) = %q", got)
+	}
+	if got := T("fr", CodePasteNotFound); got != "Paste not found" {
+		t.Errorf("T(fr) = %q, want the English fallback", got)
+	}
+	if got := T("en", "unknown_code"); got != "unknown_code" {
+		t.Errorf("T(unknown) = %q, want the code itself", got)
+	}
+}
+
+func TestCatalogs_Complete(t *testing.T) {
+	// Every language must translate every message of the default catalog
+	for lang, messages := range defaultCatalogs.messages {
+		for code := range defaultCatalogs.messages[DefaultLanguage] {
+			if messages[code] == "" {
+				t.Errorf("catalog %s is missing %s", lang, code)
+			}
+		}
+	}
+}
+
+func TestCatalogs_SetDefault(t *testing.T) {
+	c, err := Load(fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{"greeting": "Hello"}`)},
+		"locales/vi.json": {Data: []byte(`{"greeting": "Xin chào"}`)},
+	}, "locales")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if err := c.SetDefault("de"); err == nil {
+		t.Error("SetDefault() without a catalog should fail")
+	}
+	if err := c.SetDefault("vi"); err != nil {
+		t.Fatalf("SetDefault() error = %v", err)
+	}
+	if got := c.Negotiate("fr"); got != "vi" {
+		t.Errorf("Negotiate() = %q, want the new default", got)
+	}
+	if got := c.T(c.Negotiate(""), "greeting"); got != "Xin chào" {
+		t.Errorf("T() = %q, want %q", got, "Xin chào")
+	}
+}
+
+func TestLoad_RequiresDefaultCatalog(t *testing.T) {
+	_, err := Load(fstest.MapFS{
+		"locales/vi.json": {Data: []byte(`{}`)},
+	}, "locales")
+	if err == nil {
+		t.Error("Load() without an English catalog should fail")
+	}
+}
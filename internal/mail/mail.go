@@ -0,0 +1,96 @@
+// Package mail sends the emails of the service, such as notification digests, through an SMTP server.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// ErrInvalidAddress is returned for a recipient that is not a single email address
+var ErrInvalidAddress = errors.New("mail: invalid address")
+
+// Mailer sends plain text emails
+type Mailer interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// SMTPMailer sends emails through an SMTP server, upgrading the connection with STARTTLS when the
+// server offers it
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates an SMTPMailer sending from the given address through the server at addr
+// (host:port), authenticating with PLAIN when username is set
+func NewSMTPMailer(addr, username, password, from string) (*SMTPMailer, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("mail: invalid SMTP address %q: %w", addr, err)
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("mail: invalid sender %q: %w", from, err)
+	}
+
+	m := &SMTPMailer{addr: addr, from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m, nil
+}
+
+// Send sends an email to a single recipient. The context is only checked before sending: net/smtp
+// does not take one.
+func (m *SMTPMailer) Send(ctx context.Context, to, subject, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return ErrInvalidAddress
+	}
+	sender, _ := mail.ParseAddress(m.from)
+
+	msg, err := Message(m.from, recipient.String(), subject, body, time.Now())
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(m.addr, m.auth, sender.Address, []string{recipient.Address}, msg)
+}
+
+// Message builds a plain text UTF-8 email; the subject is encoded so that no header can be
+// injected through it
+func Message(from, to, subject, body string, date time.Time) ([]byte, error) {
+	if strings.ContainsAny(from+to, "\r\n") {
+		return nil, ErrInvalidAddress
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.Join(strings.Fields(subject), " ")))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
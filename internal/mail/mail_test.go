@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessage(t *testing.T) {
+	date := time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC)
+	msg, err := Message("Gisty <noreply@example.com>", "<a@example.com>", "Thông báo\r\nBcc: x@example.com", "Hello\nworld", date)
+	if err != nil {
+		t.Fatalf("Message() error = %v", err)
+	}
+
+	got := string(msg)
+	for _, want := range []string{
+		"From: Gisty <noreply@example.com>\r\n",
+		"To: <a@example.com>\r\n",
+		"Subject: =?utf-8?q?Th=C3=B4ng_b=C3=A1o_Bcc:_x@example.com?=\r\n",
+		"Date: Wed, 14 Feb 2024 12:00:00 +0000\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"\r\n\r\nHello\r\nworld",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Message() = %q, want it to contain %q", got, want)
+		}
+	}
+	if strings.Contains(got, "\r\nBcc:") {
+		t.Errorf("Message() = %q, want no header injected through the subject", got)
+	}
+
+	if _, err := Message("noreply@example.com", "a@example.com\r\nBcc: x@example.com", "Hi", "", date); err != ErrInvalidAddress {
+		t.Errorf("Message() with a line break in the recipient error = %v, want %v", err, ErrInvalidAddress)
+	}
+}
+
+func TestNewSMTPMailer(t *testing.T) {
+	if _, err := NewSMTPMailer("smtp.example.com", "", "", "noreply@example.com"); err == nil {
+		t.Error("NewSMTPMailer() without a port error = nil, want an error")
+	}
+	if _, err := NewSMTPMailer("smtp.example.com:587", "", "", "not an address"); err == nil {
+		t.Error("NewSMTPMailer() with an invalid sender error = nil, want an error")
+	}
+
+	m, err := NewSMTPMailer("smtp.example.com:587", "user", "secret", "Gisty <noreply@example.com>")
+	if err != nil {
+		t.Fatalf("NewSMTPMailer() error = %v", err)
+	}
+	if err := m.Send(context.Background(), "not an address", "Hi", ""); err != ErrInvalidAddress {
+		t.Errorf("Send() to an invalid address error = %v, want %v", err, ErrInvalidAddress)
+	}
+}
@@ -0,0 +1,329 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// Namespace is the prefix applied to all Gisty metrics
+	Namespace = "gisty"
+)
+
+var (
+	// CleanupScanned counts storage objects and upload sessions examined by the cleanup worker
+	CleanupScanned = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cleanup",
+		Name:      "scanned_total",
+		Help:      "Number of storage objects and upload sessions scanned by the cleanup worker.",
+	})
+
+	// CleanupDeleted counts orphaned objects and upload sessions removed by the cleanup worker
+	CleanupDeleted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cleanup",
+		Name:      "deleted_total",
+		Help:      "Number of orphaned storage objects and upload sessions removed by the cleanup worker.",
+	})
+
+	// CleanupFailures counts cleanup errors by storage layer (cache, storage, database)
+	CleanupFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cleanup",
+		Name:      "failures_total",
+		Help:      "Number of cleanup failures by storage layer.",
+	}, []string{"layer"})
+
+	// CleanupAbandoned counts orphaned objects given up on after reaching the delete attempt cap
+	CleanupAbandoned = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cleanup",
+		Name:      "abandoned_total",
+		Help:      "Number of orphaned storage objects abandoned after reaching the delete attempt cap.",
+	})
+
+	// CleanupDuration observes how long each cleanup run takes
+	CleanupDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "cleanup",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of cleanup worker runs.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// CleanupLastRun records the unix timestamp of the last completed cleanup run
+	CleanupLastRun = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "cleanup",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix timestamp of the last completed cleanup run.",
+	})
+
+	// ChangeStreamEvents counts paste events published from the change stream by type
+	ChangeStreamEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "changestream",
+		Name:      "events_total",
+		Help:      "Number of paste events published from the change stream by type.",
+	}, []string{"type"})
+
+	// ChangeStreamFailures counts change stream publisher errors by stage (watch, decode, publish, token)
+	ChangeStreamFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "changestream",
+		Name:      "failures_total",
+		Help:      "Number of change stream publisher failures by stage.",
+	}, []string{"stage"})
+
+	// EventsPublished counts paste events published on the event bus by type
+	EventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "events",
+		Name:      "published_total",
+		Help:      "Number of paste events published on the event bus by type.",
+	}, []string{"type"})
+
+	// EventsDropped counts events a subscriber missed because its queue was full
+	EventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "events",
+		Name:      "dropped_total",
+		Help:      "Number of paste events dropped because the subscriber queue was full.",
+	}, []string{"subscriber"})
+
+	// EventFailures counts events a subscriber failed to handle
+	EventFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "events",
+		Name:      "failures_total",
+		Help:      "Number of paste events a subscriber failed to handle.",
+	}, []string{"subscriber"})
+
+	// CacheRequests counts content cache lookups by result (hit, miss, bypass)
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Number of content cache lookups by result.",
+	}, []string{"result"})
+
+	// CacheLocalHits counts content cache hits served by the in-process cache, also counted as hits
+	// in CacheRequests
+	CacheLocalHits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "local_hits_total",
+		Help:      "Number of content cache hits served by the in-process cache.",
+	})
+
+	// CacheSharedFetches counts cache misses served by a storage read shared with concurrent misses
+	// on the same paste
+	CacheSharedFetches = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "shared_fetches_total",
+		Help:      "Number of cache misses served by a storage read shared with other misses.",
+	})
+
+	// CacheAdmissions counts decisions on content above the small size read from storage, by
+	// result (admitted, rejected)
+	CacheAdmissions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "admissions_total",
+		Help:      "Number of cache admission decisions for large content by result.",
+	}, []string{"result"})
+
+	// RateLimitDecisions counts rate limiter decisions by limiter, outcome (allow, deny) and route.
+	// Client identities are deliberately not used as labels to keep cardinality bounded.
+	RateLimitDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "ratelimit",
+		Name:      "decisions_total",
+		Help:      "Number of rate limiter decisions by limiter, outcome and route.",
+	}, []string{"limiter", "decision", "route"})
+
+	// InFlightRequests tracks requests currently being served by route class (read, write)
+	InFlightRequests = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "in_flight_requests",
+		Help:      "Number of requests currently being served by route class.",
+	}, []string{"class"})
+
+	// LoadShed counts requests rejected because the concurrency limit of their route class was reached
+	LoadShed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "shed_requests_total",
+		Help:      "Number of requests rejected by load shedding by route class.",
+	}, []string{"class"})
+
+	// SlowRequests counts requests exceeding the slow request threshold by method and route
+	SlowRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "slow_requests_total",
+		Help:      "Number of requests exceeding the slow request threshold by method and route.",
+	}, []string{"method", "route"})
+
+	// SLORequests counts the requests of routes with service level objectives by objective, e.g.
+	// "GET /api/v1/pastes/:id"
+	SLORequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "slo",
+		Name:      "requests_total",
+		Help:      "Number of requests of routes with service level objectives by objective.",
+	}, []string{"objective"})
+
+	// SLOErrors counts the requests failing an objective by objective and SLO: a 5xx for
+	// availability, slower than the threshold for latency
+	SLOErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "slo",
+		Name:      "errors_total",
+		Help:      "Number of requests failing a service level objective by objective and SLO.",
+	}, []string{"objective", "slo"})
+
+	// SLOBurnRate reports how many times faster than sustainable this instance spends the error
+	// budget of each objective, by objective, SLO and window
+	SLOBurnRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "slo",
+		Name:      "burn_rate",
+		Help:      "Error budget burn rate of this instance by objective, SLO and window.",
+	}, []string{"objective", "slo", "window"})
+
+	// KGSUnusedKeys reports the unused keys in the key pool at the last count
+	KGSUnusedKeys = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "kgs",
+		Name:      "unused_keys",
+		Help:      "Number of unused keys in the key pool at the last count.",
+	})
+
+	// KGSExhausted reports whether the unused keys are below the critical floor (1) or not (0)
+	KGSExhausted = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "kgs",
+		Name:      "exhausted",
+		Help:      "Whether the unused keys are below the critical floor and creates are rejected (1) or not (0).",
+	})
+
+	// StalePastes reports the pastes that never expire and were not read for the configured days,
+	// as of the last stale report
+	StalePastes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "stale",
+		Name:      "pastes",
+		Help:      "Number of pastes that never expire and were not read for the configured days, as of the last stale report.",
+	})
+
+	// StaleBytes reports the stored size of the pastes listed by the last stale report
+	StaleBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "stale",
+		Name:      "bytes",
+		Help:      "Stored size in bytes of the stale pastes listed by the last stale report.",
+	})
+
+	// StaleBytesEstimated reports the stored size of all stale pastes as of the last stale report,
+	// extrapolated from the listed ones when the report was truncated
+	StaleBytesEstimated = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "stale",
+		Name:      "bytes_estimated",
+		Help:      "Estimated stored size in bytes of all stale pastes, extrapolated from the listed ones when the last stale report was truncated.",
+	})
+
+	// StaleReportTruncated is 1 when the last stale report listed fewer pastes than are stale
+	StaleReportTruncated = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "stale",
+		Name:      "report_truncated",
+		Help:      "Whether the last stale report listed fewer pastes than are stale (1) or all of them (0).",
+	})
+
+	// StorageVerified counts the pastes whose stored content was checked by the storage verifier,
+	// by result: "ok", "corrupt" or "error" when it could not be downloaded
+	StorageVerified = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "storage",
+		Name:      "verified_total",
+		Help:      "Pastes whose stored content was re-downloaded and checked by the storage verifier, by result.",
+	}, []string{"result"})
+
+	// QuarantinedPastes reports the quarantined pastes, as of the last verification run
+	QuarantinedPastes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "storage",
+		Name:      "quarantined_pastes",
+		Help:      "Number of pastes quarantined because their stored content failed verification or by content screening, as of the last verification run.",
+	})
+
+	// Screened counts the new pastes checked by content screening, by action: "pass", "flag",
+	// "quarantine" or "reject"
+	Screened = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "screening",
+		Name:      "pastes_total",
+		Help:      "New pastes checked by content screening, by action taken.",
+	}, []string{"action"})
+
+	// IPBlocks reports the IPs and CIDRs on the deny list in force
+	IPBlocks = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "ipdeny",
+		Name:      "blocks",
+		Help:      "Number of IPs and CIDRs on the deny list in force.",
+	})
+
+	// IPDenied counts the requests denied by the IP deny list
+	IPDenied = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "ipdeny",
+		Name:      "denied_total",
+		Help:      "Total number of requests denied by the IP deny list.",
+	})
+
+	// Reports counts the abuse reports of pastes by reason
+	Reports = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "reports",
+		Name:      "total",
+		Help:      "Abuse reports of pastes, by reason.",
+	}, []string{"reason"})
+
+	// ScreenerErrors counts the screenings a screener failed, skipping it
+	ScreenerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "screening",
+		Name:      "errors_total",
+		Help:      "Screenings of new pastes a screener failed and was skipped in, by screener.",
+	}, []string{"screener"})
+
+	// DependencyHealthy reports the health of each dependency as seen by the watchdog (1 healthy, 0 unhealthy)
+	DependencyHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "watchdog",
+		Name:      "dependency_healthy",
+		Help:      "Whether the watchdog considers each dependency healthy (1) or unhealthy (0).",
+	}, []string{"dependency"})
+
+	// DependencyTransitions counts the health changes of each dependency by the state entered
+	DependencyTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "watchdog",
+		Name:      "transitions_total",
+		Help:      "Number of dependency health changes by dependency and state entered.",
+	}, []string{"dependency", "state"})
+)
+
+// Handler returns the HTTP handler serving metrics in Prometheus format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+)
+
+const (
+	// AdminTokenHeader is the header carrying the admin token
+	AdminTokenHeader = "X-Admin-Token"
+)
+
+// AdminAuthMiddleware protects admin routes with a static bearer token.
+// The token may be sent as "Authorization: Bearer <token>" or in the X-Admin-Token header.
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsAdmin(c, token) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorBody(c, i18n.CodeUnauthorized))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the request carries the admin token, as accepted by AdminAuthMiddleware.
+// It is always false when no token is configured.
+func IsAdmin(c *gin.Context, token string) bool {
+	provided := c.GetHeader(AdminTokenHeader)
+	if provided == "" {
+		provided = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		value  string
+		want   bool
+	}{
+		{"admin header", "s3cret", AdminTokenHeader, "s3cret", true},
+		{"bearer token", "s3cret", "Authorization", "Bearer s3cret", true},
+		{"wrong token", "s3cret", AdminTokenHeader, "guess", false},
+		{"no token sent", "s3cret", "", "", false},
+		{"no token configured", "", AdminTokenHeader, "", false},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "/api/v1/pastes/xK9a2B/redact", nil)
+		if tt.header != "" {
+			c.Request.Header.Set(tt.header, tt.value)
+		}
+		if got := IsAdmin(c, tt.token); got != tt.want {
+			t.Errorf("IsAdmin(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
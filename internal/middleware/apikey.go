@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+)
+
+const (
+	// APIKeyHeader is the header carrying an API key
+	APIKeyHeader = "X-API-Key"
+	// apiKeyContextKey stores the identifier of the authenticated API key in the request context
+	apiKeyContextKey = "gisty.api_key"
+)
+
+// APIKeyMiddleware authenticates requests sending an API key in the X-API-Key header.
+// Requests without the header stay anonymous; an unknown key is rejected rather than
+// silently downgraded, so misconfigured clients notice.
+func APIKeyMiddleware(keys []string) gin.HandlerFunc {
+	var hashes [][]byte
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			sum := sha256.Sum256([]byte(key))
+			hashes = append(hashes, sum[:])
+		}
+	}
+
+	return func(c *gin.Context) {
+		provided := c.GetHeader(APIKeyHeader)
+		if provided == "" {
+			c.Next()
+			return
+		}
+
+		// Compare digests so every comparison takes the same time whatever the key length
+		sum := sha256.Sum256([]byte(provided))
+		matched := 0
+		for _, hash := range hashes {
+			matched |= subtle.ConstantTimeCompare(sum[:], hash)
+		}
+		if matched != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorBody(c, i18n.CodeUnauthorized))
+			return
+		}
+
+		c.Set(apiKeyContextKey, hex.EncodeToString(sum[:8]))
+		c.Next()
+	}
+}
+
+// APIKeyID returns a stable, non-reversible identifier of the API key that authenticated
+// the request, or "" for anonymous requests
+func APIKeyID(c *gin.Context) string {
+	return c.GetString(apiKeyContextKey)
+}
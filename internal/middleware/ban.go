@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+)
+
+// BanChecker reports whether a client IP is banned
+type BanChecker interface {
+	IsBanned(ctx context.Context, ip string) (bool, error)
+}
+
+// BanMiddleware rejects requests from banned client IPs with 403.
+// It is meant for write routes; banned clients can still read.
+// If the bans cannot be read the request is let through (fail open).
+func BanMiddleware(checker BanChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		banned, err := checker.IsBanned(c.Request.Context(), c.ClientIP())
+		if err != nil {
+			log.Printf("[Ban] Failed to read bans: %v", err)
+			c.Next()
+			return
+		}
+
+		if banned {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorBody(c, i18n.CodeIPBanned))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeBans struct {
+	banned map[string]bool
+	err    error
+}
+
+func (f fakeBans) IsBanned(_ context.Context, ip string) (bool, error) {
+	return f.banned[ip], f.err
+}
+
+func TestBanMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	statusFor := func(checker BanChecker, remoteAddr string) int {
+		router := gin.New()
+		router.POST("/pastes", BanMiddleware(checker), func(c *gin.Context) {
+			c.Status(http.StatusCreated)
+		})
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/pastes", nil)
+		req.RemoteAddr = remoteAddr
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	bans := fakeBans{banned: map[string]bool{"203.0.113.7": true}}
+	if got := statusFor(bans, "203.0.113.7:1234"); got != http.StatusForbidden {
+		t.Errorf("banned client status = %d, want %d", got, http.StatusForbidden)
+	}
+	if got := statusFor(bans, "198.51.100.1:1234"); got != http.StatusCreated {
+		t.Errorf("other client status = %d, want %d", got, http.StatusCreated)
+	}
+
+	// Requests are let through when the bans cannot be read
+	failing := fakeBans{banned: map[string]bool{"203.0.113.7": true}, err: errors.New("redis down")}
+	if got := statusFor(failing, "203.0.113.7:1234"); got != http.StatusCreated {
+		t.Errorf("status on error = %d, want %d", got, http.StatusCreated)
+	}
+}
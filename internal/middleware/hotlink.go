@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HotlinkConfig holds the referrer policy for raw content
+type HotlinkConfig struct {
+	// AllowedReferrers lists the hosts allowed to embed raw content. Entries of the form
+	// "*.example.com" match any subdomain of example.com. The serving host is always allowed.
+	AllowedReferrers []string
+	// ViewBaseURL prefixes the view redirect when raw content is served from another host
+	// than the frontend (e.g. a short-link domain); empty keeps the redirect relative.
+	ViewBaseURL string
+}
+
+// HotlinkMiddleware redirects requests for raw content referred by a third-party page to the
+// paste view, so raw links cannot be used to serve arbitrary content from this domain to other sites.
+// Requests without a Referer (direct access, curl, privacy-conscious browsers) are let through.
+func HotlinkMiddleware(config *HotlinkConfig) gin.HandlerFunc {
+	var allowed []string
+	viewBase := ""
+	if config != nil {
+		viewBase = strings.TrimRight(config.ViewBaseURL, "/")
+		for _, host := range config.AllowedReferrers {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if host != "" {
+				allowed = append(allowed, host)
+			}
+		}
+	}
+
+	return func(c *gin.Context) {
+		referrer := c.GetHeader("Referer")
+		if referrer == "" {
+			c.Next()
+			return
+		}
+
+		ref, err := url.Parse(referrer)
+		host := ""
+		if err == nil {
+			host = strings.ToLower(ref.Hostname())
+		}
+
+		if host != "" && (host == requestHost(c.Request) || referrerAllowed(host, allowed)) {
+			c.Next()
+			return
+		}
+
+		log.Printf("[Hotlink] Redirecting raw request for %s referred by %s", c.Param("id"), host)
+		c.Redirect(http.StatusFound, viewBase+"/view/"+c.Param("id"))
+		c.Abort()
+	}
+}
+
+// referrerAllowed reports whether the referrer host matches an allow-list entry
+func referrerAllowed(host string, allowed []string) bool {
+	for _, entry := range allowed {
+		if suffix, ok := strings.CutPrefix(entry, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// requestHost returns the lower-cased host of the request without the port
+func requestHost(r *http.Request) string {
+	host := r.Host
+	if u, err := url.Parse("//" + host); err == nil {
+		host = u.Hostname()
+	}
+	return strings.ToLower(host)
+}
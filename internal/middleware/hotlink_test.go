@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHotlinkMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/:id", HotlinkMiddleware(&HotlinkConfig{
+		AllowedReferrers: []string{"docs.example.com", " *.partner.org ", ""},
+	}), func(c *gin.Context) {
+		c.String(http.StatusOK, "raw")
+	})
+
+	tests := []struct {
+		name     string
+		referrer string
+		wantCode int
+	}{
+		{"no referrer", "", http.StatusOK},
+		{"same host", "https://gisty.test:8080/view/abc123", http.StatusOK},
+		{"allowed host", "https://docs.example.com/page", http.StatusOK},
+		{"allowed subdomain", "https://blog.partner.org/post", http.StatusOK},
+		{"bare wildcard domain", "https://partner.org/", http.StatusFound},
+		{"third party", "https://evil.example/page", http.StatusFound},
+		{"lookalike suffix", "https://evilpartner.org/", http.StatusFound},
+		{"unparseable", "::not a url", http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+			req.Host = "gisty.test:8080"
+			if tt.referrer != "" {
+				req.Header.Set("Referer", tt.referrer)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusFound {
+				if got := w.Header().Get("Location"); got != "/view/abc123" {
+					t.Errorf("Location = %q, want %q", got, "/view/abc123")
+				}
+			}
+		})
+	}
+}
+
+func TestHotlinkMiddleware_ViewBaseURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/:id", HotlinkMiddleware(&HotlinkConfig{
+		AllowedReferrers: []string{"gisty.test"},
+		ViewBaseURL:      "https://gisty.test/",
+	}), func(c *gin.Context) {
+		c.String(http.StatusOK, "raw")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Host = "g.test"
+	req.Header.Set("Referer", "https://evil.example/page")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Location"); got != "https://gisty.test/view/abc123" {
+		t.Errorf("Location = %q, want %q", got, "https://gisty.test/view/abc123")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Host = "g.test"
+	req.Header.Set("Referer", "https://gisty.test/view/abc123")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d for the main host", w.Code, http.StatusOK)
+	}
+}
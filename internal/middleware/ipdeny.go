@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/metrics"
+)
+
+// IPBlocker reports whether requests from a client IP are denied
+type IPBlocker interface {
+	Blocked(ip string) bool
+}
+
+// IPDenyMiddleware rejects every request from a denied client IP with 403.
+// It runs ahead of rate limiting, so blocked clients use up neither limits nor Redis calls.
+func IPDenyMiddleware(blocker IPBlocker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if blocker.Blocked(c.ClientIP()) {
+			metrics.IPDenied.Inc()
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorBody(c, i18n.CodeIPDenied))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeBlocker map[string]bool
+
+func (f fakeBlocker) Blocked(ip string) bool {
+	return f[ip]
+}
+
+func TestIPDenyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(IPDenyMiddleware(fakeBlocker{"203.0.113.7": true}))
+	router.GET("/pastes/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	statusFor := func(remoteAddr string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/pastes/abc123", nil)
+		req.RemoteAddr = remoteAddr
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if got := statusFor("203.0.113.7:1234"); got != http.StatusForbidden {
+		t.Errorf("blocked client status = %d, want %d", got, http.StatusForbidden)
+	}
+	if got := statusFor("198.51.100.1:1234"); got != http.StatusOK {
+		t.Errorf("other client status = %d, want %d", got, http.StatusOK)
+	}
+}
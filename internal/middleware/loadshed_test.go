@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConcurrencyLimiter_QueueAndShed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewConcurrencyLimiter(&ConcurrencyLimitConfig{
+		Class:        RouteClassWrite,
+		MaxInFlight:  1,
+		QueueSize:    1,
+		QueueTimeout: time.Second,
+	})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	router := gin.New()
+	router.GET("/", limiter.Middleware(), func(c *gin.Context) {
+		started <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	// The first request takes the only slot, the second waits in the queue
+	var wg sync.WaitGroup
+	codes := make([]int, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		codes[0] = serve().Code
+	}()
+	<-started
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		codes[1] = serve().Code
+	}()
+	waitFor(t, func() bool { return limiter.queued.Load() == 1 })
+
+	// The queue is full, so the third request is shed immediately
+	w := serve()
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status when saturated = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want %q", got, "1")
+	}
+
+	// Releasing the slot lets the queued request through
+	close(release)
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("request %d status = %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+	if limiter.InFlight() != 0 {
+		t.Errorf("InFlight() = %d, want 0", limiter.InFlight())
+	}
+}
+
+func TestConcurrencyLimiter_QueueTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewConcurrencyLimiter(&ConcurrencyLimitConfig{
+		MaxInFlight:  1,
+		QueueSize:    1,
+		QueueTimeout: 20 * time.Millisecond,
+	})
+
+	// Hold the only slot
+	limiter.slots <- struct{}{}
+	defer func() { <-limiter.slots }()
+
+	router := gin.New()
+	router.GET("/", limiter.Middleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status after queue timeout = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+}
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
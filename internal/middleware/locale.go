@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+)
+
+// localeKey is the gin context key holding the negotiated language
+const localeKey = "locale"
+
+// Locale returns the language negotiated from the request's Accept-Language header
+func Locale(c *gin.Context) string {
+	if lang, ok := c.Get(localeKey); ok {
+		return lang.(string)
+	}
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	c.Set(localeKey, lang)
+	return lang
+}
+
+// ErrorText returns the localized message for an error code and marks the response language
+func ErrorText(c *gin.Context, code string) string {
+	lang := Locale(c)
+	c.Header("Content-Language", lang)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	return i18n.T(lang, code)
+}
+
+// ErrorBody builds a JSON error response with a localized message and a stable code.
+// Extra fields are merged into the body.
+func ErrorBody(c *gin.Context, code string, extra ...gin.H) gin.H {
+	body := gin.H{
+		"error": ErrorText(c, code),
+		"code":  code,
+	}
+	for _, fields := range extra {
+		for k, v := range fields {
+			body[k] = v
+		}
+	}
+	return body
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+)
+
+// MaintenanceChecker reports whether maintenance mode is active
+type MaintenanceChecker interface {
+	Check(ctx context.Context) (bool, time.Duration, error)
+}
+
+// MaintenanceMiddleware rejects requests with 503 and Retry-After while maintenance mode is on.
+// It is meant for write routes; reads keep working during maintenance.
+// If the state cannot be read the request is let through (fail open).
+func MaintenanceMiddleware(checker MaintenanceChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		enabled, retryAfter, err := checker.Check(c.Request.Context())
+		if err != nil {
+			log.Printf("[Maintenance] Failed to read maintenance state: %v", err)
+			c.Next()
+			return
+		}
+
+		if enabled {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, ErrorBody(c, i18n.CodeMaintenance))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// SessionHeader carries an anonymous session token: a random value the client generates once
+	// and sends with every request, so pastes created without an API key can be managed later
+	SessionHeader = "X-Gisty-Session"
+	// MinSessionTokenLength is the shortest accepted session token; shorter ones are ignored
+	MinSessionTokenLength = 16
+)
+
+// OwnerID returns a stable, non-reversible identifier of who sends the request: the API key
+// when one authenticated it, else the signed-in user, else the anonymous session token. It
+// returns "" when none is sent.
+func OwnerID(c *gin.Context) string {
+	if id := APIKeyID(c); id != "" {
+		return "key:" + id
+	}
+	if id := UserID(c); id != "" {
+		return "user:" + id
+	}
+	token := c.GetHeader(SessionHeader)
+	if len(token) < MinSessionTokenLength {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return "session:" + hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOwnerID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ownerOf := func(key, user, session string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("POST", "/api/v1/pastes", nil)
+		if session != "" {
+			c.Request.Header.Set(SessionHeader, session)
+		}
+		if key != "" {
+			c.Set(apiKeyContextKey, key)
+		}
+		if user != "" {
+			c.Set(userContextKey, user)
+		}
+		return OwnerID(c)
+	}
+
+	if got := ownerOf("", "", ""); got != "" {
+		t.Errorf("OwnerID() anonymous = %q, want empty", got)
+	}
+	if got := ownerOf("", "", "short"); got != "" {
+		t.Errorf("OwnerID() with short session = %q, want empty", got)
+	}
+
+	session := "8f14e45f-ceea-467a-9575-2f4b1f1f8e2a"
+	owner := ownerOf("", "", session)
+	if !strings.HasPrefix(owner, "session:") || strings.Contains(owner, session) {
+		t.Errorf("OwnerID() with session = %q, want a session hash", owner)
+	}
+	if got := ownerOf("", "", session); got != owner {
+		t.Errorf("OwnerID() is not stable: %q != %q", got, owner)
+	}
+
+	// An API key takes precedence over the session
+	if got := ownerOf("abcd", "", session); got != "key:abcd" {
+		t.Errorf("OwnerID() with API key = %q, want key:abcd", got)
+	}
+
+	// So does a signed-in user
+	if got := ownerOf("", "u1", session); got != "user:u1" {
+		t.Errorf("OwnerID() of a signed-in user = %q, want user:u1", got)
+	}
+	if got := ownerOf("abcd", "u1", session); got != "key:abcd" {
+		t.Errorf("OwnerID() with API key and user = %q, want key:abcd", got)
+	}
+}
@@ -1,11 +1,17 @@
 package middleware
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
+	"github.com/huylvt/gisty/internal/metrics"
 	"github.com/ulule/limiter/v3"
 	"github.com/ulule/limiter/v3/drivers/store/memory"
 )
@@ -13,6 +19,8 @@ import (
 const (
 	// DefaultRateLimit is the default rate limit per minute
 	DefaultRateLimit = 5
+	// DefaultRateLimiterName is the name of the limiter guarding writes
+	DefaultRateLimiterName = "write"
 	// DefaultRatePeriod is the default rate limiting period
 	DefaultRatePeriod = time.Minute
 )
@@ -23,12 +31,39 @@ type RateLimitConfig struct {
 	RequestsPerMinute int
 	// Enabled controls whether rate limiting is active
 	Enabled bool
+	// Algorithm selects the rate limiting algorithm (fixed_window, sliding_window, token_bucket)
+	Algorithm string
+	// Burst is the token bucket size; it defaults to RequestsPerMinute
+	Burst int
+	// Name identifies the limiter in metrics and logs (e.g. write, read, hotlink)
+	Name string
+	// KeyFunc derives the client key of a request; it defaults to the client IP
+	KeyFunc func(c *gin.Context) string
+	// APIKeyRequestsPerMinute is the limit of requests authenticated with an API key, counted per key.
+	// 0 limits them like anonymous clients.
+	APIKeyRequestsPerMinute int
+}
+
+// Rate limit decisions
+const (
+	RateLimitAllow = "allow"
+	RateLimitDeny  = "deny"
+)
+
+// RateLimitStatus describes the current counters of a client
+type RateLimitStatus struct {
+	ClientKey string
+	Limit     int64
+	Remaining int64
+	Reset     time.Time
+	Limited   bool
 }
 
 // RateLimiter wraps the limiter instance
 type RateLimiter struct {
-	limiter *limiter.Limiter
-	config  RateLimitConfig
+	limiter    rateAlgorithm
+	keyLimiter rateAlgorithm // nil when API keys get no separate quota
+	config     RateLimitConfig
 }
 
 // NewRateLimiter creates a new RateLimiter with the given configuration
@@ -36,6 +71,9 @@ func NewRateLimiter(config *RateLimitConfig) *RateLimiter {
 	cfg := RateLimitConfig{
 		RequestsPerMinute: DefaultRateLimit,
 		Enabled:           true,
+		Algorithm:         AlgorithmFixedWindow,
+		Name:              DefaultRateLimiterName,
+		KeyFunc:           ClientIPKey,
 	}
 
 	if config != nil {
@@ -43,21 +81,56 @@ func NewRateLimiter(config *RateLimitConfig) *RateLimiter {
 			cfg.RequestsPerMinute = config.RequestsPerMinute
 		}
 		cfg.Enabled = config.Enabled
+		if config.Algorithm != "" {
+			cfg.Algorithm = config.Algorithm
+		}
+		cfg.Burst = config.Burst
+		if config.Name != "" {
+			cfg.Name = config.Name
+		}
+		if config.KeyFunc != nil {
+			cfg.KeyFunc = config.KeyFunc
+		}
+		cfg.APIKeyRequestsPerMinute = max(config.APIKeyRequestsPerMinute, 0)
 	}
-
-	// Create rate using format "requests-period"
-	rate := limiter.Rate{
-		Period: DefaultRatePeriod,
-		Limit:  int64(cfg.RequestsPerMinute),
+	if cfg.Burst <= 0 {
+		cfg.Burst = cfg.RequestsPerMinute
 	}
 
-	// Use in-memory store
-	store := memory.NewStore()
+	if cfg.Algorithm != AlgorithmFixedWindow && cfg.Algorithm != AlgorithmSlidingWindow && cfg.Algorithm != AlgorithmTokenBucket {
+		log.Printf("[RateLimiter] Unknown algorithm '%s', using %s", cfg.Algorithm, AlgorithmFixedWindow)
+		cfg.Algorithm = AlgorithmFixedWindow
+	}
 
-	return &RateLimiter{
-		limiter: limiter.New(store, rate),
+	r := &RateLimiter{
+		limiter: newRateAlgorithm(cfg.Algorithm, int64(cfg.RequestsPerMinute), int64(cfg.Burst)),
 		config:  cfg,
 	}
+	if cfg.APIKeyRequestsPerMinute > 0 {
+		// API keys get the burst of their own limit
+		limit := int64(cfg.APIKeyRequestsPerMinute)
+		r.keyLimiter = newRateAlgorithm(cfg.Algorithm, limit, limit)
+	}
+	return r
+}
+
+// newRateAlgorithm creates the counters of a rate limiting algorithm
+func newRateAlgorithm(algorithm string, limit, burst int64) rateAlgorithm {
+	switch algorithm {
+	case AlgorithmSlidingWindow:
+		return newSlidingWindow(limit, DefaultRatePeriod)
+	case AlgorithmTokenBucket:
+		return newTokenBucket(limit, burst, DefaultRatePeriod)
+	default:
+		// Create rate using format "requests-period"
+		rate := limiter.Rate{
+			Period: DefaultRatePeriod,
+			Limit:  limit,
+		}
+
+		// Use in-memory store
+		return limiter.New(memory.NewStore(), rate)
+	}
 }
 
 // Middleware returns a Gin middleware that applies rate limiting
@@ -69,15 +142,16 @@ func (r *RateLimiter) Middleware() gin.HandlerFunc {
 			return
 		}
 
-		// Get client IP
-		ip := c.ClientIP()
+		// Get client key (the client IP unless configured otherwise); API keys have their own quota
+		algorithm, key := r.limiter, r.config.KeyFunc(c)
+		if id := APIKeyID(c); id != "" && r.keyLimiter != nil {
+			algorithm, key = r.keyLimiter, "key:"+id
+		}
 
 		// Get limiter context
-		ctx, err := r.limiter.Get(c.Request.Context(), ip)
+		ctx, err := algorithm.Get(c.Request.Context(), key)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-				"error": "Rate limiter error",
-			})
+			c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorBody(c, i18n.CodeRateLimiterError))
 			return
 		}
 
@@ -88,13 +162,14 @@ func (r *RateLimiter) Middleware() gin.HandlerFunc {
 
 		// Check if rate limit exceeded
 		if ctx.Reached {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
+			r.recordDecision(c, key, RateLimitDeny, ctx)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorBody(c, i18n.CodeRateLimited, gin.H{
 				"retry_after": ctx.Reset - time.Now().Unix(),
-			})
+			}))
 			return
 		}
 
+		r.recordDecision(c, key, RateLimitAllow, ctx)
 		c.Next()
 	}
 }
@@ -102,4 +177,62 @@ func (r *RateLimiter) Middleware() gin.HandlerFunc {
 // GetConfig returns the current rate limit configuration
 func (r *RateLimiter) GetConfig() RateLimitConfig {
 	return r.config
-}
\ No newline at end of file
+}
+
+// Inspect returns the current counters of a client without consuming a request
+func (r *RateLimiter) Inspect(ctx context.Context, ip string) (*RateLimitStatus, error) {
+	lctx, err := r.limiter.Peek(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	return toRateLimitStatus(ip, lctx), nil
+}
+
+// Reset clears the counters of a client
+func (r *RateLimiter) Reset(ctx context.Context, ip string) (*RateLimitStatus, error) {
+	lctx, err := r.limiter.Reset(ctx, ip)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[RateLimiter] reset client=%s", ClientKey(ip))
+	return toRateLimitStatus(ip, lctx), nil
+}
+
+// ClientIPKey keys requests by client IP
+func ClientIPKey(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// PasteClientKey keys requests by client IP and paste ID, limiting how often one client reads one paste
+func PasteClientKey(c *gin.Context) string {
+	return c.ClientIP() + "|" + c.Param("id")
+}
+
+// ClientKey returns a stable, non-reversible identifier for a client IP used in logs.
+// Support can compute it from the IP a user reports to find the matching log lines.
+func ClientKey(ip string) string {
+	sum := sha256.Sum256([]byte(ip))
+	return hex.EncodeToString(sum[:8])
+}
+
+// recordDecision exports a rate limit decision as a metric and logs denials
+func (r *RateLimiter) recordDecision(c *gin.Context, key, decision string, ctx limiter.Context) {
+	route := c.FullPath()
+	metrics.RateLimitDecisions.WithLabelValues(r.config.Name, decision, route).Inc()
+
+	if decision == RateLimitDeny {
+		log.Printf("[RateLimiter] limiter=%s decision=%s client=%s route=%s method=%s limit=%d remaining=%d reset=%d",
+			r.config.Name, decision, ClientKey(key), route, c.Request.Method, ctx.Limit, ctx.Remaining, ctx.Reset)
+	}
+}
+
+// toRateLimitStatus converts a limiter context to a RateLimitStatus
+func toRateLimitStatus(ip string, ctx limiter.Context) *RateLimitStatus {
+	return &RateLimitStatus{
+		ClientKey: ClientKey(ip),
+		Limit:     ctx.Limit,
+		Remaining: ctx.Remaining,
+		Reset:     time.Unix(ctx.Reset, 0),
+		Limited:   ctx.Reached,
+	}
+}
@@ -0,0 +1,237 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ulule/limiter/v3"
+)
+
+// Rate limiting algorithms
+const (
+	// AlgorithmFixedWindow counts requests per fixed period. Bursts of up to twice the limit
+	// are possible across a window boundary.
+	AlgorithmFixedWindow = "fixed_window"
+	// AlgorithmSlidingWindow weights the previous window's count by its overlap with the
+	// sliding period, smoothing out boundary bursts.
+	AlgorithmSlidingWindow = "sliding_window"
+	// AlgorithmTokenBucket refills tokens continuously at the configured rate and allows
+	// bursts up to the bucket size.
+	AlgorithmTokenBucket = "token_bucket"
+)
+
+// rateAlgorithm decides whether a request from a key is allowed
+type rateAlgorithm interface {
+	// Get consumes a request for the key and reports whether the limit is reached
+	Get(ctx context.Context, key string) (limiter.Context, error)
+	// Peek reports the state of the key without consuming a request
+	Peek(ctx context.Context, key string) (limiter.Context, error)
+	// Reset clears the state of the key
+	Reset(ctx context.Context, key string) (limiter.Context, error)
+}
+
+// keyedState holds per-key algorithm state and evicts idle keys
+type keyedState[T any] struct {
+	mu        sync.Mutex
+	entries   map[string]*T
+	idleAfter time.Duration
+	lastSweep time.Time
+	lastSeen  func(*T) time.Time
+}
+
+func newKeyedState[T any](idleAfter time.Duration, lastSeen func(*T) time.Time) *keyedState[T] {
+	return &keyedState[T]{
+		entries:   make(map[string]*T),
+		idleAfter: idleAfter,
+		lastSeen:  lastSeen,
+	}
+}
+
+// sweep removes keys that have been idle long enough to be equivalent to a fresh key.
+// It must be called with the lock held.
+func (s *keyedState[T]) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.idleAfter {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if now.Sub(s.lastSeen(entry)) >= s.idleAfter {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// slidingWindowEntry holds the counts of the current and previous windows of a key
+type slidingWindowEntry struct {
+	windowStart time.Time
+	current     int64
+	previous    int64
+	lastSeen    time.Time
+}
+
+// slidingWindow implements the sliding window counter algorithm
+type slidingWindow struct {
+	limit  int64
+	period time.Duration
+	now    func() time.Time
+	state  *keyedState[slidingWindowEntry]
+}
+
+func newSlidingWindow(limit int64, period time.Duration) *slidingWindow {
+	return &slidingWindow{
+		limit:  limit,
+		period: period,
+		now:    time.Now,
+		state: newKeyedState(2*period, func(e *slidingWindowEntry) time.Time {
+			return e.lastSeen
+		}),
+	}
+}
+
+func (w *slidingWindow) Get(_ context.Context, key string) (limiter.Context, error) {
+	return w.apply(key, true, false), nil
+}
+
+func (w *slidingWindow) Peek(_ context.Context, key string) (limiter.Context, error) {
+	return w.apply(key, false, false), nil
+}
+
+func (w *slidingWindow) Reset(_ context.Context, key string) (limiter.Context, error) {
+	return w.apply(key, false, true), nil
+}
+
+func (w *slidingWindow) apply(key string, consume, reset bool) limiter.Context {
+	now := w.now()
+
+	w.state.mu.Lock()
+	defer w.state.mu.Unlock()
+	w.state.sweep(now)
+
+	windowStart := now.Truncate(w.period)
+	entry, ok := w.state.entries[key]
+	if !ok || reset {
+		entry = &slidingWindowEntry{windowStart: windowStart}
+		if consume {
+			w.state.entries[key] = entry
+		} else if ok {
+			delete(w.state.entries, key)
+		}
+	}
+
+	// Roll the windows forward
+	if elapsed := windowStart.Sub(entry.windowStart); elapsed > 0 {
+		if elapsed == w.period {
+			entry.previous = entry.current
+		} else {
+			entry.previous = 0
+		}
+		entry.current = 0
+		entry.windowStart = windowStart
+	}
+	entry.lastSeen = now
+
+	// Weight the previous window by how much of it still overlaps the sliding period
+	overlap := 1 - float64(now.Sub(windowStart))/float64(w.period)
+	estimated := float64(entry.previous)*overlap + float64(entry.current)
+
+	reached := estimated+1 > float64(w.limit)
+	if consume && !reached {
+		entry.current++
+		estimated++
+	}
+
+	remaining := w.limit - int64(math.Ceil(estimated))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return limiter.Context{
+		Limit:     w.limit,
+		Remaining: remaining,
+		Reset:     windowStart.Add(w.period).Unix(),
+		Reached:   consume && reached,
+	}
+}
+
+// tokenBucketEntry holds the tokens of a key
+type tokenBucketEntry struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// tokenBucket implements the token bucket algorithm
+type tokenBucket struct {
+	burst int64
+	rate  float64 // tokens per second
+	now   func() time.Time
+	state *keyedState[tokenBucketEntry]
+}
+
+func newTokenBucket(limit, burst int64, period time.Duration) *tokenBucket {
+	rate := float64(limit) / period.Seconds()
+	// A bucket idle long enough to refill completely is equivalent to a fresh one
+	refillTime := time.Duration(float64(burst) / rate * float64(time.Second))
+
+	return &tokenBucket{
+		burst: burst,
+		rate:  rate,
+		now:   time.Now,
+		state: newKeyedState(refillTime, func(e *tokenBucketEntry) time.Time {
+			return e.lastSeen
+		}),
+	}
+}
+
+func (b *tokenBucket) Get(_ context.Context, key string) (limiter.Context, error) {
+	return b.apply(key, true, false), nil
+}
+
+func (b *tokenBucket) Peek(_ context.Context, key string) (limiter.Context, error) {
+	return b.apply(key, false, false), nil
+}
+
+func (b *tokenBucket) Reset(_ context.Context, key string) (limiter.Context, error) {
+	return b.apply(key, false, true), nil
+}
+
+func (b *tokenBucket) apply(key string, consume, reset bool) limiter.Context {
+	now := b.now()
+
+	b.state.mu.Lock()
+	defer b.state.mu.Unlock()
+	b.state.sweep(now)
+
+	entry, ok := b.state.entries[key]
+	if !ok || reset {
+		entry = &tokenBucketEntry{tokens: float64(b.burst), lastSeen: now}
+		if consume {
+			b.state.entries[key] = entry
+		} else if ok {
+			delete(b.state.entries, key)
+		}
+	}
+
+	// Refill tokens for the time elapsed since the last request
+	entry.tokens = math.Min(float64(b.burst), entry.tokens+now.Sub(entry.lastSeen).Seconds()*b.rate)
+	entry.lastSeen = now
+
+	reached := entry.tokens < 1
+	if consume && !reached {
+		entry.tokens--
+	}
+
+	// The next token is available once the bucket refills to one
+	resetAt := now
+	if entry.tokens < 1 {
+		resetAt = now.Add(time.Duration((1 - entry.tokens) / b.rate * float64(time.Second)))
+	}
+
+	return limiter.Context{
+		Limit:     b.burst,
+		Remaining: int64(math.Floor(entry.tokens)),
+		Reset:     int64(math.Ceil(float64(resetAt.UnixNano()) / float64(time.Second))),
+		Reached:   consume && reached,
+	}
+}
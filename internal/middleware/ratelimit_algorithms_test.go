@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for deterministic algorithm tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// allowed sends n requests for the key and returns how many were allowed
+func allowed(t *testing.T, algorithm rateAlgorithm, key string, n int) int {
+	t.Helper()
+
+	count := 0
+	for i := 0; i < n; i++ {
+		ctx, err := algorithm.Get(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if !ctx.Reached {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSlidingWindow_NoBurstAtWindowBoundary(t *testing.T) {
+	// Start one second before a window boundary
+	clock := &fakeClock{now: time.Date(2024, 1, 15, 14, 0, 59, 0, time.UTC)}
+	w := newSlidingWindow(10, time.Minute)
+	w.now = clock.Now
+
+	if got := allowed(t, w, "client", 10); got != 10 {
+		t.Fatalf("allowed before boundary = %d, want 10", got)
+	}
+
+	// Right after the boundary the previous window still counts almost fully,
+	// so a fixed window's burst doubling is not possible
+	clock.Advance(2 * time.Second)
+	if got := allowed(t, w, "client", 10); got != 0 {
+		t.Errorf("allowed just after boundary = %d, want 0", got)
+	}
+
+	// Halfway through the next window about half the budget is available again
+	clock.Advance(29 * time.Second)
+	if got := allowed(t, w, "client", 10); got != 5 {
+		t.Errorf("allowed halfway through next window = %d, want 5", got)
+	}
+
+	// Two windows later the client starts fresh
+	clock.Advance(2 * time.Minute)
+	if got := allowed(t, w, "client", 10); got != 10 {
+		t.Errorf("allowed after idle windows = %d, want 10", got)
+	}
+}
+
+func TestTokenBucket_BurstAndRefill(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)}
+	// 60 requests/minute (1 per second) with a burst of 5
+	b := newTokenBucket(60, 5, time.Minute)
+	b.now = clock.Now
+
+	if got := allowed(t, b, "client", 10); got != 5 {
+		t.Fatalf("allowed burst = %d, want 5", got)
+	}
+
+	// Tokens refill continuously at the configured rate
+	clock.Advance(3 * time.Second)
+	if got := allowed(t, b, "client", 10); got != 3 {
+		t.Errorf("allowed after 3s = %d, want 3", got)
+	}
+
+	// The bucket never holds more than the burst size
+	clock.Advance(time.Hour)
+	if got := allowed(t, b, "client", 10); got != 5 {
+		t.Errorf("allowed after idle = %d, want 5", got)
+	}
+}
+
+func TestRateAlgorithms_PeekAndReset(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)}
+
+	w := newSlidingWindow(3, time.Minute)
+	w.now = clock.Now
+	b := newTokenBucket(3, 3, time.Minute)
+	b.now = clock.Now
+
+	for name, algorithm := range map[string]rateAlgorithm{"sliding_window": w, "token_bucket": b} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			allowed(t, algorithm, "client", 3)
+
+			// Peek reports the exhausted budget without consuming
+			peek, err := algorithm.Peek(ctx, "client")
+			if err != nil {
+				t.Fatalf("Peek() error = %v", err)
+			}
+			if peek.Remaining != 0 || peek.Reached {
+				t.Errorf("Peek() = %+v, want Remaining 0 and not Reached", peek)
+			}
+			if got := allowed(t, algorithm, "client", 1); got != 0 {
+				t.Errorf("allowed after exhausting = %d, want 0", got)
+			}
+
+			// Reset restores the full budget
+			reset, err := algorithm.Reset(ctx, "client")
+			if err != nil {
+				t.Fatalf("Reset() error = %v", err)
+			}
+			if reset.Remaining != 3 {
+				t.Errorf("Reset() Remaining = %d, want 3", reset.Remaining)
+			}
+			if got := allowed(t, algorithm, "client", 3); got != 3 {
+				t.Errorf("allowed after reset = %d, want 3", got)
+			}
+
+			// Other clients are unaffected
+			if got := allowed(t, algorithm, "other", 3); got != 3 {
+				t.Errorf("allowed for other client = %d, want 3", got)
+			}
+		})
+	}
+}
+
+func TestNewRateLimiter_Algorithm(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		want      string
+	}{
+		{"", AlgorithmFixedWindow},
+		{AlgorithmFixedWindow, AlgorithmFixedWindow},
+		{AlgorithmSlidingWindow, AlgorithmSlidingWindow},
+		{AlgorithmTokenBucket, AlgorithmTokenBucket},
+		{"leaky_bucket", AlgorithmFixedWindow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			r := NewRateLimiter(&RateLimitConfig{RequestsPerMinute: 10, Enabled: true, Algorithm: tt.algorithm})
+			if got := r.GetConfig().Algorithm; got != tt.want {
+				t.Errorf("Algorithm = %q, want %q", got, tt.want)
+			}
+			if got := r.GetConfig().Burst; got != 10 {
+				t.Errorf("Burst = %d, want 10", got)
+			}
+		})
+	}
+}
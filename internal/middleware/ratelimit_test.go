@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiter_PasteClientKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewRateLimiter(&RateLimitConfig{
+		RequestsPerMinute: 2,
+		Enabled:           true,
+		Name:              "paste_read",
+		KeyFunc:           PasteClientKey,
+	})
+
+	router := gin.New()
+	router.GET("/:id", limiter.Middleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(path, ip string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := get("/abc", "10.0.0.1"); code != http.StatusOK {
+			t.Fatalf("read %d status = %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+	if code := get("/abc", "10.0.0.1"); code != http.StatusTooManyRequests {
+		t.Errorf("read over limit status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	// Other pastes and other clients have their own budgets
+	if code := get("/xyz", "10.0.0.1"); code != http.StatusOK {
+		t.Errorf("other paste status = %d, want %d", code, http.StatusOK)
+	}
+	if code := get("/abc", "10.0.0.2"); code != http.StatusOK {
+		t.Errorf("other client status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestRateLimiter_APIKeyQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := NewRateLimiter(&RateLimitConfig{
+		RequestsPerMinute:       1,
+		Enabled:                 true,
+		APIKeyRequestsPerMinute: 3,
+	})
+
+	router := gin.New()
+	router.Use(APIKeyMiddleware([]string{"secret-key", " other-key "}))
+	router.GET("/", limiter.Middleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := get(""); code != http.StatusOK {
+		t.Fatalf("anonymous status = %d, want %d", code, http.StatusOK)
+	}
+	if code := get(""); code != http.StatusTooManyRequests {
+		t.Errorf("anonymous over limit status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+
+	// The API key has its own, larger budget even from an exhausted IP
+	for i := 0; i < 3; i++ {
+		if code := get("secret-key"); code != http.StatusOK {
+			t.Fatalf("keyed request %d status = %d, want %d", i+1, code, http.StatusOK)
+		}
+	}
+	if code := get("secret-key"); code != http.StatusTooManyRequests {
+		t.Errorf("keyed over limit status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	if code := get("other-key"); code != http.StatusOK {
+		t.Errorf("other key status = %d, want %d", code, http.StatusOK)
+	}
+
+	if code := get("wrong-key"); code != http.StatusUnauthorized {
+		t.Errorf("unknown key status = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
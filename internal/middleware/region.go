@@ -0,0 +1,17 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+const (
+	// RegionHeader is the response header naming the region that served the request
+	RegionHeader = "X-Gisty-Region"
+)
+
+// RegionHeaderMiddleware adds the X-Gisty-Region header to every response, so requests routed
+// by GeoDNS can be traced to the region that served them
+func RegionHeaderMiddleware(region string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(RegionHeader, region)
+		c.Next()
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/i18n"
 	"github.com/microcosm-cc/bluemonday"
 )
 
@@ -11,8 +12,12 @@ const (
 	// MaxContentSize is the maximum allowed content size (1MB)
 	MaxContentSize = 1 * 1024 * 1024 // 1MB
 
-	// MaxRequestBodySize is the maximum allowed request body size (1MB + overhead for JSON)
-	MaxRequestBodySize = MaxContentSize + 1024 // 1MB + 1KB for JSON overhead
+	// MaxEncodedContentSize is the size of content of MaxContentSize sent base64 encoded
+	MaxEncodedContentSize = (MaxContentSize + 2) / 3 * 4
+
+	// MaxRequestBodySize is the maximum allowed request body size: content of MaxContentSize sent
+	// base64 encoded, plus overhead for JSON. The service checks the size of content once decoded.
+	MaxRequestBodySize = MaxEncodedContentSize + 1024 // ~1.33MB + 1KB for JSON overhead
 )
 
 // Sanitizer provides input sanitization functionality
@@ -41,10 +46,9 @@ func ContentSizeMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Check Content-Length header first for quick rejection
 		if c.Request.ContentLength > MaxRequestBodySize {
-			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
-				"error":    "Content too large",
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorBody(c, i18n.CodeContentTooLarge, gin.H{
 				"max_size": "1MB",
-			})
+			}))
 			return
 		}
 
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestContentSizeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/api/v1/pastes", ContentSizeMiddleware(), func(c *gin.Context) {
+		var req struct {
+			Content  string `json:"content"`
+			Encoding string `json:"encoding"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	body := func(content, encoding string) string {
+		b, _ := json.Marshal(map[string]string{"content": content, "encoding": encoding})
+		return string(b)
+	}
+	justUnder := strings.Repeat("\x00\x01\x02", (MaxContentSize-1)/3)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"plain just under 1MB", body(strings.Repeat("a", MaxContentSize-1), ""), http.StatusCreated},
+		{"base64 just under 1MB", body(base64.StdEncoding.EncodeToString([]byte(justUnder)), "base64"), http.StatusCreated},
+		{"over the encoded cap", body(strings.Repeat("A", MaxRequestBodySize), "base64"), http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/pastes", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestContentSizeMiddleware_NoContentLength(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/api/v1/pastes", ContentSizeMiddleware(), func(c *gin.Context) {
+		var req struct {
+			Content string `json:"content"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Status(http.StatusRequestEntityTooLarge)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	// A body of unknown length is cut off at MaxRequestBodySize while it is read
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/pastes",
+		strings.NewReader(`{"content":"`+strings.Repeat("A", MaxRequestBodySize)+`"}`))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
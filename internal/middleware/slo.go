@@ -0,0 +1,466 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/metrics"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSLOObjectives are the objectives tracked when none are configured: paste reads and raw
+// content served without errors 99.9% of the time, and within 500ms 99% of the time; creates
+// 99.5% and within 1s 99% of the time
+const DefaultSLOObjectives = "GET /api/v1/pastes/:id=99.9%,500ms@99%;" +
+	"GET /:id=99.9%,500ms@99%;" +
+	"POST /api/v1/pastes=99.5%,1s@99%"
+
+// SLO kinds tracked for an objective
+const (
+	SLOAvailability = "availability"
+	SLOLatency      = "latency"
+)
+
+// SLO statuses, from the burn rates of the error budget
+const (
+	SLOStatusOK       = "ok"
+	SLOStatusWarning  = "warning"
+	SLOStatusCritical = "critical"
+)
+
+// Burn rates of the multiwindow alerts: a critical burn spends 2% of a 30-day error budget in an
+// hour, a warning 5% in six hours. Both need the short window burning too, so alerts stop soon
+// after the burn does.
+const (
+	sloCriticalBurnRate = 14.4
+	sloWarningBurnRate  = 6
+)
+
+// SLOWindows are the windows burn rates are computed over
+var SLOWindows = []time.Duration{5 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour}
+
+// sloBucketCount is the number of one-minute buckets covering the longest window
+const sloBucketCount = 6 * 60
+
+// sloRefreshInterval is how often the burn rate gauges are refreshed at most
+const sloRefreshInterval = 10 * time.Second
+
+// ErrInvalidSLOObjectives is returned for an objective spec that does not parse
+var ErrInvalidSLOObjectives = errors.New("slo: invalid objectives")
+
+// SLOObjective holds the objectives of one route
+type SLOObjective struct {
+	// Method and Route identify the route as registered, e.g. GET and /api/v1/pastes/:id
+	Method string
+	Route  string
+	// Availability is the fraction of requests that must not fail with a 5xx, e.g. 0.999 (0
+	// does not track availability)
+	Availability float64
+	// Latency is the fraction of requests that must be served within LatencyThreshold, e.g.
+	// 0.99 (0 does not track latency)
+	Latency          float64
+	LatencyThreshold time.Duration
+}
+
+// Name identifies the objective in metrics and reports, e.g. "GET /api/v1/pastes/:id"
+func (o SLOObjective) Name() string {
+	return o.Method + " " + o.Route
+}
+
+// ParseSLOObjectives parses objectives separated by semicolons, each a route followed by its
+// availability target and latency target, e.g.
+// "GET /api/v1/pastes/:id=99.9%,500ms@99%;POST /api/v1/pastes=99.5%". Either target may be
+// omitted.
+func ParseSLOObjectives(spec string) ([]SLOObjective, error) {
+	var objectives []SLOObjective
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, targets, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		path = strings.TrimSpace(path)
+		if !ok || !hasPath || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSLOObjectives, entry)
+		}
+		objective := SLOObjective{Method: strings.ToUpper(method), Route: path}
+		if seen[objective.Name()] {
+			return nil, fmt.Errorf("%w: %q listed twice", ErrInvalidSLOObjectives, objective.Name())
+		}
+		seen[objective.Name()] = true
+
+		for _, target := range strings.Split(targets, ",") {
+			target = strings.TrimSpace(target)
+			threshold, ratio, isLatency := strings.Cut(target, "@")
+			if !isLatency {
+				ratio = target
+			}
+			value, err := parseSLOTarget(ratio)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidSLOObjectives, entry)
+			}
+			if !isLatency {
+				objective.Availability = value
+				continue
+			}
+			duration, err := time.ParseDuration(strings.TrimSpace(threshold))
+			if err != nil || duration <= 0 {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidSLOObjectives, entry)
+			}
+			objective.Latency = value
+			objective.LatencyThreshold = duration
+		}
+		objectives = append(objectives, objective)
+	}
+	return objectives, nil
+}
+
+// parseSLOTarget parses a target percentage such as "99.9%" into a fraction below 1
+func parseSLOTarget(s string) (float64, error) {
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil || percent <= 0 || percent >= 100 {
+		return 0, ErrInvalidSLOObjectives
+	}
+	// Rounded so 99.9% is exactly 0.999 as written, not 0.9990000000000001
+	return math.Round(percent*1e7) / 1e9, nil
+}
+
+// sloBucket counts the requests of one minute
+type sloBucket struct {
+	minute int64
+	total  int64
+	failed int64 // answered with a 5xx
+	slow   int64 // served slower than the latency threshold
+}
+
+// sloState holds the recent requests of one objective
+type sloState struct {
+	objective SLOObjective
+	mu        sync.Mutex
+	buckets   [sloBucketCount]sloBucket
+}
+
+// SLOTracker measures the requests of the routes with objectives and reports how fast each one
+// spends its error budget. Requests are counted in metrics, from which the exported alert rules
+// compute fleet-wide burn rates; the burn rates of the tracker itself cover this instance since
+// it started.
+type SLOTracker struct {
+	states      map[string]*sloState
+	order       []*sloState
+	started     time.Time
+	now         func() time.Time
+	lastRefresh atomic.Int64
+}
+
+// NewSLOTracker creates an SLOTracker for the given objectives
+func NewSLOTracker(objectives []SLOObjective) *SLOTracker {
+	t := &SLOTracker{
+		states:  make(map[string]*sloState, len(objectives)),
+		started: time.Now(),
+		now:     time.Now,
+	}
+	for _, objective := range objectives {
+		state := &sloState{objective: objective}
+		t.states[objective.Name()] = state
+		t.order = append(t.order, state)
+	}
+	return t
+}
+
+// Objectives returns the tracked objectives
+func (t *SLOTracker) Objectives() []SLOObjective {
+	objectives := make([]SLOObjective, 0, len(t.order))
+	for _, state := range t.order {
+		objectives = append(objectives, state.objective)
+	}
+	return objectives
+}
+
+// Middleware returns a Gin middleware that counts the requests of routes with objectives. It
+// also refreshes the burn rate gauges every few seconds, before the request is served so a
+// metrics scrape exports fresh values even while no tracked route is requested.
+func (t *SLOTracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t.refreshMetrics()
+		state := t.states[c.Request.Method+" "+c.FullPath()]
+		if state == nil {
+			c.Next()
+			return
+		}
+
+		start := t.now()
+		c.Next()
+		t.record(state, c.Writer.Status(), t.now().Sub(start))
+	}
+}
+
+// record counts a request of an objective
+func (t *SLOTracker) record(state *sloState, status int, elapsed time.Duration) {
+	objective := state.objective
+	failed := status >= http.StatusInternalServerError
+	slow := objective.Latency > 0 && elapsed > objective.LatencyThreshold
+
+	metrics.SLORequests.WithLabelValues(objective.Name()).Inc()
+	if failed && objective.Availability > 0 {
+		metrics.SLOErrors.WithLabelValues(objective.Name(), SLOAvailability).Inc()
+	}
+	if slow {
+		metrics.SLOErrors.WithLabelValues(objective.Name(), SLOLatency).Inc()
+	}
+
+	minute := t.now().Unix() / 60
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	bucket := &state.buckets[minute%sloBucketCount]
+	if bucket.minute != minute {
+		*bucket = sloBucket{minute: minute}
+	}
+	bucket.total++
+	if failed {
+		bucket.failed++
+	}
+	if slow {
+		bucket.slow++
+	}
+}
+
+// SLOWindow holds the requests of an objective over a window and the burn rate of its error budget
+type SLOWindow struct {
+	Window   string `json:"window" example:"1h"`
+	Requests int64  `json:"requests" example:"120000"`
+	Errors   int64  `json:"errors" example:"36"` // requests failing the objective
+	// BurnRate is how many times faster than sustainable the error budget is spent: 1 spends it
+	// exactly over the SLO period, 0 when no request was served
+	BurnRate float64 `json:"burn_rate" example:"0.3"`
+}
+
+// SLOStatus reports one objective of a route
+type SLOStatus struct {
+	Objective string      `json:"objective" example:"GET /api/v1/pastes/:id"`
+	SLO       string      `json:"slo" example:"availability" enums:"availability,latency"`
+	Target    float64     `json:"target" example:"0.999"`
+	Threshold string      `json:"threshold,omitempty" example:"500ms"` // latency objectives only
+	Status    string      `json:"status" example:"ok" enums:"ok,warning,critical"`
+	Windows   []SLOWindow `json:"windows"`
+}
+
+// SLOReport reports the objectives of this instance
+type SLOReport struct {
+	// Status is the worst status of the objectives: critical when the error budget burns over
+	// 14.4 times too fast in the last hour and 5 minutes, warning over 6 times in the last 6 hours
+	// and 30 minutes
+	Status     string      `json:"status" example:"ok" enums:"ok,warning,critical"`
+	Since      string      `json:"since" example:"2024-01-15T14:00:00Z"` // when this instance started tracking
+	Objectives []SLOStatus `json:"objectives"`
+}
+
+// Report computes the burn rates of every objective over SLOWindows
+func (t *SLOTracker) Report() *SLOReport {
+	report := &SLOReport{
+		Status:     SLOStatusOK,
+		Since:      t.started.UTC().Format(time.RFC3339),
+		Objectives: []SLOStatus{},
+	}
+	now := t.now()
+	for _, state := range t.order {
+		for _, status := range state.statuses(now) {
+			if sloSeverity(status.Status) > sloSeverity(report.Status) {
+				report.Status = status.Status
+			}
+			report.Objectives = append(report.Objectives, status)
+		}
+	}
+	return report
+}
+
+// statuses computes the status of the availability and latency objectives of a route
+func (s *sloState) statuses(now time.Time) []SLOStatus {
+	windows := make([]struct{ total, failed, slow int64 }, len(SLOWindows))
+	current := now.Unix() / 60
+	s.mu.Lock()
+	for _, bucket := range s.buckets {
+		age := time.Duration(current-bucket.minute) * time.Minute
+		for i, window := range SLOWindows {
+			if bucket.total > 0 && age >= 0 && age < window {
+				windows[i].total += bucket.total
+				windows[i].failed += bucket.failed
+				windows[i].slow += bucket.slow
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	var statuses []SLOStatus
+	status := func(slo string, target float64, errors func(i int) int64) SLOStatus {
+		st := SLOStatus{Objective: s.objective.Name(), SLO: slo, Target: target}
+		burnRates := make([]float64, len(SLOWindows))
+		for i, window := range SLOWindows {
+			burnRates[i] = burnRate(errors(i), windows[i].total, target)
+			st.Windows = append(st.Windows, SLOWindow{
+				Window:   formatWindow(window),
+				Requests: windows[i].total,
+				Errors:   errors(i),
+				BurnRate: math.Round(burnRates[i]*1000) / 1000,
+			})
+		}
+		// Windows: 5m, 30m, 1h, 6h
+		switch {
+		case burnRates[2] > sloCriticalBurnRate && burnRates[0] > sloCriticalBurnRate:
+			st.Status = SLOStatusCritical
+		case burnRates[3] > sloWarningBurnRate && burnRates[1] > sloWarningBurnRate:
+			st.Status = SLOStatusWarning
+		default:
+			st.Status = SLOStatusOK
+		}
+		return st
+	}
+	if s.objective.Availability > 0 {
+		statuses = append(statuses, status(SLOAvailability, s.objective.Availability, func(i int) int64 { return windows[i].failed }))
+	}
+	if s.objective.Latency > 0 {
+		st := status(SLOLatency, s.objective.Latency, func(i int) int64 { return windows[i].slow })
+		st.Threshold = s.objective.LatencyThreshold.String()
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// refreshMetrics exports the burn rates of every objective, at most every sloRefreshInterval
+func (t *SLOTracker) refreshMetrics() {
+	now := t.now()
+	last := t.lastRefresh.Load()
+	if now.UnixNano()-last < int64(sloRefreshInterval) || !t.lastRefresh.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+	for _, state := range t.order {
+		for _, status := range state.statuses(now) {
+			for _, window := range status.Windows {
+				metrics.SLOBurnRate.WithLabelValues(status.Objective, status.SLO, window.Window).Set(window.BurnRate)
+			}
+		}
+	}
+}
+
+// burnRate returns the rate the error budget of target is spent at, given errors out of total
+// requests
+func burnRate(errors, total int64, target float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(errors) / float64(total) / (1 - target)
+}
+
+// sloSeverity orders statuses from ok to critical
+func sloSeverity(status string) int {
+	switch status {
+	case SLOStatusCritical:
+		return 2
+	case SLOStatusWarning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// formatWindow renders a window as Prometheus does, e.g. 5m or 6h
+func formatWindow(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return strconv.Itoa(int(window/time.Hour)) + "h"
+	}
+	return strconv.Itoa(int(window/time.Minute)) + "m"
+}
+
+// prometheusRule is a recording or alerting rule in a Prometheus rule file
+type prometheusRule struct {
+	Alert       string            `yaml:"alert"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// prometheusRuleGroup is a group of a Prometheus rule file
+type prometheusRuleGroup struct {
+	Name  string           `yaml:"name"`
+	Rules []prometheusRule `yaml:"rules"`
+}
+
+// AlertRules renders Prometheus alerting rules for the objectives, in the rule file format. The
+// rules compute burn rates across every instance from the request counters, and alert on the
+// same multiwindow burn rates as the tracker: a page on a critical burn, a ticket on a warning.
+func (t *SLOTracker) AlertRules() ([]byte, error) {
+	group := prometheusRuleGroup{Name: "gisty-slo", Rules: []prometheusRule{}}
+	for _, state := range t.order {
+		objective := state.objective
+		targets := []struct {
+			slo    string
+			target float64
+			good   string
+		}{
+			{SLOAvailability, objective.Availability, "served without a 5xx"},
+			{SLOLatency, objective.Latency, "served within " + objective.LatencyThreshold.String()},
+		}
+		for _, target := range targets {
+			if target.target <= 0 {
+				continue
+			}
+			burn := func(window string) string {
+				errors := fmt.Sprintf(`sum(rate(%s_slo_errors_total{objective=%q,slo=%q}[%s]))`,
+					metrics.Namespace, objective.Name(), target.slo, window)
+				requests := fmt.Sprintf(`sum(rate(%s_slo_requests_total{objective=%q}[%s]))`,
+					metrics.Namespace, objective.Name(), window)
+				return fmt.Sprintf("(%s / %s) / %s", errors, requests, strconv.FormatFloat(1-target.target, 'g', 6, 64))
+			}
+			labels := func(severity string) map[string]string {
+				return map[string]string{"severity": severity, "objective": objective.Name(), "slo": target.slo}
+			}
+			percent := strconv.FormatFloat(target.target*100, 'f', -1, 64)
+			group.Rules = append(group.Rules,
+				prometheusRule{
+					Alert: "GistySLOBurnRateCritical",
+					Expr: fmt.Sprintf("%s > %g and %s > %g",
+						burn("1h"), float64(sloCriticalBurnRate), burn("5m"), float64(sloCriticalBurnRate)),
+					For:    "2m",
+					Labels: labels("page"),
+					Annotations: map[string]string{
+						"summary": fmt.Sprintf("%s is burning its %s error budget over %g times too fast",
+							objective.Name(), target.slo, float64(sloCriticalBurnRate)),
+						"description": fmt.Sprintf("%s targets %s%% of requests %s; at this rate its 30-day error budget runs out in about 2 days.",
+							objective.Name(), percent, target.good),
+					},
+				},
+				prometheusRule{
+					Alert: "GistySLOBurnRateWarning",
+					Expr: fmt.Sprintf("%s > %g and %s > %g",
+						burn("6h"), float64(sloWarningBurnRate), burn("30m"), float64(sloWarningBurnRate)),
+					For:    "15m",
+					Labels: labels("ticket"),
+					Annotations: map[string]string{
+						"summary": fmt.Sprintf("%s is burning its %s error budget over %g times too fast",
+							objective.Name(), target.slo, float64(sloWarningBurnRate)),
+						"description": fmt.Sprintf("%s targets %s%% of requests %s; at this rate its 30-day error budget runs out in about 5 days.",
+							objective.Name(), percent, target.good),
+					},
+				},
+			)
+		}
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string][]prometheusRuleGroup{"groups": {group}}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), encoder.Close()
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseSLOObjectives(t *testing.T) {
+	objectives, err := ParseSLOObjectives(" get /api/v1/pastes/:id=99.9%,500ms@99% ; POST /api/v1/pastes=99.5;GET /raw/:id=1s@95%;")
+	if err != nil {
+		t.Fatalf("ParseSLOObjectives() error = %v", err)
+	}
+	want := []SLOObjective{
+		{Method: "GET", Route: "/api/v1/pastes/:id", Availability: 0.999, Latency: 0.99, LatencyThreshold: 500 * time.Millisecond},
+		{Method: "POST", Route: "/api/v1/pastes", Availability: 0.995},
+		{Method: "GET", Route: "/raw/:id", Latency: 0.95, LatencyThreshold: time.Second},
+	}
+	if len(objectives) != len(want) {
+		t.Fatalf("ParseSLOObjectives() = %+v, want %+v", objectives, want)
+	}
+	for i := range want {
+		if objectives[i] != want[i] {
+			t.Errorf("objective %d = %+v, want %+v", i, objectives[i], want[i])
+		}
+	}
+
+	if _, err := ParseSLOObjectives(DefaultSLOObjectives); err != nil {
+		t.Errorf("ParseSLOObjectives(DefaultSLOObjectives) error = %v", err)
+	}
+
+	for _, spec := range []string{
+		"/api/v1/pastes=99%",
+		"GET api=99%",
+		"GET /a=",
+		"GET /a=100%",
+		"GET /a=abc",
+		"GET /a=0ms@99%",
+		"GET /a=99%;GET /a=98%",
+	} {
+		if _, err := ParseSLOObjectives(spec); !errors.Is(err, ErrInvalidSLOObjectives) {
+			t.Errorf("ParseSLOObjectives(%q) error = %v, want %v", spec, err, ErrInvalidSLOObjectives)
+		}
+	}
+}
+
+func TestSLOTracker_Report(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tracker := NewSLOTracker([]SLOObjective{
+		{Method: "GET", Route: "/pastes/:id", Availability: 0.99, Latency: 0.9, LatencyThreshold: 50 * time.Millisecond},
+	})
+	now := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	router := gin.New()
+	router.Use(tracker.Middleware())
+	router.GET("/pastes/:id", func(c *gin.Context) {
+		switch c.Param("id") {
+		case "fail":
+			c.Status(http.StatusServiceUnavailable)
+		case "slow":
+			now = now.Add(100 * time.Millisecond)
+			c.Status(http.StatusOK)
+		case "missing":
+			c.Status(http.StatusNotFound)
+		default:
+			c.Status(http.StatusOK)
+		}
+	})
+	router.GET("/other", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	serve := func(path string) {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// Requests from more than 6 hours ago have left every window
+	for range 10 {
+		serve("/pastes/fail")
+	}
+	now = now.Add(7 * time.Hour)
+
+	for range 89 {
+		serve("/pastes/ok")
+	}
+	serve("/pastes/missing")
+	serve("/pastes/slow")
+	for range 9 {
+		serve("/pastes/fail")
+	}
+	serve("/other")
+
+	report := tracker.Report()
+	if len(report.Objectives) != 2 {
+		t.Fatalf("Report() objectives = %+v, want availability and latency", report.Objectives)
+	}
+
+	availability := report.Objectives[0]
+	if availability.SLO != SLOAvailability || availability.Status != SLOStatusWarning {
+		t.Errorf("availability = %+v, want a warning", availability)
+	}
+	for _, window := range availability.Windows {
+		// 9 of 100 requests failed, 9 times the 1% budget
+		if window.Requests != 100 || window.Errors != 9 || window.BurnRate != 9 {
+			t.Errorf("availability window %s = %+v, want 9 errors of 100 burning at 9", window.Window, window)
+		}
+	}
+
+	latency := report.Objectives[1]
+	if latency.SLO != SLOLatency || latency.Threshold != "50ms" || latency.Status != SLOStatusOK {
+		t.Errorf("latency = %+v, want ok with a 50ms threshold", latency)
+	}
+	if window := latency.Windows[0]; window.Errors != 1 || window.BurnRate != 0.1 {
+		t.Errorf("latency window %s = %+v, want 1 slow request burning at 0.1", window.Window, window)
+	}
+	if report.Status != SLOStatusWarning {
+		t.Errorf("Report() status = %s, want %s", report.Status, SLOStatusWarning)
+	}
+
+	// Failures past the critical burn rate in every window
+	for range 50 {
+		serve("/pastes/fail")
+	}
+	if report := tracker.Report(); report.Status != SLOStatusCritical {
+		t.Errorf("Report() status after an outage = %s, want %s", report.Status, SLOStatusCritical)
+	}
+}
+
+func TestSLOTracker_AlertRules(t *testing.T) {
+	tracker := NewSLOTracker([]SLOObjective{
+		{Method: "GET", Route: "/api/v1/pastes/:id", Availability: 0.999, Latency: 0.99, LatencyThreshold: 500 * time.Millisecond},
+		{Method: "POST", Route: "/api/v1/pastes", Availability: 0.995},
+	})
+
+	out, err := tracker.AlertRules()
+	if err != nil {
+		t.Fatalf("AlertRules() error = %v", err)
+	}
+	var file struct {
+		Groups []prometheusRuleGroup `yaml:"groups"`
+	}
+	if err := yaml.Unmarshal(out, &file); err != nil {
+		t.Fatalf("AlertRules() is not YAML: %v\n%s", err, out)
+	}
+	if len(file.Groups) != 1 || len(file.Groups[0].Rules) != 6 {
+		t.Fatalf("AlertRules() = %s, want one group of 6 rules", out)
+	}
+
+	rule := file.Groups[0].Rules[0]
+	wantExpr := `sum(rate(gisty_slo_errors_total{objective="GET /api/v1/pastes/:id",slo="availability"}[1h]))`
+	if rule.Alert != "GistySLOBurnRateCritical" || rule.Labels["severity"] != "page" || !strings.Contains(rule.Expr, wantExpr) ||
+		!strings.Contains(rule.Expr, "/ 0.001 > 14.4") {
+		t.Errorf("first rule = %+v, want a page on the availability burn rate", rule)
+	}
+	if rule := file.Groups[0].Rules[3]; rule.Labels["slo"] != SLOLatency || rule.Labels["severity"] != "ticket" {
+		t.Errorf("fourth rule = %+v, want a latency ticket", rule)
+	}
+}
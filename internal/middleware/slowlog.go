@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/timing"
+	"github.com/huylvt/gisty/internal/tracing"
+)
+
+const (
+	// DefaultSlowRequestThreshold is the default duration above which a request is logged as slow
+	DefaultSlowRequestThreshold = time.Second
+)
+
+// RequestTimingConfig holds request budget and slow request logging configuration
+type RequestTimingConfig struct {
+	// Timeout is the time budget of a request; its context is cancelled once exceeded. 0 disables it.
+	Timeout time.Duration
+	// SlowThreshold is the duration above which a request is logged and counted as slow
+	SlowThreshold time.Duration
+}
+
+// RequestTimer applies a per-request time budget and reports slow requests
+// with a breakdown of the time spent in cache, database and storage calls.
+type RequestTimer struct {
+	config RequestTimingConfig
+}
+
+// NewRequestTimer creates a new RequestTimer with the given configuration
+func NewRequestTimer(config *RequestTimingConfig) *RequestTimer {
+	cfg := RequestTimingConfig{
+		SlowThreshold: DefaultSlowRequestThreshold,
+	}
+
+	if config != nil {
+		if config.Timeout > 0 {
+			cfg.Timeout = config.Timeout
+		}
+		if config.SlowThreshold > 0 {
+			cfg.SlowThreshold = config.SlowThreshold
+		}
+	}
+
+	return &RequestTimer{config: cfg}
+}
+
+// Middleware returns a Gin middleware that times requests
+func (t *RequestTimer) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		ctx, recorder := timing.WithRecorder(c.Request.Context())
+		if t.config.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, t.config.Timeout)
+			defer cancel()
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		elapsed := time.Since(start)
+		if elapsed < t.config.SlowThreshold {
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.SlowRequests.WithLabelValues(c.Request.Method, route).Inc()
+		traceID := ""
+		if id := tracing.TraceID(ctx); id != "" {
+			// The trace holds the individual cache, database and storage calls
+			traceID = " trace_id=" + id
+		}
+		log.Printf("[SlowRequest] method=%s route=%s status=%d duration=%s %s%s",
+			c.Request.Method, route, c.Writer.Status(), elapsed.Round(time.Millisecond),
+			formatPhases(recorder.Phases(), elapsed), traceID)
+	}
+}
+
+// GetConfig returns the current request timing configuration
+func (t *RequestTimer) GetConfig() RequestTimingConfig {
+	return t.config
+}
+
+// formatPhases renders the phase breakdown, attributing the remaining time to "other"
+func formatPhases(phases []timing.Phase, total time.Duration) string {
+	var b strings.Builder
+	other := total
+	for _, p := range phases {
+		fmt.Fprintf(&b, "%s=%s(%d) ", p.Name, p.Duration.Round(time.Millisecond), p.Calls)
+		other -= p.Duration
+	}
+	// Concurrent calls can add up to more than the request duration
+	if other < 0 {
+		other = 0
+	}
+	fmt.Fprintf(&b, "other=%s", other.Round(time.Millisecond))
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// untracedPaths are probed too often to be worth a trace
+var untracedPaths = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
+}
+
+// TracingMiddleware starts a server span for every request, continuing the trace of an
+// incoming traceparent header. Spans are named after the route, e.g., "GET /api/v1/pastes/:id".
+func TracingMiddleware(serviceName string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName,
+		otelgin.WithGinFilter(func(c *gin.Context) bool {
+			return !untracedPaths[c.Request.URL.Path]
+		}),
+	)
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// SessionCookie carries the session token of a signed-in user in browsers; API clients send
+	// it as "Authorization: Bearer <token>" instead
+	SessionCookie = "gisty_session"
+	// userContextKey stores the ID of the signed-in user in the request context
+	userContextKey = "gisty.user"
+	// sessionContextKey stores the ID of the session or API token of the request
+	sessionContextKey = "gisty.session"
+)
+
+// SessionVerifier returns the user ID and session ID of a session token used from clientIP
+type SessionVerifier interface {
+	VerifySession(ctx context.Context, token, clientIP string) (string, string, error)
+}
+
+// UserAuthMiddleware identifies signed-in users from their session token. Requests without a
+// valid token stay anonymous rather than being rejected: the Authorization header also carries
+// the admin and storage events tokens, and routes that need a user check UserID themselves.
+func UserAuthMiddleware(sessions SessionVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if cookie, err := c.Cookie(SessionCookie); err == nil && cookie != "" {
+			token = cookie
+		}
+		if token != "" {
+			if userID, sessionID, err := sessions.VerifySession(c.Request.Context(), token, c.ClientIP()); err == nil {
+				c.Set(userContextKey, userID)
+				c.Set(sessionContextKey, sessionID)
+			}
+		}
+		c.Next()
+	}
+}
+
+// UserID returns the ID of the signed-in user sending the request, or "" for anonymous requests
+func UserID(c *gin.Context) string {
+	return c.GetString(userContextKey)
+}
+
+// SessionID returns the ID of the session or API token of the signed-in user sending the
+// request, or "" when sessions are not recorded
+func SessionID(c *gin.Context) string {
+	return c.GetString(sessionContextKey)
+}
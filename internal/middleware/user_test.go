@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeSessions accepts the tokens it maps to a user ID
+type fakeSessions map[string]string
+
+func (f fakeSessions) VerifySession(_ context.Context, token, _ string) (string, string, error) {
+	if userID, ok := f[token]; ok {
+		return userID, "session-" + token, nil
+	}
+	return "", "", errors.New("invalid session")
+}
+
+func TestUserAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(UserAuthMiddleware(fakeSessions{"good": "u1", "cookie": "u2"}))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, UserID(c)+SessionID(c))
+	})
+
+	userOf := func(authorization, cookie string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: SessionCookie, Value: cookie})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code, w.Body.String()
+	}
+
+	if _, user := userOf("", ""); user != "" {
+		t.Errorf("UserID() anonymous = %q, want empty", user)
+	}
+	if _, user := userOf("Bearer good", ""); user != "u1session-good" {
+		t.Errorf("UserID() and SessionID() with bearer token = %q, want u1 and session-good", user)
+	}
+	if _, user := userOf("", "cookie"); user != "u2session-cookie" {
+		t.Errorf("UserID() and SessionID() with cookie = %q, want u2 and session-cookie", user)
+	}
+
+	// Other bearer tokens, such as the admin token, go through anonymously
+	if code, user := userOf("Bearer admin-token", ""); code != http.StatusOK || user != "" {
+		t.Errorf("UserID() with another token = %d %q, want 200 and empty", code, user)
+	}
+}
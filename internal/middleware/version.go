@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/huylvt/gisty/internal/version"
+)
+
+const (
+	// VersionHeader is the response header carrying the server version
+	VersionHeader = "X-Gisty-Version"
+)
+
+// VersionHeaderMiddleware adds the X-Gisty-Version header to every response
+func VersionHeaderMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(VersionHeader, version.Version)
+		c.Next()
+	}
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Comment is a message a signed-in user left on a paste
+type Comment struct {
+	ID      string `bson:"comment_id" json:"id"`
+	ShortID string `bson:"short_id" json:"short_id"`
+	UserID  string `bson:"user_id" json:"-"`
+	// Username is the author's username when the comment was written
+	Username  string    `bson:"username" json:"username"`
+	Body      string    `bson:"body" json:"body"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Kinds of notifications
+const (
+	NotificationComment = "comment" // someone commented on a paste of the user
+	NotificationMention = "mention" // someone mentioned the user in a comment
+)
+
+// Notification tells a user about a comment on one of their pastes or mentioning them
+type Notification struct {
+	ID     string `bson:"notification_id" json:"id"`
+	UserID string `bson:"user_id" json:"-"`
+	Kind   string `bson:"kind" json:"kind"`
+	// ShortID and CommentID locate the comment; Actor is its author's username and Excerpt its
+	// beginning
+	ShortID   string    `bson:"short_id" json:"short_id"`
+	CommentID string    `bson:"comment_id" json:"comment_id"`
+	Actor     string    `bson:"actor" json:"actor"`
+	Excerpt   string    `bson:"excerpt" json:"excerpt"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	// ReadAt is set once the user marks the notification read
+	ReadAt *time.Time `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	// DigestedAt is set once the notification was sent in an email digest
+	DigestedAt *time.Time `bson:"digested_at,omitempty" json:"-"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// PasteEventType identifies a paste lifecycle event
+type PasteEventType string
+
+const (
+	// PasteEventCreated is emitted once a paste becomes readable
+	PasteEventCreated PasteEventType = "paste.created"
+	// PasteEventUpdated is emitted when the content or metadata of a readable paste changes
+	PasteEventUpdated PasteEventType = "paste.updated"
+	// PasteEventDeleted is emitted when a paste is removed
+	PasteEventDeleted PasteEventType = "paste.deleted"
+	// PasteEventRead is emitted when the content of a paste is served
+	PasteEventRead PasteEventType = "paste.read"
+)
+
+// PasteEvent describes a change to a paste for downstream consumers such as replicas,
+// analytics and cache invalidation. Events may be delivered more than once; consumers
+// deduplicate them by ID.
+type PasteEvent struct {
+	ID         string         `json:"id"`
+	Type       PasteEventType `json:"type"`
+	ShortID    string         `json:"short_id"`
+	Region     string         `json:"region,omitempty"` // region of the instance that published the event
+	OccurredAt time.Time      `json:"occurred_at"`
+
+	// Paste is the metadata after the change or at the time of the read; nil for deleted pastes
+	Paste *Paste `json:"paste,omitempty"`
+}
@@ -1,9 +1,16 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // Paste represents a paste entry in the database
 type Paste struct {
+	// ID is the document ID, which orders the pastes created at the same time in listings
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+
 	ShortID       string     `bson:"short_id" json:"short_id"`
 	UserID        *string    `bson:"user_id,omitempty" json:"user_id,omitempty"`
 	ContentKey    string     `bson:"content_key" json:"content_key"`
@@ -12,6 +19,200 @@ type Paste struct {
 	SyntaxType    string     `bson:"syntax_type" json:"syntax_type"`
 	IsPrivate     bool       `bson:"is_private" json:"is_private"`
 	BurnAfterRead bool       `bson:"burn_after_read" json:"burn_after_read"`
+
+	// Content policy flags: Binary pastes are served as application/octet-stream,
+	// PreviewTruncated pastes are rendered from a preview with long lines cut
+	Binary           bool `bson:"binary,omitempty" json:"binary,omitempty"`
+	PreviewTruncated bool `bson:"preview_truncated,omitempty" json:"preview_truncated,omitempty"`
+
+	// ContentHash is the hex-encoded SHA-256 of the current content, used as its entity tag; pastes
+	// created before it was recorded get it on their next read
+	ContentHash string `bson:"content_hash,omitempty" json:"-"`
+
+	// Encrypted pastes hold client-side encrypted ciphertext the server cannot read: it is stored
+	// and returned as-is, without content policy checks or syntax detection
+	Encrypted bool `bson:"is_encrypted,omitempty" json:"is_encrypted,omitempty"`
+
+	// SourceIPHash is a keyed hash of the creator's IP, kept for incident review; the IP itself is not stored
+	SourceIPHash string `bson:"source_ip_hash,omitempty" json:"-"`
+	// Optional descriptive metadata; tags are normalized lowercase and indexed for listing by tag
+	Title       string   `bson:"title,omitempty" json:"title,omitempty"`
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+	Tags        []string `bson:"tags,omitempty" json:"tags,omitempty"`
+
+	// OwnerID identifies the API key or anonymous session that created the paste
+	OwnerID string `bson:"owner_id,omitempty" json:"-"`
+	// DeleteTokenHash is the SHA-256 of the delete token returned when the paste was created
+	// anonymously; the token itself is not stored
+	DeleteTokenHash string `bson:"delete_token_hash,omitempty" json:"-"`
+	// OutputOf is set on pastes holding the output of another paste, such as a CI job log of a
+	// script or a sandbox run; Producer describes what produced it
+	OutputOf string    `bson:"output_of,omitempty" json:"output_of,omitempty"`
+	Producer *Producer `bson:"producer,omitempty" json:"producer,omitempty"`
+	// ForkedFrom is set on pastes created as a copy of another paste
+	ForkedFrom string `bson:"forked_from,omitempty" json:"forked_from,omitempty"`
+	// SourceURL is set on pastes created from the content of a URL fetched by the server
+	SourceURL string `bson:"source_url,omitempty" json:"source_url,omitempty"`
+	// DerivedFrom is set on snippets: pastes holding a range of lines of another paste
+	DerivedFrom *DerivedFrom `bson:"derived_from,omitempty" json:"derived_from,omitempty"`
+
+	// ViewCount counts reads of the content; a paste with MaxViews is deleted after that many reads
+	ViewCount int64 `bson:"view_count,omitempty" json:"view_count,omitempty"`
+	MaxViews  int   `bson:"max_views,omitempty" json:"max_views,omitempty"`
+	// LastReadAt is the time of the last counted read, for reports of forgotten pastes
+	LastReadAt *time.Time `bson:"last_read_at,omitempty" json:"-"`
+
+	// BurnedAt is set when a reader claims a burn-after-read paste; the paste is gone for everyone else
+	BurnedAt *time.Time `bson:"burned_at,omitempty" json:"-"`
+
+	// Revision counts the previous versions kept for an edited paste; UpdatedAt is the time of the last edit
+	Revision  int        `bson:"revision,omitempty" json:"revision,omitempty"`
+	UpdatedAt *time.Time `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+	// RedactedAt is the time of the last redaction, which rewrites the content and all revisions in place
+	RedactedAt *time.Time `bson:"redacted_at,omitempty" json:"redacted_at,omitempty"`
+
+	// Delivery holds the owner's header overrides for raw delivery
+	Delivery *DeliveryHeaders `bson:"delivery,omitempty" json:"delivery,omitempty"`
+
+	// PinnedAt is set while the user who created the paste pins it to the top of their profile
+	PinnedAt *time.Time `bson:"pinned_at,omitempty" json:"-"`
+
+	// GroupID is shared by the pastes created together from the files of a bundle or a multi-file
+	// gist, one per file; Path is the file's slash-separated path in the bundle
+	GroupID string `bson:"group_id,omitempty" json:"group_id,omitempty"`
+	Path    string `bson:"path,omitempty" json:"path,omitempty"`
+	// Live pastes only grow: their owner appends to them while readers follow along
+	Live bool `bson:"live,omitempty" json:"live,omitempty"`
+	// AppendLock is held by the append in progress on a live paste, so appends are not lost
+	AppendLock *AppendLock `bson:"append_lock,omitempty" json:"-"`
+
+	// Validation is the result of validating JSON or YAML content against a schema supplied at creation
+	Validation *SchemaValidation `bson:"validation,omitempty" json:"validation,omitempty"`
+	// Lint holds the linter annotations of the content, computed in the background after each change
+	Lint *LintResult `bson:"lint,omitempty" json:"lint,omitempty"`
+
+	// ArchivedAt is set while a bucket lifecycle rule keeps the content in an archival storage
+	// class; the paste is not readable until the content is restored
+	ArchivedAt *time.Time `bson:"archived_at,omitempty" json:"archived_at,omitempty"`
+	// Quarantine is set on pastes whose stored content failed verification, or that content
+	// screening quarantined; the paste is not readable until an operator releases it
+	Quarantine *Quarantine `bson:"quarantine,omitempty" json:"-"`
+	// Screening is set on new pastes that content screening flagged or quarantined, until an
+	// operator dismisses it
+	Screening *Screening `bson:"screening,omitempty" json:"-"`
+
+	// Upload is set while the content is being uploaded directly to storage; the paste is not readable until completed
+	Upload *PendingUpload `bson:"upload,omitempty" json:"-"`
+}
+
+// DeliveryHeaders are per-paste overrides of the raw endpoint's response headers.
+// Values are validated against a whitelist when the paste is created.
+type DeliveryHeaders struct {
+	ContentType  string `bson:"content_type,omitempty" json:"content_type,omitempty"`
+	CacheControl string `bson:"cache_control,omitempty" json:"cache_control,omitempty"`
+	Filename     string `bson:"filename,omitempty" json:"filename,omitempty"`
+}
+
+// SchemaValidation is the result of validating a paste against a JSON Schema. The schema is kept
+// so edits are validated against it again.
+type SchemaValidation struct {
+	Schema      string    `bson:"schema" json:"-"`
+	Valid       bool      `bson:"valid" json:"valid"`
+	Errors      []string  `bson:"errors,omitempty" json:"errors,omitempty"`
+	ValidatedAt time.Time `bson:"validated_at" json:"validated_at"`
+}
+
+// LintResult holds the annotations of a version of the content; it is stale once Revision
+// differs from the paste's
+type LintResult struct {
+	Revision    int          `bson:"revision" json:"revision"`
+	Annotations []Annotation `bson:"annotations,omitempty" json:"annotations,omitempty"`
+	LintedAt    time.Time    `bson:"linted_at" json:"linted_at"`
+}
+
+// Annotation is a linter finding at a 1-based line and column; Column 0 means the whole line
+type Annotation struct {
+	Linter   string `bson:"linter" json:"linter"`
+	Severity string `bson:"severity" json:"severity"` // "error" or "warning"
+	Line     int    `bson:"line" json:"line"`
+	Column   int    `bson:"column,omitempty" json:"column,omitempty"`
+	Message  string `bson:"message" json:"message"`
+}
+
+// PasteRevision is a previous version of an edited paste. Revisions are numbered from 1;
+// the content of each one is kept in storage under its own key.
+type PasteRevision struct {
+	ShortID    string    `bson:"short_id" json:"short_id"`
+	Revision   int       `bson:"revision" json:"revision"`
+	SyntaxType string    `bson:"syntax_type" json:"syntax_type"`
+	Binary     bool      `bson:"binary,omitempty" json:"binary,omitempty"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`   // when this version was written
+	ReplacedAt time.Time `bson:"replaced_at" json:"replaced_at"` // when it was replaced by an edit
+}
+
+// DerivedFrom records the paste and the inclusive range of 1-based lines a snippet was taken from
+type DerivedFrom struct {
+	ShortID   string `bson:"short_id" json:"short_id"`
+	StartLine int    `bson:"start_line" json:"start_line"`
+	EndLine   int    `bson:"end_line" json:"end_line"`
+}
+
+// Producer describes the job or tool that produced the content of a paste
+type Producer struct {
+	Kind string `bson:"kind" json:"kind"`                     // e.g. "ci", "runner"
+	Name string `bson:"name,omitempty" json:"name,omitempty"` // job, pipeline or language name
+	URL  string `bson:"url,omitempty" json:"url,omitempty"`   // link to the job run
+}
+
+// AppendLock serializes appends to a live paste; it is released when the append is recorded or
+// once Until has passed, should the append never finish
+type AppendLock struct {
+	Token string    `bson:"token"`
+	Until time.Time `bson:"until"`
+}
+
+// Quarantine records why and when a paste was quarantined
+type Quarantine struct {
+	Reason string    `bson:"reason"` // e.g. "checksum mismatch"
+	At     time.Time `bson:"at"`
+}
+
+// Screening records why content screening flagged or quarantined a new paste
+type Screening struct {
+	Action  string    `bson:"action"` // "flag" or "quarantine"
+	Score   int       `bson:"score"`
+	Reasons []string  `bson:"reasons"` // e.g. "domains: link to spam.example"
+	At      time.Time `bson:"at"`
+}
+
+// PendingUpload describes content the client has announced but not yet finished uploading
+type PendingUpload struct {
+	Size      int64  `bson:"size"`
+	SHA256    string `bson:"sha256"`     // hex-encoded checksum of the content
+	ExpiresIn string `bson:"expires_in"` // expiration applied once the upload completes
+
+	// Resumable uploads use an S3 multipart upload, split into fixed-size parts
+	MultipartID string `bson:"multipart_id,omitempty"`
+	PartSize    int64  `bson:"part_size,omitempty"`
+}
+
+// PartCount returns the number of parts of a resumable upload
+func (u *PendingUpload) PartCount() int {
+	if u.PartSize <= 0 {
+		return 1
+	}
+	return int((u.Size + u.PartSize - 1) / u.PartSize)
+}
+
+// PartSizeOf returns the expected size of the given 1-based part of a resumable upload
+func (u *PendingUpload) PartSizeOf(partNumber int) int64 {
+	if u.PartSize <= 0 {
+		return u.Size
+	}
+	if partNumber == u.PartCount() {
+		return u.Size - u.PartSize*int64(partNumber-1)
+	}
+	return u.PartSize
 }
 
 // IsExpired checks if the paste has expired
@@ -25,4 +226,24 @@ func (p *Paste) IsExpired() bool {
 // HasExpiration returns true if the paste has an expiration time set
 func (p *Paste) HasExpiration() bool {
 	return p.ExpiresAt != nil
-}
\ No newline at end of file
+}
+
+// IsPending returns true if the paste content is still being uploaded
+func (p *Paste) IsPending() bool {
+	return p.Upload != nil
+}
+
+// ViewsExhausted returns true if the paste has a view limit and reached it
+func (p *Paste) ViewsExhausted() bool {
+	return p.MaxViews > 0 && p.ViewCount >= int64(p.MaxViews)
+}
+
+// IsQuarantined returns true if the stored content of the paste was found corrupt
+func (p *Paste) IsQuarantined() bool {
+	return p.Quarantine != nil
+}
+
+// IsBurned returns true if the burn-after-read paste has already been read
+func (p *Paste) IsBurned() bool {
+	return p.BurnedAt != nil
+}
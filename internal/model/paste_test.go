@@ -77,4 +77,48 @@ func TestPaste_HasExpiration(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestPendingUpload_Parts(t *testing.T) {
+	tests := []struct {
+		name         string
+		upload       PendingUpload
+		wantCount    int
+		wantLastSize int64
+	}{
+		{
+			name:         "single part upload",
+			upload:       PendingUpload{Size: 100},
+			wantCount:    1,
+			wantLastSize: 100,
+		},
+		{
+			name:         "exact multiple of part size",
+			upload:       PendingUpload{Size: 30, PartSize: 10},
+			wantCount:    3,
+			wantLastSize: 10,
+		},
+		{
+			name:         "shorter last part",
+			upload:       PendingUpload{Size: 25, PartSize: 10},
+			wantCount:    3,
+			wantLastSize: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.upload.PartCount(); got != tt.wantCount {
+				t.Errorf("PartCount() = %v, want %v", got, tt.wantCount)
+			}
+			if got := tt.upload.PartSizeOf(tt.wantCount); got != tt.wantLastSize {
+				t.Errorf("PartSizeOf(last) = %v, want %v", got, tt.wantLastSize)
+			}
+			if tt.wantCount > 1 {
+				if got := tt.upload.PartSizeOf(1); got != tt.upload.PartSize {
+					t.Errorf("PartSizeOf(1) = %v, want %v", got, tt.upload.PartSize)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// Reasons a paste is reported for
+const (
+	ReportSpam         = "spam"
+	ReportMalware      = "malware"
+	ReportPhishing     = "phishing"
+	ReportCopyright    = "copyright" // DMCA and other copyright takedown requests
+	ReportPersonalData = "personal_data"
+	ReportIllegal      = "illegal"
+	ReportOther        = "other"
+)
+
+// Resolutions of a report
+const (
+	ResolutionDismissed = "dismissed" // nothing wrong was found, the paste is left as is
+	ResolutionHidden    = "hidden"    // the paste is quarantined until an operator releases it
+	ResolutionDeleted   = "deleted"
+)
+
+// Report is an abuse or takedown report of a paste, awaiting review until an operator resolves it
+type Report struct {
+	ID      string `bson:"report_id" json:"id"`
+	ShortID string `bson:"short_id" json:"short_id"`
+	Reason  string `bson:"reason" json:"reason"`
+	Details string `bson:"details,omitempty" json:"details,omitempty"`
+	// Contact is how the reporter can be reached, e.g., the email of a copyright holder
+	Contact string `bson:"contact,omitempty" json:"contact,omitempty"`
+	// SourceIPHash is the keyed hash of the reporter's IP, when IP hashing is configured
+	SourceIPHash string    `bson:"source_ip_hash,omitempty" json:"source_ip_hash,omitempty"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+
+	// Resolution, Note and ResolvedAt are set once an operator resolves the report
+	Resolution string     `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	Note       string     `bson:"note,omitempty" json:"note,omitempty"`
+	ResolvedAt *time.Time `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+// IsResolved returns true once an operator resolved the report
+func (r *Report) IsResolved() bool {
+	return r.ResolvedAt != nil
+}
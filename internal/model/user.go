@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// User is an account signed in with an OAuth provider. Pastes created while signed in carry
+// the user's ID in Paste.UserID.
+type User struct {
+	ID string `bson:"user_id" json:"id"`
+	// Provider and ProviderID identify the account at the OAuth provider the user signs in with
+	Provider   string `bson:"provider" json:"provider"`
+	ProviderID string `bson:"provider_id" json:"-"`
+
+	// PasswordHash is the bcrypt hash of the password of an account of the password provider,
+	// whose provider ID is the lowercased email; EmailVerified is set once its owner followed the
+	// verification link sent to the email
+	PasswordHash  string `bson:"password_hash,omitempty" json:"-"`
+	EmailVerified bool   `bson:"email_verified,omitempty" json:"email_verified,omitempty"`
+
+	// TOTPSecret is the base32 secret of the user's authenticator app, set at enrollment and
+	// enabled once a first code confirms it; destructive actions then need a code or one of the
+	// RecoveryCodes, kept as SHA-256 hashes. TOTPLastStep is the time step of the last code used,
+	// so that a code is not accepted twice.
+	TOTPSecret    string   `bson:"totp_secret,omitempty" json:"-"`
+	TOTPEnabled   bool     `bson:"totp_enabled,omitempty" json:"totp_enabled"`
+	RecoveryCodes []string `bson:"recovery_codes,omitempty" json:"-"`
+	TOTPLastStep  int64    `bson:"totp_last_step,omitempty" json:"-"`
+
+	// Profile copied from the provider at each sign-in
+	Login     string `bson:"login,omitempty" json:"login,omitempty"`
+	Name      string `bson:"name,omitempty" json:"name,omitempty"`
+	Email     string `bson:"email,omitempty" json:"email,omitempty"`
+	AvatarURL string `bson:"avatar_url,omitempty" json:"avatar_url,omitempty"`
+
+	// Username names the user's public profile, /u/{username}: unique and lowercase, chosen from
+	// the provider login or email at the first sign-in
+	Username string `bson:"username,omitempty" json:"username,omitempty"`
+	// ProfileHidden disables the public profile, which lists the user's public pastes
+	ProfileHidden bool `bson:"profile_hidden,omitempty" json:"profile_hidden"`
+	// EmailDigest opts the user in to emails listing their unread notifications
+	EmailDigest bool `bson:"email_digest,omitempty" json:"email_digest"`
+
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	LastLoginAt time.Time `bson:"last_login_at" json:"last_login_at"`
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/timing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// CommentCollectionName is the MongoDB collection name for comments on pastes
+	CommentCollectionName = "comments"
+)
+
+// CommentRepository handles comments on pastes
+type CommentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewCommentRepository creates a new CommentRepository
+func NewCommentRepository(db *mongo.Database) (*CommentRepository, error) {
+	repo := &CommentRepository{
+		collection: db.Collection(CommentCollectionName),
+	}
+
+	// Create indexes
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// createIndexes creates the required indexes for the comments collection
+func (r *CommentRepository) createIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "comment_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "short_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "comment_id", Value: -1}},
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Create stores a comment, giving it a new ID when it has none
+func (r *CommentRepository) Create(ctx context.Context, comment *model.Comment) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	if comment.ID == "" {
+		comment.ID = newID()
+	}
+	_, err := r.collection.InsertOne(ctx, comment)
+	return err
+}
+
+// ListByShortID returns up to limit comments on a paste after the cursor (zero for the newest),
+// newest first
+func (r *CommentRepository) ListByShortID(ctx context.Context, shortID string, after PageCursor, limit int) ([]*model.Comment, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := pageFilter(bson.M{"short_id": shortID}, after, "comment_id", after.ID)
+	opts := options.Find().SetSort(pageSort("comment_id")).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []*model.Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// DeleteAll removes all comments from the collection (for testing)
+func (r *CommentRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{})
+	return err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be parsed
+var ErrInvalidCursor = errors.New("repository: invalid page cursor")
+
+// PageCursor marks where a page of a listing sorted newest first ends: the creation time and ID
+// of its last item. The ID orders items created in the same millisecond, so none is skipped or
+// listed twice across pages.
+type PageCursor struct {
+	CreatedAt time.Time
+	// ID is the hex ObjectID of a paste, or the ID of a comment or notification. A cursor given
+	// as a time only has none and lists what was created strictly before it.
+	ID string
+}
+
+// ParsePageCursor parses a cursor formatted by PageCursor.String, or an RFC 3339 time
+func ParsePageCursor(s string) (PageCursor, error) {
+	raw, id, _ := strings.Cut(s, "_")
+	createdAt, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return PageCursor{}, ErrInvalidCursor
+	}
+	if id != "" {
+		if _, err := primitive.ObjectIDFromHex(id); err != nil {
+			return PageCursor{}, ErrInvalidCursor
+		}
+	}
+	return PageCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// String formats the cursor as its RFC 3339 time and ID separated by an underscore
+func (c PageCursor) String() string {
+	if c.CreatedAt.IsZero() {
+		return ""
+	}
+	s := c.CreatedAt.UTC().Format(time.RFC3339Nano)
+	if c.ID != "" {
+		s += "_" + c.ID
+	}
+	return s
+}
+
+// IsZero reports whether the cursor is unset, listing from the newest item
+func (c PageCursor) IsZero() bool {
+	return c.CreatedAt.IsZero()
+}
+
+// pageSort sorts a listing newest first, by creation time then ID
+func pageSort(idField string) bson.D {
+	return bson.D{{Key: "created_at", Value: -1}, {Key: idField, Value: -1}}
+}
+
+// pageFilter restricts filter to the items after the cursor in the order of pageSort. id is the
+// cursor's ID as stored in idField.
+func pageFilter(filter bson.M, cursor PageCursor, idField string, id any) bson.M {
+	if cursor.IsZero() {
+		return filter
+	}
+	if cursor.ID == "" {
+		filter["created_at"] = bson.M{"$lt": cursor.CreatedAt}
+		return filter
+	}
+	after := bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{"$lt": cursor.CreatedAt}},
+		bson.M{"created_at": cursor.CreatedAt, idField: bson.M{"$lt": id}},
+	}}
+	// The filter may have an $or of its own
+	return bson.M{"$and": bson.A{filter, after}}
+}
+
+// pasteCursorID returns the _id of the paste a cursor ends at
+func pasteCursorID(cursor PageCursor) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(cursor.ID)
+	return id
+}
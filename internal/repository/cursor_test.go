@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePageCursor(t *testing.T) {
+	createdAt := time.Date(2024, 1, 15, 14, 0, 0, 123_000_000, time.UTC)
+	cursor := PageCursor{CreatedAt: createdAt, ID: "65a5496c8f1d2e3a4b5c6d7e"}
+	if got := cursor.String(); got != "2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e" {
+		t.Errorf("String() = %q", got)
+	}
+
+	tests := []struct {
+		raw     string
+		want    PageCursor
+		wantErr bool
+	}{
+		{raw: cursor.String(), want: cursor},
+		// A time alone still works, as in links made before cursors had an ID
+		{raw: "2024-01-15T14:00:00.123Z", want: PageCursor{CreatedAt: createdAt}},
+		{raw: "2024-01-15T14:00:00.123Z_nothex", wantErr: true},
+		{raw: "yesterday", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePageCursor(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePageCursor(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (!got.CreatedAt.Equal(tt.want.CreatedAt) || got.ID != tt.want.ID) {
+			t.Errorf("ParsePageCursor(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+
+	if (PageCursor{}).String() != "" || !(PageCursor{}).IsZero() {
+		t.Error("zero cursor should format empty")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
 )
 
 // MongoDB wraps the MongoDB client and database
@@ -20,15 +21,17 @@ func NewMongoClient(ctx context.Context, uri, dbName string) (*MongoDB, error) {
 	clientOptions := options.Client().
 		ApplyURI(uri).
 		SetConnectTimeout(10 * time.Second).
-		SetServerSelectionTimeout(5 * time.Second)
+		SetServerSelectionTimeout(5 * time.Second).
+		SetMonitor(otelmongo.NewMonitor()) // a span per command
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
 	}
 
-	// Verify connection
+	// Verify connection, not leaking the client of a failed attempt
 	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		_ = client.Disconnect(context.WithoutCancel(ctx))
 		return nil, err
 	}
 
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/timing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// NotificationCollectionName is the MongoDB collection name for the users' notifications
+	NotificationCollectionName = "notifications"
+	// NotificationTTL is the time notifications are kept, read or not
+	NotificationTTL = 90 * 24 * time.Hour
+)
+
+// NotificationRepository handles the notifications of users
+type NotificationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewNotificationRepository creates a new NotificationRepository
+func NewNotificationRepository(db *mongo.Database) (*NotificationRepository, error) {
+	repo := &NotificationRepository{
+		collection: db.Collection(NotificationCollectionName),
+	}
+
+	// Create indexes
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// createIndexes creates the required indexes for the notifications collection. The TTL index
+// drops notifications after NotificationTTL so inboxes do not grow forever.
+func (r *NotificationRepository) createIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "notification_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "notification_id", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(NotificationTTL.Seconds())),
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// CreateMany stores notifications, giving a new ID to those that have none
+func (r *NotificationRepository) CreateMany(ctx context.Context, notifications []*model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	docs := make([]interface{}, len(notifications))
+	for i, n := range notifications {
+		if n.ID == "" {
+			n.ID = newID()
+		}
+		docs[i] = n
+	}
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+// ListByUser returns up to limit notifications of a user after the cursor (zero for the newest),
+// newest first, only the unread ones when unreadOnly is set
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID string, unreadOnly bool, after PageCursor, limit int) ([]*model.Notification, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read_at"] = bson.M{"$exists": false}
+	}
+	filter = pageFilter(filter, after, "notification_id", after.ID)
+	opts := options.Find().SetSort(pageSort("notification_id")).SetLimit(int64(limit))
+	return r.find(ctx, filter, opts)
+}
+
+// CountUnread counts the unread notifications of a user
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID string) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	return r.collection.CountDocuments(ctx, bson.M{"user_id": userID, "read_at": bson.M{"$exists": false}})
+}
+
+// MarkRead marks the given unread notifications of a user read, all of them when ids is nil, and
+// returns the number marked
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID string, ids []string, now time.Time) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"user_id": userID, "read_at": bson.M{"$exists": false}}
+	if ids != nil {
+		filter["notification_id"] = bson.M{"$in": ids}
+	}
+	result, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"read_at": now}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// ListDigestUsers returns the IDs of the users with unread notifications not sent in a digest yet
+func (r *NotificationRepository) ListDigestUsers(ctx context.Context) ([]string, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	values, err := r.collection.Distinct(ctx, "user_id", undigestedFilter(""))
+	if err != nil {
+		return nil, err
+	}
+	userIDs := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			userIDs = append(userIDs, id)
+		}
+	}
+	return userIDs, nil
+}
+
+// ListUndigested returns up to limit unread notifications of a user not sent in a digest yet,
+// oldest first
+func (r *NotificationRepository) ListUndigested(ctx context.Context, userID string, limit int) ([]*model.Notification, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(int64(limit))
+	return r.find(ctx, undigestedFilter(userID), opts)
+}
+
+// MarkDigested records that the given notifications were sent in a digest, so the next digest
+// leaves them out
+func (r *NotificationRepository) MarkDigested(ctx context.Context, ids []string, now time.Time) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	_, err := r.collection.UpdateMany(ctx, bson.M{"notification_id": bson.M{"$in": ids}}, bson.M{"$set": bson.M{"digested_at": now}})
+	return err
+}
+
+// DeleteAll removes all notifications from the collection (for testing)
+func (r *NotificationRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{})
+	return err
+}
+
+// undigestedFilter matches the unread notifications not sent in a digest, of one user unless
+// userID is empty
+func undigestedFilter(userID string) bson.M {
+	filter := bson.M{
+		"read_at":     bson.M{"$exists": false},
+		"digested_at": bson.M{"$exists": false},
+	}
+	if userID != "" {
+		filter["user_id"] = userID
+	}
+	return filter
+}
+
+// find returns the notifications matching filter
+func (r *NotificationRepository) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]*model.Notification, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []*model.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
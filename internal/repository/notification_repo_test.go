@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestNotificationRepository(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+	defer func() { _ = db.Collection(NotificationCollectionName).Drop(context.Background()) }()
+
+	repo, err := NewNotificationRepository(db)
+	if err != nil {
+		t.Fatalf("NewNotificationRepository() error = %v", err)
+	}
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Millisecond)
+	var notifications []*model.Notification
+	for i := 0; i < 3; i++ {
+		notifications = append(notifications, &model.Notification{
+			UserID:    "user-1",
+			Kind:      model.NotificationComment,
+			ShortID:   "abc123",
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+		})
+	}
+	notifications = append(notifications, &model.Notification{UserID: "user-2", Kind: model.NotificationMention, CreatedAt: now})
+	if err := repo.CreateMany(ctx, notifications); err != nil {
+		t.Fatalf("CreateMany() error = %v", err)
+	}
+
+	page, err := repo.ListByUser(ctx, "user-1", false, PageCursor{}, 2)
+	if err != nil || len(page) != 2 || page[0].ID != notifications[2].ID {
+		t.Fatalf("ListByUser() = %+v, %v, want the 2 newest notifications of user-1", page, err)
+	}
+	older, err := repo.ListByUser(ctx, "user-1", false, PageCursor{CreatedAt: page[1].CreatedAt, ID: page[1].ID}, 2)
+	if err != nil || len(older) != 1 || older[0].ID != notifications[0].ID {
+		t.Errorf("ListByUser(before) = %+v, %v, want the oldest notification", older, err)
+	}
+
+	// Marking is limited to the user's own notifications
+	if marked, err := repo.MarkRead(ctx, "user-1", []string{notifications[0].ID, notifications[3].ID}, now); err != nil || marked != 1 {
+		t.Errorf("MarkRead() = %d, %v, want 1", marked, err)
+	}
+	if unread, err := repo.CountUnread(ctx, "user-1"); err != nil || unread != 2 {
+		t.Errorf("CountUnread() = %d, %v, want 2", unread, err)
+	}
+	unread, err := repo.ListByUser(ctx, "user-1", true, PageCursor{}, 10)
+	if err != nil || len(unread) != 2 {
+		t.Errorf("ListByUser(unread) = %+v, %v, want 2", unread, err)
+	}
+
+	users, err := repo.ListDigestUsers(ctx)
+	if err != nil || len(users) != 2 {
+		t.Errorf("ListDigestUsers() = %v, %v, want both users", users, err)
+	}
+	undigested, err := repo.ListUndigested(ctx, "user-1", 10)
+	if err != nil || len(undigested) != 2 || undigested[0].ID != notifications[1].ID {
+		t.Fatalf("ListUndigested() = %+v, %v, want the unread notifications oldest first", undigested, err)
+	}
+	if err := repo.MarkDigested(ctx, []string{undigested[0].ID, undigested[1].ID}, now); err != nil {
+		t.Fatalf("MarkDigested() error = %v", err)
+	}
+	if users, err := repo.ListDigestUsers(ctx); err != nil || len(users) != 1 || users[0] != "user-2" {
+		t.Errorf("ListDigestUsers() after a digest = %v, %v, want user-2", users, err)
+	}
+
+	if marked, err := repo.MarkRead(ctx, "user-1", nil, now); err != nil || marked != 2 {
+		t.Errorf("MarkRead(all) = %d, %v, want 2", marked, err)
+	}
+}
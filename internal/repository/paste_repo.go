@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/timing"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -50,20 +51,84 @@ func (r *PasteRepository) createIndexes(ctx context.Context) error {
 			Options: options.Index().SetUnique(true),
 		},
 		{
+			// MongoDB removes expired pastes itself; the cleanup worker only reconciles storage
 			Keys:    bson.D{{Key: "expires_at", Value: 1}},
-			Options: options.Index().SetSparse(true),
+			Options: options.Index().SetSparse(true).SetExpireAfterSeconds(0),
 		},
 		{
 			Keys: bson.D{{Key: "created_at", Value: -1}},
 		},
+		{
+			Keys:    bson.D{{Key: "source_ip_hash", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "tags", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "owner_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "output_of", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "content_hash", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "last_read_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "group_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "quarantine.at", Value: -1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "screening.at", Value: -1}},
+			Options: options.Index().SetSparse(true),
+		},
 	}
 
 	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	if isIndexConflict(err) {
+		// Deployments predating the TTL index have a plain index on expires_at with the same name
+		if _, dropErr := r.collection.Indexes().DropOne(ctx, expiresAtIndexName); dropErr != nil {
+			return dropErr
+		}
+		_, err = r.collection.Indexes().CreateMany(ctx, indexes)
+	}
 	return err
 }
 
+// expiresAtIndexName is the name MongoDB gives the index on expires_at
+const expiresAtIndexName = "expires_at_1"
+
+// isIndexConflict reports whether an index could not be created because one with the same
+// name or keys but different options already exists
+func isIndexConflict(err error) bool {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	// IndexOptionsConflict, IndexKeySpecsConflict
+	return cmdErr.Code == 85 || cmdErr.Code == 86
+}
+
 // Create creates a new paste in the database
 func (r *PasteRepository) Create(ctx context.Context, paste *model.Paste) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
 	_, err := r.collection.InsertOne(ctx, paste)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
@@ -76,6 +141,8 @@ func (r *PasteRepository) Create(ctx context.Context, paste *model.Paste) error
 
 // GetByShortID retrieves a paste by its short ID
 func (r *PasteRepository) GetByShortID(ctx context.Context, shortID string) (*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
 	var paste model.Paste
 	err := r.collection.FindOne(ctx, bson.M{"short_id": shortID}).Decode(&paste)
 	if err != nil {
@@ -87,8 +154,254 @@ func (r *PasteRepository) GetByShortID(ctx context.Context, shortID string) (*mo
 	return &paste, nil
 }
 
+// caseInsensitiveCollation compares short IDs regardless of case
+var caseInsensitiveCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// EnsureCaseInsensitiveIndex creates the index FindShortIDsIgnoreCase needs
+func (r *PasteRepository) EnsureCaseInsensitiveIndex(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "short_id", Value: 1}},
+		Options: options.Index().SetName("short_id_ci").SetCollation(caseInsensitiveCollation),
+	})
+	return err
+}
+
+// FindShortIDsIgnoreCase returns up to limit short IDs equal to shortID regardless of case
+func (r *PasteRepository) FindShortIDsIgnoreCase(ctx context.Context, shortID string, limit int64) ([]string, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	opts := options.Find().
+		SetCollation(caseInsensitiveCollation).
+		SetProjection(bson.M{"short_id": 1}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{"short_id": shortID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pastes []*model.Paste
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	shortIDs := make([]string, len(pastes))
+	for i, paste := range pastes {
+		shortIDs[i] = paste.ShortID
+	}
+	return shortIDs, nil
+}
+
+// ExistingShortIDs returns which of the given short IDs still have a paste record
+func (r *PasteRepository) ExistingShortIDs(ctx context.Context, shortIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(shortIDs))
+	if len(shortIDs) == 0 {
+		return existing, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"short_id": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"short_id": bson.M{"$in": shortIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pastes []*model.Paste
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	for _, paste := range pastes {
+		existing[paste.ShortID] = true
+	}
+	return existing, nil
+}
+
+// GetByShortIDs retrieves the pastes with the given short IDs; missing ones are omitted
+func (r *PasteRepository) GetByShortIDs(ctx context.Context, shortIDs []string) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	pastes := []*model.Paste{}
+	if len(shortIDs) == 0 {
+		return pastes, nil
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"short_id": bson.M{"$in": shortIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// ListOutputs retrieves up to limit readable pastes holding the output of the given paste, newest
+// first. Pending, burned, expired and self-destructing outputs are skipped, and private ones
+// unless includePrivate is set.
+func (r *PasteRepository) ListOutputs(ctx context.Context, shortID string, includePrivate bool, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{
+		"output_of":       shortID,
+		"upload":          bson.M{"$exists": false},
+		"burned_at":       bson.M{"$exists": false},
+		"burn_after_read": false,
+		"max_views":       bson.M{"$not": bson.M{"$gt": 0}},
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": time.Now()}},
+		},
+	}
+	if !includePrivate {
+		filter["is_private"] = false
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// ListCreatedBetween retrieves up to limit pastes created in [from, to), oldest first,
+// optionally only those created from the given source IP hash or carrying the given tag
+func (r *PasteRepository) ListCreatedBetween(ctx context.Context, from, to time.Time, sourceIPHash, tag string, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"created_at": bson.M{"$gte": from, "$lt": to}}
+	if sourceIPHash != "" {
+		filter["source_ip_hash"] = sourceIPHash
+	}
+	if tag != "" {
+		filter["tags"] = tag
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "short_id", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// ExpireSelfDestructingByOwner expires the unread burn-after-read and view-limited pastes of an
+// owner at once and returns their short IDs, so readers get ErrPasteNotFound before they are deleted
+func (r *PasteRepository) ExpireSelfDestructingByOwner(ctx context.Context, ownerID string, now time.Time) ([]string, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{
+		"owner_id":  ownerID,
+		"burned_at": bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"burn_after_read": true},
+			bson.M{"max_views": bson.M{"$gt": 0}},
+		},
+	}
+	opts := options.Find().SetProjection(bson.M{"short_id": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pastes []*model.Paste
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	shortIDs := make([]string, 0, len(pastes))
+	for _, paste := range pastes {
+		shortIDs = append(shortIDs, paste.ShortID)
+	}
+	if len(shortIDs) == 0 {
+		return shortIDs, nil
+	}
+
+	// Marked as burned so the claim of a concurrent reader fails
+	_, err = r.collection.UpdateMany(ctx,
+		bson.M{"short_id": bson.M{"$in": shortIDs}},
+		bson.M{"$set": bson.M{"expires_at": now, "burned_at": now}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return shortIDs, nil
+}
+
+// staleFilter selects the readable pastes that never expire and were not read since cutoff.
+// Pastes never read, or read before read times were recorded, count from their creation.
+func staleFilter(cutoff time.Time) bson.M {
+	return bson.M{
+		"expires_at": nil,
+		"upload":     bson.M{"$exists": false},
+		"$or": bson.A{
+			bson.M{"last_read_at": bson.M{"$lt": cutoff}},
+			bson.M{"last_read_at": nil, "created_at": bson.M{"$lt": cutoff}},
+		},
+	}
+}
+
+// ListStale retrieves up to limit pastes that never expire and were not read since cutoff,
+// least recently created first
+func (r *PasteRepository) ListStale(ctx context.Context, cutoff time.Time, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "short_id", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, staleFilter(cutoff), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// CountStale counts the pastes that never expire and were not read since cutoff
+func (r *PasteRepository) CountStale(ctx context.Context, cutoff time.Time) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	return r.collection.CountDocuments(ctx, staleFilter(cutoff))
+}
+
+// ExpireStale expires at now the pastes that never expire and were not read since cutoff, and
+// returns how many. A paste read since the report listing it is left alone.
+func (r *PasteRepository) ExpireStale(ctx context.Context, cutoff, now time.Time) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	result, err := r.collection.UpdateMany(ctx, staleFilter(cutoff), bson.M{"$set": bson.M{"expires_at": now}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
 // Delete removes a paste by its short ID
 func (r *PasteRepository) Delete(ctx context.Context, shortID string) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
 	result, err := r.collection.DeleteOne(ctx, bson.M{"short_id": shortID})
 	if err != nil {
 		return err
@@ -99,6 +412,244 @@ func (r *PasteRepository) Delete(ctx context.Context, shortID string) error {
 	return nil
 }
 
+// ListGroup retrieves up to limit pastes of a group, in short ID order. Callers skip the pending
+// and expired ones.
+func (r *PasteRepository) ListGroup(ctx context.Context, groupID string, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "short_id", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{"group_id": groupID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// ListByUser retrieves up to limit pastes created by a signed-in user, newest first, starting
+// after the cursor when it is not zero
+func (r *PasteRepository) ListByUser(ctx context.Context, userID string, after PageCursor, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := pageFilter(bson.M{"user_id": userID}, after, "_id", pasteCursorID(after))
+
+	opts := options.Find().
+		SetSort(pageSort("_id")).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// ListByContentHash retrieves up to limit readable pastes whose content has the given hash,
+// newest first. Given an owner or user ID, only the pastes of that owner or user are listed;
+// given neither, all pastes are.
+func (r *PasteRepository) ListByContentHash(ctx context.Context, hash, ownerID, userID string, now time.Time, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	conditions := bson.A{
+		// Expired pastes linger until the TTL monitor removes them
+		bson.M{"$or": bson.A{
+			bson.M{"expires_at": nil},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		}},
+	}
+	var owners bson.A
+	if ownerID != "" {
+		owners = append(owners, bson.M{"owner_id": ownerID})
+	}
+	if userID != "" {
+		owners = append(owners, bson.M{"user_id": userID})
+	}
+	if len(owners) > 0 {
+		conditions = append(conditions, bson.M{"$or": owners})
+	}
+	filter := bson.M{
+		"content_hash": hash,
+		"burned_at":    bson.M{"$exists": false},
+		"upload":       bson.M{"$exists": false},
+		"$and":         conditions,
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// FilterByUser returns the short IDs among shortIDs of the pastes created by a signed-in user
+func (r *PasteRepository) FilterByUser(ctx context.Context, userID string, shortIDs []string) ([]string, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"user_id": userID, "short_id": bson.M{"$in": shortIDs}}
+	opts := options.Find().SetProjection(bson.M{"short_id": 1})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var pastes []*model.Paste
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	owned := make([]string, 0, len(pastes))
+	for _, paste := range pastes {
+		owned = append(owned, paste.ShortID)
+	}
+	return owned, nil
+}
+
+// publicUserFilter selects the pastes of a user listed on their public profile: readable, not
+// private and not self-destructing, as listing them would let anyone burn them
+func publicUserFilter(userID string, now time.Time) bson.M {
+	return bson.M{
+		"user_id":         userID,
+		"is_private":      false,
+		"upload":          bson.M{"$exists": false},
+		"burned_at":       bson.M{"$exists": false},
+		"burn_after_read": false,
+		"max_views":       bson.M{"$not": bson.M{"$gt": 0}},
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$exists": false}},
+			bson.M{"expires_at": bson.M{"$gt": now}},
+		},
+	}
+}
+
+// ListPublicByUser retrieves up to limit pastes listed on a user's public profile, newest first,
+// starting after the cursor when it is not zero. Pinned pastes are listed apart.
+func (r *PasteRepository) ListPublicByUser(ctx context.Context, userID string, after PageCursor, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := publicUserFilter(userID, time.Now())
+	filter["pinned_at"] = bson.M{"$exists": false}
+	filter = pageFilter(filter, after, "_id", pasteCursorID(after))
+
+	opts := options.Find().
+		SetSort(pageSort("_id")).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// ListPinnedByUser retrieves up to limit pastes pinned to a user's public profile, in the order
+// they were pinned
+func (r *PasteRepository) ListPinnedByUser(ctx context.Context, userID string, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := publicUserFilter(userID, time.Now())
+	filter["pinned_at"] = bson.M{"$exists": true}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "pinned_at", Value: 1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// CountPinnedByUser returns the number of pastes pinned to a user's public profile
+func (r *PasteRepository) CountPinnedByUser(ctx context.Context, userID string) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := publicUserFilter(userID, time.Now())
+	filter["pinned_at"] = bson.M{"$exists": true}
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+// SetPinned pins a paste created by a user to their profile, or unpins it when pinnedAt is nil
+func (r *PasteRepository) SetPinned(ctx context.Context, shortID, userID string, pinnedAt *time.Time) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	update := bson.M{"$unset": bson.M{"pinned_at": ""}}
+	if pinnedAt != nil {
+		update = bson.M{"$set": bson.M{"pinned_at": *pinnedAt}}
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"short_id": shortID, "user_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// CountPublicByUser returns the number of pastes listed on a user's public profile and the sum of
+// their views
+func (r *PasteRepository) CountPublicByUser(ctx context.Context, userID string) (pastes, views int64, err error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: publicUserFilter(userID, time.Now())}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    nil,
+			"pastes": bson.M{"$sum": 1},
+			"views":  bson.M{"$sum": "$view_count"},
+		}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		Pastes int64 `bson:"pastes"`
+		Views  int64 `bson:"views"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return 0, 0, err
+	}
+	if len(totals) == 0 {
+		return 0, 0, nil
+	}
+	return totals[0].Pastes, totals[0].Views, nil
+}
+
 // GetExpired retrieves all pastes that have expired
 func (r *PasteRepository) GetExpired(ctx context.Context) ([]*model.Paste, error) {
 	cursor, err := r.collection.Find(ctx, bson.M{
@@ -120,25 +671,360 @@ func (r *PasteRepository) GetExpired(ctx context.Context) ([]*model.Paste, error
 	return pastes, nil
 }
 
-// GetExpiredBatch retrieves expired pastes in batches for efficient cleanup
-func (r *PasteRepository) GetExpiredBatch(ctx context.Context, limit int64) ([]*model.Paste, error) {
-	opts := options.Find().SetLimit(limit)
-	cursor, err := r.collection.Find(ctx, bson.M{
-		"expires_at": bson.M{
-			"$lt": time.Now(),
-			"$ne": nil,
+// UpdateUpload replaces the pending upload state of a paste
+func (r *PasteRepository) UpdateUpload(ctx context.Context, shortID string, upload *model.PendingUpload) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"short_id": shortID,
+		"upload":   bson.M{"$exists": true},
+	}, bson.M{"$set": bson.M{"upload": upload}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// ActivateUpload marks a pending upload as complete and applies its final expiration (nil means never).
+// It returns ErrPasteNotFound if no pending paste exists with the short ID.
+func (r *PasteRepository) ActivateUpload(ctx context.Context, shortID string, expiresAt *time.Time) error {
+	unset := bson.M{"upload": ""}
+	update := bson.M{}
+	if expiresAt != nil {
+		update["$set"] = bson.M{"expires_at": *expiresAt}
+	} else {
+		unset["expires_at"] = ""
+	}
+	update["$unset"] = unset
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"short_id": shortID,
+		"upload":   bson.M{"$exists": true},
+	}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// IncrementViews atomically counts a read of a paste and returns it with the new count.
+// Once a paste with max_views has been read that many times, it returns ErrPasteNotFound.
+func (r *PasteRepository) IncrementViews(ctx context.Context, shortID string) (*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{
+		"short_id": shortID,
+		"$expr": bson.M{"$or": bson.A{
+			bson.M{"$lte": bson.A{bson.M{"$ifNull": bson.A{"$max_views", 0}}, 0}},
+			bson.M{"$lt": bson.A{bson.M{"$ifNull": bson.A{"$view_count", 0}}, "$max_views"}},
+		}},
+	}
+	update := bson.M{
+		"$inc":         bson.M{"view_count": 1},
+		"$currentDate": bson.M{"last_read_at": true},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var paste model.Paste
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&paste); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, err
+	}
+	return &paste, nil
+}
+
+// UncountView reverts IncrementViews when the content could not be served
+func (r *PasteRepository) UncountView(ctx context.Context, shortID string) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"short_id": shortID, "view_count": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"view_count": -1}},
+	)
+	return err
+}
+
+// ClaimBurnAfterRead atomically marks an unread burn-after-read paste as read and returns it as it was
+// before the claim. Exactly one of several concurrent readers wins; the others get ErrPasteNotFound.
+// The claim also makes the paste expire after grace, so the cleanup worker removes it if the winner
+// fails to delete it.
+func (r *PasteRepository) ClaimBurnAfterRead(ctx context.Context, shortID string, grace time.Duration) (*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	now := time.Now()
+	filter := bson.M{
+		"short_id":        shortID,
+		"burn_after_read": true,
+		"burned_at":       bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"burned_at": now, "expires_at": now.Add(grace)}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var paste model.Paste
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&paste); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, err
+	}
+	return &paste, nil
+}
+
+// ReleaseBurnAfterRead reverts a claim whose content could not be served, restoring the
+// paste's expiration (nil means never) so it can still be read once
+func (r *PasteRepository) ReleaseBurnAfterRead(ctx context.Context, shortID string, expiresAt *time.Time) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	unset := bson.M{"burned_at": ""}
+	update := bson.M{}
+	if expiresAt != nil {
+		update["$set"] = bson.M{"expires_at": *expiresAt}
+	} else {
+		unset["expires_at"] = ""
+	}
+	update["$unset"] = unset
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"short_id": shortID}, update)
+	return err
+}
+
+// UpdateContent applies an edit to a paste. The update only matches while the paste is still at
+// expectedRevision, so an edit based on a stale version returns ErrPasteNotFound instead of winning.
+func (r *PasteRepository) UpdateContent(ctx context.Context, shortID string, expectedRevision int, set bson.M) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"short_id": shortID, "revision": expectedRevision}
+	if expectedRevision == 0 {
+		// Never edited: the field is omitted
+		filter["revision"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// LockAppend takes the append lock of a live paste until until and returns the paste as locked.
+// It returns ErrPasteNotFound when the paste is gone, not live, or locked by another append.
+func (r *PasteRepository) LockAppend(ctx context.Context, shortID, token string, until time.Time) (*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{
+		"short_id": shortID,
+		"live":     true,
+		"$or": bson.A{
+			bson.M{"append_lock": bson.M{"$exists": false}},
+			bson.M{"append_lock.until": bson.M{"$lt": time.Now()}},
 		},
-	}, opts)
+	}
+	update := bson.M{"$set": bson.M{"append_lock": model.AppendLock{Token: token, Until: until}}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var paste model.Paste
+	if err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&paste); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, err
+	}
+	return &paste, nil
+}
+
+// UnlockAppend releases the append lock taken with token, applying set when the append succeeded.
+// It returns ErrPasteNotFound when the lock expired and another append took it.
+func (r *PasteRepository) UnlockAppend(ctx context.Context, shortID, token string, set bson.M) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	update := bson.M{"$unset": bson.M{"append_lock": ""}}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"short_id": shortID, "append_lock.token": token}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// SetLint stores the lint result of a paste, unless the paste was edited past the linted revision
+func (r *PasteRepository) SetLint(ctx context.Context, shortID string, lint *model.LintResult) error {
+	filter := bson.M{"short_id": shortID, "revision": lint.Revision}
+	if lint.Revision == 0 {
+		filter["revision"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"lint": lint}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// SetContentHash records the content hash of a paste that has none yet. A paste edited since
+// its content was read already has the hash of the new content and is left alone.
+func (r *PasteRepository) SetContentHash(ctx context.Context, shortID, hash string) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{
+		"short_id":     shortID,
+		"content_hash": bson.M{"$exists": false},
+	}, bson.M{"$set": bson.M{"content_hash": hash}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// SetArchived marks the content of a paste as archived by the storage at archivedAt, or as
+// readable again when archivedAt is nil
+func (r *PasteRepository) SetArchived(ctx context.Context, shortID string, archivedAt *time.Time) error {
+	update := bson.M{"$unset": bson.M{"archived_at": ""}}
+	if archivedAt != nil {
+		update = bson.M{"$set": bson.M{"archived_at": *archivedAt}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"short_id": shortID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// SetQuarantine quarantines a paste, or releases it when quarantine is nil
+func (r *PasteRepository) SetQuarantine(ctx context.Context, shortID string, quarantine *model.Quarantine) error {
+	update := bson.M{"$unset": bson.M{"quarantine": ""}}
+	if quarantine != nil {
+		update = bson.M{"$set": bson.M{"quarantine": quarantine}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"short_id": shortID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// ListQuarantined retrieves up to limit quarantined pastes, most recently quarantined first
+func (r *PasteRepository) ListQuarantined(ctx context.Context, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "quarantine.at", Value: -1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{"quarantine": bson.M{"$exists": true}}, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var pastes []*model.Paste
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
+	return pastes, nil
+}
+
+// CountQuarantined returns the number of quarantined pastes
+func (r *PasteRepository) CountQuarantined(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"quarantine": bson.M{"$exists": true}})
+}
+
+// ListScreened retrieves up to limit pastes flagged or quarantined by content screening, most
+// recently screened first
+func (r *PasteRepository) ListScreened(ctx context.Context, limit int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "screening.at", Value: -1}}).
+		SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{"screening": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	pastes := []*model.Paste{}
 	if err := cursor.All(ctx, &pastes); err != nil {
 		return nil, err
 	}
+	return pastes, nil
+}
+
+// CountScreened returns the number of pastes flagged or quarantined by content screening
+func (r *PasteRepository) CountScreened(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"screening": bson.M{"$exists": true}})
+}
+
+// DismissScreening clears the screening of a paste, and its quarantine if release is set
+func (r *PasteRepository) DismissScreening(ctx context.Context, shortID string, release bool) error {
+	unset := bson.M{"screening": ""}
+	if release {
+		unset["quarantine"] = ""
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"short_id": shortID}, bson.M{"$unset": unset})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPasteNotFound
+	}
+	return nil
+}
+
+// SamplePastes retrieves up to size pastes picked at random among those whose content is
+// readable from storage: not expired, pending, burned, archived or already quarantined
+func (r *PasteRepository) SamplePastes(ctx context.Context, now time.Time, size int64) ([]*model.Paste, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"upload":      bson.M{"$exists": false},
+			"burned_at":   bson.M{"$exists": false},
+			"archived_at": bson.M{"$exists": false},
+			"quarantine":  bson.M{"$exists": false},
+			"$or": bson.A{
+				bson.M{"expires_at": nil},
+				bson.M{"expires_at": bson.M{"$gt": now}},
+			},
+		}}},
+		{{Key: "$sample", Value: bson.M{"size": size}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
+	pastes := []*model.Paste{}
+	if err := cursor.All(ctx, &pastes); err != nil {
+		return nil, err
+	}
 	return pastes, nil
 }
 
@@ -163,7 +1049,39 @@ func (r *PasteRepository) Count(ctx context.Context) (int64, error) {
 	return r.collection.CountDocuments(ctx, bson.M{})
 }
 
-// CountExpired returns the number of expired pastes
+// DailyCount is the number of pastes created on a UTC day
+type DailyCount struct {
+	Date  string `bson:"_id"` // YYYY-MM-DD
+	Count int64  `bson:"count"`
+}
+
+// CountCreatedPerDay returns the number of stored pastes created on each UTC day since a time,
+// oldest first. Days without pastes are omitted.
+func (r *PasteRepository) CountCreatedPerDay(ctx context.Context, since time.Time) ([]DailyCount, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"created_at": bson.M{"$gte": since}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$created_at"}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := []DailyCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// CountExpired returns the number of expired pastes the TTL monitor has not removed yet
 func (r *PasteRepository) CountExpired(ctx context.Context) (int64, error) {
 	return r.collection.CountDocuments(ctx, bson.M{
 		"expires_at": bson.M{
@@ -173,6 +1091,34 @@ func (r *PasteRepository) CountExpired(ctx context.Context) (int64, error) {
 	})
 }
 
+// EnableChangeStreamPreImages makes MongoDB record the state of pastes before each change,
+// so change streams can report the short ID of deleted pastes (requires MongoDB 6.0+)
+func (r *PasteRepository) EnableChangeStreamPreImages(ctx context.Context) error {
+	return r.collection.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: r.collection.Name()},
+		{Key: "changeStreamPreAndPostImages", Value: bson.M{"enabled": true}},
+	}).Err()
+}
+
+// Watch opens a change stream of inserted, updated, replaced and deleted pastes, resuming
+// after the given token when it is not nil. Change streams require a replica set.
+func (r *PasteRepository) Watch(ctx context.Context, resumeToken bson.Raw) (*mongo.ChangeStream, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}},
+		}}},
+	}
+
+	opts := options.ChangeStream().
+		SetFullDocument(options.UpdateLookup).
+		SetFullDocumentBeforeChange(options.WhenAvailable)
+	if resumeToken != nil {
+		opts.SetResumeAfter(resumeToken)
+	}
+
+	return r.collection.Watch(ctx, pipeline, opts)
+}
+
 // DeleteAll removes all pastes from the collection (for testing)
 func (r *PasteRepository) DeleteAll(ctx context.Context) error {
 	_, err := r.collection.DeleteMany(ctx, bson.M{})
@@ -157,6 +157,36 @@ func TestPasteRepository_DuplicateShortID(t *testing.T) {
 	}
 }
 
+func TestPasteRepository_FindShortIDsIgnoreCase(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+
+	repo, err := NewPasteRepository(db)
+	if err != nil {
+		t.Fatalf("NewPasteRepository() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := repo.EnsureCaseInsensitiveIndex(ctx); err != nil {
+		t.Fatalf("EnsureCaseInsensitiveIndex() error = %v", err)
+	}
+
+	for _, shortID := range []string{"xK9a2B", "Dup001", "dUP001"} {
+		if err := repo.Create(ctx, &model.Paste{ShortID: shortID, CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("Create(%s) error = %v", shortID, err)
+		}
+	}
+
+	if got, err := repo.FindShortIDsIgnoreCase(ctx, "xk9a2b", 2); err != nil || len(got) != 1 || got[0] != "xK9a2B" {
+		t.Errorf("FindShortIDsIgnoreCase(xk9a2b) = %v, %v, want [xK9a2B]", got, err)
+	}
+	if got, err := repo.FindShortIDsIgnoreCase(ctx, "dup001", 2); err != nil || len(got) != 2 {
+		t.Errorf("FindShortIDsIgnoreCase(dup001) = %v, %v, want both colliding IDs", got, err)
+	}
+	if got, err := repo.FindShortIDsIgnoreCase(ctx, "none00", 2); err != nil || len(got) != 0 {
+		t.Errorf("FindShortIDsIgnoreCase(none00) = %v, %v, want none", got, err)
+	}
+}
+
 func TestPasteRepository_GetByShortID_NotFound(t *testing.T) {
 	db, cleanup := setupTestPasteDB(t)
 	defer cleanup()
@@ -464,4 +494,170 @@ func TestPasteRepository_WithUserID(t *testing.T) {
 	if !retrieved.IsPrivate {
 		t.Error("IsPrivate should be true")
 	}
-}
\ No newline at end of file
+
+	// It is listed with the user's pastes only
+	pastes, err := repo.ListByUser(ctx, userID, PageCursor{}, 10)
+	if err != nil {
+		t.Fatalf("ListByUser() error = %v", err)
+	}
+	if len(pastes) != 1 || pastes[0].ShortID != "withuser" {
+		t.Errorf("ListByUser() = %d pastes, want withuser", len(pastes))
+	}
+	if pastes, err := repo.ListByUser(ctx, "someone-else", PageCursor{}, 10); err != nil || len(pastes) != 0 {
+		t.Errorf("ListByUser() of another user = %d pastes, %v, want none", len(pastes), err)
+	}
+
+	// Private pastes are not on the user's public profile
+	public := &model.Paste{ShortID: "pubuser", UserID: &userID, ContentKey: "gisty/pubuser.gz", CreatedAt: time.Now(), SyntaxType: "text", ViewCount: 3}
+	burn := &model.Paste{ShortID: "burnuser", UserID: &userID, ContentKey: "gisty/burnuser.gz", CreatedAt: time.Now(), SyntaxType: "text", BurnAfterRead: true}
+	for _, p := range []*model.Paste{public, burn} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	pastes, err = repo.ListPublicByUser(ctx, userID, PageCursor{}, 10)
+	if err != nil || len(pastes) != 1 || pastes[0].ShortID != "pubuser" {
+		t.Errorf("ListPublicByUser() = %d pastes, %v, want pubuser", len(pastes), err)
+	}
+	if count, views, err := repo.CountPublicByUser(ctx, userID); err != nil || count != 1 || views != 3 {
+		t.Errorf("CountPublicByUser() = %d, %d, %v, want 1 paste and 3 views", count, views, err)
+	}
+}
+
+func TestPasteRepository_ListByUserCursor(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+
+	repo, err := NewPasteRepository(db)
+	if err != nil {
+		t.Fatalf("NewPasteRepository() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Pastes created in the same millisecond are neither skipped nor repeated across pages
+	userID := "user-cursor"
+	createdAt := time.Now().UTC().Truncate(time.Millisecond)
+	for _, shortID := range []string{"same1", "same2", "same3"} {
+		paste := &model.Paste{ShortID: shortID, UserID: &userID, ContentKey: "gisty/" + shortID + ".gz", CreatedAt: createdAt, SyntaxType: "text"}
+		if err := repo.Create(ctx, paste); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	seen := map[string]bool{}
+	var after PageCursor
+	for range 3 {
+		page, err := repo.ListByUser(ctx, userID, after, 2)
+		if err != nil {
+			t.Fatalf("ListByUser() error = %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, paste := range page {
+			if seen[paste.ShortID] {
+				t.Errorf("ListByUser() listed %s twice", paste.ShortID)
+			}
+			seen[paste.ShortID] = true
+		}
+		last := page[len(page)-1]
+		after = PageCursor{CreatedAt: last.CreatedAt, ID: last.ID.Hex()}
+	}
+	if len(seen) != 3 {
+		t.Errorf("ListByUser() pages listed %d pastes, want 3", len(seen))
+	}
+}
+
+func TestPasteRepository_ListByContentHash(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+
+	repo, err := NewPasteRepository(db)
+	if err != nil {
+		t.Fatalf("NewPasteRepository() error = %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now()
+	past := now.Add(-time.Minute)
+
+	userID := "user123"
+	for _, paste := range []*model.Paste{
+		{ShortID: "hashmine", ContentHash: "h1", OwnerID: "key:a", CreatedAt: now},
+		{ShortID: "hashuser", ContentHash: "h1", OwnerID: "session:b", UserID: &userID, CreatedAt: now},
+		{ShortID: "hashother", ContentHash: "h1", OwnerID: "key:c", CreatedAt: now},
+		{ShortID: "hashgone", ContentHash: "h1", OwnerID: "key:a", CreatedAt: now, ExpiresAt: &past},
+		{ShortID: "hashdiff", ContentHash: "h2", OwnerID: "key:a", CreatedAt: now},
+	} {
+		if err := repo.Create(ctx, paste); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name            string
+		ownerID, userID string
+		want            int
+	}{
+		{"owner", "key:a", "", 1},
+		{"owner or user", "key:a", userID, 2},
+		{"everyone", "", "", 3},
+	}
+	for _, tt := range tests {
+		pastes, err := repo.ListByContentHash(ctx, "h1", tt.ownerID, tt.userID, now, 10)
+		if err != nil || len(pastes) != tt.want {
+			t.Errorf("ListByContentHash(%s) = %d pastes, %v, want %d", tt.name, len(pastes), err, tt.want)
+		}
+	}
+}
+
+func TestPasteRepository_Stale(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+
+	repo, err := NewPasteRepository(db)
+	if err != nil {
+		t.Fatalf("NewPasteRepository() error = %v", err)
+	}
+	ctx := context.Background()
+	now := time.Now()
+	old := now.AddDate(0, 0, -200)
+	later := now.Add(time.Hour)
+
+	for _, paste := range []*model.Paste{
+		{ShortID: "staleold", CreatedAt: old},
+		{ShortID: "stalenew", CreatedAt: now},
+		{ShortID: "staleexp", CreatedAt: old, ExpiresAt: &later},
+	} {
+		if err := repo.Create(ctx, paste); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	// A recent read makes an old paste fresh again
+	if err := repo.Create(ctx, &model.Paste{ShortID: "staleread", CreatedAt: old}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := repo.IncrementViews(ctx, "staleread"); err != nil {
+		t.Fatalf("IncrementViews() error = %v", err)
+	}
+
+	cutoff := now.AddDate(0, 0, -180)
+	pastes, err := repo.ListStale(ctx, cutoff, 10)
+	if err != nil || len(pastes) != 1 || pastes[0].ShortID != "staleold" {
+		t.Fatalf("ListStale() = %d pastes, %v, want staleold only", len(pastes), err)
+	}
+	if count, err := repo.CountStale(ctx, cutoff); err != nil || count != 1 {
+		t.Errorf("CountStale() = %d, %v, want 1", count, err)
+	}
+
+	expired, err := repo.ExpireStale(ctx, cutoff, now)
+	if err != nil || expired != 1 {
+		t.Fatalf("ExpireStale() = %d, %v, want 1", expired, err)
+	}
+	paste, err := repo.GetByShortID(ctx, "staleold")
+	if err != nil || paste.ExpiresAt == nil {
+		t.Errorf("GetByShortID() after ExpireStale = %+v, %v, want an expiry", paste, err)
+	}
+	if count, err := repo.CountStale(ctx, cutoff); err != nil || count != 0 {
+		t.Errorf("CountStale() after ExpireStale = %d, %v, want 0", count, err)
+	}
+}
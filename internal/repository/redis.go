@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -19,9 +20,14 @@ func NewRedisClient(ctx context.Context, uri string) (*Redis, error) {
 	}
 
 	client := redis.NewClient(opt)
+	// A span per command and pipeline
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		return nil, err
+	}
 
-	// Verify connection
+	// Verify connection, not leaking the client of a failed attempt
 	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
 		return nil, err
 	}
 
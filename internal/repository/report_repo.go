@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/timing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ReportCollectionName is the MongoDB collection name for abuse reports of pastes
+	ReportCollectionName = "reports"
+)
+
+// ErrReportNotFound is returned when a report does not exist
+var ErrReportNotFound = errors.New("report: not found")
+
+// ReportRepository handles abuse reports of pastes
+type ReportRepository struct {
+	collection *mongo.Collection
+}
+
+// NewReportRepository creates a new ReportRepository
+func NewReportRepository(db *mongo.Database) (*ReportRepository, error) {
+	repo := &ReportRepository{
+		collection: db.Collection(ReportCollectionName),
+	}
+
+	// Create indexes
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// createIndexes creates the required indexes for the reports collection
+func (r *ReportRepository) createIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "report_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "report_id", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "short_id", Value: 1}, {Key: "resolved_at", Value: 1}},
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Create stores a report, giving it a new ID when it has none
+func (r *ReportRepository) Create(ctx context.Context, report *model.Report) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	if report.ID == "" {
+		report.ID = newID()
+	}
+	_, err := r.collection.InsertOne(ctx, report)
+	return err
+}
+
+// GetByID retrieves a report by its ID
+func (r *ReportRepository) GetByID(ctx context.Context, id string) (*model.Report, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	var report model.Report
+	err := r.collection.FindOne(ctx, bson.M{"report_id": id}).Decode(&report)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// reportFilter selects the open reports, the resolved ones, or all of them when resolved is nil
+func reportFilter(resolved *bool) bson.M {
+	if resolved == nil {
+		return bson.M{}
+	}
+	return bson.M{"resolved_at": bson.M{"$exists": *resolved}}
+}
+
+// List returns up to limit reports after the cursor (zero for the newest), newest first: the open
+// ones, the resolved ones, or all of them when resolved is nil
+func (r *ReportRepository) List(ctx context.Context, resolved *bool, after PageCursor, limit int) ([]*model.Report, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := pageFilter(reportFilter(resolved), after, "report_id", after.ID)
+	opts := options.Find().SetSort(pageSort("report_id")).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []*model.Report{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// CountOpen counts the reports awaiting review
+func (r *ReportRepository) CountOpen(ctx context.Context) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	return r.collection.CountDocuments(ctx, bson.M{"resolved_at": bson.M{"$exists": false}})
+}
+
+// HasOpen reports whether a paste has an open report from the source IP hash
+func (r *ReportRepository) HasOpen(ctx context.Context, shortID, sourceIPHash string) (bool, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{
+		"short_id":       shortID,
+		"source_ip_hash": sourceIPHash,
+		"resolved_at":    bson.M{"$exists": false},
+	}, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// resolveUpdate records the resolution of reports
+func resolveUpdate(resolution, note string, now time.Time) bson.M {
+	set := bson.M{"resolution": resolution, "resolved_at": now}
+	if note != "" {
+		set["note"] = note
+	}
+	return bson.M{"$set": set}
+}
+
+// Resolve resolves an open report; ErrReportNotFound is returned when it is not open
+func (r *ReportRepository) Resolve(ctx context.Context, id, resolution, note string, now time.Time) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"report_id": id, "resolved_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, resolveUpdate(resolution, note, now))
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrReportNotFound
+	}
+	return nil
+}
+
+// ResolveOpen resolves all the open reports of a paste and returns the number resolved
+func (r *ReportRepository) ResolveOpen(ctx context.Context, shortID, resolution, note string, now time.Time) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"short_id": shortID, "resolved_at": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateMany(ctx, filter, resolveUpdate(resolution, note, now))
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// DeleteAll removes all reports from the collection (for testing)
+func (r *ReportRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{})
+	return err
+}
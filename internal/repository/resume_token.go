@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ResumeTokenCollectionName is the MongoDB collection name for change stream resume tokens
+	ResumeTokenCollectionName = "change_stream_tokens"
+)
+
+// ResumeTokenStore persists the position of change stream consumers so they resume where
+// they stopped after a restart
+type ResumeTokenStore struct {
+	collection *mongo.Collection
+}
+
+// resumeTokenDocument is the stored position of one named consumer
+type resumeTokenDocument struct {
+	Name      string    `bson:"_id"`
+	Token     bson.Raw  `bson:"token"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// NewResumeTokenStore creates a new ResumeTokenStore
+func NewResumeTokenStore(db *mongo.Database) *ResumeTokenStore {
+	return &ResumeTokenStore{
+		collection: db.Collection(ResumeTokenCollectionName),
+	}
+}
+
+// Load returns the last saved resume token of the named consumer, or nil if it never saved one
+func (s *ResumeTokenStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	var doc resumeTokenDocument
+	err := s.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return doc.Token, nil
+}
+
+// Save records the resume token of the named consumer
+func (s *ResumeTokenStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	_, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": name},
+		bson.M{"$set": bson.M{"token": token, "updated_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Delete forgets the position of the named consumer, which then starts from the current time
+func (s *ResumeTokenStore) Delete(ctx context.Context, name string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": name})
+	return err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/timing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// RevisionCollectionName is the MongoDB collection name for previous versions of edited pastes
+	RevisionCollectionName = "paste_revisions"
+)
+
+var (
+	// ErrRevisionDuplicate is returned when a revision with the same number already exists for a paste
+	ErrRevisionDuplicate = errors.New("revision: duplicate revision")
+)
+
+// RevisionRepository handles paste revision records
+type RevisionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRevisionRepository creates a new RevisionRepository
+func NewRevisionRepository(db *mongo.Database) (*RevisionRepository, error) {
+	repo := &RevisionRepository{
+		collection: db.Collection(RevisionCollectionName),
+	}
+
+	// Create indexes
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// createIndexes creates the required indexes for the revisions collection.
+// The unique index makes concurrent edits of the same revision fail instead of overwriting each other.
+func (r *RevisionRepository) createIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "short_id", Value: 1}, {Key: "revision", Value: -1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// Create stores a revision record
+func (r *RevisionRepository) Create(ctx context.Context, revision *model.PasteRevision) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	_, err := r.collection.InsertOne(ctx, revision)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrRevisionDuplicate
+		}
+		return err
+	}
+	return nil
+}
+
+// ListByShortID returns the revisions of a paste, newest first
+func (r *RevisionRepository) ListByShortID(ctx context.Context, shortID string) ([]*model.PasteRevision, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	opts := options.Find().SetSort(bson.D{{Key: "revision", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"short_id": shortID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	revisions := []*model.PasteRevision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Delete removes a single revision record
+func (r *RevisionRepository) Delete(ctx context.Context, shortID string, revision int) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"short_id": shortID, "revision": revision})
+	return err
+}
+
+// DeleteByShortIDs removes every revision of the given pastes
+func (r *RevisionRepository) DeleteByShortIDs(ctx context.Context, shortIDs []string) (int64, error) {
+	if len(shortIDs) == 0 {
+		return 0, nil
+	}
+
+	result, err := r.collection.DeleteMany(ctx, bson.M{
+		"short_id": bson.M{"$in": shortIDs},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// DeleteAll removes all revisions from the collection (for testing)
+func (r *RevisionRepository) DeleteAll(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{})
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 )
 
 // S3Config holds S3 client configuration
@@ -51,6 +52,9 @@ func NewS3Client(ctx context.Context, cfg S3Config) (*S3, error) {
 		return nil, err
 	}
 
+	// A span per S3 call
+	otelaws.AppendMiddlewares(&awsCfg.APIOptions)
+
 	// Build S3 client options
 	s3Opts := []func(*s3.Options){}
 
@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/timing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// UserCollectionName is the MongoDB collection name for user accounts
+	UserCollectionName = "users"
+)
+
+var (
+	// ErrUserNotFound is returned when a user is not found
+	ErrUserNotFound = errors.New("user: not found")
+	// ErrUsernameTaken is returned when a username belongs to another user
+	ErrUsernameTaken = errors.New("user: username taken")
+	// ErrUserExists is returned when creating a user for a provider account that has one
+	ErrUserExists = errors.New("user: already exists")
+)
+
+// UserRepository handles user accounts
+type UserRepository struct {
+	collection *mongo.Collection
+}
+
+// NewUserRepository creates a new UserRepository
+func NewUserRepository(db *mongo.Database) (*UserRepository, error) {
+	repo := &UserRepository{
+		collection: db.Collection(UserCollectionName),
+	}
+
+	// Create indexes
+	if err := repo.createIndexes(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// createIndexes creates the required indexes for the users collection. The unique index on the
+// provider account makes concurrent first sign-ins of the same account create a single user; the
+// one on the username, sparse for the users who have none yet, keeps profile URLs unambiguous.
+func (r *UserRepository) createIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "provider_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+	}
+
+	_, err := r.collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
+// UpsertByProvider records a sign-in with a provider account: the user of that account gets the
+// profile of user and a new last login time, and is created with a new ID on the first sign-in.
+// It returns the stored user.
+func (r *UserRepository) UpsertByProvider(ctx context.Context, user *model.User, now time.Time) (*model.User, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"provider": user.Provider, "provider_id": user.ProviderID}
+	update := bson.M{
+		"$set": bson.M{
+			"login":         user.Login,
+			"name":          user.Name,
+			"email":         user.Email,
+			"avatar_url":    user.AvatarURL,
+			"last_login_at": now,
+		},
+		"$setOnInsert": bson.M{
+			"user_id":    newID(),
+			"created_at": now,
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored model.User
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stored)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent first sign-in created the user: update it instead
+		err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stored)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// Create stores a new user of a provider account, such as a password account, with a new ID. It
+// returns ErrUserExists when the account has a user.
+func (r *UserRepository) Create(ctx context.Context, user *model.User, now time.Time) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	user.ID = newID()
+	user.CreatedAt = now
+	user.LastLoginAt = now
+	if _, err := r.collection.InsertOne(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrUserExists
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByProvider retrieves the user of a provider account
+func (r *UserRepository) GetByProvider(ctx context.Context, provider, providerID string) (*model.User, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	var user model.User
+	err := r.collection.FindOne(ctx, bson.M{"provider": provider, "provider_id": providerID}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	var user model.User
+	err := r.collection.FindOne(ctx, bson.M{"user_id": id}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByUsername retrieves a user by username
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*model.User, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	var user model.User
+	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetUsername gives a username to a user who has none. It returns ErrUsernameTaken when another
+// user has it, and ErrUserNotFound when the user does not exist or already has a username.
+func (r *UserRepository) SetUsername(ctx context.Context, id, username string) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{"user_id": id, "username": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"username": username}})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrUsernameTaken
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateSettings hides or shows the public profile of a user and turns their email digests on or
+// off, leaving the settings given as nil unchanged, and returns the updated user
+func (r *UserRepository) UpdateSettings(ctx context.Context, id string, profileHidden, emailDigest *bool) (*model.User, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	set := bson.M{}
+	if profileHidden != nil {
+		set["profile_hidden"] = *profileHidden
+	}
+	if emailDigest != nil {
+		set["email_digest"] = *emailDigest
+	}
+	if len(set) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var user model.User
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"user_id": id}, bson.M{"$set": set}, opts).Decode(&user)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetPasswordHash replaces the password hash of a user; verified also marks their email verified
+func (r *UserRepository) SetPasswordHash(ctx context.Context, id, hash string, verified bool) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	set := bson.M{"password_hash": hash}
+	if verified {
+		set["email_verified"] = true
+	}
+	return r.update(ctx, id, set)
+}
+
+// SetEmailVerified marks the email of a user verified
+func (r *UserRepository) SetEmailVerified(ctx context.Context, id string) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	return r.update(ctx, id, bson.M{"email_verified": true})
+}
+
+// RecordLogin sets the last login time of a user
+func (r *UserRepository) RecordLogin(ctx context.Context, id string, now time.Time) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	return r.update(ctx, id, bson.M{"last_login_at": now})
+}
+
+// SetTOTP sets the two-factor secret of a user, enabled or pending confirmation, with the hashes
+// of their recovery codes; an empty secret turns two-factor authentication off
+func (r *UserRepository) SetTOTP(ctx context.Context, id, secret string, enabled bool, recoveryCodes []string) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	if secret == "" {
+		result, err := r.collection.UpdateOne(ctx, bson.M{"user_id": id}, bson.M{"$unset": bson.M{
+			"totp_secret": "", "totp_enabled": "", "recovery_codes": "", "totp_last_step": "",
+		}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	}
+	return r.update(ctx, id, bson.M{"totp_secret": secret, "totp_enabled": enabled, "recovery_codes": recoveryCodes})
+}
+
+// SetRecoveryCodes replaces the hashes of the recovery codes of a user
+func (r *UserRepository) SetRecoveryCodes(ctx context.Context, id string, recoveryCodes []string) error {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	return r.update(ctx, id, bson.M{"recovery_codes": recoveryCodes})
+}
+
+// UseTOTPStep records the time step of a two-factor code used by a user, reporting false when a
+// code of that step or a later one was already used
+func (r *UserRepository) UseTOTPStep(ctx context.Context, id string, step int64) (bool, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	filter := bson.M{
+		"user_id": id,
+		"$or": bson.A{
+			bson.M{"totp_last_step": bson.M{"$exists": false}},
+			bson.M{"totp_last_step": bson.M{"$lt": step}},
+		},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"totp_last_step": step}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// UseRecoveryCode removes the hash of a recovery code from a user, reporting false when the user
+// has no such code left
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, id, hash string) (bool, error) {
+	defer timing.Track(ctx, timing.PhaseMongo)()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"user_id": id, "recovery_codes": hash},
+		bson.M{"$pull": bson.M{"recovery_codes": hash}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// update sets fields of a user, returning ErrUserNotFound when there is none
+func (r *UserRepository) update(ctx context.Context, id string, set bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"user_id": id}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// newID returns a random ID for users, comments and notifications
+func newID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
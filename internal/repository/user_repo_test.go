@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestUserRepository_UpsertByProvider(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+	defer func() { _ = db.Collection(UserCollectionName).Drop(context.Background()) }()
+
+	repo, err := NewUserRepository(db)
+	if err != nil {
+		t.Fatalf("NewUserRepository() error = %v", err)
+	}
+	ctx := context.Background()
+
+	first := time.Now().Truncate(time.Millisecond)
+	user, err := repo.UpsertByProvider(ctx, &model.User{Provider: "github", ProviderID: "42", Login: "octocat"}, first)
+	if err != nil {
+		t.Fatalf("UpsertByProvider() error = %v", err)
+	}
+	if user.ID == "" || user.Login != "octocat" || !user.CreatedAt.Equal(first) {
+		t.Errorf("UpsertByProvider() = %+v, want a new user", user)
+	}
+
+	// Signing in again keeps the ID and updates the profile
+	again, err := repo.UpsertByProvider(ctx, &model.User{Provider: "github", ProviderID: "42", Login: "octocat2"}, first.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("UpsertByProvider() error = %v", err)
+	}
+	if again.ID != user.ID || again.Login != "octocat2" || !again.CreatedAt.Equal(first) {
+		t.Errorf("UpsertByProvider() again = %+v, want user %s renamed", again, user.ID)
+	}
+
+	// The same account ID at another provider is another user
+	other, err := repo.UpsertByProvider(ctx, &model.User{Provider: "google", ProviderID: "42"}, first)
+	if err != nil || other.ID == user.ID {
+		t.Errorf("UpsertByProvider(google) = %+v, %v, want another user", other, err)
+	}
+
+	got, err := repo.GetByID(ctx, user.ID)
+	if err != nil || got.Login != "octocat2" {
+		t.Errorf("GetByID() = %+v, %v", got, err)
+	}
+	if _, err := repo.GetByID(ctx, "missing"); err != ErrUserNotFound {
+		t.Errorf("GetByID(missing) error = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestUserRepository_Username(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+	defer func() { _ = db.Collection(UserCollectionName).Drop(context.Background()) }()
+
+	repo, err := NewUserRepository(db)
+	if err != nil {
+		t.Fatalf("NewUserRepository() error = %v", err)
+	}
+	ctx := context.Background()
+
+	now := time.Now()
+	first, _ := repo.UpsertByProvider(ctx, &model.User{Provider: "github", ProviderID: "1", Login: "octocat"}, now)
+	second, _ := repo.UpsertByProvider(ctx, &model.User{Provider: "google", ProviderID: "1"}, now)
+	if first == nil || second == nil {
+		t.Fatal("UpsertByProvider() failed")
+	}
+
+	if err := repo.SetUsername(ctx, first.ID, "octocat"); err != nil {
+		t.Fatalf("SetUsername() error = %v", err)
+	}
+	if err := repo.SetUsername(ctx, second.ID, "octocat"); err != ErrUsernameTaken {
+		t.Errorf("SetUsername() of a taken username error = %v, want %v", err, ErrUsernameTaken)
+	}
+	// A username is kept once set
+	if err := repo.SetUsername(ctx, first.ID, "octocat-2"); err != ErrUserNotFound {
+		t.Errorf("SetUsername() again error = %v, want %v", err, ErrUserNotFound)
+	}
+
+	got, err := repo.GetByUsername(ctx, "octocat")
+	if err != nil || got.ID != first.ID {
+		t.Errorf("GetByUsername() = %+v, %v, want user %s", got, err, first.ID)
+	}
+	if _, err := repo.GetByUsername(ctx, "missing"); err != ErrUserNotFound {
+		t.Errorf("GetByUsername(missing) error = %v, want %v", err, ErrUserNotFound)
+	}
+
+	on := true
+	hidden, err := repo.UpdateSettings(ctx, first.ID, &on, nil)
+	if err != nil || !hidden.ProfileHidden || hidden.EmailDigest || hidden.Username != "octocat" {
+		t.Errorf("UpdateSettings() = %+v, %v, want the profile hidden", hidden, err)
+	}
+	digest, err := repo.UpdateSettings(ctx, first.ID, nil, &on)
+	if err != nil || !digest.ProfileHidden || !digest.EmailDigest {
+		t.Errorf("UpdateSettings() = %+v, %v, want the profile hidden and email digests on", digest, err)
+	}
+	// Signing in again keeps the username and the setting
+	again, err := repo.UpsertByProvider(ctx, &model.User{Provider: "github", ProviderID: "1", Login: "octocat"}, now)
+	if err != nil || again.Username != "octocat" || !again.ProfileHidden {
+		t.Errorf("UpsertByProvider() again = %+v, %v, want the username and setting kept", again, err)
+	}
+}
+
+func TestUserRepository_Create(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+	defer func() { _ = db.Collection(UserCollectionName).Drop(context.Background()) }()
+
+	repo, err := NewUserRepository(db)
+	if err != nil {
+		t.Fatalf("NewUserRepository() error = %v", err)
+	}
+	ctx := context.Background()
+
+	now := time.Now().Truncate(time.Millisecond)
+	user := &model.User{Provider: "password", ProviderID: "octocat@example.com", Email: "octocat@example.com", PasswordHash: "hash"}
+	if err := repo.Create(ctx, user, now); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if user.ID == "" || !user.CreatedAt.Equal(now) {
+		t.Errorf("Create() user = %+v, want an ID and creation time", user)
+	}
+	if err := repo.Create(ctx, &model.User{Provider: "password", ProviderID: "octocat@example.com"}, now); err != ErrUserExists {
+		t.Errorf("Create() again error = %v, want %v", err, ErrUserExists)
+	}
+
+	got, err := repo.GetByProvider(ctx, "password", "octocat@example.com")
+	if err != nil || got.ID != user.ID || got.PasswordHash != "hash" || got.EmailVerified {
+		t.Errorf("GetByProvider() = %+v, %v, want user %s not verified", got, err, user.ID)
+	}
+	if _, err := repo.GetByProvider(ctx, "password", "missing@example.com"); err != ErrUserNotFound {
+		t.Errorf("GetByProvider(missing) error = %v, want %v", err, ErrUserNotFound)
+	}
+
+	if err := repo.SetPasswordHash(ctx, user.ID, "hash2", true); err != nil {
+		t.Fatalf("SetPasswordHash() error = %v", err)
+	}
+	got, err = repo.GetByID(ctx, user.ID)
+	if err != nil || got.PasswordHash != "hash2" || !got.EmailVerified {
+		t.Errorf("GetByID() after SetPasswordHash = %+v, %v", got, err)
+	}
+	if err := repo.SetEmailVerified(ctx, "missing"); err != ErrUserNotFound {
+		t.Errorf("SetEmailVerified(missing) error = %v, want %v", err, ErrUserNotFound)
+	}
+}
+
+func TestUserRepository_TOTP(t *testing.T) {
+	db, cleanup := setupTestPasteDB(t)
+	defer cleanup()
+	defer func() { _ = db.Collection(UserCollectionName).Drop(context.Background()) }()
+
+	repo, err := NewUserRepository(db)
+	if err != nil {
+		t.Fatalf("NewUserRepository() error = %v", err)
+	}
+	ctx := context.Background()
+
+	user, err := repo.UpsertByProvider(ctx, &model.User{Provider: "github", ProviderID: "42"}, time.Now())
+	if err != nil {
+		t.Fatalf("UpsertByProvider() error = %v", err)
+	}
+	if err := repo.SetTOTP(ctx, user.ID, "SECRET", true, []string{"hash1", "hash2"}); err != nil {
+		t.Fatalf("SetTOTP() error = %v", err)
+	}
+
+	// A step is used once, and earlier steps not at all
+	if ok, err := repo.UseTOTPStep(ctx, user.ID, 100); err != nil || !ok {
+		t.Errorf("UseTOTPStep(100) = %v, %v, want true", ok, err)
+	}
+	for _, step := range []int64{100, 99} {
+		if ok, err := repo.UseTOTPStep(ctx, user.ID, step); err != nil || ok {
+			t.Errorf("UseTOTPStep(%d) again = %v, %v, want false", step, ok, err)
+		}
+	}
+
+	if ok, err := repo.UseRecoveryCode(ctx, user.ID, "hash1"); err != nil || !ok {
+		t.Errorf("UseRecoveryCode(hash1) = %v, %v, want true", ok, err)
+	}
+	if ok, err := repo.UseRecoveryCode(ctx, user.ID, "hash1"); err != nil || ok {
+		t.Errorf("UseRecoveryCode(hash1) again = %v, %v, want false", ok, err)
+	}
+
+	if err := repo.SetTOTP(ctx, user.ID, "", false, nil); err != nil {
+		t.Fatalf("SetTOTP(off) error = %v", err)
+	}
+	got, err := repo.GetByID(ctx, user.ID)
+	if err != nil || got.TOTPEnabled || got.TOTPSecret != "" || len(got.RecoveryCodes) != 0 || got.TOTPLastStep != 0 {
+		t.Errorf("GetByID() after SetTOTP(off) = %+v, %v, want two-factor authentication off", got, err)
+	}
+}
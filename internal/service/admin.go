@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// DefaultStatsDays is the number of days of creation counts reported by Stats
+	DefaultStatsDays = 30
+	// MaxStatsDays caps the number of days of creation counts
+	MaxStatsDays = 366
+)
+
+// ErrInvalidStatsDays is returned when the number of days of a stats request is out of range
+var ErrInvalidStatsDays = errors.New("paste: invalid number of days")
+
+// KeyPoolStats describes the pool of pre-generated short IDs
+type KeyPoolStats struct {
+	Total  int64 `json:"total" example:"5000"`
+	Unused int64 `json:"unused" example:"4200"`
+	Used   int64 `json:"used" example:"800"`
+}
+
+// DailyCreations is the number of pastes created on a UTC day
+type DailyCreations struct {
+	Date  string `json:"date" example:"2024-01-15"`
+	Count int64  `json:"count" example:"42"`
+}
+
+// StatsResponse represents the paste and key pool totals reported to operators
+type StatsResponse struct {
+	PastesTotal int64 `json:"pastes_total" example:"1234"`
+	// ExpiredPending counts expired pastes the MongoDB TTL monitor has not removed yet
+	ExpiredPending int64        `json:"expired_pending" example:"3"`
+	KeyPool        KeyPoolStats `json:"key_pool"`
+	// CreatedPerDay counts the stored pastes created on each of the last days, oldest first and
+	// today included; pastes deleted or expired since are not counted
+	CreatedPerDay []DailyCreations `json:"created_per_day"`
+}
+
+// Stats returns the paste totals, the key pool state and the creations of the last days
+func (s *PasteService) Stats(ctx context.Context, days int) (*StatsResponse, error) {
+	if days == 0 {
+		days = DefaultStatsDays
+	}
+	if days < 0 || days > MaxStatsDays {
+		return nil, ErrInvalidStatsDays
+	}
+
+	response := &StatsResponse{}
+	var err error
+	if response.PastesTotal, err = s.pasteRepo.Count(ctx); err != nil {
+		return nil, fmt.Errorf("paste: failed to count pastes: %w", err)
+	}
+	if response.ExpiredPending, err = s.pasteRepo.CountExpired(ctx); err != nil {
+		return nil, fmt.Errorf("paste: failed to count expired pastes: %w", err)
+	}
+	if response.KeyPool.Total, err = s.kgs.CountTotalKeys(ctx); err != nil {
+		return nil, fmt.Errorf("paste: failed to count keys: %w", err)
+	}
+	if response.KeyPool.Unused, err = s.kgs.CountUnusedKeys(ctx); err != nil {
+		return nil, fmt.Errorf("paste: failed to count unused keys: %w", err)
+	}
+	response.KeyPool.Used = response.KeyPool.Total - response.KeyPool.Unused
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, 1-days)
+	counts, err := s.pasteRepo.CountCreatedPerDay(ctx, since)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to count creations: %w", err)
+	}
+	response.CreatedPerDay = fillDays(since, days, counts)
+
+	return response, nil
+}
+
+// fillDays lists the counts of days consecutive days from since, with zero for missing days
+func fillDays(since time.Time, days int, counts []repository.DailyCount) []DailyCreations {
+	byDate := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		byDate[c.Date] = c.Count
+	}
+	filled := make([]DailyCreations, days)
+	for i := range filled {
+		date := since.AddDate(0, 0, i).Format(time.DateOnly)
+		filled[i] = DailyCreations{Date: date, Count: byDate[date]}
+	}
+	return filled
+}
+
+// ForceDeletePaste removes a paste from all layers whatever its state, including pending
+// uploads, burned and expired pastes, and returns its metadata for the moderation record
+func (s *PasteService) ForceDeletePaste(ctx context.Context, shortID string) (*PasteSummary, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+
+	s.deletePaste(ctx, shortID)
+
+	log.Printf("[PasteService.ForceDeletePaste] Deleted %s (created %s)", shortID, paste.CreatedAt.UTC().Format(time.RFC3339))
+	summary := toPasteSummary(paste)
+	return &summary, nil
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func TestFillDays(t *testing.T) {
+	since := time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+	got := fillDays(since, 4, []repository.DailyCount{
+		{Date: "2024-01-30", Count: 3},
+		{Date: "2024-02-01", Count: 5},
+	})
+
+	want := []DailyCreations{
+		{Date: "2024-01-30", Count: 3},
+		{Date: "2024-01-31", Count: 0},
+		{Date: "2024-02-01", Count: 5},
+		{Date: "2024-02-02", Count: 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fillDays() returned %d days, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("fillDays()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStaleCutoff(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	got, err := staleCutoff(now, 30)
+	if err != nil {
+		t.Fatalf("staleCutoff() error = %v", err)
+	}
+	if want := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("staleCutoff() = %v, want %v", got, want)
+	}
+
+	for _, days := range []int{0, -1, MaxStaleDays + 1} {
+		if _, err := staleCutoff(now, days); !errors.Is(err, ErrInvalidStaleDays) {
+			t.Errorf("staleCutoff(%d) error = %v, want ErrInvalidStaleDays", days, err)
+		}
+	}
+}
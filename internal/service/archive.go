@@ -0,0 +1,256 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+// Archive formats of the files of a group
+const (
+	ArchiveZip = "zip"
+	ArchiveTar = "tar"
+)
+
+// ErrInvalidArchiveFormat is returned for an archive format other than zip and tar
+var ErrInvalidArchiveFormat = errors.New("paste: invalid archive format")
+
+// GetPasteGroup returns the group of the paste with the given short ID without reading its
+// content: the group it was created with, or a group of its own for a paste created alone. An ID
+// no paste has is looked up as a group ID.
+func (s *PasteService) GetPasteGroup(ctx context.Context, id string) (*GroupResponse, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, id)
+	if errors.Is(err, repository.ErrPasteNotFound) {
+		return s.GetGroup(ctx, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.GroupID != "" {
+		return s.GetGroup(ctx, paste.GroupID)
+	}
+
+	filePath := paste.ShortID
+	if paste.Delivery != nil && paste.Delivery.Filename != "" {
+		filePath = paste.Delivery.Filename
+	} else if paste.Title != "" {
+		filePath = paste.Title
+	}
+	return &GroupResponse{Files: []GroupFile{{
+		Path:       filePath,
+		ShortID:    paste.ShortID,
+		URL:        s.buildURL(paste.ShortID),
+		SyntaxType: paste.SyntaxType,
+	}}}, nil
+}
+
+// WriteArchive writes the files of a group to w as a zip or tar archive, one file at a time so
+// the archive is never held in memory. Downloading an archive does not count a view of its files:
+// burn-after-read and view-limited pastes, which may only be read one counted view at a time, are
+// left out, as are files deleted, expired or hidden since the group was listed. Each file is read
+// from storage once; tar headers carry the size of a file ahead of its content, so content is
+// buffered up to MaxContentSize, and larger directly uploaded content spooled to a temporary file.
+// Nothing is written to w before the first file is open.
+func (s *PasteService) WriteArchive(ctx context.Context, w io.Writer, group *GroupResponse, format string) error {
+	var archive archiveWriter
+	switch format {
+	case ArchiveZip:
+		archive = &zipArchive{}
+	case ArchiveTar:
+		archive = &tarArchive{}
+	default:
+		return ErrInvalidArchiveFormat
+	}
+
+	var written int
+	for _, file := range group.Files {
+		paste, content, size, err := s.openArchiveFile(ctx, file.ShortID, format == ArchiveTar)
+		if errors.Is(err, ErrPasteNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if written == 0 {
+			archive.open(w)
+		}
+		err = archive.add(archivePath(file), paste.CreatedAt, size, content)
+		content.Close()
+		if err != nil {
+			return fmt.Errorf("paste: failed to archive %s: %w", file.ShortID, err)
+		}
+		written++
+	}
+	if written == 0 {
+		return ErrPasteNotFound
+	}
+
+	log.Printf("[PasteService.WriteArchive] Success: group=%s, files=%d, format=%s", group.GroupID, written, format)
+	return archive.close()
+}
+
+// openArchiveFile opens the content of a file of an archive and returns its size, without counting
+// a view. Files an archive leaves out are reported as ErrPasteNotFound. The size is -1 when it is
+// not needed and the content is larger than MaxContentSize. The caller must close the reader.
+func (s *PasteService) openArchiveFile(ctx context.Context, shortID string, needSize bool) (*model.Paste, io.ReadCloser, int64, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if errors.Is(err, repository.ErrPasteNotFound) {
+		return nil, nil, 0, ErrPasteNotFound
+	}
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsExpired() || paste.IsPending() || paste.IsBurned() || paste.ArchivedAt != nil || paste.IsQuarantined() || !revalidatable(paste) {
+		return nil, nil, 0, ErrPasteNotFound
+	}
+
+	if s.healthy(DependencyRedis) {
+		if content, found, err := s.cache.Lookup(ctx, shortID); err == nil && found {
+			return paste, io.NopCloser(strings.NewReader(content)), int64(len(content)), nil
+		}
+	}
+	if !s.healthy(DependencyS3) {
+		return nil, nil, 0, fmt.Errorf("%w: %s", ErrDependencyUnavailable, DependencyS3)
+	}
+	stream, err := s.storage.OpenContent(ctx, shortID)
+	if errors.Is(err, ErrContentNotFound) {
+		return nil, nil, 0, ErrPasteNotFound
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	head, err := io.ReadAll(io.LimitReader(stream, MaxContentSize+1))
+	if err != nil {
+		stream.Close()
+		return nil, nil, 0, fmt.Errorf("paste: failed to read content: %w", err)
+	}
+	if len(head) <= MaxContentSize {
+		stream.Close()
+		return paste, io.NopCloser(bytes.NewReader(head)), int64(len(head)), nil
+	}
+
+	content := io.MultiReader(bytes.NewReader(head), stream)
+	if !needSize {
+		return paste, &readCloser{Reader: content, Closer: stream}, -1, nil
+	}
+	defer stream.Close()
+	spool, size, err := spoolContent(content)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("paste: failed to read content: %w", err)
+	}
+	return paste, spool, size, nil
+}
+
+// spoolContent copies content to a temporary file, removed when closed, and returns it rewound
+// with the size of the content
+func spoolContent(content io.Reader) (io.ReadCloser, int64, error) {
+	f, err := os.CreateTemp("", "gisty-archive-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	spool := &tempFile{File: f}
+	size, err := io.Copy(f, content)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spool.Close()
+		return nil, 0, err
+	}
+	return spool, size, nil
+}
+
+// tempFile is a temporary file removed when closed
+type tempFile struct {
+	*os.File
+}
+
+// Close closes and removes the file
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	if rmErr := os.Remove(f.Name()); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// archivePath returns the path of a file in an archive: its path in the group when it is a clean
+// relative path, its short ID otherwise
+func archivePath(file GroupFile) string {
+	if p, err := normalizeBundlePath(file.Path); err == nil {
+		return p
+	}
+	return file.ShortID
+}
+
+// archiveWriter writes the files of an archive in turn
+type archiveWriter interface {
+	open(w io.Writer)
+	// add writes a file; size is only used by formats that need it ahead of the content
+	add(name string, modified time.Time, size int64, content io.Reader) error
+	close() error
+}
+
+// zipArchive writes a zip archive, compressing each file with deflate
+type zipArchive struct {
+	w *zip.Writer
+}
+
+func (a *zipArchive) open(w io.Writer) {
+	a.w = zip.NewWriter(w)
+}
+
+func (a *zipArchive) add(name string, modified time.Time, _ int64, content io.Reader) error {
+	fw, err := a.w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(fw, content)
+	return err
+}
+
+func (a *zipArchive) close() error {
+	return a.w.Close()
+}
+
+// tarArchive writes an uncompressed tar archive
+type tarArchive struct {
+	w *tar.Writer
+}
+
+func (a *tarArchive) open(w io.Writer) {
+	a.w = tar.NewWriter(w)
+}
+
+func (a *tarArchive) add(name string, modified time.Time, size int64, content io.Reader) error {
+	if err := a.w.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0o644,
+		Size:     size,
+		ModTime:  modified,
+		Format:   tar.FormatPAX,
+	}); err != nil {
+		return err
+	}
+	if _, err := io.Copy(a.w, content); err != nil {
+		return err
+	}
+	return a.w.Flush()
+}
+
+func (a *tarArchive) close() error {
+	return a.w.Close()
+}
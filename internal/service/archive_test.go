@@ -0,0 +1,161 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestPasteService_WriteArchive(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	bundle, err := svc.CreateBundle(ctx, &CreateBundleRequest{Files: []BundleFile{
+		{Path: "README.md", Content: "# Demo\n"},
+		{Path: "cmd/demo/main.go", Content: "package main\n"},
+	}})
+	if err != nil {
+		t.Fatalf("CreateBundle() error = %v", err)
+	}
+	want := map[string]string{"README.md": "# Demo\n", "cmd/demo/main.go": "package main\n"}
+
+	// Any file of the bundle, or the group ID, gives the whole group
+	group, err := svc.GetPasteGroup(ctx, bundle.Files[1].ShortID)
+	if err != nil || group.GroupID != bundle.GroupID || len(group.Files) != 2 {
+		t.Fatalf("GetPasteGroup() = %+v, %v, want the bundle's group", group, err)
+	}
+	if byGroup, err := svc.GetPasteGroup(ctx, bundle.GroupID); err != nil || len(byGroup.Files) != 2 {
+		t.Errorf("GetPasteGroup() of the group ID = %+v, %v, want the bundle's group", byGroup, err)
+	}
+
+	var zipped bytes.Buffer
+	if err := svc.WriteArchive(ctx, &zipped, group, ArchiveZip); err != nil {
+		t.Fatalf("WriteArchive(zip) error = %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	got := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", f.Name, err)
+		}
+		content, _ := io.ReadAll(r)
+		r.Close()
+		got[f.Name] = string(content)
+	}
+	if len(got) != len(want) || got["README.md"] != want["README.md"] || got["cmd/demo/main.go"] != want["cmd/demo/main.go"] {
+		t.Errorf("zip archive = %v, want %v", got, want)
+	}
+
+	var tarred bytes.Buffer
+	if err := svc.WriteArchive(ctx, &tarred, group, ArchiveTar); err != nil {
+		t.Fatalf("WriteArchive(tar) error = %v", err)
+	}
+	tr := tar.NewReader(&tarred)
+	got = map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar Next() error = %v", err)
+		}
+		content, _ := io.ReadAll(tr)
+		got[header.Name] = string(content)
+	}
+	if len(got) != len(want) || got["README.md"] != want["README.md"] || got["cmd/demo/main.go"] != want["cmd/demo/main.go"] {
+		t.Errorf("tar archive = %v, want %v", got, want)
+	}
+
+	// A paste created alone is a group of its own
+	solo, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "hello"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	if group, err := svc.GetPasteGroup(ctx, solo.ShortID); err != nil || group.GroupID != "" || len(group.Files) != 1 {
+		t.Errorf("GetPasteGroup() of a single paste = %+v, %v, want a group of one", group, err)
+	}
+
+	if err := svc.WriteArchive(ctx, io.Discard, group, "rar"); err != ErrInvalidArchiveFormat {
+		t.Errorf("WriteArchive(rar) error = %v, want %v", err, ErrInvalidArchiveFormat)
+	}
+	if _, err := svc.GetPasteGroup(ctx, "missing"); err != ErrPasteNotFound {
+		t.Errorf("GetPasteGroup() of a missing ID error = %v, want %v", err, ErrPasteNotFound)
+	}
+}
+
+func TestPasteService_WriteArchive_Views(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	plain, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "plain"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	burn, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "burn", ExpiresIn: "burn"})
+	if err != nil {
+		t.Fatalf("CreatePaste(burn) error = %v", err)
+	}
+	limited, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "limited", MaxViews: 1})
+	if err != nil {
+		t.Fatalf("CreatePaste(max_views) error = %v", err)
+	}
+
+	// Downloading an archive does not count a view
+	group, _ := svc.GetPasteGroup(ctx, plain.ShortID)
+	for i := 0; i < 2; i++ {
+		if err := svc.WriteArchive(ctx, io.Discard, group, ArchiveTar); err != nil {
+			t.Fatalf("WriteArchive() error = %v", err)
+		}
+	}
+	if got, err := svc.GetPaste(ctx, plain.ShortID); err != nil || got.Views != 1 {
+		t.Errorf("GetPaste() after archiving = %+v, %v, want the first view", got, err)
+	}
+
+	// Burn-after-read and view-limited pastes are left out, and stay readable
+	for _, id := range []string{burn.ShortID, limited.ShortID} {
+		group, _ := svc.GetPasteGroup(ctx, id)
+		if err := svc.WriteArchive(ctx, io.Discard, group, ArchiveZip); err != ErrPasteNotFound {
+			t.Errorf("WriteArchive(%s) error = %v, want %v", id, err, ErrPasteNotFound)
+		}
+		if _, err := svc.GetPaste(ctx, id); err != nil {
+			t.Errorf("GetPaste(%s) after archiving error = %v", id, err)
+		}
+	}
+}
+
+func TestSpoolContent(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), MaxContentSize+10)
+
+	spool, size, err := spoolContent(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("spoolContent() error = %v", err)
+	}
+	if size != int64(len(content)) {
+		t.Errorf("spoolContent() size = %d, want %d", size, len(content))
+	}
+	got, _ := io.ReadAll(spool)
+	if !bytes.Equal(got, content) {
+		t.Errorf("spooled content length = %d, want %d", len(got), len(content))
+	}
+
+	name := spool.(*tempFile).Name()
+	if err := spool.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("spool file %s still exists after Close", name)
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// BanKeyPrefix prefixes the Redis key of each banned IP
+	BanKeyPrefix = "gisty:ban:"
+	// BanIndexKey is the Redis set of banned IPs, for listing
+	BanIndexKey = "gisty:bans"
+	// MaxBanReasonLength is the longest accepted ban reason
+	MaxBanReasonLength = 256
+)
+
+// ErrInvalidBan is returned when a ban has a negative duration or an overlong reason
+var ErrInvalidBan = errors.New("paste: invalid ban")
+
+// IPBan is a client IP barred from writing
+type IPBan struct {
+	IP        string     `json:"ip" example:"203.0.113.7"`
+	Reason    string     `json:"reason,omitempty" example:"spam"`
+	CreatedAt time.Time  `json:"created_at" example:"2024-01-15T14:00:00Z"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2024-01-16T14:00:00Z"`
+}
+
+// Bans manages the IP bans shared by all replicas through Redis.
+// A ban expires with its Redis key; the index set is pruned when listing.
+type Bans struct {
+	client *redis.Client
+}
+
+// NewBans creates a new Bans service
+func NewBans(redisClient *repository.Redis) *Bans {
+	return &Bans{
+		client: redisClient.Client,
+	}
+}
+
+// Ban bars ip from writing for duration, or until lifted when duration is 0
+func (b *Bans) Ban(ctx context.Context, ip, reason string, duration time.Duration) (*IPBan, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return nil, ErrInvalidSourceIP
+	}
+	if duration < 0 || len(reason) > MaxBanReasonLength {
+		return nil, ErrInvalidBan
+	}
+
+	now := time.Now().UTC()
+	ban := &IPBan{IP: parsed.String(), Reason: reason, CreatedAt: now}
+	if duration > 0 {
+		expiresAt := now.Add(duration)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	data, err := json.Marshal(ban)
+	if err != nil {
+		return nil, err
+	}
+	pipe := b.client.TxPipeline()
+	pipe.Set(ctx, BanKeyPrefix+ban.IP, data, duration)
+	pipe.SAdd(ctx, BanIndexKey, ban.IP)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+// Unban lifts the ban of ip and reports whether there was one
+func (b *Bans) Unban(ctx context.Context, ip string) (bool, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false, ErrInvalidSourceIP
+	}
+
+	pipe := b.client.TxPipeline()
+	deleted := pipe.Del(ctx, BanKeyPrefix+parsed.String())
+	pipe.SRem(ctx, BanIndexKey, parsed.String())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return deleted.Val() > 0, nil
+}
+
+// IsBanned reports whether ip is barred from writing
+func (b *Bans) IsBanned(ctx context.Context, ip string) (bool, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false, nil
+	}
+	n, err := b.client.Exists(ctx, BanKeyPrefix+parsed.String()).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// List returns the active bans, newest first
+func (b *Bans) List(ctx context.Context) ([]IPBan, error) {
+	ips, err := b.client.SMembers(ctx, BanIndexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	bans := []IPBan{}
+	if len(ips) == 0 {
+		return bans, nil
+	}
+	keys := make([]string, len(ips))
+	for i, ip := range ips {
+		keys[i] = BanKeyPrefix + ip
+	}
+	values, err := b.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []any
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// The ban expired; forget it
+			expired = append(expired, ips[i])
+			continue
+		}
+		var ban IPBan
+		if err := json.Unmarshal([]byte(data), &ban); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	if len(expired) > 0 {
+		_ = b.client.SRem(ctx, BanIndexKey, expired...).Err()
+	}
+
+	sort.Slice(bans, func(i, j int) bool { return bans[i].CreatedAt.After(bans[j].CreatedAt) })
+	return bans, nil
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func setupTestBans(t *testing.T) (*Bans, func()) {
+	ctx := context.Background()
+
+	redisClient, err := repository.NewRedisClient(ctx, "redis://localhost:6379")
+	if err != nil {
+		t.Skipf("Redis not available: %v", err)
+	}
+
+	bans := NewBans(redisClient)
+
+	cleanup := func() {
+		redisClient.Client.Del(ctx, BanIndexKey, BanKeyPrefix+"203.0.113.7", BanKeyPrefix+"2001:db8::1")
+		redisClient.Close()
+	}
+
+	return bans, cleanup
+}
+
+func TestBans_BanUnban(t *testing.T) {
+	bans, cleanup := setupTestBans(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	if _, err := bans.Ban(ctx, "not-an-ip", "", 0); !errors.Is(err, ErrInvalidSourceIP) {
+		t.Errorf("Ban() invalid IP error = %v, want %v", err, ErrInvalidSourceIP)
+	}
+	if _, err := bans.Ban(ctx, "203.0.113.7", "", -time.Second); !errors.Is(err, ErrInvalidBan) {
+		t.Errorf("Ban() negative duration error = %v, want %v", err, ErrInvalidBan)
+	}
+
+	ban, err := bans.Ban(ctx, "203.0.113.7", "spam", time.Hour)
+	if err != nil {
+		t.Fatalf("Ban() error = %v", err)
+	}
+	if ban.ExpiresAt == nil || ban.Reason != "spam" {
+		t.Errorf("Ban() = %+v", ban)
+	}
+	// IPv6 addresses are stored in canonical form
+	if _, err := bans.Ban(ctx, "2001:DB8:0::1", "", 0); err != nil {
+		t.Fatalf("Ban() error = %v", err)
+	}
+	if banned, err := bans.IsBanned(ctx, "2001:db8::1"); err != nil || !banned {
+		t.Errorf("IsBanned() = %v, %v, want true", banned, err)
+	}
+
+	list, err := bans.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 2 {
+		t.Errorf("List() returned %d bans, want 2", len(list))
+	}
+
+	lifted, err := bans.Unban(ctx, "203.0.113.7")
+	if err != nil || !lifted {
+		t.Errorf("Unban() = %v, %v, want true", lifted, err)
+	}
+	if banned, _ := bans.IsBanned(ctx, "203.0.113.7"); banned {
+		t.Error("IsBanned() after Unban() = true")
+	}
+	if lifted, _ := bans.Unban(ctx, "203.0.113.7"); lifted {
+		t.Error("Unban() of an unbanned IP should report false")
+	}
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// MaxBulkDelete is the number of pastes deleted at most by one DeleteUserPastes
+const MaxBulkDelete = 100
+
+// BulkDeleteRequest lists the pastes of the signed-in user to delete
+type BulkDeleteRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=100" example:"xK9a2B,p7Qm3Z"`
+}
+
+// BulkDeleteResponse lists the pastes deleted by DeleteUserPastes
+type BulkDeleteResponse struct {
+	Deleted  int      `json:"deleted" example:"2"`
+	ShortIDs []string `json:"short_ids"`
+}
+
+// DeleteUserPastes deletes the listed pastes created by a signed-in user. Pastes that do not
+// exist or belong to someone else are skipped and left out of the response.
+func (s *PasteService) DeleteUserPastes(ctx context.Context, userID string, shortIDs []string) (*BulkDeleteResponse, error) {
+	if userID == "" {
+		return nil, ErrSignInRequired
+	}
+	if len(shortIDs) > MaxBulkDelete {
+		shortIDs = shortIDs[:MaxBulkDelete]
+	}
+
+	owned, err := s.pasteRepo.FilterByUser(ctx, userID, shortIDs)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to get pastes: %w", err)
+	}
+	for _, shortID := range owned {
+		s.deletePaste(ctx, shortID)
+	}
+
+	log.Printf("[PasteService.DeleteUserPastes] Deleted %d pastes of user %s", len(owned), userID)
+	return &BulkDeleteResponse{Deleted: len(owned), ShortIDs: owned}, nil
+}
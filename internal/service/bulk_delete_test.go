@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPasteService_DeleteUserPastes(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	mine, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "mine", UserID: "user1"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	theirs, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "theirs", UserID: "user2"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	response, err := svc.DeleteUserPastes(ctx, "user1", []string{mine.ShortID, theirs.ShortID, "missing"})
+	if err != nil {
+		t.Fatalf("DeleteUserPastes() error = %v", err)
+	}
+	if response.Deleted != 1 || len(response.ShortIDs) != 1 || response.ShortIDs[0] != mine.ShortID {
+		t.Errorf("DeleteUserPastes() = %+v, want only %s", response, mine.ShortID)
+	}
+
+	if _, err := svc.GetPaste(ctx, mine.ShortID); !errors.Is(err, ErrPasteNotFound) {
+		t.Errorf("GetPaste(mine) error = %v, want %v", err, ErrPasteNotFound)
+	}
+	if _, err := svc.GetPaste(ctx, theirs.ShortID); err != nil {
+		t.Errorf("GetPaste(theirs) error = %v, want the paste kept", err)
+	}
+
+	if _, err := svc.DeleteUserPastes(ctx, "", []string{theirs.ShortID}); !errors.Is(err, ErrSignInRequired) {
+		t.Errorf("DeleteUserPastes(anonymous) error = %v, want %v", err, ErrSignInRequired)
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// MaxBulkGet is the number of pastes read at most by one GetPastes
+	MaxBulkGet = 100
+	// bulkGetConcurrency caps the pastes of a bulk read read at the same time
+	bulkGetConcurrency = 8
+)
+
+// BulkGetRequest lists the pastes to read in one round trip
+type BulkGetRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=100" example:"xK9a2B,p7Qm3Z"`
+	// MaxBytes caps the returned content of each paste at this many bytes (0 returns full
+	// content); the server's default cap applies when omitted
+	MaxBytes *int `json:"max_bytes,omitempty" binding:"omitempty,min=0" example:"4096"`
+}
+
+// BulkGetResult is the outcome of reading one paste of a bulk read: the paste, or why it could
+// not be read
+type BulkGetResult struct {
+	ShortID string
+	Paste   *GetPasteResponse
+	Err     error
+}
+
+// GetPastes reads each listed paste as GetPaste does, counting a view of each, a few at a time.
+// A paste that cannot be read does not fail the others: its result holds the error instead.
+// The results follow the order of the IDs, each ID read once however often it is listed.
+func (s *PasteService) GetPastes(ctx context.Context, shortIDs []string) []BulkGetResult {
+	seen := make(map[string]bool, len(shortIDs))
+	results := make([]BulkGetResult, 0, min(len(shortIDs), MaxBulkGet))
+	for _, shortID := range shortIDs {
+		if seen[shortID] || len(results) == MaxBulkGet {
+			continue
+		}
+		seen[shortID] = true
+		results = append(results, BulkGetResult{ShortID: shortID})
+	}
+
+	var g errgroup.Group
+	g.SetLimit(bulkGetConcurrency)
+	for i := range results {
+		g.Go(func() error {
+			results[i].Paste, results[i].Err = s.GetPaste(ctx, results[i].ShortID)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return results
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPasteService_GetPastes(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	first, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "first"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	second, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "second"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	results := svc.GetPastes(ctx, []string{second.ShortID, "missing", first.ShortID, second.ShortID})
+	if len(results) != 3 {
+		t.Fatalf("GetPastes() returned %d results, want 3", len(results))
+	}
+	if results[0].ShortID != second.ShortID || results[0].Err != nil || results[0].Paste.Content != "second" {
+		t.Errorf("GetPastes()[0] = %+v, want the second paste", results[0])
+	}
+	if results[1].ShortID != "missing" || results[1].Paste != nil || !errors.Is(results[1].Err, ErrPasteNotFound) {
+		t.Errorf("GetPastes()[1] = %+v, want %v", results[1], ErrPasteNotFound)
+	}
+	if results[2].ShortID != first.ShortID || results[2].Err != nil || results[2].Paste.Content != "first" {
+		t.Errorf("GetPastes()[2] = %+v, want the first paste", results[2])
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/huylvt/gisty/internal/codec"
+)
+
+const (
+	// MaxBundleFiles caps the number of files of a bundle, each stored as its own paste
+	MaxBundleFiles = 100
+	// MaxBundlePathLength caps the length in bytes of the path of a bundle file
+	MaxBundlePathLength = 255
+)
+
+// ErrInvalidBundle is returned for bundles without files, with too many files, or with a file
+// path that is not a clean relative path or is used twice
+var ErrInvalidBundle = errors.New("paste: invalid bundle")
+
+// BundleFile is a file of a bundle, at a slash-separated path relative to the bundle root
+type BundleFile struct {
+	Path       string `json:"path" binding:"required"`
+	Content    string `json:"content"`
+	Encoding   string `json:"encoding"`    // transfer encoding of content: "base64", "hex" or "" for none
+	SyntaxType string `json:"syntax_type"` // detected from the path and content when empty
+}
+
+// CreateBundleRequest represents the request to create the pastes of a file tree, such as a
+// directory pushed by the CLI
+type CreateBundleRequest struct {
+	Files       []BundleFile `json:"files" binding:"required"`
+	ExpiresIn   string       `json:"expires_in"`
+	IsPrivate   bool         `json:"is_private"`
+	Description string       `json:"description"`
+
+	// SourceIP is the caller's IP, set by the handler; only its keyed hash is stored
+	SourceIP string `json:"-"`
+	// OwnerID identifies the caller's API key or anonymous session, set by the handler
+	OwnerID string `json:"-"`
+	// UserID is the ID of the signed-in user, set by the handler
+	UserID string `json:"-"`
+}
+
+// BundleFileResponse is a file of a bundle and the paste it was stored as
+type BundleFileResponse struct {
+	Path       string `json:"path"`
+	SyntaxType string `json:"syntax_type"`
+	ShortID    string `json:"short_id"`
+	URL        string `json:"url"`
+	// DeleteToken is returned once for files created anonymously, and required to delete their paste
+	DeleteToken string `json:"delete_token,omitempty"`
+}
+
+// CreateBundleResponse represents the pastes created from a bundle, one per file in path order
+type CreateBundleResponse struct {
+	GroupID   string               `json:"group_id"`
+	Files     []BundleFileResponse `json:"files"`
+	ExpiresAt *string              `json:"expires_at,omitempty"`
+}
+
+// GroupFile is a paste of a group, without its content
+type GroupFile struct {
+	Path       string `json:"path"` // the file's path in its bundle, or its filename for gist imports
+	ShortID    string `json:"short_id"`
+	URL        string `json:"url"`
+	SyntaxType string `json:"syntax_type"`
+}
+
+// GroupResponse lists the readable pastes of a group in path order
+type GroupResponse struct {
+	GroupID string      `json:"group_id"`
+	Files   []GroupFile `json:"files"`
+}
+
+// CreateBundle stores each file of a bundle as a paste, keeping its path, and its base name as
+// download filename. The syntax type of each file is detected from its path and content unless
+// given. The pastes share a group ID, by which GetGroup lists them as a tree. The files are
+// created together: if one cannot be stored, the pastes already created are deleted. Their
+// content together is limited like the content of a single paste.
+func (s *PasteService) CreateBundle(ctx context.Context, req *CreateBundleRequest) (*CreateBundleResponse, error) {
+	if len(req.Files) == 0 || len(req.Files) > MaxBundleFiles {
+		return nil, ErrInvalidBundle
+	}
+
+	files := make([]BundleFile, len(req.Files))
+	seen := make(map[string]bool, len(req.Files))
+	size := 0
+	for i, file := range req.Files {
+		p, err := normalizeBundlePath(file.Path)
+		if err != nil || seen[p] {
+			return nil, ErrInvalidBundle
+		}
+		seen[p] = true
+
+		if file.Encoding != codec.None {
+			content, err := codec.Decode(file.Encoding, file.Content)
+			if err != nil {
+				return nil, ErrInvalidEncoding
+			}
+			file.Content, file.Encoding = content, codec.None
+		}
+		if size += len(file.Content); size > MaxContentSize {
+			return nil, ErrContentTooLarge
+		}
+		file.Path = p
+		files[i] = file
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	response := &CreateBundleResponse{GroupID: newGistGroupID(), Files: make([]BundleFileResponse, 0, len(files))}
+	for _, file := range files {
+		syntaxType := file.SyntaxType
+		if syntaxType == "" {
+			syntaxType = s.syntaxDetector.DetectLanguageWithFilename(path.Base(file.Path), file.Content)
+		}
+		created, err := s.CreatePaste(ctx, &CreatePasteRequest{
+			Content:     file.Content,
+			SyntaxType:  syntaxType,
+			ExpiresIn:   req.ExpiresIn,
+			IsPrivate:   req.IsPrivate,
+			Title:       truncateRunes(file.Path, MaxTitleLength),
+			Description: req.Description,
+			Delivery:    gistDelivery(path.Base(file.Path)),
+			SourceIP:    req.SourceIP,
+			OwnerID:     req.OwnerID,
+			UserID:      req.UserID,
+			GroupID:     response.GroupID,
+			Path:        file.Path,
+		})
+		if err != nil {
+			for _, done := range response.Files {
+				s.deletePaste(ctx, done.ShortID)
+			}
+			return nil, err
+		}
+		response.Files = append(response.Files, BundleFileResponse{
+			Path:        file.Path,
+			SyntaxType:  syntaxType,
+			ShortID:     created.ShortID,
+			URL:         created.URL,
+			DeleteToken: created.DeleteToken,
+		})
+		response.ExpiresAt = created.ExpiresAt
+	}
+
+	log.Printf("[PasteService.CreateBundle] Success: group=%s, files=%d", response.GroupID, len(response.Files))
+	return response, nil
+}
+
+// GetGroup lists the pastes of a group, such as a bundle or a gist import, without reading their
+// content. Pastes deleted, burned or expired since are left out.
+func (s *PasteService) GetGroup(ctx context.Context, groupID string) (*GroupResponse, error) {
+	pastes, err := s.pasteRepo.ListGroup(ctx, groupID, MaxBundleFiles)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list group: %w", err)
+	}
+
+	response := &GroupResponse{GroupID: groupID, Files: make([]GroupFile, 0, len(pastes))}
+	for _, paste := range pastes {
+		if paste.IsPending() || paste.IsBurned() || paste.IsExpired() {
+			continue
+		}
+		filePath := paste.Path
+		if filePath == "" {
+			// Gist imports keep the filename as title
+			filePath = paste.Title
+		}
+		response.Files = append(response.Files, GroupFile{
+			Path:       filePath,
+			ShortID:    paste.ShortID,
+			URL:        s.buildURL(paste.ShortID),
+			SyntaxType: paste.SyntaxType,
+		})
+	}
+	if len(response.Files) == 0 {
+		return nil, ErrPasteNotFound
+	}
+	sort.Slice(response.Files, func(i, j int) bool { return response.Files[i].Path < response.Files[j].Path })
+	return response, nil
+}
+
+// normalizeBundlePath returns the clean form of a relative, slash-separated file path. Absolute
+// paths, paths leaving the bundle root and paths with control characters are rejected.
+func normalizeBundlePath(p string) (string, error) {
+	if p == "" || len(p) > MaxBundlePathLength || !utf8.ValidString(p) || strings.ContainsRune(p, '\\') {
+		return "", ErrInvalidBundle
+	}
+	for _, r := range p {
+		if unicode.IsControl(r) {
+			return "", ErrInvalidBundle
+		}
+	}
+	clean := path.Clean(strings.TrimPrefix(p, "./"))
+	if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", ErrInvalidBundle
+	}
+	return clean, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNormalizeBundlePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "main.go", want: "main.go"},
+		{path: "./cmd/gisty/main.go", want: "cmd/gisty/main.go"},
+		{path: "cmd//gisty/./main.go", want: "cmd/gisty/main.go"},
+		{path: "cmd/../main.go", want: "main.go"},
+		{path: "", wantErr: true},
+		{path: ".", wantErr: true},
+		{path: "../secret", wantErr: true},
+		{path: "cmd/../../secret", wantErr: true},
+		{path: "/etc/passwd", wantErr: true},
+		{path: `cmd\main.go`, wantErr: true},
+		{path: "bad\nname", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeBundlePath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeBundlePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeBundlePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPasteService_CreateBundle(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	resp, err := svc.CreateBundle(ctx, &CreateBundleRequest{Files: []BundleFile{
+		{Path: "README.md", Content: "# Demo\n"},
+		{Path: "./cmd/demo/main.go", Content: "package main\n\nfunc main() {}\n"},
+		{Path: "data.bin", Content: "AAEC", Encoding: "base64"},
+	}})
+	if err != nil {
+		t.Fatalf("CreateBundle() error = %v", err)
+	}
+	if resp.GroupID == "" || len(resp.Files) != 3 {
+		t.Fatalf("CreateBundle() = %+v, want 3 files in a group", resp)
+	}
+	if resp.Files[0].Path != "README.md" || resp.Files[1].Path != "cmd/demo/main.go" {
+		t.Errorf("CreateBundle() files = %+v, want path order", resp.Files)
+	}
+	if resp.Files[1].SyntaxType != "go" {
+		t.Errorf("syntax type of main.go = %q, want go", resp.Files[1].SyntaxType)
+	}
+
+	group, err := svc.GetGroup(ctx, resp.GroupID)
+	if err != nil {
+		t.Fatalf("GetGroup() error = %v", err)
+	}
+	if len(group.Files) != 3 || group.Files[1].Path != "cmd/demo/main.go" || group.Files[1].ShortID != resp.Files[1].ShortID {
+		t.Errorf("GetGroup() = %+v, want the bundle's files", group.Files)
+	}
+
+	paste, err := svc.GetPaste(ctx, resp.Files[1].ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if paste.Path != "cmd/demo/main.go" || paste.GroupID != resp.GroupID {
+		t.Errorf("GetPaste() path = %q, group = %q, want the bundle's", paste.Path, paste.GroupID)
+	}
+
+	// A bundle is created entirely or not at all
+	if _, err := svc.CreateBundle(ctx, &CreateBundleRequest{Files: []BundleFile{
+		{Path: "a.txt", Content: "a"},
+		{Path: "./a.txt", Content: "b"},
+	}}); err != ErrInvalidBundle {
+		t.Errorf("CreateBundle() with a duplicate path error = %v, want %v", err, ErrInvalidBundle)
+	}
+	if _, err := svc.CreateBundle(ctx, &CreateBundleRequest{}); err != ErrInvalidBundle {
+		t.Errorf("CreateBundle() without files error = %v, want %v", err, ErrInvalidBundle)
+	}
+}
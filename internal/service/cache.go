@@ -2,9 +2,12 @@ package service
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
+	"github.com/huylvt/gisty/internal/metrics"
 	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/timing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -13,12 +16,45 @@ const (
 	DefaultCacheTTL = 1 * time.Hour
 	// CacheKeyPrefix is the prefix for all cache keys
 	CacheKeyPrefix = "paste:"
+	// cacheFlushBatchSize is the number of keys scanned and deleted per round when flushing
+	cacheFlushBatchSize = 500
 )
 
-// Cache handles caching operations using Redis
+// Cache lookup results
+const (
+	CacheResultHit    = "hit"
+	CacheResultMiss   = "miss"
+	CacheResultBypass = "bypass"
+)
+
+// CacheStats holds the content cache lookup counters since process start
+type CacheStats struct {
+	Hits     int64
+	Misses   int64
+	Bypasses int64
+	// LocalHits counts the hits served by the in-process cache, without a Redis round-trip
+	LocalHits int64
+}
+
+// HitRatio returns the fraction of cache lookups that were hits
+func (s CacheStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Cache handles caching operations using Redis, optionally behind an in-process cache
 type Cache struct {
 	client     *redis.Client
 	defaultTTL time.Duration
+	local      *LocalCache // nil disables the in-process cache
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	bypasses  atomic.Int64
+	localHits atomic.Int64
 }
 
 // NewCache creates a new Cache service
@@ -37,34 +73,134 @@ func NewCacheWithTTL(redisClient *repository.Redis, defaultTTL time.Duration) *C
 	}
 }
 
+// SetLocal layers an in-process cache in front of Redis, absorbing read storms on hot pastes;
+// nil removes it
+func (c *Cache) SetLocal(local *LocalCache) {
+	c.local = local
+}
+
 // Set stores content in cache with the specified TTL
 func (c *Cache) Set(ctx context.Context, shortID, content string, ttl time.Duration) error {
+	defer timing.Track(ctx, timing.PhaseCache)()
+
 	if ttl <= 0 {
 		ttl = c.defaultTTL
 	}
 
 	key := c.buildKey(shortID)
-	return c.client.Set(ctx, key, content, ttl).Err()
+	if err := c.client.Set(ctx, key, content, ttl).Err(); err != nil {
+		return err
+	}
+	if c.local != nil {
+		c.local.Set(shortID, content, ttl)
+	}
+	return nil
 }
 
 // Get retrieves content from cache
 // Returns the content, a boolean indicating if the key was found, and an error
 func (c *Cache) Get(ctx context.Context, shortID string) (string, bool, error) {
+	content, found, _, err := c.get(ctx, shortID)
+	return content, found, err
+}
+
+// get retrieves content from the in-process cache, then from Redis, and reports which one had it
+func (c *Cache) get(ctx context.Context, shortID string) (string, bool, bool, error) {
+	if c.local != nil {
+		if content, ok := c.local.Get(shortID); ok {
+			return content, true, true, nil
+		}
+	}
+
+	defer timing.Track(ctx, timing.PhaseCache)()
+
 	key := c.buildKey(shortID)
 
 	content, err := c.client.Get(ctx, key).Result()
 	if err != nil {
 		if err == redis.Nil {
-			return "", false, nil // Key not found
+			return "", false, false, nil // Key not found
 		}
-		return "", false, err
+		return "", false, false, err
 	}
 
-	return content, true, nil
+	if c.local != nil {
+		c.local.Set(shortID, content, 0)
+	}
+	return content, true, false, nil
+}
+
+// Lookup retrieves content from cache like Get, recording the result in the cache statistics.
+// Redis errors are counted as a bypass since the caller falls back to storage.
+func (c *Cache) Lookup(ctx context.Context, shortID string) (string, bool, error) {
+	content, found, local, err := c.get(ctx, shortID)
+	switch {
+	case err != nil:
+		c.RecordBypass()
+	case found:
+		c.hits.Add(1)
+		metrics.CacheRequests.WithLabelValues(CacheResultHit).Inc()
+		if local {
+			c.localHits.Add(1)
+			metrics.CacheLocalHits.Inc()
+		}
+	default:
+		c.misses.Add(1)
+		metrics.CacheRequests.WithLabelValues(CacheResultMiss).Inc()
+	}
+	return content, found, err
+}
+
+// RecordBypass records a read that deliberately skipped the cache
+func (c *Cache) RecordBypass() {
+	c.bypasses.Add(1)
+	metrics.CacheRequests.WithLabelValues(CacheResultBypass).Inc()
+}
+
+// Stats returns the cache lookup counters
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Bypasses:  c.bypasses.Load(),
+		LocalHits: c.localHits.Load(),
+	}
+}
+
+// Flush removes every cached paste under the cache namespace and returns the number of keys deleted
+func (c *Cache) Flush(ctx context.Context) (int64, error) {
+	if c.local != nil {
+		c.local.Flush()
+	}
+
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, CacheKeyPrefix+"*", cacheFlushBatchSize).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := c.client.Del(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
 }
 
 // Delete removes content from cache
 func (c *Cache) Delete(ctx context.Context, shortID string) error {
+	defer timing.Track(ctx, timing.PhaseCache)()
+
+	if c.local != nil {
+		c.local.Delete(shortID)
+	}
 	key := c.buildKey(shortID)
 	return c.client.Del(ctx, key).Err()
 }
@@ -102,6 +238,24 @@ func (c *Cache) Refresh(ctx context.Context, shortID string, ttl time.Duration)
 	return c.client.Expire(ctx, key, ttl).Err()
 }
 
+// RecordAccess counts a read of shortID and returns the number of reads within window of the
+// first one counted
+func (c *Cache) RecordAccess(ctx context.Context, shortID string, window time.Duration) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseCache)()
+
+	key := CacheAccessKeyPrefix + shortID
+	reads, err := c.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if reads == 1 {
+		if err := c.client.Expire(ctx, key, window).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return reads, nil
+}
+
 // buildKey constructs the cache key for a given shortID
 func (c *Cache) buildKey(shortID string) string {
 	return CacheKeyPrefix + shortID
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/model"
+)
+
+// ErrInvalidCachePolicy is returned when a cache policy has a negative TTL, size or view threshold,
+// or an admission threshold without a window
+var ErrInvalidCachePolicy = errors.New("paste: invalid cache policy")
+
+const (
+	// DefaultCacheHotTTL is how long popular pastes stay cached
+	DefaultCacheHotTTL = 6 * time.Hour
+	// DefaultCacheHotViews is the view count from which a paste is popular
+	DefaultCacheHotViews = 100
+	// DefaultCachePermanentTTL is how long pastes that never expire stay cached
+	DefaultCachePermanentTTL = 24 * time.Hour
+	// DefaultCacheSmallSize is the size in bytes up to which a paste is small
+	DefaultCacheSmallSize = 4 * 1024
+	// DefaultCacheSmallTTL is how long small pastes stay cached
+	DefaultCacheSmallTTL = 24 * time.Hour
+	// DefaultCacheMaxSize is the size in bytes above which content is never cached
+	DefaultCacheMaxSize = 256 * 1024
+	// DefaultCacheAdmitViews is the number of recent reads after which content above the small
+	// size is admitted to the cache
+	DefaultCacheAdmitViews = 2
+	// DefaultCacheAdmitWindow is the time within which those reads must happen
+	DefaultCacheAdmitWindow = time.Hour
+	// CacheAccessKeyPrefix prefixes the Redis counters of recent reads of uncached content; it is
+	// outside the cache namespace so that flushing the cache keeps them
+	CacheAccessKeyPrefix = "gisty:cache:access:"
+)
+
+// Cache admission results
+const (
+	CacheAdmissionAdmitted = "admitted"
+	CacheAdmissionRejected = "rejected"
+)
+
+// CachePolicy decides how long the content of a paste stays in the Redis cache, by class.
+// A paste gets the longest TTL of the classes it belongs to, never outliving the paste itself.
+// A class with a zero TTL is disabled.
+type CachePolicy struct {
+	DefaultTTL time.Duration // every cacheable paste
+
+	HotTTL   time.Duration // pastes read at least HotViews times
+	HotViews int64
+
+	PermanentTTL time.Duration // pastes without an expiration
+
+	SmallTTL  time.Duration // pastes of at most SmallSize bytes, cheap to keep
+	SmallSize int
+
+	// MaxSize is the size in bytes above which content is never cached; 0 caches any size
+	MaxSize int
+
+	// Content above SmallSize that is not hot enters the cache only once read AdmitViews times
+	// within AdmitWindow, so that one-off reads of large pastes do not evict small and popular
+	// ones. The reads are counted in Redis; an AdmitViews of 0 or 1 admits everything.
+	AdmitViews  int64
+	AdmitWindow time.Duration
+}
+
+// DefaultCachePolicy returns the policy used when none is configured
+func DefaultCachePolicy() CachePolicy {
+	return CachePolicy{
+		DefaultTTL:   DefaultCacheTTL,
+		HotTTL:       DefaultCacheHotTTL,
+		HotViews:     DefaultCacheHotViews,
+		PermanentTTL: DefaultCachePermanentTTL,
+		SmallTTL:     DefaultCacheSmallTTL,
+		SmallSize:    DefaultCacheSmallSize,
+		MaxSize:      DefaultCacheMaxSize,
+		AdmitViews:   DefaultCacheAdmitViews,
+		AdmitWindow:  DefaultCacheAdmitWindow,
+	}
+}
+
+// Validate checks that no TTL, size or threshold is negative and that reads are counted
+// within a window when admission is enabled
+func (p CachePolicy) Validate() error {
+	if p.DefaultTTL < 0 || p.HotTTL < 0 || p.PermanentTTL < 0 || p.SmallTTL < 0 ||
+		p.HotViews < 0 || p.SmallSize < 0 || p.MaxSize < 0 || p.AdmitViews < 0 || p.AdmitWindow < 0 {
+		return ErrInvalidCachePolicy
+	}
+	if p.AdmitViews > 1 && p.AdmitWindow == 0 {
+		return ErrInvalidCachePolicy
+	}
+	return nil
+}
+
+// TTL returns how long the content of a paste of size bytes is cached, or false when it is not
+// cached at all: burn-after-read pastes, content above MaxSize and pastes about to expire
+func (p CachePolicy) TTL(paste *model.Paste, size int, now time.Time) (time.Duration, bool) {
+	if paste.BurnAfterRead || (p.MaxSize > 0 && size > p.MaxSize) {
+		return 0, false
+	}
+
+	ttl := p.DefaultTTL
+	if p.HotViews > 0 && paste.ViewCount >= p.HotViews {
+		ttl = max(ttl, p.HotTTL)
+	}
+	if paste.ExpiresAt == nil {
+		ttl = max(ttl, p.PermanentTTL)
+	}
+	if size <= p.SmallSize {
+		ttl = max(ttl, p.SmallTTL)
+	}
+
+	if paste.ExpiresAt != nil {
+		ttl = min(ttl, paste.ExpiresAt.Sub(now))
+	}
+	if ttl <= 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
+// NeedsAdmission reports whether content of size bytes must be read AdmitViews times before
+// it is cached: small and hot pastes are admitted right away
+func (p CachePolicy) NeedsAdmission(paste *model.Paste, size int) bool {
+	if p.AdmitViews <= 1 || size <= p.SmallSize {
+		return false
+	}
+	return p.HotViews == 0 || paste.ViewCount < p.HotViews
+}
+
+// admitToCache counts a read of content of size bytes fetched from storage and reports whether
+// the content may enter the cache. When the reads cannot be counted the content is not cached.
+func (s *PasteService) admitToCache(ctx context.Context, paste *model.Paste, size int) bool {
+	if !s.cachePolicy.NeedsAdmission(paste, size) {
+		return true
+	}
+
+	reads, err := s.cache.RecordAccess(ctx, paste.ShortID, s.cachePolicy.AdmitWindow)
+	if err != nil {
+		log.Printf("[PasteService.admitToCache] Failed to count read of %s: %v", paste.ShortID, err)
+		return false
+	}
+	if reads < s.cachePolicy.AdmitViews {
+		metrics.CacheAdmissions.WithLabelValues(CacheAdmissionRejected).Inc()
+		return false
+	}
+	metrics.CacheAdmissions.WithLabelValues(CacheAdmissionAdmitted).Inc()
+	return true
+}
+
+// cachingReader returns a reader of stream that caches the content of paste once read to the
+// end, when the cache policy allows it. Content is buffered up to MaxSize bytes (MaxContentSize
+// when any size is cached); past that the buffer is dropped and the content streamed through.
+func (s *PasteService) cachingReader(ctx context.Context, paste *model.Paste, stream io.ReadCloser) io.ReadCloser {
+	if paste.BurnAfterRead {
+		return stream
+	}
+	return &cachingReader{
+		ReadCloser: stream,
+		buf:        &bytes.Buffer{},
+		limit:      s.cacheBufferLimit(),
+		done: func(content string) {
+			// The response is still being written; the request is not over yet
+			s.cacheContent(context.WithoutCancel(ctx), paste, content)
+		},
+	}
+}
+
+// compressedCachingReader is cachingReader for a stream of gzip compressed content: the content
+// is decompressed for the cache once read to the end, only when it was small enough to buffer
+func (s *PasteService) compressedCachingReader(ctx context.Context, paste *model.Paste, stream io.ReadCloser) io.ReadCloser {
+	if paste.BurnAfterRead {
+		return stream
+	}
+	limit := s.cacheBufferLimit()
+	return &cachingReader{
+		ReadCloser: stream,
+		buf:        &bytes.Buffer{},
+		limit:      limit,
+		done: func(compressed string) {
+			gz, err := gzip.NewReader(strings.NewReader(compressed))
+			if err != nil {
+				return
+			}
+			content, err := readLimited(gz, int64(limit))
+			if err != nil {
+				return
+			}
+			s.cacheContent(context.WithoutCancel(ctx), paste, string(content))
+		},
+	}
+}
+
+// cacheBufferLimit returns how much content a caching reader buffers: MaxSize, or MaxContentSize
+// when any size is cached
+func (s *PasteService) cacheBufferLimit() int {
+	if s.cachePolicy.MaxSize == 0 {
+		return MaxContentSize
+	}
+	return s.cachePolicy.MaxSize
+}
+
+// cachingReader buffers what is read through it and hands it to done at EOF
+type cachingReader struct {
+	io.ReadCloser
+	buf   *bytes.Buffer
+	limit int
+	done  func(content string)
+}
+
+// Read reads from the stream, buffering until the content turns out larger than the limit
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if r.buf != nil {
+		if r.buf.Len()+n > r.limit {
+			r.buf = nil
+		} else {
+			r.buf.Write(p[:n])
+		}
+	}
+	if errors.Is(err, io.EOF) && r.buf != nil {
+		content := r.buf.String()
+		r.buf = nil
+		r.done(content)
+	}
+	return n, err
+}
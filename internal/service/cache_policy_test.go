@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestCachePolicy_TTL(t *testing.T) {
+	now := time.Now()
+	in := func(d time.Duration) *time.Time {
+		at := now.Add(d)
+		return &at
+	}
+	policy := DefaultCachePolicy()
+
+	tests := []struct {
+		name   string
+		paste  *model.Paste
+		size   int
+		want   time.Duration
+		cached bool
+	}{
+		{"default", &model.Paste{ExpiresAt: in(7 * 24 * time.Hour)}, 10 * 1024, DefaultCacheTTL, true},
+		{"small", &model.Paste{ExpiresAt: in(7 * 24 * time.Hour)}, 100, DefaultCacheSmallTTL, true},
+		{"permanent", &model.Paste{}, 10 * 1024, DefaultCachePermanentTTL, true},
+		{"hot", &model.Paste{ExpiresAt: in(7 * 24 * time.Hour), ViewCount: DefaultCacheHotViews}, 10 * 1024, DefaultCacheHotTTL, true},
+		{"capped by expiry", &model.Paste{ExpiresAt: in(10 * time.Minute)}, 100, 10 * time.Minute, true},
+		{"expired", &model.Paste{ExpiresAt: in(-time.Minute)}, 100, 0, false},
+		{"too large", &model.Paste{}, DefaultCacheMaxSize + 1, 0, false},
+		{"burn after read", &model.Paste{BurnAfterRead: true}, 100, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, cached := policy.TTL(tt.paste, tt.size, now)
+			if cached != tt.cached || got != tt.want {
+				t.Errorf("TTL() = %v, %v, want %v, %v", got, cached, tt.want, tt.cached)
+			}
+		})
+	}
+
+	// Disabled classes and size limit
+	policy.PermanentTTL = 0
+	policy.MaxSize = 0
+	if got, _ := policy.TTL(&model.Paste{}, DefaultCacheMaxSize+1, now); got != DefaultCacheTTL {
+		t.Errorf("TTL() without permanent class = %v, want %v", got, DefaultCacheTTL)
+	}
+
+	if err := (CachePolicy{DefaultTTL: -time.Second}).Validate(); err != ErrInvalidCachePolicy {
+		t.Errorf("Validate() error = %v, want %v", err, ErrInvalidCachePolicy)
+	}
+}
+
+func TestCachePolicy_NeedsAdmission(t *testing.T) {
+	policy := DefaultCachePolicy()
+
+	tests := []struct {
+		name  string
+		paste *model.Paste
+		size  int
+		want  bool
+	}{
+		{"small", &model.Paste{}, DefaultCacheSmallSize, false},
+		{"large", &model.Paste{}, DefaultCacheSmallSize + 1, true},
+		{"large and hot", &model.Paste{ViewCount: DefaultCacheHotViews}, DefaultCacheSmallSize + 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.NeedsAdmission(tt.paste, tt.size); got != tt.want {
+				t.Errorf("NeedsAdmission() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	policy.AdmitViews = 1
+	if policy.NeedsAdmission(&model.Paste{}, DefaultCacheMaxSize) {
+		t.Error("NeedsAdmission() with admission disabled = true, want false")
+	}
+
+	if err := (CachePolicy{AdmitViews: 2}).Validate(); err != ErrInvalidCachePolicy {
+		t.Errorf("Validate() without admission window error = %v, want %v", err, ErrInvalidCachePolicy)
+	}
+}
+
+func TestCachingReader(t *testing.T) {
+	read := func(content string, limit int) (string, bool) {
+		var cached string
+		called := false
+		r := &cachingReader{
+			ReadCloser: io.NopCloser(strings.NewReader(content)),
+			buf:        &bytes.Buffer{},
+			limit:      limit,
+			done:       func(c string) { cached, called = c, true },
+		}
+		got, err := io.ReadAll(r)
+		if err != nil || string(got) != content {
+			t.Fatalf("ReadAll() = %q, %v, want %q", got, err, content)
+		}
+		return cached, called
+	}
+
+	if cached, called := read("hello world", 64); !called || cached != "hello world" {
+		t.Errorf("cached %q (%v), want the whole content", cached, called)
+	}
+	if _, called := read(strings.Repeat("x", 65), 64); called {
+		t.Error("content over the limit was cached")
+	}
+}
@@ -261,4 +261,115 @@ func TestCache_SetWithDefaultTTL(t *testing.T) {
 	if remainingTTL < 29*time.Minute || remainingTTL > customTTL {
 		t.Errorf("Default TTL = %v, want close to %v", remainingTTL, customTTL)
 	}
-}
\ No newline at end of file
+}
+
+func TestCache_LookupStats(t *testing.T) {
+	cache, cleanup := setupTestCache(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	if err := cache.Set(ctx, "test004", "cached", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if _, found, err := cache.Lookup(ctx, "test004"); err != nil || !found {
+		t.Fatalf("Lookup() found = %v, err = %v, want hit", found, err)
+	}
+	if _, found, err := cache.Lookup(ctx, "nonexistent"); err != nil || found {
+		t.Fatalf("Lookup() found = %v, err = %v, want miss", found, err)
+	}
+	cache.RecordBypass()
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Bypasses != 1 {
+		t.Errorf("Stats() = %+v, want 1 hit, 1 miss, 1 bypass", stats)
+	}
+	if stats.HitRatio() != 0.5 {
+		t.Errorf("HitRatio() = %v, want 0.5", stats.HitRatio())
+	}
+}
+
+func TestCache_Flush(t *testing.T) {
+	cache, cleanup := setupTestCache(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for _, shortID := range []string{"test004", "test005"} {
+		if err := cache.Set(ctx, shortID, "content", time.Minute); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+
+	deleted, err := cache.Flush(ctx)
+	if err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if deleted < 2 {
+		t.Errorf("Flush() deleted = %d, want at least 2", deleted)
+	}
+
+	for _, shortID := range []string{"test004", "test005"} {
+		exists, _ := cache.Exists(ctx, shortID)
+		if exists {
+			t.Errorf("Exists(%s) = true after Flush()", shortID)
+		}
+	}
+}
+
+func TestCache_RecordAccess(t *testing.T) {
+	cache, cleanup := setupTestCache(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	defer cache.client.Del(ctx, CacheAccessKeyPrefix+"test001")
+
+	for want := int64(1); want <= 3; want++ {
+		reads, err := cache.RecordAccess(ctx, "test001", time.Minute)
+		if err != nil {
+			t.Fatalf("RecordAccess() error = %v", err)
+		}
+		if reads != want {
+			t.Errorf("RecordAccess() = %d, want %d", reads, want)
+		}
+	}
+
+	// The window starts with the first read
+	ttl, err := cache.client.TTL(ctx, CacheAccessKeyPrefix+"test001").Result()
+	if err != nil {
+		t.Fatalf("TTL() error = %v", err)
+	}
+	if ttl <= 0 || ttl > time.Minute {
+		t.Errorf("access counter TTL = %v, want within 1m", ttl)
+	}
+}
+
+func TestCache_Local(t *testing.T) {
+	cache, cleanup := setupTestCache(t)
+	defer cleanup()
+	cache.SetLocal(NewLocalCache(1024, time.Minute))
+
+	ctx := context.Background()
+	if err := cache.Set(ctx, "test001", "Hello, World!", time.Minute); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// Served from memory even once Redis lost the key
+	cache.client.Del(ctx, "paste:test001")
+	content, found, err := cache.Lookup(ctx, "test001")
+	if err != nil || !found || content != "Hello, World!" {
+		t.Fatalf("Lookup() = %q, %v, %v, want the content from the local cache", content, found, err)
+	}
+	if stats := cache.Stats(); stats.LocalHits != 1 || stats.Hits != 1 {
+		t.Errorf("Stats() = %+v, want 1 hit served locally", stats)
+	}
+
+	// Deleting removes it from both
+	if err := cache.Delete(ctx, "test001"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, found, _ := cache.Get(ctx, "test001"); found {
+		t.Error("Get() should miss after Delete")
+	}
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+const (
+	// CDNProviderFastly purges through the Fastly API, by Surrogate-Key
+	CDNProviderFastly = "fastly"
+	// CDNProviderCloudflare purges through the Cloudflare API, by Cache-Tag
+	CDNProviderCloudflare = "cloudflare"
+
+	// ViewsSurrogateKey tags every rendered view, to purge them all at once, as after a change of
+	// the page template
+	ViewsSurrogateKey = "gisty-views"
+
+	// DefaultCDNPurgeTimeout bounds each purge request
+	DefaultCDNPurgeTimeout = 10 * time.Second
+)
+
+var (
+	// ErrUnknownCDNProvider is returned when the configured CDN provider is not supported
+	ErrUnknownCDNProvider = errors.New("cdn: unknown provider")
+	// ErrCDNPurgeRejected is returned when the CDN answers a purge with a non-2xx status
+	ErrCDNPurgeRejected = errors.New("cdn: purge rejected")
+)
+
+// SurrogateKey returns the key tagging the rendered views of a paste at the edge, purged when the
+// paste changes or is deleted
+func SurrogateKey(shortID string) string {
+	return "paste-" + shortID
+}
+
+// CDNPurger removes cached responses from a CDN fronting the server, by surrogate key
+type CDNPurger struct {
+	provider string
+	url      string
+	token    string
+	client   *http.Client
+}
+
+// NewCDNPurger creates a CDNPurger calling the purge API of the provider at url, e.g.
+// https://api.fastly.com/service/<id>/purge or
+// https://api.cloudflare.com/client/v4/zones/<zone>/purge_cache
+func NewCDNPurger(provider, url, token string) (*CDNPurger, error) {
+	switch provider {
+	case CDNProviderFastly, CDNProviderCloudflare:
+	default:
+		return nil, fmt.Errorf("%w: %q (supported: %s, %s)", ErrUnknownCDNProvider, provider, CDNProviderFastly, CDNProviderCloudflare)
+	}
+	return &CDNPurger{
+		provider: provider,
+		url:      url,
+		token:    token,
+		client:   &http.Client{Timeout: DefaultCDNPurgeTimeout},
+	}, nil
+}
+
+// Purge removes the responses tagged with any of the keys from the CDN cache
+func (p *CDNPurger) Purge(ctx context.Context, keys ...string) error {
+	var body []byte
+	if p.provider == CDNProviderCloudflare {
+		var err error
+		if body, err = json.Marshal(map[string][]string{"tags": keys}); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	switch p.provider {
+	case CDNProviderFastly:
+		req.Header.Set("Surrogate-Key", strings.Join(keys, " "))
+		req.Header.Set("Fastly-Key", p.token)
+	case CDNProviderCloudflare:
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cdn: purge failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: status %d", ErrCDNPurgeRejected, resp.StatusCode)
+	}
+	return nil
+}
+
+// HandleEvent purges the rendered views of pastes that were edited or deleted; it is subscribed
+// to the event bus
+func (p *CDNPurger) HandleEvent(ctx context.Context, event *model.PasteEvent) error {
+	if event.Type != model.PasteEventUpdated && event.Type != model.PasteEventDeleted {
+		return nil
+	}
+	return p.Purge(ctx, SurrogateKey(event.ShortID))
+}
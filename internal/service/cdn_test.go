@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestCDNPurger_Purge(t *testing.T) {
+	var got *http.Request
+	var body map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	fastly, err := NewCDNPurger(CDNProviderFastly, server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewCDNPurger() error = %v", err)
+	}
+	if err := fastly.Purge(ctx, "paste-abc", ViewsSurrogateKey); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if got.Method != http.MethodPost || got.Header.Get("Surrogate-Key") != "paste-abc gisty-views" || got.Header.Get("Fastly-Key") != "secret" {
+		t.Errorf("Fastly purge = %s Surrogate-Key %q Fastly-Key %q", got.Method, got.Header.Get("Surrogate-Key"), got.Header.Get("Fastly-Key"))
+	}
+
+	cloudflare, err := NewCDNPurger(CDNProviderCloudflare, server.URL, "secret")
+	if err != nil {
+		t.Fatalf("NewCDNPurger() error = %v", err)
+	}
+	if err := cloudflare.Purge(ctx, "paste-abc"); err != nil {
+		t.Fatalf("Purge() error = %v", err)
+	}
+	if got.Header.Get("Authorization") != "Bearer secret" || len(body["tags"]) != 1 || body["tags"][0] != "paste-abc" {
+		t.Errorf("Cloudflare purge = Authorization %q, body %v", got.Header.Get("Authorization"), body)
+	}
+
+	if _, err := NewCDNPurger("akamai", server.URL, ""); !errors.Is(err, ErrUnknownCDNProvider) {
+		t.Errorf("NewCDNPurger(akamai) error = %v, want %v", err, ErrUnknownCDNProvider)
+	}
+}
+
+func TestCDNPurger_HandleEvent(t *testing.T) {
+	var purged []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		purged = append(purged, r.Header.Get("Surrogate-Key"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	purger, err := NewCDNPurger(CDNProviderFastly, server.URL, "")
+	if err != nil {
+		t.Fatalf("NewCDNPurger() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Reads and creations leave the CDN alone
+	for _, eventType := range []model.PasteEventType{model.PasteEventCreated, model.PasteEventRead, model.PasteEventUpdated, model.PasteEventDeleted} {
+		if err := purger.HandleEvent(ctx, &model.PasteEvent{Type: eventType, ShortID: "abc"}); err != nil {
+			t.Errorf("HandleEvent(%s) error = %v", eventType, err)
+		}
+	}
+	if len(purged) != 2 || purged[0] != "paste-abc" || purged[1] != "paste-abc" {
+		t.Errorf("purged keys = %v, want paste-abc on update and delete", purged)
+	}
+
+	status = http.StatusForbidden
+	err = purger.HandleEvent(ctx, &model.PasteEvent{Type: model.PasteEventDeleted, ShortID: "abc"})
+	if !errors.Is(err, ErrCDNPurgeRejected) {
+		t.Errorf("HandleEvent() of a rejected purge error = %v, want %v", err, ErrCDNPurgeRejected)
+	}
+}
@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/huylvt/gisty/internal/mail"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// MaxCommentLength caps the characters of a comment
+	MaxCommentLength = 2000
+	// DefaultCommentLimit is the number of comments or notifications of a page when no limit is given
+	DefaultCommentLimit = 50
+	// MaxCommentLimit caps the number of comments or notifications of a page
+	MaxCommentLimit = 100
+	// maxMentions caps the users notified of a comment mentioning them; later mentions are ignored
+	maxMentions = 10
+	// maxMentionLength is the length of the longest usernames
+	maxMentionLength = 39
+	// excerptLength is the number of characters of a comment quoted in its notifications
+	excerptLength = 140
+	// digestLimit caps the notifications listed in one email digest
+	digestLimit = 50
+)
+
+var (
+	// ErrCommentsDisabled is returned when the server keeps no comments
+	ErrCommentsDisabled = errors.New("paste: comments are disabled")
+	// ErrInvalidComment is returned for an empty comment or one longer than MaxCommentLength
+	ErrInvalidComment = errors.New("paste: invalid comment")
+	// ErrCommentsClosed is returned when commenting on a paste that disappears once read:
+	// burn-after-read or view-limited
+	ErrCommentsClosed = errors.New("paste: comments are closed")
+)
+
+// mentionPattern matches @username mentions of lowercased text, not preceded by a word character
+// so that email addresses are not mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([a-z0-9]+(?:-[a-z0-9]+)*)`)
+
+// CreateCommentRequest represents the request body for commenting on a paste
+type CreateCommentRequest struct {
+	// Body is the comment; @username mentions notify those users
+	Body string `json:"body" binding:"required" example:"Nice, but @octocat has a faster version"`
+}
+
+// CommentsResponse lists the comments on a paste, newest first
+type CommentsResponse struct {
+	Comments []*model.Comment `json:"comments"`
+	// Next is the before cursor of the next page; empty on the last page
+	Next string `json:"next,omitempty" example:"2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"`
+}
+
+// NotificationsResponse lists the notifications of a user, newest first
+type NotificationsResponse struct {
+	Notifications []*model.Notification `json:"notifications"`
+	// Unread counts all the unread notifications of the user
+	Unread int64 `json:"unread" example:"3"`
+	// Next is the before cursor of the next page; empty on the last page
+	Next string `json:"next,omitempty" example:"2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"`
+}
+
+// MarkReadRequest represents the request body for marking notifications read
+type MarkReadRequest struct {
+	// IDs lists the notifications to mark read; All marks every notification read instead
+	IDs []string `json:"ids,omitempty" example:"65a4f1c2e4b0a1b2c3d4e5f6"`
+	All bool     `json:"all,omitempty" example:"false"`
+}
+
+// MarkReadResponse represents the result of marking notifications read
+type MarkReadResponse struct {
+	Marked int64 `json:"marked" example:"3"`
+	Unread int64 `json:"unread" example:"0"`
+}
+
+// SetComments enables comments on pastes, with notifications of the users commented on or
+// mentioned. Without them, comments are disabled.
+func (s *PasteService) SetComments(comments *repository.CommentRepository, notifications *repository.NotificationRepository, users *repository.UserRepository) {
+	s.commentRepo = comments
+	s.notificationRepo = notifications
+	s.userRepo = users
+}
+
+// CreateComment adds a comment of a signed-in user to a paste, notifying the user who created
+// the paste and the users it mentions
+func (s *PasteService) CreateComment(ctx context.Context, userID, shortID string, req *CreateCommentRequest) (*model.Comment, error) {
+	if s.commentRepo == nil {
+		return nil, ErrCommentsDisabled
+	}
+	if userID == "" {
+		return nil, ErrSignInRequired
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" || utf8.RuneCountInString(body) > MaxCommentLength || !utf8.ValidString(body) {
+		return nil, ErrInvalidComment
+	}
+
+	paste, err := s.commentablePaste(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+	if paste.BurnAfterRead || paste.MaxViews > 0 {
+		return nil, ErrCommentsClosed
+	}
+	author, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrSignInRequired
+		}
+		return nil, fmt.Errorf("paste: failed to get user: %w", err)
+	}
+
+	comment := &model.Comment{
+		ShortID:   paste.ShortID,
+		UserID:    userID,
+		Username:  author.Username,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
+		return nil, fmt.Errorf("paste: failed to create comment: %w", err)
+	}
+
+	// The comment is posted even when its notifications fail
+	if err := s.notifyComment(ctx, paste, comment); err != nil {
+		log.Printf("[PasteService.CreateComment] Failed to notify comment %s: %v", comment.ID, err)
+	}
+	return comment, nil
+}
+
+// ListComments returns up to limit comments on a paste, newest first, starting after the cursor
+// when it is not zero
+func (s *PasteService) ListComments(ctx context.Context, shortID string, after repository.PageCursor, limit int) (*CommentsResponse, error) {
+	if s.commentRepo == nil {
+		return nil, ErrCommentsDisabled
+	}
+	if _, err := s.commentablePaste(ctx, shortID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultCommentLimit
+	}
+	limit = min(limit, MaxCommentLimit)
+
+	comments, err := s.commentRepo.ListByShortID(ctx, shortID, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list comments: %w", err)
+	}
+	response := &CommentsResponse{Comments: comments}
+	if len(comments) > limit {
+		response.Comments = comments[:limit]
+		last := comments[limit-1]
+		response.Next = repository.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.String()
+	}
+	return response, nil
+}
+
+// ListNotifications returns up to limit notifications of a signed-in user, newest first,
+// starting after the cursor when it is not zero, only the unread ones when unreadOnly is set
+func (s *PasteService) ListNotifications(ctx context.Context, userID string, unreadOnly bool, after repository.PageCursor, limit int) (*NotificationsResponse, error) {
+	if s.notificationRepo == nil {
+		return nil, ErrCommentsDisabled
+	}
+	if userID == "" {
+		return nil, ErrSignInRequired
+	}
+	if limit <= 0 {
+		limit = DefaultCommentLimit
+	}
+	limit = min(limit, MaxCommentLimit)
+
+	notifications, err := s.notificationRepo.ListByUser(ctx, userID, unreadOnly, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list notifications: %w", err)
+	}
+	unread, err := s.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to count notifications: %w", err)
+	}
+
+	response := &NotificationsResponse{Notifications: notifications, Unread: unread}
+	if len(notifications) > limit {
+		response.Notifications = notifications[:limit]
+		last := notifications[limit-1]
+		response.Next = repository.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.String()
+	}
+	return response, nil
+}
+
+// MarkNotificationsRead marks notifications of a signed-in user read: the listed ones, or all
+// of them
+func (s *PasteService) MarkNotificationsRead(ctx context.Context, userID string, req *MarkReadRequest) (*MarkReadResponse, error) {
+	if s.notificationRepo == nil {
+		return nil, ErrCommentsDisabled
+	}
+	if userID == "" {
+		return nil, ErrSignInRequired
+	}
+
+	response := &MarkReadResponse{}
+	if req.All || len(req.IDs) > 0 {
+		var ids []string
+		if !req.All {
+			ids = req.IDs
+		}
+		marked, err := s.notificationRepo.MarkRead(ctx, userID, ids, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("paste: failed to mark notifications read: %w", err)
+		}
+		response.Marked = marked
+	}
+	unread, err := s.notificationRepo.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to count notifications: %w", err)
+	}
+	response.Unread = unread
+	return response, nil
+}
+
+// SendNotificationDigests emails each user who opted in to digests the unread notifications they
+// were not sent yet, and returns the number of emails sent. Notifications of users who did not opt
+// in are recorded as digested, so turning digests on later does not send old ones.
+func (s *PasteService) SendNotificationDigests(ctx context.Context, mailer mail.Mailer) (int, error) {
+	if s.notificationRepo == nil {
+		return 0, ErrCommentsDisabled
+	}
+	userIDs, err := s.notificationRepo.ListDigestUsers(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("paste: failed to list digest users: %w", err)
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		notifications, err := s.notificationRepo.ListUndigested(ctx, userID, digestLimit)
+		if err != nil {
+			return sent, fmt.Errorf("paste: failed to list notifications: %w", err)
+		}
+		if len(notifications) == 0 {
+			continue
+		}
+
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+			return sent, fmt.Errorf("paste: failed to get user: %w", err)
+		}
+		if user != nil && user.EmailDigest && user.Email != "" {
+			subject, body := s.digest(notifications)
+			if err := mailer.Send(ctx, user.Email, subject, body); err != nil {
+				// Left undigested, the notifications are sent with the next digest
+				log.Printf("[PasteService.SendNotificationDigests] Failed to email user %s: %v", userID, err)
+				continue
+			}
+			sent++
+		}
+
+		ids := make([]string, len(notifications))
+		for i, n := range notifications {
+			ids[i] = n.ID
+		}
+		if err := s.notificationRepo.MarkDigested(ctx, ids, time.Now()); err != nil {
+			return sent, fmt.Errorf("paste: failed to mark notifications digested: %w", err)
+		}
+	}
+	return sent, nil
+}
+
+// commentablePaste returns the paste comments are read from or posted to
+func (s *PasteService) commentablePaste(ctx context.Context, shortID string) (*model.Paste, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	return paste, nil
+}
+
+// notifyComment notifies the user who created the paste of a comment on it, and the users the
+// comment mentions. Nobody is notified of their own comment, nor twice of the same comment.
+func (s *PasteService) notifyComment(ctx context.Context, paste *model.Paste, comment *model.Comment) error {
+	notified := map[string]bool{comment.UserID: true}
+	var notifications []*model.Notification
+	add := func(userID, kind string) {
+		if notified[userID] {
+			return
+		}
+		notified[userID] = true
+		notifications = append(notifications, &model.Notification{
+			UserID:    userID,
+			Kind:      kind,
+			ShortID:   comment.ShortID,
+			CommentID: comment.ID,
+			Actor:     comment.Username,
+			Excerpt:   excerpt(comment.Body),
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+
+	if paste.UserID != nil {
+		add(*paste.UserID, model.NotificationComment)
+	}
+	for _, username := range ParseMentions(comment.Body) {
+		user, err := s.userRepo.GetByUsername(ctx, username)
+		if errors.Is(err, repository.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		add(user.ID, model.NotificationMention)
+	}
+	return s.notificationRepo.CreateMany(ctx, notifications)
+}
+
+// digest returns the subject and body of the email listing notifications
+func (s *PasteService) digest(notifications []*model.Notification) (string, string) {
+	subject := "1 new notification on Gisty"
+	if len(notifications) > 1 {
+		subject = fmt.Sprintf("%d new notifications on Gisty", len(notifications))
+	}
+
+	var b strings.Builder
+	for _, n := range notifications {
+		actor := n.Actor
+		if actor == "" {
+			actor = "Someone"
+		}
+		action := "commented on your paste"
+		if n.Kind == model.NotificationMention {
+			action = "mentioned you on"
+		}
+		fmt.Fprintf(&b, "%s %s %s:\n  %s\n  %s\n\n", actor, action, n.ShortID, n.Excerpt, s.buildURL(n.ShortID))
+	}
+	b.WriteString("You get this email because you turned on notification digests. Turn them off with\n")
+	b.WriteString(`PUT /api/v1/me/profile {"email_digest": false}.` + "\n")
+	return subject, b.String()
+}
+
+// ParseMentions returns the distinct usernames mentioned as @username in a comment, lowercased,
+// up to maxMentions
+func ParseMentions(body string) []string {
+	var usernames []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(strings.ToLower(body), -1) {
+		username := m[1]
+		if seen[username] || len(username) > maxMentionLength {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == maxMentions {
+			break
+		}
+	}
+	return usernames
+}
+
+// excerpt returns the beginning of a comment on a single line
+func excerpt(body string) string {
+	text := strings.Join(strings.Fields(body), " ")
+	if utf8.RuneCountInString(text) <= excerptLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:excerptLength-1]) + "…"
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{body: "no mentions", want: nil},
+		{body: "@octocat look", want: []string{"octocat"}},
+		{body: "cc @Octo-Cat, @bob and @octo-cat again", want: []string{"octo-cat", "bob"}},
+		{body: "mail me at me@example.com", want: nil},
+		{body: "(@alice) @bob-", want: []string{"alice", "bob"}},
+		{body: "@@alice @-bob", want: nil},
+		{body: "@" + strings.Repeat("a", 40), want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := ParseMentions(tt.body); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMentions(%q) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+
+	var many []string
+	for i := 0; i < maxMentions+5; i++ {
+		many = append(many, "@user"+string(rune('a'+i)))
+	}
+	if got := ParseMentions(strings.Join(many, " ")); len(got) != maxMentions {
+		t.Errorf("ParseMentions() of %d mentions = %d usernames, want %d", len(many), len(got), maxMentions)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := excerpt("  line one\n\tline two "); got != "line one line two" {
+		t.Errorf("excerpt() = %q, want the comment on one line", got)
+	}
+	long := strings.Repeat("é", excerptLength+10)
+	if got := excerpt(long); len([]rune(got)) != excerptLength || !strings.HasSuffix(got, "…") {
+		t.Errorf("excerpt() of a long comment = %q, want %d characters ending with an ellipsis", got, excerptLength)
+	}
+}
+
+// fakeMailer records the emails sent
+type fakeMailer struct {
+	sent map[string]string
+}
+
+func (m *fakeMailer) Send(_ context.Context, to, subject, body string) error {
+	m.sent[to] = subject + "\n" + body
+	return nil
+}
+
+func TestPasteService_Comments(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now()
+	users := map[string]*model.User{}
+	for _, username := range []string{"owner", "alice", "bob"} {
+		user, err := svc.userRepo.UpsertByProvider(ctx, &model.User{Provider: "github", ProviderID: username, Email: username + "@example.com"}, now)
+		if err != nil {
+			t.Fatalf("UpsertByProvider() error = %v", err)
+		}
+		if err := svc.userRepo.SetUsername(ctx, user.ID, username); err != nil {
+			t.Fatalf("SetUsername() error = %v", err)
+		}
+		user.Username = username
+		users[username] = user
+	}
+
+	paste, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "package main", UserID: users["owner"].ID})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	comment, err := svc.CreateComment(ctx, users["alice"].ID, paste.ShortID, &CreateCommentRequest{Body: " Nice, @owner! cc @bob @alice @nobody "})
+	if err != nil {
+		t.Fatalf("CreateComment() error = %v", err)
+	}
+	if comment.Username != "alice" || comment.Body != "Nice, @owner! cc @bob @alice @nobody" {
+		t.Errorf("CreateComment() = %+v, want alice's trimmed comment", comment)
+	}
+
+	comments, err := svc.ListComments(ctx, paste.ShortID, repository.PageCursor{}, 0)
+	if err != nil || len(comments.Comments) != 1 || comments.Comments[0].ID != comment.ID {
+		t.Errorf("ListComments() = %+v, %v, want the comment", comments, err)
+	}
+
+	// The owner is notified once of the comment, bob of the mention, alice of nothing
+	for username, wantKind := range map[string]string{"owner": model.NotificationComment, "bob": model.NotificationMention, "alice": ""} {
+		inbox, err := svc.ListNotifications(ctx, users[username].ID, true, repository.PageCursor{}, 0)
+		if err != nil {
+			t.Fatalf("ListNotifications(%s) error = %v", username, err)
+		}
+		if wantKind == "" {
+			if len(inbox.Notifications) != 0 {
+				t.Errorf("ListNotifications(%s) = %+v, want none", username, inbox.Notifications)
+			}
+			continue
+		}
+		if len(inbox.Notifications) != 1 || inbox.Unread != 1 || inbox.Notifications[0].Kind != wantKind || inbox.Notifications[0].Actor != "alice" {
+			t.Errorf("ListNotifications(%s) = %+v, want one %s notification", username, inbox, wantKind)
+		}
+	}
+
+	marked, err := svc.MarkNotificationsRead(ctx, users["owner"].ID, &MarkReadRequest{All: true})
+	if err != nil || marked.Marked != 1 || marked.Unread != 0 {
+		t.Errorf("MarkNotificationsRead() = %+v, %v, want 1 marked and none unread", marked, err)
+	}
+
+	// Only bob turned digests on; a second run sends nothing new
+	on := true
+	if _, err := svc.userRepo.UpdateSettings(ctx, users["bob"].ID, nil, &on); err != nil {
+		t.Fatalf("UpdateSettings() error = %v", err)
+	}
+	mailer := &fakeMailer{sent: map[string]string{}}
+	sent, err := svc.SendNotificationDigests(ctx, mailer)
+	if err != nil || sent != 1 || !strings.Contains(mailer.sent["bob@example.com"], "alice mentioned you on "+paste.ShortID) {
+		t.Errorf("SendNotificationDigests() = %d, %v, sent %v, want a digest to bob", sent, err, mailer.sent)
+	}
+	if sent, err := svc.SendNotificationDigests(ctx, mailer); err != nil || sent != 0 {
+		t.Errorf("SendNotificationDigests() again = %d, %v, want none", sent, err)
+	}
+
+	if _, err := svc.CreateComment(ctx, users["alice"].ID, paste.ShortID, &CreateCommentRequest{Body: "  "}); err != ErrInvalidComment {
+		t.Errorf("CreateComment() of a blank comment error = %v, want %v", err, ErrInvalidComment)
+	}
+	if _, err := svc.CreateComment(ctx, "", paste.ShortID, &CreateCommentRequest{Body: "hi"}); err != ErrSignInRequired {
+		t.Errorf("CreateComment() signed out error = %v, want %v", err, ErrSignInRequired)
+	}
+	burn, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "secret", ExpiresIn: "burn"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	if _, err := svc.CreateComment(ctx, users["alice"].ID, burn.ShortID, &CreateCommentRequest{Body: "hi"}); err != ErrCommentsClosed {
+		t.Errorf("CreateComment() on a burn-after-read paste error = %v, want %v", err, ErrCommentsClosed)
+	}
+	if _, err := svc.ListComments(ctx, "missing", repository.PageCursor{}, 0); err != ErrPasteNotFound {
+		t.Errorf("ListComments() of a missing paste error = %v, want %v", err, ErrPasteNotFound)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is a codec content is compressed with in storage. Stored objects are recognised by
+// the magic number their codec starts them with, so objects written with another codec, or
+// uploaded uncompressed by clients, are read back whatever the codec configured.
+type Compression string
+
+const (
+	// CompressionGzip compresses content with gzip, the default
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd compresses content with Zstandard, for better ratios and much faster
+	// decompression of large pastes
+	CompressionZstd Compression = "zstd"
+	// compressionNone marks objects stored as-is
+	compressionNone Compression = ""
+
+	// compressionMagicLength is the number of leading bytes the codec of an object is told from
+	compressionMagicLength = 4
+)
+
+// ErrInvalidCompression is returned for an unknown compression codec
+var ErrInvalidCompression = errors.New("storage: invalid compression")
+
+// zstdMagic starts every Zstandard frame
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// ParseCompression returns the codec with the given name; an empty name is gzip
+func ParseCompression(name string) (Compression, error) {
+	switch Compression(name) {
+	case "", CompressionGzip:
+		return CompressionGzip, nil
+	case CompressionZstd:
+		return CompressionZstd, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidCompression, name)
+	}
+}
+
+// newWriter returns a writer compressing into w; closing it flushes the compressed stream
+func (c Compression) newWriter(w io.Writer) (io.WriteCloser, error) {
+	if c == CompressionZstd {
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	}
+	return gzip.NewWriter(w), nil
+}
+
+// detectCompression returns the codec of stored content from its leading bytes
+func detectCompression(magic []byte) Compression {
+	switch {
+	case isGzip(magic):
+		return CompressionGzip
+	case bytes.HasPrefix(magic, zstdMagic):
+		return CompressionZstd
+	default:
+		return compressionNone
+	}
+}
+
+// newDecompressor returns a reader decompressing r, compressed with the given codec
+func newDecompressor(c Compression, r io.Reader) (io.ReadCloser, error) {
+	switch c {
+	case CompressionGzip:
+		return gzip.NewReader(r)
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	default:
+		return io.NopCloser(r), nil
+	}
+}
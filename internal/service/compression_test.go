@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParseCompression(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Compression
+		wantErr bool
+	}{
+		{"", CompressionGzip, false},
+		{"gzip", CompressionGzip, false},
+		{"zstd", CompressionZstd, false},
+		{"brotli", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCompression(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseCompression(%q) = %q, %v, want %q, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+		if tt.wantErr && !errors.Is(err, ErrInvalidCompression) {
+			t.Errorf("ParseCompression(%q) error = %v, want %v", tt.name, err, ErrInvalidCompression)
+		}
+	}
+}
+
+func TestCompression_RoundTrip(t *testing.T) {
+	content := strings.Repeat("func main() { println(\"hello\") }\n", 100)
+
+	for _, compression := range []Compression{CompressionGzip, CompressionZstd} {
+		var buf bytes.Buffer
+		w, err := compression.newWriter(&buf)
+		if err != nil {
+			t.Fatalf("%s: newWriter() error = %v", compression, err)
+		}
+		if _, err := io.WriteString(w, content); err != nil {
+			t.Fatalf("%s: Write() error = %v", compression, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close() error = %v", compression, err)
+		}
+		if buf.Len() >= len(content) {
+			t.Errorf("%s: compressed to %d bytes, want less than %d", compression, buf.Len(), len(content))
+		}
+
+		// The codec is recognised from the stored bytes alone
+		if got := detectCompression(buf.Bytes()); got != compression {
+			t.Errorf("detectCompression() = %q, want %q", got, compression)
+		}
+		got, err := decompressContent(buf.Bytes(), DefaultMaxDecompressedSize)
+		if err != nil || got != content {
+			t.Errorf("%s: decompressContent() = %d bytes, %v, want the %d written", compression, len(got), err, len(content))
+		}
+		if _, err := decompressContent(buf.Bytes(), int64(len(content)-1)); !errors.Is(err, ErrDecompressionBomb) {
+			t.Errorf("%s: decompressContent() over limit error = %v, want %v", compression, err, ErrDecompressionBomb)
+		}
+	}
+
+	// Content uploaded as-is is read unchanged
+	if got := detectCompression([]byte("plain text")); got != compressionNone {
+		t.Errorf("detectCompression() of plain text = %q, want none", got)
+	}
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrLineTooLong is returned when content has a line longer than the configured maximum
+	ErrLineTooLong = errors.New("paste: line exceeds maximum length")
+	// ErrBinaryContent is returned when content contains NUL bytes
+	ErrBinaryContent = errors.New("paste: content contains NUL bytes")
+	// ErrInvalidContentPolicy is returned when the content policy action is unknown
+	ErrInvalidContentPolicy = errors.New("paste: invalid content policy")
+)
+
+// Content policy actions, applied to pastes with overlong lines or NUL bytes
+const (
+	// ContentPolicyReject refuses the paste
+	ContentPolicyReject = "reject"
+	// ContentPolicyMarkBinary stores the paste as binary: it is served as application/octet-stream
+	// and never syntax highlighted
+	ContentPolicyMarkBinary = "binary"
+	// ContentPolicyTruncatePreview stores the paste unchanged but renders a preview with NUL bytes
+	// replaced and long lines cut
+	ContentPolicyTruncatePreview = "truncate"
+)
+
+const (
+	// DefaultMaxLineLength is the default maximum length in bytes of a single line
+	DefaultMaxLineLength = 16 * 1024
+	// previewEllipsis marks a line cut in the preview
+	previewEllipsis = "…"
+)
+
+// ContentPolicy decides what happens to pastes that could cause renderer or browser pathologies:
+// extremely long single lines and embedded NUL bytes.
+type ContentPolicy struct {
+	MaxLineLength int
+	Action        string
+}
+
+// ContentFlags records how a paste was classified by the content policy
+type ContentFlags struct {
+	Binary           bool
+	PreviewTruncated bool
+}
+
+// DefaultContentPolicy returns the policy used when none is configured
+func DefaultContentPolicy() ContentPolicy {
+	return ContentPolicy{
+		MaxLineLength: DefaultMaxLineLength,
+		Action:        ContentPolicyTruncatePreview,
+	}
+}
+
+// Validate checks the policy action
+func (p ContentPolicy) Validate() error {
+	switch p.Action {
+	case ContentPolicyReject, ContentPolicyMarkBinary, ContentPolicyTruncatePreview:
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidContentPolicy, p.Action)
+	}
+}
+
+// Apply inspects content and returns its flags, or an error if the policy rejects it
+func (p ContentPolicy) Apply(content string) (ContentFlags, error) {
+	hasNUL, longLine := p.inspect(content)
+	if !hasNUL && !longLine {
+		return ContentFlags{}, nil
+	}
+
+	switch p.Action {
+	case ContentPolicyReject:
+		if hasNUL {
+			return ContentFlags{}, ErrBinaryContent
+		}
+		return ContentFlags{}, ErrLineTooLong
+	case ContentPolicyMarkBinary:
+		return ContentFlags{Binary: true}, nil
+	default:
+		return ContentFlags{PreviewTruncated: true}, nil
+	}
+}
+
+// Preview returns content safe to render: NUL bytes are replaced and lines longer than the
+// maximum are cut
+func (p ContentPolicy) Preview(content string) string {
+	if p.MaxLineLength > 0 {
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			if len(line) > p.MaxLineLength {
+				lines[i] = strings.ToValidUTF8(line[:p.MaxLineLength], "") + previewEllipsis
+			}
+		}
+		content = strings.Join(lines, "\n")
+	}
+	return strings.ReplaceAll(content, "\x00", "\uFFFD")
+}
+
+// inspect reports whether content has NUL bytes and whether it has a line over the maximum length
+func (p ContentPolicy) inspect(content string) (hasNUL, longLine bool) {
+	hasNUL = strings.IndexByte(content, 0) >= 0
+	if p.MaxLineLength <= 0 {
+		return hasNUL, false
+	}
+
+	for len(content) > 0 {
+		end := strings.IndexByte(content, '\n')
+		if end < 0 {
+			end = len(content)
+		}
+		if end > p.MaxLineLength {
+			return hasNUL, true
+		}
+		content = content[min(end+1, len(content)):]
+	}
+	return hasNUL, false
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestContentPolicy_Apply(t *testing.T) {
+	longLine := "short line\n" + strings.Repeat("x", 11) + "\nend"
+	withNUL := "header\x00payload"
+
+	tests := []struct {
+		name      string
+		action    string
+		content   string
+		wantFlags ContentFlags
+		wantErr   error
+	}{
+		{"clean content", ContentPolicyReject, "line one\nline two", ContentFlags{}, nil},
+		{"line at limit", ContentPolicyReject, strings.Repeat("x", 10) + "\n", ContentFlags{}, nil},
+		{"reject long line", ContentPolicyReject, longLine, ContentFlags{}, ErrLineTooLong},
+		{"reject NUL", ContentPolicyReject, withNUL, ContentFlags{}, ErrBinaryContent},
+		{"mark binary", ContentPolicyMarkBinary, withNUL, ContentFlags{Binary: true}, nil},
+		{"truncate preview", ContentPolicyTruncatePreview, longLine, ContentFlags{PreviewTruncated: true}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := ContentPolicy{MaxLineLength: 10, Action: tt.action}
+			flags, err := policy.Apply(tt.content)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply() error = %v, want %v", err, tt.wantErr)
+			}
+			if flags != tt.wantFlags {
+				t.Errorf("Apply() flags = %+v, want %+v", flags, tt.wantFlags)
+			}
+		})
+	}
+}
+
+func TestContentPolicy_Preview(t *testing.T) {
+	policy := ContentPolicy{MaxLineLength: 5, Action: ContentPolicyTruncatePreview}
+
+	got := policy.Preview("abc\x00\n1234567890\nok")
+	want := "abc�\n12345…\nok"
+	if got != want {
+		t.Errorf("Preview() = %q, want %q", got, want)
+	}
+
+	// Multi-byte characters are never split
+	if got := policy.Preview("ééé"); got != "éé…" {
+		t.Errorf("Preview() = %q, want %q", got, "éé…")
+	}
+}
+
+func TestContentPolicy_Validate(t *testing.T) {
+	if err := DefaultContentPolicy().Validate(); err != nil {
+		t.Errorf("DefaultContentPolicy().Validate() error = %v", err)
+	}
+	if err := (ContentPolicy{Action: "drop"}).Validate(); !errors.Is(err, ErrInvalidContentPolicy) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrInvalidContentPolicy)
+	}
+}
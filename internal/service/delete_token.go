@@ -0,0 +1,73 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+var (
+	// ErrDeleteForbidden is returned when deleting a paste without its delete token and without being its owner
+	ErrDeleteForbidden = errors.New("paste: delete not allowed")
+	// ErrEditForbidden is returned when editing a paste without its delete token and without being its owner
+	ErrEditForbidden = errors.New("paste: edit not allowed")
+)
+
+// DeletePasteRequest identifies who deletes a paste
+type DeletePasteRequest struct {
+	// DeleteToken is the token returned when the paste was created anonymously
+	DeleteToken string
+	// OwnerID identifies the caller's API key or anonymous session, set by the handler
+	OwnerID string
+	// UserID is the ID of the signed-in caller, set by the handler
+	UserID string
+	// Admin is set by the handler when the caller sent the admin token
+	Admin bool
+}
+
+// issueDeleteToken gives a paste created anonymously, without an API key or a signed-in user, a
+// delete token and returns it; only its hash is stored, so it is returned once. Other pastes are
+// deleted by their owner and get none.
+func issueDeleteToken(paste *model.Paste, ownerID, userID string) string {
+	// API key owners are recorded as "key:<id>" by the handler
+	if userID != "" || strings.HasPrefix(ownerID, "key:") {
+		return ""
+	}
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+	paste.DeleteTokenHash = hashDeleteToken(token)
+	return token
+}
+
+// hashDeleteToken returns the stored form of a delete token
+func hashDeleteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// canDelete reports whether req may delete paste: with the delete token of the paste, or as the
+// API key, user or anonymous session that created it, or as an admin. Pastes created before delete
+// tokens were issued and without an owner are only deleted by admins.
+func canDelete(paste *model.Paste, req *DeletePasteRequest) bool {
+	if req == nil {
+		return false
+	}
+	if req.Admin {
+		return true
+	}
+	if req.DeleteToken != "" && paste.DeleteTokenHash != "" &&
+		hmac.Equal([]byte(hashDeleteToken(req.DeleteToken)), []byte(paste.DeleteTokenHash)) {
+		return true
+	}
+	if req.UserID != "" && paste.UserID != nil && *paste.UserID == req.UserID {
+		return true
+	}
+	return req.OwnerID != "" && paste.OwnerID == req.OwnerID
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestIssueDeleteToken(t *testing.T) {
+	tests := []struct {
+		name            string
+		ownerID, userID string
+		wantToken       bool
+	}{
+		{"anonymous", "", "", true},
+		{"anonymous session", "session:abc", "", true},
+		{"api key", "key:abc", "", false},
+		{"signed-in user", "user:u1", "u1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paste := &model.Paste{}
+			token := issueDeleteToken(paste, tt.ownerID, tt.userID)
+			if (token != "") != tt.wantToken {
+				t.Fatalf("issueDeleteToken() = %q, want a token: %v", token, tt.wantToken)
+			}
+			if tt.wantToken && paste.DeleteTokenHash != hashDeleteToken(token) {
+				t.Errorf("DeleteTokenHash = %q, want the hash of the token", paste.DeleteTokenHash)
+			}
+			if !tt.wantToken && paste.DeleteTokenHash != "" {
+				t.Errorf("DeleteTokenHash = %q, want none", paste.DeleteTokenHash)
+			}
+		})
+	}
+}
+
+func TestCanDelete(t *testing.T) {
+	anonymous := &model.Paste{OwnerID: "session:abc"}
+	token := issueDeleteToken(anonymous, anonymous.OwnerID, "")
+	userID := "u1"
+	owned := &model.Paste{OwnerID: "user:u1", UserID: &userID}
+	legacy := &model.Paste{}
+
+	tests := []struct {
+		name  string
+		paste *model.Paste
+		req   *DeletePasteRequest
+		want  bool
+	}{
+		{"delete token", anonymous, &DeletePasteRequest{DeleteToken: token}, true},
+		{"wrong delete token", anonymous, &DeletePasteRequest{DeleteToken: token + "x"}, false},
+		{"creating session", anonymous, &DeletePasteRequest{OwnerID: "session:abc"}, true},
+		{"other session", anonymous, &DeletePasteRequest{OwnerID: "session:def"}, false},
+		{"no credentials", anonymous, &DeletePasteRequest{}, false},
+		{"nil request", anonymous, nil, false},
+		{"signed-in creator", owned, &DeletePasteRequest{UserID: "u1"}, true},
+		{"other user", owned, &DeletePasteRequest{UserID: "u2", OwnerID: "user:u2"}, false},
+		{"token on owned paste", owned, &DeletePasteRequest{DeleteToken: token}, false},
+		{"legacy paste", legacy, &DeletePasteRequest{DeleteToken: "anything"}, false},
+		{"admin", legacy, &DeletePasteRequest{Admin: true}, true},
+	}
+	for _, tt := range tests {
+		if got := canDelete(tt.paste, tt.req); got != tt.want {
+			t.Errorf("canDelete(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"mime"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+// ErrInvalidDeliveryHeaders is returned when a delivery header override is not allowed
+var ErrInvalidDeliveryHeaders = errors.New("paste: invalid delivery headers")
+
+const (
+	// MaxDeliveryFilenameLength is the maximum length in bytes of a download filename
+	MaxDeliveryFilenameLength = 255
+	// MaxDeliveryCacheAge is the largest max-age accepted in a Cache-Control override (one year)
+	MaxDeliveryCacheAge = 365 * 24 * 60 * 60
+)
+
+// DeliveryContentTypes lists the content types a paste may be served as. Types a browser would
+// render as active content (HTML, SVG, XML, JavaScript) are deliberately absent.
+var DeliveryContentTypes = map[string]bool{
+	"text/plain":                true,
+	"text/markdown":             true,
+	"text/csv":                  true,
+	"text/tab-separated-values": true,
+	"text/x-diff":               true,
+	"application/json":          true,
+	"application/x-ndjson":      true,
+	"application/yaml":          true,
+	"application/toml":          true,
+	"application/octet-stream":  true,
+}
+
+// cacheControlDirectives lists the Cache-Control directives accepted in an override;
+// the boolean tells whether the directive takes a number of seconds
+var cacheControlDirectives = map[string]bool{
+	"public":          false,
+	"private":         false,
+	"no-cache":        false,
+	"no-store":        false,
+	"no-transform":    false,
+	"must-revalidate": false,
+	"immutable":       false,
+	"max-age":         true,
+	"s-maxage":        true,
+}
+
+// NormalizeDeliveryHeaders validates delivery header overrides and returns them in canonical form.
+// It returns nil when no override is set.
+func NormalizeDeliveryHeaders(h *model.DeliveryHeaders) (*model.DeliveryHeaders, error) {
+	if h == nil {
+		return nil, nil
+	}
+
+	var (
+		out model.DeliveryHeaders
+		err error
+	)
+	if out.ContentType, err = normalizeDeliveryContentType(h.ContentType); err != nil {
+		return nil, err
+	}
+	if out.CacheControl, err = normalizeCacheControl(h.CacheControl); err != nil {
+		return nil, err
+	}
+	if out.Filename, err = normalizeDeliveryFilename(h.Filename); err != nil {
+		return nil, err
+	}
+
+	if out == (model.DeliveryHeaders{}) {
+		return nil, nil
+	}
+	return &out, nil
+}
+
+// normalizeDeliveryContentType accepts a whitelisted media type; text is always served as UTF-8
+func normalizeDeliveryContentType(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(value)
+	if err != nil || !DeliveryContentTypes[mediaType] {
+		return "", ErrInvalidDeliveryHeaders
+	}
+	for name, v := range params {
+		if name != "charset" || !strings.EqualFold(v, "utf-8") {
+			return "", ErrInvalidDeliveryHeaders
+		}
+	}
+
+	if mediaType == "application/octet-stream" {
+		return mediaType, nil
+	}
+	return mime.FormatMediaType(mediaType, map[string]string{"charset": "utf-8"}), nil
+}
+
+// normalizeCacheControl accepts a comma-separated list of whitelisted directives
+func normalizeCacheControl(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	var directives []string
+	for _, directive := range strings.Split(value, ",") {
+		name, arg, hasArg := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		takesArg, ok := cacheControlDirectives[name]
+		if !ok || hasArg != takesArg {
+			return "", ErrInvalidDeliveryHeaders
+		}
+		if takesArg {
+			seconds, err := strconv.Atoi(arg)
+			if err != nil || seconds < 0 || seconds > MaxDeliveryCacheAge {
+				return "", ErrInvalidDeliveryHeaders
+			}
+			name += "=" + strconv.Itoa(seconds)
+		}
+		directives = append(directives, name)
+	}
+	return strings.Join(directives, ", "), nil
+}
+
+// normalizeDeliveryFilename accepts a plain file name without path separators, quotes or control characters
+func normalizeDeliveryFilename(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	if len(value) > MaxDeliveryFilenameLength || !utf8.ValidString(value) || value == "." || value == ".." {
+		return "", ErrInvalidDeliveryHeaders
+	}
+	for _, r := range value {
+		if unicode.IsControl(r) || r == '/' || r == '\\' || r == '"' {
+			return "", ErrInvalidDeliveryHeaders
+		}
+	}
+	return value, nil
+}
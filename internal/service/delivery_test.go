@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestNormalizeDeliveryHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   *model.DeliveryHeaders
+		want    *model.DeliveryHeaders
+		wantErr bool
+	}{
+		{"nil", nil, nil, false},
+		{"empty", &model.DeliveryHeaders{ContentType: " "}, nil, false},
+		{"json", &model.DeliveryHeaders{ContentType: "Application/JSON"},
+			&model.DeliveryHeaders{ContentType: "application/json; charset=utf-8"}, false},
+		{"utf-8 charset", &model.DeliveryHeaders{ContentType: "text/csv; charset=UTF-8"},
+			&model.DeliveryHeaders{ContentType: "text/csv; charset=utf-8"}, false},
+		{"octet-stream", &model.DeliveryHeaders{ContentType: "application/octet-stream"},
+			&model.DeliveryHeaders{ContentType: "application/octet-stream"}, false},
+		{"html", &model.DeliveryHeaders{ContentType: "text/html"}, nil, true},
+		{"svg", &model.DeliveryHeaders{ContentType: "image/svg+xml"}, nil, true},
+		{"other charset", &model.DeliveryHeaders{ContentType: "text/plain; charset=utf-7"}, nil, true},
+		{"cache control", &model.DeliveryHeaders{CacheControl: "Public,  max-age=300"},
+			&model.DeliveryHeaders{CacheControl: "public, max-age=300"}, false},
+		{"cache control without age", &model.DeliveryHeaders{CacheControl: "max-age"}, nil, true},
+		{"cache control too long", &model.DeliveryHeaders{CacheControl: "max-age=99999999"}, nil, true},
+		{"cache control unknown", &model.DeliveryHeaders{CacheControl: "public, stale-if-error=60"}, nil, true},
+		{"filename", &model.DeliveryHeaders{Filename: " báo cáo.csv "},
+			&model.DeliveryHeaders{Filename: "báo cáo.csv"}, false},
+		{"filename with path", &model.DeliveryHeaders{Filename: "../etc/passwd"}, nil, true},
+		{"filename with quote", &model.DeliveryHeaders{Filename: `a".txt`}, nil, true},
+		{"filename with newline", &model.DeliveryHeaders{Filename: "a\r\nSet-Cookie: x"}, nil, true},
+		{"filename too long", &model.DeliveryHeaders{Filename: strings.Repeat("a", MaxDeliveryFilenameLength+1)}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeDeliveryHeaders(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDeliveryHeaders) {
+					t.Fatalf("NormalizeDeliveryHeaders() error = %v, want ErrInvalidDeliveryHeaders", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeDeliveryHeaders() error = %v", err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("NormalizeDeliveryHeaders() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestGetPasteResponse_DownloadFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *GetPasteResponse
+		want     string
+	}{
+		{"download filename", &GetPasteResponse{ShortID: "xK9a2B", SyntaxType: "go", Delivery: &model.DeliveryHeaders{Filename: "main.go"}}, "main.go"},
+		{"syntax extension", &GetPasteResponse{ShortID: "xK9a2B", SyntaxType: "python"}, "xK9a2B.py"},
+		{"plain text", &GetPasteResponse{ShortID: "xK9a2B", SyntaxType: "plaintext"}, "xK9a2B.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.response.DownloadFilename(); got != tt.want {
+				t.Errorf("DownloadFilename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// Drain is the drain state of this instance. A draining instance fails its readiness probe so load
+// balancers stop routing to it, stops its background workers once their in-flight jobs are done,
+// then shuts down. Unlike maintenance mode it is local to the instance and cannot be undone.
+type Drain struct {
+	mu    sync.Mutex
+	since *time.Time
+	done  chan struct{}
+}
+
+// NewDrain creates the drain state of an instance that is not draining
+func NewDrain() *Drain {
+	return &Drain{done: make(chan struct{})}
+}
+
+// Start puts the instance in drain mode and returns when it started draining. Later calls return
+// the time of the first.
+func (d *Drain) Start() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.since == nil {
+		now := time.Now()
+		d.since = &now
+		close(d.done)
+	}
+	return *d.since
+}
+
+// Draining reports whether the instance is draining, and since when
+func (d *Drain) Draining() (bool, time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.since == nil {
+		return false, time.Time{}
+	}
+	return true, *d.since
+}
+
+// Done returns a channel closed when the instance starts draining
+func (d *Drain) Done() <-chan struct{} {
+	return d.done
+}
@@ -0,0 +1,31 @@
+package service
+
+import "testing"
+
+func TestDrain(t *testing.T) {
+	d := NewDrain()
+	if draining, _ := d.Draining(); draining {
+		t.Fatal("new Drain is draining")
+	}
+	select {
+	case <-d.Done():
+		t.Fatal("Done closed before Start")
+	default:
+	}
+
+	since := d.Start()
+	draining, got := d.Draining()
+	if !draining || !got.Equal(since) {
+		t.Errorf("Draining() = %v, %v, want true, %v", draining, got, since)
+	}
+	select {
+	case <-d.Done():
+	default:
+		t.Fatal("Done not closed after Start")
+	}
+
+	// Draining again keeps the first start
+	if again := d.Start(); !again.Equal(since) {
+		t.Errorf("second Start() = %v, want %v", again, since)
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"github.com/huylvt/gisty/internal/event"
+	"github.com/huylvt/gisty/internal/model"
+)
+
+// SetEventBus makes the service announce paste lifecycle events on the bus.
+// Views are then counted for trending by a bus subscriber instead of while serving the read.
+func (s *PasteService) SetEventBus(bus event.Bus) {
+	s.events = bus
+	bus.Subscribe("trending", s.recordView)
+}
+
+// publish announces an event on the bus, if any
+func (s *PasteService) publish(eventType model.PasteEventType, shortID string, paste *model.Paste) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(&model.PasteEvent{
+		Type:    eventType,
+		ShortID: shortID,
+		Paste:   paste,
+	})
+}
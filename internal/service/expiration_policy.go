@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidExpirationPolicy is returned when an expiration policy names an unknown syntax type or has no positive duration
+var ErrInvalidExpirationPolicy = errors.New("paste: invalid expiration policy")
+
+// ExpirationPolicyResult reports the expiration policy of a paste's syntax type that applied when it was created
+type ExpirationPolicyResult struct {
+	SyntaxType string `json:"syntax_type"`
+	MaxTTL     string `json:"max_ttl"`
+	// Capped is set when the requested expiration was shortened to MaxTTL
+	Capped bool `json:"capped"`
+}
+
+// ParseExpirationPolicies parses a comma-separated list of syntax=duration pairs, such as
+// "dotenv=24h,ini=24h", into the longest lifetime of pastes of each syntax type
+func ParseExpirationPolicies(spec string) (map[string]time.Duration, error) {
+	policies := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		syntaxType, rawTTL, ok := strings.Cut(entry, "=")
+		syntaxType = strings.ToLower(strings.TrimSpace(syntaxType))
+		if !ok || !ValidSyntaxTypes[syntaxType] {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidExpirationPolicy, entry)
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(rawTTL))
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidExpirationPolicy, entry)
+		}
+		policies[syntaxType] = ttl
+	}
+	return policies, nil
+}
+
+// FormatExpirationPolicies renders policies in the format read by ParseExpirationPolicies, sorted by syntax type
+func FormatExpirationPolicies(policies map[string]time.Duration) string {
+	entries := make([]string, 0, len(policies))
+	for syntaxType, ttl := range policies {
+		entries = append(entries, syntaxType+"="+ttl.String())
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// SetExpirationPolicies caps the lifetime of new pastes by syntax type, as a guard against
+// credentials pasted by mistake (e.g. .env files). The cap follows the resolved syntax type,
+// so a client declaring another type is not held to it.
+func (s *PasteService) SetExpirationPolicies(policies map[string]time.Duration) {
+	s.expirationPolicies = policies
+}
+
+// applyExpirationPolicy caps expiresAt by the policy of the syntax type, if any. Pastes that would
+// never expire, including unread burn-after-read ones, get the maximum lifetime.
+func (s *PasteService) applyExpirationPolicy(syntaxType string, expiresAt *time.Time) (*time.Time, *ExpirationPolicyResult) {
+	maxTTL, ok := s.expirationPolicies[syntaxType]
+	if !ok {
+		return expiresAt, nil
+	}
+
+	result := &ExpirationPolicyResult{SyntaxType: syntaxType, MaxTTL: maxTTL.String()}
+	limit := time.Now().Add(maxTTL)
+	if expiresAt == nil || expiresAt.After(limit) {
+		expiresAt = &limit
+		result.Capped = true
+	}
+	return expiresAt, result
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseExpirationPolicies(t *testing.T) {
+	policies, err := ParseExpirationPolicies(" dotenv=24h, INI=1h30m ,")
+	if err != nil {
+		t.Fatalf("ParseExpirationPolicies() error = %v", err)
+	}
+	if len(policies) != 2 || policies["dotenv"] != 24*time.Hour || policies["ini"] != 90*time.Minute {
+		t.Errorf("ParseExpirationPolicies() = %v", policies)
+	}
+	if got := FormatExpirationPolicies(policies); got != "dotenv=24h0m0s,ini=1h30m0s" {
+		t.Errorf("FormatExpirationPolicies() = %q", got)
+	}
+
+	for _, spec := range []string{"dotenv", "unknown=1h", "ini=forever", "ini=0s", "ini=-1h"} {
+		if _, err := ParseExpirationPolicies(spec); !errors.Is(err, ErrInvalidExpirationPolicy) {
+			t.Errorf("ParseExpirationPolicies(%q) error = %v, want ErrInvalidExpirationPolicy", spec, err)
+		}
+	}
+}
+
+func TestPasteService_ApplyExpirationPolicy(t *testing.T) {
+	s := &PasteService{expirationPolicies: map[string]time.Duration{"dotenv": 24 * time.Hour}}
+	inHour := time.Now().Add(time.Hour)
+	inWeek := time.Now().Add(7 * 24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		syntaxType string
+		expiresAt  *time.Time
+		wantCapped bool
+		wantPolicy bool
+	}{
+		{"no policy", "go", nil, false, false},
+		{"never expires", "dotenv", nil, true, true},
+		{"longer than the cap", "dotenv", &inWeek, true, true},
+		{"within the cap", "dotenv", &inHour, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expiresAt, policy := s.applyExpirationPolicy(tt.syntaxType, tt.expiresAt)
+			if (policy != nil) != tt.wantPolicy {
+				t.Fatalf("applyExpirationPolicy() policy = %+v, want policy %v", policy, tt.wantPolicy)
+			}
+			if policy == nil {
+				if expiresAt != tt.expiresAt {
+					t.Errorf("applyExpirationPolicy() changed the expiration without a policy")
+				}
+				return
+			}
+			if policy.Capped != tt.wantCapped || policy.MaxTTL != "24h0m0s" {
+				t.Errorf("applyExpirationPolicy() policy = %+v", policy)
+			}
+			if expiresAt == nil || time.Until(*expiresAt) > 24*time.Hour {
+				t.Errorf("applyExpirationPolicy() expiresAt = %v, want at most 24h from now", expiresAt)
+			}
+		})
+	}
+}
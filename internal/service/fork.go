@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+// ErrNotForkable is returned for burn-after-read and view-limited pastes, whose content must not
+// outlive their reads in a copy
+var ErrNotForkable = errors.New("paste: content cannot be forked")
+
+// ForkPasteRequest represents the request to fork a paste
+type ForkPasteRequest struct {
+	ExpiresIn string `json:"expires_in"` // expiration of the fork, as for a new paste
+	IsPrivate bool   `json:"is_private"`
+	Title     string `json:"title"` // defaults to the title of the source
+
+	// SourceIP is the caller's IP, set by the handler; only its keyed hash is stored
+	SourceIP string `json:"-"`
+	// OwnerID identifies the caller's API key or anonymous session, set by the handler
+	OwnerID string `json:"-"`
+	// UserID is the ID of the signed-in user, set by the handler
+	UserID string `json:"-"`
+}
+
+// ForkPaste copies the content and descriptive metadata of a paste into a new paste owned by
+// the caller, recording the source in forked_from. The fork is created like any new paste:
+// it gets its own expiration, privacy and rate limits, and nothing else of the source.
+func (s *PasteService) ForkPaste(ctx context.Context, shortID string, req *ForkPasteRequest) (*CreatePasteResponse, error) {
+	paste, content, err := s.readSource(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+	if paste.BurnAfterRead || paste.MaxViews > 0 {
+		return nil, ErrNotForkable
+	}
+
+	title := req.Title
+	if title == "" {
+		title = paste.Title
+	}
+	fork, err := s.CreatePaste(ctx, &CreatePasteRequest{
+		Content:     content,
+		SyntaxType:  paste.SyntaxType,
+		ExpiresIn:   req.ExpiresIn,
+		IsPrivate:   req.IsPrivate,
+		Encrypted:   paste.Encrypted,
+		Title:       title,
+		Description: paste.Description,
+		Tags:        paste.Tags,
+		SourceIP:    req.SourceIP,
+		OwnerID:     req.OwnerID,
+		UserID:      req.UserID,
+		ForkedFrom:  shortID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[PasteService.ForkPaste] Success: short_id=%s, fork=%s", shortID, fork.ShortID)
+	return fork, nil
+}
+
+// readSource returns a paste and its content to copy into a new paste, without counting a view.
+// The caller rejects pastes whose content must not outlive their reads.
+func (s *PasteService) readSource(ctx context.Context, shortID string) (*model.Paste, string, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, "", ErrPasteNotFound
+		}
+		return nil, "", fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, "", ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, "", ErrPasteExpired
+	}
+	if paste.ArchivedAt != nil {
+		return nil, "", ErrPasteArchived
+	}
+	if paste.IsQuarantined() {
+		return nil, "", ErrPasteQuarantined
+	}
+
+	content, err := s.storage.GetContent(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, ErrContentNotFound) {
+			return nil, "", ErrPasteNotFound
+		}
+		return nil, "", fmt.Errorf("paste: failed to get content: %w", err)
+	}
+	return paste, content, nil
+}
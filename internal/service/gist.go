@@ -0,0 +1,502 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// DefaultGitHubAPIURL is the GitHub REST API gists are read from and created with
+	DefaultGitHubAPIURL = "https://api.github.com"
+	// MaxGistFiles caps the number of files of an imported gist, each stored as its own paste
+	MaxGistFiles = 10
+	// gistTimeout bounds each call to the GitHub API
+	gistTimeout = 15 * time.Second
+	// gistImportTag tags the pastes created from a gist
+	gistImportTag = "gist"
+)
+
+var (
+	// ErrGistDisabled is returned when gist import and export are not enabled on this instance
+	ErrGistDisabled = errors.New("paste: gist import and export disabled")
+	// ErrInvalidGist is returned when a gist reference is neither a gist ID nor a gist URL
+	ErrInvalidGist = errors.New("paste: invalid gist reference")
+	// ErrGistNotFound is returned when GitHub does not know the gist
+	ErrGistNotFound = errors.New("paste: gist not found")
+	// ErrGistTooLarge is returned for gists with more than MaxGistFiles files or a file too large to store
+	ErrGistTooLarge = errors.New("paste: gist too large")
+	// ErrNotExportable is returned for pastes whose content must not leave the instance or cannot
+	// be a gist file: encrypted, binary, burn-after-read and view-limited pastes
+	ErrNotExportable = errors.New("paste: content cannot be exported")
+	// ErrGitHubUnauthorized is returned when GitHub rejects the token of an export
+	ErrGitHubUnauthorized = errors.New("paste: GitHub token rejected")
+	// ErrGitHubUnavailable is returned when GitHub fails or cannot be reached
+	ErrGitHubUnavailable = errors.New("paste: GitHub unavailable")
+)
+
+// gistIDPattern matches gist IDs: hex strings, or the digits of the oldest gists
+var gistIDPattern = regexp.MustCompile(`^[0-9a-f]{1,64}$`)
+
+// Gist is a GitHub gist with its files keyed by filename
+type Gist struct {
+	ID          string              `json:"id,omitempty"`
+	HTMLURL     string              `json:"html_url,omitempty"`
+	Description string              `json:"description"`
+	Public      bool                `json:"public"`
+	Files       map[string]GistFile `json:"files"`
+}
+
+// GistFile is a file of a gist; Truncated is set by GitHub for content too large to inline
+type GistFile struct {
+	Filename  string `json:"filename,omitempty"`
+	Language  string `json:"language,omitempty"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// Gists reads and creates GitHub gists
+type Gists interface {
+	Get(ctx context.Context, id string) (*Gist, error)
+	// Create creates gist on behalf of the owner of token
+	Create(ctx context.Context, token string, gist *Gist) (*Gist, error)
+}
+
+// ImportGistRequest represents the request to import a gist
+type ImportGistRequest struct {
+	Gist      string `json:"gist" binding:"required"` // gist ID or URL
+	ExpiresIn string `json:"expires_in"`
+	IsPrivate bool   `json:"is_private"`
+
+	// SourceIP is the caller's IP, set by the handler; only its keyed hash is stored
+	SourceIP string `json:"-"`
+	// OwnerID identifies the caller's API key or anonymous session, set by the handler
+	OwnerID string `json:"-"`
+	// UserID is the ID of the signed-in user, set by the handler
+	UserID string `json:"-"`
+}
+
+// ImportedGistFile is a gist file and the paste it was stored as
+type ImportedGistFile struct {
+	Filename   string `json:"filename"`
+	SyntaxType string `json:"syntax_type"`
+	ShortID    string `json:"short_id"`
+	URL        string `json:"url"`
+	// DeleteToken is returned once for files imported anonymously, and required to delete their paste
+	DeleteToken string `json:"delete_token,omitempty"`
+}
+
+// ImportGistResponse represents the pastes created from a gist, one per file in filename order
+type ImportGistResponse struct {
+	GistID    string             `json:"gist_id"`
+	GroupID   string             `json:"group_id,omitempty"` // shared by the pastes of a multi-file gist
+	Files     []ImportedGistFile `json:"files"`
+	ExpiresAt *string            `json:"expires_at,omitempty"`
+}
+
+// ExportGistRequest represents the request to export a paste as a gist
+type ExportGistRequest struct {
+	// Token is a GitHub token allowed to create gists; it is used for this call only, never stored or logged
+	Token    string `json:"token" binding:"required"`
+	Public   bool   `json:"public"`
+	Filename string `json:"filename"` // defaults to the paste's download filename, or one derived from its syntax type
+}
+
+// ExportGistResponse represents the gist created from a paste
+type ExportGistResponse struct {
+	ShortID  string `json:"short_id"`
+	GistID   string `json:"gist_id"`
+	GistURL  string `json:"gist_url"`
+	Filename string `json:"filename"`
+	// Files lists the files of the gist: the paste's, and those of the rest of its group
+	Files []string `json:"files"`
+}
+
+// SetGists enables importing pastes from gists and exporting them to GitHub
+func (s *PasteService) SetGists(gists Gists) {
+	s.gists = gists
+}
+
+// ImportGist stores each file of a gist as a paste, keeping its filename as title and download
+// filename and its language as syntax type. The files are imported together: if one cannot be
+// stored, the pastes already created are deleted. The pastes of a multi-file gist share a group
+// ID, by which ExportGist exports them again as one gist.
+func (s *PasteService) ImportGist(ctx context.Context, req *ImportGistRequest) (*ImportGistResponse, error) {
+	if s.gists == nil {
+		return nil, ErrGistDisabled
+	}
+	id, err := ParseGistID(req.Gist)
+	if err != nil {
+		return nil, err
+	}
+
+	gistCtx, cancel := context.WithTimeout(ctx, gistTimeout)
+	defer cancel()
+	gist, err := s.gists.Get(gistCtx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(gist.Files) == 0 {
+		return nil, ErrGistNotFound
+	}
+	if len(gist.Files) > MaxGistFiles {
+		return nil, ErrGistTooLarge
+	}
+
+	filenames := make([]string, 0, len(gist.Files))
+	for filename, file := range gist.Files {
+		if file.Truncated || len(file.Content) > MaxContentSize {
+			return nil, ErrGistTooLarge
+		}
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	response := &ImportGistResponse{GistID: id, Files: make([]ImportedGistFile, 0, len(filenames))}
+	if len(filenames) > 1 {
+		response.GroupID = newGistGroupID()
+	}
+	for _, filename := range filenames {
+		file := gist.Files[filename]
+		syntaxType := s.gistSyntaxType(filename, file)
+		created, err := s.CreatePaste(ctx, &CreatePasteRequest{
+			Content:     file.Content,
+			SyntaxType:  syntaxType,
+			ExpiresIn:   req.ExpiresIn,
+			IsPrivate:   req.IsPrivate,
+			Title:       truncateRunes(filename, MaxTitleLength),
+			Description: truncateRunes(gist.Description, MaxDescriptionLength),
+			Tags:        []string{gistImportTag},
+			Delivery:    gistDelivery(filename),
+			SourceIP:    req.SourceIP,
+			OwnerID:     req.OwnerID,
+			UserID:      req.UserID,
+			GroupID:     response.GroupID,
+			Path:        filename,
+		})
+		if err != nil {
+			for _, imported := range response.Files {
+				s.deletePaste(ctx, imported.ShortID)
+			}
+			return nil, err
+		}
+		response.Files = append(response.Files, ImportedGistFile{
+			Filename:    filename,
+			SyntaxType:  syntaxType,
+			ShortID:     created.ShortID,
+			URL:         created.URL,
+			DeleteToken: created.DeleteToken,
+		})
+		response.ExpiresAt = created.ExpiresAt
+	}
+
+	log.Printf("[PasteService.ImportGist] Success: gist=%s, files=%d", id, len(response.Files))
+	return response, nil
+}
+
+// ExportGist creates a gist holding the content of a paste, owned by the holder of the token.
+// A paste imported from a multi-file gist is exported with the rest of its group, one file each.
+// Reading the pastes for an export does not count a view.
+func (s *PasteService) ExportGist(ctx context.Context, shortID string, req *ExportGistRequest) (*ExportGistResponse, error) {
+	if s.gists == nil {
+		return nil, ErrGistDisabled
+	}
+
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if err := checkExportable(paste); err != nil {
+		return nil, err
+	}
+
+	filename := req.Filename
+	if filename == "" {
+		filename = gistFilename(paste)
+	}
+	filename, err = normalizeDeliveryFilename(filename)
+	if err != nil || filename == "" {
+		return nil, ErrInvalidDeliveryHeaders
+	}
+
+	members, err := s.gistGroup(ctx, paste)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]GistFile, len(members))
+	filenames := make([]string, 0, len(members))
+	for _, member := range members {
+		name := filename
+		if member.ShortID != paste.ShortID {
+			if name, err = normalizeDeliveryFilename(gistFilename(member)); err != nil || name == "" {
+				return nil, ErrInvalidDeliveryHeaders
+			}
+		}
+		if _, dup := files[name]; dup {
+			// A gist holds one file of each name
+			return nil, ErrInvalidDeliveryHeaders
+		}
+
+		content, err := s.storage.GetContent(ctx, member.ShortID)
+		if err != nil {
+			if errors.Is(err, ErrContentNotFound) {
+				return nil, ErrPasteNotFound
+			}
+			return nil, fmt.Errorf("paste: failed to get content: %w", err)
+		}
+		files[name] = GistFile{Content: content}
+		filenames = append(filenames, name)
+	}
+
+	description := paste.Title
+	if paste.GroupID != "" {
+		// The title of each file of a group is its filename
+		description = ""
+	}
+	if paste.Description != "" {
+		description = strings.TrimSpace(description + "\n\n" + paste.Description)
+	}
+	gistCtx, cancel := context.WithTimeout(ctx, gistTimeout)
+	defer cancel()
+	gist, err := s.gists.Create(gistCtx, req.Token, &Gist{
+		Description: description,
+		Public:      req.Public,
+		Files:       files,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(filenames)
+	log.Printf("[PasteService.ExportGist] Success: short_id=%s, gist=%s, files=%d", shortID, gist.ID, len(files))
+	return &ExportGistResponse{
+		ShortID:  shortID,
+		GistID:   gist.ID,
+		GistURL:  gist.HTMLURL,
+		Filename: filename,
+		Files:    filenames,
+	}, nil
+}
+
+// gistGroup returns the pastes exported with paste: paste itself and the readable pastes of its
+// group, if any. Every one of them must be exportable.
+func (s *PasteService) gistGroup(ctx context.Context, paste *model.Paste) ([]*model.Paste, error) {
+	if paste.GroupID == "" {
+		return []*model.Paste{paste}, nil
+	}
+	pastes, err := s.pasteRepo.ListGroup(ctx, paste.GroupID, MaxGistFiles+1)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list group: %w", err)
+	}
+	if len(pastes) > MaxGistFiles {
+		// Bundles may hold more files than a gist import
+		return nil, ErrGistTooLarge
+	}
+
+	members := []*model.Paste{paste}
+	for _, member := range pastes {
+		if member.ShortID == paste.ShortID || member.IsPending() || member.IsBurned() || member.IsExpired() {
+			// Files deleted or expired since the import are left out
+			continue
+		}
+		if err := checkExportable(member); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// checkExportable returns why the content of paste cannot be exported as a gist file, if it cannot
+func checkExportable(paste *model.Paste) error {
+	if paste.ArchivedAt != nil {
+		return ErrPasteArchived
+	}
+	if paste.IsQuarantined() {
+		return ErrPasteQuarantined
+	}
+	if paste.Encrypted || paste.Binary || paste.BurnAfterRead || paste.MaxViews > 0 {
+		return ErrNotExportable
+	}
+	return nil
+}
+
+// newGistGroupID returns a random group ID for the pastes of a gist import
+func newGistGroupID() string {
+	b := make([]byte, 9)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseGistID returns the gist ID of a gist ID or a gist.github.com URL
+func ParseGistID(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if strings.Contains(ref, "/") {
+		u, err := url.Parse(ref)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host != "gist.github.com" {
+			return "", ErrInvalidGist
+		}
+		// https://gist.github.com/<user>/<id> or https://gist.github.com/<id>
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+		ref = segments[len(segments)-1]
+		if len(segments) > 2 {
+			// https://gist.github.com/<user>/<id>/<revision>
+			ref = segments[1]
+		}
+	}
+	if !gistIDPattern.MatchString(ref) {
+		return "", ErrInvalidGist
+	}
+	return ref, nil
+}
+
+// gistSyntaxType maps the language GitHub detected for a gist file to a syntax type, falling
+// back to detection from the filename and content
+func (s *PasteService) gistSyntaxType(filename string, file GistFile) string {
+	if syntax, ok := languageToSyntax[file.Language]; ok {
+		return syntax
+	}
+	if language := strings.ToLower(file.Language); ValidSyntaxTypes[language] {
+		return language
+	}
+	return s.syntaxDetector.DetectLanguageWithFilename(filename, file.Content)
+}
+
+// gistDelivery keeps the filename of a gist file as the download filename of its paste, when
+// it is acceptable as one
+func gistDelivery(filename string) *model.DeliveryHeaders {
+	if _, err := normalizeDeliveryFilename(filename); err != nil {
+		return nil
+	}
+	return &model.DeliveryHeaders{Filename: filename}
+}
+
+// gistFilename names the gist file of a paste: its download filename, or its short ID with the
+// usual extension of its syntax type so that GitHub highlights it
+func gistFilename(paste *model.Paste) string {
+	if paste.Delivery != nil && paste.Delivery.Filename != "" {
+		return paste.Delivery.Filename
+	}
+	return paste.ShortID + SyntaxExtension(paste.SyntaxType)
+}
+
+// GitHubGists reads and creates gists with the GitHub REST API
+type GitHubGists struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+// NewGitHubGists creates a GitHubGists client of the API at apiURL, DefaultGitHubAPIURL when
+// empty. A non-empty token authenticates imports, for the higher rate limit; exports always use
+// the caller's token.
+func NewGitHubGists(apiURL, token string) *GitHubGists {
+	if apiURL == "" {
+		apiURL = DefaultGitHubAPIURL
+	}
+	return &GitHubGists{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+		// Calls are bounded by the caller's context
+		client: &http.Client{},
+	}
+}
+
+// Get returns a gist with the content of its files
+func (g *GitHubGists) Get(ctx context.Context, id string) (*Gist, error) {
+	var gist Gist
+	status, err := g.do(ctx, http.MethodGet, "/gists/"+url.PathEscape(id), g.token, nil, &gist)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &gist, nil
+	case http.StatusNotFound:
+		return nil, ErrGistNotFound
+	default:
+		return nil, fmt.Errorf("%w: GitHub answered status %d", ErrGitHubUnavailable, status)
+	}
+}
+
+// Create creates a gist on behalf of the owner of token
+func (g *GitHubGists) Create(ctx context.Context, token string, gist *Gist) (*Gist, error) {
+	var created Gist
+	status, err := g.do(ctx, http.MethodPost, "/gists", token, gist, &created)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusCreated:
+		return &created, nil
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		// GitHub answers 404 to tokens without the gist scope
+		return nil, ErrGitHubUnauthorized
+	default:
+		return nil, fmt.Errorf("%w: GitHub answered status %d", ErrGitHubUnavailable, status)
+	}
+}
+
+// do sends a request to the GitHub API and decodes a successful answer into out
+func (g *GitHubGists) do(ctx context.Context, method, path, token string, in, out any) (int, error) {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.apiURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrGitHubUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return resp.StatusCode, nil
+	}
+	// A gist worth importing fits in MaxGistFiles files of MaxContentSize
+	if err := json.NewDecoder(io.LimitReader(resp.Body, int64(2*MaxGistFiles*MaxContentSize))).Decode(out); err != nil {
+		return 0, fmt.Errorf("%w: invalid answer: %v", ErrGitHubUnavailable, err)
+	}
+	return resp.StatusCode, nil
+}
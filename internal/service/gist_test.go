@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestParseGistID(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{"aa5a315d61ae9438b18d", "aa5a315d61ae9438b18d", false},
+		{"https://gist.github.com/octocat/aa5a315d61ae9438b18d", "aa5a315d61ae9438b18d", false},
+		{"https://gist.github.com/aa5a315d61ae9438b18d", "aa5a315d61ae9438b18d", false},
+		{"https://gist.github.com/octocat/aa5a315d61ae9438b18d/0b5c9e5f", "aa5a315d61ae9438b18d", false},
+		{" aa5a315d61ae9438b18d\n", "aa5a315d61ae9438b18d", false},
+		{"https://github.com/octocat/aa5a315d61ae9438b18d", "", true},
+		{"file:///etc/passwd", "", true},
+		{"not-a-gist", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ParseGistID(tt.ref)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseGistID(%q) = %q, %v, want %q (error %v)", tt.ref, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGistFilename(t *testing.T) {
+	tests := []struct {
+		name  string
+		paste *model.Paste
+		want  string
+	}{
+		{"download filename", &model.Paste{ShortID: "xK9a2B", SyntaxType: "go", Delivery: &model.DeliveryHeaders{Filename: "main.go"}}, "main.go"},
+		{"syntax extension", &model.Paste{ShortID: "xK9a2B", SyntaxType: "python"}, "xK9a2B.py"},
+		{"unknown syntax", &model.Paste{ShortID: "xK9a2B", SyntaxType: "nosuchsyntax"}, "xK9a2B.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gistFilename(tt.paste); got != tt.want {
+				t.Errorf("gistFilename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHubGists_Get(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/gists/aa5a315d61ae9438b18d" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer server-token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		_ = json.NewEncoder(w).Encode(Gist{
+			ID:    "aa5a315d61ae9438b18d",
+			Files: map[string]GistFile{"hello.rb": {Filename: "hello.rb", Language: "Ruby", Content: "puts 1"}},
+		})
+	}))
+	defer server.Close()
+
+	gists := NewGitHubGists(server.URL, "server-token")
+	gist, err := gists.Get(context.Background(), "aa5a315d61ae9438b18d")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if file := gist.Files["hello.rb"]; file.Language != "Ruby" || file.Content != "puts 1" {
+		t.Errorf("Get() files = %+v", gist.Files)
+	}
+
+	if _, err := gists.Get(context.Background(), "0000"); err != ErrGistNotFound {
+		t.Errorf("Get() of unknown gist error = %v, want %v", err, ErrGistNotFound)
+	}
+}
+
+func TestGitHubGists_Create(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/gists" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		switch r.Header.Get("Authorization") {
+		case "Bearer user-token":
+		case "Bearer unavailable":
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var gist Gist
+		if err := json.NewDecoder(r.Body).Decode(&gist); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if gist.Files["hello.py"].Content != "print(1)" {
+			t.Errorf("files = %+v", gist.Files)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(Gist{ID: "abc123", HTMLURL: "https://gist.github.com/abc123"})
+	}))
+	defer server.Close()
+
+	gists := NewGitHubGists(server.URL, "server-token")
+	gist := &Gist{Files: map[string]GistFile{"hello.py": {Content: "print(1)"}}}
+	created, err := gists.Create(context.Background(), "user-token", gist)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.ID != "abc123" || created.HTMLURL != "https://gist.github.com/abc123" {
+		t.Errorf("Create() = %+v", created)
+	}
+
+	if _, err := gists.Create(context.Background(), "bad-token", gist); err != ErrGitHubUnauthorized {
+		t.Errorf("Create() with rejected token error = %v, want %v", err, ErrGitHubUnauthorized)
+	}
+	if _, err := gists.Create(context.Background(), "unavailable", gist); !errors.Is(err, ErrGitHubUnavailable) {
+		t.Errorf("Create() on GitHub failure error = %v, want %v", err, ErrGitHubUnavailable)
+	}
+}
+
+// staticGists serves a fixed gist and keeps the last gist created
+type staticGists struct {
+	gist    *Gist
+	created *Gist
+}
+
+func (g *staticGists) Get(ctx context.Context, id string) (*Gist, error) {
+	return g.gist, nil
+}
+
+func (g *staticGists) Create(ctx context.Context, token string, gist *Gist) (*Gist, error) {
+	g.created = gist
+	return &Gist{ID: "bb6b426e72bf9549c29e", HTMLURL: "https://gist.github.com/bb6b426e72bf9549c29e"}, nil
+}
+
+func TestPasteService_ImportGist_DeleteTokens(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	svc.SetGists(&staticGists{gist: &Gist{
+		ID: "aa5a315d61ae9438b18d",
+		Files: map[string]GistFile{
+			"hello.py": {Content: "print('hello')", Language: "Python"},
+			"notes.md": {Content: "# Notes", Language: "Markdown"},
+		},
+	}})
+	ctx := context.Background()
+
+	response, err := svc.ImportGist(ctx, &ImportGistRequest{Gist: "aa5a315d61ae9438b18d"})
+	if err != nil {
+		t.Fatalf("ImportGist() error = %v", err)
+	}
+	if len(response.Files) != 2 {
+		t.Fatalf("ImportGist() files = %+v, want 2", response.Files)
+	}
+	// Each anonymously imported file can be deleted with its own token
+	for _, file := range response.Files {
+		if file.DeleteToken == "" {
+			t.Fatalf("ImportGist() file %s has no delete token", file.Filename)
+		}
+		if err := svc.DeletePaste(ctx, file.ShortID, &DeletePasteRequest{DeleteToken: file.DeleteToken}); err != nil {
+			t.Errorf("DeletePaste(%s) with its token error = %v", file.Filename, err)
+		}
+	}
+
+	// Files imported with an API key are deleted with the key instead
+	response, err = svc.ImportGist(ctx, &ImportGistRequest{Gist: "aa5a315d61ae9438b18d", OwnerID: "key:abc"})
+	if err != nil {
+		t.Fatalf("ImportGist() with API key error = %v", err)
+	}
+	for _, file := range response.Files {
+		if file.DeleteToken != "" {
+			t.Errorf("ImportGist() with API key returned a delete token for %s", file.Filename)
+		}
+	}
+}
+
+func TestPasteService_ImportGist_ExportsGroup(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	gists := &staticGists{gist: &Gist{
+		ID:          "aa5a315d61ae9438b18d",
+		Description: "Hello",
+		Files: map[string]GistFile{
+			"hello.py": {Content: "print('hello')", Language: "Python"},
+			"notes.md": {Content: "# Notes", Language: "Markdown"},
+		},
+	}}
+	svc.SetGists(gists)
+	ctx := context.Background()
+
+	imported, err := svc.ImportGist(ctx, &ImportGistRequest{Gist: "aa5a315d61ae9438b18d"})
+	if err != nil {
+		t.Fatalf("ImportGist() error = %v", err)
+	}
+	if imported.GroupID == "" {
+		t.Fatal("ImportGist() of a multi-file gist should return a group ID")
+	}
+	for _, file := range imported.Files {
+		paste, err := svc.GetPaste(ctx, file.ShortID)
+		if err != nil {
+			t.Fatalf("GetPaste(%s) error = %v", file.ShortID, err)
+		}
+		if paste.GroupID != imported.GroupID {
+			t.Errorf("GetPaste(%s).GroupID = %q, want %q", file.ShortID, paste.GroupID, imported.GroupID)
+		}
+	}
+
+	// Exporting any file of the group exports the whole gist again
+	exported, err := svc.ExportGist(ctx, imported.Files[0].ShortID, &ExportGistRequest{Token: "ghp_test"})
+	if err != nil {
+		t.Fatalf("ExportGist() error = %v", err)
+	}
+	if len(exported.Files) != 2 || len(gists.created.Files) != 2 {
+		t.Fatalf("ExportGist() files = %v, want both files of the group", exported.Files)
+	}
+	if gists.created.Files["notes.md"].Content != "# Notes" {
+		t.Errorf("exported notes.md = %q, want %q", gists.created.Files["notes.md"].Content, "# Notes")
+	}
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// HighlightStyle is the Chroma style of the server-rendered paste view
+const HighlightStyle = "github"
+
+// syntaxLexers maps our syntax type names to Chroma lexer names where they differ
+var syntaxLexers = map[string]string{
+	"assembly": "nasm",
+	"vim":      "viml",
+	"lisp":     "common lisp",
+	"dotenv":   "bash",
+}
+
+var (
+	highlightFormatter = chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithLineNumbers(true),
+		chromahtml.LineNumbersInTable(true),
+		chromahtml.WithLinkableLineNumbers(true, "L"),
+	)
+
+	highlightCSSOnce sync.Once
+	highlightCSS     string
+)
+
+// HighlightCSS returns the stylesheet of the classes used by HighlightHTML
+func HighlightCSS() string {
+	highlightCSSOnce.Do(func() {
+		var b strings.Builder
+		if err := highlightFormatter.WriteCSS(&b, styles.Get(HighlightStyle)); err == nil {
+			highlightCSS = b.String()
+		}
+	})
+	return highlightCSS
+}
+
+// HighlightHTML renders content as syntax-highlighted HTML with line numbers.
+// Renderings are cached by content and syntax type, so popular pastes are tokenised once.
+func (s *PasteService) HighlightHTML(content, syntaxType string) (string, error) {
+	return s.HighlightHTMLFrom(content, syntaxType, 1)
+}
+
+// HighlightHTMLFrom renders content like HighlightHTML with lines numbered from firstLine, for
+// snippets shown with the line numbers of their source
+func (s *PasteService) HighlightHTMLFrom(content, syntaxType string, firstLine int) (string, error) {
+	formatter, options := highlightFormatter, "html:"+syntaxType
+	if firstLine > 1 {
+		formatter = chromahtml.New(
+			chromahtml.WithClasses(true),
+			chromahtml.WithLineNumbers(true),
+			chromahtml.LineNumbersInTable(true),
+			chromahtml.WithLinkableLineNumbers(true, "L"),
+			chromahtml.BaseLineNumber(firstLine),
+		)
+		options += ":" + strconv.Itoa(firstLine)
+	}
+
+	return s.renderCache.GetOrRender(content, options, func(content string) (string, error) {
+		iterator, err := highlightLexer(syntaxType).Tokenise(nil, content)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		if err := formatter.Format(&b, styles.Get(HighlightStyle), iterator); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	})
+}
+
+// highlightLexer returns the Chroma lexer of a syntax type, falling back to plain text
+func highlightLexer(syntaxType string) chroma.Lexer {
+	name := syntaxType
+	if alias, ok := syntaxLexers[syntaxType]; ok {
+		name = alias
+	}
+	lexer := lexers.Get(name)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	return chroma.Coalesce(lexer)
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasteService_HighlightHTML(t *testing.T) {
+	svc := &PasteService{renderCache: NewRenderCache(DefaultRenderCacheMaxBytes)}
+
+	html, err := svc.HighlightHTML("package main\n\nfunc main() {}\n", "go")
+	if err != nil {
+		t.Fatalf("HighlightHTML() error = %v", err)
+	}
+	if !strings.Contains(html, `class="kd"`) {
+		t.Errorf("HighlightHTML() has no keyword class: %s", html)
+	}
+	if !strings.Contains(html, `id="L3"`) {
+		t.Errorf("HighlightHTML() has no linkable line numbers: %s", html)
+	}
+
+	// Content is escaped whatever the syntax type
+	html, err = svc.HighlightHTML("<script>alert(1)</script>", "unknown")
+	if err != nil {
+		t.Fatalf("HighlightHTML() error = %v", err)
+	}
+	if strings.Contains(html, "<script>") {
+		t.Errorf("HighlightHTML() did not escape content: %s", html)
+	}
+
+	// Snippets are numbered from the first line they were cut from
+	html, err = svc.HighlightHTMLFrom("a\nb\n", "text", 120)
+	if err != nil {
+		t.Fatalf("HighlightHTMLFrom() error = %v", err)
+	}
+	if !strings.Contains(html, `id="L121"`) || strings.Contains(html, `id="L1"`) {
+		t.Errorf("HighlightHTMLFrom() does not number lines from 120: %s", html)
+	}
+
+	if css := HighlightCSS(); !strings.Contains(css, ".chroma") {
+		t.Errorf("HighlightCSS() = %q, want chroma classes", css)
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// IPDenyListKey is the Redis hash of the IPs and CIDRs blocked at runtime, by CIDR
+	IPDenyListKey = "gisty:ipdeny"
+	// DefaultIPDenyReloadInterval is how often the deny list is reloaded when no interval is configured
+	DefaultIPDenyReloadInterval = 30 * time.Second
+	// MinIPv4BlockBits and MinIPv6BlockBits bound the widest CIDRs blocked at runtime, so that a
+	// typo cannot lock everyone out
+	MinIPv4BlockBits = 8
+	MinIPv6BlockBits = 32
+)
+
+// Sources of IP blocks
+const (
+	IPBlockSourceConfig = "config" // IP_DENY_LIST, fixed until restart
+	IPBlockSourceFile   = "file"   // IP_DENY_FILE, reloaded with the deny list
+	IPBlockSourceRedis  = "redis"  // added with the admin API, shared by all replicas
+)
+
+// ErrInvalidIPBlock is returned for a block of an invalid or too wide CIDR, a negative duration
+// or an overlong reason
+var ErrInvalidIPBlock = errors.New("paste: invalid IP block")
+
+// IPBlock is an IP or CIDR denied every request
+type IPBlock struct {
+	CIDR      string     `json:"cidr" example:"198.51.100.0/24"`
+	Reason    string     `json:"reason,omitempty" example:"abusive crawler"`
+	Source    string     `json:"source" example:"redis"` // config, file or redis
+	CreatedAt *time.Time `json:"created_at,omitempty" example:"2024-01-15T14:00:00Z"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2024-01-16T14:00:00Z"`
+}
+
+// IPDenyListConfig configures an IPDenyList
+type IPDenyListConfig struct {
+	// DenyList and AllowList hold IPs and CIDRs; allowed clients are never denied, e.g., monitoring
+	// probes inside a blocked range
+	DenyList  []string
+	AllowList []string
+	// File holds one IP or CIDR per line, "#" starting a comment; it is read again on every reload
+	File string
+	// ReloadInterval is how often the file and the blocks in Redis are reloaded
+	ReloadInterval time.Duration
+}
+
+// ipRules is a snapshot of the deny list, swapped whole on reload
+type ipRules struct {
+	deny   []netip.Prefix
+	blocks []IPBlock
+}
+
+// IPDenyList denies requests from IPs and CIDRs listed in the configuration, in a file or in Redis.
+// Requests are checked against an in-memory snapshot, reloaded every interval, so a blocked
+// crawler costs no Redis call.
+type IPDenyList struct {
+	client *redis.Client
+	config IPDenyListConfig
+	allow  []netip.Prefix
+	static []IPBlock
+	rules  atomic.Pointer[ipRules]
+}
+
+// NewIPDenyList creates an IPDenyList enforcing the configured entries until its first reload. It
+// fails on an invalid entry of the deny or allow list.
+func NewIPDenyList(redisClient *repository.Redis, config IPDenyListConfig) (*IPDenyList, error) {
+	if config.ReloadInterval <= 0 {
+		config.ReloadInterval = DefaultIPDenyReloadInterval
+	}
+	d := &IPDenyList{
+		client: redisClient.Client,
+		config: config,
+	}
+	for _, entry := range config.AllowList {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		prefix, err := parseIPPrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("paste: invalid IP allow list entry %q: %w", entry, err)
+		}
+		d.allow = append(d.allow, prefix)
+	}
+	for _, entry := range config.DenyList {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		prefix, err := parseIPPrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("paste: invalid IP deny list entry %q: %w", entry, err)
+		}
+		d.static = append(d.static, IPBlock{CIDR: prefix.String(), Source: IPBlockSourceConfig})
+	}
+	d.swap(d.static)
+	return d, nil
+}
+
+// parseIPPrefix parses an IP, as a single-address prefix, or a CIDR
+func parseIPPrefix(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap().WithZone("")
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Blocked reports whether requests from ip are denied. Clients on the allow list never are.
+func (d *IPDenyList) Blocked(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range d.allow {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	for _, prefix := range d.rules.Load().deny {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the blocks in force, the configured ones first, then the others newest first
+func (d *IPDenyList) List(ctx context.Context) ([]IPBlock, error) {
+	if err := d.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return append([]IPBlock{}, d.rules.Load().blocks...), nil
+}
+
+// Block denies every request from an IP or CIDR, for duration or until unblocked when duration is
+// 0. The block is shared through Redis: this replica enforces it at once, the others on their next
+// reload. Blocking a CIDR again replaces its block.
+func (d *IPDenyList) Block(ctx context.Context, cidr, reason string, duration time.Duration) (*IPBlock, error) {
+	prefix, err := parseIPPrefix(strings.TrimSpace(cidr))
+	if err != nil {
+		return nil, ErrInvalidIPBlock
+	}
+	if (prefix.Addr().Is4() && prefix.Bits() < MinIPv4BlockBits) || (prefix.Addr().Is6() && prefix.Bits() < MinIPv6BlockBits) {
+		return nil, ErrInvalidIPBlock
+	}
+	if duration < 0 || len(reason) > MaxBanReasonLength {
+		return nil, ErrInvalidIPBlock
+	}
+
+	now := time.Now().UTC()
+	block := &IPBlock{CIDR: prefix.String(), Reason: reason, Source: IPBlockSourceRedis, CreatedAt: &now}
+	if duration > 0 {
+		expiresAt := now.Add(duration)
+		block.ExpiresAt = &expiresAt
+	}
+	data, err := json.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.client.HSet(ctx, IPDenyListKey, block.CIDR, data).Err(); err != nil {
+		return nil, err
+	}
+	if err := d.Reload(ctx); err != nil {
+		log.Printf("[IPDenyList] Failed to reload after blocking %s: %v", block.CIDR, err)
+	}
+	return block, nil
+}
+
+// Unblock lifts the block of an IP or CIDR added at runtime, and reports whether there was one.
+// Configured blocks are lifted by changing the configuration.
+func (d *IPDenyList) Unblock(ctx context.Context, cidr string) (bool, error) {
+	prefix, err := parseIPPrefix(strings.TrimSpace(cidr))
+	if err != nil {
+		return false, ErrInvalidIPBlock
+	}
+	deleted, err := d.client.HDel(ctx, IPDenyListKey, prefix.String()).Result()
+	if err != nil {
+		return false, err
+	}
+	if err := d.Reload(ctx); err != nil {
+		log.Printf("[IPDenyList] Failed to reload after unblocking %s: %v", prefix, err)
+	}
+	return deleted > 0, nil
+}
+
+// Reload reads the deny list file and the blocks in Redis again, pruning the expired ones. On
+// error the blocks in force are kept.
+func (d *IPDenyList) Reload(ctx context.Context) error {
+	blocks := append([]IPBlock{}, d.static...)
+
+	if d.config.File != "" {
+		fileBlocks, err := readIPDenyFile(d.config.File)
+		if err != nil {
+			return fmt.Errorf("failed to read IP deny file: %w", err)
+		}
+		blocks = append(blocks, fileBlocks...)
+	}
+
+	values, err := d.client.HGetAll(ctx, IPDenyListKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read IP blocks: %w", err)
+	}
+	now := time.Now()
+	var runtime []IPBlock
+	var expired []string
+	for cidr, data := range values {
+		var block IPBlock
+		if err := json.Unmarshal([]byte(data), &block); err != nil {
+			log.Printf("[IPDenyList] Skipping unreadable block of %s: %v", cidr, err)
+			continue
+		}
+		if block.ExpiresAt != nil && !block.ExpiresAt.After(now) {
+			expired = append(expired, cidr)
+			continue
+		}
+		runtime = append(runtime, block)
+	}
+	if len(expired) > 0 {
+		if err := d.client.HDel(ctx, IPDenyListKey, expired...).Err(); err != nil {
+			log.Printf("[IPDenyList] Failed to prune expired blocks: %v", err)
+		}
+	}
+	sort.Slice(runtime, func(i, j int) bool {
+		return runtime[i].CreatedAt != nil && runtime[j].CreatedAt != nil && runtime[i].CreatedAt.After(*runtime[j].CreatedAt)
+	})
+
+	d.swap(append(blocks, runtime...))
+	return nil
+}
+
+// readIPDenyFile reads the blocks of a deny list file. Invalid lines are logged and skipped, so
+// that a bad edit does not lift the other blocks.
+func readIPDenyFile(path string) ([]IPBlock, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var blocks []IPBlock
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		entry, comment, _ := strings.Cut(scanner.Text(), "#")
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		prefix, err := parseIPPrefix(entry)
+		if err != nil {
+			log.Printf("[IPDenyList] Skipping invalid entry %q on line %d of %s", entry, line, path)
+			continue
+		}
+		blocks = append(blocks, IPBlock{CIDR: prefix.String(), Reason: strings.TrimSpace(comment), Source: IPBlockSourceFile})
+	}
+	return blocks, scanner.Err()
+}
+
+// swap puts blocks in force
+func (d *IPDenyList) swap(blocks []IPBlock) {
+	rules := &ipRules{blocks: blocks, deny: make([]netip.Prefix, 0, len(blocks))}
+	for _, block := range blocks {
+		if prefix, err := netip.ParsePrefix(block.CIDR); err == nil {
+			rules.deny = append(rules.deny, prefix)
+		}
+	}
+	d.rules.Store(rules)
+	metrics.IPBlocks.Set(float64(len(rules.deny)))
+}
+
+// Start reloads the deny list every interval until ctx is done
+func (d *IPDenyList) Start(ctx context.Context) {
+	log.Printf("IP deny list reloader started (interval: %v)", d.config.ReloadInterval)
+	ticker := time.NewTicker(d.config.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("IP deny list reloader stopped")
+			return
+		case <-ticker.C:
+			if err := d.Reload(ctx); err != nil {
+				log.Printf("[IPDenyList] Failed to reload: %v", err)
+			}
+		}
+	}
+}
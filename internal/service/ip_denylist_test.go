@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func TestIPDenyList_Blocked(t *testing.T) {
+	denyList, err := NewIPDenyList(&repository.Redis{}, IPDenyListConfig{
+		DenyList:  []string{" 198.51.100.0/24", "203.0.113.7", "2001:db8::/32", ""},
+		AllowList: []string{"198.51.100.10"},
+	})
+	if err != nil {
+		t.Fatalf("NewIPDenyList() error = %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"198.51.100.1", true},
+		{"::ffff:198.51.100.1", true},
+		{"198.51.100.10", false}, // allowed
+		{"203.0.113.7", true},
+		{"203.0.113.8", false},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+		{"not-an-ip", false},
+	}
+	for _, tt := range tests {
+		if got := denyList.Blocked(tt.ip); got != tt.want {
+			t.Errorf("Blocked(%q) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+
+	if _, err := NewIPDenyList(&repository.Redis{}, IPDenyListConfig{DenyList: []string{"198.51.100.0/33"}}); err == nil {
+		t.Error("NewIPDenyList() with an invalid CIDR returned no error")
+	}
+}
+
+func setupTestIPDenyList(t *testing.T, config IPDenyListConfig) (*IPDenyList, func()) {
+	ctx := context.Background()
+
+	redisClient, err := repository.NewRedisClient(ctx, "redis://localhost:6379")
+	if err != nil {
+		t.Skipf("Redis not available: %v", err)
+	}
+	redisClient.Client.Del(ctx, IPDenyListKey)
+
+	denyList, err := NewIPDenyList(redisClient, config)
+	if err != nil {
+		redisClient.Close()
+		t.Fatalf("NewIPDenyList() error = %v", err)
+	}
+
+	cleanup := func() {
+		redisClient.Client.Del(ctx, IPDenyListKey)
+		redisClient.Close()
+	}
+
+	return denyList, cleanup
+}
+
+func TestIPDenyList_BlockUnblock(t *testing.T) {
+	denyList, cleanup := setupTestIPDenyList(t, IPDenyListConfig{DenyList: []string{"203.0.113.7"}})
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for _, cidr := range []string{"not-a-cidr", "0.0.0.0/0", "::/0"} {
+		if _, err := denyList.Block(ctx, cidr, "", 0); !errors.Is(err, ErrInvalidIPBlock) {
+			t.Errorf("Block(%q) error = %v, want %v", cidr, err, ErrInvalidIPBlock)
+		}
+	}
+
+	block, err := denyList.Block(ctx, "198.51.100.77/24", "crawler", time.Hour)
+	if err != nil {
+		t.Fatalf("Block() error = %v", err)
+	}
+	if block.CIDR != "198.51.100.0/24" || block.ExpiresAt == nil {
+		t.Errorf("Block() = %+v, want the masked CIDR with an expiry", block)
+	}
+	if !denyList.Blocked("198.51.100.1") {
+		t.Error("Blocked() = false right after Block()")
+	}
+
+	blocks, err := denyList.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].Source != IPBlockSourceConfig || blocks[1].Source != IPBlockSourceRedis {
+		t.Errorf("List() = %+v, want the configured then the runtime block", blocks)
+	}
+
+	lifted, err := denyList.Unblock(ctx, "198.51.100.0/24")
+	if err != nil || !lifted {
+		t.Fatalf("Unblock() = %v, %v", lifted, err)
+	}
+	if denyList.Blocked("198.51.100.1") {
+		t.Error("Blocked() = true after Unblock()")
+	}
+	if lifted, _ := denyList.Unblock(ctx, "203.0.113.7"); lifted {
+		t.Error("Unblock() lifted a configured block")
+	}
+}
+
+func TestIPDenyList_ReloadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist")
+	if err := os.WriteFile(path, []byte("# crawlers\n192.0.2.0/24 # scraper\nbogus\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	denyList, cleanup := setupTestIPDenyList(t, IPDenyListConfig{File: path})
+	defer cleanup()
+
+	ctx := context.Background()
+	if err := denyList.Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !denyList.Blocked("192.0.2.9") {
+		t.Error("Blocked() = false for an IP in the file")
+	}
+
+	// Edits apply on the next reload
+	if err := os.WriteFile(path, []byte("192.0.2.9\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := denyList.Reload(ctx); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !denyList.Blocked("192.0.2.9") || denyList.Blocked("192.0.2.10") {
+		t.Error("Reload() did not apply the edited file")
+	}
+
+	// A missing file keeps the blocks in force
+	_ = os.Remove(path)
+	if err := denyList.Reload(ctx); err == nil || !denyList.Blocked("192.0.2.9") {
+		t.Errorf("Reload() of a missing file error = %v, want an error and the blocks kept", err)
+	}
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// DefaultJournalInlineMaxSize is the default size in bytes up to which journaled content is
+	// written inline; larger content is saved to S3 first and journaled by reference
+	DefaultJournalInlineMaxSize = 64 * 1024
+	// journalCompactSize is the size above which the journal is emptied once no create is pending
+	journalCompactSize = 16 * 1024 * 1024
+	// maxJournalLineSize bounds a journal line: a record with content of up to MaxContentSize
+	maxJournalLineSize = 4*MaxContentSize + 64*1024
+)
+
+// Journal operations
+const (
+	journalBegin = "begin"
+	journalEnd   = "end"
+)
+
+// journalEntry is a line of the journal
+type journalEntry struct {
+	Op      string `json:"op"`
+	ShortID string `json:"short_id"`
+	// Paste is the record to insert, as MongoDB extended JSON
+	Paste json.RawMessage `json:"paste,omitempty"`
+	// Content is the content to save, nil when it was saved to S3 before the entry was written
+	Content *string `json:"content,omitempty"`
+}
+
+// record decodes the paste record of a begin entry
+func (e journalEntry) record() (*model.Paste, error) {
+	var paste model.Paste
+	if err := bson.UnmarshalExtJSON(e.Paste, true, &paste); err != nil {
+		return nil, err
+	}
+	return &paste, nil
+}
+
+// Journal is a local append-only write-ahead log of creates. A create is journaled before it is
+// acknowledged and marked done once both its S3 and MongoDB writes completed, so that the creates
+// cut short by a crash are completed when the server restarts.
+type Journal struct {
+	path        string
+	inlineMax   int
+	compactSize int64 // size above which the journal is emptied once no create is pending
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	pending int // creates begun and not ended
+}
+
+// OpenJournal opens the journal at path, creating it if needed. Content of up to inlineMax
+// bytes is journaled inline; 0 or less uses DefaultJournalInlineMaxSize.
+func OpenJournal(path string, inlineMax int) (*Journal, error) {
+	if inlineMax <= 0 {
+		inlineMax = DefaultJournalInlineMaxSize
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("journal: failed to open %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &Journal{path: path, inlineMax: inlineMax, compactSize: journalCompactSize, file: file, size: info.Size()}, nil
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
+
+// inline reports whether content of the given size is journaled inline
+func (j *Journal) inline(size int) bool {
+	return size <= j.inlineMax
+}
+
+// begin journals the create of paste. Content is written inline when not nil.
+func (j *Journal) begin(paste *model.Paste, content *string) error {
+	record, err := bson.MarshalExtJSON(paste, true, false)
+	if err != nil {
+		return err
+	}
+	line, err := journalLine(journalEntry{Op: journalBegin, ShortID: paste.ShortID, Paste: record, Content: content})
+	if err != nil {
+		return err
+	}
+
+	// Count the create in the same lock hold as its entry is written, or a concurrent end could
+	// see no pending create and compact the entry away
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.write(line); err != nil {
+		return err
+	}
+	j.pending++
+	return nil
+}
+
+// end marks the create of a paste done, or given up after an error reported to the client
+func (j *Journal) end(shortID string) {
+	line, err := journalLine(journalEntry{Op: journalEnd, ShortID: shortID})
+	if err != nil {
+		log.Printf("[Journal.end] Failed to journal the end of %s: %v", shortID, err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if line != nil {
+		if err := j.write(line); err != nil {
+			log.Printf("[Journal.end] Failed to journal the end of %s: %v", shortID, err)
+		}
+	}
+	j.pending--
+	if j.pending == 0 && j.size >= j.compactSize {
+		if err := j.file.Truncate(0); err != nil {
+			log.Printf("[Journal.end] Failed to compact journal: %v", err)
+			return
+		}
+		j.size = 0
+	}
+}
+
+// journalLine encodes an entry as a journal line
+func journalLine(entry journalEntry) ([]byte, error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// write appends a line and syncs it to disk. j.mu must be held.
+func (j *Journal) write(line []byte) error {
+	n, err := j.file.Write(line)
+	j.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// unfinished returns the creates begun and not ended, in journal order
+func (j *Journal) unfinished() ([]journalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	file, err := os.Open(j.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var order []string
+	begun := make(map[string]journalEntry)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxJournalLineSize)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A line cut short by a crash; its create was never acknowledged
+			log.Printf("[Journal.unfinished] Skipping unreadable entry: %v", err)
+			continue
+		}
+		switch entry.Op {
+		case journalBegin:
+			order = append(order, entry.ShortID)
+			begun[entry.ShortID] = entry
+		case journalEnd:
+			delete(begun, entry.ShortID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	entries := make([]journalEntry, 0, len(begun))
+	for _, shortID := range order {
+		if entry, ok := begun[shortID]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// reset replaces the journal with the given entries
+func (j *Journal) reset(entries []journalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	j.size, j.pending = 0, 0
+	for _, entry := range entries {
+		line, err := journalLine(entry)
+		if err != nil {
+			return err
+		}
+		n, err := j.file.Write(line)
+		j.size += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	return j.file.Sync()
+}
+
+// SetJournal makes CreatePaste journal each create before acknowledging it. ReplayJournal
+// completes the creates a crash cut short.
+func (s *PasteService) SetJournal(journal *Journal) {
+	s.journal = journal
+}
+
+// ReplayJournal completes the journaled creates whose S3 or MongoDB write did not complete, as
+// after a crash, and returns their number. Creates that fail again stay journaled for the next
+// start; creates of expired pastes are dropped. It must run before the service takes creates.
+func (s *PasteService) ReplayJournal(ctx context.Context) (int, error) {
+	if s.journal == nil {
+		return 0, nil
+	}
+	entries, err := s.journal.unfinished()
+	if err != nil {
+		return 0, fmt.Errorf("journal: failed to read: %w", err)
+	}
+
+	replayed := 0
+	var failed []journalEntry
+	for _, entry := range entries {
+		done, err := s.replayCreate(ctx, entry)
+		if err != nil {
+			log.Printf("[PasteService.ReplayJournal] Failed to replay create of %s: %v", entry.ShortID, err)
+			failed = append(failed, entry)
+			continue
+		}
+		if done {
+			replayed++
+		}
+	}
+
+	if err := s.journal.reset(failed); err != nil {
+		return replayed, fmt.Errorf("journal: failed to compact: %w", err)
+	}
+	return replayed, nil
+}
+
+// replayCreate completes a journaled create and reports whether it had to
+func (s *PasteService) replayCreate(ctx context.Context, entry journalEntry) (bool, error) {
+	paste, err := entry.record()
+	if err != nil {
+		return false, err
+	}
+	if paste.IsExpired() {
+		return false, nil
+	}
+
+	if entry.Content != nil {
+		if err := s.storage.SaveContent(ctx, paste.ShortID, *entry.Content); err != nil {
+			return false, err
+		}
+	} else if exists, err := s.storage.ContentExists(ctx, paste.ShortID); err != nil {
+		return false, err
+	} else if !exists {
+		// Content journaled by reference is saved before the entry is written, so it was
+		// deleted since: there is nothing left to create
+		log.Printf("[PasteService.ReplayJournal] Dropping create of %s: content not found", paste.ShortID)
+		return false, nil
+	}
+
+	if err := s.pasteRepo.Create(ctx, paste); err != nil {
+		if errors.Is(err, repository.ErrPasteDuplicate) {
+			// The record was inserted before the crash
+			return false, nil
+		}
+		return false, err
+	}
+	log.Printf("[PasteService.ReplayJournal] Completed create of %s", paste.ShortID)
+	s.publish(model.PasteEventCreated, paste.ShortID, paste)
+	return true, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestJournal_Unfinished(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	journal, err := OpenJournal(path, 8)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer journal.Close()
+
+	if !journal.inline(8) || journal.inline(9) {
+		t.Errorf("inline() does not split at the inline max size of 8 bytes")
+	}
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+	content := "hello"
+	for _, paste := range []*model.Paste{
+		{ShortID: "done", SyntaxType: "text"},
+		{ShortID: "inline", SyntaxType: "go", ExpiresAt: &expiresAt, SourceIPHash: "hash"},
+		{ShortID: "byref", SyntaxType: "text"},
+	} {
+		var inline *string
+		if paste.ShortID == "inline" {
+			inline = &content
+		}
+		if err := journal.begin(paste, inline); err != nil {
+			t.Fatalf("begin() error = %v", err)
+		}
+	}
+	journal.end("done")
+
+	// A reopened journal finds the creates that did not end, with their whole record
+	reopened, err := OpenJournal(path, 8)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer reopened.Close()
+	entries, err := reopened.unfinished()
+	if err != nil {
+		t.Fatalf("unfinished() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].ShortID != "inline" || entries[1].ShortID != "byref" {
+		t.Fatalf("unfinished() = %+v, want inline and byref", entries)
+	}
+	if entries[0].Content == nil || *entries[0].Content != content || entries[1].Content != nil {
+		t.Errorf("unfinished() content = %v, %v, want %q inline and none by reference", entries[0].Content, entries[1].Content, content)
+	}
+	paste, err := entries[0].record()
+	if err != nil {
+		t.Fatalf("journaled record error = %v", err)
+	}
+	if paste.SourceIPHash != "hash" || paste.ExpiresAt == nil || !paste.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("journaled record = %+v, want the fields not serialized to API clients too", paste)
+	}
+
+	// Resetting keeps only the given entries
+	if err := reopened.reset(entries[1:]); err != nil {
+		t.Fatalf("reset() error = %v", err)
+	}
+	if entries, err = reopened.unfinished(); err != nil || len(entries) != 1 || entries[0].ShortID != "byref" {
+		t.Errorf("unfinished() after reset() = %+v, %v, want byref", entries, err)
+	}
+}
+
+func TestJournal_ConcurrentBeginEnd(t *testing.T) {
+	journal, err := OpenJournal(filepath.Join(t.TempDir(), "journal.log"), 0)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	defer journal.Close()
+	// Compact whenever no create is pending, so that an end racing a begin would drop its entry
+	journal.compactSize = 1
+
+	// A few creates at a time, so that the pending count often drops to zero
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				shortID := fmt.Sprintf("w%d-%03d", w, i)
+				if err := journal.begin(&model.Paste{ShortID: shortID, SyntaxType: "text"}, nil); err != nil {
+					t.Errorf("begin() error = %v", err)
+					return
+				}
+				// Until it ends, the create must survive the compactions of other creates
+				if !journalHas(t, journal, shortID) {
+					t.Errorf("unfinished() lost the in-flight create of %s", shortID)
+				}
+				journal.end(shortID)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if journal.pending != 0 {
+		t.Errorf("pending = %d, want 0", journal.pending)
+	}
+}
+
+// journalHas reports whether the create of shortID is begun and not ended in journal
+func journalHas(t *testing.T, journal *Journal, shortID string) bool {
+	t.Helper()
+	entries, err := journal.unfinished()
+	if err != nil {
+		t.Errorf("unfinished() error = %v", err)
+		return false
+	}
+	for _, entry := range entries {
+		if entry.ShortID == shortID {
+			return true
+		}
+	}
+	return false
+}
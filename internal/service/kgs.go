@@ -3,9 +3,13 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"log"
 	"math/big"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/huylvt/gisty/pkg/base62"
@@ -25,6 +29,8 @@ const (
 	DefaultBatchSize = 1000
 	// DefaultCheckInterval is how often the worker checks for key availability
 	DefaultCheckInterval = 1 * time.Minute
+	// DefaultLeaseTTL is how long a replica holds a block of leased keys
+	DefaultLeaseTTL = 10 * time.Minute
 )
 
 var (
@@ -38,17 +44,51 @@ type Key struct {
 	Used      bool      `bson:"used"`
 	CreatedAt time.Time `bson:"created_at"`
 	UsedAt    time.Time `bson:"used_at,omitempty"`
+	// LeasedBy is the replica the unused key is leased to, until LeaseExpiresAt
+	LeasedBy       string    `bson:"leased_by,omitempty"`
+	LeaseExpiresAt time.Time `bson:"lease_expires_at,omitempty"`
+	// Batch identifies the batch the key was claimed in by a replica's key buffer
+	Batch string `bson:"batch,omitempty"`
+	// Alphabet is the name of the alphabet the key was generated from, empty for base62
+	Alphabet string `bson:"alphabet,omitempty"`
 }
 
 // KGS is the Key Generation Service
 type KGS struct {
 	collection *mongo.Collection
+	stopCh     chan struct{}
+	doneCh     chan struct{}
+
+	// Key leasing, disabled when lease is nil
+	lease   *keyLease
+	claimMu sync.Mutex // serializes the claims of new blocks
+
+	// buffer holds keys claimed ahead of use, disabled when nil
+	buffer *keyBuffer
+
+	// alphabet new keys are generated from and taken from the pool with
+	alphabet keyAlphabet
+	// caseUnique skips generated keys equal to an existing one regardless of case
+	caseUnique bool
+
+	// floor rejects creates while the unused keys are below it, disabled when nil
+	floor     *keyFloor
+	exhausted atomic.Bool
+}
+
+// keyLease is how a replica leases keys
+type keyLease struct {
+	owner     string
+	blockSize int
+	ttl       time.Duration
 }
 
 // NewKGS creates a new Key Generation Service
 func NewKGS(db *mongo.Database) (*KGS, error) {
 	kgs := &KGS{
 		collection: db.Collection(CollectionName),
+		stopCh:     make(chan struct{}),
+		doneCh:     make(chan struct{}),
 	}
 
 	// Create indexes
@@ -69,6 +109,13 @@ func (k *KGS) createIndexes(ctx context.Context) error {
 		{
 			Keys: bson.D{{Key: "used", Value: 1}},
 		},
+		{
+			Keys: bson.D{{Key: "leased_by", Value: 1}, {Key: "used", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "lease_expires_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
 	}
 
 	_, err := k.collection.Indexes().CreateMany(ctx, indexes)
@@ -85,15 +132,25 @@ func (k *KGS) GenerateKeys(ctx context.Context, count int) (int, error) {
 	maxAttempts := count * 3 // Allow some retries for collisions
 
 	for i := 0; i < maxAttempts && generated < count; i++ {
-		key, err := generateRandomKey()
+		key, err := k.generateKey()
 		if err != nil {
 			return generated, err
 		}
+		if k.caseUnique {
+			taken, err := k.keyExistsIgnoreCase(ctx, key)
+			if err != nil {
+				return generated, err
+			}
+			if taken {
+				continue // Try another key
+			}
+		}
 
 		doc := Key{
 			Key:       key,
 			Used:      false,
 			CreatedAt: time.Now().UTC(),
+			Alphabet:  k.alphabet.name,
 		}
 
 		_, err = k.collection.InsertOne(ctx, doc)
@@ -110,14 +167,90 @@ func (k *KGS) GenerateKeys(ctx context.Context, count int) (int, error) {
 	return generated, nil
 }
 
-// GetNextKey retrieves and marks an unused key as used atomically
+// SetLeasing makes the replica claim keys in blocks of blockSize leased to it for ttl, and take
+// the keys of new pastes from its own block, so that replicas never contend for the same keys.
+// Keys of a block left unused when its lease expires, as when the replica crashed, are returned
+// to the pool by ReclaimExpiredLeases. It returns the owner ID the keys are leased to.
+// Leasing is meant to run with the key buffer of SetBuffer, which then claims its batches from
+// the block; without it, each key is still taken with one FindOneAndUpdate.
+func (k *KGS) SetLeasing(blockSize int, ttl time.Duration) string {
+	if blockSize <= 0 {
+		k.lease = nil
+		return ""
+	}
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	k.lease = &keyLease{owner: newLeaseOwner(), blockSize: blockSize, ttl: ttl}
+	return k.lease.owner
+}
+
+// GetNextKey retrieves and marks an unused key as used atomically. With a key buffer, the key
+// comes from the buffer. With leasing, the key comes from the replica's block, and from the
+// shared pool only when no block could be claimed.
 func (k *KGS) GetNextKey(ctx context.Context) (string, error) {
-	filter := bson.M{"used": false}
+	if k.buffer != nil {
+		if key, ok := k.nextBufferedKey(ctx); ok {
+			return key, nil
+		}
+	}
+	if k.lease != nil {
+		key, err := k.nextLeasedKey(ctx)
+		if !errors.Is(err, ErrNoKeysAvailable) {
+			return key, err
+		}
+		// Every unused key may be leased to other replicas, which do not need them all
+	}
+	key, err := k.takeKey(ctx, k.unusedFilter())
+	if errors.Is(err, ErrNoKeysAvailable) {
+		// The pool is empty: with a critical floor, creates are rejected until it is replenished
+		k.recordUnused(0)
+	}
+	return key, err
+}
+
+// nextLeasedKey takes a key from the replica's block, claiming a new block when it is used up
+func (k *KGS) nextLeasedKey(ctx context.Context) (string, error) {
+	key, err := k.takeKey(ctx, k.leasedFilter())
+	if !errors.Is(err, ErrNoKeysAvailable) {
+		return key, err
+	}
+
+	k.claimMu.Lock()
+	defer k.claimMu.Unlock()
+
+	// Another request may have claimed a block meanwhile
+	key, err = k.takeKey(ctx, k.leasedFilter())
+	if !errors.Is(err, ErrNoKeysAvailable) {
+		return key, err
+	}
+	claimed, err := k.claimBlock(ctx)
+	if err != nil {
+		return "", err
+	}
+	if claimed == 0 {
+		return "", ErrNoKeysAvailable
+	}
+	return k.takeKey(ctx, k.leasedFilter())
+}
+
+// leasedFilter matches the unused keys of the replica's unexpired block
+func (k *KGS) leasedFilter() bson.M {
+	return bson.M{
+		"used":             false,
+		"leased_by":        k.lease.owner,
+		"lease_expires_at": bson.M{"$gt": time.Now().UTC()},
+	}
+}
+
+// takeKey marks a key matching filter as used atomically and returns it
+func (k *KGS) takeKey(ctx context.Context, filter bson.M) (string, error) {
 	update := bson.M{
 		"$set": bson.M{
 			"used":    true,
 			"used_at": time.Now().UTC(),
 		},
+		"$unset": bson.M{"leased_by": "", "lease_expires_at": ""},
 	}
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 
@@ -133,9 +266,93 @@ func (k *KGS) GetNextKey(ctx context.Context) (string, error) {
 	return key.Key, nil
 }
 
-// CountUnusedKeys returns the count of unused keys
+// claimBlock leases a block of unleased keys to the replica and returns the number claimed, which
+// is less than the block size when other replicas claimed some of the same keys first
+func (k *KGS) claimBlock(ctx context.Context) (int, error) {
+	unleased := k.unusedFilter()
+	unleased["leased_by"] = bson.M{"$exists": false}
+	opts := options.Find().SetLimit(int64(k.lease.blockSize)).SetProjection(bson.M{"key": 1})
+	cursor, err := k.collection.Find(ctx, unleased, opts)
+	if err != nil {
+		return 0, err
+	}
+	var keys []Key
+	if err := cursor.All(ctx, &keys); err != nil {
+		return 0, err
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.Key
+	}
+	filter := bson.M{"key": bson.M{"$in": names}, "used": false, "leased_by": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{
+		"leased_by":        k.lease.owner,
+		"lease_expires_at": time.Now().UTC().Add(k.lease.ttl),
+	}}
+	result, err := k.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	log.Printf("[KGS.claimBlock] Leased %d keys to %s for %v", result.ModifiedCount, k.lease.owner, k.lease.ttl)
+	return int(result.ModifiedCount), nil
+}
+
+// ReclaimExpiredLeases returns the unused keys whose lease expired to the pool and returns their
+// number
+func (k *KGS) ReclaimExpiredLeases(ctx context.Context) (int64, error) {
+	filter := bson.M{"used": false, "lease_expires_at": bson.M{"$lte": time.Now().UTC()}}
+	return k.unlease(ctx, filter)
+}
+
+// ReleaseLeases returns the unused keys leased to the replica to the pool, on shutdown
+func (k *KGS) ReleaseLeases(ctx context.Context) (int64, error) {
+	if k.lease == nil {
+		return 0, nil
+	}
+	return k.unlease(ctx, bson.M{"used": false, "leased_by": k.lease.owner})
+}
+
+// unlease removes the lease of the keys matching filter
+func (k *KGS) unlease(ctx context.Context, filter bson.M) (int64, error) {
+	update := bson.M{"$unset": bson.M{"leased_by": "", "lease_expires_at": ""}}
+	result, err := k.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// newLeaseOwner returns an ID telling the replica apart in the keys collection: its host name, for
+// operators, with a random suffix since replicas on different hosts may share a container name
+func newLeaseOwner() string {
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "gisty"
+	}
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// Prewarm generates count keys when the pool has no unused keys, as on a fresh database, so the
+// first pastes do not fail until the replenish worker's first run. It returns the number of keys
+// generated.
+func (k *KGS) Prewarm(ctx context.Context, count int) (int, error) {
+	unused, err := k.CountUnusedKeys(ctx)
+	if err != nil || unused > 0 {
+		return 0, err
+	}
+	return k.GenerateKeys(ctx, count)
+}
+
+// CountUnusedKeys returns the count of unused keys of the KGS alphabet
 func (k *KGS) CountUnusedKeys(ctx context.Context) (int64, error) {
-	return k.collection.CountDocuments(ctx, bson.M{"used": false})
+	return k.collection.CountDocuments(ctx, k.unusedFilter())
 }
 
 // CountTotalKeys returns the total count of keys
@@ -196,6 +413,11 @@ func (k *KGS) StartReplenishWorker(ctx context.Context, cfg WorkerConfig) {
 		select {
 		case <-ctx.Done():
 			log.Println("KGS Worker stopped")
+			close(k.doneCh)
+			return
+		case <-k.stopCh:
+			log.Println("KGS Worker stopped")
+			close(k.doneCh)
 			return
 		case <-ticker.C:
 			k.checkAndReplenish(ctx, cfg)
@@ -203,8 +425,21 @@ func (k *KGS) StartReplenishWorker(ctx context.Context, cfg WorkerConfig) {
 	}
 }
 
+// StopReplenishWorker stops the replenish worker once the keys it is generating are stored
+func (k *KGS) StopReplenishWorker() {
+	close(k.stopCh)
+	<-k.doneCh
+}
+
 // checkAndReplenish checks if keys need to be replenished and generates them if necessary
 func (k *KGS) checkAndReplenish(ctx context.Context, cfg WorkerConfig) {
+	// Keys leased to replicas that crashed are available again
+	if reclaimed, err := k.ReclaimExpiredLeases(ctx); err != nil {
+		log.Printf("KGS Worker: error reclaiming expired leases: %v", err)
+	} else if reclaimed > 0 {
+		log.Printf("KGS Worker: reclaimed %d keys of expired leases", reclaimed)
+	}
+
 	unused, err := k.CountUnusedKeys(ctx)
 	if err != nil {
 		log.Printf("KGS Worker: error counting unused keys: %v", err)
@@ -217,10 +452,16 @@ func (k *KGS) checkAndReplenish(ctx context.Context, cfg WorkerConfig) {
 		generated, err := k.GenerateKeys(ctx, cfg.BatchSize)
 		if err != nil {
 			log.Printf("KGS Worker: error generating keys: %v", err)
-			return
 		}
 
-		newUnused, _ := k.CountUnusedKeys(ctx)
-		log.Printf("KGS Worker: generated %d new keys, total unused: %d", generated, newUnused)
+		newUnused, countErr := k.CountUnusedKeys(ctx)
+		if countErr != nil {
+			return
+		}
+		if err == nil {
+			log.Printf("KGS Worker: generated %d new keys, total unused: %d", generated, newUnused)
+		}
+		unused = newUnused
 	}
+	k.recordUnused(unused)
 }
\ No newline at end of file
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Key casings
+const (
+	// KeyCasingMixed generates base62 keys, the default
+	KeyCasingMixed = "mixed"
+	// KeyCasingLower generates lowercase base36 keys, which still match when retyped in another case
+	KeyCasingLower = "lower"
+)
+
+// lowercaseAlphabet is the alphabet of keys generated with KeyCasingLower
+const lowercaseAlphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// ErrInvalidKeyCasing is returned for an unknown key casing
+var ErrInvalidKeyCasing = errors.New("kgs: invalid key casing")
+
+// caseInsensitiveCollation compares keys regardless of case
+var caseInsensitiveCollation = &options.Collation{Locale: "en", Strength: 2}
+
+// keyAlphabet is an alphabet keys are generated from. Its name is recorded with each key, so the
+// pool only hands out keys of the configured alphabet; keys of another alphabet stay valid, and
+// stay in the pool for when that alphabet is configured again.
+type keyAlphabet struct {
+	name  string // empty for base62, the alphabet of keys predating the record
+	chars string // empty draws base62 keys with generateRandomKey
+}
+
+// Key alphabets. The unambiguous ones leave out the characters mistaken for one another when
+// read aloud or handwritten: 0, O and o, and 1, l and I.
+var (
+	alphabetBase62            = keyAlphabet{}
+	alphabetBase36            = keyAlphabet{name: "base36", chars: lowercaseAlphabet}
+	alphabetBase62Unambiguous = keyAlphabet{name: "base62-unambiguous", chars: "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"}
+	alphabetBase36Unambiguous = keyAlphabet{name: "base36-unambiguous", chars: "23456789abcdefghijkmnpqrstuvwxyz"}
+)
+
+// SetKeyAlphabet sets the alphabet of generated keys from their casing, and from whether
+// characters that look alike are left out. With KeyCasingLower, new keys never equal an
+// existing key regardless of case, so that case-insensitive lookups find a single paste.
+func (k *KGS) SetKeyAlphabet(ctx context.Context, casing string, unambiguous bool) error {
+	switch casing {
+	case "", KeyCasingMixed:
+		k.alphabet, k.caseUnique = alphabetBase62, false
+		if unambiguous {
+			k.alphabet = alphabetBase62Unambiguous
+		}
+		return nil
+	case KeyCasingLower:
+	default:
+		return fmt.Errorf("%w: %q (supported: %s, %s)", ErrInvalidKeyCasing, casing, KeyCasingMixed, KeyCasingLower)
+	}
+
+	_, err := k.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "key", Value: 1}},
+		Options: options.Index().SetName("key_ci").SetCollation(caseInsensitiveCollation),
+	})
+	if err != nil {
+		return err
+	}
+	k.alphabet, k.caseUnique = alphabetBase36, true
+	if unambiguous {
+		k.alphabet = alphabetBase36Unambiguous
+	}
+	return nil
+}
+
+// unusedFilter matches the unused keys of the KGS alphabet
+func (k *KGS) unusedFilter() bson.M {
+	filter := bson.M{"used": false, "alphabet": k.alphabet.name}
+	if k.alphabet.name == "" {
+		filter["alphabet"] = bson.M{"$exists": false}
+	}
+	return filter
+}
+
+// keyExistsIgnoreCase reports whether a key equal to key regardless of case was generated
+func (k *KGS) keyExistsIgnoreCase(ctx context.Context, key string) (bool, error) {
+	opts := options.FindOne().SetCollation(caseInsensitiveCollation).SetProjection(bson.M{"_id": 1})
+	err := k.collection.FindOne(ctx, bson.M{"key": key}, opts).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// generateKey generates a random key of KeyLength from the KGS alphabet
+func (k *KGS) generateKey() (string, error) {
+	if k.alphabet.chars == "" {
+		return generateRandomKey()
+	}
+	return generateKeyFrom(k.alphabet.chars)
+}
+
+// generateKeyFrom generates a random key of KeyLength with characters drawn from alphabet
+func generateKeyFrom(alphabet string) (string, error) {
+	size := big.NewInt(int64(len(alphabet)))
+	key := make([]byte, KeyLength)
+	for i := range key {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		key[i] = alphabet[n.Int64()]
+	}
+	return string(key), nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// keyBufferRefillTimeout bounds a refill of the key buffer in the background
+const keyBufferRefillTimeout = 10 * time.Second
+
+// keyBuffer holds keys a replica claimed ahead of use, so new pastes get a key without a MongoDB
+// round trip. Buffered keys are marked used in the keys collection: those of a replica that
+// crashes are lost, which the size of the key space makes harmless.
+type keyBuffer struct {
+	keys      chan string
+	mu        sync.Mutex  // serializes refills and the release
+	refilling atomic.Bool // a refill runs in the background
+	released  bool        // set on shutdown; the buffer is no longer refilled
+}
+
+// SetBuffer makes GetNextKey take keys from a buffer of size keys claimed in batches, refilled in
+// the background once it is a quarter full. A size of 0 disables the buffer. ReleaseBuffer
+// returns the keys left to the pool on shutdown.
+func (k *KGS) SetBuffer(size int) {
+	if size <= 0 {
+		k.buffer = nil
+		return
+	}
+	k.buffer = &keyBuffer{keys: make(chan string, size)}
+}
+
+// nextBufferedKey takes a key from the buffer, refilling it first when it is empty. It reports
+// false when no key could be buffered; the key is then claimed directly.
+func (k *KGS) nextBufferedKey(ctx context.Context) (string, bool) {
+	b := k.buffer
+	select {
+	case key := <-b.keys:
+		if len(b.keys) <= cap(b.keys)/4 && b.refilling.CompareAndSwap(false, true) {
+			go func() {
+				defer b.refilling.Store(false)
+				ctx, cancel := context.WithTimeout(context.Background(), keyBufferRefillTimeout)
+				defer cancel()
+				if err := k.refillBuffer(ctx); err != nil {
+					log.Printf("[KGS.refillBuffer] Failed to refill key buffer: %v", err)
+				}
+			}()
+		}
+		return key, true
+	default:
+	}
+
+	if err := k.refillBuffer(ctx); err != nil {
+		log.Printf("[KGS.refillBuffer] Failed to refill key buffer: %v", err)
+		return "", false
+	}
+	select {
+	case key := <-b.keys:
+		return key, true
+	default:
+		return "", false
+	}
+}
+
+// refillBuffer claims the keys missing from the buffer
+func (k *KGS) refillBuffer(ctx context.Context) error {
+	b := k.buffer
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	missing := cap(b.keys) - len(b.keys)
+	if b.released || missing <= 0 {
+		return nil
+	}
+	keys, err := k.claimBatch(ctx, missing)
+	if err != nil {
+		return err
+	}
+
+	// Only refills add keys, so they all fit
+	for _, key := range keys {
+		b.keys <- key
+	}
+	return nil
+}
+
+// claimBatch marks up to n keys used for the buffer and returns them: keys of the replica's
+// leased block when leasing, unleased keys otherwise
+func (k *KGS) claimBatch(ctx context.Context, n int) ([]string, error) {
+	if k.lease != nil {
+		keys, err := k.claimBatchMatching(ctx, k.leasedFilter(), n)
+		if err != nil || len(keys) > 0 {
+			return keys, err
+		}
+		k.claimMu.Lock()
+		claimed, err := k.claimBlock(ctx)
+		k.claimMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if claimed > 0 {
+			return k.claimBatchMatching(ctx, k.leasedFilter(), n)
+		}
+	}
+	unleased := k.unusedFilter()
+	unleased["leased_by"] = bson.M{"$exists": false}
+	return k.claimBatchMatching(ctx, unleased, n)
+}
+
+// claimBatchMatching marks up to n keys matching filter used, tagged with a new batch ID, and
+// returns them
+func (k *KGS) claimBatchMatching(ctx context.Context, filter bson.M, n int) ([]string, error) {
+	opts := options.Find().SetLimit(int64(n)).SetProjection(bson.M{"key": 1})
+	cursor, err := k.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var found []Key
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, nil
+	}
+	names := make([]string, len(found))
+	for i, key := range found {
+		names[i] = key.Key
+	}
+
+	batch := newBatchID()
+	update := bson.M{
+		"$set":   bson.M{"used": true, "used_at": time.Now().UTC(), "batch": batch},
+		"$unset": bson.M{"leased_by": "", "lease_expires_at": ""},
+	}
+	result, err := k.collection.UpdateMany(ctx, bson.M{"key": bson.M{"$in": names}, "used": false}, update)
+	if err != nil {
+		return nil, err
+	}
+	if int(result.ModifiedCount) == len(names) {
+		return names, nil
+	}
+
+	// Other replicas took some of the keys first: keep those tagged with the batch
+	cursor, err = k.collection.Find(ctx, bson.M{"key": bson.M{"$in": names}, "batch": batch}, options.Find().SetProjection(bson.M{"key": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var claimed []Key
+	if err := cursor.All(ctx, &claimed); err != nil {
+		return nil, err
+	}
+	names = names[:0]
+	for _, key := range claimed {
+		names = append(names, key.Key)
+	}
+	return names, nil
+}
+
+// ReleaseBuffer returns the buffered keys to the pool, on shutdown, and returns their number
+func (k *KGS) ReleaseBuffer(ctx context.Context) (int64, error) {
+	b := k.buffer
+	if b == nil {
+		return 0, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.released = true
+
+	var keys []string
+	for len(b.keys) > 0 {
+		keys = append(keys, <-b.keys)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	update := bson.M{"$set": bson.M{"used": false}, "$unset": bson.M{"used_at": "", "batch": ""}}
+	result, err := k.collection.UpdateMany(ctx, bson.M{"key": bson.M{"$in": keys}, "used": true}, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// newBatchID returns a random ID of a batch of buffered keys
+func newBatchID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+)
+
+const (
+	// DefaultKeyFloorRetryAfter is the Retry-After hint sent while the key pool is exhausted
+	DefaultKeyFloorRetryAfter = time.Minute
+	// DefaultKeyFloorCheckInterval is how often serving replicas count the unused keys
+	DefaultKeyFloorCheckInterval = 30 * time.Second
+)
+
+// ErrKeysExhausted is returned while the unused keys are below the critical floor. It wraps
+// ErrNoKeysAvailable.
+var ErrKeysExhausted = fmt.Errorf("%w: below the critical floor", ErrNoKeysAvailable)
+
+// keyFloor is the critical floor of unused keys below which creates are rejected
+type keyFloor struct {
+	floor      int64
+	retryAfter time.Duration
+}
+
+// SetCriticalFloor makes the KGS exhausted while fewer than floor keys are unused, even after the
+// replenish worker ran: an alert is logged, and creates are rejected with ErrKeysExhausted,
+// retried after retryAfter, rather than failing one by one once the pool is empty. A floor of 0
+// disables it.
+func (k *KGS) SetCriticalFloor(floor int64, retryAfter time.Duration) {
+	if floor <= 0 {
+		k.floor = nil
+		return
+	}
+	if retryAfter <= 0 {
+		retryAfter = DefaultKeyFloorRetryAfter
+	}
+	k.floor = &keyFloor{floor: floor, retryAfter: retryAfter}
+}
+
+// Exhausted reports whether the unused keys are below the critical floor, and the Retry-After
+// hint to send
+func (k *KGS) Exhausted() (bool, time.Duration) {
+	if k.floor == nil {
+		return false, 0
+	}
+	return k.exhausted.Load(), k.floor.retryAfter
+}
+
+// RetryAfter returns the Retry-After hint sent when no key is available; 0 without a floor
+func (k *KGS) RetryAfter() time.Duration {
+	if k.floor == nil {
+		return 0
+	}
+	return k.floor.retryAfter
+}
+
+// MonitorCriticalFloor counts the unused keys every interval until ctx is done, so serving
+// replicas notice an exhausted pool replenished by workers elsewhere
+func (k *KGS) MonitorCriticalFloor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if unused, err := k.CountUnusedKeys(ctx); err != nil {
+			log.Printf("[KGS.MonitorCriticalFloor] Failed to count unused keys: %v", err)
+		} else {
+			k.recordUnused(unused)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordUnused exports the number of unused keys and updates the exhausted flag, alerting on
+// changes
+func (k *KGS) recordUnused(unused int64) {
+	metrics.KGSUnusedKeys.Set(float64(unused))
+	if k.floor == nil {
+		return
+	}
+
+	exhausted := unused < k.floor.floor
+	if k.exhausted.Swap(exhausted) == exhausted {
+		return
+	}
+	if exhausted {
+		metrics.KGSExhausted.Set(1)
+		log.Printf("[KGS] ALERT: unused keys (%d) below the critical floor (%d), rejecting creates", unused, k.floor.floor)
+	} else {
+		metrics.KGSExhausted.Set(0)
+		log.Printf("[KGS] Unused keys (%d) back above the critical floor (%d), accepting creates", unused, k.floor.floor)
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -118,6 +120,116 @@ func TestKGS_GetNextKey_NoKeysAvailable(t *testing.T) {
 	}
 }
 
+func TestKGS_Prewarm(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	kgs, err := NewKGS(db)
+	if err != nil {
+		t.Fatalf("NewKGS() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// An empty pool is filled
+	generated, err := kgs.Prewarm(ctx, 20)
+	if err != nil {
+		t.Fatalf("Prewarm() error = %v", err)
+	}
+	if generated != 20 {
+		t.Errorf("Prewarm() generated %d keys, want 20", generated)
+	}
+	if _, err := kgs.GetNextKey(ctx); err != nil {
+		t.Errorf("GetNextKey() after Prewarm() error = %v", err)
+	}
+
+	// A pool with unused keys is left to the replenish worker
+	if generated, err := kgs.Prewarm(ctx, 20); err != nil || generated != 0 {
+		t.Errorf("Prewarm() of a non-empty pool = %d, %v, want 0, nil", generated, err)
+	}
+}
+
+func TestKGS_Leasing(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Two replicas sharing the key pool
+	first, err := NewKGS(db)
+	if err != nil {
+		t.Fatalf("NewKGS() error = %v", err)
+	}
+	second, err := NewKGS(db)
+	if err != nil {
+		t.Fatalf("NewKGS() error = %v", err)
+	}
+	firstOwner := first.SetLeasing(10, time.Hour)
+	secondOwner := second.SetLeasing(10, time.Hour)
+	if firstOwner == "" || firstOwner == secondOwner {
+		t.Fatalf("SetLeasing() owners = %q, %q, want distinct IDs", firstOwner, secondOwner)
+	}
+	if _, err := first.GenerateKeys(ctx, 25); err != nil {
+		t.Fatalf("GenerateKeys() error = %v", err)
+	}
+
+	keys := make(map[string]bool)
+	for i := 0; i < 3; i++ {
+		for _, kgs := range []*KGS{first, second} {
+			key, err := kgs.GetNextKey(ctx)
+			if err != nil {
+				t.Fatalf("GetNextKey() error = %v", err)
+			}
+			if keys[key] {
+				t.Errorf("GetNextKey() returned duplicate key: %s", key)
+			}
+			keys[key] = true
+		}
+	}
+
+	// Each replica took its keys from a block of its own
+	for _, owner := range []string{firstOwner, secondOwner} {
+		leased, err := db.Collection(CollectionName).CountDocuments(ctx, bson.M{"leased_by": owner})
+		if err != nil {
+			t.Fatalf("CountDocuments() error = %v", err)
+		}
+		if leased != 7 {
+			t.Errorf("keys leased to %s = %d, want 7", owner, leased)
+		}
+	}
+
+	// A replica shutting down returns its unused keys
+	if released, err := first.ReleaseLeases(ctx); err != nil || released != 7 {
+		t.Errorf("ReleaseLeases() = %d, %v, want 7, nil", released, err)
+	}
+
+	// The keys of a replica whose lease expired, as after a crash, are reclaimed
+	_, err = db.Collection(CollectionName).UpdateMany(ctx, bson.M{"leased_by": secondOwner},
+		bson.M{"$set": bson.M{"lease_expires_at": time.Now().UTC().Add(-time.Minute)}})
+	if err != nil {
+		t.Fatalf("UpdateMany() error = %v", err)
+	}
+	if reclaimed, err := first.ReclaimExpiredLeases(ctx); err != nil || reclaimed != 7 {
+		t.Errorf("ReclaimExpiredLeases() = %d, %v, want 7, nil", reclaimed, err)
+	}
+
+	// The last keys are leased to the other replicas and still handed out
+	third, err := NewKGS(db)
+	if err != nil {
+		t.Fatalf("NewKGS() error = %v", err)
+	}
+	third.SetLeasing(100, time.Hour)
+	if _, err := first.GetNextKey(ctx); err != nil {
+		t.Fatalf("GetNextKey() error = %v", err)
+	}
+	for i := 0; i < 18; i++ {
+		if _, err := third.GetNextKey(ctx); err != nil {
+			t.Fatalf("GetNextKey() of a key leased to another replica error = %v", err)
+		}
+	}
+	if _, err := third.GetNextKey(ctx); err != ErrNoKeysAvailable {
+		t.Errorf("GetNextKey() of an exhausted pool error = %v, want %v", err, ErrNoKeysAvailable)
+	}
+}
+
 func TestKGS_GetNextKey_Unique(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -316,4 +428,164 @@ func TestKGS_ReplenishWorker_AutoReplenish(t *testing.T) {
 	if unused < cfg.MinKeysThreshold {
 		t.Errorf("Worker should have replenished keys, got %d unused", unused)
 	}
-}
\ No newline at end of file
+}
+func TestKGS_Buffer(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	kgs, err := NewKGS(db)
+	if err != nil {
+		t.Fatalf("NewKGS() error = %v", err)
+	}
+	kgs.SetBuffer(8)
+	if _, err := kgs.GenerateKeys(ctx, 20); err != nil {
+		t.Fatalf("GenerateKeys() error = %v", err)
+	}
+
+	// The first key claims a whole batch
+	first, err := kgs.GetNextKey(ctx)
+	if err != nil {
+		t.Fatalf("GetNextKey() error = %v", err)
+	}
+	used, err := db.Collection(CollectionName).CountDocuments(ctx, bson.M{"used": true})
+	if err != nil {
+		t.Fatalf("CountDocuments() error = %v", err)
+	}
+	if used != 8 {
+		t.Errorf("used keys after the first GetNextKey() = %d, want 8", used)
+	}
+
+	keys := map[string]bool{first: true}
+	for i := 0; i < 2; i++ {
+		key, err := kgs.GetNextKey(ctx)
+		if err != nil {
+			t.Fatalf("GetNextKey() error = %v", err)
+		}
+		if keys[key] {
+			t.Errorf("GetNextKey() returned duplicate key: %s", key)
+		}
+		keys[key] = true
+	}
+
+	// Shutting down returns the buffered keys to the pool
+	released, err := kgs.ReleaseBuffer(ctx)
+	if err != nil || released != 5 {
+		t.Errorf("ReleaseBuffer() = %d, %v, want 5, nil", released, err)
+	}
+	unused, err := kgs.CountUnusedKeys(ctx)
+	if err != nil || unused != 17 {
+		t.Errorf("CountUnusedKeys() after ReleaseBuffer() = %d, %v, want 17, nil", unused, err)
+	}
+
+	// A released buffer is not refilled; keys are then claimed one at a time
+	if _, err := kgs.GetNextKey(ctx); err != nil {
+		t.Fatalf("GetNextKey() after ReleaseBuffer() error = %v", err)
+	}
+	if unused, _ := kgs.CountUnusedKeys(ctx); unused != 16 {
+		t.Errorf("CountUnusedKeys() after ReleaseBuffer() and GetNextKey() = %d, want 16", unused)
+	}
+}
+
+func TestGenerateKeyFrom(t *testing.T) {
+	for _, alphabet := range []keyAlphabet{alphabetBase36, alphabetBase62Unambiguous, alphabetBase36Unambiguous} {
+		for i := 0; i < 100; i++ {
+			key, err := generateKeyFrom(alphabet.chars)
+			if err != nil {
+				t.Fatalf("generateKeyFrom() error = %v", err)
+			}
+			if len(key) != KeyLength || strings.Trim(key, alphabet.chars) != "" {
+				t.Errorf("generateKeyFrom() = %q, want %d characters of %q", key, KeyLength, alphabet.chars)
+			}
+			if alphabet != alphabetBase36 && strings.ContainsAny(key, "0Oo1lI") {
+				t.Errorf("generateKeyFrom(%s) = %q, want no look-alike characters", alphabet.name, key)
+			}
+		}
+	}
+}
+
+func TestKGS_SetKeyAlphabet(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	kgs, err := NewKGS(db)
+	if err != nil {
+		t.Fatalf("NewKGS() error = %v", err)
+	}
+	if err := kgs.SetKeyAlphabet(ctx, "upper", false); !errors.Is(err, ErrInvalidKeyCasing) {
+		t.Errorf("SetKeyAlphabet(upper) error = %v, want %v", err, ErrInvalidKeyCasing)
+	}
+
+	// Keys generated before alphabets were recorded are base62
+	_, err = db.Collection(CollectionName).InsertMany(ctx, []interface{}{
+		Key{Key: "AbCdEf", CreatedAt: time.Now()},
+		Key{Key: "abc123", CreatedAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("InsertMany() error = %v", err)
+	}
+
+	// Lowercase keys never differ from an older one by case only
+	if err := kgs.SetKeyAlphabet(ctx, KeyCasingLower, true); err != nil {
+		t.Fatalf("SetKeyAlphabet(lower) error = %v", err)
+	}
+	if taken, err := kgs.keyExistsIgnoreCase(ctx, "ABC123"); err != nil || !taken {
+		t.Errorf("keyExistsIgnoreCase(ABC123) = %v, %v, want true, nil", taken, err)
+	}
+
+	// Only keys of the configured alphabet are handed out
+	if unused, err := kgs.CountUnusedKeys(ctx); err != nil || unused != 0 {
+		t.Errorf("CountUnusedKeys() of another alphabet = %d, %v, want 0, nil", unused, err)
+	}
+	if _, err := kgs.GenerateKeys(ctx, 20); err != nil {
+		t.Fatalf("GenerateKeys() error = %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		key, err := kgs.GetNextKey(ctx)
+		if err != nil {
+			t.Fatalf("GetNextKey() error = %v", err)
+		}
+		if strings.Trim(key, alphabetBase36Unambiguous.chars) != "" {
+			t.Errorf("GetNextKey() = %q, want characters of %q", key, alphabetBase36Unambiguous.chars)
+		}
+	}
+	if _, err := kgs.GetNextKey(ctx); err != ErrNoKeysAvailable {
+		t.Errorf("GetNextKey() with only keys of another alphabet error = %v, want %v", err, ErrNoKeysAvailable)
+	}
+
+	// The base62 keys are still there for base62
+	if err := kgs.SetKeyAlphabet(ctx, KeyCasingMixed, false); err != nil {
+		t.Fatalf("SetKeyAlphabet(mixed) error = %v", err)
+	}
+	if unused, err := kgs.CountUnusedKeys(ctx); err != nil || unused != 2 {
+		t.Errorf("CountUnusedKeys() of base62 = %d, %v, want 2, nil", unused, err)
+	}
+}
+
+func TestKGS_CriticalFloor(t *testing.T) {
+	kgs := &KGS{}
+	kgs.recordUnused(0)
+	if exhausted, _ := kgs.Exhausted(); exhausted {
+		t.Error("Exhausted() = true without a critical floor, want false")
+	}
+
+	kgs.SetCriticalFloor(100, 0)
+	kgs.recordUnused(99)
+	exhausted, retryAfter := kgs.Exhausted()
+	if !exhausted || retryAfter != DefaultKeyFloorRetryAfter {
+		t.Errorf("Exhausted() below the floor = %v, %v, want true, %v", exhausted, retryAfter, DefaultKeyFloorRetryAfter)
+	}
+
+	// Creates are rejected without taking a key
+	s := NewPasteService(kgs, nil, nil, nil, "http://localhost")
+	_, err := s.CreatePaste(context.Background(), &CreatePasteRequest{Content: "hello", SyntaxType: "text"})
+	if !errors.Is(err, ErrKeysExhausted) || !errors.Is(err, ErrNoKeysAvailable) {
+		t.Errorf("CreatePaste() while exhausted error = %v, want %v", err, ErrKeysExhausted)
+	}
+
+	kgs.recordUnused(100)
+	if exhausted, _ := kgs.Exhausted(); exhausted {
+		t.Error("Exhausted() back at the floor = true, want false")
+	}
+}
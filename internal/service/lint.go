@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// MaxAnnotations is the most annotations kept for a paste
+	MaxAnnotations = 100
+
+	// SeverityError marks content that does not parse
+	SeverityError = "error"
+	// SeverityWarning marks a style issue
+	SeverityWarning = "warning"
+
+	// LintStatusDone means the annotations are those of the current content
+	LintStatusDone = "done"
+	// LintStatusPending means the current content has not been linted yet
+	LintStatusPending = "pending"
+	// LintStatusUnsupported means no linter applies to the paste
+	LintStatusUnsupported = "unsupported"
+)
+
+// ErrUnknownLinter is returned when a configured linter does not exist
+var ErrUnknownLinter = errors.New("paste: unknown linter")
+
+// Linter checks pastes of some syntax types and reports findings as annotations.
+// Lint must be safe for concurrent use and must not quote the content in messages.
+type Linter interface {
+	// Name identifies the linter in annotations and configuration
+	Name() string
+	// Supports reports whether the linter checks pastes of the syntax type
+	Supports(syntaxType string) bool
+	Lint(content string) []model.Annotation
+}
+
+// builtinLinters are the linters that can be enabled by name
+var builtinLinters = map[string]Linter{
+	"gofmt":    gofmtLinter{},
+	"jsonlint": jsonLinter{},
+	"yamllint": yamlLinter{},
+}
+
+// LinterNames lists the built-in linters
+func LinterNames() []string {
+	names := make([]string, 0, len(builtinLinters))
+	for name := range builtinLinters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NewLinters returns the built-in linters of the given names
+func NewLinters(names []string) ([]Linter, error) {
+	var linters []Linter
+	for _, name := range names {
+		linter, ok := builtinLinters[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q (supported: %s)", ErrUnknownLinter, name, strings.Join(LinterNames(), ", "))
+		}
+		linters = append(linters, linter)
+	}
+	return linters, nil
+}
+
+// AnnotationsResponse represents the linter annotations of a paste
+type AnnotationsResponse struct {
+	ShortID     string             `json:"short_id"`
+	Status      string             `json:"status"` // LintStatusDone, LintStatusPending or LintStatusUnsupported
+	Annotations []model.Annotation `json:"annotations"`
+	LintedAt    *string            `json:"linted_at,omitempty"`
+}
+
+// SetLinters lints pastes in the background after each create and edit. It subscribes to the
+// event bus, so SetEventBus must be called first; without a bus pastes are not linted.
+func (s *PasteService) SetLinters(linters ...Linter) {
+	if s.events == nil {
+		log.Printf("[PasteService.SetLinters] No event bus, linting disabled")
+		return
+	}
+	s.linters = linters
+	s.events.Subscribe("lint", s.lintPaste)
+}
+
+// lintersFor returns the enabled linters supporting a paste, none for content the server cannot read
+func (s *PasteService) lintersFor(paste *model.Paste) []Linter {
+	if paste.Encrypted || paste.Binary {
+		return nil
+	}
+	var linters []Linter
+	for _, linter := range s.linters {
+		if linter.Supports(paste.SyntaxType) {
+			linters = append(linters, linter)
+		}
+	}
+	return linters
+}
+
+// lintPaste is the bus subscriber linting created and edited pastes
+func (s *PasteService) lintPaste(ctx context.Context, e *model.PasteEvent) error {
+	if e.Type != model.PasteEventCreated && e.Type != model.PasteEventUpdated {
+		return nil
+	}
+	paste := e.Paste
+	if paste == nil || paste.BurnAfterRead {
+		// Reading a burn-after-read paste, even to lint it, is not ours to do
+		return nil
+	}
+	linters := s.lintersFor(paste)
+	if len(linters) == 0 {
+		return nil
+	}
+
+	content, err := s.storage.GetContent(ctx, e.ShortID)
+	if err != nil {
+		if errors.Is(err, ErrContentNotFound) {
+			return nil
+		}
+		return fmt.Errorf("paste: failed to get content to lint: %w", err)
+	}
+
+	result := &model.LintResult{Revision: paste.Revision, LintedAt: time.Now()}
+	for _, linter := range linters {
+		result.Annotations = append(result.Annotations, linter.Lint(content)...)
+	}
+	slices.SortStableFunc(result.Annotations, func(a, b model.Annotation) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+	if len(result.Annotations) > MaxAnnotations {
+		result.Annotations = result.Annotations[:MaxAnnotations]
+	}
+
+	if err := s.pasteRepo.SetLint(ctx, e.ShortID, result); err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			// Deleted or edited since; the edit is linted on its own
+			return nil
+		}
+		return fmt.Errorf("paste: failed to store lint result: %w", err)
+	}
+	return nil
+}
+
+// currentAnnotations returns the annotations of the paste's current content, nil when not linted yet
+func currentAnnotations(paste *model.Paste) []model.Annotation {
+	if paste.Lint == nil || paste.Lint.Revision != paste.Revision {
+		return nil
+	}
+	return paste.Lint.Annotations
+}
+
+// GetAnnotations returns the linter annotations of a paste
+func (s *PasteService) GetAnnotations(ctx context.Context, shortID string) (*AnnotationsResponse, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+
+	response := &AnnotationsResponse{
+		ShortID:     shortID,
+		Status:      LintStatusUnsupported,
+		Annotations: []model.Annotation{},
+	}
+	if paste.BurnAfterRead || len(s.lintersFor(paste)) == 0 {
+		return response, nil
+	}
+	if paste.Lint == nil || paste.Lint.Revision != paste.Revision {
+		response.Status = LintStatusPending
+		return response, nil
+	}
+
+	response.Status = LintStatusDone
+	if len(paste.Lint.Annotations) > 0 {
+		response.Annotations = paste.Lint.Annotations
+	}
+	lintedAt := paste.Lint.LintedAt.Format(time.RFC3339)
+	response.LintedAt = &lintedAt
+	return response, nil
+}
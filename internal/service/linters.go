@@ -0,0 +1,253 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"go/format"
+	"go/scanner"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/huylvt/gisty/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// gofmtLinter reports the first Go syntax error, later ones often being consequences of it,
+// or code that gofmt would reformat. Snippets without a package clause are accepted, as by gofmt itself.
+type gofmtLinter struct{}
+
+func (gofmtLinter) Name() string { return "gofmt" }
+
+func (gofmtLinter) Supports(syntaxType string) bool {
+	return syntaxType == "go" || syntaxType == "golang"
+}
+
+func (l gofmtLinter) Lint(content string) []model.Annotation {
+	formatted, err := format.Source([]byte(content))
+	if err != nil {
+		annotation := model.Annotation{Linter: l.Name(), Severity: SeverityError, Line: 1, Message: err.Error()}
+		var list scanner.ErrorList
+		if errors.As(err, &list) && len(list) > 0 {
+			annotation.Line = max(list[0].Pos.Line, 1)
+			annotation.Column = list[0].Pos.Column
+			annotation.Message = list[0].Msg
+		}
+		return []model.Annotation{annotation}
+	}
+	if string(formatted) == content {
+		return nil
+	}
+	return []model.Annotation{{
+		Linter:   l.Name(),
+		Severity: SeverityWarning,
+		Line:     firstDifferentLine(content, string(formatted)),
+		Message:  "code is not gofmt-formatted",
+	}}
+}
+
+// firstDifferentLine returns the 1-based number of the first line where a and b differ
+func firstDifferentLine(a, b string) int {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	for i := range min(len(linesA), len(linesB)) {
+		if linesA[i] != linesB[i] {
+			return i + 1
+		}
+	}
+	return min(len(linesA), len(linesB))
+}
+
+// jsonLinter reports JSON syntax errors and duplicate object keys
+type jsonLinter struct{}
+
+func (jsonLinter) Name() string { return "jsonlint" }
+
+func (jsonLinter) Supports(syntaxType string) bool { return syntaxType == "json" }
+
+// jsonFrame tracks an open JSON object or array while scanning tokens
+type jsonFrame struct {
+	object  bool
+	wantKey bool
+	keys    map[string]bool
+}
+
+func (l jsonLinter) Lint(content string) []model.Annotation {
+	var annotations []model.Annotation
+	dec := json.NewDecoder(strings.NewReader(content))
+	dec.UseNumber()
+
+	var stack []*jsonFrame
+	values := 0
+	for {
+		offset := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				// The offset is just past the offending byte
+				offset = int(syntaxErr.Offset) - 1
+			}
+			line, column := offsetPosition(content, offset)
+			annotations = append(annotations, model.Annotation{
+				Linter: l.Name(), Severity: SeverityError, Line: line, Column: column,
+				Message: strings.TrimPrefix(err.Error(), "json: "),
+			})
+			break
+		}
+
+		if len(stack) == 0 {
+			values++
+			if values == 2 {
+				line, column := offsetPosition(content, skipJSONSeparators(content, offset))
+				annotations = append(annotations, model.Annotation{
+					Linter: l.Name(), Severity: SeverityError, Line: line, Column: column,
+					Message: "unexpected content after the top-level value",
+				})
+				break
+			}
+		}
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if top != nil && top.object && top.wantKey {
+			if delim, ok := tok.(json.Delim); !ok || delim != '}' {
+				key, _ := tok.(string)
+				if top.keys[key] {
+					line, column := offsetPosition(content, skipJSONSeparators(content, offset))
+					annotations = append(annotations, model.Annotation{
+						Linter: l.Name(), Severity: SeverityWarning, Line: line, Column: column,
+						Message: "duplicate key " + strconv.Quote(key),
+					})
+				}
+				top.keys[key] = true
+				top.wantKey = false
+				continue
+			}
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			stack = append(stack, &jsonFrame{object: true, wantKey: true, keys: map[string]bool{}})
+		case json.Delim('['):
+			stack = append(stack, &jsonFrame{})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.wantKey = parent.object
+			}
+		default:
+			if top != nil && top.object {
+				top.wantKey = true
+			}
+		}
+	}
+	return annotations
+}
+
+// skipJSONSeparators returns the offset of the first byte at or after offset that is not
+// whitespace or a separator the decoder consumes with the next token
+func skipJSONSeparators(content string, offset int) int {
+	for offset < len(content) && strings.IndexByte(" \t\r\n,:", content[offset]) >= 0 {
+		offset++
+	}
+	return offset
+}
+
+// offsetPosition converts a byte offset in content to a 1-based line and column
+func offsetPosition(content string, offset int) (int, int) {
+	offset = min(max(offset, 0), len(content))
+	before := content[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:]) + 1
+	return line, column
+}
+
+// yamlLinter reports YAML syntax errors and, like yamllint, duplicate keys, trailing spaces
+// and truthy values other than true and false, which YAML 1.1 parsers read as booleans
+type yamlLinter struct{}
+
+func (yamlLinter) Name() string { return "yamllint" }
+
+func (yamlLinter) Supports(syntaxType string) bool { return syntaxType == "yaml" }
+
+// yamlErrorLine extracts the line of a yaml.v3 syntax error
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// yamlTruthy are the plain scalars yamllint's truthy rule rejects, compared lowercase
+var yamlTruthy = map[string]bool{"yes": true, "no": true, "on": true, "off": true, "y": true, "n": true, "true": true, "false": true}
+
+func (l yamlLinter) Lint(content string) []model.Annotation {
+	var annotations []model.Annotation
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if trimmed := strings.TrimRight(line, " \t"); trimmed != line {
+			annotations = append(annotations, model.Annotation{
+				Linter: l.Name(), Severity: SeverityWarning, Line: i + 1,
+				Column:  utf8.RuneCountInString(trimmed) + 1,
+				Message: "trailing spaces",
+			})
+		}
+	}
+
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			annotation := model.Annotation{Linter: l.Name(), Severity: SeverityError, Line: 1, Message: err.Error()}
+			if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+				annotation.Line, _ = strconv.Atoi(m[1])
+				annotation.Message = m[2]
+			}
+			annotations = append(annotations, annotation)
+			break
+		}
+		annotations = l.walk(&doc, annotations)
+	}
+	return annotations
+}
+
+// walk checks the keys and values of a YAML node tree
+func (l yamlLinter) walk(node *yaml.Node, annotations []model.Annotation) []model.Annotation {
+	switch node.Kind {
+	case yaml.MappingNode:
+		keys := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Kind == yaml.ScalarNode {
+				if keys[key.Value] {
+					annotations = append(annotations, model.Annotation{
+						Linter: l.Name(), Severity: SeverityWarning, Line: key.Line, Column: key.Column,
+						Message: "duplicate key " + strconv.Quote(key.Value),
+					})
+				}
+				keys[key.Value] = true
+			}
+			annotations = l.walk(value, annotations)
+		}
+	case yaml.ScalarNode:
+		lower := strings.ToLower(node.Value)
+		if node.Style == 0 && yamlTruthy[lower] && node.Value != "true" && node.Value != "false" {
+			annotations = append(annotations, model.Annotation{
+				Linter: l.Name(), Severity: SeverityWarning, Line: node.Line, Column: node.Column,
+				Message: "truthy value should be one of [false, true]",
+			})
+		}
+	default:
+		for _, child := range node.Content {
+			annotations = l.walk(child, annotations)
+		}
+	}
+	return annotations
+}
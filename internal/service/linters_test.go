@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+// lintFinding is the part of an annotation the linter tests check
+type lintFinding struct {
+	severity string
+	line     int
+	message  string
+}
+
+func checkAnnotations(t *testing.T, got []model.Annotation, want []lintFinding) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d annotations %+v, want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		a := got[i]
+		if a.Severity != w.severity || a.Line != w.line || !strings.Contains(a.Message, w.message) {
+			t.Errorf("annotation %d = %+v, want %s on line %d mentioning %q", i, a, w.severity, w.line, w.message)
+		}
+	}
+}
+
+func TestGofmtLinter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []lintFinding
+	}{
+		{"formatted file", "package main\n\nfunc main() {\n\tprintln(1)\n}\n", nil},
+		{"formatted snippet", "x := 1\nprintln(x)\n", nil},
+		{"unformatted", "package main\n\nfunc main() {\nprintln(1)\n}\n", []lintFinding{{SeverityWarning, 4, "gofmt"}}},
+		{"syntax error", "package main\n\nfunc main() {\n\tprintln(1\n}\n", []lintFinding{{SeverityError, 4, "missing ','"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkAnnotations(t, gofmtLinter{}.Lint(tt.content), tt.want)
+		})
+	}
+}
+
+func TestJSONLinter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []lintFinding
+	}{
+		{"valid", `{"a": [1, {"b": 2}], "c": {"b": 3}}`, nil},
+		{"duplicate key", "{\n  \"a\": 1,\n  \"b\": {\"x\": 1},\n  \"a\": 2\n}", []lintFinding{{SeverityWarning, 4, `"a"`}}},
+		{"nested duplicate key", "[{\"x\": 1,\n\"x\": 2}]", []lintFinding{{SeverityWarning, 2, `"x"`}}},
+		{"syntax error", "{\n  \"a\": 1,\n  \"b\": tru\n}", []lintFinding{{SeverityError, 3, "invalid character"}}},
+		{"trailing content", "{}\n{}", []lintFinding{{SeverityError, 2, "after the top-level value"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkAnnotations(t, jsonLinter{}.Lint(tt.content), tt.want)
+		})
+	}
+}
+
+func TestYAMLLinter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []lintFinding
+	}{
+		{"valid", "name: api\nenabled: true\nports:\n  - 80\n  - 443\n", nil},
+		{"trailing spaces", "name: api  \nport: 80\n", []lintFinding{{SeverityWarning, 1, "trailing spaces"}}},
+		{"truthy", "enabled: yes\nquoted: \"no\"\n", []lintFinding{{SeverityWarning, 1, "truthy"}}},
+		{"duplicate key", "a: 1\nb: 2\na: 3\n", []lintFinding{{SeverityWarning, 3, `"a"`}}},
+		{"syntax error", "a: 1\nb: c: d\n", []lintFinding{{SeverityError, 2, "mapping values are not allowed"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkAnnotations(t, yamlLinter{}.Lint(tt.content), tt.want)
+		})
+	}
+}
+
+func TestNewLinters(t *testing.T) {
+	linters, err := NewLinters([]string{"gofmt", " yamllint"})
+	if err != nil || len(linters) != 2 || linters[1].Name() != "yamllint" {
+		t.Errorf("NewLinters() = %v, %v", linters, err)
+	}
+	if _, err := NewLinters([]string{"eslint"}); !errors.Is(err, ErrUnknownLinter) {
+		t.Errorf("NewLinters(unknown) error = %v, want ErrUnknownLinter", err)
+	}
+}
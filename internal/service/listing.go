@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// DefaultListLimit is the number of pastes returned by ListPastes when no limit is given
+	DefaultListLimit = 100
+	// MaxListLimit caps the number of pastes returned by ListPastes
+	MaxListLimit = 10000
+)
+
+var (
+	// ErrInvalidTimeRange is returned when a listing's start is not before its end
+	ErrInvalidTimeRange = errors.New("paste: invalid time range")
+	// ErrInvalidSourceIP is returned when the source IP filter is not an IP address
+	ErrInvalidSourceIP = errors.New("paste: invalid source IP")
+	// ErrSourceIPNotRecorded is returned when filtering by source IP while no hash key is configured
+	ErrSourceIPNotRecorded = errors.New("paste: source IPs are not recorded")
+	// ErrSignInRequired is returned when listing the caller's account pastes without a signed-in user
+	ErrSignInRequired = errors.New("paste: sign-in required")
+)
+
+// ListPastesQuery selects pastes by creation time and source for incident review
+type ListPastesQuery struct {
+	From time.Time
+	To   time.Time // zero means now
+	// SourceIP filters by creator IP; it is hashed with the configured key before the lookup.
+	// SourceIPHash filters by an already hashed IP, as found in earlier listings.
+	SourceIP     string
+	SourceIPHash string
+	Tag          string // only pastes carrying this tag
+	Limit        int
+}
+
+// PasteSummary is the metadata of a listed paste
+type PasteSummary struct {
+	ShortID       string   `json:"short_id" example:"xK9a2B"`
+	CreatedAt     string   `json:"created_at" example:"2024-01-15T14:00:00Z"`
+	ExpiresAt     *string  `json:"expires_at,omitempty" example:"2024-01-16T14:00:00Z"`
+	SyntaxType    string   `json:"syntax_type" example:"go"`
+	IsPrivate     bool     `json:"is_private" example:"false"`
+	BurnAfterRead bool     `json:"burn_after_read" example:"false"`
+	Encrypted     bool     `json:"is_encrypted" example:"false"`
+	SourceIPHash  string   `json:"source_ip_hash,omitempty" example:"5e884898da28047151d0e56f8dc62927"`
+	Title         string   `json:"title,omitempty" example:"Deploy script"`
+	Tags          []string `json:"tags,omitempty" example:"go,ops"`
+}
+
+// ListPastesResponse represents the pastes created in a time range
+type ListPastesResponse struct {
+	From   string         `json:"from" example:"2024-01-15T00:00:00Z"`
+	To     string         `json:"to" example:"2024-01-16T00:00:00Z"`
+	Pastes []PasteSummary `json:"pastes"`
+	// Truncated is set when more pastes match than the limit allowed
+	Truncated bool `json:"truncated" example:"false"`
+}
+
+// SetSourceIPHashKey enables recording a keyed hash of the creator's IP on new pastes.
+// The key must stay the same for hashes to remain comparable across restarts.
+func (s *PasteService) SetSourceIPHashKey(key string) {
+	s.ipHashKey = []byte(key)
+}
+
+// HashSourceIP returns the keyed hash stored for pastes created from ip, or "" when
+// source IPs are not recorded or ip is not an IP address
+func (s *PasteService) HashSourceIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if len(s.ipHashKey) == 0 || parsed == nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.ipHashKey)
+	mac.Write([]byte(parsed.String()))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// ListPastes returns the pastes created in a time range, oldest first
+func (s *PasteService) ListPastes(ctx context.Context, q *ListPastesQuery) (*ListPastesResponse, error) {
+	to := q.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if q.From.IsZero() || !q.From.Before(to) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	sourceIPHash := q.SourceIPHash
+	if q.SourceIP != "" {
+		if len(s.ipHashKey) == 0 {
+			return nil, ErrSourceIPNotRecorded
+		}
+		if sourceIPHash = s.HashSourceIP(q.SourceIP); sourceIPHash == "" {
+			return nil, ErrInvalidSourceIP
+		}
+	}
+
+	// Fetch one more than the limit to tell whether the listing is complete
+	tag := strings.ToLower(strings.TrimSpace(q.Tag))
+	pastes, err := s.pasteRepo.ListCreatedBetween(ctx, q.From, to, sourceIPHash, tag, int64(limit)+1)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list pastes: %w", err)
+	}
+
+	response := &ListPastesResponse{
+		From:   q.From.UTC().Format(time.RFC3339),
+		To:     to.UTC().Format(time.RFC3339),
+		Pastes: make([]PasteSummary, 0, min(len(pastes), limit)),
+	}
+	if len(pastes) > limit {
+		pastes = pastes[:limit]
+		response.Truncated = true
+	}
+	for _, paste := range pastes {
+		response.Pastes = append(response.Pastes, toPasteSummary(paste))
+	}
+
+	return response, nil
+}
+
+// UserPastesResponse represents a page of the pastes of a signed-in user, newest first
+type UserPastesResponse struct {
+	// Pinned lists the pastes pinned to the top of a public profile, on its first page only; they
+	// are not repeated in pastes
+	Pinned []PasteSummary `json:"pinned,omitempty"`
+	Pastes []PasteSummary `json:"pastes"`
+	// Next is the before cursor of the next page; empty on the last page
+	Next string `json:"next,omitempty" example:"2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"`
+}
+
+// ListUserPastes returns up to limit pastes created by a signed-in user, newest first, starting
+// after the cursor when it is not zero
+func (s *PasteService) ListUserPastes(ctx context.Context, userID string, after repository.PageCursor, limit int) (*UserPastesResponse, error) {
+	if userID == "" {
+		return nil, ErrSignInRequired
+	}
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	pastes, err := s.pasteRepo.ListByUser(ctx, userID, after, int64(limit)+1)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list pastes: %w", err)
+	}
+
+	response := &UserPastesResponse{Pastes: make([]PasteSummary, 0, min(len(pastes), limit))}
+	if len(pastes) > limit {
+		pastes = pastes[:limit]
+		response.Next = pasteCursor(pastes[limit-1]).String()
+	}
+	for _, paste := range pastes {
+		summary := toPasteSummary(paste)
+		// The hash only matters for incident review
+		summary.SourceIPHash = ""
+		response.Pastes = append(response.Pastes, summary)
+	}
+	return response, nil
+}
+
+// pasteCursor returns the cursor of the page of a listing ending at a paste
+func pasteCursor(paste *model.Paste) repository.PageCursor {
+	return repository.PageCursor{CreatedAt: paste.CreatedAt, ID: paste.ID.Hex()}
+}
+
+// toPasteSummary returns the listed metadata of a paste
+func toPasteSummary(paste *model.Paste) PasteSummary {
+	summary := PasteSummary{
+		ShortID:       paste.ShortID,
+		CreatedAt:     paste.CreatedAt.UTC().Format(time.RFC3339),
+		SyntaxType:    paste.SyntaxType,
+		IsPrivate:     paste.IsPrivate,
+		BurnAfterRead: paste.BurnAfterRead,
+		Encrypted:     paste.Encrypted,
+		SourceIPHash:  paste.SourceIPHash,
+		Title:         paste.Title,
+		Tags:          paste.Tags,
+	}
+	if paste.ExpiresAt != nil {
+		formatted := paste.ExpiresAt.UTC().Format(time.RFC3339)
+		summary.ExpiresAt = &formatted
+	}
+	return summary
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPasteService_HashSourceIP(t *testing.T) {
+	svc := &PasteService{}
+	if got := svc.HashSourceIP("203.0.113.7"); got != "" {
+		t.Errorf("HashSourceIP() without key = %q, want empty", got)
+	}
+
+	svc.SetSourceIPHashKey("secret")
+	hash := svc.HashSourceIP("203.0.113.7")
+	if len(hash) != 32 {
+		t.Fatalf("HashSourceIP() = %q, want 32 hex characters", hash)
+	}
+	if got := svc.HashSourceIP("203.0.113.7"); got != hash {
+		t.Errorf("HashSourceIP() is not stable: %q != %q", got, hash)
+	}
+	if got := svc.HashSourceIP("::ffff:203.0.113.7"); got != hash {
+		t.Errorf("IPv4-mapped address hashed differently: %q != %q", got, hash)
+	}
+	if got := svc.HashSourceIP("203.0.113.8"); got == hash {
+		t.Error("different IPs share a hash")
+	}
+	if got := svc.HashSourceIP("not-an-ip"); got != "" {
+		t.Errorf("HashSourceIP(invalid) = %q, want empty", got)
+	}
+
+	other := &PasteService{}
+	other.SetSourceIPHashKey("other")
+	if other.HashSourceIP("203.0.113.7") == hash {
+		t.Error("hash does not depend on the key")
+	}
+}
+
+func TestPasteService_ListPastes_Validation(t *testing.T) {
+	svc := &PasteService{}
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		query ListPastesQuery
+		want  error
+	}{
+		{"missing from", ListPastesQuery{To: now}, ErrInvalidTimeRange},
+		{"from after to", ListPastesQuery{From: now, To: now.Add(-time.Hour)}, ErrInvalidTimeRange},
+		{"from in the future", ListPastesQuery{From: now.Add(time.Hour)}, ErrInvalidTimeRange},
+		{"ip without hash key", ListPastesQuery{From: now.Add(-time.Hour), SourceIP: "203.0.113.7"}, ErrSourceIPNotRecorded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.ListPastes(ctx, &tt.query); !errors.Is(err, tt.want) {
+				t.Errorf("ListPastes() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	svc.SetSourceIPHashKey("secret")
+	if _, err := svc.ListPastes(ctx, &ListPastesQuery{From: now.Add(-time.Hour), SourceIP: "nope"}); !errors.Is(err, ErrInvalidSourceIP) {
+		t.Errorf("ListPastes() invalid IP error = %v, want ErrInvalidSourceIP", err)
+	}
+}
+
+func TestPasteService_LookupContentHash_Validation(t *testing.T) {
+	svc := &PasteService{}
+	ctx := context.Background()
+	hash := ContentHash("hello")
+
+	tests := []struct {
+		name  string
+		query LookupQuery
+		want  error
+	}{
+		{"missing hash", LookupQuery{OwnerID: "key:abcd"}, ErrInvalidChecksum},
+		{"short hash", LookupQuery{SHA256: hash[:10], OwnerID: "key:abcd"}, ErrInvalidChecksum},
+		{"anonymous caller", LookupQuery{SHA256: hash}, ErrOwnerRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.LookupContentHash(ctx, &tt.query); !errors.Is(err, tt.want) {
+				t.Errorf("LookupContentHash() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
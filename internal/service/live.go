@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/codec"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrNotLive is returned when appending to or following a paste that was not created live
+	ErrNotLive = errors.New("paste: not a live paste")
+	// ErrInvalidLive is returned when a live paste is requested with burn-after-read, a view limit
+	// or encrypted content, which it cannot be appended to and followed with
+	ErrInvalidLive = errors.New("paste: invalid live paste")
+	// ErrLivePaste is returned when editing a live paste, which only grows by appends
+	ErrLivePaste = errors.New("paste: live pastes are only appended to")
+)
+
+const (
+	// appendLockTTL bounds how long a failed append holds the lock of a live paste
+	appendLockTTL = 30 * time.Second
+	// appendLockWait is how long an append waits for the appends in progress before giving up
+	appendLockWait = 2 * time.Second
+	// appendLockRetry is the delay between attempts to take the lock
+	appendLockRetry = 50 * time.Millisecond
+)
+
+// AppendPasteRequest represents content appended to a live paste
+type AppendPasteRequest struct {
+	Content  string `json:"content" binding:"required"`
+	Encoding string `json:"encoding"` // transfer encoding of content: "base64", "hex" or "" for none
+
+	// Caller proves the right to append as for deleting the paste, set by the handler
+	Caller *DeletePasteRequest `json:"-"`
+}
+
+// AppendPasteResponse represents the response after appending to a live paste
+type AppendPasteResponse struct {
+	ShortID   string `json:"short_id"`
+	Size      int    `json:"size"` // content size in bytes after the append
+	UpdatedAt string `json:"updated_at"`
+}
+
+// LiveChunk is what a follower of a live paste reads: the content from byte Offset on, up to Size.
+// Appends may end within a UTF-8 sequence, so followers wanting exact bytes request an encoding.
+type LiveChunk struct {
+	ShortID  string `json:"short_id"`
+	Offset   int    `json:"offset"`
+	Content  string `json:"content"`
+	Size     int    `json:"size"`               // content size in bytes, the offset of the next chunk
+	Encoding string `json:"encoding,omitempty"` // transfer encoding of content, when one was requested
+	// Ended is set on the last chunk, once the paste was deleted or expired
+	Ended bool `json:"ended,omitempty"`
+
+	// UpdatedAt is the time of the last append read, passed back to ReadLive to skip unchanged pastes
+	UpdatedAt time.Time `json:"-"`
+}
+
+// validateLive rejects live pastes that could not be appended to or followed
+func validateLive(burnAfterRead bool, maxViews int, encrypted bool) error {
+	if burnAfterRead || maxViews > 0 || encrypted {
+		return ErrInvalidLive
+	}
+	return nil
+}
+
+// AppendPaste appends content to a live paste. Appends are serialized by a lock on the paste
+// record, so concurrent appends all land, in some order.
+func (s *PasteService) AppendPaste(ctx context.Context, shortID string, req *AppendPasteRequest) (*AppendPasteResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.AppendPaste", trace.WithAttributes(attribute.String("short_id", shortID)))
+	defer span.End()
+
+	if req.Encoding != codec.None {
+		content, err := codec.Decode(req.Encoding, req.Content)
+		if err != nil {
+			return nil, ErrInvalidEncoding
+		}
+		req.Content, req.Encoding = content, codec.None
+	}
+	if len(req.Content) == 0 {
+		return nil, ErrEmptyContent
+	}
+
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if !paste.Live {
+		return nil, ErrNotLive
+	}
+	if !canDelete(paste, req.Caller) {
+		return nil, ErrEditForbidden
+	}
+	if paste.ArchivedAt != nil {
+		return nil, ErrPasteArchived
+	}
+	if paste.IsQuarantined() {
+		return nil, ErrPasteQuarantined
+	}
+	if err := s.checkWritable(); err != nil {
+		log.Printf("[PasteService.AppendPaste] Error: %v", err)
+		return nil, err
+	}
+
+	token, err := s.lockAppend(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.appendContent(ctx, shortID, token, req.Content)
+	if err != nil {
+		// Release the lock for the next append; a lock lost to expiry is not ours to release
+		if !errors.Is(err, ErrEditConflict) {
+			_ = s.pasteRepo.UnlockAppend(ctx, shortID, token, nil)
+		}
+		return nil, err
+	}
+	return response, nil
+}
+
+// lockAppend takes the append lock of a live paste, waiting up to appendLockWait for the appends
+// in progress, and returns its token
+func (s *PasteService) lockAppend(ctx context.Context, shortID string) (string, error) {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	deadline := time.Now().Add(appendLockWait)
+	for {
+		_, err := s.pasteRepo.LockAppend(ctx, shortID, token, time.Now().Add(appendLockTTL))
+		if err == nil {
+			return token, nil
+		}
+		if !errors.Is(err, repository.ErrPasteNotFound) {
+			return "", fmt.Errorf("paste: failed to lock paste: %w", err)
+		}
+		// Locked by another append, or deleted since it was read
+		if time.Now().After(deadline) {
+			return "", ErrEditConflict
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(appendLockRetry):
+		}
+	}
+}
+
+// appendContent appends content to the stored content of a live paste while holding its append lock
+func (s *PasteService) appendContent(ctx context.Context, shortID, token, content string) (*AppendPasteResponse, error) {
+	current, err := s.storage.GetContent(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, ErrContentNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get content: %w", err)
+	}
+	content = current + content
+	if len(content) > MaxContentSize {
+		return nil, ErrContentTooLarge
+	}
+	flags, err := s.contentPolicy.Apply(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.SaveContent(ctx, shortID, content); err != nil {
+		log.Printf("[PasteService.AppendPaste] Error saving to S3: %v", err)
+		return nil, fmt.Errorf("paste: failed to save content: %w", err)
+	}
+
+	now := time.Now()
+	fields := bson.M{
+		"updated_at":        now,
+		"binary":            flags.Binary,
+		"preview_truncated": flags.PreviewTruncated,
+		"content_hash":      ContentHash(content),
+	}
+	if flags.Binary {
+		// Binary content is never highlighted
+		fields["syntax_type"] = DefaultSyntaxType
+	}
+	if err := s.pasteRepo.UnlockAppend(ctx, shortID, token, fields); err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			// The lock expired and another append may have replaced the content
+			log.Printf("[PasteService.AppendPaste] Lost the append lock of %s", shortID)
+			return nil, ErrEditConflict
+		}
+		return nil, fmt.Errorf("paste: failed to update record: %w", err)
+	}
+
+	// Readers must not see the previous content from cache
+	_ = s.cache.Delete(ctx, shortID)
+
+	if paste, err := s.pasteRepo.GetByShortID(ctx, shortID); err == nil {
+		s.publish(model.PasteEventUpdated, shortID, paste)
+	}
+
+	return &AppendPasteResponse{
+		ShortID:   shortID,
+		Size:      len(content),
+		UpdatedAt: now.Format(time.RFC3339),
+	}, nil
+}
+
+// ReadLive reads a live paste from byte offset on, for a follower, without counting a view. since
+// is the UpdatedAt of the previous chunk: while the paste was not appended to since, nothing is
+// read from storage and the chunk is nil. An offset past the content, left by a redaction that
+// shortened it, reads from the end.
+func (s *PasteService) ReadLive(ctx context.Context, shortID string, offset int, since time.Time) (*LiveChunk, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if !paste.Live {
+		return nil, ErrNotLive
+	}
+	if paste.ArchivedAt != nil {
+		return nil, ErrPasteArchived
+	}
+	if paste.IsQuarantined() {
+		return nil, ErrPasteQuarantined
+	}
+
+	updatedAt := paste.CreatedAt
+	if paste.UpdatedAt != nil {
+		updatedAt = *paste.UpdatedAt
+	}
+	if !since.IsZero() && updatedAt.Equal(since) {
+		return nil, nil
+	}
+
+	content, err := s.storage.GetContent(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, ErrContentNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get content: %w", err)
+	}
+
+	offset = min(offset, len(content))
+	return &LiveChunk{
+		ShortID:   shortID,
+		Offset:    offset,
+		Content:   content[offset:],
+		Size:      len(content),
+		UpdatedAt: updatedAt,
+	}, nil
+}
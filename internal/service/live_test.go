@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPasteService_AppendPaste(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "start\n", SyntaxType: "plaintext", Live: true})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	creator := &DeletePasteRequest{DeleteToken: createResp.DeleteToken}
+
+	first, err := svc.ReadLive(ctx, createResp.ShortID, 0, time.Time{})
+	if err != nil {
+		t.Fatalf("ReadLive() error = %v", err)
+	}
+	if first.Content != "start\n" || first.Size != 6 {
+		t.Fatalf("ReadLive() = %+v, want the initial content", first)
+	}
+	if unchanged, err := svc.ReadLive(ctx, createResp.ShortID, first.Size, first.UpdatedAt); err != nil || unchanged != nil {
+		t.Fatalf("ReadLive() of an unchanged paste = %+v (err %v), want nil", unchanged, err)
+	}
+
+	// Concurrent appends all land
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := svc.AppendPaste(ctx, createResp.ShortID, &AppendPasteRequest{Content: fmt.Sprintf("line %d\n", i), Caller: creator}); err != nil {
+				t.Errorf("AppendPaste() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	next, err := svc.ReadLive(ctx, createResp.ShortID, first.Size, first.UpdatedAt)
+	if err != nil || next == nil {
+		t.Fatalf("ReadLive() after appends = %+v (err %v)", next, err)
+	}
+	if next.Offset != first.Size || len(next.Content) != 5*len("line 0\n") || next.Size != first.Size+len(next.Content) {
+		t.Errorf("ReadLive() after appends = %+v, want the 5 appended lines", next)
+	}
+
+	if _, err := svc.AppendPaste(ctx, createResp.ShortID, &AppendPasteRequest{Content: "x"}); err != ErrEditForbidden {
+		t.Errorf("AppendPaste() without the delete token error = %v, want %v", err, ErrEditForbidden)
+	}
+	if _, err := svc.UpdatePaste(ctx, createResp.ShortID, &UpdatePasteRequest{Content: "x", Caller: creator}); err != ErrLivePaste {
+		t.Errorf("UpdatePaste() of a live paste error = %v, want %v", err, ErrLivePaste)
+	}
+	if _, err := svc.AppendPaste(ctx, createResp.ShortID, &AppendPasteRequest{Content: "", Caller: creator}); err != ErrEmptyContent {
+		t.Errorf("AppendPaste() with empty content error = %v, want %v", err, ErrEmptyContent)
+	}
+}
+
+func TestPasteService_AppendPasteNotLive(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "static", SyntaxType: "plaintext"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	creator := &DeletePasteRequest{DeleteToken: createResp.DeleteToken}
+	if _, err := svc.AppendPaste(ctx, createResp.ShortID, &AppendPasteRequest{Content: "x", Caller: creator}); err != ErrNotLive {
+		t.Errorf("AppendPaste() error = %v, want %v", err, ErrNotLive)
+	}
+	if _, err := svc.ReadLive(ctx, createResp.ShortID, 0, time.Time{}); err != ErrNotLive {
+		t.Errorf("ReadLive() error = %v, want %v", err, ErrNotLive)
+	}
+
+	if _, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "x", ExpiresIn: "burn", Live: true}); err != ErrInvalidLive {
+		t.Errorf("CreatePaste() of a live burn-after-read paste error = %v, want %v", err, ErrInvalidLive)
+	}
+	if _, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "x", MaxViews: 3, Live: true}); err != ErrInvalidLive {
+		t.Errorf("CreatePaste() of a live view-limited paste error = %v, want %v", err, ErrInvalidLive)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultLocalCacheTTL is how long content stays in the in-process cache when no TTL is configured
+const DefaultLocalCacheTTL = 2 * time.Second
+
+// localEntry is content held in the in-process cache
+type localEntry struct {
+	shortID   string
+	content   string
+	expiresAt time.Time
+}
+
+// LocalCache is an in-process, size-bounded LRU cache of paste content layered in front of Redis.
+// Entries live for a few seconds only: other instances do not invalidate it, so an edit or deletion
+// made elsewhere is seen once the entry expires. Paste metadata is always read from MongoDB, so
+// expired and deleted pastes are never served from it.
+type LocalCache struct {
+	mu        sync.Mutex
+	maxBytes  int64
+	usedBytes int64
+	ttl       time.Duration
+	ll        *list.List
+	items     map[string]*list.Element
+}
+
+// NewLocalCache creates a LocalCache holding at most maxBytes of content, each entry for at most ttl
+func NewLocalCache(maxBytes int64, ttl time.Duration) *LocalCache {
+	if ttl <= 0 {
+		ttl = DefaultLocalCacheTTL
+	}
+
+	return &LocalCache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached content of a paste, unless it expired
+func (c *LocalCache) Get(shortID string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[shortID]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*localEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return "", false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.content, true
+}
+
+// Set stores the content of a paste for ttl, capped to the cache TTL, evicting least recently used
+// entries until it fits. Content larger than the whole budget is not cached.
+func (c *LocalCache) Set(shortID, content string, ttl time.Duration) {
+	size := int64(len(content))
+	if size > c.maxBytes {
+		return
+	}
+	if ttl <= 0 || ttl > c.ttl {
+		ttl = c.ttl
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[shortID]; ok {
+		c.removeElement(elem)
+	}
+	for c.usedBytes+size > c.maxBytes {
+		c.removeElement(c.ll.Back())
+	}
+
+	c.items[shortID] = c.ll.PushFront(&localEntry{shortID: shortID, content: content, expiresAt: expiresAt})
+	c.usedBytes += size
+}
+
+// Delete removes the content of a paste
+func (c *LocalCache) Delete(shortID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[shortID]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Flush removes every entry
+func (c *LocalCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.usedBytes = 0
+}
+
+// Len returns the number of entries, expired ones included
+func (c *LocalCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// removeElement removes an entry; the caller holds the lock
+func (c *LocalCache) removeElement(elem *list.Element) {
+	entry := c.ll.Remove(elem).(*localEntry)
+	delete(c.items, entry.shortID)
+	c.usedBytes -= int64(len(entry.content))
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalCache_SizeBoundedEviction(t *testing.T) {
+	cache := NewLocalCache(10, time.Minute)
+
+	cache.Set("a", "aaaa", 0) // 4 bytes
+	cache.Set("b", "bbbb", 0) // 8 bytes
+
+	// Touch "a" so "b" becomes least recently used
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("Get(a) should hit")
+	}
+
+	cache.Set("c", "cccc", 0) // 12 bytes -> evict "b"
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Get(b) should miss after eviction")
+	}
+	if content, ok := cache.Get("a"); !ok || content != "aaaa" {
+		t.Errorf("Get(a) = %q, %v, want aaaa, true", content, ok)
+	}
+
+	// Content larger than the budget is never cached
+	cache.Set("huge", strings.Repeat("x", 11), 0)
+	if _, ok := cache.Get("huge"); ok {
+		t.Error("Get(huge) should miss for oversized content")
+	}
+}
+
+func TestLocalCache_Expiry(t *testing.T) {
+	cache := NewLocalCache(1024, 50*time.Millisecond)
+
+	cache.Set("short", "content", 10*time.Millisecond) // shorter than the cache TTL
+	cache.Set("long", "content", time.Hour)            // capped to the cache TTL
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok := cache.Get("short"); ok {
+		t.Error("Get(short) should miss once its TTL passed")
+	}
+	if _, ok := cache.Get("long"); !ok {
+		t.Error("Get(long) should hit within the cache TTL")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := cache.Get("long"); ok {
+		t.Error("Get(long) should miss once the cache TTL passed")
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d, want expired entries removed", cache.Len())
+	}
+}
+
+func TestLocalCache_DeleteAndFlush(t *testing.T) {
+	cache := NewLocalCache(1024, time.Minute)
+	cache.Set("a", "aaaa", 0)
+	cache.Set("b", "bbbb", 0)
+
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Get(a) should miss after Delete")
+	}
+
+	cache.Flush()
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d after Flush, want 0", cache.Len())
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// LookupQuery selects pastes by the SHA-256 of their content
+type LookupQuery struct {
+	SHA256 string // hex-encoded SHA-256 of the content
+	// OwnerID and UserID scope the lookup to the caller's pastes, set by the handler; All looks up
+	// every paste, for admins
+	OwnerID string
+	UserID  string
+	All     bool
+	Limit   int
+}
+
+// LookupResponse lists the pastes holding a given content, newest first
+type LookupResponse struct {
+	SHA256 string         `json:"sha256" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+	Pastes []PasteSummary `json:"pastes"`
+	// Truncated is set when more pastes match than the limit allowed
+	Truncated bool `json:"truncated" example:"false"`
+}
+
+// LookupContentHash returns the readable pastes whose content has the given SHA-256, so clients
+// can tell whether they already pasted a file. Pastes created before hashes were recorded are
+// found once they have been read.
+func (s *PasteService) LookupContentHash(ctx context.Context, q *LookupQuery) (*LookupResponse, error) {
+	sum, err := decodeChecksum(q.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	if !q.All && q.OwnerID == "" && q.UserID == "" {
+		return nil, ErrOwnerRequired
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	hash := hex.EncodeToString(sum)
+	ownerID, userID := q.OwnerID, q.UserID
+	if q.All {
+		ownerID, userID = "", ""
+	}
+	// Fetch one more than the limit to tell whether the listing is complete
+	pastes, err := s.pasteRepo.ListByContentHash(ctx, hash, ownerID, userID, time.Now(), int64(limit)+1)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to look up content: %w", err)
+	}
+
+	response := &LookupResponse{SHA256: hash, Pastes: make([]PasteSummary, 0, min(len(pastes), limit))}
+	if len(pastes) > limit {
+		pastes = pastes[:limit]
+		response.Truncated = true
+	}
+	for _, paste := range pastes {
+		summary := toPasteSummary(paste)
+		if !q.All {
+			// The hash only matters for incident review
+			summary.SourceIPHash = ""
+		}
+		response.Pastes = append(response.Pastes, summary)
+	}
+	return response, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// MaintenanceKey is the Redis key holding the maintenance mode state
+	MaintenanceKey = "gisty:maintenance"
+	// DefaultMaintenanceRetryAfter is the Retry-After hint used when none is configured
+	DefaultMaintenanceRetryAfter = 5 * time.Minute
+)
+
+// MaintenanceState describes the current maintenance mode
+type MaintenanceState struct {
+	Enabled    bool          `json:"enabled"`
+	Message    string        `json:"message,omitempty"`
+	RetryAfter time.Duration `json:"retry_after"`
+	Since      *time.Time    `json:"since,omitempty"`
+}
+
+// Maintenance manages the maintenance mode flag shared by all replicas through Redis
+type Maintenance struct {
+	client *redis.Client
+}
+
+// NewMaintenance creates a new Maintenance service
+func NewMaintenance(redisClient *repository.Redis) *Maintenance {
+	return &Maintenance{
+		client: redisClient.Client,
+	}
+}
+
+// Get returns the current maintenance state
+func (m *Maintenance) Get(ctx context.Context) (*MaintenanceState, error) {
+	data, err := m.client.Get(ctx, MaintenanceKey).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return &MaintenanceState{}, nil
+		}
+		return nil, err
+	}
+
+	var state MaintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Enable turns maintenance mode on
+func (m *Maintenance) Enable(ctx context.Context, message string, retryAfter time.Duration) (*MaintenanceState, error) {
+	if retryAfter <= 0 {
+		retryAfter = DefaultMaintenanceRetryAfter
+	}
+
+	now := time.Now().UTC()
+	state := &MaintenanceState{
+		Enabled:    true,
+		Message:    message,
+		RetryAfter: retryAfter,
+		Since:      &now,
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.client.Set(ctx, MaintenanceKey, data, 0).Err(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Disable turns maintenance mode off
+func (m *Maintenance) Disable(ctx context.Context) error {
+	return m.client.Del(ctx, MaintenanceKey).Err()
+}
+
+// Check reports whether writes should be rejected and the Retry-After hint to send
+func (m *Maintenance) Check(ctx context.Context) (bool, time.Duration, error) {
+	state, err := m.Get(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	return state.Enabled, state.RetryAfter, nil
+}
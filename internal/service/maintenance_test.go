@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func setupTestMaintenance(t *testing.T) (*Maintenance, func()) {
+	ctx := context.Background()
+
+	redisClient, err := repository.NewRedisClient(ctx, "redis://localhost:6379")
+	if err != nil {
+		t.Skipf("Redis not available: %v", err)
+	}
+
+	maintenance := NewMaintenance(redisClient)
+
+	cleanup := func() {
+		redisClient.Client.Del(ctx, MaintenanceKey)
+		redisClient.Close()
+	}
+
+	return maintenance, cleanup
+}
+
+func TestMaintenance_EnableDisable(t *testing.T) {
+	maintenance, cleanup := setupTestMaintenance(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Disabled by default
+	enabled, _, err := maintenance.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if enabled {
+		t.Error("Maintenance should be disabled by default")
+	}
+
+	// Enable
+	state, err := maintenance.Enable(ctx, "migrating", 2*time.Minute)
+	if err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	if !state.Enabled || state.Since == nil {
+		t.Error("Enable() should return an enabled state with Since set")
+	}
+
+	enabled, retryAfter, err := maintenance.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !enabled {
+		t.Error("Maintenance should be enabled")
+	}
+	if retryAfter != 2*time.Minute {
+		t.Errorf("Check() retryAfter = %v, want %v", retryAfter, 2*time.Minute)
+	}
+
+	current, err := maintenance.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if current.Message != "migrating" {
+		t.Errorf("Get().Message = %q, want %q", current.Message, "migrating")
+	}
+
+	// Disable
+	if err := maintenance.Disable(ctx); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	enabled, _, err = maintenance.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if enabled {
+		t.Error("Maintenance should be disabled")
+	}
+}
+
+func TestMaintenance_DefaultRetryAfter(t *testing.T) {
+	maintenance, cleanup := setupTestMaintenance(t)
+	defer cleanup()
+
+	state, err := maintenance.Enable(context.Background(), "", 0)
+	if err != nil {
+		t.Fatalf("Enable() error = %v", err)
+	}
+	if state.RetryAfter != DefaultMaintenanceRetryAfter {
+		t.Errorf("RetryAfter = %v, want %v", state.RetryAfter, DefaultMaintenanceRetryAfter)
+	}
+}
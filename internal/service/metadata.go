@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTitleLength is the longest accepted title, in characters
+	MaxTitleLength = 200
+	// MaxDescriptionLength is the longest accepted description, in characters
+	MaxDescriptionLength = 2000
+	// MaxTags is the most tags a paste can have
+	MaxTags = 10
+)
+
+// ErrInvalidMetadata is returned when a title, description or tag is too long or malformed
+var ErrInvalidMetadata = errors.New("paste: invalid title, description or tags")
+
+// tagPattern is the accepted form of a normalized tag
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._+-]{0,31}$`)
+
+// PasteMetadata is the optional descriptive metadata of a paste
+type PasteMetadata struct {
+	Title       string
+	Description string
+	Tags        []string
+}
+
+// NormalizeMetadata trims the title and description and lowercases, sorts and de-duplicates the tags.
+// Titles are single-line; tags are 1-32 characters of letters, digits and ._+- starting with a letter or digit.
+func NormalizeMetadata(title, description string, tags []string) (*PasteMetadata, error) {
+	m := &PasteMetadata{
+		Title:       strings.TrimSpace(title),
+		Description: strings.TrimSpace(description),
+	}
+	if !utf8.ValidString(m.Title) || utf8.RuneCountInString(m.Title) > MaxTitleLength ||
+		strings.ContainsAny(m.Title, "\r\n") {
+		return nil, ErrInvalidMetadata
+	}
+	if !utf8.ValidString(m.Description) || utf8.RuneCountInString(m.Description) > MaxDescriptionLength {
+		return nil, ErrInvalidMetadata
+	}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, ErrInvalidMetadata
+		}
+		m.Tags = append(m.Tags, tag)
+	}
+	slices.Sort(m.Tags)
+	m.Tags = slices.Compact(m.Tags)
+	if len(m.Tags) > MaxTags {
+		return nil, ErrInvalidMetadata
+	}
+
+	return m, nil
+}
+
+// truncateRunes cuts s to at most n characters
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
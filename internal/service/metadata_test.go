@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeMetadata(t *testing.T) {
+	m, err := NormalizeMetadata("  Deploy script ", "\nRuns the deploy.\n", []string{"Go", "ops", " go ", "k8s"})
+	if err != nil {
+		t.Fatalf("NormalizeMetadata() error = %v", err)
+	}
+	if m.Title != "Deploy script" || m.Description != "Runs the deploy." {
+		t.Errorf("NormalizeMetadata() = %q, %q; want trimmed title and description", m.Title, m.Description)
+	}
+	if want := []string{"go", "k8s", "ops"}; !slices.Equal(m.Tags, want) {
+		t.Errorf("Tags = %v, want %v", m.Tags, want)
+	}
+
+	m, err = NormalizeMetadata("", "", nil)
+	if err != nil || m.Title != "" || m.Tags != nil {
+		t.Errorf("NormalizeMetadata() without metadata = %+v, %v", m, err)
+	}
+}
+
+func TestNormalizeMetadata_Invalid(t *testing.T) {
+	tooManyTags := make([]string, MaxTags+1)
+	for i := range tooManyTags {
+		tooManyTags[i] = "tag" + string(rune('a'+i))
+	}
+
+	tests := []struct {
+		name        string
+		title, desc string
+		tags        []string
+	}{
+		{"multi-line title", "a\nb", "", nil},
+		{"long title", strings.Repeat("x", MaxTitleLength+1), "", nil},
+		{"long description", "", strings.Repeat("x", MaxDescriptionLength+1), nil},
+		{"empty tag", "", "", []string{" "}},
+		{"tag with space", "", "", []string{"two words"}},
+		{"long tag", "", "", []string{strings.Repeat("a", 33)}},
+		{"too many tags", "", "", tooManyTags},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NormalizeMetadata(tt.title, tt.desc, tt.tags); !errors.Is(err, ErrInvalidMetadata) {
+				t.Errorf("NormalizeMetadata() error = %v, want ErrInvalidMetadata", err)
+			}
+		})
+	}
+}
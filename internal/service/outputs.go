@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// ProducerRunner is the producer kind of pastes holding the output of a sandbox run
+	ProducerRunner = "runner"
+	// MaxProducerNameLength is the longest accepted producer name
+	MaxProducerNameLength = 128
+	// MaxProducerURLLength is the longest accepted producer URL
+	MaxProducerURLLength = 2048
+	// DefaultOutputsLimit is the number of outputs returned by ListOutputs when no limit is given
+	DefaultOutputsLimit = 20
+	// MaxOutputsLimit caps the number of outputs returned by ListOutputs
+	MaxOutputsLimit = 100
+)
+
+var (
+	// ErrInvalidProducer is returned when the producer of a paste has no valid kind, or an overlong name or invalid URL
+	ErrInvalidProducer = errors.New("paste: invalid producer")
+	// ErrInputNotFound is returned when a paste is created as the output of a paste that cannot be read
+	ErrInputNotFound = errors.New("paste: input paste not found")
+)
+
+// producerKindPattern matches producer kinds: short lowercase identifiers such as "ci" or "github-actions"
+var producerKindPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// OutputSummary is the metadata of a paste holding the output of another paste
+type OutputSummary struct {
+	ShortID    string          `json:"short_id"`
+	URL        string          `json:"url"`
+	Title      string          `json:"title,omitempty"`
+	SyntaxType string          `json:"syntax_type"`
+	CreatedAt  string          `json:"created_at"`
+	ExpiresAt  *string         `json:"expires_at,omitempty"`
+	Producer   *model.Producer `json:"producer,omitempty"`
+}
+
+// ListOutputsResponse represents the outputs of a paste, newest first
+type ListOutputsResponse struct {
+	ShortID string          `json:"short_id"`
+	Outputs []OutputSummary `json:"outputs"`
+	// Truncated is set when more outputs exist than the limit allowed
+	Truncated bool `json:"truncated"`
+}
+
+// NormalizeProducer validates the producer of a paste, trimming its fields.
+// A nil producer is valid and returned as nil.
+func NormalizeProducer(producer *model.Producer) (*model.Producer, error) {
+	if producer == nil {
+		return nil, nil
+	}
+
+	normalized := &model.Producer{
+		Kind: strings.ToLower(strings.TrimSpace(producer.Kind)),
+		Name: strings.TrimSpace(producer.Name),
+		URL:  strings.TrimSpace(producer.URL),
+	}
+	if !producerKindPattern.MatchString(normalized.Kind) {
+		return nil, ErrInvalidProducer
+	}
+	if len(normalized.Name) > MaxProducerNameLength || strings.ContainsAny(normalized.Name, "\r\n") {
+		return nil, ErrInvalidProducer
+	}
+	if normalized.URL != "" {
+		if len(normalized.URL) > MaxProducerURLLength {
+			return nil, ErrInvalidProducer
+		}
+		u, err := url.Parse(normalized.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, ErrInvalidProducer
+		}
+	}
+	return normalized, nil
+}
+
+// checkOutputOf verifies that the input of an output paste exists and can be read
+func (s *PasteService) checkOutputOf(ctx context.Context, shortID string) error {
+	input, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return ErrInputNotFound
+		}
+		return fmt.Errorf("paste: failed to get input paste: %w", err)
+	}
+	if input.IsPending() || input.IsBurned() || input.IsExpired() {
+		return ErrInputNotFound
+	}
+	return nil
+}
+
+// ListOutputs returns up to limit pastes holding the output of a paste, newest first, so tooling
+// can navigate from a script to its captured output. Outputs that a listing would consume
+// (burn-after-read, view-limited) are never listed, and private outputs only for a private input,
+// whose short ID already grants the same access.
+func (s *PasteService) ListOutputs(ctx context.Context, shortID string, limit int) (*ListOutputsResponse, error) {
+	if limit <= 0 {
+		limit = DefaultOutputsLimit
+	}
+	limit = min(limit, MaxOutputsLimit)
+
+	input, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if input.IsPending() || input.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if input.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+
+	// One more than the limit tells whether the listing is truncated
+	pastes, err := s.pasteRepo.ListOutputs(ctx, shortID, input.IsPrivate, int64(limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list outputs: %w", err)
+	}
+
+	response := &ListOutputsResponse{ShortID: shortID, Outputs: []OutputSummary{}}
+	if len(pastes) > limit {
+		pastes = pastes[:limit]
+		response.Truncated = true
+	}
+	for _, paste := range pastes {
+		summary := OutputSummary{
+			ShortID:    paste.ShortID,
+			URL:        s.buildURL(paste.ShortID),
+			Title:      paste.Title,
+			SyntaxType: paste.SyntaxType,
+			CreatedAt:  paste.CreatedAt.UTC().Format(time.RFC3339),
+			Producer:   paste.Producer,
+		}
+		if paste.ExpiresAt != nil {
+			formatted := paste.ExpiresAt.UTC().Format(time.RFC3339)
+			summary.ExpiresAt = &formatted
+		}
+		response.Outputs = append(response.Outputs, summary)
+	}
+	return response, nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+func TestNormalizeProducer(t *testing.T) {
+	producer, err := NormalizeProducer(&model.Producer{Kind: " CI ", Name: " build 12 ", URL: "https://ci.example.com/jobs/12"})
+	if err != nil {
+		t.Fatalf("NormalizeProducer() error = %v", err)
+	}
+	want := model.Producer{Kind: "ci", Name: "build 12", URL: "https://ci.example.com/jobs/12"}
+	if *producer != want {
+		t.Errorf("NormalizeProducer() = %+v, want %+v", *producer, want)
+	}
+
+	if producer, err := NormalizeProducer(nil); producer != nil || err != nil {
+		t.Errorf("NormalizeProducer(nil) = %v, %v, want nil, nil", producer, err)
+	}
+}
+
+func TestNormalizeProducer_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		producer model.Producer
+	}{
+		{"missing kind", model.Producer{Name: "build"}},
+		{"kind with spaces", model.Producer{Kind: "github actions"}},
+		{"kind too long", model.Producer{Kind: strings.Repeat("a", 33)}},
+		{"name too long", model.Producer{Kind: "ci", Name: strings.Repeat("a", MaxProducerNameLength+1)}},
+		{"multi-line name", model.Producer{Kind: "ci", Name: "a\nb"}},
+		{"javascript url", model.Producer{Kind: "ci", URL: "javascript:alert(1)"}},
+		{"relative url", model.Producer{Kind: "ci", URL: "/jobs/12"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NormalizeProducer(&tt.producer); !errors.Is(err, ErrInvalidProducer) {
+				t.Errorf("NormalizeProducer() error = %v, want ErrInvalidProducer", err)
+			}
+		})
+	}
+}
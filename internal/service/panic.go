@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrOwnerRequired is returned when a request acting on the caller's pastes sends neither an API key nor a session
+var ErrOwnerRequired = errors.New("paste: owner required")
+
+// PanicResponse lists the pastes destroyed by Panic
+type PanicResponse struct {
+	Deleted  int      `json:"deleted"`
+	ShortIDs []string `json:"short_ids"`
+}
+
+// Panic immediately expires and deletes every unread burn-after-read or view-limited paste
+// created by the owner, for a secret link sent to the wrong place. Pastes become unreadable
+// before their content is removed.
+func (s *PasteService) Panic(ctx context.Context, ownerID string) (*PanicResponse, error) {
+	if ownerID == "" {
+		return nil, ErrOwnerRequired
+	}
+
+	shortIDs, err := s.pasteRepo.ExpireSelfDestructingByOwner(ctx, ownerID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to expire pastes: %w", err)
+	}
+	for _, shortID := range shortIDs {
+		s.deletePaste(ctx, shortID)
+	}
+
+	log.Printf("[PasteService.Panic] Deleted %d pastes of %s", len(shortIDs), ownerID)
+	return &PanicResponse{Deleted: len(shortIDs), ShortIDs: shortIDs}, nil
+}
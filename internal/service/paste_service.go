@@ -2,14 +2,26 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"github.com/huylvt/gisty/internal/codec"
+	"github.com/huylvt/gisty/internal/event"
+	"github.com/huylvt/gisty/internal/metrics"
 	"github.com/huylvt/gisty/internal/model"
 	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
@@ -25,13 +37,26 @@ var (
 	ErrPasteNotFound = errors.New("paste: not found")
 	// ErrPasteExpired is returned when paste has expired
 	ErrPasteExpired = errors.New("paste: expired")
+	// ErrPasteBurned is returned for a burn-after-read paste that was read, or a view-limited paste
+	// out of views. It wraps ErrPasteNotFound: callers that do not tell them apart answer 404.
+	ErrPasteBurned = fmt.Errorf("%w: burned", ErrPasteNotFound)
+	// ErrInvalidMaxViews is returned when max_views is out of range or combined with burn-after-read
+	ErrInvalidMaxViews = errors.New("paste: invalid max_views value")
+	// ErrInvalidEncoding is returned for an unknown encoding, or content that does not match its encoding
+	ErrInvalidEncoding = errors.New("paste: invalid content encoding")
 )
 
 const (
+	// ContentEncodingGzip is the content coding of content streamed as stored
+	ContentEncodingGzip = "gzip"
 	// MaxContentSize is the maximum allowed content size (1MB)
 	MaxContentSize = 1 * 1024 * 1024
 	// DefaultSyntaxType is the default syntax type for pastes
 	DefaultSyntaxType = "plaintext"
+	// MaxViewsLimit is the largest accepted max_views value
+	MaxViewsLimit = 1000000
+	// BurnClaimGrace is how long a claimed burn-after-read paste survives if its reader fails to delete it
+	BurnClaimGrace = time.Minute
 )
 
 // ValidSyntaxTypes is a whitelist of allowed syntax types
@@ -66,6 +91,7 @@ var ValidSyntaxTypes = map[string]bool{
 	"yaml":       true,
 	"toml":       true,
 	"ini":        true,
+	"dotenv":     true,
 	"dockerfile": true,
 	"makefile":   true,
 	"nginx":      true,
@@ -93,13 +119,53 @@ type CreatePasteRequest struct {
 	SyntaxType string `json:"syntax_type"`
 	ExpiresIn  string `json:"expires_in"` // "10m", "1h", "1d", "1w", "never", "burn"
 	IsPrivate  bool   `json:"is_private"`
+	Encrypted  bool   `json:"is_encrypted"` // content is client-side encrypted ciphertext
+	MaxViews   int    `json:"max_views"`    // delete the paste after this many reads (0 = unlimited)
+	Encoding   string `json:"encoding"`     // transfer encoding of content: "base64", "hex" or "" for none
+	Live       bool   `json:"live"`         // the owner appends to the paste later, see AppendPaste
+
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+
+	Delivery *model.DeliveryHeaders `json:"delivery"` // raw endpoint header overrides, see NormalizeDeliveryHeaders
+
+	// Schema is a JSON Schema to validate JSON or YAML content against; the result is stored with the paste
+	Schema json.RawMessage `json:"schema,omitempty"`
+
+	// SourceIP is the creator's IP, set by the handler; only its keyed hash is stored
+	SourceIP string `json:"-"`
+	// OwnerID identifies the creator's API key or anonymous session, set by the handler
+	OwnerID string `json:"-"`
+	// UserID is the ID of the signed-in creator, set by the handler
+	UserID string `json:"-"`
+
+	// OutputOf links the paste as the output of an existing paste, produced by Producer
+	OutputOf string          `json:"output_of"`
+	Producer *model.Producer `json:"producer"`
+
+	// ForkedFrom is the paste this one is a copy of, set by ForkPaste
+	ForkedFrom string `json:"-"`
+	// SourceURL is the URL the content was fetched from, set by ImportURL
+	SourceURL string `json:"-"`
+	// DerivedFrom is the paste and lines this one was cut from, set by CreateSnippet
+	DerivedFrom *model.DerivedFrom `json:"-"`
+	// GroupID links the paste to the other files of a gist or bundle, set by ImportGist and
+	// CreateBundle; Path is the file's path in the bundle
+	GroupID string `json:"-"`
+	Path    string `json:"-"`
 }
 
 // CreatePasteResponse represents the response after creating a paste
 type CreatePasteResponse struct {
-	ShortID   string  `json:"short_id"`
-	URL       string  `json:"url"`
-	ExpiresAt *string `json:"expires_at,omitempty"`
+	ShortID    string                  `json:"short_id"`
+	URL        string                  `json:"url"`
+	ExpiresAt  *string                 `json:"expires_at,omitempty"`
+	Validation *model.SchemaValidation `json:"validation,omitempty"`
+	// DeleteToken is returned once for pastes created anonymously, and required to delete them
+	DeleteToken string `json:"delete_token,omitempty"`
+	// ExpirationPolicy is the expiration policy of the paste's syntax type, when one is configured
+	ExpirationPolicy *ExpirationPolicyResult `json:"expiration_policy,omitempty"`
 }
 
 // GetPasteResponse represents the response when retrieving a paste
@@ -109,16 +175,158 @@ type GetPasteResponse struct {
 	SyntaxType string  `json:"syntax_type"`
 	CreatedAt  string  `json:"created_at"`
 	ExpiresAt  *string `json:"expires_at,omitempty"`
+	Binary     bool    `json:"binary,omitempty"`
+	Encrypted  bool    `json:"is_encrypted"`      // clients must decrypt the content themselves
+	Preview    string  `json:"preview,omitempty"` // content safe to render, set when lines were too long or NUL bytes present
+	Size       int     `json:"size"`              // total content size in bytes, even when truncated
+	Truncated  bool    `json:"truncated,omitempty"`
+	Views      int64   `json:"views"`               // reads so far, including this one
+	MaxViews   int     `json:"max_views,omitempty"` // the paste is deleted once views reaches it
+	Encoding   string  `json:"encoding,omitempty"`  // transfer encoding of content, when one was requested
+
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+
+	Delivery   *model.DeliveryHeaders  `json:"delivery,omitempty"`
+	Validation *model.SchemaValidation `json:"validation,omitempty"`
+	OutputOf   string                  `json:"output_of,omitempty"` // the paste this one holds the output of
+	Producer   *model.Producer         `json:"producer,omitempty"`
+	ForkedFrom string                  `json:"forked_from,omitempty"` // the paste this one is a copy of
+	SourceURL  string                  `json:"source_url,omitempty"`  // the URL the content was fetched from
+
+	DerivedFrom *model.DerivedFrom `json:"derived_from,omitempty"` // the paste and lines this one was cut from
+	GroupID     string             `json:"group_id,omitempty"`     // shared with the other files of the same bundle or gist import
+	Path        string             `json:"path,omitempty"`         // the file's path in its bundle, or its filename in a gist
+	Live        bool               `json:"live,omitempty"`         // appended to by its owner, see ReadLive
+
+	// ContentURL is set instead of the content of large pastes read with GetPasteWithURL, whose
+	// response has no content and a size of 0: a pre-signed URL downloading the content straight
+	// from storage until ContentURLExpiresAt, compressed with ContentURLEncoding when set
+	ContentURL          string  `json:"content_url,omitempty"`
+	ContentURLExpiresAt *string `json:"content_url_expires_at,omitempty"`
+	ContentURLEncoding  string  `json:"content_url_encoding,omitempty"`
+
+	// Annotations of the current content, for the HTML view; the API serves them on their own endpoint
+	Annotations []model.Annotation `json:"-"`
+	// ContentHash identifies the content for the ETag header; empty when the paste cannot be revalidated
+	ContentHash string `json:"-"`
+	// ContentEncoding is ContentEncodingGzip when OpenPaste streams the content gzip compressed
+	ContentEncoding string `json:"-"`
+}
+
+// Truncate caps the content (and preview) at maxBytes, cutting on a UTF-8 boundary.
+// A maxBytes of 0 or less leaves the response untouched.
+func (r *GetPasteResponse) Truncate(maxBytes int) {
+	if maxBytes <= 0 {
+		return
+	}
+	if len(r.Content) > maxBytes {
+		r.Content = truncateUTF8(r.Content, maxBytes)
+		r.Truncated = true
+	}
+	if len(r.Preview) > maxBytes {
+		r.Preview = truncateUTF8(r.Preview, maxBytes)
+		r.Truncated = true
+	}
+}
+
+// Encode replaces the content with its given transfer encoding, after any truncation. The preview
+// is left as is, being text meant to be rendered.
+func (r *GetPasteResponse) Encode(encoding string) error {
+	content, err := codec.Encode(encoding, r.Content)
+	if err != nil {
+		return ErrInvalidEncoding
+	}
+	r.Content, r.Encoding = content, encoding
+	return nil
+}
+
+// optionalString returns a pointer to s, or nil when s is empty
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// truncateUTF8 returns the longest prefix of s of at most n bytes that does not split a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// PasteTTLResponse describes how long a paste remains readable. RemainingSeconds is computed
+// server-side so clients can count down without trusting their own clock.
+type PasteTTLResponse struct {
+	ShortID          string  `json:"short_id"`
+	ExpiresAt        *string `json:"expires_at,omitempty"`
+	RemainingSeconds *int64  `json:"remaining_seconds,omitempty"`
+	BurnAfterRead    bool    `json:"burn_after_read"`
+	RemainingViews   *int64  `json:"remaining_views,omitempty"` // only for pastes with max_views
+	Expired          bool    `json:"expired"`
+	ServerTime       string  `json:"server_time"`
 }
 
 // PasteService handles paste business logic
 type PasteService struct {
-	kgs            *KGS
-	storage        *Storage
-	cache          *Cache
-	pasteRepo      *repository.PasteRepository
-	syntaxDetector *SyntaxDetector
-	baseURL        string
+	kgs          *KGS
+	storage      *Storage
+	cache        *Cache
+	pasteRepo    *repository.PasteRepository
+	revisionRepo *repository.RevisionRepository
+	// commentRepo, notificationRepo and userRepo keep comments and notify users of them (nil
+	// disables comments)
+	commentRepo      *repository.CommentRepository
+	notificationRepo *repository.NotificationRepository
+	userRepo         *repository.UserRepository
+	trending         *Trending
+	events           event.Bus
+	ipHashKey        []byte
+	syntaxDetector   *SyntaxDetector
+	renderCache      *RenderCache
+	contentPolicy    ContentPolicy
+	cachePolicy      CachePolicy
+	linters          []Linter
+	runner           Runner
+	runLanguages     map[string]bool
+	runTimeout       time.Duration
+	gists            Gists
+	urlFetcher       *URLFetcher
+	screeners        []Screener
+	screeningPolicy  ScreeningPolicy
+	baseURL          string
+
+	// expirationPolicies caps the lifetime of new pastes by syntax type
+	expirationPolicies map[string]time.Duration
+
+	// deletions tracks the deletions running in the background, awaited on shutdown
+	deletions sync.WaitGroup
+
+	// fetches collapses concurrent storage reads of the same paste content on a cache miss
+	fetches singleflight.Group
+
+	// Content stored above downloadMinSize bytes may be downloaded from storage with a
+	// pre-signed URL valid for downloadURLExpiry (0 disables)
+	downloadMinSize   int64
+	downloadURLExpiry time.Duration
+
+	// watchdog flags unhealthy dependencies, skipped or failed fast (nil trusts them all)
+	watchdog *Watchdog
+
+	// journal records creates until their writes complete (nil disables journaling)
+	journal *Journal
+
+	// reportRepo keeps the abuse reports of pastes (nil disables reports)
+	reportRepo *repository.ReportRepository
+
+	// caseInsensitiveIDs lets ResolveShortID match short IDs regardless of case
+	caseInsensitiveIDs bool
 }
 
 // NewPasteService creates a new PasteService
@@ -129,35 +337,86 @@ func NewPasteService(kgs *KGS, storage *Storage, cache *Cache, pasteRepo *reposi
 		cache:          cache,
 		pasteRepo:      pasteRepo,
 		syntaxDetector: NewSyntaxDetector(),
+		renderCache:    NewRenderCache(DefaultRenderCacheMaxBytes),
+		contentPolicy:  DefaultContentPolicy(),
+		cachePolicy:    DefaultCachePolicy(),
 		baseURL:        baseURL,
 	}
 }
 
+// SetContentPolicy replaces the policy applied to pastes with overlong lines or NUL bytes
+func (s *PasteService) SetContentPolicy(policy ContentPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	s.contentPolicy = policy
+	return nil
+}
+
+// SetCachePolicy replaces the policy deciding how long content stays cached
+func (s *PasteService) SetCachePolicy(policy CachePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	s.cachePolicy = policy
+	return nil
+}
+
+// SetWatchdog makes the service skip the cache while Redis is unhealthy, and reject writes and
+// uncached reads while S3 or MongoDB is, instead of waiting for them to time out
+func (s *PasteService) SetWatchdog(watchdog *Watchdog) {
+	s.watchdog = watchdog
+}
+
+// healthy reports whether the watchdog considers a dependency healthy
+func (s *PasteService) healthy(dependency string) bool {
+	return s.watchdog == nil || s.watchdog.Healthy(dependency)
+}
+
+// KeysRetryAfter returns the Retry-After hint to send when no short ID is available; 0 for none
+func (s *PasteService) KeysRetryAfter() time.Duration {
+	return s.kgs.RetryAfter()
+}
+
+// checkWritable returns ErrDependencyUnavailable when the stores a write needs are unhealthy
+func (s *PasteService) checkWritable() error {
+	for _, dependency := range []string{DependencyS3, DependencyMongoDB} {
+		if !s.healthy(dependency) {
+			return fmt.Errorf("%w: %s", ErrDependencyUnavailable, dependency)
+		}
+	}
+	return nil
+}
+
 // CreatePaste creates a new paste
 func (s *PasteService) CreatePaste(ctx context.Context, req *CreatePasteRequest) (*CreatePasteResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.CreatePaste")
+	defer span.End()
+
 	log.Printf("[PasteService.CreatePaste] Starting: content_len=%d, syntax=%s, expires_in=%s",
 		len(req.Content), req.SyntaxType, req.ExpiresIn)
 
-	// Validate content
-	if len(req.Content) == 0 {
-		log.Printf("[PasteService.CreatePaste] Error: empty content")
-		return nil, ErrEmptyContent
-	}
-	if len(req.Content) > MaxContentSize {
-		log.Printf("[PasteService.CreatePaste] Error: content too large (%d > %d)", len(req.Content), MaxContentSize)
-		return nil, ErrContentTooLarge
+	// Content sent base64 or hex encoded is stored decoded
+	if req.Encoding != codec.None {
+		content, err := codec.Decode(req.Encoding, req.Content)
+		if err != nil {
+			return nil, ErrInvalidEncoding
+		}
+		req.Content, req.Encoding = content, codec.None
 	}
 
-	// Normalize and validate syntax type
-	syntaxType := strings.ToLower(strings.TrimSpace(req.SyntaxType))
-	if !ValidSyntaxTypes[syntaxType] {
-		log.Printf("[PasteService.CreatePaste] Error: invalid syntax type: %s", syntaxType)
-		return nil, ErrInvalidSyntaxType
+	// Validate content and resolve the syntax type
+	syntaxType, flags, err := s.prepareContent(req.Content, req.SyntaxType, req.Encrypted)
+	if err != nil {
+		log.Printf("[PasteService.CreatePaste] Error: %v", err)
+		return nil, err
 	}
-	if syntaxType == "" {
-		// Auto-detect language from content
-		syntaxType = s.syntaxDetector.DetectLanguage(req.Content)
-		log.Printf("[PasteService.CreatePaste] Auto-detected syntax: %s", syntaxType)
+
+	// Screen for spam and abuse; rejected pastes do not use up a short ID
+	screening := s.screen(ctx, screeningText(req.Content, req.Title, req.Description, !req.Encrypted && !flags.Binary))
+	if screening != nil && screening.Action == ScreeningReject {
+		log.Printf("[PasteService.CreatePaste] Rejected by screening (score %d): %s", screening.Score, strings.Join(screening.Reasons, ", "))
+		return nil, ErrContentRejected
 	}
 
 	// Parse expiration
@@ -166,7 +425,61 @@ func (s *PasteService) CreatePaste(ctx context.Context, req *CreatePasteRequest)
 		log.Printf("[PasteService.CreatePaste] Error parsing expiration '%s': %v", req.ExpiresIn, err)
 		return nil, err
 	}
+	expiresAt, policy := s.applyExpirationPolicy(syntaxType, expiresAt)
 	log.Printf("[PasteService.CreatePaste] Parsed expiration: expiresAt=%v, burnAfterRead=%v", expiresAt, burnAfterRead)
+	if req.Live {
+		if err := validateLive(burnAfterRead, req.MaxViews, req.Encrypted); err != nil {
+			return nil, err
+		}
+	}
+
+	// Burn-after-read is a single view, so a larger view limit contradicts it
+	if req.MaxViews < 0 || req.MaxViews > MaxViewsLimit || (burnAfterRead && req.MaxViews > 1) {
+		return nil, ErrInvalidMaxViews
+	}
+
+	metadata, err := NormalizeMetadata(req.Title, req.Description, req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// Non-conforming content is still accepted: the result is stored for readers to see
+	var validation *model.SchemaValidation
+	if len(req.Schema) > 0 && string(req.Schema) != "null" {
+		validation, err = ValidateDocument(req.Content, syntaxType, req.Schema)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	producer, err := NormalizeProducer(req.Producer)
+	if err != nil {
+		return nil, err
+	}
+	if req.OutputOf != "" {
+		if err := s.checkOutputOf(ctx, req.OutputOf); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validate delivery header overrides
+	delivery, err := NormalizeDeliveryHeaders(req.Delivery)
+	if err != nil {
+		log.Printf("[PasteService.CreatePaste] Error: invalid delivery headers: %+v", *req.Delivery)
+		return nil, err
+	}
+	if delivery != nil && (burnAfterRead || req.MaxViews > 0) && delivery.CacheControl != "" {
+		// A cached copy would serve reads that the single read or the view limit does not count
+		delivery.CacheControl = "no-store"
+	}
+
+	if err := s.checkWritable(); err != nil {
+		log.Printf("[PasteService.CreatePaste] Error: %v", err)
+		return nil, err
+	}
+	if exhausted, _ := s.kgs.Exhausted(); exhausted {
+		return nil, ErrKeysExhausted
+	}
 
 	// Get a unique short ID from KGS
 	shortID, err := s.kgs.GetNextKey(ctx)
@@ -176,49 +489,100 @@ func (s *PasteService) CreatePaste(ctx context.Context, req *CreatePasteRequest)
 	}
 	log.Printf("[PasteService.CreatePaste] Got short ID: %s", shortID)
 
-	// Save content to S3
-	if err := s.storage.SaveContent(ctx, shortID, req.Content); err != nil {
-		log.Printf("[PasteService.CreatePaste] Error saving to S3: %v", err)
-		return nil, fmt.Errorf("paste: failed to save content: %w", err)
-	}
-	log.Printf("[PasteService.CreatePaste] Saved content to S3")
-
-	// Create paste record in MongoDB
 	paste := &model.Paste{
 		ShortID:       shortID,
 		ContentKey:    s.storage.buildKey(shortID),
+		ContentHash:   ContentHash(req.Content),
 		ExpiresAt:     expiresAt,
 		CreatedAt:     time.Now(),
 		SyntaxType:    syntaxType,
 		IsPrivate:     req.IsPrivate,
 		BurnAfterRead: burnAfterRead,
+
+		Binary:           flags.Binary,
+		PreviewTruncated: flags.PreviewTruncated,
+		Encrypted:        req.Encrypted,
+		Delivery:         delivery,
+		SourceIPHash:     s.HashSourceIP(req.SourceIP),
+		OwnerID:          req.OwnerID,
+		UserID:           optionalString(req.UserID),
+		Live:             req.Live,
+		MaxViews:         req.MaxViews,
+		Title:            metadata.Title,
+		Description:      metadata.Description,
+		Tags:             metadata.Tags,
+		Validation:       validation,
+		OutputOf:         req.OutputOf,
+		Producer:         producer,
+		ForkedFrom:       req.ForkedFrom,
+		SourceURL:        req.SourceURL,
+		DerivedFrom:      req.DerivedFrom,
+		GroupID:          req.GroupID,
+		Path:             req.Path,
+		Screening:        screening,
+	}
+	if screening != nil && screening.Action == ScreeningQuarantine {
+		paste.Quarantine = &model.Quarantine{Reason: "screening: " + strings.Join(screening.Reasons, ", "), At: screening.At}
+	}
+	deleteToken := issueDeleteToken(paste, req.OwnerID, req.UserID)
+
+	// With a journal, the create is recorded before it is written so that it survives a crash:
+	// small content inline, large content by reference once saved to S3
+	inline := s.journal != nil && s.journal.inline(len(req.Content))
+	if inline {
+		if err := s.journal.begin(paste, &req.Content); err != nil {
+			log.Printf("[PasteService.CreatePaste] Error journaling create: %v", err)
+			return nil, fmt.Errorf("paste: failed to journal create: %w", err)
+		}
 	}
 
+	// Save content to S3
+	if err := s.storage.SaveContent(ctx, shortID, req.Content); err != nil {
+		log.Printf("[PasteService.CreatePaste] Error saving to S3: %v", err)
+		if inline {
+			s.journal.end(shortID)
+		}
+		return nil, fmt.Errorf("paste: failed to save content: %w", err)
+	}
+	log.Printf("[PasteService.CreatePaste] Saved content to S3")
+
+	if s.journal != nil && !inline {
+		if err := s.journal.begin(paste, nil); err != nil {
+			log.Printf("[PasteService.CreatePaste] Error journaling create: %v", err)
+			_ = s.storage.DeleteContent(ctx, shortID)
+			return nil, fmt.Errorf("paste: failed to journal create: %w", err)
+		}
+	}
+
+	// Create paste record in MongoDB
 	if err := s.pasteRepo.Create(ctx, paste); err != nil {
 		log.Printf("[PasteService.CreatePaste] Error creating MongoDB record: %v", err)
 		// Try to clean up S3 on failure
 		_ = s.storage.DeleteContent(ctx, shortID)
+		if s.journal != nil {
+			s.journal.end(shortID)
+		}
 		return nil, fmt.Errorf("paste: failed to create record: %w", err)
 	}
+	if s.journal != nil {
+		s.journal.end(shortID)
+	}
 	log.Printf("[PasteService.CreatePaste] Created MongoDB record")
+	s.publish(model.PasteEventCreated, shortID, paste)
 
-	// Cache the content (optional, best effort)
-	cacheTTL := DefaultCacheTTL
-	if expiresAt != nil {
-		ttl := time.Until(*expiresAt)
-		if ttl > 0 && ttl < cacheTTL {
-			cacheTTL = ttl
-		}
-	}
-	// Don't cache burn-after-read pastes
-	if !burnAfterRead {
+	// Cache the content (optional, best effort; burn-after-read pastes are never cached, and large
+	// pastes only once they are read often enough)
+	if cacheTTL, ok := s.cachePolicy.TTL(paste, len(req.Content), time.Now()); ok && !s.cachePolicy.NeedsAdmission(paste, len(req.Content)) && s.healthy(DependencyRedis) {
 		_ = s.cache.Set(ctx, shortID, req.Content, cacheTTL)
 	}
 
 	// Build response
 	response := &CreatePasteResponse{
-		ShortID: shortID,
-		URL:     s.buildURL(shortID),
+		ShortID:          shortID,
+		URL:              s.buildURL(shortID),
+		Validation:       validation,
+		DeleteToken:      deleteToken,
+		ExpirationPolicy: policy,
 	}
 
 	if expiresAt != nil {
@@ -263,6 +627,14 @@ func (s *PasteService) parseExpiration(expiresIn string) (*time.Time, bool, erro
 	case "1M":
 		duration = 30 * 24 * time.Hour
 	default:
+		// A bare number is seconds, as sent in the X-Gisty-Expires header
+		if seconds, err := strconv.Atoi(expiresIn); err == nil {
+			if seconds <= 0 {
+				return nil, false, ErrInvalidExpiresIn
+			}
+			duration = time.Duration(seconds) * time.Second
+			break
+		}
 		// Try to parse as Go duration
 		var err error
 		duration, err = time.ParseDuration(expiresIn)
@@ -280,8 +652,168 @@ func (s *PasteService) buildURL(shortID string) string {
 	return s.baseURL + "/" + shortID
 }
 
+// prepareContent validates content against the size limit and content policy and resolves its syntax type,
+// auto-detecting it when empty. Encrypted content is opaque: only its size is checked.
+func (s *PasteService) prepareContent(content, syntaxType string, encrypted bool) (string, ContentFlags, error) {
+	if len(content) == 0 {
+		return "", ContentFlags{}, ErrEmptyContent
+	}
+	if len(content) > MaxContentSize {
+		return "", ContentFlags{}, ErrContentTooLarge
+	}
+	if encrypted {
+		return DefaultSyntaxType, ContentFlags{}, nil
+	}
+	flags, err := s.contentPolicy.Apply(content)
+	if err != nil {
+		return "", ContentFlags{}, err
+	}
+
+	// Normalize and validate syntax type
+	syntaxType = strings.ToLower(strings.TrimSpace(syntaxType))
+	if !ValidSyntaxTypes[syntaxType] {
+		return "", ContentFlags{}, ErrInvalidSyntaxType
+	}
+	if flags.Binary {
+		// Binary content is never highlighted
+		syntaxType = DefaultSyntaxType
+	} else if syntaxType == "" {
+		// Auto-detect language from content, reusing the result for identical content
+		syntaxType, _ = s.renderCache.GetOrRender(content, "detect-language", func(content string) (string, error) {
+			return s.syntaxDetector.DetectLanguage(content), nil
+		})
+		log.Printf("[PasteService] Auto-detected syntax: %s", syntaxType)
+	}
+
+	return syntaxType, flags, nil
+}
+
 // GetPaste retrieves a paste by its short ID
 func (s *PasteService) GetPaste(ctx context.Context, shortID string) (*GetPasteResponse, error) {
+	return s.getPaste(ctx, shortID, false)
+}
+
+// GetPasteWithURL retrieves a paste like GetPaste, except that the content of pastes stored
+// above the download size threshold is not read: the response has a pre-signed URL to download
+// it from storage instead, so large pastes do not go through the API server
+func (s *PasteService) GetPasteWithURL(ctx context.Context, shortID string) (*GetPasteResponse, error) {
+	return s.getPaste(ctx, shortID, true)
+}
+
+// getPaste retrieves a paste, with a download URL instead of its content when withURL is set
+// and the paste is large enough
+func (s *PasteService) getPaste(ctx context.Context, shortID string, withURL bool) (*GetPasteResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.GetPaste", trace.WithAttributes(attribute.String("short_id", shortID)))
+	defer span.End()
+
+	paste, err := s.claimRead(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+
+	if withURL {
+		if response, ok := s.downloadURLResponse(ctx, paste); ok {
+			s.finishRead(ctx, paste)
+			return response, nil
+		}
+	}
+
+	// Try to get content from cache first (burn-after-read pastes are never cached)
+	content, found := s.lookupContent(ctx, paste)
+
+	// Cache miss - fetch from S3
+	if !found {
+		content, err = s.fetchContent(ctx, paste)
+		if err != nil {
+			return nil, s.releaseRead(ctx, paste, err)
+		}
+	}
+
+	s.finishRead(ctx, paste)
+
+	response := newGetPasteResponse(paste, content)
+	if revalidatable(paste) && paste.ContentHash == "" {
+		// Pastes created before content hashes were recorded get one now (best effort)
+		response.ContentHash = ContentHash(content)
+		_ = s.pasteRepo.SetContentHash(ctx, shortID, response.ContentHash)
+	}
+	if paste.PreviewTruncated {
+		response.Preview = s.contentPolicy.Preview(content)
+	}
+	return response, nil
+}
+
+// OpenPaste reads a paste like GetPaste but returns its content as a stream, so large pastes
+// are served without holding them in memory. The response has no content, preview or size.
+// Content read from storage is cached on the way when the cache policy allows it. When
+// acceptGzip is set, content stored gzip compressed is streamed as stored, and the response's
+// ContentEncoding says so. The caller must close the reader.
+func (s *PasteService) OpenPaste(ctx context.Context, shortID string, acceptGzip bool) (*GetPasteResponse, io.ReadCloser, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.OpenPaste", trace.WithAttributes(attribute.String("short_id", shortID)))
+	defer span.End()
+
+	paste, err := s.claimRead(ctx, shortID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reader io.ReadCloser
+	var contentEncoding string
+	if content, found := s.lookupContent(ctx, paste); found {
+		reader = io.NopCloser(strings.NewReader(content))
+	} else if !s.healthy(DependencyS3) {
+		return nil, nil, s.releaseRead(ctx, paste, fmt.Errorf("%w: %s", ErrDependencyUnavailable, DependencyS3))
+	} else if acceptGzip {
+		stream, compressed, err := s.storage.OpenCompressed(ctx, shortID)
+		if err != nil {
+			return nil, nil, s.releaseRead(ctx, paste, err)
+		}
+		if compressed {
+			reader = s.compressedCachingReader(ctx, paste, stream)
+			contentEncoding = ContentEncodingGzip
+		} else {
+			reader = s.cachingReader(ctx, paste, stream)
+		}
+	} else {
+		stream, err := s.storage.OpenContent(ctx, shortID)
+		if err != nil {
+			return nil, nil, s.releaseRead(ctx, paste, err)
+		}
+		reader = s.cachingReader(ctx, paste, stream)
+	}
+
+	s.finishRead(ctx, paste)
+	response := newGetPasteResponse(paste, "")
+	response.ContentEncoding = contentEncoding
+	return response, reader, nil
+}
+
+// GetContentHash returns the content hash of a paste without reading it, so clients holding the
+// current content can be told so without counting a view. It is empty when the paste cannot be
+// revalidated or read, in which case the caller reads it as usual.
+func (s *PasteService) GetContentHash(ctx context.Context, shortID string) (string, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsExpired() || paste.IsPending() || paste.IsBurned() || paste.ArchivedAt != nil || paste.IsQuarantined() || !revalidatable(paste) {
+		return "", nil
+	}
+	return paste.ContentHash, nil
+}
+
+// revalidatable reports whether clients may revalidate their copy of a paste instead of reading
+// it: every read of burn-after-read and view-limited pastes must be counted
+func revalidatable(paste *model.Paste) bool {
+	return !paste.BurnAfterRead && paste.MaxViews == 0
+}
+
+// claimRead checks that a paste can be read and counts the read: it claims burn-after-read
+// pastes and counts a view of the others. The returned paste reflects the read.
+func (s *PasteService) claimRead(ctx context.Context, shortID string) (*model.Paste, error) {
 	// Get paste metadata from MongoDB
 	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
 	if err != nil {
@@ -294,72 +826,246 @@ func (s *PasteService) GetPaste(ctx context.Context, shortID string) (*GetPasteR
 	// Check if paste has expired
 	if paste.IsExpired() {
 		// Clean up expired paste (best effort)
-		go s.deletePaste(context.Background(), shortID)
+		s.deletePasteAsync(shortID)
 		return nil, ErrPasteExpired
 	}
 
-	// Try to get content from cache first
-	content, found, err := s.cache.Get(ctx, shortID)
-	if err != nil {
-		// Log error but continue to fetch from storage
-		found = false
+	// Content of pending direct uploads is not available yet
+	if paste.IsPending() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsBurned() || paste.ViewsExhausted() {
+		return nil, ErrPasteBurned
+	}
+	if paste.ArchivedAt != nil {
+		return nil, ErrPasteArchived
+	}
+	if paste.IsQuarantined() {
+		return nil, ErrPasteQuarantined
 	}
 
-	// Cache miss - fetch from S3
-	if !found {
-		content, err = s.storage.GetContent(ctx, shortID)
+	// Only the reader that claims a burn-after-read paste gets its content
+	if paste.BurnAfterRead {
+		paste, err = s.pasteRepo.ClaimBurnAfterRead(ctx, shortID, BurnClaimGrace)
 		if err != nil {
-			if errors.Is(err, ErrContentNotFound) {
-				return nil, ErrPasteNotFound
+			if errors.Is(err, repository.ErrPasteNotFound) {
+				// Another reader claimed it first
+				return nil, ErrPasteBurned
 			}
-			return nil, fmt.Errorf("paste: failed to get content: %w", err)
+			return nil, fmt.Errorf("paste: failed to claim paste: %w", err)
 		}
+		paste.ViewCount = 1
+		return paste, nil
+	}
 
-		// Update cache (best effort, don't cache burn-after-read)
-		if !paste.BurnAfterRead {
-			cacheTTL := DefaultCacheTTL
-			if paste.ExpiresAt != nil {
-				ttl := time.Until(*paste.ExpiresAt)
-				if ttl > 0 && ttl < cacheTTL {
-					cacheTTL = ttl
-				}
+	// Count the view; concurrent readers cannot exceed a view limit
+	viewLimited := paste.MaxViews > 0
+	paste, err = s.pasteRepo.IncrementViews(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			if viewLimited {
+				// Other readers used up the last views
+				return nil, ErrPasteBurned
 			}
-			_ = s.cache.Set(ctx, shortID, content, cacheTTL)
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to count view: %w", err)
+	}
+	return paste, nil
+}
+
+// lookupContent returns the cached content of a paste claimed by claimRead, keeping popular
+// pastes cached for longer
+func (s *PasteService) lookupContent(ctx context.Context, paste *model.Paste) (string, bool) {
+	if paste.BurnAfterRead || !s.healthy(DependencyRedis) {
+		s.cache.RecordBypass()
+		return "", false
+	}
+
+	content, found, err := s.cache.Lookup(ctx, paste.ShortID)
+	if err != nil {
+		// Log error but continue to fetch from storage
+		return "", false
+	}
+	if found && s.cachePolicy.HotViews > 0 && paste.ViewCount == s.cachePolicy.HotViews {
+		// The paste just became popular: keep it cached for longer
+		if cacheTTL, ok := s.cachePolicy.TTL(paste, len(content), time.Now()); ok {
+			_ = s.cache.Refresh(ctx, paste.ShortID, cacheTTL)
 		}
 	}
+	return content, found
+}
+
+// fetchContent reads the content of a paste from storage and caches it. Concurrent misses on the
+// same paste share a single read, so a burst of readers on a paste that just went viral costs one
+// S3 GET. Burn-after-read pastes have a single reader and are read directly.
+func (s *PasteService) fetchContent(ctx context.Context, paste *model.Paste) (string, error) {
+	if !s.healthy(DependencyS3) {
+		return "", fmt.Errorf("%w: %s", ErrDependencyUnavailable, DependencyS3)
+	}
+	if paste.BurnAfterRead {
+		return s.storage.GetContent(ctx, paste.ShortID)
+	}
+
+	v, err, shared := s.fetches.Do(paste.ShortID, func() (interface{}, error) {
+		// The read is shared, so it must not fail because the reader that started it went away
+		ctx := context.WithoutCancel(ctx)
+		content, err := s.storage.GetContent(ctx, paste.ShortID)
+		if err != nil {
+			return "", err
+		}
+
+		// Update cache (best effort; oversized content is not cached, and large content only
+		// once read often enough)
+		s.cacheContent(ctx, paste, content)
+		return content, nil
+	})
+	if shared {
+		metrics.CacheSharedFetches.Inc()
+	}
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
 
-	// Handle burn after read
+// cacheContent caches content read from storage when the cache policy allows it (best effort)
+func (s *PasteService) cacheContent(ctx context.Context, paste *model.Paste, content string) {
+	if !s.healthy(DependencyRedis) {
+		return
+	}
+	if cacheTTL, ok := s.cachePolicy.TTL(paste, len(content), time.Now()); ok && s.admitToCache(ctx, paste, len(content)) {
+		_ = s.cache.Set(ctx, paste.ShortID, content, cacheTTL)
+	}
+}
+
+// releaseRead undoes claimRead when the content could not be read, so the view does not
+// count, and returns the error to report
+func (s *PasteService) releaseRead(ctx context.Context, paste *model.Paste, err error) error {
+	if errors.Is(err, ErrContentNotFound) {
+		return ErrPasteNotFound
+	}
 	if paste.BurnAfterRead {
+		if err := s.pasteRepo.ReleaseBurnAfterRead(ctx, paste.ShortID, paste.ExpiresAt); err != nil {
+			log.Printf("[PasteService.GetPaste] Failed to release claim on %s: %v", paste.ShortID, err)
+		}
+	} else if err := s.pasteRepo.UncountView(ctx, paste.ShortID); err != nil {
+		log.Printf("[PasteService.GetPaste] Failed to uncount view of %s: %v", paste.ShortID, err)
+	}
+	return fmt.Errorf("paste: failed to get content: %w", err)
+}
+
+// finishRead deletes burn-after-read pastes and pastes read for the last time, and announces the read
+func (s *PasteService) finishRead(ctx context.Context, paste *model.Paste) {
+	// Handle burn after read and the last allowed view
+	if paste.BurnAfterRead || paste.ViewsExhausted() {
 		// Delete the paste after reading (async to not block response)
-		go s.deletePaste(context.Background(), shortID)
+		s.deletePasteAsync(paste.ShortID)
 	}
 
-	// Build response
+	// Announce the read; without a bus the view is counted for trending right away
+	read := &model.PasteEvent{Type: model.PasteEventRead, ShortID: paste.ShortID, Paste: paste}
+	if s.events != nil {
+		s.events.Publish(read)
+	} else if err := s.recordView(ctx, read); err != nil {
+		log.Printf("[PasteService.GetPaste] Failed to record view of %s: %v", paste.ShortID, err)
+	}
+}
+
+// newGetPasteResponse builds the response of a read of paste with content
+func newGetPasteResponse(paste *model.Paste, content string) *GetPasteResponse {
 	response := &GetPasteResponse{
 		ShortID:    paste.ShortID,
 		Content:    content,
 		SyntaxType: paste.SyntaxType,
 		CreatedAt:  paste.CreatedAt.Format(time.RFC3339),
+		Binary:     paste.Binary,
+		Encrypted:  paste.Encrypted,
+		Size:       len(content),
+		Views:      paste.ViewCount,
+		MaxViews:   paste.MaxViews,
+		Delivery:   paste.Delivery,
+		Validation: paste.Validation,
+		OutputOf:   paste.OutputOf,
+		Producer:   paste.Producer,
+		ForkedFrom: paste.ForkedFrom,
+		SourceURL:  paste.SourceURL,
+
+		DerivedFrom: paste.DerivedFrom,
+		GroupID:     paste.GroupID,
+		Path:        paste.Path,
+		Live:        paste.Live,
+		Annotations: currentAnnotations(paste),
+
+		Title:       paste.Title,
+		Description: paste.Description,
+		Tags:        paste.Tags,
 	}
 
 	if paste.ExpiresAt != nil {
 		formatted := paste.ExpiresAt.Format(time.RFC3339)
 		response.ExpiresAt = &formatted
 	}
+	if revalidatable(paste) {
+		response.ContentHash = paste.ContentHash
+	}
+
+	return response
+}
+
+// GetTTL returns the remaining lifetime of a paste without reading its content,
+// so burn-after-read pastes are not consumed
+func (s *PasteService) GetTTL(ctx context.Context, shortID string) (*PasteTTLResponse, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+
+	now := time.Now()
+	response := &PasteTTLResponse{
+		ShortID:       paste.ShortID,
+		BurnAfterRead: paste.BurnAfterRead,
+		ServerTime:    now.UTC().Format(time.RFC3339),
+	}
+	if paste.MaxViews > 0 {
+		remaining := int64(paste.MaxViews) - paste.ViewCount
+		response.RemainingViews = &remaining
+	}
+	if paste.ExpiresAt != nil {
+		formatted := paste.ExpiresAt.Format(time.RFC3339)
+		remaining := int64(paste.ExpiresAt.Sub(now).Seconds())
+		response.ExpiresAt = &formatted
+		response.RemainingSeconds = &remaining
+	}
 
 	return response, nil
 }
 
-// DeletePaste removes a paste by its short ID
-func (s *PasteService) DeletePaste(ctx context.Context, shortID string) error {
+// DeletePaste removes a paste by its short ID, for the caller allowed to by canDelete
+func (s *PasteService) DeletePaste(ctx context.Context, shortID string, req *DeletePasteRequest) error {
+	ctx, span := tracing.Start(ctx, "PasteService.DeletePaste", trace.WithAttributes(attribute.String("short_id", shortID)))
+	defer span.End()
+
 	// Check if paste exists first
-	_, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
 	if err != nil {
 		if errors.Is(err, repository.ErrPasteNotFound) {
 			return ErrPasteNotFound
 		}
 		return fmt.Errorf("paste: failed to get paste: %w", err)
 	}
+	if !canDelete(paste, req) {
+		return ErrDeleteForbidden
+	}
 
 	// Delete from all layers
 	s.deletePaste(ctx, shortID)
@@ -373,6 +1079,35 @@ func (s *PasteService) deletePaste(ctx context.Context, shortID string) {
 	_ = s.cache.Delete(ctx, shortID)
 	// Delete from S3
 	_ = s.storage.DeleteContent(ctx, shortID)
+	// Delete previous versions
+	s.deleteRevisions(ctx, shortID)
 	// Delete from MongoDB
 	_ = s.pasteRepo.Delete(ctx, shortID)
-}
\ No newline at end of file
+
+	s.publish(model.PasteEventDeleted, shortID, nil)
+}
+
+// deletePasteAsync deletes a paste in the background, outliving the request
+func (s *PasteService) deletePasteAsync(shortID string) {
+	s.deletions.Add(1)
+	go func() {
+		defer s.deletions.Done()
+		s.deletePaste(context.Background(), shortID)
+	}()
+}
+
+// WaitDeletions waits for the deletions running in the background, or until ctx is done
+func (s *PasteService) WaitDeletions(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.deletions.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -2,9 +2,16 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/huylvt/gisty/internal/codec"
+	"github.com/huylvt/gisty/internal/model"
 	"github.com/huylvt/gisty/internal/repository"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -63,7 +70,42 @@ func setupPasteServiceTest(t *testing.T) (*PasteService, func()) {
 		t.Fatalf("Failed to create paste repository: %v", err)
 	}
 
+	revisionRepo, err := repository.NewRevisionRepository(db)
+	if err != nil {
+		redisClient.Close()
+		_ = client.Disconnect(ctx)
+		t.Fatalf("Failed to create revision repository: %v", err)
+	}
+
+	userRepo, err := repository.NewUserRepository(db)
+	if err != nil {
+		redisClient.Close()
+		_ = client.Disconnect(ctx)
+		t.Fatalf("Failed to create user repository: %v", err)
+	}
+	commentRepo, err := repository.NewCommentRepository(db)
+	if err != nil {
+		redisClient.Close()
+		_ = client.Disconnect(ctx)
+		t.Fatalf("Failed to create comment repository: %v", err)
+	}
+	notificationRepo, err := repository.NewNotificationRepository(db)
+	if err != nil {
+		redisClient.Close()
+		_ = client.Disconnect(ctx)
+		t.Fatalf("Failed to create notification repository: %v", err)
+	}
+	reportRepo, err := repository.NewReportRepository(db)
+	if err != nil {
+		redisClient.Close()
+		_ = client.Disconnect(ctx)
+		t.Fatalf("Failed to create report repository: %v", err)
+	}
+
 	pasteService := NewPasteService(kgs, storage, cache, pasteRepo, "http://localhost:8080")
+	pasteService.SetRevisionRepository(revisionRepo)
+	pasteService.SetComments(commentRepo, notificationRepo, userRepo)
+	pasteService.SetReports(reportRepo)
 
 	cleanup := func() {
 		_ = db.Drop(ctx)
@@ -195,6 +237,38 @@ func TestPasteService_CreatePaste_ContentTooLarge(t *testing.T) {
 	}
 }
 
+func TestPasteService_CreatePaste_Base64ContentSize(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// The size limit applies to content once decoded, not to its base64 encoding
+	content := strings.Repeat("abc", (MaxContentSize-1)/3)
+	resp, err := svc.CreatePaste(ctx, &CreatePasteRequest{
+		Content:  base64.StdEncoding.EncodeToString([]byte(content)),
+		Encoding: codec.Base64,
+	})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	got, err := svc.GetPaste(ctx, resp.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if len(got.Content) != len(content) {
+		t.Errorf("GetPaste() content length = %d, want %d", len(got.Content), len(content))
+	}
+
+	_, err = svc.CreatePaste(ctx, &CreatePasteRequest{
+		Content:  base64.StdEncoding.EncodeToString(make([]byte, MaxContentSize+1)),
+		Encoding: codec.Base64,
+	})
+	if err != ErrContentTooLarge {
+		t.Errorf("CreatePaste() should return ErrContentTooLarge, got %v", err)
+	}
+}
+
 func TestPasteService_CreatePaste_InvalidExpiresIn(t *testing.T) {
 	svc, cleanup := setupPasteServiceTest(t)
 	defer cleanup()
@@ -289,6 +363,8 @@ func TestPasteService_ParseExpiration(t *testing.T) {
 		{"2w", 14 * 24 * time.Hour, false, false, false},
 		{"1M", 30 * 24 * time.Hour, false, false, false},
 		{"2h30m", 2*time.Hour + 30*time.Minute, false, false, false}, // Go duration
+		{"3600", time.Hour, false, false, false},                     // seconds
+		{"0", 0, false, false, true},
 		{"invalid", 0, false, false, true},
 	}
 
@@ -335,6 +411,61 @@ func TestPasteService_ParseExpiration(t *testing.T) {
 	}
 }
 
+func TestGetPasteResponse_Truncate(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		maxBytes      int
+		wantContent   string
+		wantTruncated bool
+	}{
+		{"no cap", "hello world", 0, "hello world", false},
+		{"under cap", "hello", 10, "hello", false},
+		{"exact cap", "hello", 5, "hello", false},
+		{"over cap", "hello world", 5, "hello", true},
+		{"rune boundary", "xin chào", 7, "xin ch", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &GetPasteResponse{Content: tt.content, Size: len(tt.content)}
+			resp.Truncate(tt.maxBytes)
+
+			if resp.Content != tt.wantContent {
+				t.Errorf("Content = %q, want %q", resp.Content, tt.wantContent)
+			}
+			if resp.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", resp.Truncated, tt.wantTruncated)
+			}
+			if resp.Size != len(tt.content) {
+				t.Errorf("Size = %d, want the full size %d", resp.Size, len(tt.content))
+			}
+		})
+	}
+}
+
+func TestPasteService_PrepareContent_Encrypted(t *testing.T) {
+	svc := &PasteService{contentPolicy: ContentPolicy{MaxLineLength: 8, Action: ContentPolicyReject}}
+	ciphertext := "\x00" + strings.Repeat("A", 64)
+
+	// Ciphertext bypasses the content policy and is never highlighted
+	syntaxType, flags, err := svc.prepareContent(ciphertext, "go", true)
+	if err != nil {
+		t.Fatalf("prepareContent() error = %v", err)
+	}
+	if syntaxType != DefaultSyntaxType || flags != (ContentFlags{}) {
+		t.Errorf("prepareContent() = %q, %+v, want %q without flags", syntaxType, flags, DefaultSyntaxType)
+	}
+
+	// The size limit still applies
+	if _, _, err := svc.prepareContent(strings.Repeat("A", MaxContentSize+1), "", true); err != ErrContentTooLarge {
+		t.Errorf("prepareContent() error = %v, want %v", err, ErrContentTooLarge)
+	}
+	if _, _, err := svc.prepareContent(ciphertext, "", false); err == nil {
+		t.Error("prepareContent() of plain content should apply the policy")
+	}
+}
+
 func TestPasteService_GetPaste(t *testing.T) {
 	svc, cleanup := setupPasteServiceTest(t)
 	defer cleanup()
@@ -376,6 +507,42 @@ func TestPasteService_GetPaste(t *testing.T) {
 	}
 }
 
+func TestPasteService_GetContentHash(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "Tagged content", SyntaxType: "text"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	hash, err := svc.GetContentHash(ctx, createResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetContentHash() error = %v", err)
+	}
+	if want := ContentHash("Tagged content"); hash != want {
+		t.Errorf("GetContentHash() = %q, want %q", hash, want)
+	}
+	getResp, err := svc.GetPaste(ctx, createResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if getResp.ContentHash != hash {
+		t.Errorf("GetPaste().ContentHash = %q, want %q", getResp.ContentHash, hash)
+	}
+
+	// Every read of a burn-after-read paste counts, so it cannot be revalidated
+	burnResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "Secret", ExpiresIn: "burn"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	if hash, _ := svc.GetContentHash(ctx, burnResp.ShortID); hash != "" {
+		t.Errorf("GetContentHash() of burn-after-read paste = %q, want empty", hash)
+	}
+}
+
 func TestPasteService_GetPaste_NotFound(t *testing.T) {
 	svc, cleanup := setupPasteServiceTest(t)
 	defer cleanup()
@@ -419,11 +586,132 @@ func TestPasteService_GetPaste_BurnAfterRead(t *testing.T) {
 
 	// Second read should fail
 	_, err = svc.GetPaste(ctx, createResp.ShortID)
-	if err != ErrPasteNotFound {
+	if !errors.Is(err, ErrPasteNotFound) {
 		t.Errorf("Second GetPaste() should return ErrPasteNotFound, got %v", err)
 	}
 }
 
+func TestPasteService_GetPaste_BurnAfterRead_Concurrent(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{
+		Content:   "Secret content",
+		ExpiresIn: "burn",
+	})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	// Exactly one of the concurrent readers gets the content
+	const readers = 10
+	var wg sync.WaitGroup
+	var served atomic.Int32
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.GetPaste(ctx, createResp.ShortID)
+			switch {
+			case err == nil:
+				served.Add(1)
+			case !errors.Is(err, ErrPasteNotFound):
+				t.Errorf("GetPaste() error = %v, want ErrPasteNotFound", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := served.Load(); got != 1 {
+		t.Errorf("content served %d times, want 1", got)
+	}
+}
+
+func TestPasteService_GetPaste_MaxViews(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{
+		Content:  "Limited content",
+		MaxViews: 2,
+	})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	for want := int64(1); want <= 2; want++ {
+		getResp, err := svc.GetPaste(ctx, createResp.ShortID)
+		if err != nil {
+			t.Fatalf("GetPaste() #%d error = %v", want, err)
+		}
+		if getResp.Views != want {
+			t.Errorf("Views = %d, want %d", getResp.Views, want)
+		}
+	}
+
+	// Wait for async delete to complete
+	time.Sleep(100 * time.Millisecond)
+
+	_, err = svc.GetPaste(ctx, createResp.ShortID)
+	if !errors.Is(err, ErrPasteNotFound) {
+		t.Errorf("GetPaste() after max_views should return ErrPasteNotFound, got %v", err)
+	}
+}
+
+func TestPasteService_CreatePaste_MaxViewsNotCached(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	for name, req := range map[string]*CreatePasteRequest{
+		"max views":       {Content: "Limited content", MaxViews: 3},
+		"burn after read": {Content: "Secret content", ExpiresIn: "burn"},
+	} {
+		req.Delivery = &model.DeliveryHeaders{CacheControl: "public, max-age=300"}
+		createResp, err := svc.CreatePaste(ctx, req)
+		if err != nil {
+			t.Fatalf("CreatePaste(%s) error = %v", name, err)
+		}
+		paste, err := svc.pasteRepo.GetByShortID(ctx, createResp.ShortID)
+		if err != nil {
+			t.Fatalf("GetByShortID(%s) error = %v", name, err)
+		}
+		// Caches must not serve reads the view limit would not count
+		if paste.Delivery == nil || paste.Delivery.CacheControl != "no-store" {
+			t.Errorf("%s: Delivery = %+v, want Cache-Control no-store", name, paste.Delivery)
+		}
+	}
+}
+
+func TestPasteService_CreatePaste_InvalidMaxViews(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  *CreatePasteRequest
+	}{
+		{"negative", &CreatePasteRequest{Content: "Test", MaxViews: -1}},
+		{"too large", &CreatePasteRequest{Content: "Test", MaxViews: MaxViewsLimit + 1}},
+		{"burn with several views", &CreatePasteRequest{Content: "Test", ExpiresIn: "burn", MaxViews: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.CreatePaste(ctx, tt.req); err != ErrInvalidMaxViews {
+				t.Errorf("CreatePaste() should return ErrInvalidMaxViews, got %v", err)
+			}
+		})
+	}
+}
+
 func TestPasteService_GetPaste_CacheHit(t *testing.T) {
 	svc, cleanup := setupPasteServiceTest(t)
 	defer cleanup()
@@ -458,6 +746,47 @@ func TestPasteService_GetPaste_CacheHit(t *testing.T) {
 	}
 }
 
+func TestPasteService_GetPaste_ConcurrentMisses(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createReq := &CreatePasteRequest{
+		Content:    "Viral content",
+		SyntaxType: "text",
+	}
+	createResp, err := svc.CreatePaste(ctx, createReq)
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	_ = svc.cache.Delete(ctx, createResp.ShortID)
+
+	// Concurrent misses share the storage read and all get the content
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := svc.GetPaste(ctx, createResp.ShortID)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if resp.Content != createReq.Content {
+				errs <- errors.New("unexpected content: " + resp.Content)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("GetPaste() error = %v", err)
+	}
+}
+
 func TestPasteService_GetPaste_NoExpiration(t *testing.T) {
 	svc, cleanup := setupPasteServiceTest(t)
 	defer cleanup()
@@ -510,7 +839,7 @@ func TestPasteService_DeletePaste(t *testing.T) {
 	}
 
 	// Delete it
-	err = svc.DeletePaste(ctx, createResp.ShortID)
+	err = svc.DeletePaste(ctx, createResp.ShortID, &DeletePasteRequest{DeleteToken: createResp.DeleteToken})
 	if err != nil {
 		t.Fatalf("DeletePaste() error = %v", err)
 	}
@@ -528,7 +857,7 @@ func TestPasteService_DeletePaste_NotFound(t *testing.T) {
 
 	ctx := context.Background()
 
-	err := svc.DeletePaste(ctx, "nonexistent")
+	err := svc.DeletePaste(ctx, "nonexistent", nil)
 	if err != ErrPasteNotFound {
 		t.Errorf("DeletePaste() should return ErrPasteNotFound, got %v", err)
 	}
@@ -564,7 +893,7 @@ func TestPasteService_DeletePaste_DeletesFromAllLayers(t *testing.T) {
 	}
 
 	// Delete the paste
-	err = svc.DeletePaste(ctx, createResp.ShortID)
+	err = svc.DeletePaste(ctx, createResp.ShortID, &DeletePasteRequest{DeleteToken: createResp.DeleteToken})
 	if err != nil {
 		t.Fatalf("DeletePaste() error = %v", err)
 	}
@@ -587,3 +916,358 @@ func TestPasteService_DeletePaste_DeletesFromAllLayers(t *testing.T) {
 		t.Error("Expected MongoDB record to be deleted")
 	}
 }
+
+func TestPasteService_GetTTL(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "Countdown", ExpiresIn: "1h"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	ttl, err := svc.GetTTL(ctx, createResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetTTL() error = %v", err)
+	}
+	if ttl.RemainingSeconds == nil || *ttl.RemainingSeconds < 3590 || *ttl.RemainingSeconds > 3600 {
+		t.Errorf("RemainingSeconds = %v, want about 3600", ttl.RemainingSeconds)
+	}
+
+	// Asking for the TTL of a burn-after-read paste does not consume it
+	burnResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "Secret", ExpiresIn: "burn"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	ttl, err = svc.GetTTL(ctx, burnResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetTTL() error = %v", err)
+	}
+	if !ttl.BurnAfterRead || ttl.RemainingSeconds != nil {
+		t.Errorf("GetTTL() = %+v, want burn-after-read without remaining time", ttl)
+	}
+	if _, err := svc.GetPaste(ctx, burnResp.ShortID); err != nil {
+		t.Errorf("GetPaste() after GetTTL() error = %v", err)
+	}
+
+	if _, err := svc.GetTTL(ctx, "missing"); err != ErrPasteNotFound {
+		t.Errorf("GetTTL() on missing paste error = %v, want %v", err, ErrPasteNotFound)
+	}
+}
+
+func TestPasteService_UpdatePasteForbidden(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "original", SyntaxType: "plaintext"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	for name, caller := range map[string]*DeletePasteRequest{
+		"anonymous":         nil,
+		"wrong token":       {DeleteToken: createResp.DeleteToken + "x"},
+		"other session":     {OwnerID: "session:other"},
+		"other signed user": {UserID: "u2", OwnerID: "user:u2"},
+	} {
+		_, err := svc.UpdatePaste(ctx, createResp.ShortID, &UpdatePasteRequest{Content: "defaced", Caller: caller})
+		if err != ErrEditForbidden {
+			t.Errorf("UpdatePaste() as %s error = %v, want %v", name, err, ErrEditForbidden)
+		}
+	}
+
+	got, err := svc.GetPaste(ctx, createResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if got.Content != "original" {
+		t.Errorf("GetPaste() = %q, want the content unchanged", got.Content)
+	}
+}
+
+func TestPasteService_RedactPasteForbidden(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "user=admin\npassword=hunter2", SyntaxType: "plaintext"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	req := &RedactRequest{Patterns: []string{"hunter2"}}
+
+	// An anonymous caller must not be able to rewrite someone else's paste
+	if _, err := svc.RedactPaste(ctx, createResp.ShortID, req); err != ErrEditForbidden {
+		t.Errorf("RedactPaste() anonymously error = %v, want %v", err, ErrEditForbidden)
+	}
+	req.Caller = &DeletePasteRequest{OwnerID: "session:other"}
+	if _, err := svc.RedactPaste(ctx, createResp.ShortID, req); err != ErrEditForbidden {
+		t.Errorf("RedactPaste() as another session error = %v, want %v", err, ErrEditForbidden)
+	}
+
+	req.Caller = &DeletePasteRequest{Admin: true}
+	resp, err := svc.RedactPaste(ctx, createResp.ShortID, req)
+	if err != nil {
+		t.Fatalf("RedactPaste() as admin error = %v", err)
+	}
+	if resp.Redactions != 1 {
+		t.Errorf("Redactions = %d, want 1", resp.Redactions)
+	}
+}
+
+func TestPasteService_UpdatePaste(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	createResp, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "first", SyntaxType: "plaintext"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	// Warm the cache so the edit has to invalidate it
+	if _, err := svc.GetPaste(ctx, createResp.ShortID); err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+
+	creator := &DeletePasteRequest{DeleteToken: createResp.DeleteToken}
+	for i, content := range []string{"second", "third"} {
+		updateResp, err := svc.UpdatePaste(ctx, createResp.ShortID, &UpdatePasteRequest{Content: content, SyntaxType: "go", Caller: creator})
+		if err != nil {
+			t.Fatalf("UpdatePaste() error = %v", err)
+		}
+		if updateResp.Revision != i+1 {
+			t.Errorf("Revision = %d, want %d", updateResp.Revision, i+1)
+		}
+	}
+
+	got, err := svc.GetPaste(ctx, createResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if got.Content != "third" || got.SyntaxType != "go" {
+		t.Errorf("GetPaste() = %q (%s), want the latest edit", got.Content, got.SyntaxType)
+	}
+
+	list, err := svc.ListRevisions(ctx, createResp.ShortID)
+	if err != nil {
+		t.Fatalf("ListRevisions() error = %v", err)
+	}
+	if list.Revision != 2 || len(list.Revisions) != 2 {
+		t.Fatalf("ListRevisions() = %+v, want 2 revisions", list)
+	}
+	if list.Revisions[0].Revision != 2 || list.Revisions[1].SyntaxType != "plaintext" {
+		t.Errorf("ListRevisions() = %+v, want newest first", list.Revisions)
+	}
+
+	if _, err := svc.UpdatePaste(ctx, "missing", &UpdatePasteRequest{Content: "x", Caller: creator}); err != ErrPasteNotFound {
+		t.Errorf("UpdatePaste() on missing paste error = %v, want %v", err, ErrPasteNotFound)
+	}
+	if _, err := svc.UpdatePaste(ctx, createResp.ShortID, &UpdatePasteRequest{Content: "", Caller: creator}); err != ErrEmptyContent {
+		t.Errorf("UpdatePaste() with empty content error = %v, want %v", err, ErrEmptyContent)
+	}
+
+	// Deleting the paste removes its revisions
+	if err := svc.DeletePaste(ctx, createResp.ShortID, creator); err != nil {
+		t.Fatalf("DeletePaste() error = %v", err)
+	}
+	revisions, err := svc.revisionRepo.ListByShortID(ctx, createResp.ShortID)
+	if err != nil || len(revisions) != 0 {
+		t.Errorf("revisions after delete = %d (err %v), want 0", len(revisions), err)
+	}
+}
+
+func TestPasteService_ListOutputs(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	script, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "echo hello", SyntaxType: "bash"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	output, err := svc.CreatePaste(ctx, &CreatePasteRequest{
+		Content:  "hello",
+		OutputOf: script.ShortID,
+		Producer: &model.Producer{Kind: "ci", Name: "build 12"},
+	})
+	if err != nil {
+		t.Fatalf("CreatePaste() output error = %v", err)
+	}
+	// Neither listed: a listing must not consume a burn-after-read output, nor expose a private one
+	for _, req := range []*CreatePasteRequest{
+		{Content: "secret", OutputOf: script.ShortID, ExpiresIn: "burn"},
+		{Content: "private", OutputOf: script.ShortID, IsPrivate: true},
+	} {
+		if _, err := svc.CreatePaste(ctx, req); err != nil {
+			t.Fatalf("CreatePaste() error = %v", err)
+		}
+	}
+
+	list, err := svc.ListOutputs(ctx, script.ShortID, 0)
+	if err != nil {
+		t.Fatalf("ListOutputs() error = %v", err)
+	}
+	if len(list.Outputs) != 1 || list.Outputs[0].ShortID != output.ShortID {
+		t.Fatalf("ListOutputs() = %+v, want only %s", list.Outputs, output.ShortID)
+	}
+	if p := list.Outputs[0].Producer; p == nil || p.Kind != "ci" || p.Name != "build 12" {
+		t.Errorf("ListOutputs() producer = %+v, want ci build 12", p)
+	}
+
+	got, err := svc.GetPaste(ctx, output.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if got.OutputOf != script.ShortID {
+		t.Errorf("GetPaste().OutputOf = %q, want %q", got.OutputOf, script.ShortID)
+	}
+
+	if _, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "x", OutputOf: "missing"}); err != ErrInputNotFound {
+		t.Errorf("CreatePaste() with missing input error = %v, want %v", err, ErrInputNotFound)
+	}
+	if _, err := svc.ListOutputs(ctx, "missing", 0); err != ErrPasteNotFound {
+		t.Errorf("ListOutputs() on missing paste error = %v, want %v", err, ErrPasteNotFound)
+	}
+}
+
+func TestPasteService_GetPreview(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	plain, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "one\ntwo\nthree\n", Title: "Numbers"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	preview, err := svc.GetPreview(ctx, plain.ShortID, 2)
+	if err != nil {
+		t.Fatalf("GetPreview() error = %v", err)
+	}
+	if strings.Join(preview.Lines, ",") != "one,two" || preview.LineCount != 3 || !preview.Truncated || preview.Title != "Numbers" {
+		t.Errorf("GetPreview() = %+v", preview)
+	}
+
+	// Previews neither show nor consume a burn-after-read paste
+	burn, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "secret", ExpiresIn: "burn"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		preview, err := svc.GetPreview(ctx, burn.ShortID, 0)
+		if err != nil {
+			t.Fatalf("GetPreview() error = %v", err)
+		}
+		if !preview.BurnAfterRead || len(preview.Lines) != 0 {
+			t.Errorf("GetPreview() of burn-after-read paste = %+v, want no lines", preview)
+		}
+	}
+	got, err := svc.GetPaste(ctx, burn.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() after previews error = %v", err)
+	}
+	if got.Content != "secret" {
+		t.Errorf("GetPaste().Content = %q, want %q", got.Content, "secret")
+	}
+
+	if _, err := svc.GetPreview(ctx, "missing", 0); !errors.Is(err, ErrPasteNotFound) {
+		t.Errorf("GetPreview() on missing paste error = %v, want %v", err, ErrPasteNotFound)
+	}
+}
+
+func TestPasteService_ForkPaste(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	source, err := svc.CreatePaste(ctx, &CreatePasteRequest{
+		Content:    "print('hi')",
+		SyntaxType: "python",
+		Title:      "Greeting",
+		Tags:       []string{"demo"},
+		OwnerID:    "alice",
+	})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	fork, err := svc.ForkPaste(ctx, source.ShortID, &ForkPasteRequest{ExpiresIn: "1h", OwnerID: "bob"})
+	if err != nil {
+		t.Fatalf("ForkPaste() error = %v", err)
+	}
+	if fork.ShortID == source.ShortID || fork.ExpiresAt == nil {
+		t.Errorf("ForkPaste() = %+v, want a new paste expiring in 1h", fork)
+	}
+	got, err := svc.GetPaste(ctx, fork.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if got.Content != "print('hi')" || got.SyntaxType != "python" || got.Title != "Greeting" || got.ForkedFrom != source.ShortID {
+		t.Errorf("GetPaste() of fork = %+v", got)
+	}
+
+	// The content of burn-after-read pastes must not outlive their read
+	burn, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "secret", ExpiresIn: "burn"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	if _, err := svc.ForkPaste(ctx, burn.ShortID, &ForkPasteRequest{}); err != ErrNotForkable {
+		t.Errorf("ForkPaste() of burn-after-read paste error = %v, want %v", err, ErrNotForkable)
+	}
+
+	if _, err := svc.ForkPaste(ctx, "missing", &ForkPasteRequest{}); err != ErrPasteNotFound {
+		t.Errorf("ForkPaste() of missing paste error = %v, want %v", err, ErrPasteNotFound)
+	}
+}
+
+func TestPasteService_CreateSnippet(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	source, err := svc.CreatePaste(ctx, &CreatePasteRequest{
+		Content:    "one\ntwo\nthree\nfour\n",
+		SyntaxType: "text",
+		Title:      "Counting",
+	})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+
+	snippet, err := svc.CreateSnippet(ctx, source.ShortID, &SnippetRequest{StartLine: 2, EndLine: 3})
+	if err != nil {
+		t.Fatalf("CreateSnippet() error = %v", err)
+	}
+	got, err := svc.GetPaste(ctx, snippet.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	want := model.DerivedFrom{ShortID: source.ShortID, StartLine: 2, EndLine: 3}
+	if got.Content != "two\nthree" || got.Title != "Counting" || got.DerivedFrom == nil || *got.DerivedFrom != want {
+		t.Errorf("GetPaste() of snippet = %+v", got)
+	}
+
+	for _, req := range []SnippetRequest{{StartLine: 0}, {StartLine: 3, EndLine: 2}, {StartLine: 4, EndLine: 9}} {
+		if _, err := svc.CreateSnippet(ctx, source.ShortID, &req); err != ErrInvalidLineRange {
+			t.Errorf("CreateSnippet(%+v) error = %v, want %v", req, err, ErrInvalidLineRange)
+		}
+	}
+
+	burn, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "secret", ExpiresIn: "burn"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	if _, err := svc.CreateSnippet(ctx, burn.ShortID, &SnippetRequest{StartLine: 1}); err != ErrNotSnippable {
+		t.Errorf("CreateSnippet() of burn-after-read paste error = %v, want %v", err, ErrNotSnippable)
+	}
+}
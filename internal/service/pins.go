@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+// MaxPinnedPastes caps the pastes a user pins to the top of their profile
+const MaxPinnedPastes = 6
+
+var (
+	// ErrNotPinnable is returned when pinning a paste the user did not create while signed in,
+	// or one their profile does not list: private, burn-after-read or view-limited
+	ErrNotPinnable = errors.New("paste: paste cannot be pinned")
+	// ErrTooManyPins is returned when pinning a paste while MaxPinnedPastes are pinned
+	ErrTooManyPins = errors.New("paste: too many pinned pastes")
+)
+
+// PinsResponse lists the pastes pinned to the top of a user's profile, in the order they were pinned
+type PinsResponse struct {
+	Pinned []PasteSummary `json:"pinned"`
+}
+
+// PinPaste pins one of the user's public pastes to the top of their profile. Pinning a pinned
+// paste again changes nothing.
+func (s *PasteService) PinPaste(ctx context.Context, userID, shortID string) (*PinsResponse, error) {
+	if userID == "" {
+		return nil, ErrSignInRequired
+	}
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if paste.UserID == nil || *paste.UserID != userID || paste.IsPrivate || paste.BurnAfterRead || paste.MaxViews > 0 {
+		return nil, ErrNotPinnable
+	}
+
+	if paste.PinnedAt == nil {
+		now := time.Now()
+		if err := s.pasteRepo.SetPinned(ctx, shortID, userID, &now); err != nil {
+			return nil, fmt.Errorf("paste: failed to pin paste: %w", err)
+		}
+		// Counting after pinning keeps concurrent pins from going over the limit together
+		count, err := s.pasteRepo.CountPinnedByUser(ctx, userID)
+		if err == nil && count > MaxPinnedPastes {
+			err = ErrTooManyPins
+		}
+		if err != nil {
+			if unpinErr := s.pasteRepo.SetPinned(ctx, shortID, userID, nil); unpinErr != nil {
+				log.Printf("[PasteService.PinPaste] Failed to unpin %s: %v", shortID, unpinErr)
+			}
+			if errors.Is(err, ErrTooManyPins) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("paste: failed to count pinned pastes: %w", err)
+		}
+	}
+
+	return s.ListPins(ctx, userID)
+}
+
+// UnpinPaste unpins one of the user's pastes from their profile. Unpinning a paste that is not
+// pinned changes nothing.
+func (s *PasteService) UnpinPaste(ctx context.Context, userID, shortID string) error {
+	if userID == "" {
+		return ErrSignInRequired
+	}
+	if err := s.pasteRepo.SetPinned(ctx, shortID, userID, nil); err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			// Pastes of other users are not told apart from missing ones
+			return ErrPasteNotFound
+		}
+		return fmt.Errorf("paste: failed to unpin paste: %w", err)
+	}
+	return nil
+}
+
+// ListPins returns the pastes the user pinned to their profile
+func (s *PasteService) ListPins(ctx context.Context, userID string) (*PinsResponse, error) {
+	if userID == "" {
+		return nil, ErrSignInRequired
+	}
+	pastes, err := s.pasteRepo.ListPinnedByUser(ctx, userID, MaxPinnedPastes)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list pinned pastes: %w", err)
+	}
+
+	response := &PinsResponse{Pinned: make([]PasteSummary, 0, len(pastes))}
+	for _, paste := range pastes {
+		summary := toPasteSummary(paste)
+		summary.SourceIPHash = ""
+		response.Pinned = append(response.Pinned, summary)
+	}
+	return response, nil
+}
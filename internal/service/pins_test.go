@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func TestPasteService_PinPaste(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := &model.User{ID: "pin-user-" + time.Now().Format("150405.000000"), Username: "pinner"}
+
+	create := func(req *CreatePasteRequest) string {
+		t.Helper()
+		req.UserID = user.ID
+		created, err := svc.CreatePaste(ctx, req)
+		if err != nil {
+			t.Fatalf("CreatePaste() error = %v", err)
+		}
+		return created.ShortID
+	}
+	older := create(&CreatePasteRequest{Content: "older"})
+	newer := create(&CreatePasteRequest{Content: "newer"})
+
+	pins, err := svc.PinPaste(ctx, user.ID, older)
+	if err != nil || len(pins.Pinned) != 1 || pins.Pinned[0].ShortID != older {
+		t.Fatalf("PinPaste() = %+v, %v, want the paste pinned", pins, err)
+	}
+	if pins, err := svc.PinPaste(ctx, user.ID, older); err != nil || len(pins.Pinned) != 1 {
+		t.Errorf("PinPaste() again = %+v, %v, want no change", pins, err)
+	}
+
+	// The pinned paste leads the profile, and is not repeated in its pages
+	page, err := svc.ListPublicUserPastes(ctx, user, repository.PageCursor{}, 10)
+	if err != nil || len(page.Pinned) != 1 || page.Pinned[0].ShortID != older || len(page.Pastes) != 1 || page.Pastes[0].ShortID != newer {
+		t.Errorf("ListPublicUserPastes() = %+v, %v, want %s pinned above %s", page, err, older, newer)
+	}
+
+	if _, err := svc.PinPaste(ctx, user.ID, create(&CreatePasteRequest{Content: "private", IsPrivate: true})); err != ErrNotPinnable {
+		t.Errorf("PinPaste() of a private paste error = %v, want %v", err, ErrNotPinnable)
+	}
+	if _, err := svc.PinPaste(ctx, "someone-else", newer); err != ErrNotPinnable {
+		t.Errorf("PinPaste() of another user's paste error = %v, want %v", err, ErrNotPinnable)
+	}
+	for i := 1; i < MaxPinnedPastes; i++ {
+		if _, err := svc.PinPaste(ctx, user.ID, create(&CreatePasteRequest{Content: "pinned"})); err != nil {
+			t.Fatalf("PinPaste() %d error = %v", i, err)
+		}
+	}
+	if _, err := svc.PinPaste(ctx, user.ID, newer); err != ErrTooManyPins {
+		t.Errorf("PinPaste() over the limit error = %v, want %v", err, ErrTooManyPins)
+	}
+
+	if err := svc.UnpinPaste(ctx, user.ID, older); err != nil {
+		t.Fatalf("UnpinPaste() error = %v", err)
+	}
+	if pins, err := svc.ListPins(ctx, user.ID); err != nil || len(pins.Pinned) != MaxPinnedPastes-1 {
+		t.Errorf("ListPins() = %+v, %v, want %d pins left", pins, err, MaxPinnedPastes-1)
+	}
+	if err := svc.UnpinPaste(ctx, "someone-else", newer); err != ErrPasteNotFound {
+		t.Errorf("UnpinPaste() of another user's paste error = %v, want %v", err, ErrPasteNotFound)
+	}
+}
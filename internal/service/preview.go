@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// DefaultPreviewLines is the number of lines in a preview when none is requested
+	DefaultPreviewLines = 10
+	// MaxPreviewLines caps the number of lines in a preview
+	MaxPreviewLines = 50
+	// MaxPreviewLineLength is the longest preview line in bytes; longer lines are cut
+	MaxPreviewLineLength = 200
+)
+
+// PreviewResponse is the metadata and first lines of a paste, served without counting a view
+type PreviewResponse struct {
+	ShortID       string  `json:"short_id" example:"xK9a2B"`
+	Title         string  `json:"title,omitempty" example:"Deploy script"`
+	SyntaxType    string  `json:"syntax_type" example:"go"`
+	CreatedAt     string  `json:"created_at" example:"2024-01-15T14:00:00Z"`
+	ExpiresAt     *string `json:"expires_at,omitempty" example:"2024-01-16T14:00:00Z"`
+	BurnAfterRead bool    `json:"burn_after_read" example:"false"`
+	MaxViews      int     `json:"max_views,omitempty" example:"0"`
+	Encrypted     bool    `json:"is_encrypted" example:"false"`
+	Binary        bool    `json:"binary,omitempty" example:"false"`
+	// Lines holds the first lines of the content; it is omitted for burn-after-read, view-limited,
+	// encrypted and binary pastes, whose content a preview must not reveal, and archived pastes
+	Lines     []string `json:"lines,omitempty" example:"package main,,func main() {"`
+	LineCount int      `json:"line_count,omitempty" example:"42"` // lines in the whole content
+	// Truncated is set when lines were left out or cut
+	Truncated bool `json:"truncated" example:"true"`
+}
+
+// GetPreview returns the metadata and first lines of a paste for link unfurlers and wary readers.
+// It never counts a view, claims a burn-after-read paste or publishes a read event.
+func (s *PasteService) GetPreview(ctx context.Context, shortID string, lines int) (*PreviewResponse, error) {
+	if lines <= 0 {
+		lines = DefaultPreviewLines
+	}
+	lines = min(lines, MaxPreviewLines)
+
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if paste.IsPending() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsBurned() || paste.ViewsExhausted() {
+		return nil, ErrPasteBurned
+	}
+
+	response := &PreviewResponse{
+		ShortID:       paste.ShortID,
+		Title:         paste.Title,
+		SyntaxType:    paste.SyntaxType,
+		CreatedAt:     paste.CreatedAt.Format(time.RFC3339),
+		BurnAfterRead: paste.BurnAfterRead,
+		MaxViews:      paste.MaxViews,
+		Encrypted:     paste.Encrypted,
+		Binary:        paste.Binary,
+	}
+	if paste.ExpiresAt != nil {
+		formatted := paste.ExpiresAt.Format(time.RFC3339)
+		response.ExpiresAt = &formatted
+	}
+	if paste.BurnAfterRead || paste.MaxViews > 0 || paste.Encrypted || paste.Binary || paste.ArchivedAt != nil || paste.IsQuarantined() {
+		return response, nil
+	}
+
+	var content string
+	found := false
+	if s.healthy(DependencyRedis) {
+		content, found, err = s.cache.Lookup(ctx, shortID)
+	}
+	if err != nil || !found {
+		content, err = s.storage.GetContent(ctx, shortID)
+		if err != nil {
+			if errors.Is(err, ErrContentNotFound) {
+				return nil, ErrPasteNotFound
+			}
+			return nil, fmt.Errorf("paste: failed to get content: %w", err)
+		}
+	}
+
+	response.Lines, response.LineCount, response.Truncated = previewLines(content, lines)
+	return response, nil
+}
+
+// previewLines returns the first n lines of content, each cut to MaxPreviewLineLength bytes,
+// the number of lines in content and whether anything was left out
+func previewLines(content string, n int) ([]string, int, bool) {
+	all := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	truncated := len(all) > n
+	lines := all[:min(n, len(all))]
+
+	preview := make([]string, len(lines))
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if len(line) > MaxPreviewLineLength {
+			// Cut on a rune boundary
+			cut := MaxPreviewLineLength
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			line = line[:cut]
+			truncated = true
+		}
+		preview[i] = line
+	}
+	return preview, len(all), truncated
+}
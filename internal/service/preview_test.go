@@ -0,0 +1,39 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreviewLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		n             int
+		wantLines     []string
+		wantCount     int
+		wantTruncated bool
+	}{
+		{"short", "a\nb\n", 10, []string{"a", "b"}, 2, false},
+		{"cut to n lines", "a\nb\nc\nd", 2, []string{"a", "b"}, 4, true},
+		{"crlf", "a\r\nb\r\n", 10, []string{"a", "b"}, 2, false},
+		{"long line", strings.Repeat("x", MaxPreviewLineLength+5), 10, []string{strings.Repeat("x", MaxPreviewLineLength)}, 1, true},
+		// A multi-byte rune straddling the limit is dropped whole
+		{"rune boundary", strings.Repeat("x", MaxPreviewLineLength-1) + "é", 10, []string{strings.Repeat("x", MaxPreviewLineLength-1)}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, count, truncated := previewLines(tt.content, tt.n)
+			if strings.Join(lines, "|") != strings.Join(tt.wantLines, "|") {
+				t.Errorf("lines = %q, want %q", lines, tt.wantLines)
+			}
+			if count != tt.wantCount {
+				t.Errorf("count = %d, want %d", count, tt.wantCount)
+			}
+			if truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
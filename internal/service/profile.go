@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// DefaultProfileLimit is the number of pastes of a profile page when no limit is given
+	DefaultProfileLimit = 30
+	// MaxProfileLimit caps the number of pastes of a profile page
+	MaxProfileLimit = 100
+	// gravatarURL serves the avatar of a Gravatar hash, an identicon for emails without one
+	gravatarURL = "https://www.gravatar.com/avatar/%s?s=160&d=identicon"
+)
+
+// ErrProfileNotFound is returned for a username no user has, or whose user hid their profile;
+// the two are not told apart
+var ErrProfileNotFound = errors.New("paste: profile not found")
+
+// ProfileTotals counts the pastes listed on a profile and their views
+type ProfileTotals struct {
+	Pastes int64 `json:"pastes" example:"42"`
+	Views  int64 `json:"views" example:"1337"`
+}
+
+// ProfileResponse represents the public profile of a user with a page of their public pastes,
+// newest first. Private, burn-after-read and view-limited pastes are never listed.
+type ProfileResponse struct {
+	Username string `json:"username" example:"octocat"`
+	Name     string `json:"name,omitempty" example:"The Octocat"`
+	// GravatarHash is the SHA-256 hash of the user's email, for Gravatar; the email stays private
+	GravatarHash string        `json:"gravatar_hash" example:"84059b07d4be67b806386c0aad8070a23f18836bbaae342275dc0a83414c32ee"`
+	AvatarURL    string        `json:"avatar_url" example:"https://www.gravatar.com/avatar/84059b07d4be67b806386c0aad8070a23f18836bbaae342275dc0a83414c32ee?s=160&d=identicon"`
+	JoinedAt     string        `json:"joined_at" example:"2024-01-15T14:00:00Z"`
+	Totals       ProfileTotals `json:"totals"`
+	// Pinned lists the pastes the user pinned to the top of the profile, on its first page only
+	Pinned []PasteSummary `json:"pinned,omitempty"`
+	Pastes []PasteSummary `json:"pastes"`
+	// Next is the before cursor of the next page; empty on the last page
+	Next string `json:"next,omitempty" example:"2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"`
+}
+
+// GetProfile returns the public profile of a user, with up to limit of their public pastes
+// after the cursor when it is not zero. Hidden profiles are not found.
+func (s *PasteService) GetProfile(ctx context.Context, user *model.User, after repository.PageCursor, limit int) (*ProfileResponse, error) {
+	page, err := s.ListPublicUserPastes(ctx, user, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	pastes, views, err := s.pasteRepo.CountPublicByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to count pastes: %w", err)
+	}
+
+	hash := GravatarHash(user.Email)
+	if user.Email == "" {
+		// Without an email, the user ID still gives each user their own identicon
+		hash = GravatarHash(user.ID)
+	}
+	return &ProfileResponse{
+		Username:     user.Username,
+		Name:         user.Name,
+		GravatarHash: hash,
+		AvatarURL:    fmt.Sprintf(gravatarURL, hash),
+		JoinedAt:     user.CreatedAt.UTC().Format(time.RFC3339),
+		Totals:       ProfileTotals{Pastes: pastes, Views: views},
+		Pinned:       page.Pinned,
+		Pastes:       page.Pastes,
+		Next:         page.Next,
+	}, nil
+}
+
+// ListPublicUserPastes returns up to limit of the pastes listed on a user's public profile,
+// newest first, starting after the cursor when it is not zero. The first page also lists the
+// pinned pastes, which the pages leave out.
+func (s *PasteService) ListPublicUserPastes(ctx context.Context, user *model.User, after repository.PageCursor, limit int) (*UserPastesResponse, error) {
+	if user.ProfileHidden || user.Username == "" {
+		return nil, ErrProfileNotFound
+	}
+	if limit <= 0 {
+		limit = DefaultProfileLimit
+	}
+	limit = min(limit, MaxProfileLimit)
+
+	pastes, err := s.pasteRepo.ListPublicByUser(ctx, user.ID, after, int64(limit)+1)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list pastes: %w", err)
+	}
+
+	response := &UserPastesResponse{Pastes: make([]PasteSummary, 0, min(len(pastes), limit))}
+	if after.IsZero() {
+		pins, err := s.ListPins(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		response.Pinned = pins.Pinned
+	}
+	if len(pastes) > limit {
+		pastes = pastes[:limit]
+		response.Next = pasteCursor(pastes[limit-1]).String()
+	}
+	for _, paste := range pastes {
+		summary := toPasteSummary(paste)
+		summary.SourceIPHash = ""
+		response.Pastes = append(response.Pastes, summary)
+	}
+	return response, nil
+}
+
+// GravatarHash returns the Gravatar hash of an email: the hex SHA-256 of its trimmed, lowercase form
+func GravatarHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
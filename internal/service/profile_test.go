@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func TestGravatarHash(t *testing.T) {
+	// Gravatar hashes the trimmed, lowercase email
+	want := "84059b07d4be67b806386c0aad8070a23f18836bbaae342275dc0a83414c32ee"
+	for _, email := range []string{"myemailaddress@example.com", " MyEmailAddress@example.com "} {
+		if got := GravatarHash(email); got != want {
+			t.Errorf("GravatarHash(%q) = %s, want %s", email, got, want)
+		}
+	}
+}
+
+func TestPasteService_GetProfile(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	user := &model.User{ID: "profile-user-" + time.Now().Format("150405.000000"), Username: "octocat", Email: "cat@example.com", CreatedAt: time.Now()}
+
+	var shortIDs []string
+	for _, req := range []*CreatePasteRequest{
+		{Content: "first", UserID: user.ID},
+		{Content: "second", UserID: user.ID},
+		{Content: "private", UserID: user.ID, IsPrivate: true},
+		{Content: "burn", UserID: user.ID, ExpiresIn: "burn"},
+	} {
+		created, err := svc.CreatePaste(ctx, req)
+		if err != nil {
+			t.Fatalf("CreatePaste() error = %v", err)
+		}
+		shortIDs = append(shortIDs, created.ShortID)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	profile, err := svc.GetProfile(ctx, user, repository.PageCursor{}, 1)
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if profile.Totals.Pastes != 2 || len(profile.Pastes) != 1 || profile.Pastes[0].ShortID != shortIDs[1] || profile.Next == "" {
+		t.Errorf("GetProfile() = %+v, want the newest of 2 public pastes and a next page", profile)
+	}
+	if profile.GravatarHash != GravatarHash(user.Email) {
+		t.Errorf("GetProfile() gravatar hash = %s, want the email's", profile.GravatarHash)
+	}
+
+	next, err := repository.ParsePageCursor(profile.Next)
+	if err != nil {
+		t.Fatalf("next cursor %q: %v", profile.Next, err)
+	}
+	page, err := svc.ListPublicUserPastes(ctx, user, next, 10)
+	if err != nil || len(page.Pastes) != 1 || page.Pastes[0].ShortID != shortIDs[0] || page.Next != "" {
+		t.Errorf("ListPublicUserPastes() = %+v, %v, want the oldest paste on the last page", page, err)
+	}
+
+	user.ProfileHidden = true
+	if _, err := svc.GetProfile(ctx, user, repository.PageCursor{}, 0); err != ErrProfileNotFound {
+		t.Errorf("GetProfile() of a hidden profile error = %v, want %v", err, ErrProfileNotFound)
+	}
+}
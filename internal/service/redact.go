@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// DefaultRedactionMarker replaces redacted lines and matches
+	DefaultRedactionMarker = "[REDACTED]"
+	// MaxRedactionMarkerLength is the longest accepted custom marker
+	MaxRedactionMarkerLength = 64
+	// MaxRedactionPatterns is the most regular expressions accepted in one request
+	MaxRedactionPatterns = 20
+	// MaxRedactionPatternLength is the longest accepted regular expression
+	MaxRedactionPatternLength = 256
+)
+
+var (
+	// ErrInvalidRedaction is returned when a redaction request has no valid line range or pattern
+	ErrInvalidRedaction = errors.New("paste: invalid redaction")
+	// ErrNotRedactable is returned for content the server cannot read: encrypted and binary pastes
+	ErrNotRedactable = errors.New("paste: content cannot be redacted")
+)
+
+// LineRange is an inclusive range of 1-based line numbers; End 0 means the single line Start
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end,omitempty"`
+}
+
+// RedactRequest represents the request to redact a paste and its history
+type RedactRequest struct {
+	Lines    []LineRange `json:"lines"`    // lines of the current content; identical lines in revisions are redacted too
+	Patterns []string    `json:"patterns"` // regular expressions (RE2 syntax) redacted in every version
+	Marker   string      `json:"marker"`   // replacement text, DefaultRedactionMarker when empty
+
+	// Caller proves the right to redact as for deleting the paste, set by the handler
+	Caller *DeletePasteRequest `json:"-"`
+}
+
+// RedactResponse represents the response after redacting a paste
+type RedactResponse struct {
+	ShortID            string `json:"short_id"`
+	Redactions         int    `json:"redactions"`          // replaced lines and matches across all versions
+	RevisionsRewritten int    `json:"revisions_rewritten"` // previous versions whose content changed
+	RedactedAt         string `json:"redacted_at"`
+}
+
+// redaction is a validated redaction request
+type redaction struct {
+	lines    []LineRange
+	patterns []*regexp.Regexp
+	marker   string
+}
+
+// RedactPaste replaces line ranges and pattern matches with a marker in the current content and
+// every revision of a paste, for secrets pasted by mistake. Unlike an edit it keeps no copy of
+// the previous content, and cached renderings of it are dropped. Only the creator of the paste
+// or an admin may redact it.
+func (s *PasteService) RedactPaste(ctx context.Context, shortID string, req *RedactRequest) (*RedactResponse, error) {
+	r, err := newRedaction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if !canDelete(paste, req.Caller) {
+		return nil, ErrEditForbidden
+	}
+	if paste.Encrypted || paste.Binary {
+		return nil, ErrNotRedactable
+	}
+
+	content, err := s.storage.GetContent(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, ErrContentNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get content: %w", err)
+	}
+
+	// Lines are addressed in the current content; their text identifies them in older versions
+	lineTexts, err := r.selectLines(content)
+	if err != nil {
+		return nil, err
+	}
+	redacted, total := r.apply(content, lineTexts)
+
+	now := time.Now()
+	response := &RedactResponse{ShortID: shortID, RedactedAt: now.Format(time.RFC3339)}
+
+	// Rewrite history first, so a failure leaves the secret at most in the current content,
+	// where a retry finds it again
+	if s.revisionRepo != nil {
+		revisions, err := s.revisionRepo.ListByShortID(ctx, shortID)
+		if err != nil {
+			return nil, fmt.Errorf("paste: failed to list revisions: %w", err)
+		}
+		for _, rev := range revisions {
+			if rev.Binary {
+				continue
+			}
+			old, err := s.storage.GetRevisionContent(ctx, shortID, rev.Revision)
+			if err != nil {
+				if errors.Is(err, ErrContentNotFound) {
+					continue
+				}
+				return nil, fmt.Errorf("paste: failed to get revision %d: %w", rev.Revision, err)
+			}
+			updated, n := r.apply(old, lineTexts)
+			if n == 0 {
+				continue
+			}
+			if err := s.storage.ReplaceRevisionContent(ctx, shortID, rev.Revision, updated); err != nil {
+				return nil, fmt.Errorf("paste: failed to save revision %d: %w", rev.Revision, err)
+			}
+			s.renderCache.Forget(old)
+			total += n
+			response.RevisionsRewritten++
+		}
+	}
+
+	if redacted != content {
+		if err := s.storage.SaveContent(ctx, shortID, redacted); err != nil {
+			log.Printf("[PasteService.RedactPaste] Error saving to S3: %v", err)
+			return nil, fmt.Errorf("paste: failed to save content: %w", err)
+		}
+		err = s.pasteRepo.UpdateContent(ctx, shortID, paste.Revision, bson.M{
+			"updated_at":   now,
+			"redacted_at":  now,
+			"content_hash": ContentHash(redacted),
+		})
+		if err != nil {
+			if errors.Is(err, repository.ErrPasteNotFound) {
+				// Deleted or edited since we read it
+				return nil, ErrEditConflict
+			}
+			return nil, fmt.Errorf("paste: failed to update record: %w", err)
+		}
+		s.renderCache.Forget(content)
+	}
+	response.Redactions = total
+
+	// Readers must not see the secret from cache
+	_ = s.cache.Delete(ctx, shortID)
+
+	paste.UpdatedAt = &now
+	paste.RedactedAt = &now
+	s.publish(model.PasteEventUpdated, shortID, paste)
+
+	log.Printf("[PasteService.RedactPaste] Success: short_id=%s, redactions=%d, revisions=%d",
+		shortID, total, response.RevisionsRewritten)
+	return response, nil
+}
+
+// newRedaction validates a redaction request and compiles its patterns
+func newRedaction(req *RedactRequest) (*redaction, error) {
+	if len(req.Lines) == 0 && len(req.Patterns) == 0 {
+		return nil, ErrInvalidRedaction
+	}
+	if len(req.Patterns) > MaxRedactionPatterns {
+		return nil, ErrInvalidRedaction
+	}
+
+	r := &redaction{lines: req.Lines, marker: req.Marker}
+	if r.marker == "" {
+		r.marker = DefaultRedactionMarker
+	}
+	if len(r.marker) > MaxRedactionMarkerLength || strings.ContainsAny(r.marker, "\r\n") {
+		return nil, ErrInvalidRedaction
+	}
+
+	for _, lr := range req.Lines {
+		if lr.Start < 1 || (lr.End != 0 && lr.End < lr.Start) {
+			return nil, ErrInvalidRedaction
+		}
+	}
+	for _, pattern := range req.Patterns {
+		if pattern == "" || len(pattern) > MaxRedactionPatternLength {
+			return nil, ErrInvalidRedaction
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil || re.MatchString("") {
+			// A pattern matching the empty string would insert markers everywhere
+			return nil, ErrInvalidRedaction
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// selectLines returns the non-blank text of the requested lines of content.
+// It fails when a range goes past the last line.
+func (r *redaction) selectLines(content string) (map[string]bool, error) {
+	lines := strings.Split(content, "\n")
+	texts := make(map[string]bool)
+	for _, lr := range r.lines {
+		end := lr.End
+		if end == 0 {
+			end = lr.Start
+		}
+		if end > len(lines) {
+			return nil, ErrInvalidRedaction
+		}
+		for _, line := range lines[lr.Start-1 : end] {
+			if strings.TrimSpace(line) != "" {
+				texts[line] = true
+			}
+		}
+	}
+	return texts, nil
+}
+
+// apply replaces the lines whose text is in lineTexts, then every pattern match, with the marker.
+// It returns the redacted content and the number of replacements.
+func (r *redaction) apply(content string, lineTexts map[string]bool) (string, int) {
+	count := 0
+	if len(lineTexts) > 0 {
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			if lineTexts[line] {
+				lines[i] = r.marker
+				count++
+			}
+		}
+		content = strings.Join(lines, "\n")
+	}
+
+	for _, re := range r.patterns {
+		matches := re.FindAllStringIndex(content, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		count += len(matches)
+		content = re.ReplaceAllLiteralString(content, r.marker)
+	}
+
+	return content, count
+}
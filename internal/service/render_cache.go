@@ -0,0 +1,147 @@
+package service
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+const (
+	// DefaultRenderCacheMaxBytes is the default memory budget of the render cache (64MB)
+	DefaultRenderCacheMaxBytes = 64 * 1024 * 1024
+)
+
+// RenderKey identifies a rendered artifact by the hash of its source content and the render options.
+// Pastes with identical content (forks, duplicates) share the same entries.
+type RenderKey struct {
+	ContentHash string
+	Options     string
+}
+
+// renderEntry is a cached rendered artifact
+type renderEntry struct {
+	key   RenderKey
+	value string
+}
+
+// RenderCache is an in-process, size-bounded LRU cache of rendered content and transforms
+type RenderCache struct {
+	mu        sync.Mutex
+	maxBytes  int64
+	usedBytes int64
+	ll        *list.List
+	items     map[RenderKey]*list.Element
+}
+
+// NewRenderCache creates a new RenderCache holding at most maxBytes of rendered output
+func NewRenderCache(maxBytes int64) *RenderCache {
+	if maxBytes <= 0 {
+		maxBytes = DefaultRenderCacheMaxBytes
+	}
+
+	return &RenderCache{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[RenderKey]*list.Element),
+	}
+}
+
+// ContentHash returns the hex-encoded SHA-256 of the content
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Get returns the cached value for the key
+func (c *RenderCache) Get(key RenderKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	c.ll.MoveToFront(elem)
+	return elem.Value.(*renderEntry).value, true
+}
+
+// Set stores a value, evicting least recently used entries until it fits.
+// Values larger than the whole budget are not cached.
+func (c *RenderCache) Set(key RenderKey, value string) {
+	size := int64(len(value))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*renderEntry)
+		c.usedBytes += size - int64(len(entry.value))
+		entry.value = value
+		c.ll.MoveToFront(elem)
+	} else {
+		c.items[key] = c.ll.PushFront(&renderEntry{key: key, value: value})
+		c.usedBytes += size
+	}
+
+	for c.usedBytes > c.maxBytes {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		entry := oldest.Value.(*renderEntry)
+		c.ll.Remove(oldest)
+		delete(c.items, entry.key)
+		c.usedBytes -= int64(len(entry.value))
+	}
+}
+
+// GetOrRender returns the cached rendering of content with the given options,
+// calling render and caching its result on a miss
+func (c *RenderCache) GetOrRender(content, options string, render func(content string) (string, error)) (string, error) {
+	key := RenderKey{ContentHash: ContentHash(content), Options: options}
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := render(content)
+	if err != nil {
+		return "", err
+	}
+	c.Set(key, value)
+	return value, nil
+}
+
+// Forget drops every rendering of the given content, e.g., after it was redacted
+func (c *RenderCache) Forget(content string) {
+	hash := ContentHash(content)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.items {
+		if key.ContentHash != hash {
+			continue
+		}
+		c.usedBytes -= int64(len(elem.Value.(*renderEntry).value))
+		c.ll.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+// Len returns the number of cached entries
+func (c *RenderCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// Size returns the number of bytes currently cached
+func (c *RenderCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usedBytes
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestContentHash(t *testing.T) {
+	// Known SHA-256 of "hello"
+	want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if got := ContentHash("hello"); got != want {
+		t.Errorf("ContentHash() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderCache_SharedAcrossIdenticalContent(t *testing.T) {
+	cache := NewRenderCache(1024)
+
+	calls := 0
+	render := func(content string) (string, error) {
+		calls++
+		return strings.ToUpper(content), nil
+	}
+
+	// Two pastes with identical content and options render once
+	for i := 0; i < 2; i++ {
+		got, err := cache.GetOrRender("same content", "theme=dark", render)
+		if err != nil {
+			t.Fatalf("GetOrRender() error = %v", err)
+		}
+		if got != "SAME CONTENT" {
+			t.Errorf("GetOrRender() = %q, want %q", got, "SAME CONTENT")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("render called %d times, want 1", calls)
+	}
+
+	// Different options render again
+	if _, err := cache.GetOrRender("same content", "theme=light", render); err != nil {
+		t.Fatalf("GetOrRender() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("render called %d times, want 2", calls)
+	}
+}
+
+func TestRenderCache_RenderError(t *testing.T) {
+	cache := NewRenderCache(1024)
+	renderErr := errors.New("boom")
+
+	_, err := cache.GetOrRender("content", "", func(string) (string, error) {
+		return "", renderErr
+	})
+	if !errors.Is(err, renderErr) {
+		t.Errorf("GetOrRender() error = %v, want %v", err, renderErr)
+	}
+	if cache.Len() != 0 {
+		t.Errorf("Len() = %d, want 0 after failed render", cache.Len())
+	}
+}
+
+func TestRenderCache_SizeBoundedEviction(t *testing.T) {
+	cache := NewRenderCache(10)
+
+	cache.Set(RenderKey{ContentHash: "a"}, "aaaa") // 4 bytes
+	cache.Set(RenderKey{ContentHash: "b"}, "bbbb") // 8 bytes
+
+	// Touch "a" so "b" becomes least recently used
+	if _, ok := cache.Get(RenderKey{ContentHash: "a"}); !ok {
+		t.Fatal("Get(a) should hit")
+	}
+
+	cache.Set(RenderKey{ContentHash: "c"}, "cccc") // 12 bytes -> evict "b"
+
+	if _, ok := cache.Get(RenderKey{ContentHash: "b"}); ok {
+		t.Error("Get(b) should miss after eviction")
+	}
+	if _, ok := cache.Get(RenderKey{ContentHash: "a"}); !ok {
+		t.Error("Get(a) should still hit")
+	}
+	if cache.Size() != 8 {
+		t.Errorf("Size() = %d, want 8", cache.Size())
+	}
+
+	// Values larger than the budget are never cached
+	cache.Set(RenderKey{ContentHash: "huge"}, strings.Repeat("x", 11))
+	if _, ok := cache.Get(RenderKey{ContentHash: "huge"}); ok {
+		t.Error("Get(huge) should miss for oversized values")
+	}
+}
+
+func TestRenderCache_Forget(t *testing.T) {
+	cache := NewRenderCache(1024)
+	render := func(content string) (string, error) { return strings.ToUpper(content), nil }
+
+	_, _ = cache.GetOrRender("secret", "html:text", render)
+	_, _ = cache.GetOrRender("secret", "detect-language", render)
+	_, _ = cache.GetOrRender("public", "html:text", render)
+
+	cache.Forget("secret")
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d after Forget, want 1", cache.Len())
+	}
+	if cache.Size() != int64(len("PUBLIC")) {
+		t.Errorf("Size() = %d after Forget, want %d", cache.Size(), len("PUBLIC"))
+	}
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// MaxReportDetails caps the characters of the details of a report and of a resolution note
+	MaxReportDetails = 2000
+	// MaxReportContact caps the characters of the contact of a reporter
+	MaxReportContact = 254
+	// DefaultReportLimit is the number of reports of a page when no limit is given
+	DefaultReportLimit = 50
+	// MaxReportLimit caps the number of reports of a page
+	MaxReportLimit = 100
+)
+
+// Report listings
+const (
+	ReportStatusOpen     = "open"
+	ReportStatusResolved = "resolved"
+	ReportStatusAll      = "all"
+)
+
+// Actions resolving a report
+const (
+	// ReportActionDismiss leaves the paste as is
+	ReportActionDismiss = "dismiss"
+	// ReportActionHide quarantines the paste until an operator releases it
+	ReportActionHide = "hide"
+	// ReportActionDelete deletes the paste
+	ReportActionDelete = "delete"
+)
+
+// reportReasons are the reasons a paste can be reported for
+var reportReasons = map[string]bool{
+	model.ReportSpam:         true,
+	model.ReportMalware:      true,
+	model.ReportPhishing:     true,
+	model.ReportCopyright:    true,
+	model.ReportPersonalData: true,
+	model.ReportIllegal:      true,
+	model.ReportOther:        true,
+}
+
+var (
+	// ErrReportsDisabled is returned when the server takes no abuse reports
+	ErrReportsDisabled = errors.New("paste: reports are disabled")
+	// ErrInvalidReport is returned for a report of an unknown reason, or with overlong details or contact
+	ErrInvalidReport = errors.New("paste: invalid report")
+	// ErrInvalidReportStatus is returned when listing reports of an unknown status
+	ErrInvalidReportStatus = errors.New("paste: invalid report status")
+	// ErrAlreadyReported is returned when the same reporter reports a paste again while their
+	// report is open
+	ErrAlreadyReported = errors.New("paste: already reported")
+	// ErrReportNotFound is returned when a report does not exist
+	ErrReportNotFound = errors.New("paste: report not found")
+	// ErrReportResolved is returned when resolving a report that is already resolved
+	ErrReportResolved = errors.New("paste: report already resolved")
+	// ErrInvalidResolution is returned for an unknown action or an overlong note
+	ErrInvalidResolution = errors.New("paste: invalid report resolution")
+)
+
+// ReportPasteRequest represents the request body for reporting a paste
+type ReportPasteRequest struct {
+	// Reason is one of spam, malware, phishing, copyright, personal_data, illegal or other
+	Reason  string `json:"reason" binding:"required" example:"copyright"`
+	Details string `json:"details,omitempty" example:"This is my copyrighted code, published without permission."`
+	// Contact is how operators can reach the reporter, e.g., for copyright claims
+	Contact string `json:"contact,omitempty" example:"legal@example.com"`
+
+	// SourceIP is the reporter's IP, set by the handler
+	SourceIP string `json:"-"`
+}
+
+// ReportsResponse lists reports, newest first
+type ReportsResponse struct {
+	Reports []*model.Report `json:"reports"`
+	// Open counts all the reports awaiting review
+	Open int64 `json:"open" example:"3"`
+	// Next is the before cursor of the next page; empty on the last page
+	Next string `json:"next,omitempty" example:"2024-01-15T14:00:00.123Z_65a5496c8f1d2e3a4b5c6d7e"`
+}
+
+// ResolveReportRequest represents the request body for resolving a report
+type ResolveReportRequest struct {
+	// Action is dismiss to leave the paste as is, hide to quarantine it or delete to delete it
+	Action string `json:"action" binding:"required" example:"hide"`
+	// Note records why, for the moderation record
+	Note string `json:"note,omitempty" example:"Valid DMCA notice"`
+}
+
+// ResolveReportResponse represents the result of resolving a report
+type ResolveReportResponse struct {
+	Report *model.Report `json:"report"`
+	// Resolved counts the reports resolved: hiding or deleting a paste resolves all its open reports
+	Resolved int64 `json:"resolved" example:"2"`
+}
+
+// SetReports lets anyone report pastes for abuse, for operators to review. Without a repository,
+// reports are disabled.
+func (s *PasteService) SetReports(reports *repository.ReportRepository) {
+	s.reportRepo = reports
+}
+
+// ReportPaste records an abuse report of a paste
+func (s *PasteService) ReportPaste(ctx context.Context, shortID string, req *ReportPasteRequest) (*model.Report, error) {
+	if s.reportRepo == nil {
+		return nil, ErrReportsDisabled
+	}
+	reason := strings.ToLower(strings.TrimSpace(req.Reason))
+	details := strings.TrimSpace(req.Details)
+	contact := strings.TrimSpace(req.Contact)
+	if !reportReasons[reason] || !utf8.ValidString(details) || utf8.RuneCountInString(details) > MaxReportDetails ||
+		!utf8.ValidString(contact) || utf8.RuneCountInString(contact) > MaxReportContact || strings.ContainsAny(contact, "\r\n") {
+		return nil, ErrInvalidReport
+	}
+
+	paste, err := s.commentablePaste(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+	sourceIPHash := s.HashSourceIP(req.SourceIP)
+	if sourceIPHash != "" {
+		reported, err := s.reportRepo.HasOpen(ctx, paste.ShortID, sourceIPHash)
+		if err != nil {
+			return nil, fmt.Errorf("paste: failed to check reports: %w", err)
+		}
+		if reported {
+			return nil, ErrAlreadyReported
+		}
+	}
+
+	report := &model.Report{
+		ShortID:      paste.ShortID,
+		Reason:       reason,
+		Details:      details,
+		Contact:      contact,
+		SourceIPHash: sourceIPHash,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.reportRepo.Create(ctx, report); err != nil {
+		return nil, fmt.Errorf("paste: failed to create report: %w", err)
+	}
+	metrics.Reports.WithLabelValues(reason).Inc()
+	log.Printf("[PasteService.ReportPaste] Reported %s for %s: report %s", paste.ShortID, reason, report.ID)
+	return report, nil
+}
+
+// ListReports returns up to limit reports of the status, newest first, starting after the cursor
+// when it is not zero
+func (s *PasteService) ListReports(ctx context.Context, status string, after repository.PageCursor, limit int) (*ReportsResponse, error) {
+	if s.reportRepo == nil {
+		return nil, ErrReportsDisabled
+	}
+	var resolved *bool
+	switch status {
+	case "", ReportStatusOpen:
+		resolved = new(bool)
+	case ReportStatusResolved:
+		resolved = new(bool)
+		*resolved = true
+	case ReportStatusAll:
+	default:
+		return nil, ErrInvalidReportStatus
+	}
+	if limit <= 0 {
+		limit = DefaultReportLimit
+	}
+	limit = min(limit, MaxReportLimit)
+
+	reports, err := s.reportRepo.List(ctx, resolved, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list reports: %w", err)
+	}
+	open, err := s.reportRepo.CountOpen(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to count reports: %w", err)
+	}
+
+	response := &ReportsResponse{Reports: reports, Open: open}
+	if len(reports) > limit {
+		response.Reports = reports[:limit]
+		last := reports[limit-1]
+		response.Next = repository.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.String()
+	}
+	return response, nil
+}
+
+// ResolveReport resolves an open report. Hiding or deleting the paste resolves all its open
+// reports alike; a paste already gone is not an error.
+func (s *PasteService) ResolveReport(ctx context.Context, id string, req *ResolveReportRequest) (*ResolveReportResponse, error) {
+	if s.reportRepo == nil {
+		return nil, ErrReportsDisabled
+	}
+	note := strings.TrimSpace(req.Note)
+	if !utf8.ValidString(note) || utf8.RuneCountInString(note) > MaxReportDetails {
+		return nil, ErrInvalidResolution
+	}
+
+	report, err := s.reportRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReportNotFound) {
+			return nil, ErrReportNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get report: %w", err)
+	}
+	if report.IsResolved() {
+		return nil, ErrReportResolved
+	}
+
+	now := time.Now()
+	response := &ResolveReportResponse{}
+	switch req.Action {
+	case ReportActionDismiss:
+		err = s.reportRepo.Resolve(ctx, id, model.ResolutionDismissed, note, now)
+		if errors.Is(err, repository.ErrReportNotFound) {
+			// Resolved concurrently
+			return nil, ErrReportResolved
+		}
+		response.Resolved = 1
+	case ReportActionHide:
+		if err := s.hideReported(ctx, report); err != nil {
+			return nil, err
+		}
+		response.Resolved, err = s.reportRepo.ResolveOpen(ctx, report.ShortID, model.ResolutionHidden, note, now)
+	case ReportActionDelete:
+		if _, err := s.ForceDeletePaste(ctx, report.ShortID); err != nil && !errors.Is(err, ErrPasteNotFound) {
+			return nil, err
+		}
+		response.Resolved, err = s.reportRepo.ResolveOpen(ctx, report.ShortID, model.ResolutionDeleted, note, now)
+	default:
+		return nil, ErrInvalidResolution
+	}
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to resolve report: %w", err)
+	}
+
+	if response.Report, err = s.reportRepo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("paste: failed to get report: %w", err)
+	}
+	log.Printf("[PasteService.ResolveReport] Resolved report %s of %s: %s", id, report.ShortID, response.Report.Resolution)
+	return response, nil
+}
+
+// hideReported quarantines a reported paste, and has edge caches drop it
+func (s *PasteService) hideReported(ctx context.Context, report *model.Report) error {
+	quarantine := &model.Quarantine{Reason: "report: " + report.Reason, At: time.Now()}
+	err := s.pasteRepo.SetQuarantine(ctx, report.ShortID, quarantine)
+	if errors.Is(err, repository.ErrPasteNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("paste: failed to hide paste: %w", err)
+	}
+	s.publish(model.PasteEventUpdated, report.ShortID, nil)
+	return nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func TestPasteService_ReportPaste_Validation(t *testing.T) {
+	ctx := context.Background()
+	if _, err := (&PasteService{}).ReportPaste(ctx, "abc123", &ReportPasteRequest{Reason: "spam"}); !errors.Is(err, ErrReportsDisabled) {
+		t.Errorf("ReportPaste() without reports error = %v, want %v", err, ErrReportsDisabled)
+	}
+
+	svc := &PasteService{reportRepo: &repository.ReportRepository{}}
+	for _, req := range []*ReportPasteRequest{
+		{Reason: "boring"},
+		{Reason: "spam", Details: strings.Repeat("x", MaxReportDetails+1)},
+		{Reason: "other", Contact: "a@example.com\r\nBcc: b@example.com"},
+	} {
+		if _, err := svc.ReportPaste(ctx, "abc123", req); !errors.Is(err, ErrInvalidReport) {
+			t.Errorf("ReportPaste(%+v) error = %v, want %v", req, err, ErrInvalidReport)
+		}
+	}
+	if _, err := svc.ListReports(ctx, "pending", repository.PageCursor{}, 0); !errors.Is(err, ErrInvalidReportStatus) {
+		t.Errorf("ListReports() of an unknown status error = %v, want %v", err, ErrInvalidReportStatus)
+	}
+}
+
+func TestPasteService_Reports(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	svc.SetSourceIPHashKey("test-key")
+	ctx := context.Background()
+
+	paste, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "leaked"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	if _, err := svc.ReportPaste(ctx, "nope00", &ReportPasteRequest{Reason: "spam"}); !errors.Is(err, ErrPasteNotFound) {
+		t.Errorf("ReportPaste() of a missing paste error = %v, want %v", err, ErrPasteNotFound)
+	}
+
+	first, err := svc.ReportPaste(ctx, paste.ShortID, &ReportPasteRequest{Reason: " Copyright ", SourceIP: "203.0.113.1"})
+	if err != nil {
+		t.Fatalf("ReportPaste() error = %v", err)
+	}
+	if first.Reason != "copyright" || first.SourceIPHash == "" {
+		t.Errorf("ReportPaste() = %+v", first)
+	}
+	if _, err := svc.ReportPaste(ctx, paste.ShortID, &ReportPasteRequest{Reason: "spam", SourceIP: "203.0.113.1"}); !errors.Is(err, ErrAlreadyReported) {
+		t.Errorf("ReportPaste() twice error = %v, want %v", err, ErrAlreadyReported)
+	}
+	second, err := svc.ReportPaste(ctx, paste.ShortID, &ReportPasteRequest{Reason: "personal_data", SourceIP: "203.0.113.2"})
+	if err != nil {
+		t.Fatalf("ReportPaste() error = %v", err)
+	}
+
+	page, err := svc.ListReports(ctx, "", repository.PageCursor{}, 1)
+	if err != nil {
+		t.Fatalf("ListReports() error = %v", err)
+	}
+	if page.Open != 2 || len(page.Reports) != 1 || page.Reports[0].ID != second.ID || page.Next == "" {
+		t.Fatalf("ListReports() = %+v, want the newest of 2 open reports and a next cursor", page)
+	}
+
+	if _, err := svc.ResolveReport(ctx, first.ID, &ResolveReportRequest{Action: "ban"}); !errors.Is(err, ErrInvalidResolution) {
+		t.Errorf("ResolveReport() with an unknown action error = %v, want %v", err, ErrInvalidResolution)
+	}
+	resolved, err := svc.ResolveReport(ctx, first.ID, &ResolveReportRequest{Action: ReportActionHide, Note: "valid notice"})
+	if err != nil {
+		t.Fatalf("ResolveReport() error = %v", err)
+	}
+	if resolved.Resolved != 2 || resolved.Report.Resolution != "hidden" || resolved.Report.Note != "valid notice" {
+		t.Errorf("ResolveReport() = %+v, want both reports of the paste resolved", resolved)
+	}
+	if _, err := svc.GetPaste(ctx, paste.ShortID); !errors.Is(err, ErrPasteQuarantined) {
+		t.Errorf("GetPaste() of a hidden paste error = %v, want %v", err, ErrPasteQuarantined)
+	}
+	if _, err := svc.ResolveReport(ctx, second.ID, &ResolveReportRequest{Action: ReportActionDismiss}); !errors.Is(err, ErrReportResolved) {
+		t.Errorf("ResolveReport() of a resolved report error = %v, want %v", err, ErrReportResolved)
+	}
+
+	all, err := svc.ListReports(ctx, ReportStatusResolved, repository.PageCursor{}, 0)
+	if err != nil {
+		t.Fatalf("ListReports() error = %v", err)
+	}
+	if all.Open != 0 || len(all.Reports) != 2 {
+		t.Errorf("ListReports() of resolved reports = %+v", all)
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/tracing"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrEditConflict is returned when a paste was edited concurrently; the client should reload and retry
+var ErrEditConflict = errors.New("paste: edited concurrently")
+
+// UpdatePasteRequest represents the request to replace the content of a paste
+type UpdatePasteRequest struct {
+	Content    string `json:"content" binding:"required"`
+	SyntaxType string `json:"syntax_type"` // empty re-detects the language of the new content
+
+	// Caller proves the right to edit as for deleting the paste, set by the handler
+	Caller *DeletePasteRequest `json:"-"`
+}
+
+// UpdatePasteResponse represents the response after editing a paste
+type UpdatePasteResponse struct {
+	ShortID   string `json:"short_id"`
+	URL       string `json:"url"`
+	Revision  int    `json:"revision"` // number of previous versions kept
+	UpdatedAt string `json:"updated_at"`
+}
+
+// RevisionResponse describes a previous version of a paste
+type RevisionResponse struct {
+	Revision   int    `json:"revision"`
+	SyntaxType string `json:"syntax_type"`
+	Binary     bool   `json:"binary,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	ReplacedAt string `json:"replaced_at"`
+}
+
+// ListRevisionsResponse lists the previous versions of a paste, newest first
+type ListRevisionsResponse struct {
+	ShortID   string             `json:"short_id"`
+	Revision  int                `json:"revision"`
+	Revisions []RevisionResponse `json:"revisions"`
+}
+
+// SetRevisionRepository enables keeping previous versions of edited pastes.
+// Without it, edits replace the content without history.
+func (s *PasteService) SetRevisionRepository(revisionRepo *repository.RevisionRepository) {
+	s.revisionRepo = revisionRepo
+}
+
+// UpdatePaste replaces the content of a paste, keeping the previous version as a revision
+func (s *PasteService) UpdatePaste(ctx context.Context, shortID string, req *UpdatePasteRequest) (*UpdatePasteResponse, error) {
+	ctx, span := tracing.Start(ctx, "PasteService.UpdatePaste", trace.WithAttributes(attribute.String("short_id", shortID)))
+	defer span.End()
+
+	log.Printf("[PasteService.UpdatePaste] Starting: short_id=%s, content_len=%d, syntax=%s",
+		shortID, len(req.Content), req.SyntaxType)
+
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if !canDelete(paste, req.Caller) {
+		return nil, ErrEditForbidden
+	}
+	if paste.Live {
+		return nil, ErrLivePaste
+	}
+	if err := s.checkWritable(); err != nil {
+		log.Printf("[PasteService.UpdatePaste] Error: %v", err)
+		return nil, err
+	}
+
+	// An encrypted paste stays encrypted: edits replace the ciphertext
+	syntaxType, flags, err := s.prepareContent(req.Content, req.SyntaxType, paste.Encrypted)
+	if err != nil {
+		log.Printf("[PasteService.UpdatePaste] Error: %v", err)
+		return nil, err
+	}
+
+	// Keep the schema validation current
+	var validation *model.SchemaValidation
+	if paste.Validation != nil {
+		validation = revalidate(paste.Validation, req.Content, syntaxType)
+	}
+
+	now := time.Now()
+	revision := paste.Revision
+	if s.revisionRepo != nil {
+		revision, err = s.saveRevision(ctx, paste, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Replace the content, then point the record at the new version
+	if err := s.storage.SaveContent(ctx, shortID, req.Content); err != nil {
+		log.Printf("[PasteService.UpdatePaste] Error saving to S3: %v", err)
+		return nil, fmt.Errorf("paste: failed to save content: %w", err)
+	}
+
+	contentHash := ContentHash(req.Content)
+	fields := bson.M{
+		"revision":          revision,
+		"updated_at":        now,
+		"syntax_type":       syntaxType,
+		"binary":            flags.Binary,
+		"preview_truncated": flags.PreviewTruncated,
+		"content_hash":      contentHash,
+	}
+	if validation != nil {
+		fields["validation"] = validation
+	}
+	err = s.pasteRepo.UpdateContent(ctx, shortID, paste.Revision, fields)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			// Deleted or edited since we read it
+			return nil, ErrEditConflict
+		}
+		return nil, fmt.Errorf("paste: failed to update record: %w", err)
+	}
+
+	// Readers must not see the previous content from cache
+	_ = s.cache.Delete(ctx, shortID)
+
+	paste.Revision = revision
+	paste.UpdatedAt = &now
+	paste.SyntaxType = syntaxType
+	paste.Binary = flags.Binary
+	paste.PreviewTruncated = flags.PreviewTruncated
+	paste.ContentHash = contentHash
+	paste.Validation = validation
+	s.publish(model.PasteEventUpdated, shortID, paste)
+
+	log.Printf("[PasteService.UpdatePaste] Success: short_id=%s, revision=%d", shortID, revision)
+	return &UpdatePasteResponse{
+		ShortID:   shortID,
+		URL:       s.buildURL(shortID),
+		Revision:  revision,
+		UpdatedAt: now.Format(time.RFC3339),
+	}, nil
+}
+
+// saveRevision records the current version of a paste as its next revision and returns the revision number.
+// The record is inserted first: its unique index lets only one of several concurrent edits claim the number.
+func (s *PasteService) saveRevision(ctx context.Context, paste *model.Paste, replacedAt time.Time) (int, error) {
+	createdAt := paste.CreatedAt
+	if paste.UpdatedAt != nil {
+		createdAt = *paste.UpdatedAt
+	}
+	revision := &model.PasteRevision{
+		ShortID:    paste.ShortID,
+		Revision:   paste.Revision + 1,
+		SyntaxType: paste.SyntaxType,
+		Binary:     paste.Binary,
+		CreatedAt:  createdAt,
+		ReplacedAt: replacedAt,
+	}
+
+	if err := s.revisionRepo.Create(ctx, revision); err != nil {
+		if errors.Is(err, repository.ErrRevisionDuplicate) {
+			return 0, ErrEditConflict
+		}
+		return 0, fmt.Errorf("paste: failed to create revision: %w", err)
+	}
+
+	if err := s.storage.SaveRevision(ctx, paste.ShortID, revision.Revision); err != nil {
+		log.Printf("[PasteService.UpdatePaste] Error copying revision %d of %s: %v", revision.Revision, paste.ShortID, err)
+		_ = s.revisionRepo.Delete(ctx, paste.ShortID, revision.Revision)
+		if errors.Is(err, ErrContentNotFound) {
+			return 0, ErrPasteNotFound
+		}
+		return 0, fmt.Errorf("paste: failed to save revision: %w", err)
+	}
+
+	return revision.Revision, nil
+}
+
+// ListRevisions returns the previous versions of a paste without reading its content,
+// so burn-after-read pastes are not consumed
+func (s *PasteService) ListRevisions(ctx context.Context, shortID string) (*ListRevisionsResponse, error) {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+
+	response := &ListRevisionsResponse{
+		ShortID:   paste.ShortID,
+		Revision:  paste.Revision,
+		Revisions: []RevisionResponse{},
+	}
+	if s.revisionRepo == nil {
+		return response, nil
+	}
+
+	revisions, err := s.revisionRepo.ListByShortID(ctx, shortID)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list revisions: %w", err)
+	}
+	for _, r := range revisions {
+		response.Revisions = append(response.Revisions, RevisionResponse{
+			Revision:   r.Revision,
+			SyntaxType: r.SyntaxType,
+			Binary:     r.Binary,
+			CreatedAt:  r.CreatedAt.Format(time.RFC3339),
+			ReplacedAt: r.ReplacedAt.Format(time.RFC3339),
+		})
+	}
+
+	return response, nil
+}
+
+// deleteRevisions removes every revision of a paste (best effort)
+func (s *PasteService) deleteRevisions(ctx context.Context, shortID string) {
+	if s.revisionRepo == nil {
+		return
+	}
+
+	revisions, err := s.revisionRepo.ListByShortID(ctx, shortID)
+	if err != nil || len(revisions) == 0 {
+		return
+	}
+	for _, r := range revisions {
+		_ = s.storage.DeleteRevision(ctx, shortID, r.Revision)
+	}
+	_, _ = s.revisionRepo.DeleteByShortIDs(ctx, []string{shortID})
+}
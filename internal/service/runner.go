@@ -0,0 +1,317 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// DefaultRunTimeout is the default time limit of a program in the sandbox
+	DefaultRunTimeout = 5 * time.Second
+	// MaxRunStdinSize is the largest accepted standard input of a run (64KB)
+	MaxRunStdinSize = 64 * 1024
+	// RunOutputTTL is the longest an output paste is kept; it never outlives its source
+	RunOutputTTL = 7 * 24 * time.Hour
+	// runnerGrace is added to the program time limit to bound the call to the sandbox
+	runnerGrace = 5 * time.Second
+)
+
+var (
+	// ErrRunnerDisabled is returned when no sandbox is configured on this instance
+	ErrRunnerDisabled = errors.New("paste: code execution disabled")
+	// ErrLanguageNotAllowed is returned when the syntax type of a paste is not in the runner's allow-list
+	ErrLanguageNotAllowed = errors.New("paste: language not allowed to run")
+	// ErrNotRunnable is returned for pastes whose content must not be read on behalf of the caller:
+	// encrypted, binary, burn-after-read and view-limited pastes
+	ErrNotRunnable = errors.New("paste: content cannot be run")
+	// ErrInvalidRunRequest is returned when the standard input of a run is too large
+	ErrInvalidRunRequest = errors.New("paste: invalid run request")
+	// ErrRunnerUnavailable is returned when the sandbox fails or cannot be reached
+	ErrRunnerUnavailable = errors.New("paste: sandbox unavailable")
+)
+
+// RunRequest is a program to run in the sandbox
+type RunRequest struct {
+	Language string
+	Content  string
+	Stdin    string
+	Timeout  time.Duration // time limit of the program
+}
+
+// RunResult is the outcome of a program run in the sandbox
+type RunResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	TimedOut bool
+}
+
+// Runner executes programs in an external sandbox. Code is never executed in-process.
+type Runner interface {
+	Run(ctx context.Context, req *RunRequest) (*RunResult, error)
+}
+
+// RunnerConfig holds the code execution settings of the paste service
+type RunnerConfig struct {
+	// Languages are the syntax types allowed to run
+	Languages []string
+	// Timeout is the time limit of a program, DefaultRunTimeout when zero
+	Timeout time.Duration
+}
+
+// RunPasteRequest represents the request to run a paste
+type RunPasteRequest struct {
+	Stdin string `json:"stdin"`
+
+	// OwnerID identifies the caller's API key or anonymous session, set by the handler
+	OwnerID string `json:"-"`
+	// UserID is the ID of the signed-in user, set by the handler
+	UserID string `json:"-"`
+}
+
+// RunPasteResponse represents the output of a paste run, also stored as a linked paste
+type RunPasteResponse struct {
+	ShortID    string  `json:"short_id"`  // the paste that was run
+	OutputID   string  `json:"output_id"` // the paste holding the output
+	OutputURL  string  `json:"output_url"`
+	ExpiresAt  *string `json:"expires_at,omitempty"` // expiry of the output paste
+	Language   string  `json:"language"`
+	Stdout     string  `json:"stdout"`
+	Stderr     string  `json:"stderr"`
+	ExitCode   int     `json:"exit_code"`
+	TimedOut   bool    `json:"timed_out"`
+	DurationMS int64   `json:"duration_ms"`
+	// DeleteToken is returned once for outputs of anonymous runs, and required to delete the output paste
+	DeleteToken string `json:"delete_token,omitempty"`
+}
+
+// SetRunner enables running pastes of the allowed languages in an external sandbox
+func (s *PasteService) SetRunner(runner Runner, config RunnerConfig) {
+	s.runner = runner
+	s.runLanguages = make(map[string]bool, len(config.Languages))
+	for _, language := range config.Languages {
+		if language = strings.ToLower(strings.TrimSpace(language)); language != "" {
+			s.runLanguages[language] = true
+		}
+	}
+	s.runTimeout = config.Timeout
+	if s.runTimeout <= 0 {
+		s.runTimeout = DefaultRunTimeout
+	}
+}
+
+// RunPaste runs the content of a paste in the sandbox and stores the output as a new paste
+// linked to it. The output paste has the privacy of its source and expires with it, after
+// RunOutputTTL at the latest.
+func (s *PasteService) RunPaste(ctx context.Context, shortID string, req *RunPasteRequest) (*RunPasteResponse, error) {
+	if s.runner == nil {
+		return nil, ErrRunnerDisabled
+	}
+	if len(req.Stdin) > MaxRunStdinSize {
+		return nil, ErrInvalidRunRequest
+	}
+
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsPending() || paste.IsBurned() {
+		return nil, ErrPasteNotFound
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if paste.ArchivedAt != nil {
+		return nil, ErrPasteArchived
+	}
+	if paste.IsQuarantined() {
+		return nil, ErrPasteQuarantined
+	}
+	if paste.Encrypted || paste.Binary || paste.BurnAfterRead || paste.MaxViews > 0 {
+		return nil, ErrNotRunnable
+	}
+	if !s.runLanguages[paste.SyntaxType] {
+		return nil, ErrLanguageNotAllowed
+	}
+
+	content, err := s.storage.GetContent(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, ErrContentNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get content: %w", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, s.runTimeout+runnerGrace)
+	defer cancel()
+	start := time.Now()
+	result, err := s.runner.Run(runCtx, &RunRequest{
+		Language: paste.SyntaxType,
+		Content:  content,
+		Stdin:    req.Stdin,
+		Timeout:  s.runTimeout,
+	})
+	duration := time.Since(start)
+	if err != nil {
+		log.Printf("[PasteService.RunPaste] Sandbox error for %s: %v", shortID, err)
+		return nil, fmt.Errorf("%w: %v", ErrRunnerUnavailable, err)
+	}
+
+	expiresIn := RunOutputTTL
+	if paste.ExpiresAt != nil {
+		expiresIn = min(expiresIn, time.Until(*paste.ExpiresAt))
+	}
+	output, err := s.CreatePaste(ctx, &CreatePasteRequest{
+		Content:    formatRunOutput(result),
+		SyntaxType: DefaultSyntaxType,
+		ExpiresIn:  max(expiresIn, time.Second).Round(time.Second).String(),
+		IsPrivate:  paste.IsPrivate,
+		Title:      "Output of " + shortID,
+		OwnerID:    req.OwnerID,
+		UserID:     req.UserID,
+		OutputOf:   shortID,
+		Producer:   &model.Producer{Kind: ProducerRunner, Name: paste.SyntaxType},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to store run output: %w", err)
+	}
+
+	log.Printf("[PasteService.RunPaste] Success: short_id=%s, output=%s, exit_code=%d, duration=%v",
+		shortID, output.ShortID, result.ExitCode, duration)
+	return &RunPasteResponse{
+		ShortID:     shortID,
+		OutputID:    output.ShortID,
+		OutputURL:   output.URL,
+		ExpiresAt:   output.ExpiresAt,
+		Language:    paste.SyntaxType,
+		Stdout:      result.Stdout,
+		Stderr:      result.Stderr,
+		ExitCode:    result.ExitCode,
+		TimedOut:    result.TimedOut,
+		DurationMS:  duration.Milliseconds(),
+		DeleteToken: output.DeleteToken,
+	}, nil
+}
+
+// formatRunOutput renders a run result as the content of its output paste, within MaxContentSize
+func formatRunOutput(result *RunResult) string {
+	status := fmt.Sprintf("--- exit status %d ---\n", result.ExitCode)
+	if result.TimedOut {
+		status = "--- timed out ---\n"
+	}
+
+	const stderrHeader = "--- stderr ---\n"
+	var b strings.Builder
+	// Leave room for the headers and the newlines ending each section
+	budget := MaxContentSize - len(status) - len(stderrHeader) - 2
+	stdout := truncateUTF8(result.Stdout, budget)
+	b.WriteString(stdout)
+	if stdout != "" && !strings.HasSuffix(stdout, "\n") {
+		b.WriteString("\n")
+	}
+	if stderr := truncateUTF8(result.Stderr, max(budget-len(stdout), 0)); stderr != "" {
+		b.WriteString(stderrHeader)
+		b.WriteString(stderr)
+		if !strings.HasSuffix(stderr, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString(status)
+	return b.String()
+}
+
+// HTTPRunner runs programs by POSTing them as JSON to a sandbox service:
+//
+//	{"language": "python", "content": "print(1)", "stdin": "", "timeout_ms": 5000}
+//
+// and expects a 200 answer of the form:
+//
+//	{"stdout": "1\n", "stderr": "", "exit_code": 0, "timed_out": false}
+type HTTPRunner struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// NewHTTPRunner creates an HTTPRunner posting to endpoint; a non-empty token is sent as a bearer token
+func NewHTTPRunner(endpoint, token string) *HTTPRunner {
+	return &HTTPRunner{
+		endpoint: endpoint,
+		token:    token,
+		// Calls are bounded by the caller's context
+		client: &http.Client{},
+	}
+}
+
+// httpRunRequest is the body sent to the sandbox
+type httpRunRequest struct {
+	Language  string `json:"language"`
+	Content   string `json:"content"`
+	Stdin     string `json:"stdin"`
+	TimeoutMS int64  `json:"timeout_ms"`
+}
+
+// httpRunResponse is the answer of the sandbox
+type httpRunResponse struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+	TimedOut bool   `json:"timed_out"`
+}
+
+// Run sends the program to the sandbox and returns its output
+func (r *HTTPRunner) Run(ctx context.Context, req *RunRequest) (*RunResult, error) {
+	body, err := json.Marshal(&httpRunRequest{
+		Language:  req.Language,
+		Content:   req.Content,
+		Stdin:     req.Stdin,
+		TimeoutMS: req.Timeout.Milliseconds(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sandbox answered status %d", resp.StatusCode)
+	}
+	var out httpRunResponse
+	// Output beyond what a paste can hold is not worth reading
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 4*MaxContentSize)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid sandbox answer: %w", err)
+	}
+	return &RunResult{
+		Stdout:   out.Stdout,
+		Stderr:   out.Stderr,
+		ExitCode: out.ExitCode,
+		TimedOut: out.TimedOut,
+	}, nil
+}
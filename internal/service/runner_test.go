@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPRunner_Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var req httpRunRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if req.Language != "python" || req.Content != "print(input())" || req.Stdin != "42" || req.TimeoutMS != 3000 {
+			t.Errorf("request = %+v", req)
+		}
+		_ = json.NewEncoder(w).Encode(httpRunResponse{Stdout: "42\n", ExitCode: 0})
+	}))
+	defer server.Close()
+
+	result, err := NewHTTPRunner(server.URL, "secret").Run(context.Background(), &RunRequest{
+		Language: "python",
+		Content:  "print(input())",
+		Stdin:    "42",
+		Timeout:  3 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Stdout != "42\n" || result.ExitCode != 0 || result.TimedOut {
+		t.Errorf("Run() = %+v", result)
+	}
+}
+
+func TestHTTPRunner_Run_SandboxError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPRunner(server.URL, "").Run(context.Background(), &RunRequest{Language: "go"}); err == nil {
+		t.Error("Run() error = nil for a 503 answer")
+	}
+}
+
+func TestFormatRunOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		result RunResult
+		want   string
+	}{
+		{"stdout only", RunResult{Stdout: "hi\n"}, "hi\n--- exit status 0 ---\n"},
+		{"stdout without newline", RunResult{Stdout: "hi"}, "hi\n--- exit status 0 ---\n"},
+		{"stderr and failure", RunResult{Stderr: "boom", ExitCode: 1}, "--- stderr ---\nboom\n--- exit status 1 ---\n"},
+		{"no output", RunResult{TimedOut: true}, "--- timed out ---\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRunOutput(&tt.result); got != tt.want {
+				t.Errorf("formatRunOutput() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	huge := formatRunOutput(&RunResult{Stdout: strings.Repeat("x", 2*MaxContentSize), Stderr: "late"})
+	if len(huge) > MaxContentSize {
+		t.Errorf("formatRunOutput() of huge output = %d bytes, want at most %d", len(huge), MaxContentSize)
+	}
+}
+
+// echoRunner answers every run with the content as standard output
+type echoRunner struct{}
+
+func (echoRunner) Run(ctx context.Context, req *RunRequest) (*RunResult, error) {
+	return &RunResult{Stdout: req.Content}, nil
+}
+
+func TestPasteService_RunPaste_DeleteToken(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	svc.SetRunner(echoRunner{}, RunnerConfig{Languages: []string{"python"}})
+	ctx := context.Background()
+
+	created, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "print(1)", SyntaxType: "python"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	response, err := svc.RunPaste(ctx, created.ShortID, &RunPasteRequest{})
+	if err != nil {
+		t.Fatalf("RunPaste() error = %v", err)
+	}
+	if response.DeleteToken == "" {
+		t.Fatal("RunPaste() returned no delete token for an anonymous run")
+	}
+	if err := svc.DeletePaste(ctx, response.OutputID, &DeletePasteRequest{DeleteToken: response.DeleteToken}); err != nil {
+		t.Errorf("DeletePaste() of the output with its token error = %v", err)
+	}
+}
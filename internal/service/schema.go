@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// MaxSchemaSize is the largest accepted JSON Schema document (64KB)
+	MaxSchemaSize = 64 * 1024
+	// MaxSchemaErrors is the most validation errors kept for a paste
+	MaxSchemaErrors = 20
+	// schemaURL is the location the supplied schema is compiled under
+	schemaURL = "gisty:///schema.json"
+)
+
+// ErrInvalidSchema is returned when a schema is not a valid JSON Schema, or the paste is not JSON or YAML
+var ErrInvalidSchema = errors.New("paste: invalid schema")
+
+// schemaSyntaxTypes are the syntax types whose content can be validated against a schema
+var schemaSyntaxTypes = map[string]bool{
+	"json": true,
+	"yaml": true,
+}
+
+// noSchemaLoader refuses to load $ref targets other than the standard metaschemas, so a
+// schema cannot make the server read local files or fetch URLs
+type noSchemaLoader struct{}
+
+func (noSchemaLoader) Load(url string) (any, error) {
+	return nil, fmt.Errorf("external references are not supported: %s", url)
+}
+
+// CompileSchema parses and compiles a JSON Schema document supplied with a paste
+func CompileSchema(schema []byte) (*jsonschema.Schema, error) {
+	if len(schema) == 0 || len(schema) > MaxSchemaSize {
+		return nil, ErrInvalidSchema
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, ErrInvalidSchema
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.UseLoader(noSchemaLoader{})
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, ErrInvalidSchema
+	}
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, ErrInvalidSchema
+	}
+	return compiled, nil
+}
+
+// ValidateDocument validates JSON or YAML content against a JSON Schema. Content that does not
+// parse or does not conform is reported in the result; only a bad schema, or content of another
+// syntax type, is an error.
+func ValidateDocument(content, syntaxType string, schema []byte) (*model.SchemaValidation, error) {
+	if !schemaSyntaxTypes[syntaxType] {
+		return nil, ErrInvalidSchema
+	}
+	compiled, err := CompileSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.SchemaValidation{
+		Schema:      string(schema),
+		Valid:       true,
+		ValidatedAt: time.Now(),
+	}
+
+	doc, err := parseDocument(content, syntaxType)
+	if err != nil {
+		result.Valid = false
+		result.Errors = []string{err.Error()}
+		return result, nil
+	}
+
+	err = compiled.Validate(doc)
+	if err == nil {
+		return result, nil
+	}
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return nil, fmt.Errorf("paste: failed to validate against schema: %w", err)
+	}
+	result.Valid = false
+	result.Errors = schemaErrors(verr)
+	return result, nil
+}
+
+// parseDocument decodes JSON or YAML content into the value model of the schema validator
+func parseDocument(content, syntaxType string) (any, error) {
+	data := []byte(content)
+	if syntaxType == "yaml" {
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
+		// Round-trip through JSON so numbers and maps take the validator's types
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("YAML cannot be represented as JSON: %v", err)
+		}
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return doc, nil
+}
+
+// schemaErrors flattens a validation error into "location: message" lines, at most MaxSchemaErrors
+func schemaErrors(verr *jsonschema.ValidationError) []string {
+	var errs []string
+	for _, unit := range verr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		errs = append(errs, location+": "+strings.TrimSpace(unit.Error.String()))
+		if len(errs) == MaxSchemaErrors {
+			break
+		}
+	}
+	return errs
+}
+
+// revalidate validates edited content against the schema of a previous validation
+func revalidate(previous *model.SchemaValidation, content, syntaxType string) *model.SchemaValidation {
+	result, err := ValidateDocument(content, syntaxType, []byte(previous.Schema))
+	if err != nil {
+		result = &model.SchemaValidation{
+			Schema:      previous.Schema,
+			ValidatedAt: time.Now(),
+			Errors:      []string{fmt.Sprintf("content of syntax type %s cannot be validated", syntaxType)},
+		}
+	}
+	return result
+}
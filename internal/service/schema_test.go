@@ -0,0 +1,93 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+	"type": "object",
+	"required": ["name", "port"],
+	"properties": {
+		"name": {"type": "string"},
+		"port": {"type": "integer", "minimum": 1}
+	}
+}`
+
+func TestValidateDocument(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		syntaxType string
+		valid      bool
+		errorHas   string
+	}{
+		{"valid json", `{"name": "api", "port": 8080}`, "json", true, ""},
+		{"valid yaml", "name: api\nport: 8080\n", "yaml", true, ""},
+		{"wrong type", `{"name": "api", "port": "8080"}`, "json", false, "/port"},
+		{"missing property", "name: api\n", "yaml", false, "port"},
+		{"malformed json", `{"name": `, "json", false, "invalid JSON"},
+		{"malformed yaml", "name: [api\n", "yaml", false, "invalid YAML"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ValidateDocument(tt.content, tt.syntaxType, []byte(testSchema))
+			if err != nil {
+				t.Fatalf("ValidateDocument() error = %v", err)
+			}
+			if result.Valid != tt.valid {
+				t.Fatalf("Valid = %v, want %v (errors %v)", result.Valid, tt.valid, result.Errors)
+			}
+			if tt.valid && len(result.Errors) != 0 {
+				t.Errorf("Errors = %v, want none", result.Errors)
+			}
+			if !tt.valid && !strings.Contains(strings.Join(result.Errors, "\n"), tt.errorHas) {
+				t.Errorf("Errors = %v, want one mentioning %q", result.Errors, tt.errorHas)
+			}
+			if result.Schema != testSchema {
+				t.Error("Schema was not kept for revalidation")
+			}
+		})
+	}
+}
+
+func TestValidateDocument_InvalidSchema(t *testing.T) {
+	tests := []struct {
+		name       string
+		syntaxType string
+		schema     string
+	}{
+		{"not json", "json", `{"type": `},
+		{"bad keyword value", "json", `{"type": 42}`},
+		{"file reference", "json", `{"$ref": "file:///etc/passwd"}`},
+		{"remote reference", "json", `{"$ref": "https://example.com/schema.json"}`},
+		{"too large", "json", `{"description": "` + strings.Repeat("x", MaxSchemaSize) + `"}`},
+		{"not a structured syntax", "python", testSchema},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateDocument(`{}`, tt.syntaxType, []byte(tt.schema))
+			if !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("ValidateDocument() error = %v, want ErrInvalidSchema", err)
+			}
+		})
+	}
+}
+
+func TestRevalidate(t *testing.T) {
+	previous, err := ValidateDocument(`{"name": "api", "port": 8080}`, "json", []byte(testSchema))
+	if err != nil {
+		t.Fatalf("ValidateDocument() error = %v", err)
+	}
+
+	if result := revalidate(previous, `{"name": "api"}`, "json"); result.Valid {
+		t.Error("revalidate() of non-conforming edit is valid")
+	}
+	result := revalidate(previous, "print('hi')", "python")
+	if result.Valid || len(result.Errors) != 1 || result.Schema != testSchema {
+		t.Errorf("revalidate() after syntax change = %+v, want invalid with the schema kept", result)
+	}
+}
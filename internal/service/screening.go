@@ -0,0 +1,515 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+// Screening actions taken on suspicious new pastes, from the mildest
+const (
+	// ScreeningFlag stores the paste and lists it for operators to review
+	ScreeningFlag = "flag"
+	// ScreeningQuarantine stores the paste but does not serve it until an operator releases it
+	ScreeningQuarantine = "quarantine"
+	// ScreeningReject refuses the paste
+	ScreeningReject = "reject"
+)
+
+// screeningActions lists the screening actions from the mildest
+var screeningActions = []string{ScreeningFlag, ScreeningQuarantine, ScreeningReject}
+
+// Screening strictness levels, setting the scores at which each action is taken
+const (
+	StrictnessLow    = "low"
+	StrictnessMedium = "medium"
+	StrictnessHigh   = "high"
+)
+
+// Scores of the built-in screeners' findings. The scores of a paste's findings add up, and the
+// total decides the action.
+const (
+	ScoreKeyword         = 30
+	ScoreDeniedDomain    = 50
+	ScoreSecretsDump     = 60
+	ScoreCredentialsDump = 100
+)
+
+const (
+	// DefaultScreeningTimeout bounds the screening of a paste, external scanners included
+	DefaultScreeningTimeout = 2 * time.Second
+	// DefaultCredentialLines is the number of email:password lines making a credential dump
+	DefaultCredentialLines = 10
+	// DefaultSecretTokens is the number of distinct secrets making a secrets dump
+	DefaultSecretTokens = 10
+	// secretEntropy is the Shannon entropy in bits per character above which an assigned value
+	// is taken for a secret rather than a placeholder
+	secretEntropy = 3.5
+)
+
+// screeningThresholds are the scores at which pastes are flagged, quarantined and rejected
+var screeningThresholds = map[string][3]int{
+	StrictnessLow:    {50, 100, 150},
+	StrictnessMedium: {30, 60, 100},
+	StrictnessHigh:   {20, 40, 60},
+}
+
+var (
+	// ErrContentRejected is returned when content screening refuses a new paste as spam or abuse
+	ErrContentRejected = errors.New("paste: content rejected by screening")
+	// ErrInvalidScreening is returned when the screening strictness or action is unknown
+	ErrInvalidScreening = errors.New("paste: invalid screening policy")
+	// ErrPasteNotScreened is returned when dismissing the screening of a paste that was not flagged
+	// or quarantined by screening
+	ErrPasteNotScreened = errors.New("paste: not screened")
+)
+
+// ScreeningFinding is a reason a screener found content suspicious
+type ScreeningFinding struct {
+	Reason string
+	Score  int
+}
+
+// Screener checks the content of new pastes for spam and abuse. Screen must be safe for
+// concurrent use and must not quote the content in reasons.
+type Screener interface {
+	// Name identifies the screener in reasons, logs and metrics
+	Name() string
+	Screen(ctx context.Context, content string) ([]ScreeningFinding, error)
+}
+
+// ScreeningPolicy decides what happens to new pastes the screeners find suspicious
+type ScreeningPolicy struct {
+	Strictness string
+	// MaxAction is the harshest action taken, e.g. ScreeningFlag to only report suspicious pastes
+	MaxAction string
+	Timeout   time.Duration
+}
+
+// Validate checks the policy strictness and action
+func (p ScreeningPolicy) Validate() error {
+	if _, ok := screeningThresholds[p.Strictness]; !ok {
+		return fmt.Errorf("%w: strictness %q (supported: low, medium, high)", ErrInvalidScreening, p.Strictness)
+	}
+	if !slices.Contains(screeningActions, p.MaxAction) {
+		return fmt.Errorf("%w: action %q (supported: flag, quarantine, reject)", ErrInvalidScreening, p.MaxAction)
+	}
+	return nil
+}
+
+// Action returns the action taken on content of the given score, or "" to accept it as is
+func (p ScreeningPolicy) Action(score int) string {
+	thresholds := screeningThresholds[p.Strictness]
+	var action string
+	switch {
+	case score >= thresholds[2]:
+		action = ScreeningReject
+	case score >= thresholds[1]:
+		action = ScreeningQuarantine
+	case score >= thresholds[0]:
+		action = ScreeningFlag
+	default:
+		return ""
+	}
+	if slices.Index(screeningActions, action) > slices.Index(screeningActions, p.MaxAction) {
+		return p.MaxAction
+	}
+	return action
+}
+
+// SetScreening screens the content of new pastes with the screeners, flagging, quarantining or
+// rejecting them as the policy decides
+func (s *PasteService) SetScreening(policy ScreeningPolicy, screeners ...Screener) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	s.screeningPolicy = policy
+	s.screeners = screeners
+	return nil
+}
+
+// screen runs the screeners on the text of a new paste and returns the screening to record on it,
+// nil when the paste is accepted as is. Screeners that fail are skipped, so an unavailable
+// scanner does not block creates.
+func (s *PasteService) screen(ctx context.Context, text string) *model.Screening {
+	if len(s.screeners) == 0 || text == "" {
+		return nil
+	}
+	if s.screeningPolicy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.screeningPolicy.Timeout)
+		defer cancel()
+	}
+
+	var score int
+	var reasons []string
+	for _, screener := range s.screeners {
+		findings, err := screener.Screen(ctx, text)
+		if err != nil {
+			log.Printf("[PasteService.screen] Screener %s failed: %v", screener.Name(), err)
+			metrics.ScreenerErrors.WithLabelValues(screener.Name()).Inc()
+			continue
+		}
+		for _, finding := range findings {
+			score += finding.Score
+			reasons = append(reasons, screener.Name()+": "+finding.Reason)
+		}
+	}
+
+	action := s.screeningPolicy.Action(score)
+	if action == "" {
+		metrics.Screened.WithLabelValues("pass").Inc()
+		return nil
+	}
+	metrics.Screened.WithLabelValues(action).Inc()
+	return &model.Screening{
+		Action:  action,
+		Score:   score,
+		Reasons: reasons,
+		At:      time.Now(),
+	}
+}
+
+// screeningText returns the text of a new paste the screeners check: its title and description,
+// and its content unless the server cannot read it
+func screeningText(content, title, description string, readable bool) string {
+	var parts []string
+	for _, part := range []string{title, description} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if readable {
+		parts = append(parts, content)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// keywordScreener finds blacklisted keywords, case-insensitively
+type keywordScreener struct {
+	keywords []string
+}
+
+// NewKeywordScreener returns a screener scoring each distinct keyword found in the content
+func NewKeywordScreener(keywords []string) Screener {
+	screener := keywordScreener{}
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			screener.keywords = append(screener.keywords, keyword)
+		}
+	}
+	return screener
+}
+
+// Name identifies the screener
+func (keywordScreener) Name() string { return "keywords" }
+
+// Screen scores each keyword found in the content
+func (k keywordScreener) Screen(_ context.Context, content string) ([]ScreeningFinding, error) {
+	content = strings.ToLower(content)
+	var findings []ScreeningFinding
+	for _, keyword := range k.keywords {
+		if strings.Contains(content, keyword) {
+			findings = append(findings, ScreeningFinding{Reason: fmt.Sprintf("keyword %q", keyword), Score: ScoreKeyword})
+		}
+	}
+	return findings, nil
+}
+
+// linkPattern matches the links of content, up to the end of their host
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?|ftp)://[^\s/?#"'<>()\[\]]+`)
+
+// domainScreener finds links to blacklisted domains
+type domainScreener struct {
+	domains []string
+}
+
+// NewDomainScreener returns a screener scoring each distinct blacklisted domain, or subdomain
+// of one, linked to from the content
+func NewDomainScreener(domains []string) Screener {
+	screener := domainScreener{}
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain != "" {
+			screener.domains = append(screener.domains, domain)
+		}
+	}
+	return screener
+}
+
+// Name identifies the screener
+func (domainScreener) Name() string { return "domains" }
+
+// Screen scores each blacklisted domain linked to from the content
+func (d domainScreener) Screen(_ context.Context, content string) ([]ScreeningFinding, error) {
+	linked := make(map[string]bool)
+	for _, link := range linkPattern.FindAllString(content, -1) {
+		parsed, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		if host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), "."); host != "" {
+			linked[host] = true
+		}
+	}
+
+	var findings []ScreeningFinding
+	for _, domain := range d.domains {
+		for host := range linked {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				findings = append(findings, ScreeningFinding{Reason: "link to " + domain, Score: ScoreDeniedDomain})
+				break
+			}
+		}
+	}
+	return findings, nil
+}
+
+var (
+	// credentialPattern matches an email:password line of a credential dump; ";" and "|" are
+	// common separators too
+	credentialPattern = regexp.MustCompile(`(?i)^[^\s@:;|]+@[a-z0-9.-]+\.[a-z]{2,}\s*[:;|]\s*\S+$`)
+	// assignedSecretPattern matches a value assigned to a key named like a secret
+	assignedSecretPattern = regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|pwd|access[_-]?key|private[_-]?key)["']?\s*[:=]\s*["']?([A-Za-z0-9+/=_.\-]{12,})`)
+	// knownSecretPattern matches credentials of well-known formats, recognizable without a key
+	knownSecretPattern = regexp.MustCompile(`AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36}|xox[abprs]-[A-Za-z0-9-]{10,}|sk_live_[A-Za-z0-9]{16,}|-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+)
+
+// credentialScreener finds dumps of leaked credentials: lists of email:password combinations
+// and pastes holding many secrets
+type credentialScreener struct {
+	minLines   int
+	minSecrets int
+}
+
+// NewCredentialScreener returns a screener finding credential dumps of at least minLines
+// email:password lines, and secrets dumps of at least minSecrets distinct secrets: values of
+// well-known credential formats, or high-entropy values assigned to keys named like secrets.
+// A zero minimum disables the check.
+func NewCredentialScreener(minLines, minSecrets int) Screener {
+	return credentialScreener{minLines: minLines, minSecrets: minSecrets}
+}
+
+// Name identifies the screener
+func (credentialScreener) Name() string { return "credentials" }
+
+// Screen counts the credentials of the content
+func (c credentialScreener) Screen(_ context.Context, content string) ([]ScreeningFinding, error) {
+	var findings []ScreeningFinding
+	if c.minLines > 0 {
+		var lines int
+		for _, line := range strings.Split(content, "\n") {
+			if credentialPattern.MatchString(strings.TrimSpace(line)) {
+				lines++
+			}
+		}
+		if lines >= c.minLines {
+			findings = append(findings, ScreeningFinding{
+				Reason: fmt.Sprintf("credential dump of %d email:password lines", lines),
+				Score:  ScoreCredentialsDump,
+			})
+		}
+	}
+
+	if c.minSecrets > 0 {
+		secrets := make(map[string]bool)
+		for _, secret := range knownSecretPattern.FindAllString(content, -1) {
+			secrets[secret] = true
+		}
+		for _, match := range assignedSecretPattern.FindAllStringSubmatch(content, -1) {
+			if shannonEntropy(match[1]) >= secretEntropy {
+				secrets[match[1]] = true
+			}
+		}
+		if len(secrets) >= c.minSecrets {
+			findings = append(findings, ScreeningFinding{
+				Reason: fmt.Sprintf("secrets dump of %d secrets", len(secrets)),
+				Score:  ScoreSecretsDump,
+			})
+		}
+	}
+	return findings, nil
+}
+
+// shannonEntropy returns the Shannon entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	var n int
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var entropy float64
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// HTTPScanner screens content with an external scanning service by POSTing it as JSON:
+//
+//	{"content": "..."}
+//
+// and expects a 200 answer of the form, scores ranging from 0 (clean) to 100:
+//
+//	{"score": 80, "reasons": ["phishing kit"]}
+type HTTPScanner struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// NewHTTPScanner creates an HTTPScanner posting to endpoint; a non-empty token is sent as a bearer token
+func NewHTTPScanner(endpoint, token string) *HTTPScanner {
+	return &HTTPScanner{
+		endpoint: endpoint,
+		token:    token,
+		// Calls are bounded by the screening timeout
+		client: &http.Client{},
+	}
+}
+
+// httpScanResponse is the answer of the scanner
+type httpScanResponse struct {
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Name identifies the screener
+func (*HTTPScanner) Name() string { return "scanner" }
+
+// Screen sends the content to the scanner and returns its verdict as a single finding
+func (h *HTTPScanner) Screen(ctx context.Context, content string) ([]ScreeningFinding, error) {
+	body, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scanner answered status %d", resp.StatusCode)
+	}
+	var out httpScanResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("invalid scanner answer: %w", err)
+	}
+	score := min(max(out.Score, 0), 100)
+	if score == 0 {
+		return nil, nil
+	}
+	reason := strings.Join(out.Reasons, "; ")
+	if reason == "" {
+		reason = fmt.Sprintf("score %d", score)
+	}
+	return []ScreeningFinding{{Reason: reason, Score: score}}, nil
+}
+
+// ScreenedPaste is a paste flagged or quarantined by content screening
+type ScreenedPaste struct {
+	PasteSummary
+	Action     string   `json:"action" example:"flag"`
+	Score      int      `json:"score" example:"50"`
+	Reasons    []string `json:"reasons" example:"domains: link to spam.example"`
+	ScreenedAt string   `json:"screened_at" example:"2024-01-15T14:00:00Z"`
+}
+
+// ScreeningReport lists the pastes flagged or quarantined by content screening
+type ScreeningReport struct {
+	// Count is the number of screened pastes awaiting review; Pastes lists up to the limit of
+	// them, most recent first
+	Count  int64           `json:"count" example:"1"`
+	Pastes []ScreenedPaste `json:"pastes"`
+	// Truncated is set when more pastes await review than the limit allowed
+	Truncated bool `json:"truncated" example:"false"`
+}
+
+// ListScreened returns up to limit pastes flagged or quarantined by content screening, for
+// operators to review
+func (s *PasteService) ListScreened(ctx context.Context, limit int) (*ScreeningReport, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	count, err := s.pasteRepo.CountScreened(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to count screened pastes: %w", err)
+	}
+	pastes, err := s.pasteRepo.ListScreened(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list screened pastes: %w", err)
+	}
+
+	report := &ScreeningReport{
+		Count:     count,
+		Pastes:    make([]ScreenedPaste, 0, len(pastes)),
+		Truncated: count > int64(len(pastes)),
+	}
+	for _, paste := range pastes {
+		report.Pastes = append(report.Pastes, ScreenedPaste{
+			PasteSummary: toPasteSummary(paste),
+			Action:       paste.Screening.Action,
+			Score:        paste.Screening.Score,
+			Reasons:      paste.Screening.Reasons,
+			ScreenedAt:   paste.Screening.At.UTC().Format(time.RFC3339),
+		})
+	}
+	return report, nil
+}
+
+// DismissScreening clears the screening of a paste found legitimate on review, serving it again
+// if screening quarantined it
+func (s *PasteService) DismissScreening(ctx context.Context, shortID string) error {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return ErrPasteNotFound
+		}
+		return fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.Screening == nil {
+		return ErrPasteNotScreened
+	}
+
+	if err := s.pasteRepo.DismissScreening(ctx, shortID, paste.Screening.Action == ScreeningQuarantine); err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return ErrPasteNotFound
+		}
+		return fmt.Errorf("paste: failed to dismiss screening: %w", err)
+	}
+	log.Printf("[PasteService.DismissScreening] Dismissed: %s", shortID)
+	return nil
+}
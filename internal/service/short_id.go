@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// ErrShortIDAmbiguous is returned when a short ID matches several pastes regardless of case. It
+// wraps ErrPasteNotFound: no paste has the short ID as given.
+var ErrShortIDAmbiguous = fmt.Errorf("%w: short ID matches several pastes", ErrPasteNotFound)
+
+// SetCaseInsensitiveIDs makes ResolveShortID find pastes from short IDs retyped in another case
+func (s *PasteService) SetCaseInsensitiveIDs(ctx context.Context) error {
+	if err := s.pasteRepo.EnsureCaseInsensitiveIndex(ctx); err != nil {
+		return err
+	}
+	s.caseInsensitiveIDs = true
+	return nil
+}
+
+// ResolveShortID returns the short ID of the paste equal to shortID regardless of case, for a
+// shortID no paste has as given. It returns ErrPasteNotFound when lookups are case-sensitive or
+// no paste matches, and ErrShortIDAmbiguous when several pastes do.
+func (s *PasteService) ResolveShortID(ctx context.Context, shortID string) (string, error) {
+	if !s.caseInsensitiveIDs {
+		return "", ErrPasteNotFound
+	}
+
+	shortIDs, err := s.pasteRepo.FindShortIDsIgnoreCase(ctx, shortID, 2)
+	if err != nil {
+		return "", fmt.Errorf("paste: failed to resolve short ID: %w", err)
+	}
+	switch len(shortIDs) {
+	case 0:
+		return "", ErrPasteNotFound
+	case 1:
+		return shortIDs[0], nil
+	default:
+		log.Printf("[PasteService.ResolveShortID] %s matches several pastes regardless of case", shortID)
+		return "", ErrShortIDAmbiguous
+	}
+}
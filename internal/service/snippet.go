@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+var (
+	// ErrInvalidLineRange is returned for a snippet range that is empty or past the end of the content
+	ErrInvalidLineRange = errors.New("paste: invalid line range")
+	// ErrNotSnippable is returned for pastes that cannot be cut into snippets: burn-after-read and
+	// view-limited pastes, whose content must not outlive their reads, and encrypted and binary
+	// pastes, whose content has no lines the server can read
+	ErrNotSnippable = errors.New("paste: content cannot be cut into a snippet")
+)
+
+// SnippetRequest represents the request to cut a snippet out of a paste
+type SnippetRequest struct {
+	StartLine int    `json:"start_line"` // first line of the snippet, 1-based
+	EndLine   int    `json:"end_line"`   // last line of the snippet, inclusive; 0 means StartLine
+	ExpiresIn string `json:"expires_in"` // expiration of the snippet, as for a new paste
+	IsPrivate bool   `json:"is_private"`
+	Title     string `json:"title"` // defaults to the title of the source
+
+	// SourceIP is the caller's IP, set by the handler; only its keyed hash is stored
+	SourceIP string `json:"-"`
+	// OwnerID identifies the caller's API key or anonymous session, set by the handler
+	OwnerID string `json:"-"`
+	// UserID is the ID of the signed-in user, set by the handler
+	UserID string `json:"-"`
+}
+
+// CreateSnippet creates a new paste holding a range of lines of a paste, recording the source and
+// the range in derived_from so views keep the original line numbers. Like a fork, the snippet is
+// created like any new paste and reading the source does not count a view.
+func (s *PasteService) CreateSnippet(ctx context.Context, shortID string, req *SnippetRequest) (*CreatePasteResponse, error) {
+	start, end := req.StartLine, req.EndLine
+	if end == 0 {
+		end = start
+	}
+	if start < 1 || end < start {
+		return nil, ErrInvalidLineRange
+	}
+
+	paste, content, err := s.readSource(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+	if paste.BurnAfterRead || paste.MaxViews > 0 || paste.Encrypted || paste.Binary {
+		return nil, ErrNotSnippable
+	}
+
+	lines := strings.Split(content, "\n")
+	if end > len(lines) {
+		return nil, ErrInvalidLineRange
+	}
+	snippet := strings.Join(lines[start-1:end], "\n")
+	if strings.TrimSpace(snippet) == "" {
+		return nil, ErrInvalidLineRange
+	}
+
+	title := req.Title
+	if title == "" {
+		title = paste.Title
+	}
+	response, err := s.CreatePaste(ctx, &CreatePasteRequest{
+		Content:     snippet,
+		SyntaxType:  paste.SyntaxType,
+		ExpiresIn:   req.ExpiresIn,
+		IsPrivate:   req.IsPrivate,
+		Title:       title,
+		Description: paste.Description,
+		Tags:        paste.Tags,
+		SourceIP:    req.SourceIP,
+		OwnerID:     req.OwnerID,
+		UserID:      req.UserID,
+		DerivedFrom: &model.DerivedFrom{ShortID: shortID, StartLine: start, EndLine: end},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[PasteService.CreateSnippet] Success: short_id=%s, lines=%d-%d, snippet=%s", shortID, start, end, response.ShortID)
+	return response, nil
+}
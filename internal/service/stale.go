@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultStaleDays is the number of days without a read after which a paste that never
+	// expires is reported as stale
+	DefaultStaleDays = 180
+	// MaxStaleDays caps the days of a stale report
+	MaxStaleDays = 3650
+	// staleStatConcurrency bounds the content sizes a stale report reads from S3 at once
+	staleStatConcurrency = 16
+)
+
+// ErrInvalidStaleDays is returned when the days of a stale report are out of range
+var ErrInvalidStaleDays = errors.New("paste: invalid stale days")
+
+// StalePaste is a paste listed in a stale report
+type StalePaste struct {
+	PasteSummary
+	// LastReadAt is empty for pastes never read since read times were recorded
+	LastReadAt string `json:"last_read_at,omitempty" example:"2023-06-01T10:00:00Z"`
+	// Size is the stored size of the current content in bytes, 0 when it could not be read
+	Size int64 `json:"size" example:"2048"`
+}
+
+// StaleReport lists the pastes that never expire and were not read for a number of days
+type StaleReport struct {
+	Days        int    `json:"days" example:"180"`
+	Cutoff      string `json:"cutoff" example:"2023-07-19T14:00:00Z"`
+	GeneratedAt string `json:"generated_at" example:"2024-01-15T14:00:00Z"`
+	// Count is the number of stale pastes; Pastes lists up to the limit of them, oldest first
+	Count  int64        `json:"count" example:"1200"`
+	Pastes []StalePaste `json:"pastes"`
+	// TotalSize is the stored size of the listed pastes in bytes, without their revisions
+	TotalSize int64 `json:"total_size" example:"2457600"`
+	// EstimatedTotalSize extrapolates TotalSize to all Count stale pastes from the average size of
+	// the listed ones whose size was read; it equals TotalSize when all of them were
+	EstimatedTotalSize int64 `json:"estimated_total_size" example:"29491200"`
+	// Truncated is set when more pastes are stale than the limit allowed
+	Truncated bool `json:"truncated" example:"true"`
+}
+
+// staleCutoff returns the time before which a paste not read is stale
+func staleCutoff(now time.Time, days int) (time.Time, error) {
+	if days < 1 || days > MaxStaleDays {
+		return time.Time{}, fmt.Errorf("%w: %d (1 to %d)", ErrInvalidStaleDays, days, MaxStaleDays)
+	}
+	return now.AddDate(0, 0, -days), nil
+}
+
+// StaleReport returns the pastes that never expire and were not read for days, with the storage
+// they take, to reclaim the space of forgotten pastes. Pastes never read since read times were
+// recorded count from their creation.
+func (s *PasteService) StaleReport(ctx context.Context, days, limit int) (*StaleReport, error) {
+	now := time.Now()
+	cutoff, err := staleCutoff(now, days)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	count, err := s.pasteRepo.CountStale(ctx, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to count stale pastes: %w", err)
+	}
+	pastes, err := s.pasteRepo.ListStale(ctx, cutoff, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list stale pastes: %w", err)
+	}
+
+	report := &StaleReport{
+		Days:        days,
+		Cutoff:      cutoff.UTC().Format(time.RFC3339),
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+		Count:       count,
+		Pastes:      make([]StalePaste, 0, len(pastes)),
+		Truncated:   count > int64(len(pastes)),
+	}
+	for _, paste := range pastes {
+		stale := StalePaste{PasteSummary: toPasteSummary(paste)}
+		if paste.LastReadAt != nil {
+			stale.LastReadAt = paste.LastReadAt.UTC().Format(time.RFC3339)
+		}
+		report.Pastes = append(report.Pastes, stale)
+	}
+
+	sized := s.statStalePastes(ctx, report.Pastes)
+	for _, stale := range report.Pastes {
+		report.TotalSize += stale.Size
+	}
+	report.EstimatedTotalSize = estimateStaleSize(report.TotalSize, sized, count)
+	return report, nil
+}
+
+// estimateStaleSize extrapolates the total size of the sized listed pastes to count pastes
+func estimateStaleSize(total int64, sized int, count int64) int64 {
+	if sized == 0 || count <= int64(sized) {
+		return total
+	}
+	return int64(float64(total) / float64(sized) * float64(count))
+}
+
+// statStalePastes reads the stored size of the listed pastes, staleStatConcurrency at a time,
+// and returns the number of sizes read
+func (s *PasteService) statStalePastes(ctx context.Context, pastes []StalePaste) int {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		sized int
+	)
+	slots := make(chan struct{}, staleStatConcurrency)
+	for i := range pastes {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			info, err := s.storage.StatContent(ctx, pastes[i].ShortID)
+			if err != nil {
+				log.Printf("[PasteService.StaleReport] Failed to stat content of %s: %v", pastes[i].ShortID, err)
+				return
+			}
+			pastes[i].Size = info.Size
+			mu.Lock()
+			sized++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return sized
+}
+
+// ExpireStalePastes expires at once every paste that never expires and was not read for days,
+// and returns how many. The TTL monitor then removes them and the cleanup worker their content.
+func (s *PasteService) ExpireStalePastes(ctx context.Context, days int) (int64, error) {
+	now := time.Now()
+	cutoff, err := staleCutoff(now, days)
+	if err != nil {
+		return 0, err
+	}
+
+	expired, err := s.pasteRepo.ExpireStale(ctx, cutoff, now)
+	if err != nil {
+		return 0, fmt.Errorf("paste: failed to expire stale pastes: %w", err)
+	}
+	log.Printf("[PasteService.ExpireStalePastes] Expired %d pastes not read for %d days", expired, days)
+	return expired, nil
+}
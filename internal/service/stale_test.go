@@ -0,0 +1,23 @@
+package service
+
+import "testing"
+
+func TestEstimateStaleSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int64
+		sized int
+		count int64
+		want  int64
+	}{
+		{"all listed", 3000, 3, 3, 3000},
+		{"truncated", 3000, 3, 30, 30000},
+		{"some sizes unread", 3000, 2, 4, 6000},
+		{"no size read", 0, 0, 50, 0},
+	}
+	for _, tt := range tests {
+		if got := estimateStaleSize(tt.total, tt.sized, tt.count); got != tt.want {
+			t.Errorf("estimateStaleSize(%s) = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,18 +1,27 @@
 package service
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/huylvt/gisty/internal/repository"
+	"github.com/huylvt/gisty/internal/timing"
 )
 
 const (
@@ -20,6 +29,15 @@ const (
 	S3KeyPrefix = "gisty/"
 	// S3KeySuffix is the suffix for gzipped content
 	S3KeySuffix = ".gz"
+	// S3RevisionPrefix groups previous versions of edited pastes under S3KeyPrefix
+	S3RevisionPrefix = "revisions/"
+	// DefaultMaxDecompressedSize is the default maximum size in bytes of content read back from storage
+	DefaultMaxDecompressedSize = 64 * 1024 * 1024
+	// MaxCompressionRatio is the maximum decompressed-to-compressed size ratio accepted for uploaded gzip content
+	MaxCompressionRatio = 100
+	// StreamPartSize is the size of the parts content is uploaded in when it compresses to more
+	// than one part, and so the most a streaming upload holds in memory (the S3 minimum part size)
+	StreamPartSize = 5 * 1024 * 1024
 )
 
 var (
@@ -27,55 +45,310 @@ var (
 	ErrContentNotFound = errors.New("storage: content not found")
 	// ErrAccessDenied is returned when access to the content is denied
 	ErrAccessDenied = errors.New("storage: access denied")
+	// ErrMultipartUploadNotFound is returned when a multipart upload does not exist or was already completed
+	ErrMultipartUploadNotFound = errors.New("storage: multipart upload not found")
+	// ErrInvalidMultipartUpload is returned when S3 rejects the parts of a multipart upload
+	ErrInvalidMultipartUpload = errors.New("storage: invalid multipart upload")
+	// ErrDecompressionBomb is returned when content exceeds the decompressed size or compression ratio limits
+	ErrDecompressionBomb = errors.New("storage: content exceeds decompression limits")
 )
 
 // Storage handles content storage operations
 type Storage struct {
-	s3Client   *repository.S3
-	bucketName string
+	s3Client            *repository.S3
+	bucketName          string
+	maxDecompressedSize int64
+	compression         Compression
 }
 
 // NewStorage creates a new Storage service
 func NewStorage(s3Client *repository.S3) *Storage {
+	return NewStorageWithLimit(s3Client, DefaultMaxDecompressedSize)
+}
+
+// NewStorageWithLimit creates a new Storage service that refuses to read back content
+// larger than maxDecompressedSize bytes
+func NewStorageWithLimit(s3Client *repository.S3, maxDecompressedSize int64) *Storage {
+	if maxDecompressedSize <= 0 {
+		maxDecompressedSize = DefaultMaxDecompressedSize
+	}
+
 	log.Printf("[Storage] Initialized with bucket: %s", s3Client.BucketName)
 	return &Storage{
-		s3Client:   s3Client,
-		bucketName: s3Client.BucketName,
+		s3Client:            s3Client,
+		bucketName:          s3Client.BucketName,
+		maxDecompressedSize: maxDecompressedSize,
+		compression:         CompressionGzip,
 	}
 }
 
-// SaveContent saves content to S3 with gzip compression
+// SetCompression sets the codec content is written with. Content written with any other codec
+// is still read back.
+func (s *Storage) SetCompression(compression Compression) {
+	s.compression = compression
+}
+
+// SaveContent saves content to S3, compressed
 func (s *Storage) SaveContent(ctx context.Context, shortID, content string) error {
-	// Compress content with gzip
-	compressed, err := compressContent(content)
-	if err != nil {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	_, err := s.putCompressed(ctx, s.buildKey(shortID), strings.NewReader(content))
+	return err
+}
+
+// SaveContentStream compresses r on the fly and uploads it, holding at most one upload
+// part in memory whatever the size of the content. It returns the number of bytes read from r.
+func (s *Storage) SaveContentStream(ctx context.Context, shortID string, r io.Reader) (int64, error) {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	return s.putCompressed(ctx, s.buildKey(shortID), r)
+}
+
+// GetContent retrieves and decompresses content from S3
+func (s *Storage) GetContent(ctx context.Context, shortID string) (string, error) {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	return s.getDecompressed(ctx, s.buildKey(shortID))
+}
+
+// OpenContent returns a reader decompressing the content from S3 as it is read, so large
+// content can be served without holding it in memory. Reading fails with ErrDecompressionBomb
+// past the decompressed size limit. The caller must close the reader.
+func (s *Storage) OpenContent(ctx context.Context, shortID string) (io.ReadCloser, error) {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	return s.openDecompressed(ctx, s.buildKey(shortID))
+}
+
+// GetRevisionContent retrieves and decompresses the content of a previous version of a paste
+func (s *Storage) GetRevisionContent(ctx context.Context, shortID string, revision int) (string, error) {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	return s.getDecompressed(ctx, s.buildRevisionKey(shortID, revision))
+}
+
+// ReplaceRevisionContent overwrites the content of a previous version of a paste
+func (s *Storage) ReplaceRevisionContent(ctx context.Context, shortID string, revision int, content string) error {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	_, err := s.putCompressed(ctx, s.buildRevisionKey(shortID, revision), strings.NewReader(content))
+	return err
+}
+
+// putCompressed compresses r through a pipe and uploads it under the given key. Content
+// compressing to less than StreamPartSize is uploaded with a single PutObject; larger content
+// is uploaded as a multipart upload, one part at a time. It returns the bytes read from r.
+func (s *Storage) putCompressed(ctx context.Context, key string, r io.Reader) (int64, error) {
+	pr, pw := io.Pipe()
+	// Closing the reader stops the compressor if the upload fails half-way
+	defer pr.Close()
+
+	var read atomic.Int64
+	go func() {
+		w, err := s.compression.newWriter(pw)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		n, err := io.Copy(w, r)
+		read.Store(n)
+		if err == nil {
+			err = w.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	part := make([]byte, StreamPartSize)
+	n, err := io.ReadFull(pr, part)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
 		log.Printf("[Storage.SaveContent] Compression failed: %v", err)
-		return fmt.Errorf("storage: failed to compress content: %w", err)
+		return 0, fmt.Errorf("storage: failed to compress content: %w", err)
 	}
+	if err != nil {
+		// The whole compressed content fits in one part
+		log.Printf("[Storage.SaveContent] Uploading to bucket=%s, key=%s, size=%d bytes (compressed from %d)",
+			s.bucketName, key, n, read.Load())
 
-	key := s.buildKey(shortID)
-	log.Printf("[Storage.SaveContent] Uploading to bucket=%s, key=%s, size=%d bytes (compressed from %d)",
-		s.bucketName, key, len(compressed), len(content))
+		// Note: ContentEncoding and Metadata headers removed due to Ceph S3 compatibility issues
+		// Content is still compressed, we handle decompression on read
+		_, err = s.s3Client.Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(s.bucketName),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(part[:n]),
+			ContentType: aws.String("application/octet-stream"),
+		})
+		if err != nil {
+			log.Printf("[Storage.SaveContent] PutObject failed: bucket=%s, key=%s, error=%v", s.bucketName, key, err)
+			return 0, fmt.Errorf("storage: failed to upload content: %w", err)
+		}
+
+		log.Printf("[Storage.SaveContent] Upload successful: %s", key)
+		return read.Load(), nil
+	}
+
+	compressed, err := s.putMultipart(ctx, key, pr, part)
+	if err != nil {
+		log.Printf("[Storage.SaveContent] Multipart upload failed: bucket=%s, key=%s, error=%v", s.bucketName, key, err)
+		return 0, err
+	}
+
+	log.Printf("[Storage.SaveContent] Upload successful: %s, size=%d bytes (compressed from %d)", key, compressed, read.Load())
+	return read.Load(), nil
+}
 
-	// Note: ContentEncoding and Metadata headers removed due to Ceph S3 compatibility issues
-	// Content is still gzip compressed, we handle decompression on read
-	_, err = s.s3Client.Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      aws.String(s.bucketName),
-		Key:         aws.String(key),
-		Body:        bytes.NewReader(compressed),
-		ContentType: aws.String("application/octet-stream"),
+// putMultipart uploads part, then the rest of r in parts of the same size, as one object. The
+// upload is aborted on failure. It returns the size of the object.
+func (s *Storage) putMultipart(ctx context.Context, key string, r io.Reader, part []byte) (int64, error) {
+	created, err := s.s3Client.Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(s.bucketName),
+		Key:               aws.String(key),
+		ContentType:       aws.String("application/octet-stream"),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
 	})
 	if err != nil {
-		log.Printf("[Storage.SaveContent] PutObject failed: bucket=%s, key=%s, error=%v", s.bucketName, key, err)
-		return fmt.Errorf("storage: failed to upload content: %w", err)
+		return 0, fmt.Errorf("storage: failed to create multipart upload: %w", err)
 	}
+	uploadID := created.UploadId
 
-	log.Printf("[Storage.SaveContent] Upload successful: %s", key)
-	return nil
+	abort := func() {
+		// The request may be gone already; abort anyway so the parts are not kept
+		_, err := s.s3Client.Client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucketName),
+			Key:      aws.String(key),
+			UploadId: uploadID,
+		})
+		if err != nil {
+			log.Printf("[Storage.SaveContent] Failed to abort multipart upload of %s: %v", key, err)
+		}
+	}
+
+	var size int64
+	var completed []types.CompletedPart
+	n := len(part)
+	for partNumber := int32(1); n > 0; partNumber++ {
+		uploaded, err := s.s3Client.Client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:            aws.String(s.bucketName),
+			Key:               aws.String(key),
+			UploadId:          uploadID,
+			PartNumber:        aws.Int32(partNumber),
+			Body:              bytes.NewReader(part[:n]),
+			ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+		})
+		if err != nil {
+			abort()
+			return 0, fmt.Errorf("storage: failed to upload part %d: %w", partNumber, err)
+		}
+		completed = append(completed, types.CompletedPart{
+			PartNumber:     aws.Int32(partNumber),
+			ETag:           uploaded.ETag,
+			ChecksumSHA256: uploaded.ChecksumSHA256,
+		})
+		size += int64(n)
+
+		n, err = io.ReadFull(r, part)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			abort()
+			return 0, fmt.Errorf("storage: failed to compress content: %w", err)
+		}
+	}
+
+	_, err = s.s3Client.Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucketName),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		abort()
+		return 0, s.handleMultipartError(err)
+	}
+	return size, nil
 }
 
-// GetContent retrieves and decompresses content from S3
-func (s *Storage) GetContent(ctx context.Context, shortID string) (string, error) {
+// getDecompressed downloads the object under the given key and decompresses it
+func (s *Storage) getDecompressed(ctx context.Context, key string) (string, error) {
+	reader, err := s.openDecompressed(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		if errors.Is(err, ErrDecompressionBomb) {
+			log.Printf("[Storage.GetContent] Content exceeds %d bytes: %s", s.maxDecompressedSize, key)
+			return "", err
+		}
+		return "", fmt.Errorf("storage: failed to decompress content: %w", err)
+	}
+
+	return string(content), nil
+}
+
+// openDecompressed opens the object under the given key for reading, decompressing it on the
+// fly when it is compressed. Objects uploaded directly by clients are stored as-is and read
+// unchanged. Reading fails with ErrDecompressionBomb past maxDecompressedSize bytes.
+func (s *Storage) openDecompressed(ctx context.Context, key string) (io.ReadCloser, error) {
+	body, closer, compression, err := s.openObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := newDecompressor(compression, body)
+	if err != nil {
+		closer.Close()
+		return nil, fmt.Errorf("storage: failed to decompress content: %w", err)
+	}
+	return &limitedReadCloser{Reader: reader, Closer: closers{reader, closer}, remaining: s.maxDecompressedSize}, nil
+}
+
+// OpenCompressed returns a reader of the content as stored in S3 when it is gzip compressed, so it
+// can be sent to clients accepting gzip without decompressing and compressing it again. It reports
+// whether the reader yields gzip compressed bytes; other content is read decompressed, as
+// OpenContent would. The caller must close the reader.
+func (s *Storage) OpenCompressed(ctx context.Context, shortID string) (io.ReadCloser, bool, error) {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	body, closer, compression, err := s.openObject(ctx, s.buildKey(shortID))
+	if err != nil {
+		return nil, false, err
+	}
+	if compression == CompressionGzip {
+		// Clients decompress the content themselves, so it is not limited here
+		return &readCloser{Reader: body, Closer: closer}, true, nil
+	}
+
+	reader, err := newDecompressor(compression, body)
+	if err != nil {
+		closer.Close()
+		return nil, false, fmt.Errorf("storage: failed to decompress content: %w", err)
+	}
+	return &limitedReadCloser{Reader: reader, Closer: closers{reader, closer}, remaining: s.maxDecompressedSize}, false, nil
+}
+
+// openObject opens the object under the given key and returns the codec it is compressed with
+func (s *Storage) openObject(ctx context.Context, key string) (*bufio.Reader, io.Closer, Compression, error) {
+	result, err := s.s3Client.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, nil, compressionNone, s.handleS3Error(err)
+	}
+
+	body := bufio.NewReader(result.Body)
+	magic, err := body.Peek(compressionMagicLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		result.Body.Close()
+		return nil, nil, compressionNone, fmt.Errorf("storage: failed to read content: %w", err)
+	}
+	return body, result.Body, detectCompression(magic), nil
+}
+
+// CheckCompression verifies that a client-uploaded object, if compressed, stays within the
+// decompressed size and compression ratio limits. Content compressed by SaveContent is bounded by
+// MaxContentSize and does not need the check.
+func (s *Storage) CheckCompression(ctx context.Context, shortID string) error {
 	key := s.buildKey(shortID)
 
 	result, err := s.s3Client.Client.GetObject(ctx, &s3.GetObjectInput{
@@ -83,27 +356,46 @@ func (s *Storage) GetContent(ctx context.Context, shortID string) (string, error
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return "", s.handleS3Error(err)
+		return s.handleS3Error(err)
 	}
 	defer result.Body.Close()
 
-	// Read compressed data
-	compressed, err := io.ReadAll(result.Body)
+	body := bufio.NewReader(result.Body)
+	magic, err := body.Peek(compressionMagicLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("storage: failed to read content: %w", err)
+	}
+	compression := detectCompression(magic)
+	if compression == compressionNone {
+		return nil
+	}
+
+	reader, err := newDecompressor(compression, body)
 	if err != nil {
-		return "", fmt.Errorf("storage: failed to read content: %w", err)
+		return fmt.Errorf("storage: failed to decompress content: %w", err)
 	}
+	defer reader.Close()
 
-	// Decompress content
-	content, err := decompressContent(compressed)
+	// Decompress into the void, stopping as soon as the limit is exceeded
+	decompressed, err := io.Copy(io.Discard, io.LimitReader(reader, s.maxDecompressedSize+1))
 	if err != nil {
-		return "", fmt.Errorf("storage: failed to decompress content: %w", err)
+		return fmt.Errorf("storage: failed to decompress content: %w", err)
+	}
+
+	compressed := aws.ToInt64(result.ContentLength)
+	if decompressed > s.maxDecompressedSize || (compressed > 0 && decompressed/compressed > MaxCompressionRatio) {
+		log.Printf("[Storage.CheckCompression] Rejecting %s: %d bytes compressed, at least %d decompressed",
+			key, compressed, decompressed)
+		return ErrDecompressionBomb
 	}
 
-	return content, nil
+	return nil
 }
 
 // DeleteContent removes content from S3
 func (s *Storage) DeleteContent(ctx context.Context, shortID string) error {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
 	key := s.buildKey(shortID)
 
 	_, err := s.s3Client.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -117,6 +409,294 @@ func (s *Storage) DeleteContent(ctx context.Context, shortID string) error {
 	return nil
 }
 
+// SaveRevision copies the current content of a paste to the storage key of the given revision
+func (s *Storage) SaveRevision(ctx context.Context, shortID string, revision int) error {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	key := s.buildRevisionKey(shortID, revision)
+	_, err := s.s3Client.Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucketName),
+		Key:        aws.String(key),
+		CopySource: aws.String(s.bucketName + "/" + s.buildKey(shortID)), // keys are base62, no escaping needed
+	})
+	if err != nil {
+		return s.handleS3Error(err)
+	}
+
+	return nil
+}
+
+// DeleteRevision removes the content of a revision from S3
+func (s *Storage) DeleteRevision(ctx context.Context, shortID string, revision int) error {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	_, err := s.s3Client.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.buildRevisionKey(shortID, revision)),
+	})
+	if err != nil {
+		return fmt.Errorf("storage: failed to delete revision: %w", err)
+	}
+
+	return nil
+}
+
+// PresignedRequest describes a pre-signed S3 request the client performs directly
+type PresignedRequest struct {
+	URL       string
+	Method    string
+	Headers   map[string]string
+	ExpiresAt time.Time
+}
+
+// ObjectInfo holds the metadata of a stored object
+type ObjectInfo struct {
+	Size int64
+	// ChecksumSHA256 is the base64-encoded SHA-256 reported by S3, empty if the backend does not track it
+	ChecksumSHA256 string
+	// Compression is the codec the object is compressed with, set by ProbeContent
+	Compression Compression
+}
+
+// PresignUpload returns a pre-signed PUT request for uploading content of the given size directly to S3.
+// The size and base64-encoded SHA-256 checksum are part of the signature, so S3 rejects any other body.
+func (s *Storage) PresignUpload(ctx context.Context, shortID string, size int64, checksumSHA256 string, expiry time.Duration) (*PresignedRequest, error) {
+	key := s.buildKey(shortID)
+
+	presignClient := s3.NewPresignClient(s.s3Client.Client)
+	req, err := presignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:         aws.String(s.bucketName),
+		Key:            aws.String(key),
+		ContentLength:  aws.Int64(size),
+		ContentType:    aws.String("application/octet-stream"),
+		ChecksumSHA256: aws.String(checksumSHA256),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to presign upload: %w", err)
+	}
+
+	return toPresignedRequest(req.URL, req.Method, req.SignedHeader, expiry), nil
+}
+
+// StatContent returns the size and checksum of the stored content without downloading it
+func (s *Storage) StatContent(ctx context.Context, shortID string) (*ObjectInfo, error) {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	key := s.buildKey(shortID)
+
+	result, err := s.s3Client.Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket:       aws.String(s.bucketName),
+		Key:          aws.String(key),
+		ChecksumMode: types.ChecksumModeEnabled,
+	})
+	if err != nil {
+		return nil, s.handleS3Error(err)
+	}
+
+	return &ObjectInfo{
+		Size:           aws.ToInt64(result.ContentLength),
+		ChecksumSHA256: aws.ToString(result.ChecksumSHA256),
+	}, nil
+}
+
+// ProbeContent returns the stored size and the compression of the content, reading only the
+// bytes the codec is told from
+func (s *Storage) ProbeContent(ctx context.Context, shortID string) (*ObjectInfo, error) {
+	defer timing.Track(ctx, timing.PhaseStorage)()
+
+	result, err := s.s3Client.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(s.buildKey(shortID)),
+		Range:  aws.String(fmt.Sprintf("bytes=0-%d", compressionMagicLength-1)),
+	})
+	if err != nil {
+		return nil, s.handleS3Error(err)
+	}
+	defer result.Body.Close()
+
+	magic, err := io.ReadAll(io.LimitReader(result.Body, compressionMagicLength))
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to read content: %w", err)
+	}
+
+	// The size of the whole object follows the range in Content-Range: "bytes 0-3/12345"
+	size := aws.ToInt64(result.ContentLength)
+	if _, total, ok := strings.Cut(aws.ToString(result.ContentRange), "/"); ok {
+		if n, err := strconv.ParseInt(total, 10, 64); err == nil {
+			size = n
+		}
+	}
+	return &ObjectInfo{Size: size, Compression: detectCompression(magic)}, nil
+}
+
+// PresignDownload returns a pre-signed GET request for the stored content, answered with the given
+// content type. S3 sends compressed content as stored, with a Content-Encoding naming its codec,
+// so HTTP clients supporting it decompress the content on the way.
+func (s *Storage) PresignDownload(ctx context.Context, shortID, contentType string, compression Compression, expiry time.Duration) (*PresignedRequest, error) {
+	input := &s3.GetObjectInput{
+		Bucket:              aws.String(s.bucketName),
+		Key:                 aws.String(s.buildKey(shortID)),
+		ResponseContentType: aws.String(contentType),
+	}
+	if compression != compressionNone {
+		input.ResponseContentEncoding = aws.String(string(compression))
+	}
+
+	presignClient := s3.NewPresignClient(s.s3Client.Client)
+	req, err := presignClient.PresignGetObject(ctx, input, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to presign download: %w", err)
+	}
+
+	return toPresignedRequest(req.URL, req.Method, req.SignedHeader, expiry), nil
+}
+
+// HashContent streams the stored object and returns its base64-encoded SHA-256.
+// It is used to verify uploads on S3-compatible backends that do not report checksums.
+func (s *Storage) HashContent(ctx context.Context, shortID string) (string, error) {
+	key := s.buildKey(shortID)
+
+	result, err := s.s3Client.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return "", s.handleS3Error(err)
+	}
+	defer result.Body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, result.Body); err != nil {
+		return "", fmt.Errorf("storage: failed to read content: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
+
+// UploadedPart describes a part of a multipart upload stored in S3
+type UploadedPart struct {
+	PartNumber     int32
+	Size           int64
+	ETag           string
+	ChecksumSHA256 string
+}
+
+// CreateMultipartUpload starts an S3 multipart upload for the content and returns its upload ID.
+// Parts are checksummed with SHA-256 so S3 verifies each of them on upload.
+func (s *Storage) CreateMultipartUpload(ctx context.Context, shortID string) (string, error) {
+	key := s.buildKey(shortID)
+
+	result, err := s.s3Client.Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:            aws.String(s.bucketName),
+		Key:               aws.String(key),
+		ContentType:       aws.String("application/octet-stream"),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	})
+	if err != nil {
+		return "", fmt.Errorf("storage: failed to create multipart upload: %w", err)
+	}
+
+	return aws.ToString(result.UploadId), nil
+}
+
+// PresignUploadPart returns a pre-signed PUT request for one part of a multipart upload.
+// The part size and base64-encoded SHA-256 checksum are part of the signature.
+func (s *Storage) PresignUploadPart(ctx context.Context, shortID, uploadID string, partNumber int32, size int64, checksumSHA256 string, expiry time.Duration) (*PresignedRequest, error) {
+	key := s.buildKey(shortID)
+
+	presignClient := s3.NewPresignClient(s.s3Client.Client)
+	req, err := presignClient.PresignUploadPart(ctx, &s3.UploadPartInput{
+		Bucket:         aws.String(s.bucketName),
+		Key:            aws.String(key),
+		UploadId:       aws.String(uploadID),
+		PartNumber:     aws.Int32(partNumber),
+		ContentLength:  aws.Int64(size),
+		ChecksumSHA256: aws.String(checksumSHA256),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to presign upload part: %w", err)
+	}
+
+	return toPresignedRequest(req.URL, req.Method, req.SignedHeader, expiry), nil
+}
+
+// ListUploadedParts returns the parts uploaded so far for a multipart upload, ordered by part number
+func (s *Storage) ListUploadedParts(ctx context.Context, shortID, uploadID string) ([]UploadedPart, error) {
+	key := s.buildKey(shortID)
+
+	var parts []UploadedPart
+	paginator := s3.NewListPartsPaginator(s.s3Client.Client, &s3.ListPartsInput{
+		Bucket:   aws.String(s.bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, s.handleMultipartError(err)
+		}
+		for _, part := range page.Parts {
+			parts = append(parts, UploadedPart{
+				PartNumber:     aws.ToInt32(part.PartNumber),
+				Size:           aws.ToInt64(part.Size),
+				ETag:           aws.ToString(part.ETag),
+				ChecksumSHA256: aws.ToString(part.ChecksumSHA256),
+			})
+		}
+	}
+
+	return parts, nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the final object
+func (s *Storage) CompleteMultipartUpload(ctx context.Context, shortID, uploadID string, parts []UploadedPart) error {
+	key := s.buildKey(shortID)
+
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completedPart := types.CompletedPart{
+			PartNumber: aws.Int32(part.PartNumber),
+			ETag:       aws.String(part.ETag),
+		}
+		if part.ChecksumSHA256 != "" {
+			completedPart.ChecksumSHA256 = aws.String(part.ChecksumSHA256)
+		}
+		completed = append(completed, completedPart)
+	}
+
+	_, err := s.s3Client.Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucketName),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return s.handleMultipartError(err)
+	}
+
+	return nil
+}
+
+// AbortMultipartUpload discards a multipart upload and the parts uploaded so far.
+// Aborting an upload that no longer exists is not an error.
+func (s *Storage) AbortMultipartUpload(ctx context.Context, shortID, uploadID string) error {
+	key := s.buildKey(shortID)
+
+	_, err := s.s3Client.Client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		if errors.Is(s.handleMultipartError(err), ErrMultipartUploadNotFound) {
+			return nil
+		}
+		return fmt.Errorf("storage: failed to abort multipart upload: %w", err)
+	}
+
+	return nil
+}
+
 // ContentExists checks if content exists in S3
 func (s *Storage) ContentExists(ctx context.Context, shortID string) (bool, error) {
 	key := s.buildKey(shortID)
@@ -136,11 +716,174 @@ func (s *Storage) ContentExists(ctx context.Context, shortID string) (bool, erro
 	return true, nil
 }
 
+// StoredObject is the content of a paste, or of one of its revisions, found in S3
+type StoredObject struct {
+	Key     string
+	ShortID string
+	// Revision is the revision number, 0 for the current content
+	Revision     int
+	LastModified time.Time
+}
+
+// ListObjects lists up to limit paste content and revision objects in key order, starting after
+// the given key. It returns the key to continue from, empty once the listing is complete.
+// Objects under S3KeyPrefix that gisty did not write are skipped.
+func (s *Storage) ListObjects(ctx context.Context, afterKey string, limit int32) ([]StoredObject, string, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucketName),
+		Prefix:  aws.String(S3KeyPrefix),
+		MaxKeys: aws.Int32(limit),
+	}
+	if afterKey != "" {
+		input.StartAfter = aws.String(afterKey)
+	}
+
+	output, err := s.s3Client.Client.ListObjectsV2(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("storage: failed to list objects: %w", err)
+	}
+
+	objects := make([]StoredObject, 0, len(output.Contents))
+	lastKey := ""
+	for _, obj := range output.Contents {
+		key := aws.ToString(obj.Key)
+		lastKey = key
+		shortID, revision, ok := parseKey(key)
+		if !ok {
+			continue
+		}
+		objects = append(objects, StoredObject{
+			Key:          key,
+			ShortID:      shortID,
+			Revision:     revision,
+			LastModified: aws.ToTime(obj.LastModified),
+		})
+	}
+
+	if !aws.ToBool(output.IsTruncated) {
+		lastKey = ""
+	}
+	return objects, lastKey, nil
+}
+
+// ObjectsDeleteError reports the objects a DeleteObjects request could not remove, with S3's
+// reason for each; the other objects were removed
+type ObjectsDeleteError struct {
+	Failed map[string]string
+	Total  int
+}
+
+func (e *ObjectsDeleteError) Error() string {
+	return fmt.Sprintf("storage: failed to delete %d of %d objects", len(e.Failed), e.Total)
+}
+
+// DeleteObjects removes the given objects in a single request (at most 1000 keys). When only
+// some objects could not be removed, the error is an *ObjectsDeleteError listing them.
+func (s *Storage) DeleteObjects(ctx context.Context, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	identifiers := make([]types.ObjectIdentifier, 0, len(keys))
+	for _, key := range keys {
+		identifiers = append(identifiers, types.ObjectIdentifier{Key: aws.String(key)})
+	}
+
+	output, err := s.s3Client.Client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String(s.bucketName),
+		Delete: &types.Delete{Objects: identifiers, Quiet: aws.Bool(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("storage: failed to delete objects: %w", err)
+	}
+	if len(output.Errors) > 0 {
+		failed := make(map[string]string, len(output.Errors))
+		for _, objectErr := range output.Errors {
+			failed[aws.ToString(objectErr.Key)] = aws.ToString(objectErr.Code) + ": " + aws.ToString(objectErr.Message)
+		}
+		return &ObjectsDeleteError{Failed: failed, Total: len(keys)}
+	}
+
+	return nil
+}
+
+// StoredUpload is an unfinished multipart upload found in S3
+type StoredUpload struct {
+	ShortID   string
+	UploadID  string
+	Initiated time.Time
+}
+
+// ListMultipartUploads lists the unfinished multipart uploads of paste content
+func (s *Storage) ListMultipartUploads(ctx context.Context) ([]StoredUpload, error) {
+	input := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.bucketName),
+		Prefix: aws.String(S3KeyPrefix),
+	}
+
+	var uploads []StoredUpload
+	for {
+		output, err := s.s3Client.Client.ListMultipartUploads(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("storage: failed to list multipart uploads: %w", err)
+		}
+
+		for _, upload := range output.Uploads {
+			shortID, revision, ok := parseKey(aws.ToString(upload.Key))
+			if !ok || revision != 0 {
+				continue
+			}
+			uploads = append(uploads, StoredUpload{
+				ShortID:   shortID,
+				UploadID:  aws.ToString(upload.UploadId),
+				Initiated: aws.ToTime(upload.Initiated),
+			})
+		}
+
+		if !aws.ToBool(output.IsTruncated) {
+			return uploads, nil
+		}
+		input.KeyMarker = output.NextKeyMarker
+		input.UploadIdMarker = output.NextUploadIdMarker
+	}
+}
+
 // buildKey constructs the S3 key for a given shortID
 func (s *Storage) buildKey(shortID string) string {
 	return S3KeyPrefix + shortID + S3KeySuffix
 }
 
+// buildRevisionKey constructs the S3 key of a previous version of a paste
+func (s *Storage) buildRevisionKey(shortID string, revision int) string {
+	return S3KeyPrefix + S3RevisionPrefix + shortID + "/" + strconv.Itoa(revision) + S3KeySuffix
+}
+
+// parseKey returns the short ID and revision number (0 for the current content) stored under
+// a key built by buildKey or buildRevisionKey
+func parseKey(key string) (string, int, bool) {
+	if !strings.HasPrefix(key, S3KeyPrefix) || !strings.HasSuffix(key, S3KeySuffix) {
+		return "", 0, false
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(key, S3KeyPrefix), S3KeySuffix)
+
+	if rest, ok := strings.CutPrefix(name, S3RevisionPrefix); ok {
+		shortID, number, found := strings.Cut(rest, "/")
+		if !found || shortID == "" {
+			return "", 0, false
+		}
+		revision, err := strconv.Atoi(number)
+		if err != nil || revision < 1 {
+			return "", 0, false
+		}
+		return shortID, revision, true
+	}
+
+	if name == "" || strings.Contains(name, "/") {
+		return "", 0, false
+	}
+	return name, 0, true
+}
+
 // handleS3Error converts S3 errors to storage errors
 func (s *Storage) handleS3Error(err error) error {
 	var notFound *types.NoSuchKey
@@ -162,6 +905,44 @@ func (s *Storage) handleS3Error(err error) error {
 	return fmt.Errorf("storage: S3 error: %w", err)
 }
 
+// handleMultipartError converts S3 multipart errors to storage errors
+func (s *Storage) handleMultipartError(err error) error {
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return ErrMultipartUploadNotFound
+	}
+
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchUpload":
+			return ErrMultipartUploadNotFound
+		case "InvalidPart", "InvalidPartOrder", "EntityTooSmall", "BadDigest":
+			return fmt.Errorf("%w: %s", ErrInvalidMultipartUpload, apiErr.ErrorCode())
+		}
+	}
+
+	return fmt.Errorf("storage: S3 error: %w", err)
+}
+
+// toPresignedRequest builds a PresignedRequest, listing the signed headers the client must send
+func toPresignedRequest(url, method string, signedHeader http.Header, expiry time.Duration) *PresignedRequest {
+	headers := make(map[string]string, len(signedHeader))
+	for name := range signedHeader {
+		if name == "Host" {
+			continue
+		}
+		headers[name] = signedHeader.Get(name)
+	}
+
+	return &PresignedRequest{
+		URL:       url,
+		Method:    method,
+		Headers:   headers,
+		ExpiresAt: time.Now().Add(expiry),
+	}
+}
+
 // compressContent compresses content using gzip
 func compressContent(content string) ([]byte, error) {
 	var buf bytes.Buffer
@@ -178,18 +959,86 @@ func compressContent(content string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// decompressContent decompresses gzipped content
-func decompressContent(compressed []byte) (string, error) {
-	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+// decompressContent decompresses content, failing with ErrDecompressionBomb when the result
+// would exceed limit bytes. Objects uploaded directly by clients are stored as-is and returned
+// unchanged.
+func decompressContent(compressed []byte, limit int64) (string, error) {
+	compression := detectCompression(compressed)
+	if compression == compressionNone {
+		return string(compressed), nil
+	}
+
+	reader, err := newDecompressor(compression, bytes.NewReader(compressed))
 	if err != nil {
 		return "", err
 	}
 	defer reader.Close()
 
-	decompressed, err := io.ReadAll(reader)
+	decompressed, err := readLimited(reader, limit)
 	if err != nil {
 		return "", err
 	}
 
 	return string(decompressed), nil
+}
+
+// readCloser reads from a reader and closes the stream it wraps
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// closers closes each of its closers in order, returning the first error
+type closers []io.Closer
+
+// Close closes every closer
+func (cs closers) Close() error {
+	var first error
+	for _, c := range cs {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// limitedReadCloser reads at most remaining bytes, failing with ErrDecompressionBomb on more
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+	remaining int64
+}
+
+// Read reads from the underlying reader, failing once more than the limit was read
+func (l *limitedReadCloser) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrDecompressionBomb
+	}
+	// Read one byte past the limit to tell content of exactly the limit from larger content
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.Reader.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n + int(l.remaining), ErrDecompressionBomb
+	}
+	return n, err
+}
+
+// readLimited reads r to the end, failing with ErrDecompressionBomb if it holds more than limit bytes
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, ErrDecompressionBomb
+	}
+	return data, nil
+}
+
+// isGzip reports whether data starts with the gzip magic number
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
 }
\ No newline at end of file
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+var (
+	// ErrInvalidStorageEvents is returned when a body is not an S3 event notification
+	ErrInvalidStorageEvents = errors.New("paste: invalid storage event notification")
+	// ErrPasteArchived is returned for pastes whose content was moved to an archival storage
+	// class by a bucket lifecycle rule and must be restored before it can be read
+	ErrPasteArchived = errors.New("paste: content archived")
+)
+
+// Storage event kinds, from the S3 event name
+const (
+	StorageEventRemoved    = "removed"
+	StorageEventArchived   = "archived"
+	StorageEventRestored   = "restored"
+	StorageEventUnrestored = "unrestored"
+)
+
+// StorageEvent is an S3 event notification record about an object of the bucket
+type StorageEvent struct {
+	Name   string // S3 event name, e.g., "LifecycleExpiration:Delete"
+	Bucket string
+	Key    string
+}
+
+// Kind returns what the event means for the object, or "" for events that do not matter
+func (e StorageEvent) Kind() string {
+	switch {
+	case strings.HasPrefix(e.Name, "ObjectRemoved:"), strings.HasPrefix(e.Name, "LifecycleExpiration:"):
+		return StorageEventRemoved
+	case e.Name == "LifecycleTransition":
+		return StorageEventArchived
+	case e.Name == "ObjectRestore:Completed":
+		return StorageEventRestored
+	case e.Name == "ObjectRestore:Delete":
+		// The temporary restored copy expired; the object is archived again
+		return StorageEventUnrestored
+	default:
+		return ""
+	}
+}
+
+// s3Notification is the body of an S3 event notification, also sent by MinIO webhook targets
+type s3Notification struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+	// Event is set on the test event S3 sends when notifications are configured
+	Event string `json:"Event"`
+}
+
+// ParseStorageEvents decodes an S3 event notification. The test event S3 sends when
+// notifications are configured holds no records.
+func ParseStorageEvents(body []byte) ([]StorageEvent, error) {
+	var notification s3Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, ErrInvalidStorageEvents
+	}
+	if notification.Records == nil && notification.Event != "s3:TestEvent" {
+		return nil, ErrInvalidStorageEvents
+	}
+
+	events := make([]StorageEvent, 0, len(notification.Records))
+	for _, record := range notification.Records {
+		// Keys are URL-encoded in notifications
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return nil, ErrInvalidStorageEvents
+		}
+		events = append(events, StorageEvent{
+			Name:   record.EventName,
+			Bucket: record.S3.Bucket.Name,
+			Key:    key,
+		})
+	}
+	return events, nil
+}
+
+// StorageEventsResult counts the pastes a batch of storage events changed
+type StorageEventsResult struct {
+	Deleted  int `json:"deleted"`  // pastes whose content was removed
+	Archived int `json:"archived"` // pastes whose content became unreadable until restored
+	Restored int `json:"restored"` // pastes whose content is readable again
+	Ignored  int `json:"ignored"`  // events about other buckets, revisions, unknown pastes or of no interest
+}
+
+// ReconcileStorageEvents brings paste metadata in line with changes the bucket made on its own,
+// so lifecycle rules can manage archival and expiry: pastes whose content was removed are deleted,
+// pastes whose content was archived are marked so until it is restored. Events about pastes
+// gisty already deleted, previous revisions and other buckets are ignored.
+func (s *PasteService) ReconcileStorageEvents(ctx context.Context, events []StorageEvent) (*StorageEventsResult, error) {
+	result := &StorageEventsResult{}
+	for _, e := range events {
+		shortID, revision, ok := parseKey(e.Key)
+		kind := e.Kind()
+		if e.Bucket != s.storage.bucketName || !ok || revision != 0 || kind == "" {
+			result.Ignored++
+			continue
+		}
+
+		var err error
+		switch kind {
+		case StorageEventRemoved:
+			if err = s.pasteRepo.Delete(ctx, shortID); err == nil {
+				_ = s.cache.Delete(ctx, shortID)
+				s.deleteRevisions(ctx, shortID)
+				s.publish(model.PasteEventDeleted, shortID, nil)
+				result.Deleted++
+			}
+		case StorageEventArchived, StorageEventUnrestored:
+			now := time.Now()
+			if err = s.pasteRepo.SetArchived(ctx, shortID, &now); err == nil {
+				result.Archived++
+			}
+		case StorageEventRestored:
+			if err = s.pasteRepo.SetArchived(ctx, shortID, nil); err == nil {
+				result.Restored++
+			}
+		}
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			result.Ignored++
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("paste: failed to reconcile %s of %s: %w", e.Name, shortID, err)
+		}
+		log.Printf("[PasteService.ReconcileStorageEvents] %s: %s", e.Name, shortID)
+	}
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseStorageEvents(t *testing.T) {
+	body := `{"Records":[
+		{"eventName":"LifecycleExpiration:Delete","s3":{"bucket":{"name":"gisty"},"object":{"key":"gisty/xK9a2B.gz"}}},
+		{"eventName":"ObjectRestore:Completed","s3":{"bucket":{"name":"gisty"},"object":{"key":"gisty%2FaB3+cD.gz"}}}
+	]}`
+
+	events, err := ParseStorageEvents([]byte(body))
+	if err != nil {
+		t.Fatalf("ParseStorageEvents() error = %v", err)
+	}
+	want := []StorageEvent{
+		{Name: "LifecycleExpiration:Delete", Bucket: "gisty", Key: "gisty/xK9a2B.gz"},
+		{Name: "ObjectRestore:Completed", Bucket: "gisty", Key: "gisty/aB3 cD.gz"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("ParseStorageEvents() = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+}
+
+func TestParseStorageEvents_TestEvent(t *testing.T) {
+	events, err := ParseStorageEvents([]byte(`{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"gisty"}`))
+	if err != nil || len(events) != 0 {
+		t.Errorf("ParseStorageEvents(test event) = %v, %v, want no events", events, err)
+	}
+}
+
+func TestParseStorageEvents_Invalid(t *testing.T) {
+	for _, body := range []string{
+		``,
+		`not json`,
+		`{}`,
+		`{"Records":[{"eventName":"ObjectRemoved:Delete","s3":{"bucket":{"name":"gisty"},"object":{"key":"gisty%zz.gz"}}}]}`,
+	} {
+		if _, err := ParseStorageEvents([]byte(body)); !errors.Is(err, ErrInvalidStorageEvents) {
+			t.Errorf("ParseStorageEvents(%q) error = %v, want ErrInvalidStorageEvents", body, err)
+		}
+	}
+}
+
+func TestStorageEventKind(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ObjectRemoved:Delete", StorageEventRemoved},
+		{"ObjectRemoved:DeleteMarkerCreated", StorageEventRemoved},
+		{"LifecycleExpiration:Delete", StorageEventRemoved},
+		{"LifecycleTransition", StorageEventArchived},
+		{"ObjectRestore:Completed", StorageEventRestored},
+		{"ObjectRestore:Delete", StorageEventUnrestored},
+		{"ObjectRestore:Post", ""},
+		{"ObjectCreated:Put", ""},
+	}
+
+	for _, tt := range tests {
+		if got := (StorageEvent{Name: tt.name}).Kind(); got != tt.want {
+			t.Errorf("Kind(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,20 @@
 package service
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/huylvt/gisty/internal/repository"
 )
 
@@ -193,7 +203,7 @@ func TestStorage_CompressionEfficiency(t *testing.T) {
 	}
 
 	// Verify roundtrip
-	decompressed, err := decompressContent(compressed)
+	decompressed, err := decompressContent(compressed, DefaultMaxDecompressedSize)
 	if err != nil {
 		t.Fatalf("decompressContent() error = %v", err)
 	}
@@ -237,4 +247,245 @@ Emoji: 😀🎉🚀`
 
 	// Cleanup
 	_ = storage.DeleteContent(ctx, shortID)
-}
\ No newline at end of file
+}
+
+func TestStorage_DecompressionLimit(t *testing.T) {
+	content := strings.Repeat("0", 10000)
+	compressed, err := compressContent(content)
+	if err != nil {
+		t.Fatalf("compressContent() error = %v", err)
+	}
+
+	if _, err := decompressContent(compressed, 9999); !errors.Is(err, ErrDecompressionBomb) {
+		t.Errorf("decompressContent() over limit error = %v, want %v", err, ErrDecompressionBomb)
+	}
+	if got, err := decompressContent(compressed, 10000); err != nil || got != content {
+		t.Errorf("decompressContent() at limit error = %v", err)
+	}
+
+	// Uncompressed objects are bounded too
+	if _, err := readLimited(strings.NewReader(content), 9999); !errors.Is(err, ErrDecompressionBomb) {
+		t.Errorf("readLimited() over limit error = %v, want %v", err, ErrDecompressionBomb)
+	}
+}
+
+func TestStorage_CheckCompression(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	put := func(shortID string, body []byte) {
+		t.Helper()
+		_, err := storage.s3Client.Client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(storage.bucketName),
+			Key:    aws.String(storage.buildKey(shortID)),
+			Body:   bytes.NewReader(body),
+		})
+		if err != nil {
+			t.Fatalf("PutObject() error = %v", err)
+		}
+		t.Cleanup(func() { _ = storage.DeleteContent(ctx, shortID) })
+	}
+
+	// Plain uploads are not decompressed
+	put("bomb001", []byte(strings.Repeat("plain ", 1000)))
+	if err := storage.CheckCompression(ctx, "bomb001"); err != nil {
+		t.Errorf("CheckCompression() plain error = %v", err)
+	}
+
+	// Ordinary gzip content passes
+	normal, _ := compressContent("package main\n\nfunc main() {}\n")
+	put("bomb002", normal)
+	if err := storage.CheckCompression(ctx, "bomb002"); err != nil {
+		t.Errorf("CheckCompression() normal gzip error = %v", err)
+	}
+
+	// Highly compressed zeros exceed the ratio limit
+	bomb, _ := compressContent(strings.Repeat("\x00", 4*1024*1024))
+	put("bomb003", bomb)
+	if err := storage.CheckCompression(ctx, "bomb003"); !errors.Is(err, ErrDecompressionBomb) {
+		t.Errorf("CheckCompression() bomb error = %v, want %v", err, ErrDecompressionBomb)
+	}
+
+	// Reading the bomb back with a small limit fails safely
+	limited := NewStorageWithLimit(storage.s3Client, 1024*1024)
+	if _, err := limited.GetContent(ctx, "bomb003"); !errors.Is(err, ErrDecompressionBomb) {
+		t.Errorf("GetContent() bomb error = %v, want %v", err, ErrDecompressionBomb)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		shortID  string
+		revision int
+		ok       bool
+	}{
+		{"gisty/abc123.gz", "abc123", 0, true},
+		{"gisty/revisions/abc123/2.gz", "abc123", 2, true},
+		{"gisty/revisions/abc123/0.gz", "", 0, false},
+		{"gisty/revisions/abc123.gz", "", 0, false},
+		{"gisty/abc123.txt", "", 0, false},
+		{"other/abc123.gz", "", 0, false},
+		{"gisty/.gz", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		shortID, revision, ok := parseKey(tt.key)
+		if shortID != tt.shortID || revision != tt.revision || ok != tt.ok {
+			t.Errorf("parseKey(%q) = (%q, %d, %v), want (%q, %d, %v)",
+				tt.key, shortID, revision, ok, tt.shortID, tt.revision, tt.ok)
+		}
+		if ok {
+			var s Storage
+			want := s.buildKey(shortID)
+			if revision > 0 {
+				want = s.buildRevisionKey(shortID, revision)
+			}
+			if want != tt.key {
+				t.Errorf("parseKey(%q) does not round-trip, built %q", tt.key, want)
+			}
+		}
+	}
+}
+
+func TestStorage_SaveContentStream_Multipart(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	shortID := "test-stream"
+
+	// Random-looking content barely compresses, so it spans several parts
+	var content bytes.Buffer
+	for i := 0; content.Len() < 2*StreamPartSize+1024; i++ {
+		content.WriteString(fmt.Sprintf("%08x%x\n", i, sha256.Sum256([]byte{byte(i), byte(i >> 8), byte(i >> 16)})))
+	}
+	want := content.String()
+
+	n, err := storage.SaveContentStream(ctx, shortID, strings.NewReader(want))
+	if err != nil {
+		t.Fatalf("SaveContentStream() error = %v", err)
+	}
+	defer func() { _ = storage.DeleteContent(ctx, shortID) }()
+	if n != int64(len(want)) {
+		t.Errorf("SaveContentStream() = %d bytes, want %d", n, len(want))
+	}
+
+	reader, err := storage.OpenContent(ctx, shortID)
+	if err != nil {
+		t.Fatalf("OpenContent() error = %v", err)
+	}
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("OpenContent() returned %d bytes, want the %d saved", len(got), len(want))
+	}
+}
+
+func TestStorage_OpenCompressed(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	shortID := "test-compressed"
+	want := strings.Repeat("compressible content\n", 100)
+
+	if err := storage.SaveContent(ctx, shortID, want); err != nil {
+		t.Fatalf("SaveContent() error = %v", err)
+	}
+	defer func() { _ = storage.DeleteContent(ctx, shortID) }()
+
+	reader, compressed, err := storage.OpenCompressed(ctx, shortID)
+	if err != nil {
+		t.Fatalf("OpenCompressed() error = %v", err)
+	}
+	defer reader.Close()
+	if !compressed {
+		t.Fatal("OpenCompressed() did not report gzip content")
+	}
+
+	// The stored gzip stream is returned as-is
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	got, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("OpenCompressed() content = %d bytes, want the %d saved", len(got), len(want))
+	}
+}
+
+func TestStorage_PresignDownload(t *testing.T) {
+	storage, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	shortID := "test-download"
+	want := strings.Repeat("downloaded content\n", 100)
+
+	if err := storage.SaveContent(ctx, shortID, want); err != nil {
+		t.Fatalf("SaveContent() error = %v", err)
+	}
+	defer func() { _ = storage.DeleteContent(ctx, shortID) }()
+
+	info, err := storage.ProbeContent(ctx, shortID)
+	if err != nil {
+		t.Fatalf("ProbeContent() error = %v", err)
+	}
+	if info.Compression != CompressionGzip {
+		t.Errorf("ProbeContent() compression = %q, want %q", info.Compression, CompressionGzip)
+	}
+	if info.Size <= 0 || info.Size >= int64(len(want)) {
+		t.Errorf("ProbeContent() size = %d, want the compressed size", info.Size)
+	}
+
+	presigned, err := storage.PresignDownload(ctx, shortID, "text/plain; charset=utf-8", info.Compression, time.Minute)
+	if err != nil {
+		t.Fatalf("PresignDownload() error = %v", err)
+	}
+	resp, err := http.Get(presigned.URL)
+	if err != nil {
+		t.Fatalf("GET presigned URL error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET presigned URL status = %d, want 200", resp.StatusCode)
+	}
+
+	// S3 announces the stored gzip stream, which the HTTP client decompresses
+	if !resp.Uncompressed {
+		t.Error("GET presigned URL response was not sent with Content-Encoding: gzip")
+	}
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("downloaded content = %d bytes, want the %d saved", len(got), len(want))
+	}
+}
+
+func TestLimitedReadCloser(t *testing.T) {
+	content := strings.Repeat("x", 100)
+
+	at := &limitedReadCloser{Reader: strings.NewReader(content), Closer: io.NopCloser(nil), remaining: 100}
+	if got, err := io.ReadAll(at); err != nil || len(got) != 100 {
+		t.Errorf("ReadAll() at limit = %d bytes, %v", len(got), err)
+	}
+
+	over := &limitedReadCloser{Reader: strings.NewReader(content), Closer: io.NopCloser(nil), remaining: 99}
+	got, err := io.ReadAll(over)
+	if !errors.Is(err, ErrDecompressionBomb) {
+		t.Errorf("ReadAll() over limit error = %v, want %v", err, ErrDecompressionBomb)
+	}
+	if len(got) > 99 {
+		t.Errorf("ReadAll() over limit returned %d bytes, want at most 99", len(got))
+	}
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/go-enry/go-enry/v2"
@@ -29,6 +31,7 @@ var languageToSyntax = map[string]string{
 	"YAML":         "yaml",
 	"TOML":         "toml",
 	"INI":          "ini",
+	"Dotenv":       "dotenv",
 	"Dockerfile":   "dockerfile",
 	"Makefile":     "makefile",
 	"Lua":          "lua",
@@ -61,6 +64,25 @@ var languageToSyntax = map[string]string{
 	"Text":         "plaintext",
 }
 
+// SyntaxExtension returns the usual file extension of a syntax type, such as ".py" for python,
+// or ".txt" when it has none
+func SyntaxExtension(syntaxType string) string {
+	var languages []string
+	for language, syntax := range languageToSyntax {
+		if syntax == syntaxType {
+			languages = append(languages, language)
+		}
+	}
+	// Several languages map to some syntax types; pick the same one every time
+	sort.Strings(languages)
+	for _, language := range languages {
+		if extensions := enry.GetLanguageExtensions(language); len(extensions) > 0 {
+			return extensions[0]
+		}
+	}
+	return ".txt"
+}
+
 // SyntaxDetector provides language detection functionality
 type SyntaxDetector struct{}
 
@@ -135,6 +157,11 @@ func (d *SyntaxDetector) detectByPatterns(content string) string {
 		return "html"
 	}
 
+	// Check for .env files, before YAML since values may contain colons
+	if isDotenv(content) {
+		return "dotenv"
+	}
+
 	// Check for YAML
 	if strings.Contains(content, "---\n") || (strings.Contains(content, ":") && !strings.Contains(content, ";")) {
 		lines := strings.Split(content, "\n")
@@ -170,6 +197,26 @@ func (d *SyntaxDetector) detectByPatterns(content string) string {
 	return DefaultSyntaxType
 }
 
+// dotenvLine matches a variable assignment of a .env file
+var dotenvLine = regexp.MustCompile(`^(export\s+)?[A-Z_][A-Z0-9_]*=`)
+
+// isDotenv reports whether content looks like a .env file: at least two variable assignments
+// and nothing but assignments, comments and blank lines
+func isDotenv(content string) bool {
+	assignments := 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !dotenvLine.MatchString(line) {
+			return false
+		}
+		assignments++
+	}
+	return assignments >= 2
+}
+
 // DetectLanguageWithFilename attempts to detect language using both filename and content
 // Filename takes precedence if it provides a clear match
 func (d *SyntaxDetector) DetectLanguageWithFilename(filename, content string) string {
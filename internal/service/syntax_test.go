@@ -123,6 +123,11 @@ config:
 			content:  "This is just some plain text without any code.",
 			expected: "plaintext",
 		},
+		{
+			name:     "dotenv",
+			content:  "# database\nDB_URL=postgres://app:hunter2@db:5432/app\nexport API_KEY=abc123\n",
+			expected: "dotenv",
+		},
 	}
 
 	for _, tt := range tests {
@@ -192,6 +197,12 @@ func TestSyntaxDetector_DetectLanguageWithFilename(t *testing.T) {
 			content:  `{"name": "test"}`,
 			expected: "json",
 		},
+		{
+			name:     "dotenv file",
+			filename: ".env",
+			content:  "PORT=8080",
+			expected: "dotenv",
+		},
 	}
 
 	for _, tt := range tests {
@@ -203,3 +214,24 @@ func TestSyntaxDetector_DetectLanguageWithFilename(t *testing.T) {
 		})
 	}
 }
+
+func TestSyntaxExtension(t *testing.T) {
+	tests := []struct {
+		syntaxType string
+		want       string
+	}{
+		{"python", ".py"},
+		{"go", ".go"},
+		{"javascript", ".js"},
+		{"bash", ".sh"},
+		{"markdown", ".md"},
+		{"plaintext", ".txt"},
+		{"nosuchsyntax", ".txt"},
+	}
+
+	for _, tt := range tests {
+		if got := SyntaxExtension(tt.syntaxType); got != tt.want {
+			t.Errorf("SyntaxExtension(%q) = %q, want %q", tt.syntaxType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TrendingKeyPrefix is the prefix of the Redis sorted sets holding decayed view scores
+	TrendingKeyPrefix = "gisty:trending:"
+	// DefaultTrendingHalfLife is the default time after which a view counts half as much
+	DefaultTrendingHalfLife = 6 * time.Hour
+	// DefaultTrendingLimit is the default number of pastes returned
+	DefaultTrendingLimit = 10
+	// MaxTrendingLimit is the maximum number of pastes returned
+	MaxTrendingLimit = 50
+
+	// trendingGenerationHalfLives is the length of a score generation in half-lives. Scores grow as
+	// 2^(age/half-life) within a generation, so this bounds them to 2^32 and keeps float64 precision.
+	trendingGenerationHalfLives = 32
+)
+
+// ErrTrendingDisabled is returned when trending is not enabled on this instance
+var ErrTrendingDisabled = errors.New("paste: trending disabled")
+
+// TrendingPaste is a public paste ranked by recent views
+type TrendingPaste struct {
+	ShortID    string  `json:"short_id"`
+	URL        string  `json:"url"`
+	SyntaxType string  `json:"syntax_type"`
+	CreatedAt  string  `json:"created_at"`
+	Score      float64 `json:"score"` // decayed view count
+}
+
+// TrendingResponse lists the trending pastes, highest score first
+type TrendingResponse struct {
+	Pastes   []TrendingPaste `json:"pastes"`
+	HalfLife string          `json:"half_life"`
+}
+
+// TrendingScore is the decayed view count of a paste
+type TrendingScore struct {
+	ShortID string
+	Score   float64 // views weighted by age, in views as of now
+}
+
+// Trending ranks pastes by views over an exponentially decaying window.
+//
+// It uses forward decay: instead of decaying every score over time, a view at time t adds
+// 2^((t-start)/halfLife), so newer views weigh more and ranking stays a plain sorted set.
+// Scores restart from a new sorted set every generation; the previous one is merged with
+// the weight it would have in the new generation.
+type Trending struct {
+	client   *redis.Client
+	halfLife time.Duration
+}
+
+// NewTrending creates a new Trending service; halfLife defaults to DefaultTrendingHalfLife
+func NewTrending(redisClient *repository.Redis, halfLife time.Duration) *Trending {
+	if halfLife <= 0 {
+		halfLife = DefaultTrendingHalfLife
+	}
+	return &Trending{
+		client:   redisClient.Client,
+		halfLife: halfLife,
+	}
+}
+
+// HalfLife returns the decay half-life
+func (t *Trending) HalfLife() time.Duration {
+	return t.halfLife
+}
+
+// RecordView adds a view of a paste
+func (t *Trending) RecordView(ctx context.Context, shortID string) error {
+	now := time.Now()
+	generation := t.generation(now)
+	key := t.key(generation)
+
+	pipe := t.client.Pipeline()
+	pipe.ZIncrBy(ctx, key, t.weight(now, generation), shortID)
+	// Two generations: the current one and the one merged into it
+	pipe.Expire(ctx, key, 2*t.generationLength())
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Top returns up to limit pastes with the highest decayed view counts
+func (t *Trending) Top(ctx context.Context, limit int) ([]TrendingScore, error) {
+	now := time.Now()
+	generation := t.generation(now)
+
+	// A view from the previous generation weighs 2^-generationHalfLives of the same view now
+	results, err := t.client.ZUnionWithScores(ctx, redis.ZStore{
+		Keys:    []string{t.key(generation), t.key(generation - 1)},
+		Weights: []float64{1, math.Pow(2, -trendingGenerationHalfLives)},
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// ZUNION has no limit: rank here, normalizing scores to views as of now
+	scale := t.weight(now, generation)
+	scores := make([]TrendingScore, 0, min(limit, len(results)))
+	for i := len(results) - 1; i >= 0 && len(scores) < limit; i-- {
+		member, _ := results[i].Member.(string)
+		scores = append(scores, TrendingScore{ShortID: member, Score: results[i].Score / scale})
+	}
+	return scores, nil
+}
+
+// generation returns the index of the score generation containing now
+func (t *Trending) generation(now time.Time) int64 {
+	return now.UnixNano() / int64(t.generationLength())
+}
+
+// generationLength returns the duration of a score generation
+func (t *Trending) generationLength() time.Duration {
+	return trendingGenerationHalfLives * t.halfLife
+}
+
+// weight returns the score added by a view at now
+func (t *Trending) weight(now time.Time, generation int64) float64 {
+	start := time.Unix(0, generation*int64(t.generationLength()))
+	return math.Exp2(float64(now.Sub(start)) / float64(t.halfLife))
+}
+
+// key returns the sorted set of a generation
+func (t *Trending) key(generation int64) string {
+	return TrendingKeyPrefix + strconv.FormatInt(generation, 10)
+}
+
+// SetTrending enables view counting and the trending list
+func (s *PasteService) SetTrending(trending *Trending) {
+	s.trending = trending
+}
+
+// recordView counts a read for trending; private, burn-after-read and encrypted pastes are never listed
+func (s *PasteService) recordView(ctx context.Context, e *model.PasteEvent) error {
+	if e.Type != model.PasteEventRead || s.trending == nil {
+		return nil
+	}
+	if paste := e.Paste; paste == nil || paste.IsPrivate || paste.BurnAfterRead || paste.Encrypted {
+		return nil
+	}
+	return s.trending.RecordView(ctx, e.ShortID)
+}
+
+// GetTrending returns up to limit public pastes ranked by decayed views
+func (s *PasteService) GetTrending(ctx context.Context, limit int) (*TrendingResponse, error) {
+	if s.trending == nil {
+		return nil, ErrTrendingDisabled
+	}
+	if limit <= 0 {
+		limit = DefaultTrendingLimit
+	}
+	limit = min(limit, MaxTrendingLimit)
+
+	// Fetch extra candidates: deleted, expired and private pastes are dropped below
+	scores, err := s.trending.Top(ctx, 2*limit)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to rank trending pastes: %w", err)
+	}
+	shortIDs := make([]string, len(scores))
+	for i, score := range scores {
+		shortIDs[i] = score.ShortID
+	}
+	pastes, err := s.pasteRepo.GetByShortIDs(ctx, shortIDs)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to get trending pastes: %w", err)
+	}
+	byID := make(map[string]*model.Paste, len(pastes))
+	for _, paste := range pastes {
+		byID[paste.ShortID] = paste
+	}
+
+	response := &TrendingResponse{
+		Pastes:   []TrendingPaste{},
+		HalfLife: s.trending.HalfLife().String(),
+	}
+	for _, score := range scores {
+		paste, ok := byID[score.ShortID]
+		if !ok || paste.IsPrivate || paste.BurnAfterRead || paste.Encrypted || paste.IsPending() || paste.IsExpired() {
+			continue
+		}
+		response.Pastes = append(response.Pastes, TrendingPaste{
+			ShortID:    paste.ShortID,
+			URL:        s.buildURL(paste.ShortID),
+			SyntaxType: paste.SyntaxType,
+			CreatedAt:  paste.CreatedAt.Format(time.RFC3339),
+			Score:      score.Score,
+		})
+		if len(response.Pastes) == limit {
+			break
+		}
+	}
+
+	return response, nil
+}
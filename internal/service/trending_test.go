@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+func TestTrending_Weight(t *testing.T) {
+	trending := &Trending{halfLife: time.Hour}
+
+	start := time.Unix(0, 5*int64(trending.generationLength()))
+	generation := trending.generation(start)
+	if generation != 5 {
+		t.Fatalf("generation() = %d, want 5", generation)
+	}
+
+	// A view one half-life later weighs twice as much
+	w1 := trending.weight(start.Add(time.Hour), generation)
+	w2 := trending.weight(start.Add(2*time.Hour), generation)
+	if math.Abs(w2/w1-2) > 1e-9 {
+		t.Errorf("weight ratio = %v, want 2", w2/w1)
+	}
+
+	// Scores stay bounded within a generation
+	end := start.Add(trending.generationLength() - time.Nanosecond)
+	if w := trending.weight(end, generation); w > math.Exp2(trendingGenerationHalfLives) {
+		t.Errorf("weight at end of generation = %v, want at most 2^%d", w, trendingGenerationHalfLives)
+	}
+}
+
+func TestTrending_Top(t *testing.T) {
+	ctx := context.Background()
+
+	redisClient, err := repository.NewRedisClient(ctx, "redis://localhost:6379")
+	if err != nil {
+		t.Skipf("Redis not available: %v", err)
+	}
+	defer redisClient.Close()
+
+	trending := NewTrending(redisClient, time.Hour)
+	generation := trending.generation(time.Now())
+	defer redisClient.Client.Del(ctx, trending.key(generation), trending.key(generation-1))
+	redisClient.Client.Del(ctx, trending.key(generation), trending.key(generation-1))
+
+	for i := 0; i < 3; i++ {
+		_ = trending.RecordView(ctx, "popular")
+	}
+	_ = trending.RecordView(ctx, "quiet")
+
+	top, err := trending.Top(ctx, 10)
+	if err != nil {
+		t.Fatalf("Top() error = %v", err)
+	}
+	if len(top) != 2 || top[0].ShortID != "popular" || top[1].ShortID != "quiet" {
+		t.Fatalf("Top() = %+v, want popular then quiet", top)
+	}
+	if math.Abs(top[0].Score-3) > 0.01 {
+		t.Errorf("Score = %v, want about 3 fresh views", top[0].Score)
+	}
+
+	top, err = trending.Top(ctx, 1)
+	if err != nil || len(top) != 1 {
+		t.Errorf("Top(1) = %+v, %v, want one paste", top, err)
+	}
+}
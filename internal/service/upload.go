@@ -0,0 +1,589 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// DefaultUploadMaxSize is the default maximum size of a directly uploaded paste (50MB)
+	DefaultUploadMaxSize = 50 * 1024 * 1024
+	// DefaultUploadURLExpiry is the default lifetime of a pre-signed upload URL
+	DefaultUploadURLExpiry = 15 * time.Minute
+	// DefaultMultipartMaxSize is the default maximum size of a resumable upload (512MB)
+	DefaultMultipartMaxSize = 512 * 1024 * 1024
+	// DefaultUploadPartSize is the default size of each resumable upload part (8MB)
+	DefaultUploadPartSize = 8 * 1024 * 1024
+	// DefaultUploadSessionTTL is the default time before an unfinished resumable upload expires
+	DefaultUploadSessionTTL = 24 * time.Hour
+
+	// minUploadPartSize is the smallest part size S3 accepts for all but the last part
+	minUploadPartSize = 5 * 1024 * 1024
+	// maxUploadParts is the maximum number of parts of an S3 multipart upload
+	maxUploadParts = 10000
+)
+
+var (
+	// ErrUploadTooLarge is returned when the announced upload size exceeds the limit
+	ErrUploadTooLarge = errors.New("upload: content too large")
+	// ErrInvalidChecksum is returned when the announced SHA-256 checksum is malformed
+	ErrInvalidChecksum = errors.New("upload: invalid sha256 checksum")
+	// ErrUploadAlreadyCompleted is returned when completing a paste that is not pending
+	ErrUploadAlreadyCompleted = errors.New("upload: already completed")
+	// ErrUploadIncomplete is returned when the content has not been uploaded to storage yet
+	ErrUploadIncomplete = errors.New("upload: content not uploaded")
+	// ErrUploadMismatch is returned when the uploaded object does not match the announced size or checksum
+	ErrUploadMismatch = errors.New("upload: content does not match announced size or checksum")
+	// ErrNotResumableUpload is returned when a part operation targets an upload that is not resumable
+	ErrNotResumableUpload = errors.New("upload: not a resumable upload")
+	// ErrInvalidPartNumber is returned when a part number is outside the upload's part range
+	ErrInvalidPartNumber = errors.New("upload: invalid part number")
+)
+
+// UploadConfig holds direct upload configuration
+type UploadConfig struct {
+	MaxSize   int64         // maximum size of a directly uploaded paste
+	URLExpiry time.Duration // lifetime of pre-signed upload URLs and of unfinished uploads
+
+	MultipartMaxSize int64         // maximum size of a resumable upload
+	PartSize         int64         // size of each resumable upload part
+	SessionTTL       time.Duration // time before an unfinished resumable upload expires
+}
+
+// InitUploadRequest represents the request to start a direct upload
+type InitUploadRequest struct {
+	Size       int64  `json:"size" binding:"required"`
+	SHA256     string `json:"sha256" binding:"required"` // hex-encoded SHA-256 of the content
+	SyntaxType string `json:"syntax_type"`
+	ExpiresIn  string `json:"expires_in"`
+	IsPrivate  bool   `json:"is_private"`
+	Encrypted  bool   `json:"is_encrypted"` // content is client-side encrypted ciphertext
+
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+
+	// SourceIP is the creator's IP, set by the handler; only its keyed hash is stored
+	SourceIP string `json:"-"`
+	// OwnerID identifies the creator's API key or anonymous session, set by the handler
+	OwnerID string `json:"-"`
+	// UserID is the ID of the signed-in creator, set by the handler
+	UserID string `json:"-"`
+}
+
+// InitResumableUploadResponse represents a new resumable upload session
+type InitResumableUploadResponse struct {
+	ShortID   string `json:"short_id"`
+	PartSize  int64  `json:"part_size"`
+	PartCount int    `json:"part_count"`
+	ExpiresAt string `json:"expires_at"`
+	// DeleteToken is returned once for uploads started anonymously, and required to delete the paste
+	DeleteToken string `json:"delete_token,omitempty"`
+}
+
+// UploadSessionResponse represents the progress of a resumable upload session
+type UploadSessionResponse struct {
+	ShortID       string  `json:"short_id"`
+	Size          int64   `json:"size"`
+	PartSize      int64   `json:"part_size"`
+	PartCount     int     `json:"part_count"`
+	UploadedParts []int32 `json:"uploaded_parts"`
+	ExpiresAt     string  `json:"expires_at"`
+}
+
+// UploadPartRequest represents the request for a pre-signed part upload URL
+type UploadPartRequest struct {
+	SHA256 string `json:"sha256" binding:"required"` // hex-encoded SHA-256 of the part
+}
+
+// UploadPartResponse represents the pre-signed request the client uses to upload one part
+type UploadPartResponse struct {
+	PartNumber int               `json:"part_number"`
+	Size       int64             `json:"size"`
+	UploadURL  string            `json:"upload_url"`
+	Method     string            `json:"method"`
+	Headers    map[string]string `json:"headers"`
+	ExpiresAt  string            `json:"expires_at"`
+}
+
+// InitUploadResponse represents the pre-signed request the client uses to upload content
+type InitUploadResponse struct {
+	ShortID   string            `json:"short_id"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt string            `json:"expires_at"`
+	// DeleteToken is returned once for uploads started anonymously, and required to delete the paste
+	DeleteToken string `json:"delete_token,omitempty"`
+}
+
+// UploadService handles pastes whose content is uploaded directly to storage by the client
+type UploadService struct {
+	pastes *PasteService
+	config *UploadConfig
+}
+
+// NewUploadService creates a new UploadService
+func NewUploadService(pasteService *PasteService, config *UploadConfig) *UploadService {
+	cfg := &UploadConfig{}
+	if config != nil {
+		*cfg = *config
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultUploadMaxSize
+	}
+	if cfg.URLExpiry <= 0 {
+		cfg.URLExpiry = DefaultUploadURLExpiry
+	}
+	if cfg.MultipartMaxSize <= 0 {
+		cfg.MultipartMaxSize = DefaultMultipartMaxSize
+	}
+	if cfg.PartSize < minUploadPartSize {
+		cfg.PartSize = DefaultUploadPartSize
+	}
+	if cfg.SessionTTL <= 0 {
+		cfg.SessionTTL = DefaultUploadSessionTTL
+	}
+
+	return &UploadService{
+		pastes: pasteService,
+		config: cfg,
+	}
+}
+
+// MaxSize returns the maximum size of a directly uploaded paste
+func (s *UploadService) MaxSize() int64 {
+	return s.config.MaxSize
+}
+
+// MultipartMaxSize returns the maximum size of a resumable upload
+func (s *UploadService) MultipartMaxSize() int64 {
+	return s.config.MultipartMaxSize
+}
+
+// InitUpload reserves a short ID for the content and returns a pre-signed URL to upload it to.
+// The paste stays pending, and unreadable, until CompleteUpload verifies the uploaded object.
+func (s *UploadService) InitUpload(ctx context.Context, req *InitUploadRequest) (*InitUploadResponse, error) {
+	log.Printf("[UploadService.InitUpload] Starting: size=%d, syntax=%s, expires_in=%s",
+		req.Size, req.SyntaxType, req.ExpiresIn)
+
+	paste, rawChecksum, err := s.newPendingPaste(ctx, req, s.config.MaxSize, s.config.URLExpiry)
+	if err != nil {
+		return nil, err
+	}
+	shortID := paste.ShortID
+	deleteToken := issueDeleteToken(paste, req.OwnerID, req.UserID)
+
+	if err := s.pastes.pasteRepo.Create(ctx, paste); err != nil {
+		log.Printf("[UploadService.InitUpload] Error creating MongoDB record: %v", err)
+		return nil, fmt.Errorf("paste: failed to create record: %w", err)
+	}
+
+	presigned, err := s.pastes.storage.PresignUpload(ctx, shortID, req.Size,
+		base64.StdEncoding.EncodeToString(rawChecksum), s.config.URLExpiry)
+	if err != nil {
+		log.Printf("[UploadService.InitUpload] Error presigning upload: %v", err)
+		_ = s.pastes.pasteRepo.Delete(ctx, shortID)
+		return nil, err
+	}
+
+	log.Printf("[UploadService.InitUpload] Pending upload created: short_id=%s", shortID)
+	return &InitUploadResponse{
+		ShortID:     shortID,
+		UploadURL:   presigned.URL,
+		Method:      presigned.Method,
+		Headers:     presigned.Headers,
+		ExpiresAt:   presigned.ExpiresAt.UTC().Format(time.RFC3339),
+		DeleteToken: deleteToken,
+	}, nil
+}
+
+// CompleteUpload verifies the uploaded object against the announced size and checksum and activates the paste
+func (s *UploadService) CompleteUpload(ctx context.Context, shortID string) (*CreatePasteResponse, error) {
+	paste, err := s.pastes.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if !paste.IsPending() {
+		return nil, ErrUploadAlreadyCompleted
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+
+	// Resumable uploads are assembled from their parts first
+	resumable := paste.Upload.MultipartID != ""
+	if resumable {
+		if err := s.completeMultipart(ctx, paste); err != nil {
+			log.Printf("[UploadService.CompleteUpload] Assembling parts failed for %s: %v", shortID, err)
+			return nil, err
+		}
+	}
+
+	if err := s.verifyUpload(ctx, shortID, paste.Upload); err != nil {
+		log.Printf("[UploadService.CompleteUpload] Verification failed for %s: %v", shortID, err)
+		// An assembled resumable upload cannot be re-uploaded, so discard it
+		if resumable && errors.Is(err, ErrUploadMismatch) {
+			s.pastes.deletePaste(ctx, shortID)
+		}
+		return nil, err
+	}
+
+	// Gzip uploads are decompressed on read, so reject decompression bombs up front
+	if err := s.pastes.storage.CheckCompression(ctx, shortID); err != nil {
+		log.Printf("[UploadService.CompleteUpload] Compression check failed for %s: %v", shortID, err)
+		if errors.Is(err, ErrDecompressionBomb) {
+			s.pastes.deletePaste(ctx, shortID)
+		}
+		return nil, err
+	}
+
+	// Expiration is measured from completion, not from when the upload was announced
+	expiresAt, _, err := s.pastes.parseExpiration(paste.Upload.ExpiresIn)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, policy := s.pastes.applyExpirationPolicy(paste.SyntaxType, expiresAt)
+	if err := s.pastes.pasteRepo.ActivateUpload(ctx, shortID, expiresAt); err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrUploadAlreadyCompleted
+		}
+		return nil, fmt.Errorf("paste: failed to activate paste: %w", err)
+	}
+
+	log.Printf("[UploadService.CompleteUpload] Upload completed: short_id=%s, size=%d", shortID, paste.Upload.Size)
+	paste.Upload = nil
+	paste.ExpiresAt = expiresAt
+	s.pastes.publish(model.PasteEventCreated, shortID, paste)
+
+	response := &CreatePasteResponse{
+		ShortID:          shortID,
+		URL:              s.pastes.buildURL(shortID),
+		ExpirationPolicy: policy,
+	}
+	if expiresAt != nil {
+		formatted := expiresAt.Format(time.RFC3339)
+		response.ExpiresAt = &formatted
+	}
+
+	return response, nil
+}
+
+// verifyUpload checks the stored object against the announced size and checksum
+func (s *UploadService) verifyUpload(ctx context.Context, shortID string, upload *model.PendingUpload) error {
+	info, err := s.pastes.storage.StatContent(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, ErrContentNotFound) {
+			return ErrUploadIncomplete
+		}
+		return err
+	}
+	if info.Size != upload.Size {
+		return ErrUploadMismatch
+	}
+
+	rawChecksum, err := hex.DecodeString(upload.SHA256)
+	if err != nil {
+		return ErrUploadMismatch
+	}
+	expected := base64.StdEncoding.EncodeToString(rawChecksum)
+
+	// Fall back to hashing the object when the storage backend does not report checksums,
+	// or reports a composite checksum of the parts (multipart uploads, "<checksum>-<parts>")
+	actual := info.ChecksumSHA256
+	if actual == "" || strings.Contains(actual, "-") {
+		actual, err = s.pastes.storage.HashContent(ctx, shortID)
+		if err != nil {
+			return err
+		}
+	}
+	if actual != expected {
+		return ErrUploadMismatch
+	}
+
+	return nil
+}
+
+// InitResumableUpload starts a resumable upload session backed by an S3 multipart upload.
+// The client requests a pre-signed URL per part, can resume after interruptions by checking
+// the uploaded parts, and finishes with CompleteUpload. Unfinished sessions expire after SessionTTL.
+func (s *UploadService) InitResumableUpload(ctx context.Context, req *InitUploadRequest) (*InitResumableUploadResponse, error) {
+	log.Printf("[UploadService.InitResumableUpload] Starting: size=%d, syntax=%s, expires_in=%s",
+		req.Size, req.SyntaxType, req.ExpiresIn)
+
+	paste, _, err := s.newPendingPaste(ctx, req, s.config.MultipartMaxSize, s.config.SessionTTL)
+	if err != nil {
+		return nil, err
+	}
+	deleteToken := issueDeleteToken(paste, req.OwnerID, req.UserID)
+
+	// Grow the part size for very large uploads to stay within the S3 part limit
+	partSize := s.config.PartSize
+	for req.Size > partSize*maxUploadParts {
+		partSize *= 2
+	}
+	paste.Upload.PartSize = partSize
+
+	paste.Upload.MultipartID, err = s.pastes.storage.CreateMultipartUpload(ctx, paste.ShortID)
+	if err != nil {
+		log.Printf("[UploadService.InitResumableUpload] Error creating multipart upload: %v", err)
+		return nil, err
+	}
+
+	if err := s.pastes.pasteRepo.Create(ctx, paste); err != nil {
+		log.Printf("[UploadService.InitResumableUpload] Error creating MongoDB record: %v", err)
+		_ = s.pastes.storage.AbortMultipartUpload(ctx, paste.ShortID, paste.Upload.MultipartID)
+		return nil, fmt.Errorf("paste: failed to create record: %w", err)
+	}
+
+	log.Printf("[UploadService.InitResumableUpload] Upload session created: short_id=%s, parts=%d",
+		paste.ShortID, paste.Upload.PartCount())
+	return &InitResumableUploadResponse{
+		ShortID:     paste.ShortID,
+		PartSize:    paste.Upload.PartSize,
+		PartCount:   paste.Upload.PartCount(),
+		ExpiresAt:   paste.ExpiresAt.UTC().Format(time.RFC3339),
+		DeleteToken: deleteToken,
+	}, nil
+}
+
+// GetUploadSession returns the progress of a resumable upload so the client can resume it
+func (s *UploadService) GetUploadSession(ctx context.Context, shortID string) (*UploadSessionResponse, error) {
+	paste, err := s.getResumableUpload(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+
+	parts, err := s.pastes.storage.ListUploadedParts(ctx, shortID, paste.Upload.MultipartID)
+	if err != nil {
+		return nil, err
+	}
+
+	uploaded := make([]int32, 0, len(parts))
+	for _, part := range parts {
+		uploaded = append(uploaded, part.PartNumber)
+	}
+
+	return &UploadSessionResponse{
+		ShortID:       shortID,
+		Size:          paste.Upload.Size,
+		PartSize:      paste.Upload.PartSize,
+		PartCount:     paste.Upload.PartCount(),
+		UploadedParts: uploaded,
+		ExpiresAt:     paste.ExpiresAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// PresignPart returns a pre-signed URL for uploading one part of a resumable upload.
+// The part size is fixed by the session and the part checksum is verified by S3.
+func (s *UploadService) PresignPart(ctx context.Context, shortID string, partNumber int, req *UploadPartRequest) (*UploadPartResponse, error) {
+	paste, err := s.getResumableUpload(ctx, shortID)
+	if err != nil {
+		return nil, err
+	}
+	if partNumber < 1 || partNumber > paste.Upload.PartCount() {
+		return nil, ErrInvalidPartNumber
+	}
+
+	rawChecksum, err := decodeChecksum(req.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	size := paste.Upload.PartSizeOf(partNumber)
+	presigned, err := s.pastes.storage.PresignUploadPart(ctx, shortID, paste.Upload.MultipartID, int32(partNumber), size,
+		base64.StdEncoding.EncodeToString(rawChecksum), s.config.URLExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UploadPartResponse{
+		PartNumber: partNumber,
+		Size:       size,
+		UploadURL:  presigned.URL,
+		Method:     presigned.Method,
+		Headers:    presigned.Headers,
+		ExpiresAt:  presigned.ExpiresAt.UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// AbortUpload cancels a pending upload, discarding any uploaded content
+func (s *UploadService) AbortUpload(ctx context.Context, shortID string) error {
+	paste, err := s.pastes.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return ErrPasteNotFound
+		}
+		return fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if !paste.IsPending() {
+		return ErrUploadAlreadyCompleted
+	}
+
+	if paste.Upload.MultipartID != "" {
+		if err := s.pastes.storage.AbortMultipartUpload(ctx, shortID, paste.Upload.MultipartID); err != nil {
+			return err
+		}
+	}
+	s.pastes.deletePaste(ctx, shortID)
+
+	log.Printf("[UploadService.AbortUpload] Upload aborted: short_id=%s", shortID)
+	return nil
+}
+
+// completeMultipart checks that every part was uploaded with its expected size and assembles the object
+func (s *UploadService) completeMultipart(ctx context.Context, paste *model.Paste) error {
+	upload := paste.Upload
+
+	parts, err := s.pastes.storage.ListUploadedParts(ctx, paste.ShortID, upload.MultipartID)
+	if err != nil {
+		if !errors.Is(err, ErrMultipartUploadNotFound) {
+			return err
+		}
+		// A previous completion assembled the object but failed before recording it
+		if exists, existsErr := s.pastes.storage.ContentExists(ctx, paste.ShortID); existsErr != nil || !exists {
+			return err
+		}
+		return s.clearMultipart(ctx, paste)
+	}
+
+	if len(parts) != upload.PartCount() {
+		return ErrUploadIncomplete
+	}
+	for i, part := range parts {
+		if int(part.PartNumber) != i+1 {
+			return ErrUploadIncomplete
+		}
+		if part.Size != upload.PartSizeOf(i+1) {
+			return ErrUploadMismatch
+		}
+	}
+
+	if err := s.pastes.storage.CompleteMultipartUpload(ctx, paste.ShortID, upload.MultipartID, parts); err != nil {
+		if errors.Is(err, ErrInvalidMultipartUpload) {
+			return fmt.Errorf("%w: %v", ErrUploadMismatch, err)
+		}
+		return err
+	}
+
+	return s.clearMultipart(ctx, paste)
+}
+
+// clearMultipart records that the multipart upload of a paste has been assembled
+func (s *UploadService) clearMultipart(ctx context.Context, paste *model.Paste) error {
+	paste.Upload.MultipartID = ""
+	if err := s.pastes.pasteRepo.UpdateUpload(ctx, paste.ShortID, paste.Upload); err != nil {
+		return fmt.Errorf("paste: failed to update upload: %w", err)
+	}
+	return nil
+}
+
+// getResumableUpload retrieves a pending paste with an active multipart upload
+func (s *UploadService) getResumableUpload(ctx context.Context, shortID string) (*model.Paste, error) {
+	paste, err := s.pastes.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return nil, ErrPasteNotFound
+		}
+		return nil, fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if !paste.IsPending() {
+		return nil, ErrUploadAlreadyCompleted
+	}
+	if paste.IsExpired() {
+		return nil, ErrPasteExpired
+	}
+	if paste.Upload.MultipartID == "" {
+		return nil, ErrNotResumableUpload
+	}
+	return paste, nil
+}
+
+// newPendingPaste validates an upload request and builds the pending paste for it.
+// The paste expires after ttl unless the upload is completed, so abandoned uploads are reclaimed by the cleanup worker.
+func (s *UploadService) newPendingPaste(ctx context.Context, req *InitUploadRequest, maxSize int64, ttl time.Duration) (*model.Paste, []byte, error) {
+	if req.Size <= 0 {
+		return nil, nil, ErrEmptyContent
+	}
+	if req.Size > maxSize {
+		log.Printf("[UploadService] Error: upload too large (%d > %d)", req.Size, maxSize)
+		return nil, nil, ErrUploadTooLarge
+	}
+
+	rawChecksum, err := decodeChecksum(req.SHA256)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Content is not available to auto-detect the language, so fall back to the default
+	syntaxType := strings.ToLower(strings.TrimSpace(req.SyntaxType))
+	if !ValidSyntaxTypes[syntaxType] {
+		return nil, nil, ErrInvalidSyntaxType
+	}
+	if syntaxType == "" || req.Encrypted {
+		syntaxType = DefaultSyntaxType
+	}
+
+	// Validate expiration now; it is applied when the upload completes
+	_, burnAfterRead, err := s.pastes.parseExpiration(req.ExpiresIn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata, err := NormalizeMetadata(req.Title, req.Description, req.Tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shortID, err := s.pastes.kgs.GetNextKey(ctx)
+	if err != nil {
+		log.Printf("[UploadService] Error getting short ID from KGS: %v", err)
+		return nil, nil, fmt.Errorf("paste: failed to get short ID: %w", err)
+	}
+
+	deadline := time.Now().Add(ttl)
+	paste := &model.Paste{
+		ShortID:       shortID,
+		ContentKey:    s.pastes.storage.buildKey(shortID),
+		ExpiresAt:     &deadline,
+		CreatedAt:     time.Now(),
+		SyntaxType:    syntaxType,
+		IsPrivate:     req.IsPrivate,
+		BurnAfterRead: burnAfterRead,
+		Encrypted:     req.Encrypted,
+		SourceIPHash:  s.pastes.HashSourceIP(req.SourceIP),
+		OwnerID:       req.OwnerID,
+		UserID:        optionalString(req.UserID),
+		Title:         metadata.Title,
+		Description:   metadata.Description,
+		Tags:          metadata.Tags,
+		Upload: &model.PendingUpload{
+			Size:      req.Size,
+			SHA256:    hex.EncodeToString(rawChecksum),
+			ExpiresIn: req.ExpiresIn,
+		},
+	}
+
+	return paste, rawChecksum, nil
+}
+
+// decodeChecksum parses a hex-encoded SHA-256 checksum
+func decodeChecksum(checksum string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.ToLower(strings.TrimSpace(checksum)))
+	if err != nil || len(raw) != 32 {
+		return nil, ErrInvalidChecksum
+	}
+	return raw, nil
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUploadService_InitUpload_Validation(t *testing.T) {
+	svc := NewUploadService(&PasteService{}, &UploadConfig{MaxSize: 100})
+	validChecksum := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name    string
+		req     *InitUploadRequest
+		wantErr error
+	}{
+		{"empty size", &InitUploadRequest{Size: 0, SHA256: validChecksum}, ErrEmptyContent},
+		{"too large", &InitUploadRequest{Size: 101, SHA256: validChecksum}, ErrUploadTooLarge},
+		{"checksum not hex", &InitUploadRequest{Size: 10, SHA256: "not-a-checksum"}, ErrInvalidChecksum},
+		{"checksum too short", &InitUploadRequest{Size: 10, SHA256: "abcd"}, ErrInvalidChecksum},
+		{"invalid syntax", &InitUploadRequest{Size: 10, SHA256: validChecksum, SyntaxType: "nope"}, ErrInvalidSyntaxType},
+		{"invalid expiration", &InitUploadRequest{Size: 10, SHA256: validChecksum, ExpiresIn: "soon"}, ErrInvalidExpiresIn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.InitUpload(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("InitUpload() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUploadService_InitResumableUpload_Validation(t *testing.T) {
+	svc := NewUploadService(&PasteService{}, &UploadConfig{MaxSize: 100, MultipartMaxSize: 1000})
+	validChecksum := strings.Repeat("ab", 32)
+
+	// The resumable limit applies instead of the single upload limit
+	_, err := svc.InitResumableUpload(context.Background(), &InitUploadRequest{Size: 1001, SHA256: validChecksum})
+	if !errors.Is(err, ErrUploadTooLarge) {
+		t.Errorf("InitResumableUpload() error = %v, want %v", err, ErrUploadTooLarge)
+	}
+
+	_, err = svc.InitResumableUpload(context.Background(), &InitUploadRequest{Size: 500, SHA256: "abcd"})
+	if !errors.Is(err, ErrInvalidChecksum) {
+		t.Errorf("InitResumableUpload() error = %v, want %v", err, ErrInvalidChecksum)
+	}
+}
+
+func TestUploadService_ResumableUpload(t *testing.T) {
+	pasteService, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	svc := NewUploadService(pasteService, &UploadConfig{PartSize: minUploadPartSize})
+	ctx := context.Background()
+
+	// Two full parts and a short last part
+	content := strings.Repeat("r", 2*minUploadPartSize+1024)
+	sum := sha256.Sum256([]byte(content))
+
+	initResp, err := svc.InitResumableUpload(ctx, &InitUploadRequest{
+		Size:   int64(len(content)),
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		t.Fatalf("InitResumableUpload() error = %v", err)
+	}
+	if initResp.PartCount != 3 {
+		t.Fatalf("PartCount = %d, want 3", initResp.PartCount)
+	}
+
+	uploadPart := func(partNumber int) {
+		start := int64(partNumber-1) * initResp.PartSize
+		end := start + initResp.PartSize
+		if end > int64(len(content)) {
+			end = int64(len(content))
+		}
+		part := content[start:end]
+		partSum := sha256.Sum256([]byte(part))
+
+		partResp, err := svc.PresignPart(ctx, initResp.ShortID, partNumber, &UploadPartRequest{SHA256: hex.EncodeToString(partSum[:])})
+		if err != nil {
+			t.Fatalf("PresignPart(%d) error = %v", partNumber, err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, partResp.Method, partResp.UploadURL, strings.NewReader(part))
+		if err != nil {
+			t.Fatalf("NewRequest() error = %v", err)
+		}
+		for name, value := range partResp.Headers {
+			req.Header.Set(name, value)
+		}
+		req.ContentLength = int64(len(part))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("upload part %d error = %v", partNumber, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("upload part %d status = %d, want %d", partNumber, resp.StatusCode, http.StatusOK)
+		}
+	}
+
+	// Upload the first part, then "resume" from the session state
+	uploadPart(1)
+	session, err := svc.GetUploadSession(ctx, initResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetUploadSession() error = %v", err)
+	}
+	if len(session.UploadedParts) != 1 || session.UploadedParts[0] != 1 {
+		t.Errorf("UploadedParts = %v, want [1]", session.UploadedParts)
+	}
+
+	if _, err := svc.CompleteUpload(ctx, initResp.ShortID); !errors.Is(err, ErrUploadIncomplete) {
+		t.Errorf("CompleteUpload() with missing parts error = %v, want %v", err, ErrUploadIncomplete)
+	}
+
+	uploadPart(2)
+	uploadPart(3)
+
+	if _, err := svc.CompleteUpload(ctx, initResp.ShortID); err != nil {
+		t.Fatalf("CompleteUpload() error = %v", err)
+	}
+
+	got, err := pasteService.GetPaste(ctx, initResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if got.Content != content {
+		t.Error("GetPaste() content does not match uploaded content")
+	}
+}
+
+func TestUploadService_InitAndComplete(t *testing.T) {
+	pasteService, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	svc := NewUploadService(pasteService, nil)
+	ctx := context.Background()
+
+	content := strings.Repeat("direct upload content\n", 1000)
+	sum := sha256.Sum256([]byte(content))
+
+	initResp, err := svc.InitUpload(ctx, &InitUploadRequest{
+		Size:      int64(len(content)),
+		SHA256:    hex.EncodeToString(sum[:]),
+		ExpiresIn: "1h",
+	})
+	if err != nil {
+		t.Fatalf("InitUpload() error = %v", err)
+	}
+
+	// Pending pastes are not readable
+	if _, err := pasteService.GetPaste(ctx, initResp.ShortID); !errors.Is(err, ErrPasteNotFound) {
+		t.Errorf("GetPaste() on pending upload error = %v, want %v", err, ErrPasteNotFound)
+	}
+
+	// Completing before uploading fails
+	if _, err := svc.CompleteUpload(ctx, initResp.ShortID); !errors.Is(err, ErrUploadIncomplete) {
+		t.Errorf("CompleteUpload() before upload error = %v, want %v", err, ErrUploadIncomplete)
+	}
+
+	// Upload directly to storage with the pre-signed request
+	req, err := http.NewRequestWithContext(ctx, initResp.Method, initResp.UploadURL, strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("NewRequest() error = %v", err)
+	}
+	for name, value := range initResp.Headers {
+		req.Header.Set(name, value)
+	}
+	req.ContentLength = int64(len(content))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("upload error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("upload status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	completeResp, err := svc.CompleteUpload(ctx, initResp.ShortID)
+	if err != nil {
+		t.Fatalf("CompleteUpload() error = %v", err)
+	}
+	if completeResp.ExpiresAt == nil {
+		t.Error("ExpiresAt should be set after completion")
+	}
+
+	got, err := pasteService.GetPaste(ctx, initResp.ShortID)
+	if err != nil {
+		t.Fatalf("GetPaste() error = %v", err)
+	}
+	if got.Content != content {
+		t.Error("GetPaste() content does not match uploaded content")
+	}
+
+	// Completing twice fails
+	if _, err := svc.CompleteUpload(ctx, initResp.ShortID); !errors.Is(err, ErrUploadAlreadyCompleted) {
+		t.Errorf("CompleteUpload() twice error = %v, want %v", err, ErrUploadAlreadyCompleted)
+	}
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/huylvt/gisty/internal/version"
+)
+
+const (
+	// DefaultURLFetchTimeout bounds a fetch of a URL, redirects included, when no timeout is configured
+	DefaultURLFetchTimeout = 10 * time.Second
+	// maxURLRedirects caps the redirects followed by a fetch
+	maxURLRedirects = 5
+)
+
+// DefaultURLImportContentTypes are the media types fetched when none are configured
+var DefaultURLImportContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/xml",
+	"application/javascript",
+	"application/x-yaml",
+	"application/yaml",
+	"application/toml",
+	"application/x-sh",
+}
+
+var (
+	// ErrURLImportDisabled is returned when creating pastes from URLs is not enabled on this instance
+	ErrURLImportDisabled = errors.New("paste: URL import disabled")
+	// ErrInvalidSourceURL is returned for URLs other than absolute http and https URLs without credentials
+	ErrInvalidSourceURL = errors.New("paste: invalid source URL")
+	// ErrSourceURLForbidden is returned for URLs whose host is denied or resolves to a private,
+	// loopback, link-local or otherwise non-public address
+	ErrSourceURLForbidden = errors.New("paste: source URL not allowed")
+	// ErrUnsupportedSourceType is returned when the fetched content is not of an allowed media type
+	ErrUnsupportedSourceType = errors.New("paste: unsupported source content type")
+	// ErrSourceUnavailable is returned when the URL cannot be fetched or answers with an error
+	ErrSourceUnavailable = errors.New("paste: source URL unavailable")
+)
+
+// reservedPrefixes are the ranges of globally routable-looking addresses that still reach
+// private networks or are not meant for the public internet
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, embedding any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2002::/16"),      // 6to4, embedding any IPv4 address
+	netip.MustParsePrefix("2001::/32"),      // Teredo
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("100::/64"),       // discard
+	netip.MustParsePrefix("fec0::/10"),      // deprecated site-local
+}
+
+// ImportURLRequest represents the request to create a paste from the content of a URL
+type ImportURLRequest struct {
+	URL        string `json:"url" binding:"required"`
+	ExpiresIn  string `json:"expires_in"`
+	IsPrivate  bool   `json:"is_private"`
+	SyntaxType string `json:"syntax_type"` // detected from the URL's filename and the content when empty
+	Title      string `json:"title"`       // the URL's filename when empty
+
+	// SourceIP is the caller's IP, set by the handler; only its keyed hash is stored
+	SourceIP string `json:"-"`
+	// OwnerID identifies the caller's API key or anonymous session, set by the handler
+	OwnerID string `json:"-"`
+	// UserID is the ID of the signed-in user, set by the handler
+	UserID string `json:"-"`
+}
+
+// FetchedURL is the content of a URL
+type FetchedURL struct {
+	Content     string
+	ContentType string // media type, without parameters
+	// Filename is the name given by Content-Disposition, or the last segment of the URL path
+	Filename string
+}
+
+// URLFetcherConfig tunes what a URLFetcher fetches
+type URLFetcherConfig struct {
+	// Timeout bounds a fetch, redirects included; DefaultURLFetchTimeout when zero
+	Timeout time.Duration
+	// ContentTypes are the media types fetched, "text/*" matching any text type;
+	// DefaultURLImportContentTypes when empty
+	ContentTypes []string
+	// DenyList holds IPs, CIDRs and host names never fetched from, on top of non-public
+	// addresses; a host name also denies its subdomains
+	DenyList []string
+}
+
+// URLFetcher fetches the text content of public http and https URLs. Every address connected
+// to, including after redirects and whatever DNS answers, is checked when dialing, so a host
+// cannot be made to resolve to an internal address between the check and the connection.
+type URLFetcher struct {
+	client       *http.Client
+	contentTypes []string
+	denyPrefixes []netip.Prefix
+	denyHosts    []string
+
+	// allowAddr reports whether an address may be connected to
+	allowAddr func(netip.Addr) bool
+}
+
+// SetURLFetcher enables creating pastes from the content of URLs fetched with fetcher
+func (s *PasteService) SetURLFetcher(fetcher *URLFetcher) {
+	s.urlFetcher = fetcher
+}
+
+// ImportURL fetches the content of a URL and stores it as a paste recording the URL as its
+// source. The syntax type is detected from the URL's filename and the content unless given.
+func (s *PasteService) ImportURL(ctx context.Context, req *ImportURLRequest) (*CreatePasteResponse, error) {
+	if s.urlFetcher == nil {
+		return nil, ErrURLImportDisabled
+	}
+	sourceURL, err := s.urlFetcher.checkURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	fetched, err := s.urlFetcher.Fetch(ctx, sourceURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	syntaxType := req.SyntaxType
+	if syntaxType == "" {
+		syntaxType = s.syntaxDetector.DetectLanguageWithFilename(fetched.Filename, fetched.Content)
+	}
+	title := req.Title
+	if title == "" {
+		title = truncateRunes(fetched.Filename, MaxTitleLength)
+	}
+	response, err := s.CreatePaste(ctx, &CreatePasteRequest{
+		Content:    fetched.Content,
+		SyntaxType: syntaxType,
+		ExpiresIn:  req.ExpiresIn,
+		IsPrivate:  req.IsPrivate,
+		Title:      title,
+		SourceIP:   req.SourceIP,
+		OwnerID:    req.OwnerID,
+		UserID:     req.UserID,
+		SourceURL:  sourceURL.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[PasteService.ImportURL] Success: short_id=%s, host=%s, size=%d", response.ShortID, sourceURL.Host, len(fetched.Content))
+	return response, nil
+}
+
+// NewURLFetcher creates a URLFetcher. It fails on a deny list entry that looks like an IP or a
+// CIDR but is not a valid one.
+func NewURLFetcher(config URLFetcherConfig) (*URLFetcher, error) {
+	f := &URLFetcher{allowAddr: publicAddr}
+	for _, contentType := range config.ContentTypes {
+		if contentType = strings.ToLower(strings.TrimSpace(contentType)); contentType != "" {
+			f.contentTypes = append(f.contentTypes, contentType)
+		}
+	}
+	if len(f.contentTypes) == 0 {
+		f.contentTypes = DefaultURLImportContentTypes
+	}
+	for _, entry := range config.DenyList {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.Contains(entry, "/"):
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("paste: invalid URL import deny list entry %q: %w", entry, err)
+			}
+			f.denyPrefixes = append(f.denyPrefixes, prefix.Masked())
+		default:
+			if addr, err := netip.ParseAddr(entry); err == nil {
+				f.denyPrefixes = append(f.denyPrefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			} else {
+				f.denyHosts = append(f.denyHosts, strings.TrimSuffix(entry, "."))
+			}
+		}
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultURLFetchTimeout
+	}
+	dialer := &net.Dialer{Timeout: timeout, Control: f.controlDial}
+	f.client = &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// No proxy: the addresses connected to must be the ones checked
+			Proxy:                  nil,
+			DialContext:            dialer.DialContext,
+			TLSHandshakeTimeout:    timeout,
+			ResponseHeaderTimeout:  timeout,
+			MaxResponseHeaderBytes: 64 << 10,
+			DisableKeepAlives:      true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxURLRedirects {
+				return fmt.Errorf("%w: too many redirects", ErrSourceUnavailable)
+			}
+			_, err := f.checkURL(req.URL.String())
+			return err
+		},
+	}
+	return f, nil
+}
+
+// Fetch returns the content of a URL, up to MaxContentSize bytes of an allowed media type
+func (f *URLFetcher) Fetch(ctx context.Context, rawURL string) (*FetchedURL, error) {
+	u, err := f.checkURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidSourceURL
+	}
+	req.Header.Set("User-Agent", "gisty/"+version.Get().Version)
+	req.Header.Set("Accept", "text/plain, text/*;q=0.9, */*;q=0.5")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		for _, sentinel := range []error{ErrSourceURLForbidden, ErrInvalidSourceURL, ErrSourceUnavailable} {
+			if errors.Is(err, sentinel) {
+				return nil, sentinel
+			}
+		}
+		return nil, fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: answered status %d", ErrSourceUnavailable, resp.StatusCode)
+	}
+	if resp.ContentLength > MaxContentSize {
+		return nil, ErrContentTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxContentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSourceUnavailable, err)
+	}
+	if len(data) > MaxContentSize {
+		return nil, ErrContentTooLarge
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !f.allowedType(mediaType) {
+		return nil, ErrUnsupportedSourceType
+	}
+
+	return &FetchedURL{
+		Content:     string(data),
+		ContentType: mediaType,
+		Filename:    responseFilename(resp),
+	}, nil
+}
+
+// checkURL parses a URL and checks that it may be fetched: an http or https URL without
+// credentials whose host is not denied. Addresses are checked again when dialing.
+func (f *URLFetcher) checkURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return nil, ErrInvalidSourceURL
+	}
+	u.Fragment = ""
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !f.allowed(addr) {
+			return nil, ErrSourceURLForbidden
+		}
+		return u, nil
+	}
+	for _, denied := range f.denyHosts {
+		if host == denied || strings.HasSuffix(host, "."+denied) {
+			return nil, ErrSourceURLForbidden
+		}
+	}
+	return u, nil
+}
+
+// controlDial refuses connections to addresses that may not be fetched from
+func (f *URLFetcher) controlDial(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || !f.allowed(addrPort.Addr()) {
+		return ErrSourceURLForbidden
+	}
+	return nil
+}
+
+// allowed reports whether an address is public and not denied
+func (f *URLFetcher) allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !f.allowAddr(addr) {
+		return false
+	}
+	for _, prefix := range f.denyPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// allowedType reports whether content of a media type may be fetched
+func (f *URLFetcher) allowedType(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	for _, allowed := range f.contentTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// publicAddr reports whether an address is a public unicast address: not private, loopback,
+// link-local, multicast, unspecified or reserved
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// responseFilename returns the filename of a response: the one given by Content-Disposition,
+// or the last segment of the path of the final URL when it has an extension
+func responseFilename(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return path.Base(strings.ReplaceAll(params["filename"], "\\", "/"))
+	}
+	if base := path.Base(resp.Request.URL.Path); path.Ext(base) != "" {
+		return base
+	}
+	return ""
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+)
+
+const (
+	// DefaultVerifySample is the number of pastes a verification run checks by default
+	DefaultVerifySample = 20
+	// MaxVerifySample caps the pastes of a verification run
+	MaxVerifySample = 1000
+)
+
+// Reasons the stored content of a paste is found corrupt
+const (
+	CorruptMissing      = "content missing"
+	CorruptUnreadable   = "decompression failed"
+	CorruptHashMismatch = "checksum mismatch"
+)
+
+var (
+	// ErrCorruptContent is returned for a stored object that does not decompress
+	ErrCorruptContent = errors.New("storage: corrupt content")
+	// ErrPasteQuarantined is returned for pastes whose stored content failed verification, or that
+	// content screening quarantined; they are not served until an operator releases them
+	ErrPasteQuarantined = errors.New("paste: content quarantined")
+	// ErrPasteNotQuarantined is returned when releasing a paste that is not quarantined
+	ErrPasteNotQuarantined = errors.New("paste: not quarantined")
+)
+
+// VerifyContent downloads the stored object of a paste, decompresses it and returns the
+// hex-encoded SHA-256 of the content, as recorded in the paste's content hash. The object is
+// downloaded in full before it is decompressed, so failed downloads are not taken for corruption:
+// only objects that do not decompress, including gzip objects failing their CRC, fail with
+// ErrCorruptContent.
+func (s *Storage) VerifyContent(ctx context.Context, shortID string) (string, error) {
+	body, closer, compression, err := s.openObject(ctx, s.buildKey(shortID))
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+
+	stored, err := readLimited(body, s.maxDecompressedSize)
+	if errors.Is(err, ErrDecompressionBomb) {
+		return "", fmt.Errorf("%w: %w", ErrCorruptContent, err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("storage: failed to read content: %w", err)
+	}
+	return hashStored(stored, compression, s.maxDecompressedSize)
+}
+
+// hashStored decompresses a stored object and returns the hex-encoded SHA-256 of its content,
+// failing with ErrCorruptContent if it does not decompress within limit bytes
+func hashStored(stored []byte, compression Compression, limit int64) (string, error) {
+	reader, err := newDecompressor(compression, bytes.NewReader(stored))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCorruptContent, err)
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, io.LimitReader(reader, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCorruptContent, err)
+	}
+	if n > limit {
+		return "", fmt.Errorf("%w: %w", ErrCorruptContent, ErrDecompressionBomb)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CorruptPaste is a paste whose stored content failed verification
+type CorruptPaste struct {
+	ShortID string `json:"short_id" example:"xK9a2B"`
+	Reason  string `json:"reason" example:"checksum mismatch"`
+}
+
+// VerifyRun holds the results of a verification run
+type VerifyRun struct {
+	StartedAt string `json:"started_at" example:"2024-01-15T14:00:00Z"`
+	Sampled   int    `json:"sampled" example:"20"`
+	Verified  int    `json:"verified" example:"19"` // pastes whose content decompressed and matched its checksum
+	// Failures counts the pastes whose content could not be downloaded; they are checked again
+	// when sampled by a later run
+	Failures    int            `json:"failures" example:"0"`
+	Corrupt     []CorruptPaste `json:"corrupt"`
+	Quarantined bool           `json:"quarantined" example:"true"` // whether the corrupt pastes were quarantined
+}
+
+// VerifyStoredPastes samples up to size readable pastes at random, size taking
+// DefaultVerifySample when not positive, and checks that the stored content of each one still
+// downloads, decompresses and matches its recorded checksum, to catch silent storage corruption
+// early. Pastes recorded before checksums were are only checked to decompress. Corrupt pastes are
+// reported, and quarantined when quarantine is set so they are not served until released.
+func (s *PasteService) VerifyStoredPastes(ctx context.Context, size int, quarantine bool) (*VerifyRun, error) {
+	if size <= 0 {
+		size = DefaultVerifySample
+	}
+	size = min(size, MaxVerifySample)
+	if !s.healthy(DependencyS3) {
+		return nil, fmt.Errorf("%w: %s", ErrDependencyUnavailable, DependencyS3)
+	}
+
+	now := time.Now()
+	pastes, err := s.pasteRepo.SamplePastes(ctx, now, int64(size))
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to sample pastes: %w", err)
+	}
+
+	run := &VerifyRun{
+		StartedAt:   now.UTC().Format(time.RFC3339),
+		Sampled:     len(pastes),
+		Corrupt:     []CorruptPaste{},
+		Quarantined: quarantine,
+	}
+	for _, paste := range pastes {
+		reason, err := s.verifyPaste(ctx, paste)
+		if err != nil {
+			log.Printf("[PasteService.VerifyStoredPastes] Failed to verify %s: %v", paste.ShortID, err)
+			run.Failures++
+			continue
+		}
+		if reason == "" {
+			run.Verified++
+			continue
+		}
+
+		log.Printf("[PasteService.VerifyStoredPastes] Corrupt content: %s (%s)", paste.ShortID, reason)
+		run.Corrupt = append(run.Corrupt, CorruptPaste{ShortID: paste.ShortID, Reason: reason})
+		if !quarantine {
+			continue
+		}
+		err = s.pasteRepo.SetQuarantine(ctx, paste.ShortID, &model.Quarantine{Reason: reason, At: time.Now()})
+		if err != nil && !errors.Is(err, repository.ErrPasteNotFound) {
+			log.Printf("[PasteService.VerifyStoredPastes] Failed to quarantine %s: %v", paste.ShortID, err)
+		}
+	}
+	return run, nil
+}
+
+// verifyPaste returns why the stored content of a sampled paste is corrupt, or "" if it is not.
+// An edit or delete racing the check looks like corruption, so a paste found corrupt is read
+// again and checked once more as it is now.
+func (s *PasteService) verifyPaste(ctx context.Context, paste *model.Paste) (string, error) {
+	reason, err := s.checkStoredContent(ctx, paste)
+	if reason == "" || err != nil {
+		return reason, err
+	}
+
+	paste, err = s.pasteRepo.GetByShortID(ctx, paste.ShortID)
+	if errors.Is(err, repository.ErrPasteNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if paste.IsExpired() || paste.IsBurned() || paste.IsPending() || paste.ArchivedAt != nil {
+		return "", nil
+	}
+	return s.checkStoredContent(ctx, paste)
+}
+
+// checkStoredContent downloads the content of a paste and returns why it is corrupt, or "" if
+// it is not
+func (s *PasteService) checkStoredContent(ctx context.Context, paste *model.Paste) (string, error) {
+	hash, err := s.storage.VerifyContent(ctx, paste.ShortID)
+	switch {
+	case errors.Is(err, ErrContentNotFound):
+		return CorruptMissing, nil
+	case errors.Is(err, ErrCorruptContent):
+		log.Printf("[PasteService.VerifyStoredPastes] %s: %v", paste.ShortID, err)
+		return CorruptUnreadable, nil
+	case err != nil:
+		return "", err
+	case paste.ContentHash != "" && hash != paste.ContentHash:
+		return CorruptHashMismatch, nil
+	default:
+		return "", nil
+	}
+}
+
+// QuarantinedPaste is a paste quarantined for corrupt stored content or by content screening
+type QuarantinedPaste struct {
+	PasteSummary
+	Reason        string `json:"reason" example:"checksum mismatch"`
+	QuarantinedAt string `json:"quarantined_at" example:"2024-01-15T14:00:00Z"`
+}
+
+// QuarantineReport lists the quarantined pastes
+type QuarantineReport struct {
+	// Count is the number of quarantined pastes; Pastes lists up to the limit of them, most
+	// recently quarantined first
+	Count  int64              `json:"count" example:"1"`
+	Pastes []QuarantinedPaste `json:"pastes"`
+	// Truncated is set when more pastes are quarantined than the limit allowed
+	Truncated bool `json:"truncated" example:"false"`
+}
+
+// ListQuarantined returns up to limit quarantined pastes, for operators to restore their content
+// from a backup, delete them or release them
+func (s *PasteService) ListQuarantined(ctx context.Context, limit int) (*QuarantineReport, error) {
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	count, err := s.pasteRepo.CountQuarantined(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to count quarantined pastes: %w", err)
+	}
+	pastes, err := s.pasteRepo.ListQuarantined(ctx, int64(limit))
+	if err != nil {
+		return nil, fmt.Errorf("paste: failed to list quarantined pastes: %w", err)
+	}
+
+	report := &QuarantineReport{
+		Count:     count,
+		Pastes:    make([]QuarantinedPaste, 0, len(pastes)),
+		Truncated: count > int64(len(pastes)),
+	}
+	for _, paste := range pastes {
+		report.Pastes = append(report.Pastes, QuarantinedPaste{
+			PasteSummary:  toPasteSummary(paste),
+			Reason:        paste.Quarantine.Reason,
+			QuarantinedAt: paste.Quarantine.At.UTC().Format(time.RFC3339),
+		})
+	}
+	return report, nil
+}
+
+// ReleaseQuarantine serves a quarantined paste again, once its content was restored or found sound
+func (s *PasteService) ReleaseQuarantine(ctx context.Context, shortID string) error {
+	paste, err := s.pasteRepo.GetByShortID(ctx, shortID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return ErrPasteNotFound
+		}
+		return fmt.Errorf("paste: failed to get paste: %w", err)
+	}
+	if !paste.IsQuarantined() {
+		return ErrPasteNotQuarantined
+	}
+
+	if err := s.pasteRepo.SetQuarantine(ctx, shortID, nil); err != nil {
+		if errors.Is(err, repository.ErrPasteNotFound) {
+			return ErrPasteNotFound
+		}
+		return fmt.Errorf("paste: failed to release paste: %w", err)
+	}
+	log.Printf("[PasteService.ReleaseQuarantine] Released: %s", shortID)
+	return nil
+}
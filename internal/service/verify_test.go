@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashStored(t *testing.T) {
+	content := strings.Repeat("package main\n", 100)
+	gzipped, err := compressContent(content)
+	if err != nil {
+		t.Fatalf("compressContent() error = %v", err)
+	}
+	var zstded bytes.Buffer
+	zw, _ := CompressionZstd.newWriter(&zstded)
+	_, _ = zw.Write([]byte(content))
+	zw.Close()
+
+	// Flip a byte of the gzip CRC-32 trailer
+	badCRC := bytes.Clone(gzipped)
+	badCRC[len(badCRC)-5] ^= 0xff
+
+	tests := []struct {
+		name        string
+		stored      []byte
+		compression Compression
+		limit       int64
+		wantErr     error
+	}{
+		{"gzip", gzipped, CompressionGzip, DefaultMaxDecompressedSize, nil},
+		{"zstd", zstded.Bytes(), CompressionZstd, DefaultMaxDecompressedSize, nil},
+		{"uncompressed", []byte(content), compressionNone, DefaultMaxDecompressedSize, nil},
+		{"gzip checksum mismatch", badCRC, CompressionGzip, DefaultMaxDecompressedSize, ErrCorruptContent},
+		{"truncated gzip", gzipped[:len(gzipped)/2], CompressionGzip, DefaultMaxDecompressedSize, ErrCorruptContent},
+		{"truncated zstd", zstded.Bytes()[:zstded.Len()/2], CompressionZstd, DefaultMaxDecompressedSize, ErrCorruptContent},
+		{"over the limit", gzipped, CompressionGzip, 10, ErrCorruptContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := hashStored(tt.stored, tt.compression, tt.limit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("hashStored() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && hash != ContentHash(content) {
+				t.Errorf("hashStored() = %s, want %s", hash, ContentHash(content))
+			}
+		})
+	}
+}
+
+func TestPasteService_VerifyStoredPastes(t *testing.T) {
+	svc, cleanup := setupPasteServiceTest(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	sound, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "sound"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	tampered, err := svc.CreatePaste(ctx, &CreatePasteRequest{Content: "original"})
+	if err != nil {
+		t.Fatalf("CreatePaste() error = %v", err)
+	}
+	// Content changed behind the service's back no longer matches its checksum
+	if err := svc.storage.SaveContent(ctx, tampered.ShortID, "tampered"); err != nil {
+		t.Fatalf("SaveContent() error = %v", err)
+	}
+
+	run, err := svc.VerifyStoredPastes(ctx, MaxVerifySample, true)
+	if err != nil {
+		t.Fatalf("VerifyStoredPastes() error = %v", err)
+	}
+	var found bool
+	for _, corrupt := range run.Corrupt {
+		if corrupt.ShortID == sound.ShortID {
+			t.Errorf("VerifyStoredPastes() reported the sound paste as corrupt: %s", corrupt.Reason)
+		}
+		if corrupt.ShortID == tampered.ShortID {
+			found = true
+			if corrupt.Reason != CorruptHashMismatch {
+				t.Errorf("VerifyStoredPastes() reason = %q, want %q", corrupt.Reason, CorruptHashMismatch)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("VerifyStoredPastes() = %+v, want %s reported", run, tampered.ShortID)
+	}
+
+	if _, err := svc.GetPaste(ctx, tampered.ShortID); !errors.Is(err, ErrPasteQuarantined) {
+		t.Errorf("GetPaste() of a quarantined paste error = %v, want %v", err, ErrPasteQuarantined)
+	}
+	if _, err := svc.GetPaste(ctx, sound.ShortID); err != nil {
+		t.Errorf("GetPaste() of a sound paste error = %v", err)
+	}
+
+	report, err := svc.ListQuarantined(ctx, 0)
+	if err != nil || report.Count != 1 || report.Pastes[0].ShortID != tampered.ShortID {
+		t.Fatalf("ListQuarantined() = %+v, %v, want the tampered paste", report, err)
+	}
+
+	if err := svc.ReleaseQuarantine(ctx, tampered.ShortID); err != nil {
+		t.Fatalf("ReleaseQuarantine() error = %v", err)
+	}
+	if err := svc.ReleaseQuarantine(ctx, tampered.ShortID); !errors.Is(err, ErrPasteNotQuarantined) {
+		t.Errorf("ReleaseQuarantine() again error = %v, want %v", err, ErrPasteNotQuarantined)
+	}
+	if _, err := svc.GetPaste(ctx, tampered.ShortID); err != nil {
+		t.Errorf("GetPaste() of a released paste error = %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+)
+
+// Dependencies probed by the watchdog
+const (
+	DependencyMongoDB = "mongodb"
+	DependencyRedis   = "redis"
+	DependencyS3      = "s3"
+)
+
+const (
+	// DefaultWatchdogInterval is the default interval between dependency probes
+	DefaultWatchdogInterval = 5 * time.Second
+	// DefaultWatchdogTimeout is the default time a dependency has to answer a probe
+	DefaultWatchdogTimeout = 2 * time.Second
+	// DefaultWatchdogFailureThreshold is the default number of failed probes in a row after
+	// which a dependency is unhealthy
+	DefaultWatchdogFailureThreshold = 3
+)
+
+// ErrDependencyUnavailable is returned when a dependency the operation needs is unhealthy
+var ErrDependencyUnavailable = errors.New("paste: dependency unavailable")
+
+// WatchdogConfig holds configuration for the dependency watchdog
+type WatchdogConfig struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	// FailureThreshold is the number of failed probes in a row after which a dependency is
+	// unhealthy; a single successful probe makes it healthy again
+	FailureThreshold int
+}
+
+// dependencyHealth is the health of a dependency as seen by the watchdog
+type dependencyHealth struct {
+	Name     string
+	Healthy  bool
+	Since    time.Time // time of the last change, or of the start of the watchdog
+	Failures int       // failed probes in a row
+}
+
+// watchedDependency is a dependency probed by the watchdog
+type watchedDependency struct {
+	check  func(ctx context.Context) error
+	health dependencyHealth
+}
+
+// Watchdog probes dependencies continuously and keeps a health flag for each, so that requests
+// skip or reject work on an unhealthy dependency instead of waiting for it to time out. Unlike
+// the readiness probe it does not take the instance out of load balancing: reads served from
+// the cache keep working while S3 is down.
+type Watchdog struct {
+	config WatchdogConfig
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	mu           sync.RWMutex
+	dependencies map[string]*watchedDependency
+}
+
+// NewWatchdog creates a Watchdog; zero config fields take their default
+func NewWatchdog(config *WatchdogConfig) *Watchdog {
+	cfg := WatchdogConfig{
+		Interval:         DefaultWatchdogInterval,
+		Timeout:          DefaultWatchdogTimeout,
+		FailureThreshold: DefaultWatchdogFailureThreshold,
+	}
+	if config != nil {
+		if config.Interval > 0 {
+			cfg.Interval = config.Interval
+		}
+		if config.Timeout > 0 {
+			cfg.Timeout = config.Timeout
+		}
+		if config.FailureThreshold > 0 {
+			cfg.FailureThreshold = config.FailureThreshold
+		}
+	}
+
+	return &Watchdog{
+		config:       cfg,
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+		dependencies: make(map[string]*watchedDependency),
+	}
+}
+
+// AddDependency adds a dependency to probe with check, healthy until its probes fail
+func (w *Watchdog) AddDependency(name string, check func(ctx context.Context) error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.dependencies[name] = &watchedDependency{
+		check:  check,
+		health: dependencyHealth{Name: name, Healthy: true, Since: time.Now()},
+	}
+	metrics.DependencyHealthy.WithLabelValues(name).Set(1)
+}
+
+// Healthy reports whether a dependency is healthy; dependencies not watched are
+func (w *Watchdog) Healthy(name string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	dep, ok := w.dependencies[name]
+	return !ok || dep.health.Healthy
+}
+
+// Start probes the dependencies every interval until ctx is done or Stop is called
+func (w *Watchdog) Start(ctx context.Context) {
+	log.Printf("Watchdog started (interval: %v, timeout: %v, failure threshold: %d)",
+		w.config.Interval, w.config.Timeout, w.config.FailureThreshold)
+	defer close(w.doneCh)
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Watchdog stopped")
+			return
+		case <-w.stopCh:
+			log.Println("Watchdog stopped")
+			return
+		case <-ticker.C:
+			w.ProbeAll(ctx)
+		}
+	}
+}
+
+// Stop stops the watchdog after its current round of probes
+func (w *Watchdog) Stop() {
+	close(w.stopCh)
+	<-w.doneCh
+}
+
+// ProbeAll probes every dependency concurrently and updates their health
+func (w *Watchdog) ProbeAll(ctx context.Context) {
+	w.mu.RLock()
+	names := make([]string, 0, len(w.dependencies))
+	checks := make([]func(ctx context.Context) error, 0, len(w.dependencies))
+	for name, dep := range w.dependencies {
+		names = append(names, name)
+		checks = append(checks, dep.check)
+	}
+	w.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for i := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+			defer cancel()
+			w.record(names[i], checks[i](ctx))
+		}()
+	}
+	wg.Wait()
+}
+
+// record updates the health of a dependency with the result of a probe, logging and counting
+// the changes
+func (w *Watchdog) record(name string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dep, ok := w.dependencies[name]
+	if !ok {
+		return
+	}
+	health := &dep.health
+
+	if err == nil {
+		if !health.Healthy {
+			log.Printf("[Watchdog] %s healthy again after %v", name, time.Since(health.Since).Round(time.Second))
+			w.transition(health, true)
+		}
+		health.Failures = 0
+		return
+	}
+
+	health.Failures++
+	if health.Healthy && health.Failures >= w.config.FailureThreshold {
+		log.Printf("[Watchdog] %s unhealthy after %d failed probes: %v", name, health.Failures, err)
+		w.transition(health, false)
+	}
+}
+
+// transition changes the health of a dependency and exports it
+func (w *Watchdog) transition(health *dependencyHealth, healthy bool) {
+	health.Healthy = healthy
+	health.Since = time.Now()
+
+	state, value := "unhealthy", 0.0
+	if healthy {
+		state, value = "healthy", 1
+	}
+	metrics.DependencyHealthy.WithLabelValues(health.Name).Set(value)
+	metrics.DependencyTransitions.WithLabelValues(health.Name, state).Inc()
+}
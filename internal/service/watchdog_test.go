@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWatchdog_Transitions(t *testing.T) {
+	w := NewWatchdog(&WatchdogConfig{FailureThreshold: 2})
+	var err error
+	w.AddDependency(DependencyRedis, func(ctx context.Context) error { return err })
+	ctx := context.Background()
+
+	if !w.Healthy(DependencyRedis) || !w.Healthy("unknown") {
+		t.Fatal("Healthy() = false before any probe, want true")
+	}
+
+	// A single failed probe is tolerated
+	err = errors.New("connection refused")
+	w.ProbeAll(ctx)
+	if !w.Healthy(DependencyRedis) {
+		t.Error("Healthy() = false after 1 failed probe, want true below the threshold")
+	}
+	w.ProbeAll(ctx)
+	if w.Healthy(DependencyRedis) {
+		t.Error("Healthy() = true after 2 failed probes, want false")
+	}
+
+	// One successful probe recovers
+	err = nil
+	w.ProbeAll(ctx)
+	if !w.Healthy(DependencyRedis) {
+		t.Error("Healthy() = false after a successful probe, want true")
+	}
+}
+
+func TestPasteService_RejectsWritesWhileStorageUnhealthy(t *testing.T) {
+	w := NewWatchdog(&WatchdogConfig{FailureThreshold: 1})
+	w.AddDependency(DependencyS3, func(ctx context.Context) error { return errors.New("timeout") })
+	w.ProbeAll(context.Background())
+
+	s := NewPasteService(nil, nil, nil, nil, "http://localhost")
+	s.SetWatchdog(w)
+
+	_, err := s.CreatePaste(context.Background(), &CreatePasteRequest{Content: "hello", SyntaxType: "text"})
+	if !errors.Is(err, ErrDependencyUnavailable) {
+		t.Errorf("CreatePaste() error = %v, want %v", err, ErrDependencyUnavailable)
+	}
+}
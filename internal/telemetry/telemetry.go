@@ -0,0 +1,300 @@
+// Package telemetry reports anonymous usage statistics to the maintainers, so they know which
+// features are worth their time. It is off unless TELEMETRY_ENABLED=true and
+// TELEMETRY_ENDPOINT are set.
+//
+// A report holds aggregate counters only: the build version, the number of pastes, how many
+// were created, read and deleted since the last report, how often each feature and syntax type
+// was used, and which optional features are configured. It never holds paste IDs, content,
+// titles, IP addresses or URLs. Instances are told apart by a random ID shared by the
+// replicas of a deployment.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/version"
+)
+
+const (
+	// DefaultInterval is the time between two reports
+	DefaultInterval = 24 * time.Hour
+	// DefaultTimeout bounds each report delivery
+	DefaultTimeout = 10 * time.Second
+
+	// instanceIDKey is the Redis key of the deployment's random instance ID
+	instanceIDKey = "gisty:telemetry:instance_id"
+)
+
+var (
+	// ErrEndpointRequired is returned when telemetry is enabled without an endpoint
+	ErrEndpointRequired = errors.New("telemetry: endpoint required")
+	// ErrReportRejected is returned when the endpoint answers with a non-2xx status
+	ErrReportRejected = errors.New("telemetry: report rejected")
+)
+
+// PasteCounter counts the stored pastes; the paste repository implements it
+type PasteCounter interface {
+	Count(ctx context.Context) (int64, error)
+}
+
+// Config holds the reporter configuration
+type Config struct {
+	Endpoint   string
+	Interval   time.Duration
+	InstanceID string
+	Mode       string   // run mode of the process: all, serve or worker
+	Features   []string // optional features configured on this instance
+}
+
+// Report is the JSON document POSTed to the endpoint
+type Report struct {
+	InstanceID    string           `json:"instance_id"`
+	Version       string           `json:"version"`
+	Commit        string           `json:"commit"`
+	GoVersion     string           `json:"go_version"`
+	OS            string           `json:"os"`
+	Arch          string           `json:"arch"`
+	Mode          string           `json:"mode"`
+	UptimeSeconds int64            `json:"uptime_seconds"`
+	PeriodSeconds int64            `json:"period_seconds"` // time covered by the counters
+	PastesTotal   int64            `json:"pastes_total"`   // -1 when the count failed
+	Events        map[string]int64 `json:"events"`         // paste events by type
+	FeatureUsage  map[string]int64 `json:"feature_usage"`  // new pastes using each feature
+	SyntaxTypes   map[string]int64 `json:"syntax_types"`   // new pastes by syntax type
+	Features      []string         `json:"features"`
+}
+
+// counters are the usage counts since the last delivered report
+type counters struct {
+	since        time.Time
+	events       map[string]int64
+	featureUsage map[string]int64
+	syntaxTypes  map[string]int64
+}
+
+func newCounters(now time.Time) *counters {
+	return &counters{
+		since:        now,
+		events:       make(map[string]int64),
+		featureUsage: make(map[string]int64),
+		syntaxTypes:  make(map[string]int64),
+	}
+}
+
+// Reporter counts paste events and periodically reports the totals
+type Reporter struct {
+	pastes  PasteCounter
+	config  Config
+	client  *http.Client
+	started time.Time
+
+	mu      sync.Mutex
+	current *counters
+}
+
+// NewReporter creates a Reporter; it fails without an endpoint
+func NewReporter(pastes PasteCounter, cfg *Config) (*Reporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, ErrEndpointRequired
+	}
+	config := *cfg
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+	if config.InstanceID == "" {
+		config.InstanceID = newInstanceID()
+	}
+	config.Features = append([]string(nil), cfg.Features...)
+	sort.Strings(config.Features)
+
+	now := time.Now()
+	return &Reporter{
+		pastes:  pastes,
+		config:  config,
+		client:  &http.Client{Timeout: DefaultTimeout},
+		started: now,
+		current: newCounters(now),
+	}, nil
+}
+
+// Record counts a paste event; it is subscribed to the event bus
+func (r *Reporter) Record(_ context.Context, event *model.PasteEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.current.events[string(event.Type)]++
+	if event.Type != model.PasteEventCreated || event.Paste == nil {
+		return nil
+	}
+
+	p := event.Paste
+	r.current.syntaxTypes[p.SyntaxType]++
+	for feature, used := range map[string]bool{
+		"encrypted":       p.Encrypted,
+		"binary":          p.Binary,
+		"private":         p.IsPrivate,
+		"burn_after_read": p.BurnAfterRead,
+		"max_views":       p.MaxViews > 0,
+		"expiration":      p.ExpiresAt != nil,
+		"title":           p.Title != "",
+		"tags":            len(p.Tags) > 0,
+		"schema":          p.Validation != nil,
+		"output_of":       p.OutputOf != "",
+		"fork":            p.ForkedFrom != "",
+		"snippet":         p.DerivedFrom != nil,
+	} {
+		if used {
+			r.current.featureUsage[feature]++
+		}
+	}
+	return nil
+}
+
+// Start reports every interval until the context is cancelled
+func (r *Reporter) Start(ctx context.Context) {
+	log.Printf("[Telemetry] Reporting anonymous usage statistics to %s every %v (instance %s)",
+		r.config.Endpoint, r.config.Interval, r.config.InstanceID)
+
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("[Telemetry] Stopped")
+			return
+		case <-ticker.C:
+			if err := r.Send(ctx); err != nil {
+				log.Printf("[Telemetry] Failed to send report: %v", err)
+			}
+		}
+	}
+}
+
+// Send delivers a report of the counts since the last delivered one.
+// The counts are kept for the next report when delivery fails.
+func (r *Reporter) Send(ctx context.Context) error {
+	now := time.Now()
+	r.mu.Lock()
+	sent := r.current
+	r.current = newCounters(now)
+	r.mu.Unlock()
+
+	if err := r.post(ctx, r.buildReport(ctx, sent, now)); err != nil {
+		r.mu.Lock()
+		r.current = mergeCounters(sent, r.current)
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// buildReport assembles the report of the given counters
+func (r *Reporter) buildReport(ctx context.Context, c *counters, now time.Time) *Report {
+	info := version.Get()
+	report := &Report{
+		InstanceID:    r.config.InstanceID,
+		Version:       info.Version,
+		Commit:        info.Commit,
+		GoVersion:     info.GoVersion,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		Mode:          r.config.Mode,
+		UptimeSeconds: int64(now.Sub(r.started).Seconds()),
+		PeriodSeconds: int64(now.Sub(c.since).Seconds()),
+		PastesTotal:   -1,
+		Events:        c.events,
+		FeatureUsage:  c.featureUsage,
+		SyntaxTypes:   c.syntaxTypes,
+		Features:      r.config.Features,
+	}
+	if r.pastes != nil {
+		if total, err := r.pastes.Count(ctx); err == nil {
+			report.PastesTotal = total
+		} else {
+			log.Printf("[Telemetry] Failed to count pastes: %v", err)
+		}
+	}
+	return report
+}
+
+// post delivers a report as a JSON POST; any 2xx answer acknowledges it
+func (r *Reporter) post(ctx context.Context, report *Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gisty/"+version.Get().Version)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: status %d", ErrReportRejected, resp.StatusCode)
+	}
+	return nil
+}
+
+// mergeCounters adds the counts of newer to older, which keeps its start time
+func mergeCounters(older, newer *counters) *counters {
+	for k, n := range newer.events {
+		older.events[k] += n
+	}
+	for k, n := range newer.featureUsage {
+		older.featureUsage[k] += n
+	}
+	for k, n := range newer.syntaxTypes {
+		older.syntaxTypes[k] += n
+	}
+	return older
+}
+
+// LoadInstanceID returns the random instance ID of the deployment, creating it on first use.
+// The ID is shared through Redis by all replicas; a random one is used when Redis fails.
+func LoadInstanceID(ctx context.Context, rdb *redis.Client) string {
+	id := newInstanceID()
+	if rdb == nil {
+		return id
+	}
+	if err := rdb.SetNX(ctx, instanceIDKey, id, 0).Err(); err != nil {
+		log.Printf("[Telemetry] Failed to store instance ID: %v", err)
+		return id
+	}
+	stored, err := rdb.Get(ctx, instanceIDKey).Result()
+	if err != nil {
+		log.Printf("[Telemetry] Failed to load instance ID: %v", err)
+		return id
+	}
+	return stored
+}
+
+// newInstanceID returns a random 128-bit hex ID
+func newInstanceID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
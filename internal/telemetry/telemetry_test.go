@@ -0,0 +1,143 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+type fakeCounter struct {
+	count int64
+	err   error
+}
+
+func (f fakeCounter) Count(context.Context) (int64, error) {
+	return f.count, f.err
+}
+
+func TestNewReporter_RequiresEndpoint(t *testing.T) {
+	if _, err := NewReporter(nil, &Config{}); !errors.Is(err, ErrEndpointRequired) {
+		t.Errorf("NewReporter() error = %v, want %v", err, ErrEndpointRequired)
+	}
+}
+
+func TestReporter_Send(t *testing.T) {
+	var received []*Report
+	var raw string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		raw = string(body)
+		var report Report
+		if err := json.Unmarshal([]byte(raw), &report); err != nil {
+			t.Errorf("invalid report: %v", err)
+		}
+		received = append(received, &report)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	r, err := NewReporter(fakeCounter{count: 42}, &Config{
+		Endpoint: server.URL,
+		Mode:     "serve",
+		Features: []string{"trending", "api_keys"},
+	})
+	if err != nil {
+		t.Fatalf("NewReporter() error = %v", err)
+	}
+
+	ctx := context.Background()
+	_ = r.Record(ctx, &model.PasteEvent{Type: model.PasteEventCreated, ShortID: "secret1", Paste: &model.Paste{
+		ShortID:       "secret1",
+		SyntaxType:    "go",
+		Title:         "my private title",
+		BurnAfterRead: true,
+	}})
+	_ = r.Record(ctx, &model.PasteEvent{Type: model.PasteEventCreated, ShortID: "secret2", Paste: &model.Paste{
+		ShortID:    "secret2",
+		SyntaxType: "go",
+		IsPrivate:  true,
+	}})
+	_ = r.Record(ctx, &model.PasteEvent{Type: model.PasteEventRead, ShortID: "secret1"})
+
+	if err := r.Send(ctx); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("received %d reports, want 1", len(received))
+	}
+	report := received[0]
+	if report.PastesTotal != 42 || report.Mode != "serve" || len(report.InstanceID) != 32 {
+		t.Errorf("report = %+v", report)
+	}
+	if report.Events["paste.created"] != 2 || report.Events["paste.read"] != 1 {
+		t.Errorf("Events = %v", report.Events)
+	}
+	if report.SyntaxTypes["go"] != 2 {
+		t.Errorf("SyntaxTypes = %v", report.SyntaxTypes)
+	}
+	if report.FeatureUsage["burn_after_read"] != 1 || report.FeatureUsage["private"] != 1 || report.FeatureUsage["title"] != 1 {
+		t.Errorf("FeatureUsage = %v", report.FeatureUsage)
+	}
+	if strings.Join(report.Features, ",") != "api_keys,trending" {
+		t.Errorf("Features = %v, want sorted", report.Features)
+	}
+	for _, leak := range []string{"secret1", "secret2", "my private title"} {
+		if strings.Contains(raw, leak) {
+			t.Errorf("report contains %q: %s", leak, raw)
+		}
+	}
+
+	// Counters start over after a delivered report
+	if err := r.Send(ctx); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if n := received[1].Events["paste.created"]; n != 0 {
+		t.Errorf("second report counted %d creations, want 0", n)
+	}
+}
+
+func TestReporter_SendKeepsCountsOnFailure(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	var last Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&last)
+	}))
+	defer server.Close()
+
+	r, err := NewReporter(fakeCounter{err: errors.New("mongo down")}, &Config{Endpoint: server.URL, Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewReporter() error = %v", err)
+	}
+
+	ctx := context.Background()
+	_ = r.Record(ctx, &model.PasteEvent{Type: model.PasteEventDeleted})
+	if err := r.Send(ctx); !errors.Is(err, ErrReportRejected) {
+		t.Fatalf("Send() error = %v, want %v", err, ErrReportRejected)
+	}
+	_ = r.Record(ctx, &model.PasteEvent{Type: model.PasteEventDeleted})
+
+	fail.Store(false)
+	if err := r.Send(ctx); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if n := last.Events["paste.deleted"]; n != 2 {
+		t.Errorf("deleted = %d, want 2 carried over from the failed report", n)
+	}
+	if last.PastesTotal != -1 {
+		t.Errorf("PastesTotal = %d, want -1 when counting fails", last.PastesTotal)
+	}
+}
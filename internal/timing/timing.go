@@ -0,0 +1,89 @@
+// Package timing records how long a request spends in each backend phase (cache, database, storage).
+// It is a lightweight alternative to tracing spans: layers call Track with the request context,
+// and the slow request middleware reports the accumulated durations.
+package timing
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Backend phases
+const (
+	PhaseCache   = "cache"
+	PhaseMongo   = "mongo"
+	PhaseStorage = "s3"
+)
+
+type recorderKey struct{}
+
+// Recorder accumulates time spent per phase. It is safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	phases map[string]*Phase
+}
+
+// Phase is the accumulated duration of one phase
+type Phase struct {
+	Name     string
+	Duration time.Duration
+	Calls    int
+}
+
+// WithRecorder returns a context carrying a new Recorder
+func WithRecorder(ctx context.Context) (context.Context, *Recorder) {
+	r := &Recorder{phases: make(map[string]*Phase)}
+	return context.WithValue(ctx, recorderKey{}, r), r
+}
+
+// FromContext returns the Recorder of the context, or nil if there is none
+func FromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// Track starts timing a phase and returns a function that stops it.
+// It is a no-op when the context carries no Recorder, so it can be used unconditionally:
+//
+//	defer timing.Track(ctx, timing.PhaseMongo)()
+func Track(ctx context.Context, phase string) func() {
+	r := FromContext(ctx)
+	if r == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		r.Add(phase, time.Since(start))
+	}
+}
+
+// Add records time spent in a phase
+func (r *Recorder) Add(phase string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.phases[phase]
+	if !ok {
+		p = &Phase{Name: phase}
+		r.phases[phase] = p
+	}
+	p.Duration += d
+	p.Calls++
+}
+
+// Phases returns the recorded phases sorted by name
+func (r *Recorder) Phases() []Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	phases := make([]Phase, 0, len(r.phases))
+	for _, p := range r.phases {
+		phases = append(phases, *p)
+	}
+	sort.Slice(phases, func(i, j int) bool {
+		return phases[i].Name < phases[j].Name
+	})
+	return phases
+}
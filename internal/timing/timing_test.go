@@ -0,0 +1,46 @@
+package timing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRecorder_Phases(t *testing.T) {
+	ctx, r := WithRecorder(context.Background())
+
+	if FromContext(ctx) != r {
+		t.Fatal("FromContext() did not return the recorder")
+	}
+
+	r.Add(PhaseStorage, 30*time.Millisecond)
+	r.Add(PhaseCache, 2*time.Millisecond)
+	r.Add(PhaseStorage, 20*time.Millisecond)
+	Track(ctx, PhaseMongo)()
+
+	phases := r.Phases()
+	if len(phases) != 3 {
+		t.Fatalf("len(Phases()) = %d, want 3", len(phases))
+	}
+
+	// Sorted by name
+	wantNames := []string{PhaseCache, PhaseMongo, PhaseStorage}
+	for i, name := range wantNames {
+		if phases[i].Name != name {
+			t.Errorf("phases[%d].Name = %q, want %q", i, phases[i].Name, name)
+		}
+	}
+
+	if phases[2].Duration != 50*time.Millisecond || phases[2].Calls != 2 {
+		t.Errorf("storage phase = %+v, want 50ms over 2 calls", phases[2])
+	}
+}
+
+func TestTrack_WithoutRecorder(t *testing.T) {
+	// Tracking without a recorder must be a harmless no-op
+	Track(context.Background(), PhaseMongo)()
+
+	if FromContext(context.Background()) != nil {
+		t.Error("FromContext() on a bare context should be nil")
+	}
+}
@@ -0,0 +1,83 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are exported with OTLP over HTTP; the
+// exporter is configured by the standard OTEL_EXPORTER_OTLP_* environment variables
+// (e.g., OTEL_EXPORTER_OTLP_ENDPOINT=http://otel-collector:4318).
+//
+// The MongoDB, Redis and S3 clients and the HTTP router are always instrumented: while tracing
+// is disabled they use the global no-op provider and spans cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/huylvt/gisty/internal/version"
+)
+
+// InstrumentationName names the tracer of the application's own spans
+const InstrumentationName = "github.com/huylvt/gisty"
+
+// Config holds the tracing configuration
+type Config struct {
+	Enabled     bool
+	ServiceName string
+	// SampleRatio is the fraction of new traces recorded, in [0, 1]; requests carrying a
+	// sampled traceparent are always recorded
+	SampleRatio float64
+}
+
+// Setup installs the global tracer provider and W3C trace context propagation.
+// It returns a function flushing pending spans, to call on shutdown.
+func Setup(ctx context.Context, cfg *Config) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("tracing: failed to create OTLP exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(version.Get().Version),
+	))
+	if err == nil {
+		res, err = resource.Merge(res, resource.Environment())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tracing: failed to build resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Start starts a span of the application, e.g., tracing.Start(ctx, "PasteService.GetPaste")
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(InstrumentationName).Start(ctx, name, opts...)
+}
+
+// TraceID returns the ID of the trace of the context, or "" when it is not sampled
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), &Config{})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+
+	ctx, span := Start(context.Background(), "test")
+	defer span.End()
+	if span.SpanContext().IsValid() {
+		t.Error("Start() recorded a span while tracing is disabled")
+	}
+	if got := TraceID(ctx); got != "" {
+		t.Errorf("TraceID() = %q while tracing is disabled, want empty", got)
+	}
+}
+
+func TestSetup_Enabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://127.0.0.1:1")
+	provider := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(provider)
+
+	shutdown, err := Setup(context.Background(), &Config{Enabled: true, ServiceName: "gisty-test", SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	ctx, span := Start(context.Background(), "test")
+	if got := TraceID(ctx); got == "" || got != span.SpanContext().TraceID().String() {
+		t.Errorf("TraceID() = %q, want the span's trace ID", got)
+	}
+	span.End()
+
+	// Nothing listens on the endpoint; shutdown must still return
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_ = shutdown(ctx)
+}
@@ -0,0 +1,40 @@
+package version
+
+import "runtime"
+
+// Build metadata, injected at compile time with:
+//
+//	go build -ldflags "-X github.com/huylvt/gisty/internal/version.Version=1.2.3 \
+//	  -X github.com/huylvt/gisty/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/huylvt/gisty/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	// Version is the release version of the build
+	Version = "dev"
+	// Commit is the git commit the binary was built from
+	Commit = "unknown"
+	// BuildDate is the UTC time the binary was built (RFC3339)
+	BuildDate = "unknown"
+)
+
+// Info holds the build metadata of the running binary
+type Info struct {
+	Version   string `json:"version" example:"1.2.3"`
+	Commit    string `json:"commit" example:"a1b2c3d"`
+	BuildDate string `json:"build_date" example:"2024-01-15T14:00:00Z"`
+	GoVersion string `json:"go_version" example:"go1.24.11"`
+}
+
+// Get returns the build metadata of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+}
+
+// String returns a human readable version string
+func (i Info) String() string {
+	return i.Version + " (commit " + i.Commit + ", built " + i.BuildDate + ")"
+}
@@ -0,0 +1,36 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	origVersion, origCommit, origBuildDate := Version, Commit, BuildDate
+	defer func() {
+		Version, Commit, BuildDate = origVersion, origCommit, origBuildDate
+	}()
+
+	Version = "1.2.3"
+	Commit = "abc1234"
+	BuildDate = "2024-01-15T14:00:00Z"
+
+	info := Get()
+	if info.Version != "1.2.3" {
+		t.Errorf("Version = %q, want %q", info.Version, "1.2.3")
+	}
+	if info.Commit != "abc1234" {
+		t.Errorf("Commit = %q, want %q", info.Commit, "abc1234")
+	}
+	if info.BuildDate != "2024-01-15T14:00:00Z" {
+		t.Errorf("BuildDate = %q, want %q", info.BuildDate, "2024-01-15T14:00:00Z")
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("GoVersion = %q, want %q", info.GoVersion, runtime.Version())
+	}
+
+	want := "1.2.3 (commit abc1234, built 2024-01-15T14:00:00Z)"
+	if info.String() != want {
+		t.Errorf("String() = %q, want %q", info.String(), want)
+	}
+}
@@ -0,0 +1,247 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/model"
+	"github.com/huylvt/gisty/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultChangeStreamName identifies the publisher's saved position
+	DefaultChangeStreamName = "paste_events"
+	// DefaultChangeStreamRetryInterval is the wait before reopening a failed change stream
+	DefaultChangeStreamRetryInterval = 5 * time.Second
+
+	// changeStreamHistoryLost is the MongoDB error code of a resume token older than the oplog
+	changeStreamHistoryLost = 286
+)
+
+// ChangeStreamPublisherConfig holds configuration for the change stream publisher
+type ChangeStreamPublisherConfig struct {
+	// Name identifies the saved stream position; publishers feeding different sinks need different names
+	Name string
+	// Region is stamped on published events
+	Region        string
+	RetryInterval time.Duration
+}
+
+// ChangeStreamPublisher tails the MongoDB change stream of the pastes collection and publishes
+// paste lifecycle events to a sink. The stream position is saved after each change, so events
+// are delivered at least once across restarts. Only one publisher per name should run at a time.
+type ChangeStreamPublisher struct {
+	pasteRepo *repository.PasteRepository
+	tokens    *repository.ResumeTokenStore
+	sink      EventSink
+	config    ChangeStreamPublisherConfig
+}
+
+// pasteChange is the subset of a change stream event the publisher reads
+type pasteChange struct {
+	ID                       bson.Raw            `bson:"_id"`
+	OperationType            string              `bson:"operationType"`
+	ClusterTime              primitive.Timestamp `bson:"clusterTime"`
+	WallTime                 *time.Time          `bson:"wallTime"`
+	FullDocument             *model.Paste        `bson:"fullDocument"`
+	FullDocumentBeforeChange *model.Paste        `bson:"fullDocumentBeforeChange"`
+	UpdateDescription        *struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+// NewChangeStreamPublisher creates a new ChangeStreamPublisher
+func NewChangeStreamPublisher(
+	pasteRepo *repository.PasteRepository,
+	tokens *repository.ResumeTokenStore,
+	sink EventSink,
+	config *ChangeStreamPublisherConfig,
+) *ChangeStreamPublisher {
+	cfg := ChangeStreamPublisherConfig{
+		Name:          DefaultChangeStreamName,
+		RetryInterval: DefaultChangeStreamRetryInterval,
+	}
+
+	if config != nil {
+		if config.Name != "" {
+			cfg.Name = config.Name
+		}
+		if config.RetryInterval > 0 {
+			cfg.RetryInterval = config.RetryInterval
+		}
+		cfg.Region = config.Region
+	}
+
+	return &ChangeStreamPublisher{
+		pasteRepo: pasteRepo,
+		tokens:    tokens,
+		sink:      sink,
+		config:    cfg,
+	}
+}
+
+// Start tails the change stream until the context is cancelled, reopening it after failures
+func (p *ChangeStreamPublisher) Start(ctx context.Context) {
+	log.Printf("Change Stream Publisher started (name: %s, region: %q)", p.config.Name, p.config.Region)
+
+	if err := p.pasteRepo.EnableChangeStreamPreImages(ctx); err != nil {
+		log.Printf("Change Stream Publisher: pre-images unavailable, deleted pastes will not be published: %v", err)
+	}
+
+	for {
+		err := p.run(ctx)
+		if ctx.Err() != nil {
+			log.Println("Change Stream Publisher stopped (context cancelled)")
+			return
+		}
+
+		var serverErr mongo.ServerError
+		if errors.As(err, &serverErr) && serverErr.HasErrorCode(changeStreamHistoryLost) {
+			// The saved position fell off the oplog: events in between are lost, restart from now
+			log.Printf("Change Stream Publisher: resume token expired, restarting from the current time")
+			if err := p.tokens.Delete(ctx, p.config.Name); err != nil {
+				log.Printf("Change Stream Publisher: error deleting resume token: %v", err)
+			}
+		}
+
+		log.Printf("Change Stream Publisher: %v, retrying in %v", err, p.config.RetryInterval)
+		select {
+		case <-ctx.Done():
+			log.Println("Change Stream Publisher stopped (context cancelled)")
+			return
+		case <-time.After(p.config.RetryInterval):
+		}
+	}
+}
+
+// run publishes changes from the saved position until the stream fails
+func (p *ChangeStreamPublisher) run(ctx context.Context) error {
+	token, err := p.tokens.Load(ctx, p.config.Name)
+	if err != nil {
+		metrics.ChangeStreamFailures.WithLabelValues("token").Inc()
+		return fmt.Errorf("loading resume token: %w", err)
+	}
+
+	stream, err := p.pasteRepo.Watch(ctx, token)
+	if err != nil {
+		metrics.ChangeStreamFailures.WithLabelValues("watch").Inc()
+		return fmt.Errorf("opening change stream: %w", err)
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var change pasteChange
+		if err := stream.Decode(&change); err != nil {
+			metrics.ChangeStreamFailures.WithLabelValues("decode").Inc()
+			return fmt.Errorf("decoding change: %w", err)
+		}
+
+		if event := toPasteEvent(&change, p.config.Region); event != nil {
+			if err := p.sink.Publish(ctx, event); err != nil {
+				metrics.ChangeStreamFailures.WithLabelValues("publish").Inc()
+				return fmt.Errorf("publishing %s of %s: %w", event.Type, event.ShortID, err)
+			}
+			metrics.ChangeStreamEvents.WithLabelValues(string(event.Type)).Inc()
+		}
+
+		if err := p.tokens.Save(ctx, p.config.Name, stream.ResumeToken()); err != nil {
+			metrics.ChangeStreamFailures.WithLabelValues("token").Inc()
+			return fmt.Errorf("saving resume token: %w", err)
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		metrics.ChangeStreamFailures.WithLabelValues("watch").Inc()
+		return err
+	}
+	return errors.New("change stream closed")
+}
+
+// toPasteEvent maps a change of the pastes collection to a lifecycle event, or returns nil
+// for changes consumers do not see: pending uploads, bookkeeping updates, and deletes
+// recorded without a pre-image
+func toPasteEvent(change *pasteChange, region string) *model.PasteEvent {
+	event := &model.PasteEvent{
+		ID:         change.eventID(),
+		Region:     region,
+		OccurredAt: change.occurredAt(),
+	}
+
+	switch change.OperationType {
+	case "insert":
+		if change.FullDocument == nil || change.FullDocument.Upload != nil {
+			return nil
+		}
+		event.Type = model.PasteEventCreated
+
+	case "update", "replace":
+		// A nil post-image means the paste was deleted before the lookup; its delete follows
+		if change.FullDocument == nil || change.FullDocument.Upload != nil {
+			return nil
+		}
+		event.Type = model.PasteEventUpdated
+		if desc := change.UpdateDescription; desc != nil {
+			if slices.Contains(desc.RemovedFields, "upload") {
+				// Completing a direct upload makes the paste readable
+				event.Type = model.PasteEventCreated
+			} else if len(desc.RemovedFields) == 0 && onlyBookkeeping(desc.UpdatedFields) {
+				return nil
+			}
+		}
+
+	case "delete":
+		if change.FullDocumentBeforeChange == nil || change.FullDocumentBeforeChange.Upload != nil {
+			return nil
+		}
+		event.Type = model.PasteEventDeleted
+		event.ShortID = change.FullDocumentBeforeChange.ShortID
+		return event
+
+	default:
+		return nil
+	}
+
+	event.ShortID = change.FullDocument.ShortID
+	event.Paste = change.FullDocument
+	return event
+}
+
+// onlyBookkeeping reports whether an update only counted a view, stored lint annotations or
+// claimed a burn-after-read paste, whose delete follows
+func onlyBookkeeping(fields bson.M) bool {
+	if _, claimed := fields["burned_at"]; claimed {
+		return true
+	}
+	if _, counted := fields["view_count"]; counted && len(fields) == 1 {
+		return true
+	}
+	if _, linted := fields["lint"]; linted && len(fields) == 1 {
+		return true
+	}
+	return false
+}
+
+// eventID derives a stable event identifier from the change's resume token
+func (c *pasteChange) eventID() string {
+	if data, ok := c.ID.Lookup("_data").StringValueOK(); ok {
+		return data
+	}
+	return c.ID.String()
+}
+
+// occurredAt returns the wall clock time of the change, falling back to the cluster time
+func (c *pasteChange) occurredAt() time.Time {
+	if c.WallTime != nil {
+		return c.WallTime.UTC()
+	}
+	return time.Unix(int64(c.ClusterTime.T), 0).UTC()
+}
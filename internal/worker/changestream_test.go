@@ -0,0 +1,116 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestToPasteEvent(t *testing.T) {
+	wallTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	paste := &model.Paste{ShortID: "abc123"}
+	pending := &model.Paste{ShortID: "abc123", Upload: &model.PendingUpload{Size: 10}}
+	token, _ := bson.Marshal(bson.M{"_data": "826A"})
+
+	type updateDescription = struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	}
+
+	tests := []struct {
+		name   string
+		change pasteChange
+		want   model.PasteEventType // empty when no event is expected
+	}{
+		{"insert", pasteChange{OperationType: "insert", FullDocument: paste}, model.PasteEventCreated},
+		{"pending upload insert", pasteChange{OperationType: "insert", FullDocument: pending}, ""},
+		{"upload completed", pasteChange{OperationType: "update", FullDocument: paste,
+			UpdateDescription: &updateDescription{RemovedFields: []string{"upload"}}}, model.PasteEventCreated},
+		{"edit", pasteChange{OperationType: "update", FullDocument: paste,
+			UpdateDescription: &updateDescription{UpdatedFields: bson.M{"revision": 1}}}, model.PasteEventUpdated},
+		{"burn-after-read claim", pasteChange{OperationType: "update", FullDocument: paste,
+			UpdateDescription: &updateDescription{UpdatedFields: bson.M{"burned_at": wallTime, "expires_at": wallTime}}}, ""},
+		{"view counted", pasteChange{OperationType: "update", FullDocument: paste,
+			UpdateDescription: &updateDescription{UpdatedFields: bson.M{"view_count": int64(3)}}}, ""},
+		{"lint annotations stored", pasteChange{OperationType: "update", FullDocument: paste,
+			UpdateDescription: &updateDescription{UpdatedFields: bson.M{"lint": bson.M{"revision": 0}}}}, ""},
+		{"update of deleted paste", pasteChange{OperationType: "update"}, ""},
+		{"delete", pasteChange{OperationType: "delete", FullDocumentBeforeChange: paste}, model.PasteEventDeleted},
+		{"delete without pre-image", pasteChange{OperationType: "delete"}, ""},
+		{"drop", pasteChange{OperationType: "drop"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change.ID = token
+			tt.change.WallTime = &wallTime
+
+			event := toPasteEvent(&tt.change, "eu-west")
+			if tt.want == "" {
+				if event != nil {
+					t.Fatalf("toPasteEvent() = %+v, want nil", event)
+				}
+				return
+			}
+			if event == nil {
+				t.Fatalf("toPasteEvent() = nil, want %s", tt.want)
+			}
+			if event.Type != tt.want {
+				t.Errorf("Type = %s, want %s", event.Type, tt.want)
+			}
+			if event.ShortID != "abc123" || event.ID != "826A" || event.Region != "eu-west" || !event.OccurredAt.Equal(wallTime) {
+				t.Errorf("toPasteEvent() = %+v", event)
+			}
+			if (event.Paste == nil) != (tt.want == model.PasteEventDeleted) {
+				t.Errorf("Paste = %v for %s event", event.Paste, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookSink_Publish(t *testing.T) {
+	var received model.PasteEvent
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Gisty-Event") != string(model.PasteEventCreated) || r.Header.Get("X-Gisty-Event-ID") != "evt-1" {
+			t.Errorf("unexpected event headers: %v", r.Header)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, time.Second)
+	event := &model.PasteEvent{ID: "evt-1", Type: model.PasteEventCreated, ShortID: "abc123"}
+
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if received.ShortID != "abc123" {
+		t.Errorf("received short_id = %q, want abc123", received.ShortID)
+	}
+
+	status = http.StatusInternalServerError
+	if err := sink.Publish(context.Background(), event); !errors.Is(err, ErrWebhookRejected) {
+		t.Errorf("Publish() error = %v, want ErrWebhookRejected", err)
+	}
+}
+
+func TestNewEventSink(t *testing.T) {
+	if _, err := NewEventSink(EventSinkWebhook, ""); !errors.Is(err, ErrWebhookURLRequired) {
+		t.Errorf("webhook without URL error = %v, want ErrWebhookURLRequired", err)
+	}
+	if _, err := NewEventSink("kafka", ""); !errors.Is(err, ErrUnknownEventSink) {
+		t.Errorf("unknown sink error = %v, want ErrUnknownEventSink", err)
+	}
+	if sink, err := NewEventSink(EventSinkLog, ""); err != nil || sink == nil {
+		t.Errorf("log sink = %v, %v", sink, err)
+	}
+}
@@ -2,9 +2,12 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sync"
 	"time"
 
+	"github.com/huylvt/gisty/internal/metrics"
 	"github.com/huylvt/gisty/internal/repository"
 	"github.com/huylvt/gisty/internal/service"
 )
@@ -12,24 +15,81 @@ import (
 const (
 	// DefaultCleanupInterval is the default interval between cleanup runs
 	DefaultCleanupInterval = 5 * time.Minute
-	// DefaultCleanupBatchSize is the default batch size for cleanup operations
+	// DefaultCleanupBatchSize is the default number of storage objects checked per batch
 	DefaultCleanupBatchSize = 100
+	// DefaultCleanupMinAge is the default age below which a storage object is never treated as orphaned
+	DefaultCleanupMinAge = time.Hour
+	// DefaultCleanupMaxAttempts is the default number of failed deletes after which an orphaned object is abandoned
+	DefaultCleanupMaxAttempts = 5
+	// DefaultCleanupScanLimit is the default number of storage objects listed per run
+	DefaultCleanupScanLimit = 10000
 )
 
 // CleanupWorkerConfig holds configuration for the cleanup worker
 type CleanupWorkerConfig struct {
 	Interval  time.Duration
 	BatchSize int64
+	// DryRun logs the objects that would be deleted without deleting anything
+	DryRun bool
+	// MinAge protects objects written just before their paste record, such as content saved
+	// by a create that has not inserted the record yet
+	MinAge time.Duration
+	// MaxAttempts caps how many runs an orphaned object that failed to delete is retried
+	MaxAttempts int
+	// ScanLimit bounds the objects listed per run; each run resumes the listing where the last
+	// one stopped, so a large bucket is covered over several runs
+	ScanLimit int64
 }
 
-// CleanupWorker handles periodic cleanup of expired pastes
+// CleanupRunStats holds the results of a single cleanup run
+type CleanupRunStats struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Scanned   int64
+	Deleted   int64
+	Failures  int64
+	DryRun    bool
+}
+
+// CleanupStatus reports the worker state for operators
+type CleanupStatus struct {
+	Interval time.Duration
+	DryRun   bool
+	MinAge   time.Duration
+	LastRun  *CleanupRunStats
+	Backlog  int64
+	// Retrying and Abandoned count the orphaned objects that failed to delete, below and at the
+	// attempt cap
+	Retrying  int
+	Abandoned int
+}
+
+// deleteFailure tracks the failed deletes of an orphaned object
+type deleteFailure struct {
+	attempts int
+	lastErr  string
+}
+
+// CleanupWorker reconciles storage with MongoDB. Expired pastes are removed by the TTL index on
+// expires_at; the worker removes the S3 objects, cache entries, revisions and unfinished uploads
+// left behind by records that no longer exist.
 type CleanupWorker struct {
 	pasteRepo *repository.PasteRepository
+	revisions *repository.RevisionRepository
 	storage   *service.Storage
 	cache     *service.Cache
 	config    CleanupWorkerConfig
 	stopCh    chan struct{}
 	doneCh    chan struct{}
+
+	mu      sync.RWMutex
+	lastRun *CleanupRunStats
+
+	// cursor is the key the next run resumes listing after, empty to start over
+	cursor string
+	// failures holds the orphaned objects whose delete failed, by key. They are kept in memory:
+	// after a restart, objects that still fail are counted again from their first attempt.
+	failures map[string]*deleteFailure
 }
 
 // NewCleanupWorker creates a new CleanupWorker
@@ -40,8 +100,11 @@ func NewCleanupWorker(
 	config *CleanupWorkerConfig,
 ) *CleanupWorker {
 	cfg := CleanupWorkerConfig{
-		Interval:  DefaultCleanupInterval,
-		BatchSize: DefaultCleanupBatchSize,
+		Interval:    DefaultCleanupInterval,
+		BatchSize:   DefaultCleanupBatchSize,
+		MinAge:      DefaultCleanupMinAge,
+		MaxAttempts: DefaultCleanupMaxAttempts,
+		ScanLimit:   DefaultCleanupScanLimit,
 	}
 
 	if config != nil {
@@ -51,6 +114,16 @@ func NewCleanupWorker(
 		if config.BatchSize > 0 {
 			cfg.BatchSize = config.BatchSize
 		}
+		if config.MinAge > 0 {
+			cfg.MinAge = config.MinAge
+		}
+		if config.MaxAttempts > 0 {
+			cfg.MaxAttempts = config.MaxAttempts
+		}
+		if config.ScanLimit > 0 {
+			cfg.ScanLimit = config.ScanLimit
+		}
+		cfg.DryRun = config.DryRun
 	}
 
 	return &CleanupWorker{
@@ -60,12 +133,19 @@ func NewCleanupWorker(
 		config:    cfg,
 		stopCh:    make(chan struct{}),
 		doneCh:    make(chan struct{}),
+		failures:  make(map[string]*deleteFailure),
 	}
 }
 
+// SetRevisionRepository makes the worker also remove the revision records of removed pastes
+func (w *CleanupWorker) SetRevisionRepository(revisions *repository.RevisionRepository) {
+	w.revisions = revisions
+}
+
 // Start begins the cleanup worker
 func (w *CleanupWorker) Start(ctx context.Context) {
-	log.Printf("Cleanup Worker started (interval: %v, batch_size: %d)", w.config.Interval, w.config.BatchSize)
+	log.Printf("Cleanup Worker started (interval: %v, batch_size: %d, scan_limit: %d, min_age: %v, max_attempts: %d, dry_run: %v)",
+		w.config.Interval, w.config.BatchSize, w.config.ScanLimit, w.config.MinAge, w.config.MaxAttempts, w.config.DryRun)
 
 	// Run initial cleanup
 	w.runCleanup(ctx)
@@ -95,54 +175,304 @@ func (w *CleanupWorker) Stop() {
 	<-w.doneCh
 }
 
-// runCleanup performs one cleanup cycle
+// LastRun returns the stats of the most recent cleanup run, or nil if none has completed
+func (w *CleanupWorker) LastRun() *CleanupRunStats {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.lastRun == nil {
+		return nil
+	}
+	stats := *w.lastRun
+	return &stats
+}
+
+// Status returns the worker configuration, last run stats and the number of expired pastes
+// the TTL monitor has not removed yet
+func (w *CleanupWorker) Status(ctx context.Context) (*CleanupStatus, error) {
+	backlog, err := w.pasteRepo.CountExpired(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &CleanupStatus{
+		Interval: w.config.Interval,
+		DryRun:   w.config.DryRun,
+		MinAge:   w.config.MinAge,
+		LastRun:  w.LastRun(),
+		Backlog:  backlog,
+	}
+	w.mu.RLock()
+	for _, failure := range w.failures {
+		if failure.attempts >= w.config.MaxAttempts {
+			status.Abandoned++
+		} else {
+			status.Retrying++
+		}
+	}
+	w.mu.RUnlock()
+	return status, nil
+}
+
+// runCleanup performs one reconciliation cycle
 func (w *CleanupWorker) runCleanup(ctx context.Context) {
-	totalCleaned := int64(0)
+	stats := &CleanupRunStats{
+		StartedAt: time.Now(),
+		DryRun:    w.config.DryRun,
+	}
 
-	for {
-		// Get a batch of expired pastes
-		expiredPastes, err := w.pasteRepo.GetExpiredBatch(ctx, w.config.BatchSize)
+	cutoff := stats.StartedAt.Add(-w.config.MinAge)
+	w.reconcileObjects(ctx, cutoff, stats)
+	w.reconcileUploads(ctx, cutoff, stats)
+
+	stats.Duration = time.Since(stats.StartedAt)
+	w.recordRun(stats)
+
+	if stats.Deleted > 0 || stats.Failures > 0 {
+		log.Printf("Cleanup Worker: scanned=%d deleted=%d failures=%d dry_run=%v duration=%v",
+			stats.Scanned, stats.Deleted, stats.Failures, stats.DryRun, stats.Duration)
+	}
+}
+
+// reconcileObjects pages through up to ScanLimit content and revision objects in S3, resuming
+// where the last run stopped, and removes those whose paste record is gone. Only the listed short
+// IDs are looked up, by the unique index, so a run costs the same however large the bucket is.
+// Objects that fail to delete are retried when the listing comes back to them, up to MaxAttempts.
+func (w *CleanupWorker) reconcileObjects(ctx context.Context, cutoff time.Time, stats *CleanupRunStats) {
+	var listed int64
+	for listed < w.config.ScanLimit {
+		limit := min(w.config.BatchSize, w.config.ScanLimit-listed)
+		objects, nextKey, err := w.storage.ListObjects(ctx, w.cursor, int32(limit))
 		if err != nil {
-			log.Printf("Cleanup Worker: error fetching expired pastes: %v", err)
+			log.Printf("Cleanup Worker: error listing storage objects: %v", err)
+			w.recordFailure(stats, "storage")
 			return
 		}
+		stats.Scanned += int64(len(objects))
+		listed += limit
 
-		if len(expiredPastes) == 0 {
-			break
+		orphans, err := w.findOrphans(ctx, objects, cutoff)
+		if err != nil {
+			log.Printf("Cleanup Worker: error looking up pastes: %v", err)
+			w.recordFailure(stats, "database")
+			return
 		}
 
-		// Collect short IDs for batch deletion
-		shortIDs := make([]string, len(expiredPastes))
-		for i, paste := range expiredPastes {
-			shortIDs[i] = paste.ShortID
+		if len(orphans) > 0 {
+			w.removeOrphans(ctx, orphans, stats)
 		}
 
-		// Delete from cache (best effort, ignore errors)
-		for _, shortID := range shortIDs {
-			_ = w.cache.Delete(ctx, shortID)
+		// Past the last key, the next run starts over
+		w.cursor = nextKey
+		if nextKey == "" {
+			return
 		}
+	}
+}
 
-		// Delete from S3 (best effort, ignore errors)
-		for _, shortID := range shortIDs {
-			_ = w.storage.DeleteContent(ctx, shortID)
+// findOrphans returns the objects older than cutoff that belong to no paste record
+func (w *CleanupWorker) findOrphans(ctx context.Context, objects []service.StoredObject, cutoff time.Time) ([]service.StoredObject, error) {
+	var candidates []service.StoredObject
+	shortIDs := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		if obj.LastModified.After(cutoff) {
+			continue
 		}
+		candidates = append(candidates, obj)
+		shortIDs = append(shortIDs, obj.ShortID)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
 
-		// Delete from MongoDB
-		deletedCount, err := w.pasteRepo.DeleteMany(ctx, shortIDs)
-		if err != nil {
-			log.Printf("Cleanup Worker: error deleting from MongoDB: %v", err)
-			return
+	existing, err := w.pasteRepo.ExistingShortIDs(ctx, shortIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var orphans []service.StoredObject
+	for _, obj := range candidates {
+		if existing[obj.ShortID] || w.abandoned(obj.Key) {
+			continue
 		}
+		orphans = append(orphans, obj)
+	}
+	return orphans, nil
+}
 
-		totalCleaned += deletedCount
+// abandoned reports whether deleting an object failed MaxAttempts times
+func (w *CleanupWorker) abandoned(key string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	failure, ok := w.failures[key]
+	return ok && failure.attempts >= w.config.MaxAttempts
+}
 
-		// If we got fewer than batch size, we're done
-		if int64(len(expiredPastes)) < w.config.BatchSize {
-			break
+// removeOrphans deletes orphaned objects, then the cache entries and revision records of their pastes
+func (w *CleanupWorker) removeOrphans(ctx context.Context, orphans []service.StoredObject, stats *CleanupRunStats) {
+	if w.config.DryRun {
+		for _, obj := range orphans {
+			log.Printf("Cleanup Worker (dry run): would delete orphaned object %s (modified at %v)",
+				obj.Key, obj.LastModified)
 		}
+		return
 	}
 
-	if totalCleaned > 0 {
-		log.Printf("Cleanup Worker: cleaned up %d expired pastes", totalCleaned)
+	keys := make([]string, 0, len(orphans))
+	for _, obj := range orphans {
+		keys = append(keys, obj.Key)
 	}
-}
\ No newline at end of file
+	failed := map[string]string{}
+	if err := w.storage.DeleteObjects(ctx, keys); err != nil {
+		var partial *service.ObjectsDeleteError
+		if !errors.As(err, &partial) {
+			// The whole request failed
+			partial = &service.ObjectsDeleteError{Failed: make(map[string]string, len(keys))}
+			for _, key := range keys {
+				partial.Failed[key] = err.Error()
+			}
+		}
+		failed = partial.Failed
+	}
+
+	var deleted []service.StoredObject
+	for _, obj := range orphans {
+		if reason, ok := failed[obj.Key]; ok {
+			w.recordFailure(stats, "storage")
+			w.recordDeleteFailure(obj.Key, reason)
+			continue
+		}
+		w.clearDeleteFailure(obj.Key)
+		deleted = append(deleted, obj)
+	}
+	stats.Deleted += int64(len(deleted))
+	if len(deleted) == 0 {
+		return
+	}
+
+	shortIDs := orphanShortIDs(deleted)
+
+	// Best effort, entries expire on their own
+	for _, shortID := range shortIDs {
+		if err := w.cache.Delete(ctx, shortID); err != nil {
+			w.recordFailure(stats, "cache")
+		}
+	}
+
+	if w.revisions != nil {
+		if _, err := w.revisions.DeleteByShortIDs(ctx, shortIDs); err != nil {
+			log.Printf("Cleanup Worker: error deleting revisions: %v", err)
+			w.recordFailure(stats, "database")
+		}
+	}
+}
+
+// reconcileUploads aborts the unfinished resumable uploads of pending pastes that expired
+func (w *CleanupWorker) reconcileUploads(ctx context.Context, cutoff time.Time, stats *CleanupRunStats) {
+	uploads, err := w.storage.ListMultipartUploads(ctx)
+	if err != nil {
+		log.Printf("Cleanup Worker: error listing multipart uploads: %v", err)
+		w.recordFailure(stats, "storage")
+		return
+	}
+
+	shortIDs := make([]string, 0, len(uploads))
+	var candidates []service.StoredUpload
+	for _, upload := range uploads {
+		if upload.Initiated.After(cutoff) {
+			continue
+		}
+		candidates = append(candidates, upload)
+		shortIDs = append(shortIDs, upload.ShortID)
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	stats.Scanned += int64(len(candidates))
+
+	existing, err := w.pasteRepo.ExistingShortIDs(ctx, shortIDs)
+	if err != nil {
+		log.Printf("Cleanup Worker: error looking up pastes: %v", err)
+		w.recordFailure(stats, "database")
+		return
+	}
+
+	for _, upload := range candidates {
+		if existing[upload.ShortID] {
+			continue
+		}
+		if w.config.DryRun {
+			log.Printf("Cleanup Worker (dry run): would abort stale upload session %s", upload.ShortID)
+			continue
+		}
+		if err := w.storage.AbortMultipartUpload(ctx, upload.ShortID, upload.UploadID); err != nil {
+			log.Printf("Cleanup Worker: error aborting upload session %s, will retry: %v", upload.ShortID, err)
+			w.recordFailure(stats, "storage")
+			continue
+		}
+		stats.Deleted++
+		log.Printf("Cleanup Worker: aborted stale upload session %s", upload.ShortID)
+	}
+}
+
+// recordDeleteFailure counts a failed delete of an orphaned object and raises an alert once the
+// attempt cap is reached, after which the object is no longer retried
+func (w *CleanupWorker) recordDeleteFailure(key, reason string) {
+	w.mu.Lock()
+	failure, ok := w.failures[key]
+	if !ok {
+		failure = &deleteFailure{}
+		w.failures[key] = failure
+	}
+	failure.attempts++
+	failure.lastErr = reason
+	attempts := failure.attempts
+	w.mu.Unlock()
+
+	if attempts >= w.config.MaxAttempts {
+		metrics.CleanupAbandoned.Inc()
+		log.Printf("ALERT Cleanup Worker: giving up on orphaned object %s after %d failed deletes: %s",
+			key, attempts, reason)
+		return
+	}
+	log.Printf("Cleanup Worker: failed to delete orphaned object %s (attempt %d/%d), will retry: %s",
+		key, attempts, w.config.MaxAttempts, reason)
+}
+
+// clearDeleteFailure forgets the failed deletes of an object that is now removed
+func (w *CleanupWorker) clearDeleteFailure(key string) {
+	w.mu.Lock()
+	delete(w.failures, key)
+	w.mu.Unlock()
+}
+
+// orphanShortIDs returns the distinct short IDs of the given objects
+func orphanShortIDs(objects []service.StoredObject) []string {
+	seen := make(map[string]bool, len(objects))
+	shortIDs := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		if !seen[obj.ShortID] {
+			seen[obj.ShortID] = true
+			shortIDs = append(shortIDs, obj.ShortID)
+		}
+	}
+	return shortIDs
+}
+
+// recordFailure increments failure counters for the given storage layer
+func (w *CleanupWorker) recordFailure(stats *CleanupRunStats, layer string) {
+	stats.Failures++
+	metrics.CleanupFailures.WithLabelValues(layer).Inc()
+}
+
+// recordRun stores the run stats and exports them as metrics
+func (w *CleanupWorker) recordRun(stats *CleanupRunStats) {
+	metrics.CleanupScanned.Add(float64(stats.Scanned))
+	metrics.CleanupDeleted.Add(float64(stats.Deleted))
+	metrics.CleanupDuration.Observe(stats.Duration.Seconds())
+	metrics.CleanupLastRun.Set(float64(stats.StartedAt.Add(stats.Duration).Unix()))
+
+	w.mu.Lock()
+	w.lastRun = stats
+	w.mu.Unlock()
+}
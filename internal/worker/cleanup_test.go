@@ -27,7 +27,7 @@ func setupCleanupTest(t *testing.T) (*CleanupWorker, *repository.PasteRepository
 
 	// Setup S3
 	s3Client, err := repository.NewS3Client(context.Background(), repository.S3Config{
-		BucketName:      "gisty-test",
+		BucketName:      "gisty-cleanup-test", // orphans are judged bucket-wide, keep other packages' objects out
 		Region:          "us-east-1",
 		AccessKeyID:     "minioadmin",
 		SecretAccessKey: "minioadmin",
@@ -54,6 +54,7 @@ func setupCleanupTest(t *testing.T) (*CleanupWorker, *repository.PasteRepository
 	worker := NewCleanupWorker(pasteRepo, storage, cache, &CleanupWorkerConfig{
 		Interval:  100 * time.Millisecond, // Short interval for testing
 		BatchSize: 10,
+		MinAge:    time.Nanosecond,
 	})
 
 	cleanup := func() {
@@ -66,63 +67,65 @@ func setupCleanupTest(t *testing.T) (*CleanupWorker, *repository.PasteRepository
 	return worker, pasteRepo, storage, cache, cleanup
 }
 
-func TestCleanupWorker_CleansExpiredPastes(t *testing.T) {
+func TestCleanupWorker_RemovesOrphanedContent(t *testing.T) {
 	worker, pasteRepo, storage, cache, cleanup := setupCleanupTest(t)
 	defer cleanup()
 
 	ctx := context.Background()
 
-	// Create expired paste directly
-	expiredTime := time.Now().Add(-1 * time.Hour)
-	expiredPaste := &model.Paste{
-		ShortID:    "expired1",
-		ContentKey: "pastes/expired1",
-		ExpiresAt:  &expiredTime,
-		CreatedAt:  time.Now().Add(-2 * time.Hour),
-		SyntaxType: "text",
-	}
-
-	// Save to MongoDB
-	if err := pasteRepo.Create(ctx, expiredPaste); err != nil {
-		t.Fatalf("Failed to create expired paste: %v", err)
-	}
-
-	// Save to S3
-	if err := storage.SaveContent(ctx, "expired1", "test content"); err != nil {
+	// Content and cache left behind by a paste the TTL monitor removed
+	if err := storage.SaveContent(ctx, "orphan1", "test content"); err != nil {
 		t.Fatalf("Failed to save content: %v", err)
 	}
-
-	// Save to cache
-	if err := cache.Set(ctx, "expired1", "test content", time.Hour); err != nil {
+	if err := cache.Set(ctx, "orphan1", "test content", time.Hour); err != nil {
 		t.Fatalf("Failed to cache content: %v", err)
 	}
-
-	// Verify paste exists
-	_, err := pasteRepo.GetByShortID(ctx, "expired1")
-	if err != nil {
-		t.Fatalf("Paste should exist before cleanup: %v", err)
+	if _, err := pasteRepo.GetByShortID(ctx, "orphan1"); err != repository.ErrPasteNotFound {
+		t.Fatalf("Paste record should not exist, got error: %v", err)
 	}
 
 	// Run cleanup
 	worker.runCleanup(ctx)
 
-	// Verify paste is deleted from MongoDB
-	_, err = pasteRepo.GetByShortID(ctx, "expired1")
-	if err != repository.ErrPasteNotFound {
-		t.Errorf("Paste should be deleted from MongoDB, got error: %v", err)
-	}
-
 	// Verify content is deleted from S3
-	_, err = storage.GetContent(ctx, "expired1")
-	if err == nil {
-		t.Error("Content should be deleted from S3")
+	exists, err := storage.ContentExists(ctx, "orphan1")
+	if err != nil {
+		t.Fatalf("ContentExists() error = %v", err)
+	}
+	if exists {
+		t.Error("Orphaned content should be deleted from S3")
 	}
 
 	// Verify content is deleted from cache
-	_, found, _ := cache.Get(ctx, "expired1")
+	_, found, _ := cache.Get(ctx, "orphan1")
 	if found {
-		t.Error("Content should be deleted from cache")
+		t.Error("Orphaned content should be deleted from cache")
+	}
+}
+
+func TestCleanupWorker_KeepsRecentObjects(t *testing.T) {
+	worker, _, storage, _, cleanup := setupCleanupTest(t)
+	defer cleanup()
+
+	worker.config.MinAge = time.Hour
+	ctx := context.Background()
+
+	// Content saved by a create that has not inserted its record yet
+	if err := storage.SaveContent(ctx, "recent1", "test content"); err != nil {
+		t.Fatalf("Failed to save content: %v", err)
+	}
+
+	// Run cleanup
+	worker.runCleanup(ctx)
+
+	exists, err := storage.ContentExists(ctx, "recent1")
+	if err != nil {
+		t.Fatalf("ContentExists() error = %v", err)
+	}
+	if !exists {
+		t.Error("Content younger than MinAge should not be deleted")
 	}
+	_ = storage.DeleteContent(ctx, "recent1")
 }
 
 func TestCleanupWorker_DoesNotCleanNonExpiredPastes(t *testing.T) {
@@ -199,70 +202,42 @@ func TestCleanupWorker_DoesNotCleanPastesWithoutExpiration(t *testing.T) {
 }
 
 func TestCleanupWorker_BatchProcessing(t *testing.T) {
-	worker, pasteRepo, storage, _, cleanup := setupCleanupTest(t)
+	worker, _, storage, _, cleanup := setupCleanupTest(t)
 	defer cleanup()
 
 	ctx := context.Background()
 
-	// Get initial count of expired pastes (from other tests running in parallel)
-	initialCount, err := pasteRepo.CountExpired(ctx)
-	if err != nil {
-		t.Fatalf("Failed to get initial count: %v", err)
-	}
-
-	// Create multiple expired pastes
-	expiredTime := time.Now().Add(-1 * time.Hour)
-	numPastes := 25 // More than batch size (10)
-	for i := 0; i < numPastes; i++ {
+	// Create orphaned content spanning multiple listing pages
+	numOrphans := 25 // More than batch size (10)
+	for i := 0; i < numOrphans; i++ {
 		shortID := fmt.Sprintf("batch%02d", i)
-		paste := &model.Paste{
-			ShortID:    shortID,
-			ContentKey: "pastes/" + shortID,
-			ExpiresAt:  &expiredTime,
-			CreatedAt:  time.Now().Add(-2 * time.Hour),
-			SyntaxType: "text",
-		}
-
-		if err := pasteRepo.Create(ctx, paste); err != nil {
-			t.Fatalf("Failed to create paste %s: %v", shortID, err)
-		}
-
 		if err := storage.SaveContent(ctx, shortID, "content for "+shortID); err != nil {
 			t.Fatalf("Failed to save content for %s: %v", shortID, err)
 		}
 	}
 
-	// Verify count before cleanup - should be at least our created pastes
-	count, err := pasteRepo.CountExpired(ctx)
-	if err != nil {
-		t.Fatalf("Failed to count expired: %v", err)
-	}
-	if count < int64(numPastes) {
-		t.Errorf("Expected at least %d expired pastes, got %d", numPastes, count)
-	}
-
 	// Run cleanup
 	worker.runCleanup(ctx)
 
-	// Verify all expired pastes are deleted
-	count, err = pasteRepo.CountExpired(ctx)
-	if err != nil {
-		t.Fatalf("Failed to count expired after cleanup: %v", err)
-	}
-	if count != 0 {
-		t.Errorf("Expected 0 expired pastes after cleanup, got %d", count)
-	}
-
-	// Verify our specific pastes are deleted
-	for i := 0; i < numPastes; i++ {
+	// Verify all orphaned content is deleted
+	for i := 0; i < numOrphans; i++ {
 		shortID := fmt.Sprintf("batch%02d", i)
-		_, err := pasteRepo.GetByShortID(ctx, shortID)
-		if err != repository.ErrPasteNotFound {
-			t.Errorf("Paste %s should be deleted", shortID)
+		exists, err := storage.ContentExists(ctx, shortID)
+		if err != nil {
+			t.Fatalf("ContentExists() error = %v", err)
+		}
+		if exists {
+			t.Errorf("Content of %s should be deleted", shortID)
 		}
 	}
 
-	_ = initialCount // used for documentation, actual count may include other tests' pastes
+	stats := worker.LastRun()
+	if stats == nil {
+		t.Fatal("LastRun() should return stats after a run")
+	}
+	if stats.Deleted < int64(numOrphans) {
+		t.Errorf("LastRun().Deleted = %d, want at least %d", stats.Deleted, numOrphans)
+	}
 }
 
 func TestCleanupWorker_StartStop(t *testing.T) {
@@ -291,4 +266,98 @@ func TestCleanupWorker_StartStop(t *testing.T) {
 	case <-time.After(time.Second):
 		t.Error("Worker did not stop within timeout")
 	}
-}
\ No newline at end of file
+}
+func TestCleanupWorker_DryRun(t *testing.T) {
+	worker, pasteRepo, storage, _, cleanup := setupCleanupTest(t)
+	defer cleanup()
+
+	worker.config.DryRun = true
+	ctx := context.Background()
+
+	// Create orphaned content spanning multiple listing pages
+	numOrphans := 15 // More than batch size (10)
+	for i := 0; i < numOrphans; i++ {
+		shortID := fmt.Sprintf("dryrun%02d", i)
+		if err := storage.SaveContent(ctx, shortID, "content for "+shortID); err != nil {
+			t.Fatalf("Failed to save content for %s: %v", shortID, err)
+		}
+	}
+
+	// An expired record the TTL monitor has not removed yet
+	expiredTime := time.Now().Add(-1 * time.Hour)
+	paste := &model.Paste{
+		ShortID:    "dryrunexpired",
+		ContentKey: "gisty/dryrunexpired.gz",
+		ExpiresAt:  &expiredTime,
+		CreatedAt:  time.Now().Add(-2 * time.Hour),
+		SyntaxType: "text",
+	}
+	if err := pasteRepo.Create(ctx, paste); err != nil {
+		t.Fatalf("Failed to create paste: %v", err)
+	}
+
+	// Run cleanup in dry-run mode
+	worker.runCleanup(ctx)
+
+	// Verify nothing was deleted
+	for i := 0; i < numOrphans; i++ {
+		shortID := fmt.Sprintf("dryrun%02d", i)
+		exists, err := storage.ContentExists(ctx, shortID)
+		if err != nil {
+			t.Fatalf("ContentExists() error = %v", err)
+		}
+		if !exists {
+			t.Errorf("Content of %s should not be deleted in dry run", shortID)
+		}
+	}
+
+	// Verify run stats were recorded
+	stats := worker.LastRun()
+	if stats == nil {
+		t.Fatal("LastRun() should return stats after a run")
+	}
+	if !stats.DryRun {
+		t.Error("LastRun().DryRun should be true")
+	}
+	if stats.Scanned < int64(numOrphans) {
+		t.Errorf("LastRun().Scanned = %d, want at least %d", stats.Scanned, numOrphans)
+	}
+	if stats.Deleted != 0 {
+		t.Errorf("LastRun().Deleted = %d, want 0", stats.Deleted)
+	}
+
+	// Verify status reports the backlog
+	status, err := worker.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() returned error: %v", err)
+	}
+	if status.Backlog < 1 {
+		t.Errorf("Status().Backlog = %d, want at least 1", status.Backlog)
+	}
+
+	for i := 0; i < numOrphans; i++ {
+		_ = storage.DeleteContent(ctx, fmt.Sprintf("dryrun%02d", i))
+	}
+}
+
+func TestCleanupWorker_AbandonsAfterMaxAttempts(t *testing.T) {
+	worker := NewCleanupWorker(nil, nil, nil, &CleanupWorkerConfig{MaxAttempts: 3})
+
+	for i := 0; i < 2; i++ {
+		worker.recordDeleteFailure("gisty/stuck.gz", "AccessDenied: denied")
+	}
+	if worker.abandoned("gisty/stuck.gz") {
+		t.Fatal("object should still be retried below MaxAttempts")
+	}
+
+	worker.recordDeleteFailure("gisty/stuck.gz", "AccessDenied: denied")
+	if !worker.abandoned("gisty/stuck.gz") {
+		t.Error("object should be abandoned after MaxAttempts failed deletes")
+	}
+
+	worker.recordDeleteFailure("gisty/flaky.gz", "SlowDown: retry")
+	worker.clearDeleteFailure("gisty/flaky.gz")
+	if worker.abandoned("gisty/flaky.gz") || len(worker.failures) != 1 {
+		t.Error("a successful delete should clear the object's failures")
+	}
+}
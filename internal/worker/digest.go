@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/mail"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// DefaultDigestInterval is the default interval between notification digests
+const DefaultDigestInterval = 24 * time.Hour
+
+// DigestSender periodically emails the users who turned digests on their unread notifications
+type DigestSender struct {
+	pasteService *service.PasteService
+	mailer       mail.Mailer
+	interval     time.Duration
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+}
+
+// NewDigestSender creates a DigestSender running every interval, DefaultDigestInterval when not
+// positive
+func NewDigestSender(pasteService *service.PasteService, mailer mail.Mailer, interval time.Duration) *DigestSender {
+	if interval <= 0 {
+		interval = DefaultDigestInterval
+	}
+	return &DigestSender{
+		pasteService: pasteService,
+		mailer:       mailer,
+		interval:     interval,
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+// Start sends digests every interval until ctx is done or Stop is called. The first run waits an
+// interval, so restarts do not email users more often.
+func (d *DigestSender) Start(ctx context.Context) {
+	log.Printf("Digest Sender started (interval: %v)", d.interval)
+	defer close(d.doneCh)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.stopCh:
+			log.Println("Digest Sender stopped")
+			return
+		case <-ticker.C:
+			d.run(ctx)
+		}
+	}
+}
+
+// Stop stops the sender after its current run
+func (d *DigestSender) Stop() {
+	close(d.stopCh)
+	<-d.doneCh
+}
+
+// run sends the digests due
+func (d *DigestSender) run(ctx context.Context) {
+	sent, err := d.pasteService.SendNotificationDigests(ctx, d.mailer)
+	if err != nil {
+		log.Printf("Digest Sender: failed after %d digests: %v", sent, err)
+		return
+	}
+	if sent > 0 {
+		log.Printf("Digest Sender: emailed %d notification digests", sent)
+	}
+}
@@ -0,0 +1,112 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/huylvt/gisty/internal/model"
+)
+
+const (
+	// EventSinkLog writes paste events to the server log
+	EventSinkLog = "log"
+	// EventSinkWebhook POSTs paste events as JSON to an HTTP endpoint, such as a
+	// Kafka REST proxy or a NATS HTTP gateway
+	EventSinkWebhook = "webhook"
+
+	// DefaultWebhookTimeout bounds each webhook delivery
+	DefaultWebhookTimeout = 10 * time.Second
+)
+
+var (
+	// ErrUnknownEventSink is returned when the configured event sink is not supported
+	ErrUnknownEventSink = errors.New("worker: unknown event sink")
+	// ErrWebhookURLRequired is returned when the webhook sink is configured without a URL
+	ErrWebhookURLRequired = errors.New("worker: webhook sink requires a URL")
+	// ErrWebhookRejected is returned when the webhook endpoint answers with a non-2xx status
+	ErrWebhookRejected = errors.New("worker: event rejected by webhook")
+)
+
+// EventSink receives the paste events published by the change stream publisher.
+// Publish must return an error unless the event was durably accepted, so that it is retried.
+type EventSink interface {
+	Publish(ctx context.Context, event *model.PasteEvent) error
+}
+
+// NewEventSink creates the sink of the given kind (log or webhook)
+func NewEventSink(kind, webhookURL string) (EventSink, error) {
+	switch kind {
+	case EventSinkLog, "":
+		return LogSink{}, nil
+	case EventSinkWebhook:
+		if webhookURL == "" {
+			return nil, ErrWebhookURLRequired
+		}
+		return NewWebhookSink(webhookURL, DefaultWebhookTimeout), nil
+	default:
+		return nil, fmt.Errorf("%w: %q (supported: %s, %s)", ErrUnknownEventSink, kind, EventSinkLog, EventSinkWebhook)
+	}
+}
+
+// LogSink writes paste events to the server log, for development and debugging
+type LogSink struct{}
+
+// Publish logs the event as JSON
+func (LogSink) Publish(_ context.Context, event *model.PasteEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	log.Printf("Paste event: %s", body)
+	return nil
+}
+
+// WebhookSink delivers paste events as JSON POST requests
+type WebhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookSink creates a WebhookSink posting to url
+func NewWebhookSink(url string, timeout time.Duration) *WebhookSink {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+	return &WebhookSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish POSTs the event; any 2xx answer acknowledges it
+func (s *WebhookSink) Publish(ctx context.Context, event *model.PasteEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gisty-Event", string(event.Type))
+	req.Header.Set("X-Gisty-Event-ID", event.ID)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: status %d", ErrWebhookRejected, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+const (
+	// DefaultStaleReportInterval is the default interval between stale reports
+	DefaultStaleReportInterval = 24 * time.Hour
+	// staleReportLimit caps the pastes whose size a scheduled report reads
+	staleReportLimit = service.MaxListLimit
+)
+
+// StaleReporter periodically reports the pastes that never expire and were not read for a number
+// of days, exporting their count and size as metrics
+type StaleReporter struct {
+	pasteService *service.PasteService
+	days         int
+	interval     time.Duration
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+}
+
+// NewStaleReporter creates a StaleReporter of the pastes not read for days, DefaultStaleDays when
+// not positive, running every interval, DefaultStaleReportInterval when not positive
+func NewStaleReporter(pasteService *service.PasteService, days int, interval time.Duration) *StaleReporter {
+	if days <= 0 {
+		days = service.DefaultStaleDays
+	}
+	if interval <= 0 {
+		interval = DefaultStaleReportInterval
+	}
+	return &StaleReporter{
+		pasteService: pasteService,
+		days:         days,
+		interval:     interval,
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+// Start runs a report at once, then every interval until ctx is done or Stop is called
+func (r *StaleReporter) Start(ctx context.Context) {
+	log.Printf("Stale Reporter started (days: %d, interval: %v)", r.days, r.interval)
+	defer close(r.doneCh)
+
+	r.run(ctx)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stopCh:
+			log.Println("Stale Reporter stopped")
+			return
+		case <-ticker.C:
+			r.run(ctx)
+		}
+	}
+}
+
+// Stop stops the reporter after its current run
+func (r *StaleReporter) Stop() {
+	close(r.stopCh)
+	<-r.doneCh
+}
+
+// run builds a report and exports it
+func (r *StaleReporter) run(ctx context.Context) {
+	report, err := r.pasteService.StaleReport(ctx, r.days, staleReportLimit)
+	if err != nil {
+		log.Printf("Stale Reporter: failed to build report: %v", err)
+		return
+	}
+
+	metrics.StalePastes.Set(float64(report.Count))
+	metrics.StaleBytes.Set(float64(report.TotalSize))
+	metrics.StaleBytesEstimated.Set(float64(report.EstimatedTotalSize))
+	truncated := 0.0
+	if report.Truncated {
+		truncated = 1
+	}
+	metrics.StaleReportTruncated.Set(truncated)
+	if report.Count > 0 {
+		log.Printf("Stale Reporter: %d pastes never expire and were not read for %d days (%d bytes listed of %d listed pastes, about %d bytes in all, truncated: %v)",
+			report.Count, r.days, report.TotalSize, len(report.Pastes), report.EstimatedTotalSize, report.Truncated)
+	}
+}
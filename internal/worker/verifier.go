@@ -0,0 +1,91 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/huylvt/gisty/internal/metrics"
+	"github.com/huylvt/gisty/internal/service"
+)
+
+// DefaultVerifyInterval is the default interval between storage verification runs
+const DefaultVerifyInterval = time.Hour
+
+// StorageVerifier periodically re-downloads a small random sample of stored pastes and checks
+// their content, giving operators early warning of silent storage corruption. Corrupt pastes are
+// logged and counted in metrics, and quarantined unless the verifier only reports them.
+type StorageVerifier struct {
+	pasteService *service.PasteService
+	sample       int
+	interval     time.Duration
+	quarantine   bool
+	stopCh       chan struct{}
+	doneCh       chan struct{}
+}
+
+// NewStorageVerifier creates a StorageVerifier checking sample pastes, DefaultVerifySample when
+// not positive, every interval, DefaultVerifyInterval when not positive
+func NewStorageVerifier(pasteService *service.PasteService, sample int, interval time.Duration, quarantine bool) *StorageVerifier {
+	if sample <= 0 {
+		sample = service.DefaultVerifySample
+	}
+	if interval <= 0 {
+		interval = DefaultVerifyInterval
+	}
+	return &StorageVerifier{
+		pasteService: pasteService,
+		sample:       min(sample, service.MaxVerifySample),
+		interval:     interval,
+		quarantine:   quarantine,
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+// Start runs a verification every interval until ctx is done or Stop is called. Unlike other
+// reports, the first run waits an interval, so restarts do not add to the load on storage.
+func (v *StorageVerifier) Start(ctx context.Context) {
+	log.Printf("Storage Verifier started (sample: %d, interval: %v, quarantine: %v)", v.sample, v.interval, v.quarantine)
+	defer close(v.doneCh)
+
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-v.stopCh:
+			log.Println("Storage Verifier stopped")
+			return
+		case <-ticker.C:
+			v.run(ctx)
+		}
+	}
+}
+
+// Stop stops the verifier after its current run
+func (v *StorageVerifier) Stop() {
+	close(v.stopCh)
+	<-v.doneCh
+}
+
+// run verifies a sample of pastes and exports the results
+func (v *StorageVerifier) run(ctx context.Context) {
+	run, err := v.pasteService.VerifyStoredPastes(ctx, v.sample, v.quarantine)
+	if err != nil {
+		log.Printf("Storage Verifier: failed to verify pastes: %v", err)
+		return
+	}
+
+	metrics.StorageVerified.WithLabelValues("ok").Add(float64(run.Verified))
+	metrics.StorageVerified.WithLabelValues("corrupt").Add(float64(len(run.Corrupt)))
+	metrics.StorageVerified.WithLabelValues("error").Add(float64(run.Failures))
+	if report, err := v.pasteService.ListQuarantined(ctx, 1); err == nil {
+		metrics.QuarantinedPastes.Set(float64(report.Count))
+	}
+	if len(run.Corrupt) > 0 {
+		log.Printf("Storage Verifier: %d of %d sampled pastes have corrupt content (quarantined: %v): %v",
+			len(run.Corrupt), run.Sampled, run.Quarantined, run.Corrupt)
+	}
+}
@@ -67,8 +67,14 @@ func TestPasteCRUD(t *testing.T) {
 		getResp, _ := DoGetPaste(t, env.Server.URL, created.ShortID)
 		AssertStatusCode(t, getResp, http.StatusOK)
 
-		// Delete
-		deleteResp, _ := DoDeletePaste(t, env.Server.URL, created.ShortID)
+		// Delete without the token, then with it
+		if created.DeleteToken == "" {
+			t.Fatal("Expected a delete token for an anonymous paste")
+		}
+		forbiddenResp, _ := DoDeletePaste(t, env.Server.URL, created.ShortID, "wrong-token")
+		AssertStatusCode(t, forbiddenResp, http.StatusForbidden)
+
+		deleteResp, _ := DoDeletePaste(t, env.Server.URL, created.ShortID, created.DeleteToken)
 		AssertStatusCode(t, deleteResp, http.StatusNoContent)
 
 		// Get (should not exist)
@@ -92,7 +98,7 @@ func TestPasteCRUD(t *testing.T) {
 	})
 
 	t.Run("Delete non-existent paste", func(t *testing.T) {
-		resp, body := DoDeletePaste(t, env.Server.URL, "nonexistent123")
+		resp, body := DoDeletePaste(t, env.Server.URL, "nonexistent123", "")
 		AssertStatusCode(t, resp, http.StatusNotFound)
 
 		errResp := ParseErrorResponse(t, body)
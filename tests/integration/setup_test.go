@@ -407,9 +407,10 @@ type CreatePasteRequest struct {
 
 // CreatePasteResponse represents the response after creating a paste
 type CreatePasteResponse struct {
-	ShortID   string `json:"short_id"`
-	URL       string `json:"url"`
-	ExpiresAt string `json:"expires_at,omitempty"`
+	ShortID     string `json:"short_id"`
+	URL         string `json:"url"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	DeleteToken string `json:"delete_token,omitempty"`
 }
 
 // GetPasteResponse represents the response when retrieving a paste
@@ -469,14 +470,17 @@ func DoGetPaste(t *testing.T, serverURL, shortID string) (*http.Response, []byte
 	return resp, respBody
 }
 
-// DoDeletePaste sends a DELETE request to delete a paste
-func DoDeletePaste(t *testing.T, serverURL, shortID string) (*http.Response, []byte) {
+// DoDeletePaste sends a DELETE request to delete a paste, with its delete token when not empty
+func DoDeletePaste(t *testing.T, serverURL, shortID, deleteToken string) (*http.Response, []byte) {
 	t.Helper()
 
 	req, err := http.NewRequest(http.MethodDelete, serverURL+"/api/v1/pastes/"+shortID, nil)
 	if err != nil {
 		t.Fatalf("Failed to create request: %v", err)
 	}
+	if deleteToken != "" {
+		req.Header.Set("X-Delete-Token", deleteToken)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
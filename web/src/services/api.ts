@@ -1,6 +1,17 @@
 import type { CreatePasteRequest, CreatePasteResponse, Paste, ApiError } from '../types';
 
 const API_BASE = '/api/v1';
+const SESSION_KEY = 'gisty.session';
+
+// Anonymous session token sent with every request, so this browser can later destroy its secret pastes
+function sessionToken(): string {
+  let token = localStorage.getItem(SESSION_KEY);
+  if (!token) {
+    token = crypto.randomUUID();
+    localStorage.setItem(SESSION_KEY, token);
+  }
+  return token;
+}
 
 class ApiService {
   private async request<T>(
@@ -11,6 +22,7 @@ class ApiService {
       ...options,
       headers: {
         'Content-Type': 'application/json',
+        'X-Gisty-Session': sessionToken(),
         ...options?.headers,
       },
     });
@@ -42,6 +54,11 @@ class ApiService {
     });
   }
 
+  // Destroys every unread burn-after-read or view-limited paste created from this browser
+  async panic(): Promise<{ deleted: number; short_ids: string[] }> {
+    return this.request('/me/panic', { method: 'POST' });
+  }
+
   async getRawPaste(shortId: string): Promise<string> {
     const response = await fetch(`/${shortId}`, {
       headers: {